@@ -5,6 +5,7 @@ import sys
 import getopt
 import os
 import shutil
+import uuid
 from string import Template
 
 
@@ -196,6 +197,7 @@ watch:
 #  address: 127.0.0.1
 #  appCode: bk_cmdb
 #  appSecret: 123456
+#  mode: iam
 #cloudServer:
 #  cryptor:
 #    enableCryptor: false
@@ -247,9 +249,72 @@ webServer:
     agentAppUrl: ${agent_url}/console/?app=bk_agent_setup
     #权限模式，web页面使用，可选值: internal, iam
     authscheme: $auth_scheme
+  export:
+    #导出请求查询的db读偏好，可选值: primary, primaryPreferred, secondary, secondaryPreferred, nearest，默认secondaryPreferred
+    readPreference: secondaryPreferred
   login:
-    #登录模式
+    #登录模式，可选值: blueking, opensource, skip-login, ldap, oidc
     version: $loginVersion
+  #ldap登录模式的配置，登录模式为ldap时生效
+  #ldap:
+  #  #ldap服务地址，支持ldap://和ldaps://
+  #  url: ldap://127.0.0.1:389
+  #  #是否跳过ldaps的证书校验
+  #  insecureSkipVerify: false
+  #  #用于查询用户和用户组的服务账号，不配置时为匿名查询
+  #  bindDN: cn=admin,dc=example,dc=com
+  #  bindPassword: admin
+  #  #查询用户的base dn和过滤条件，%s会被替换为登录的用户名
+  #  userBaseDN: ou=people,dc=example,dc=com
+  #  userFilter: (uid=%s)
+  #  #查询用户列表的过滤条件，默认为(用户名属性=*)
+  #  userListFilter: (objectClass=person)
+  #  #用户名、中文名、邮箱、电话对应的属性，AD中用户名属性一般为sAMAccountName
+  #  userNameAttr: uid
+  #  displayNameAttr: cn
+  #  emailAttr: mail
+  #  phoneAttr: telephoneNumber
+  #  #用户所属用户组的属性，AD中为memberOf
+  #  groupAttr: memberOf
+  #  #查询用户组的base dn和过滤条件，%s会被替换为用户的dn，不配置groupBaseDN时不查询用户组
+  #  groupBaseDN: ou=groups,dc=example,dc=com
+  #  groupFilter: (member=%s)
+  #  groupNameAttr: cn
+  #  #允许登录的ldap用户组，以 , 分割，不配置时允许所有用户登录
+  #  allowedGroups: cmdb-users
+  #  #ldap用户组到cmdb用户组的映射，格式为 ldap用户组:cmdb用户组，以 , 分割，登录时同步用户在cmdb用户组中的成员关系
+  #  groupMapping: cmdb-admins:admin
+  #  timeoutSeconds: 10
+  #OpenID Connect登录模式的配置，登录模式为oidc时生效
+  #oidc:
+  #  #身份提供方的issuer地址，从 issuer/.well-known/openid-configuration 获取配置
+  #  issuer: https://idp.example.com/realms/cmdb
+  #  clientID: cmdb
+  #  clientSecret: secret
+  #  #登录回调地址，默认为 domainUrl/login/callback，需要在身份提供方配置
+  #  redirectURL: http://127.0.0.1/login/callback
+  #  #申请的scope，以 , 分割
+  #  scopes: openid,profile,email
+  #  insecureSkipVerify: false
+  #  #用户名、中文名、邮箱、电话、用户组对应的id token claim
+  #  userNameClaim: preferred_username
+  #  displayNameClaim: name
+  #  emailClaim: email
+  #  phoneClaim: phone_number
+  #  groupsClaim: groups
+  #  #允许登录的用户组和用户组映射，格式同ldap
+  #  allowedGroups: cmdb-users
+  #  groupMapping: cmdb-admins:admin
+  #  timeoutSeconds: 10
+# api_server专属配置
+apiServer:
+  tenant:
+    #系统应用的应用编码，以 , 分割，系统应用可以使用所有租户和超级开发商账号(superadmin)，其他请求只能使用默认租户和绑定了请求用户的租户，
+    #需要包含webServer.site.appCode，web server使用登录用户的开发商账号请求api server
+    systemAppCodes: cc
+    #可信来源(web server和api网关)请求api server时在请求头Bk-System-Token中携带的密钥，只有携带了该密钥的请求的应用编码才被认为是系统应用，
+    #web server会自动携带，api网关需要配置为转发请求时添加该请求头，未配置时所有请求都不是系统应用的请求
+    systemToken: $system_token
 # operation_server专属配置
 operationServer:
   timer:
@@ -257,6 +322,8 @@ operationServer:
     spec: 00:30  # 00:00 - 23:59
   # 禁用运营统计数据统计功能，默认false
   disableOperationStatistic: false
+  # 运营统计查询的db读偏好，可选值: primary, primaryPreferred, secondary, secondaryPreferred, nearest，默认secondaryPreferred
+  readPreference: secondaryPreferred
 #auth_server专属配置
 authServer:
   #蓝鲸权限中心地址,可配置多个,用,(逗号)分割
@@ -265,6 +332,8 @@ authServer:
   appCode: $auth_app_code
   #cmdb项目在蓝鲸权限中心的应用密钥
   appSecret: $auth_app_secret
+  #鉴权模式，iam为使用蓝鲸权限中心鉴权，rbac为使用cmdb内置的角色授权鉴权，默认为iam
+  mode: iam
 #cloudServer专属配置
 cloudServer:
   # 加密服务使用
@@ -279,6 +348,18 @@ cloudServer:
   syncTask:
     # 同步周期,最小为5分钟
     syncPeriodMinutes: 5
+#cacheService专属配置
+cacheService:
+  # 开启实例和实例关联缓存的模型，多个模型用逗号分隔，只支持自定义模型，为空时不开启
+  instanceCacheObjects:
+  # 主机缓存的二级索引字段，可以通过这些字段批量查询主机，如bk_asset_id,bk_sn,bk_mac，多个字段用逗号分隔，为空时不开启
+  hostSecondaryKeys:
+  # 缓存校验，定期抽样校验主机和业务缓存与db中的数据是否一致，并修复不一致的缓存
+  verify:
+    # 校验周期，单位为分钟，为0时不开启定期校验，默认为30分钟
+    intervalMinutes: 30
+    # 每次校验抽样的数量，范围为[1,1000]，默认为200
+    sampleSize: 200
 #datacollection专属配置
 datacollection:
   hostsnap:
@@ -312,7 +393,8 @@ monitor:
     loginVersion = 'opensource'
     if auth_enabled == "true":
         loginVersion = 'blueking'
-    result = template.substitute(loginVersion=loginVersion, **context)
+    # the system token is a random secret shared by the api server and the web server
+    result = template.substitute(loginVersion=loginVersion, system_token=uuid.uuid4().hex, **context)
     with open(output + "common.yaml", 'w') as tmp_file:
         tmp_file.write(result)
 
@@ -380,6 +462,10 @@ errors:
 # 指定language的路径
 language:
   res: conf/language
+# cmdb内置的角色授权鉴权配置
+rbac:
+  # 初始化时绑定内置管理员角色的用户
+  adminUser: admin
     '''
 
     template = FileTemplate(migrate_file_template_str)
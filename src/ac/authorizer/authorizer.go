@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package authorizer
+
+import (
+	"configcenter/src/ac"
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/rbac"
+	"configcenter/src/apimachinery"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+)
+
+const (
+	// ModeIAM authorize with BlueKing IAM through auth server, it is the default mode.
+	ModeIAM = "iam"
+	// ModeRBAC authorize with the built-in roles and role bindings stored in cmdb.
+	ModeRBAC = "rbac"
+)
+
+// Mode returns the authorize mode configured by authServer.mode
+func Mode() string {
+	mode, err := cc.String("authServer.mode")
+	if err != nil || mode == "" {
+		return ModeIAM
+	}
+	return mode
+}
+
+// NewAuthorizer create the authorizer of the configured authorize mode
+func NewAuthorizer(clientSet apimachinery.ClientSetInterface) ac.AuthorizeInterface {
+	return NewAuthorizerWithMode(clientSet, Mode())
+}
+
+// NewAuthorizerWithMode create the authorizer of the authorize mode
+func NewAuthorizerWithMode(clientSet apimachinery.ClientSetInterface, mode string) ac.AuthorizeInterface {
+	switch mode {
+	case ModeRBAC:
+		return rbac.NewAuthorizer(clientSet)
+	case ModeIAM:
+		return iam.NewAuthorizer(clientSet)
+	default:
+		blog.Errorf("authorize mode %s is invalid, use %s mode instead", mode, ModeIAM)
+		return iam.NewAuthorizer(clientSet)
+	}
+}
@@ -14,7 +14,7 @@ package extensions
 
 import (
 	"configcenter/src/ac"
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac/authorizer"
 	"configcenter/src/apimachinery"
 	"configcenter/src/common"
 	"configcenter/src/common/mapstr"
@@ -34,7 +34,7 @@ type AuthManager struct {
 func NewAuthManager(clientSet apimachinery.ClientSetInterface) *AuthManager {
 	return &AuthManager{
 		clientSet:                    clientSet,
-		Authorizer:                   iam.NewAuthorizer(clientSet),
+		Authorizer:                   authorizer.NewAuthorizer(clientSet),
 		RegisterModuleEnabled:        false,
 		RegisterSetEnabled:           false,
 		SkipReadAuthorization:        true,
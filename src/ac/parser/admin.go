@@ -23,7 +23,10 @@ func (ps *parseStream) adminRelated() *parseStream {
 		return ps
 	}
 
-	ps.ConfigAdmin()
+	ps.ConfigAdmin().
+		AuthRBAC().
+		Tenant().
+		SystemDB()
 
 	return ps
 }
@@ -43,9 +46,194 @@ var ConfigAdminConfigs = []AuthConfig{
 		HTTPMethod:     http.MethodPut,
 		ResourceType:   meta.ConfigAdmin,
 		ResourceAction: meta.Update,
+	}, {
+		// the limiter rules are global configurations, so only the users who can manage them can see the usage
+		Name:           "getLimiterUsage",
+		Description:    "查询限流规则的配额使用情况",
+		Pattern:        "/api/v3/limiter/usage",
+		HTTPMethod:     http.MethodGet,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
 	},
 }
 
 func (ps *parseStream) ConfigAdmin() *parseStream {
 	return ParseStreamWithFramework(ps, ConfigAdminConfigs)
 }
+
+// AuthRBACConfigs is the management apis of the built-in rbac authorizer, which are treated as global configurations.
+// the query apis use the update action too, because finding the config admin is skipped and the roles and
+// policies should only be visible to the users who can manage them.
+var AuthRBACConfigs = []AuthConfig{
+	{
+		Name:           "createAuthRole",
+		Description:    "创建角色",
+		Pattern:        "/api/v3/admin/create/auth/rbac/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateAuthRole",
+		Description:    "更新角色",
+		Pattern:        "/api/v3/admin/update/auth/rbac/role",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteAuthRoles",
+		Description:    "删除角色",
+		Pattern:        "/api/v3/admin/delete/auth/rbac/role",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "listAuthRoles",
+		Description:    "查询角色",
+		Pattern:        "/api/v3/admin/findmany/auth/rbac/role",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "createAuthRoleBinding",
+		Description:    "创建角色授权",
+		Pattern:        "/api/v3/admin/create/auth/rbac/role_binding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteAuthRoleBindings",
+		Description:    "删除角色授权",
+		Pattern:        "/api/v3/admin/delete/auth/rbac/role_binding",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "listAuthRoleBindings",
+		Description:    "查询角色授权",
+		Pattern:        "/api/v3/admin/findmany/auth/rbac/role_binding",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "createAuthUserGroup",
+		Description:    "创建用户组",
+		Pattern:        "/api/v3/admin/create/auth/rbac/user_group",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateAuthUserGroup",
+		Description:    "更新用户组",
+		Pattern:        "/api/v3/admin/update/auth/rbac/user_group",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteAuthUserGroups",
+		Description:    "删除用户组",
+		Pattern:        "/api/v3/admin/delete/auth/rbac/user_group",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "listAuthUserGroups",
+		Description:    "查询用户组",
+		Pattern:        "/api/v3/admin/findmany/auth/rbac/user_group",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "getAuthPolicies",
+		Description:    "查询用户的权限策略",
+		Pattern:        "/api/v3/admin/find/auth/rbac/policy",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) AuthRBAC() *parseStream {
+	return ParseStreamWithFramework(ps, AuthRBACConfigs)
+}
+
+// TenantConfigs is the lifecycle apis of the tenants, which manage the supplier accounts of the whole system,
+// so they are treated as global configurations too.
+var TenantConfigs = []AuthConfig{
+	{
+		Name:           "createTenant",
+		Description:    "创建租户",
+		Pattern:        "/api/v3/admin/create/tenant",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateTenantStatus",
+		Description:    "启用或停用租户",
+		Pattern:        "/api/v3/admin/update/tenant/status",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "updateTenantUsers",
+		Description:    "更新租户绑定的用户",
+		Pattern:        "/api/v3/admin/update/tenant/users",
+		HTTPMethod:     http.MethodPut,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "deleteTenant",
+		Description:    "删除租户并清理其数据",
+		Pattern:        "/api/v3/admin/delete/tenant",
+		HTTPMethod:     http.MethodDelete,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "listTenants",
+		Description:    "查询租户",
+		Pattern:        "/api/v3/admin/findmany/tenant",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	},
+}
+
+func (ps *parseStream) Tenant() *parseStream {
+	return ParseStreamWithFramework(ps, TenantConfigs)
+}
+
+// SystemDBConfigs is the maintenance apis of the database, which are treated as global configurations.
+var SystemDBConfigs = []AuthConfig{
+	{
+		Name:           "checkDBIndexes",
+		Description:    "检查数据库索引",
+		Pattern:        "/api/v3/admin/find/system/db/index",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "reconcileDBIndexes",
+		Description:    "修正数据库索引",
+		Pattern:        "/api/v3/admin/migrate/system/db/index",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Update,
+	}, {
+		Name:           "listSlowQueryShapes",
+		Description:    "查询慢查询统计",
+		Pattern:        "/api/v3/admin/find/system/db/slow_query",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	}, {
+		Name:           "explainSlowQuery",
+		Description:    "查询慢查询的执行计划",
+		Pattern:        "/api/v3/admin/find/system/db/slow_query/explain",
+		HTTPMethod:     http.MethodPost,
+		ResourceType:   meta.ConfigAdmin,
+		ResourceAction: meta.Find,
+	},
+}
+
+func (ps *parseStream) SystemDB() *parseStream {
+	return ParseStreamWithFramework(ps, SystemDBConfigs)
+}
@@ -37,6 +37,9 @@ var (
 	updateSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/\d+/?$`)
 	deleteSubscribeRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/\S+/\d+/\d+/?$`)
 	watchResourceRegexp   = regexp.MustCompile(`^/api/v3/event/watch/resource/\S+/?$`)
+
+	findDeadLetterRegexp   = regexp.MustCompile(`^/api/v3/event/subscribe/dead_letter/search/[^\s/]+/\d+/\d+/?$`)
+	replayDeadLetterRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/dead_letter/replay/[^\s/]+/\d+/\d+/?$`)
 )
 
 const (
@@ -49,6 +52,44 @@ func (ps *parseStream) subscribe() *parseStream {
 		return ps
 	}
 
+	// find the dead letters of a subscription, must be checked before create subscription
+	if ps.hitRegexp(findDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("find dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Find,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	// replay the dead letters of a subscription, must be checked before create subscription
+	if ps.hitRegexp(replayDeadLetterRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[8], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("replay dead letters, but got invalid subscription id: %s", ps.RequestCtx.Elements[8])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
 	// find all the subscription
 	if ps.hitRegexp(findSubscribeRegexp, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
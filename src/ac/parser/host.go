@@ -318,6 +318,8 @@ const (
 	lockHostPattern                       = "/api/v3/host/lock"
 	unLockHostPattern                     = "/api/v3/host/lock"
 	queryHostLockPattern                  = "/api/v3/host/lock/search"
+	listHostLocksPattern                  = "/api/v3/host/lock/list"
+	expireHostLocksPattern                = "/api/v3/host/lock/expire"
 
 	// used in sync framework.
 	// moveHostToBusinessOrModulePattern = "/api/v3/hosts/sync/new/host"
@@ -500,6 +502,30 @@ func (ps *parseStream) host() *parseStream {
 		return ps
 	}
 
+	if ps.hitPattern(listHostLocksPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	if ps.hitPattern(expireHostLocksPattern, http.MethodPut) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.HostInstance,
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
 	// delete hosts batch operation.
 	if ps.hitPattern(deleteHostBatchPattern, http.MethodDelete) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
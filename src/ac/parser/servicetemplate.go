@@ -145,6 +145,40 @@ var ServiceTemplateAuthConfigs = []AuthConfig{
 		BizIndex:       7,
 		ResourceType:   meta.ProcessServiceTemplate,
 		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "listServiceTemplateVersions",
+		Description:    "查询服务模板的版本",
+		Regex:          regexp.MustCompile(`^/api/v3/findmany/proc/service_template/([0-9]+)/versions/?$`),
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:           "listServiceTemplateRollouts",
+		Description:    "查询服务模板的发布",
+		Pattern:        "/api/v3/findmany/proc/service_template/rollout",
+		HTTPMethod:     http.MethodPost,
+		BizIDGetter:    DefaultBizIDGetter,
+		ResourceType:   meta.ProcessServiceTemplate,
+		ResourceAction: meta.FindMany,
+	}, {
+		Name:         "createServiceTemplateRollout",
+		Description:  "发布服务模板到服务实例",
+		Pattern:      "/api/v3/create/proc/service_template/rollout",
+		HTTPMethod:   http.MethodPost,
+		BizIDGetter:  DefaultBizIDGetter,
+		ResourceType: meta.ProcessServiceTemplate,
+		// authorization is implemented in scene server with the service template id
+		ResourceAction: meta.SkipAction,
+	}, {
+		Name:         "updateServiceTemplateRollout",
+		Description:  "暂停、继续或回滚服务模板的发布",
+		Regex:        regexp.MustCompile(`^/api/v3/update/proc/service_template/rollout/([0-9]+)/(pause|resume|rollback)/?$`),
+		HTTPMethod:   http.MethodPut,
+		BizIDGetter:  DefaultBizIDGetter,
+		ResourceType: meta.ProcessServiceTemplate,
+		// authorization is implemented in scene server with the rollout's service template id
+		ResourceAction: meta.SkipAction,
 	},
 }
 
@@ -21,6 +21,7 @@ import (
 
 	"configcenter/src/ac/meta"
 	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
 )
 
 func (ps *parseStream) topology() *parseStream {
@@ -36,6 +37,7 @@ func (ps *parseStream) topology() *parseStream {
 		objectSet().
 		audit().
 		fullTextSearch().
+		graphQL().
 		cloudArea()
 
 	return ps
@@ -338,6 +340,12 @@ const (
 	objectStatistics         = "/api/v3/object/statistics"
 )
 
+var (
+	findObjectSchemaVersionsRegexp = regexp.MustCompile(`^/api/v3/findmany/object/[^\s/]+/schema/versions/?$`)
+	diffObjectSchemaRegexp         = regexp.MustCompile(`^/api/v3/find/object/[^\s/]+/schema/diff/?$`)
+	rollbackObjectSchemaRegexp     = regexp.MustCompile(`^/api/v3/update/object/[^\s/]+/schema/rollback/?$`)
+)
+
 func (ps *parseStream) object() *parseStream {
 	if ps.shouldReturn() {
 		return ps
@@ -355,6 +363,46 @@ func (ps *parseStream) object() *parseStream {
 		return ps
 	}
 
+	// find object schema versions or diff two of them
+	if ps.hitRegexp(findObjectSchemaVersionsRegexp, http.MethodPost) || ps.hitRegexp(diffObjectSchemaRegexp, http.MethodPost) {
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[4]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.Model,
+					Action:     meta.Find,
+					InstanceID: model.ID,
+				},
+			},
+		}
+		return ps
+	}
+
+	// rollback object schema
+	if ps.hitRegexp(rollbackObjectSchemaRegexp, http.MethodPost) {
+		model, err := ps.getOneModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[4]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.Model,
+					Action:     meta.Update,
+					InstanceID: model.ID,
+				},
+			},
+		}
+		return ps
+	}
+
 	return ps
 }
 
@@ -986,6 +1034,31 @@ func (ps *parseStream) fullTextSearch() *parseStream {
 	return ps
 }
 
+const (
+	graphQLQueryPattern  = "/api/v3/graphql"
+	graphQLSchemaPattern = "/api/v3/graphql/schema"
+)
+
+// graphQL the read only graphql api authorizes the instances it reads itself
+func (ps *parseStream) graphQL() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	if ps.hitPattern(graphQLQueryPattern, http.MethodPost) || ps.hitPattern(graphQLSchemaPattern, http.MethodGet) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Action: meta.SkipAction,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
 const (
 	findManyCloudAreaPattern      = "/api/v3/findmany/cloudarea"
 	createCloudAreaPattern        = "/api/v3/create/cloudarea"
@@ -0,0 +1,85 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"sync"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+// policyCacheTTL is the time that the policies of a user are cached, the changes of the roles, role bindings
+// and user groups take effect after the cached policies expire.
+const policyCacheTTL = 10 * time.Second
+
+type policyCacheItem struct {
+	policies []metadata.AuthPolicy
+	expireAt time.Time
+}
+
+// policyCache caches the policies of the users in memory for a short time, so that the policies are not
+// got from core service for every authorize request.
+type policyCache struct {
+	lock  sync.Mutex
+	ttl   time.Duration
+	now   func() time.Time
+	items map[string]policyCacheItem
+	// lastPrune is the last time that the expired items are removed
+	lastPrune time.Time
+}
+
+func newPolicyCache(ttl time.Duration) *policyCache {
+	return &policyCache{
+		ttl:   ttl,
+		now:   time.Now,
+		items: make(map[string]policyCacheItem),
+	}
+}
+
+func policyCacheKey(ownerID, userName string) string {
+	return ownerID + ":" + userName
+}
+
+// get returns the cached policies of the user, returns false if they are not cached or expired
+func (c *policyCache) get(ownerID, userName string) ([]metadata.AuthPolicy, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	item, exists := c.items[policyCacheKey(ownerID, userName)]
+	if !exists || !c.now().Before(item.expireAt) {
+		return nil, false
+	}
+	return item.policies, true
+}
+
+// set caches the policies of the user, the expired items are removed at most once per ttl
+func (c *policyCache) set(ownerID, userName string, policies []metadata.AuthPolicy) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := c.now()
+	if now.Sub(c.lastPrune) >= c.ttl {
+		for key, item := range c.items {
+			if !now.Before(item.expireAt) {
+				delete(c.items, key)
+			}
+		}
+		c.lastPrune = now
+	}
+
+	c.items[policyCacheKey(ownerID, userName)] = policyCacheItem{
+		policies: policies,
+		expireAt: now.Add(c.ttl),
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestPolicyCache(t *testing.T) {
+	now := time.Unix(1000, 0)
+	cache := newPolicyCache(10 * time.Second)
+	cache.now = func() time.Time { return now }
+
+	if _, exists := cache.get("0", "admin"); exists {
+		t.Fatalf("policies should not be cached before set")
+	}
+
+	policies := []metadata.AuthPolicy{{Actions: []string{metadata.AuthRoleAllActions}}}
+	cache.set("0", "admin", policies)
+
+	cached, exists := cache.get("0", "admin")
+	if !exists || len(cached) != 1 {
+		t.Fatalf("policies should be cached, got: %v, exists: %v", cached, exists)
+	}
+	if _, exists := cache.get("1", "admin"); exists {
+		t.Fatalf("policies of another supplier account should not be hit")
+	}
+
+	now = now.Add(10 * time.Second)
+	if _, exists := cache.get("0", "admin"); exists {
+		t.Fatalf("policies should be expired")
+	}
+
+	cache.set("0", "user", make([]metadata.AuthPolicy, 0))
+	if _, exists := cache.items[policyCacheKey("0", "admin")]; exists {
+		t.Fatalf("expired policies should be pruned")
+	}
+	if _, exists := cache.get("0", "user"); !exists {
+		t.Fatalf("empty policies should be cached too")
+	}
+}
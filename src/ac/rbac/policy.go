@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common/metadata"
+)
+
+// IsAuthorized check if the policies allow the action on the resource.
+// when exact is false, the resource is authorized as long as the user has the action on any resource
+// that does not conflict with it, this is the same as the any authorize of iam.
+func IsAuthorized(policies []metadata.AuthPolicy, action iam.ActionID, resource *meta.ResourceAttribute,
+	exact bool) bool {
+
+	for _, policy := range policies {
+		if !hasAction(policy.Actions, action) {
+			continue
+		}
+		if exact && scopeContains(&policy.Scope, resource) {
+			return true
+		}
+		if !exact && !scopeConflicts(&policy.Scope, resource) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAction(actions []string, action iam.ActionID) bool {
+	for _, one := range actions {
+		if one == metadata.AuthRoleAllActions || one == string(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// scopeContains check if the resource is within the scope
+func scopeContains(scope *metadata.AuthScope, resource *meta.ResourceAttribute) bool {
+	switch scope.Type {
+	case metadata.AuthScopeGlobal:
+		return true
+	case metadata.AuthScopeBusiness:
+		return GetBusinessID(resource) == scope.BizID
+	case metadata.AuthScopeModel:
+		return scope.ModelID > 0 && GetModelID(resource) == scope.ModelID
+	case metadata.AuthScopeInstance:
+		if string(resource.Type) != scope.ResourceType || !containsID(scope.InstanceIDs, resource.InstanceID) {
+			return false
+		}
+		if scope.ModelID > 0 && GetModelID(resource) != scope.ModelID {
+			return false
+		}
+		if scope.BizID > 0 && GetBusinessID(resource) != scope.BizID {
+			return false
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// scopeConflicts check if the resource is definitely out of the scope, the resource attributes that are
+// not set are not compared.
+func scopeConflicts(scope *metadata.AuthScope, resource *meta.ResourceAttribute) bool {
+	switch scope.Type {
+	case metadata.AuthScopeGlobal:
+		return false
+	case metadata.AuthScopeBusiness:
+		bizID := GetBusinessID(resource)
+		return bizID > 0 && bizID != scope.BizID
+	case metadata.AuthScopeModel:
+		modelID := GetModelID(resource)
+		return modelID > 0 && modelID != scope.ModelID
+	case metadata.AuthScopeInstance:
+		if string(resource.Type) != scope.ResourceType {
+			return true
+		}
+		if resource.InstanceID > 0 && !containsID(scope.InstanceIDs, resource.InstanceID) {
+			return true
+		}
+		if modelID := GetModelID(resource); scope.ModelID > 0 && modelID > 0 && modelID != scope.ModelID {
+			return true
+		}
+		if bizID := GetBusinessID(resource); scope.BizID > 0 && bizID > 0 && bizID != scope.BizID {
+			return true
+		}
+		return false
+	default:
+		return true
+	}
+}
+
+// GetBusinessID returns the business that the resource belongs to, or the business itself
+func GetBusinessID(resource *meta.ResourceAttribute) int64 {
+	if resource.Type == meta.Business && resource.InstanceID > 0 {
+		return resource.InstanceID
+	}
+	if resource.BusinessID > 0 {
+		return resource.BusinessID
+	}
+	for _, layer := range resource.Layers {
+		if layer.Type == meta.Business && layer.InstanceID > 0 {
+			return layer.InstanceID
+		}
+	}
+	return 0
+}
+
+// GetModelID returns the model that the resource belongs to, or the model itself
+func GetModelID(resource *meta.ResourceAttribute) int64 {
+	if resource.Type == meta.Model && resource.InstanceID > 0 {
+		return resource.InstanceID
+	}
+	for _, layer := range resource.Layers {
+		if layer.Type == meta.Model && layer.InstanceID > 0 {
+			return layer.InstanceID
+		}
+	}
+	return 0
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, one := range ids {
+		if one == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package rbac
+
+import (
+	"testing"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
+	"configcenter/src/common/metadata"
+)
+
+func TestIsAuthorized(t *testing.T) {
+	bizHost := &meta.ResourceAttribute{
+		Basic:      meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: 10},
+		BusinessID: 2,
+	}
+	modelInst := &meta.ResourceAttribute{
+		Basic:  meta.Basic{Type: meta.ModelInstance, Action: meta.Update, InstanceID: 7},
+		Layers: []meta.Item{{Type: meta.Model, InstanceID: 5}},
+	}
+	anyModelInst := &meta.ResourceAttribute{
+		Basic:  meta.Basic{Type: meta.ModelInstance, Action: meta.Update},
+		Layers: []meta.Item{{Type: meta.Model, InstanceID: 5}},
+	}
+
+	cases := []struct {
+		name     string
+		policies []metadata.AuthPolicy
+		action   iam.ActionID
+		resource *meta.ResourceAttribute
+		exact    bool
+		expect   bool
+	}{
+		{
+			name:     "no policy",
+			action:   iam.EditBusinessHost,
+			resource: bizHost,
+			exact:    true,
+			expect:   false,
+		},
+		{
+			name: "global all actions",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{metadata.AuthRoleAllActions},
+				Scope:   metadata.AuthScope{Type: metadata.AuthScopeGlobal},
+			}},
+			action:   iam.EditBusinessHost,
+			resource: bizHost,
+			exact:    true,
+			expect:   true,
+		},
+		{
+			name: "action not granted",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.ViewBusinessResource)},
+				Scope:   metadata.AuthScope{Type: metadata.AuthScopeGlobal},
+			}},
+			action:   iam.EditBusinessHost,
+			resource: bizHost,
+			exact:    true,
+			expect:   false,
+		},
+		{
+			name: "business scope matched",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditBusinessHost)},
+				Scope:   metadata.AuthScope{Type: metadata.AuthScopeBusiness, BizID: 2},
+			}},
+			action:   iam.EditBusinessHost,
+			resource: bizHost,
+			exact:    true,
+			expect:   true,
+		},
+		{
+			name: "business scope of another business",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditBusinessHost)},
+				Scope:   metadata.AuthScope{Type: metadata.AuthScopeBusiness, BizID: 3},
+			}},
+			action:   iam.EditBusinessHost,
+			resource: bizHost,
+			exact:    false,
+			expect:   false,
+		},
+		{
+			name: "model scope matched by layer",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditSysInstance)},
+				Scope:   metadata.AuthScope{Type: metadata.AuthScopeModel, ObjectID: "switch", ModelID: 5},
+			}},
+			action:   iam.EditSysInstance,
+			resource: modelInst,
+			exact:    true,
+			expect:   true,
+		},
+		{
+			name: "instance scope matched",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditSysInstance)},
+				Scope: metadata.AuthScope{Type: metadata.AuthScopeInstance, ResourceType: string(meta.ModelInstance),
+					ModelID: 5, InstanceIDs: []int64{7, 8}},
+			}},
+			action:   iam.EditSysInstance,
+			resource: modelInst,
+			exact:    true,
+			expect:   true,
+		},
+		{
+			name: "instance scope of another model",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditSysInstance)},
+				Scope: metadata.AuthScope{Type: metadata.AuthScopeInstance, ResourceType: string(meta.ModelInstance),
+					ModelID: 6, InstanceIDs: []int64{7}},
+			}},
+			action:   iam.EditSysInstance,
+			resource: modelInst,
+			exact:    true,
+			expect:   false,
+		},
+		{
+			name: "instance scope does not contain the whole model exactly",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditSysInstance)},
+				Scope: metadata.AuthScope{Type: metadata.AuthScopeInstance, ResourceType: string(meta.ModelInstance),
+					ModelID: 5, InstanceIDs: []int64{7}},
+			}},
+			action:   iam.EditSysInstance,
+			resource: anyModelInst,
+			exact:    true,
+			expect:   false,
+		},
+		{
+			name: "instance scope authorizes any instance of the model",
+			policies: []metadata.AuthPolicy{{
+				Actions: []string{string(iam.EditSysInstance)},
+				Scope: metadata.AuthScope{Type: metadata.AuthScopeInstance, ResourceType: string(meta.ModelInstance),
+					ModelID: 5, InstanceIDs: []int64{7}},
+			}},
+			action:   iam.EditSysInstance,
+			resource: anyModelInst,
+			exact:    false,
+			expect:   true,
+		},
+	}
+
+	for _, c := range cases {
+		if got := IsAuthorized(c.policies, c.action, c.resource, c.exact); got != c.expect {
+			t.Errorf("case %s: expect authorized %v, got %v", c.name, c.expect, got)
+		}
+	}
+}
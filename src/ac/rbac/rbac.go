@@ -0,0 +1,305 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package rbac is the built-in authorizer which authorize with the roles, user groups and role bindings
+// stored in cmdb, it can be used instead of BlueKing IAM. the actions of the roles are the iam actions,
+// so that the resources are converted to actions in the same way as iam.
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/ac"
+	"configcenter/src/ac/iam"
+	"configcenter/src/ac/meta"
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/auth_server/sdk/types"
+)
+
+type authorizer struct {
+	coreService coreservice.CoreServiceClientInterface
+	policies    *policyCache
+}
+
+// NewAuthorizer create a rbac authorizer, the policies of the users are got from core service
+// and cached for a short time.
+func NewAuthorizer(clientSet apimachinery.ClientSetInterface) ac.AuthorizeInterface {
+	return &authorizer{
+		coreService: clientSet.CoreService(),
+		policies:    newPolicyCache(policyCacheTTL),
+	}
+}
+
+func (a *authorizer) AuthorizeBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.authorizeBatch(ctx, h, true, user, resources...)
+}
+
+func (a *authorizer) AuthorizeAnyBatch(ctx context.Context, h http.Header, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+	return a.authorizeBatch(ctx, h, false, user, resources...)
+}
+
+func (a *authorizer) authorizeBatch(ctx context.Context, h http.Header, exact bool, user meta.UserInfo,
+	resources ...meta.ResourceAttribute) ([]types.Decision, error) {
+
+	rid := util.GetHTTPCCRequestID(h)
+	decisions := make([]types.Decision, len(resources))
+	if !auth.EnableAuthorize() {
+		for index := range decisions {
+			decisions[index].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	var policies []metadata.AuthPolicy
+	for index := range resources {
+		resource := &resources[index]
+
+		// this resource should be skipped, do not need to verify.
+		if resource.Action == meta.SkipAction {
+			decisions[index].Authorized = true
+			blog.V(5).Infof("skip authorization for resource: %+v, rid: %s", resource, rid)
+			continue
+		}
+
+		action, err := iam.ConvertResourceAction(resource.Type, resource.Action, resource.BusinessID)
+		if err != nil {
+			blog.Errorf("convert cmdb resource to iam action failed, err: %s, rid: %s", err, rid)
+			return nil, err
+		}
+
+		if action == iam.Skip {
+			decisions[index].Authorized = true
+			blog.V(5).Infof("skip authorization for resource: %+v, rid: %s", resource, rid)
+			continue
+		}
+
+		// get the user's policies only when there are resources need to be authorized
+		if policies == nil {
+			policies, err = a.getPolicies(ctx, h, user.UserName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		decisions[index].Authorized = IsAuthorized(policies, action, resource, exact)
+	}
+
+	return decisions, nil
+}
+
+func (a *authorizer) getPolicies(ctx context.Context, h http.Header, userName string) ([]metadata.AuthPolicy, error) {
+	ownerID := util.GetOwnerID(h)
+	if policies, exists := a.policies.get(ownerID, userName); exists {
+		return policies, nil
+	}
+
+	option := &metadata.GetAuthPoliciesOption{UserName: userName}
+	policies, err := a.coreService.Auth().GetAuthPolicies(ctx, h, option)
+	if err != nil {
+		blog.Errorf("get user %s auth policies failed, err: %v, rid: %s", userName, err,
+			util.GetHTTPCCRequestID(h))
+		return nil, err
+	}
+	if policies == nil {
+		policies = make([]metadata.AuthPolicy, 0)
+	}
+	a.policies.set(ownerID, userName, policies)
+	return policies, nil
+}
+
+// ListAuthorizedResources list the ids of the resources that the user has the action on
+func (a *authorizer) ListAuthorizedResources(ctx context.Context, h http.Header,
+	input meta.ListAuthorizedResourcesParam) ([]string, error) {
+
+	rid := util.GetHTTPCCRequestID(h)
+	action, err := iam.ConvertResourceAction(input.ResourceType, input.Action, input.BizID)
+	if err != nil {
+		blog.ErrorJSON("convert cmdb resource to iam action failed, err: %s, input: %s, rid: %s", err, input, rid)
+		return nil, err
+	}
+
+	policies, err := a.getPolicies(ctx, h, input.UserName)
+	if err != nil {
+		return nil, err
+	}
+
+	idMap := make(map[int64]struct{})
+	for _, policy := range policies {
+		if !hasAction(policy.Actions, action) {
+			continue
+		}
+
+		ids, err := a.listScopeResourceIDs(ctx, h, &policy.Scope, input)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			idMap[id] = struct{}{}
+		}
+	}
+
+	resourceIDs := make([]string, 0)
+	for id := range idMap {
+		resourceIDs = append(resourceIDs, strconv.FormatInt(id, 10))
+	}
+	return resourceIDs, nil
+}
+
+// listScopeResourceIDs list the ids of the resources of the resource type that is within the scope
+func (a *authorizer) listScopeResourceIDs(ctx context.Context, h http.Header, scope *metadata.AuthScope,
+	input meta.ListAuthorizedResourcesParam) ([]int64, error) {
+
+	switch scope.Type {
+	case metadata.AuthScopeGlobal:
+		cond := make(map[string]interface{})
+		if input.BizID > 0 && input.ResourceType != meta.Business {
+			cond[common.BKAppIDField] = input.BizID
+		}
+		return a.listResourceIDs(ctx, h, input.ResourceType, cond)
+	case metadata.AuthScopeBusiness:
+		if input.BizID > 0 && input.BizID != scope.BizID {
+			return nil, nil
+		}
+		if input.ResourceType == meta.Business {
+			return []int64{scope.BizID}, nil
+		}
+		return a.listResourceIDs(ctx, h, input.ResourceType, map[string]interface{}{common.BKAppIDField: scope.BizID})
+	case metadata.AuthScopeModel:
+		if input.ResourceType == meta.Model {
+			return []int64{scope.ModelID}, nil
+		}
+		return nil, nil
+	case metadata.AuthScopeInstance:
+		if string(input.ResourceType) == scope.ResourceType {
+			return scope.InstanceIDs, nil
+		}
+		return nil, nil
+	default:
+		return nil, nil
+	}
+}
+
+// resourceTables is the table and id field of the resources that can be listed
+var resourceTables = map[meta.ResourceType][2]string{
+	meta.Business:          {common.BKTableNameBaseApp, common.BKAppIDField},
+	meta.Model:             {common.BKTableNameObjDes, common.BKFieldID},
+	meta.EventPushing:      {common.BKTableNameSubscription, common.BKSubscriptionIDField},
+	meta.CloudAccount:      {common.BKTableNameCloudAccount, common.BKCloudAccountID},
+	meta.CloudResourceTask: {common.BKTableNameCloudSyncTask, common.BKCloudTaskID},
+}
+
+func (a *authorizer) listResourceIDs(ctx context.Context, h http.Header, resourceType meta.ResourceType,
+	cond map[string]interface{}) ([]int64, error) {
+
+	rid := util.GetHTTPCCRequestID(h)
+	table, exist := resourceTables[resourceType]
+	if !exist {
+		blog.Errorf("list authorized resources of resource type %s is not supported, rid: %s", resourceType, rid)
+		return nil, fmt.Errorf("list authorized resources of resource type %s is not supported", resourceType)
+	}
+
+	ids := make([]int64, 0)
+	param := metadata.PullResourceParam{
+		Collection: table[0],
+		Condition:  cond,
+		Fields:     []string{table[1]},
+		Limit:      common.BKMaxPageSize,
+	}
+	for {
+		resp, err := a.coreService.Auth().SearchAuthResource(ctx, h, param)
+		if err != nil {
+			blog.Errorf("search auth resource failed, err: %v, param: %+v, rid: %s", err, param, rid)
+			return nil, err
+		}
+		if !resp.Result {
+			blog.Errorf("search auth resource failed, err: %s, param: %+v, rid: %s", resp.ErrMsg, param, rid)
+			return nil, resp.CCError()
+		}
+
+		for _, info := range resp.Data.Info {
+			id, err := util.GetInt64ByInterface(info[table[1]])
+			if err != nil {
+				blog.Errorf("parse resource id %v failed, err: %v, rid: %s", info[table[1]], err, rid)
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+
+		if len(resp.Data.Info) < common.BKMaxPageSize {
+			break
+		}
+		param.Offset += common.BKMaxPageSize
+	}
+	return ids, nil
+}
+
+// GetNoAuthSkipUrl has no url to apply permission, the roles are managed by the administrators of cmdb.
+func (a *authorizer) GetNoAuthSkipUrl(ctx context.Context, h http.Header, input *metadata.IamPermission) (string,
+	error) {
+	return "", nil
+}
+
+// GetPermissionToApply returns the actions that the user need to be granted
+func (a *authorizer) GetPermissionToApply(ctx context.Context, h http.Header, input []meta.ResourceAttribute) (
+	*metadata.IamPermission, error) {
+
+	permission := &metadata.IamPermission{
+		SystemID:   iam.SystemIDCMDB,
+		SystemName: iam.SystemNameCMDB,
+		Actions:    make([]metadata.IamAction, 0),
+	}
+
+	actionMap := make(map[iam.ActionID]struct{})
+	for _, resource := range input {
+		if resource.Action == meta.SkipAction {
+			continue
+		}
+		action, err := iam.ConvertResourceAction(resource.Type, resource.Action, resource.BusinessID)
+		if err != nil {
+			blog.Errorf("convert cmdb resource to iam action failed, err: %s, rid: %s", err,
+				util.GetHTTPCCRequestID(h))
+			return nil, err
+		}
+		if _, exist := actionMap[action]; exist || action == iam.Skip {
+			continue
+		}
+		actionMap[action] = struct{}{}
+		permission.Actions = append(permission.Actions, metadata.IamAction{
+			ID:   string(action),
+			Name: iam.ActionIDNameMap[action],
+		})
+	}
+	return permission, nil
+}
+
+// RegisterResourceCreatorAction does nothing, the creator of a resource is not granted any permissions.
+func (a *authorizer) RegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstanceWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return make([]metadata.IamCreatorActionPolicy, 0), nil
+}
+
+// BatchRegisterResourceCreatorAction does nothing, the creator of a resource is not granted any permissions.
+func (a *authorizer) BatchRegisterResourceCreatorAction(ctx context.Context, h http.Header,
+	input metadata.IamInstancesWithCreator) ([]metadata.IamCreatorActionPolicy, error) {
+	return make([]metadata.IamCreatorActionPolicy, 0), nil
+}
@@ -86,6 +86,31 @@ func (b *baseCache) ListHostWithPage(ctx context.Context, h http.Header, opt *me
 	return resp.Data.Count, resp.Data.Info, nil
 }
 
+// ListHostWithSecondaryKeys find hosts with secondary keys, such as asset id, sn. the result is in the
+// same sequence with the request keys.
+func (b *baseCache) ListHostWithSecondaryKeys(ctx context.Context, h http.Header,
+	opt *metadata.ListHostWithSecondaryKeysOption) ([]metadata.HostWithSecondaryKey, error) {
+
+	resp := new(metadata.ListHostWithSecondaryKeysResult)
+	err := b.client.Post().
+		WithContext(ctx).
+		Body(opt).
+		SubResourcef("/findmany/cache/host/with_secondary_keys").
+		WithHeaders(h).
+		Do().
+		Into(resp)
+
+	if err != nil {
+		return nil, errors.New(common.CCErrCommHTTPDoRequestFailed, err.Error())
+	}
+
+	if !resp.Result {
+		return nil, errors.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
 func (b *baseCache) ListHostWithHostID(ctx context.Context, h http.Header, opt *metadata.ListWithIDOption) (jsonString string, err error) {
 
 	resp, err := b.client.Post().
@@ -0,0 +1,38 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/metadata"
+)
+
+type Interface interface {
+	SearchInstWithID(ctx context.Context, h http.Header, opt *metadata.SearchInstWithIDOption) (jsonString string, err error)
+	ListInstWithID(ctx context.Context, h http.Header, opt *metadata.ListInstWithIDOption) (jsonString string, err error)
+	SearchInstWithUnique(ctx context.Context, h http.Header, opt *metadata.SearchInstWithUniqueOption) (jsonString string, err error)
+	SearchInstAsstWithID(ctx context.Context, h http.Header, opt *metadata.SearchInstAsstWithIDOption) (jsonString string, err error)
+	ListInstAsstWithID(ctx context.Context, h http.Header, opt *metadata.ListWithIDOption) (jsonString string, err error)
+	SearchInstAsstWithUnique(ctx context.Context, h http.Header, opt *metadata.SearchInstAsstWithUniqueOption) (jsonString string, err error)
+}
+
+func NewCacheClient(client rest.ClientInterface) Interface {
+	return &baseCache{client: client}
+}
+
+type baseCache struct {
+	client rest.ClientInterface
+}
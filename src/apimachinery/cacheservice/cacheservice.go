@@ -17,6 +17,7 @@ import (
 
 	"configcenter/src/apimachinery/cacheservice/cache/event"
 	"configcenter/src/apimachinery/cacheservice/cache/host"
+	"configcenter/src/apimachinery/cacheservice/cache/instance"
 	"configcenter/src/apimachinery/cacheservice/cache/topology"
 	"configcenter/src/apimachinery/rest"
 	"configcenter/src/apimachinery/util"
@@ -26,6 +27,7 @@ type Cache interface {
 	Host() host.Interface
 	Topology() topology.Interface
 	Event() event.Interface
+	Instance() instance.Interface
 }
 
 type CacheServiceClientInterface interface {
@@ -64,3 +66,7 @@ func (c *cache) Topology() topology.Interface {
 func (c *cache) Event() event.Interface {
 	return event.NewCacheClient(c.restCli)
 }
+
+func (c *cache) Instance() instance.Interface {
+	return instance.NewCacheClient(c.restCli)
+}
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/apimachinery/flowctrl"
 	"configcenter/src/apimachinery/healthz"
 	"configcenter/src/apimachinery/hostserver"
+	"configcenter/src/apimachinery/openapi"
 	"configcenter/src/apimachinery/procserver"
 	"configcenter/src/apimachinery/taskserver"
 	"configcenter/src/apimachinery/toposerver"
@@ -46,6 +47,7 @@ type ClientSetInterface interface {
 	CacheService() cacheservice.CacheServiceClientInterface
 
 	Healthz() healthz.HealthzInterface
+	OpenAPI() openapi.OpenAPIInterface
 }
 
 func NewApiMachinery(c *util.APIMachineryConfig, discover discovery.DiscoveryInterface) (ClientSetInterface, error) {
@@ -156,6 +158,14 @@ func (cs *ClientSet) Healthz() healthz.HealthzInterface {
 	return healthz.NewHealthzClient(c, cs.discover)
 }
 
+func (cs *ClientSet) OpenAPI() openapi.OpenAPIInterface {
+	c := &util.Capability{
+		Client:   cs.client,
+		Throttle: cs.throttle,
+	}
+	return openapi.NewOpenAPIClient(c, cs.discover)
+}
+
 func (cs *ClientSet) CoreService() coreservice.CoreServiceClientInterface {
 	c := &util.Capability{
 		Client:   cs.client,
@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (a *auth) CreateAPIToken(ctx context.Context, h http.Header, option *metadata.SaveAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder) {
+	ret := new(metadata.OneAPITokenResult)
+	subPath := "/create/auth/api_token"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) ListAPITokens(ctx context.Context, h http.Header, option *metadata.ListAPITokensOption) (*metadata.MultipleAPIToken, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleAPITokenResult)
+	subPath := "/findmany/auth/api_token"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListAPITokens failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) RevokeAPITokens(ctx context.Context, h http.Header, option *metadata.RevokeAPITokensOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/update/auth/api_token/revoke"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("RevokeAPITokens failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+func (a *auth) VerifyAPIToken(ctx context.Context, h http.Header, option *metadata.VerifyAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder) {
+	ret := new(metadata.OneAPITokenResult)
+	subPath := "/find/auth/api_token/verify"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("VerifyAPIToken failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
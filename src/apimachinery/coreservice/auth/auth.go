@@ -17,11 +17,34 @@ import (
 	"net/http"
 
 	"configcenter/src/apimachinery/rest"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 )
 
 type AuthClientInterface interface {
 	SearchAuthResource(ctx context.Context, h http.Header, param metadata.PullResourceParam) (metadata.PullResourceResponse, error)
+
+	// built-in rbac authorization
+	CreateAuthRole(ctx context.Context, h http.Header, role *metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder)
+	UpdateAuthRole(ctx context.Context, h http.Header, role *metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder)
+	DeleteAuthRoles(ctx context.Context, h http.Header, option *metadata.DeleteAuthRBACOption) errors.CCErrorCoder
+	ListAuthRoles(ctx context.Context, h http.Header, option *metadata.ListAuthRolesOption) (*metadata.MultipleAuthRole, errors.CCErrorCoder)
+	CreateAuthRoleBinding(ctx context.Context, h http.Header, binding *metadata.AuthRoleBinding) (*metadata.AuthRoleBinding, errors.CCErrorCoder)
+	DeleteAuthRoleBindings(ctx context.Context, h http.Header, option *metadata.DeleteAuthRBACOption) errors.CCErrorCoder
+	ListAuthRoleBindings(ctx context.Context, h http.Header, option *metadata.ListAuthRoleBindingsOption) (*metadata.MultipleAuthRoleBinding, errors.CCErrorCoder)
+	CreateAuthUserGroup(ctx context.Context, h http.Header, group *metadata.AuthUserGroup) (*metadata.AuthUserGroup, errors.CCErrorCoder)
+	UpdateAuthUserGroup(ctx context.Context, h http.Header, group *metadata.AuthUserGroup) (*metadata.AuthUserGroup, errors.CCErrorCoder)
+	DeleteAuthUserGroups(ctx context.Context, h http.Header, option *metadata.DeleteAuthRBACOption) errors.CCErrorCoder
+	ListAuthUserGroups(ctx context.Context, h http.Header, option *metadata.ListAuthUserGroupsOption) (*metadata.MultipleAuthUserGroup, errors.CCErrorCoder)
+	AddAuthUserGroupMembers(ctx context.Context, h http.Header, option *metadata.AuthUserGroupMembersOption) errors.CCErrorCoder
+	RemoveAuthUserGroupMembers(ctx context.Context, h http.Header, option *metadata.AuthUserGroupMembersOption) errors.CCErrorCoder
+	GetAuthPolicies(ctx context.Context, h http.Header, option *metadata.GetAuthPoliciesOption) ([]metadata.AuthPolicy, errors.CCErrorCoder)
+
+	// personal api tokens
+	CreateAPIToken(ctx context.Context, h http.Header, option *metadata.SaveAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder)
+	ListAPITokens(ctx context.Context, h http.Header, option *metadata.ListAPITokensOption) (*metadata.MultipleAPIToken, errors.CCErrorCoder)
+	RevokeAPITokens(ctx context.Context, h http.Header, option *metadata.RevokeAPITokensOption) errors.CCErrorCoder
+	VerifyAPIToken(ctx context.Context, h http.Header, option *metadata.VerifyAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder)
 }
 
 func NewAuthClientInterface(client rest.ClientInterface) AuthClientInterface {
@@ -0,0 +1,344 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (a *auth) CreateAuthRole(ctx context.Context, h http.Header, role *metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder) {
+	ret := new(metadata.OneAuthRoleResult)
+	subPath := "/create/auth/rbac/role"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(role).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateAuthRole failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) UpdateAuthRole(ctx context.Context, h http.Header, role *metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder) {
+	ret := new(metadata.OneAuthRoleResult)
+	subPath := "/update/auth/rbac/role"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(role).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("UpdateAuthRole failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) DeleteAuthRoles(ctx context.Context, h http.Header, option *metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/delete/auth/rbac/role"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("DeleteAuthRoles failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+func (a *auth) ListAuthRoles(ctx context.Context, h http.Header, option *metadata.ListAuthRolesOption) (*metadata.MultipleAuthRole, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleAuthRoleResult)
+	subPath := "/findmany/auth/rbac/role"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListAuthRoles failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) CreateAuthRoleBinding(ctx context.Context, h http.Header, binding *metadata.AuthRoleBinding) (*metadata.AuthRoleBinding, errors.CCErrorCoder) {
+	ret := new(metadata.OneAuthRoleBindingResult)
+	subPath := "/create/auth/rbac/role_binding"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(binding).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) DeleteAuthRoleBindings(ctx context.Context, h http.Header, option *metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/delete/auth/rbac/role_binding"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("DeleteAuthRoleBindings failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+func (a *auth) ListAuthRoleBindings(ctx context.Context, h http.Header, option *metadata.ListAuthRoleBindingsOption) (*metadata.MultipleAuthRoleBinding, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleAuthRoleBindingResult)
+	subPath := "/findmany/auth/rbac/role_binding"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListAuthRoleBindings failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) CreateAuthUserGroup(ctx context.Context, h http.Header, group *metadata.AuthUserGroup) (*metadata.AuthUserGroup, errors.CCErrorCoder) {
+	ret := new(metadata.OneAuthUserGroupResult)
+	subPath := "/create/auth/rbac/user_group"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(group).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateAuthUserGroup failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) UpdateAuthUserGroup(ctx context.Context, h http.Header, group *metadata.AuthUserGroup) (*metadata.AuthUserGroup, errors.CCErrorCoder) {
+	ret := new(metadata.OneAuthUserGroupResult)
+	subPath := "/update/auth/rbac/user_group"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(group).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("UpdateAuthUserGroup failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) DeleteAuthUserGroups(ctx context.Context, h http.Header, option *metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/delete/auth/rbac/user_group"
+
+	err := a.client.Delete().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("DeleteAuthUserGroups failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+func (a *auth) ListAuthUserGroups(ctx context.Context, h http.Header, option *metadata.ListAuthUserGroupsOption) (*metadata.MultipleAuthUserGroup, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleAuthUserGroupResult)
+	subPath := "/findmany/auth/rbac/user_group"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListAuthUserGroups failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (a *auth) AddAuthUserGroupMembers(ctx context.Context, h http.Header, option *metadata.AuthUserGroupMembersOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/update/auth/rbac/user_group/members/add"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("AddAuthUserGroupMembers failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+func (a *auth) RemoveAuthUserGroupMembers(ctx context.Context, h http.Header, option *metadata.AuthUserGroupMembersOption) errors.CCErrorCoder {
+	ret := new(metadata.BaseResp)
+	subPath := "/update/auth/rbac/user_group/members/remove"
+
+	err := a.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("RemoveAuthUserGroupMembers failed, http request failed, err: %+v", err)
+		return errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return ret.CCError()
+	}
+
+	return nil
+}
+
+func (a *auth) GetAuthPolicies(ctx context.Context, h http.Header, option *metadata.GetAuthPoliciesOption) ([]metadata.AuthPolicy, errors.CCErrorCoder) {
+	ret := new(metadata.AuthPoliciesResult)
+	subPath := "/find/auth/rbac/policy"
+
+	err := a.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("GetAuthPolicies failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return ret.Data, nil
+}
@@ -201,6 +201,34 @@ func (h *host) QueryHostLock(ctx context.Context, header http.Header, input *met
 	return resp, err
 }
 
+func (h *host) ListHostLocks(ctx context.Context, header http.Header, input *metadata.ListHostLocksOption) (*metadata.ListHostLocksResult, error) {
+	resp := new(metadata.ListHostLocksResult)
+	subPath := "/findmany/host/lock"
+
+	err := h.client.Post().
+		Body(input).
+		WithContext(ctx).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
+func (h *host) ExpireHostLocks(ctx context.Context, header http.Header, input *metadata.ExpireHostLocksOption) (*metadata.ExpireHostLocksResult, error) {
+	resp := new(metadata.ExpireHostLocksResult)
+	subPath := "/update/host/lock/expire"
+
+	err := h.client.Put().
+		Body(input).
+		WithContext(ctx).
+		SubResourcef(subPath).
+		WithHeaders(header).
+		Do().
+		Into(resp)
+	return resp, err
+}
+
 // CreateDynamicGroup is dynamic group query datas base on conditions action api machinery.
 func (h *host) CreateDynamicGroup(ctx context.Context, header http.Header,
 	data *metadata.DynamicGroup) (resp *metadata.IDResult, err error) {
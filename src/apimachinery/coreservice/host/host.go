@@ -37,6 +37,8 @@ type HostClientInterface interface {
 	LockHost(ctx context.Context, header http.Header, input *metadata.HostLockRequest) (resp *metadata.HostLockResponse, err error)
 	UnlockHost(ctx context.Context, header http.Header, input *metadata.HostLockRequest) (resp *metadata.HostLockResponse, err error)
 	QueryHostLock(ctx context.Context, header http.Header, input *metadata.QueryHostLockRequest) (resp *metadata.HostLockQueryResponse, err error)
+	ListHostLocks(ctx context.Context, header http.Header, input *metadata.ListHostLocksOption) (*metadata.ListHostLocksResult, error)
+	ExpireHostLocks(ctx context.Context, header http.Header, input *metadata.ExpireHostLocksOption) (*metadata.ExpireHostLocksResult, error)
 
 	// dynamic grouping interfaces.
 	CreateDynamicGroup(ctx context.Context, header http.Header, data *metadata.DynamicGroup) (resp *metadata.IDResult, err error)
@@ -78,6 +78,20 @@ func (inst *instance) UpdateInstance(ctx context.Context, h http.Header, objID s
 	return
 }
 
+func (inst *instance) RecalculateInstance(ctx context.Context, h http.Header, objID string, input *metadata.RecalculateModelInstance) (resp *metadata.UpdatedOptionResult, err error) {
+	resp = new(metadata.UpdatedOptionResult)
+	subPath := "/update/model/%s/instance/calculated"
+
+	err = inst.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (inst *instance) ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (resp *metadata.QueryConditionResult, err error) {
 	resp = new(metadata.QueryConditionResult)
 	subPath := "/read/model/%s/instances"
@@ -466,6 +466,45 @@ func (m *model) ReadModelAttrUnique(ctx context.Context, h http.Header, inputPar
 	return
 }
 
+func (m *model) SearchModelSchemaVersions(ctx context.Context, h http.Header, objID string, input metadata.QueryCondition) (resp *metadata.SearchModelSchemaVersionResult, err error) {
+	subPath := "/read/model/%s/schema/versions"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(&resp)
+	return
+}
+
+func (m *model) DiffModelSchema(ctx context.Context, h http.Header, objID string, input metadata.DiffModelSchemaOption) (resp *metadata.ModelSchemaDiffResult, err error) {
+	subPath := "/read/model/%s/schema/diff"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(&resp)
+	return
+}
+
+func (m *model) RollbackModelSchema(ctx context.Context, h http.Header, objID string, input metadata.RollbackModelSchemaOption) (resp *metadata.RollbackModelSchemaResult, err error) {
+	subPath := "/update/model/%s/schema/rollback"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResourcef(subPath, objID).
+		WithHeaders(h).
+		Do().
+		Into(&resp)
+	return
+}
+
 // GetModelStatistics 统计各个模型的实例数
 func (m *model) GetModelStatistics(ctx context.Context, h http.Header) (resp *metadata.Response, err error) {
 	resp = new(metadata.Response)
@@ -59,6 +59,10 @@ type ModelClientInterface interface {
 	UpdateModelAttrUnique(ctx context.Context, h http.Header, objID string, id uint64, data metadata.UpdateModelAttrUnique) (*metadata.UpdatedOptionResult, error)
 	DeleteModelAttrUnique(ctx context.Context, h http.Header, objID string, id uint64) (*metadata.DeletedOptionResult, error)
 	ReadModelAttrUnique(ctx context.Context, h http.Header, inputParam metadata.QueryCondition) (*metadata.ReadModelUniqueResult, error)
+
+	SearchModelSchemaVersions(ctx context.Context, h http.Header, objID string, input metadata.QueryCondition) (*metadata.SearchModelSchemaVersionResult, error)
+	DiffModelSchema(ctx context.Context, h http.Header, objID string, input metadata.DiffModelSchemaOption) (*metadata.ModelSchemaDiffResult, error)
+	RollbackModelSchema(ctx context.Context, h http.Header, objID string, input metadata.RollbackModelSchemaOption) (*metadata.RollbackModelSchemaResult, error)
 }
 
 func NewModelClientInterface(client rest.ClientInterface) ModelClientInterface {
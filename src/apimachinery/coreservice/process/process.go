@@ -39,6 +39,13 @@ type ProcessInterface interface {
 	ListServiceTemplates(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateOption) (*metadata.MultipleServiceTemplate, errors.CCErrorCoder)
 	DeleteServiceTemplate(ctx context.Context, h http.Header, serviceTemplateID int64) errors.CCErrorCoder
 
+	// service template version and rollout
+	CreateServiceTemplateVersion(ctx context.Context, h http.Header, option *metadata.CreateServiceTemplateVersionOption) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder)
+	ListServiceTemplateVersions(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateVersionsOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder)
+	CreateServiceTemplateRollout(ctx context.Context, h http.Header, rollout *metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	UpdateServiceTemplateRollout(ctx context.Context, h http.Header, option *metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	ListServiceTemplateRollouts(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateRolloutsOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder)
+
 	// process template
 	CreateProcessTemplate(ctx context.Context, h http.Header, template *metadata.ProcessTemplate) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	GetProcessTemplate(ctx context.Context, h http.Header, templateID int64) (*metadata.ProcessTemplate, errors.CCErrorCoder)
@@ -0,0 +1,137 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+)
+
+func (p *process) CreateServiceTemplateVersion(ctx context.Context, h http.Header, option *metadata.CreateServiceTemplateVersionOption) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateVersionResult)
+	subPath := "/create/process/service_template/version"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) ListServiceTemplateVersions(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateVersionsOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleServiceTemplateVersionResult)
+	subPath := "/findmany/process/service_template/version"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) CreateServiceTemplateRollout(ctx context.Context, h http.Header, rollout *metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateRolloutResult)
+	subPath := "/create/process/service_template/rollout"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(rollout).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) UpdateServiceTemplateRollout(ctx context.Context, h http.Header, option *metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.OneServiceTemplateRolloutResult)
+	subPath := "/update/process/service_template/rollout"
+
+	err := p.client.Put().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
+
+func (p *process) ListServiceTemplateRollouts(ctx context.Context, h http.Header, option *metadata.ListServiceTemplateRolloutsOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder) {
+	ret := new(metadata.MultipleServiceTemplateRolloutResult)
+	subPath := "/findmany/process/service_template/rollout"
+
+	err := p.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, http request failed, err: %+v", err)
+		return nil, errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return nil, ret.CCError()
+	}
+
+	return &ret.Data, nil
+}
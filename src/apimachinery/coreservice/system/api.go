@@ -60,3 +60,29 @@ func (s *system) SearchConfigAdmin(ctx context.Context, h http.Header) (resp *me
 
 	return
 }
+
+func (s *system) SearchTenants(ctx context.Context, h http.Header, option *metadata.ListTenantsOption) (
+	*metadata.MultipleTenant, errors.CCErrorCoder) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	resp := new(metadata.MultipleTenantResult)
+	subPath := "/findmany/system/tenant"
+
+	httpDoErr := s.client.Post().
+		WithContext(ctx).
+		Body(option).
+		SubResourcef(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	if httpDoErr != nil {
+		blog.Errorf("SearchTenants failed, http request failed, err: %+v, rid: %s", httpDoErr, rid)
+		return nil, errors.CCHttpError
+	}
+	if err := resp.CCError(); err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
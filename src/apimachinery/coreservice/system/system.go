@@ -25,6 +25,9 @@ type SystemClientInterface interface {
 	GetUserConfig(ctx context.Context, h http.Header) (*metadata.ResponseSysUserConfigData, errors.CCErrorCoder)
 
 	SearchConfigAdmin(ctx context.Context, h http.Header) (*metadata.ConfigAdminResult, error)
+
+	SearchTenants(ctx context.Context, h http.Header, option *metadata.ListTenantsOption) (*metadata.MultipleTenant,
+		errors.CCErrorCoder)
 }
 
 func NewSystemClientInterface(client rest.ClientInterface) SystemClientInterface {
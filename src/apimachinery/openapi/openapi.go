@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/apimachinery/rest"
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/common/openapi"
+	"configcenter/src/common/types"
+)
+
+// OpenAPIInterface get the OpenAPI documents of the services
+type OpenAPIInterface interface {
+	GetDocument(ctx context.Context, h http.Header, moduleName string) (*openapi.Document, error)
+}
+
+func NewOpenAPIClient(capability *util.Capability, disc discovery.DiscoveryInterface) OpenAPIInterface {
+	return &openAPI{
+		capability: capability,
+		disc:       disc,
+	}
+}
+
+type openAPI struct {
+	capability *util.Capability
+	disc       discovery.DiscoveryInterface
+}
+
+// GetDocument get the OpenAPI document of the routes registered by the module
+func (o *openAPI) GetDocument(ctx context.Context, h http.Header, moduleName string) (*openapi.Document, error) {
+	switch moduleName {
+	case types.CC_MODULE_DATACOLLECTION:
+		o.capability.Discover = o.disc.DataCollect()
+
+	case types.CC_MODULE_HOST:
+		o.capability.Discover = o.disc.HostServer()
+
+	case types.CC_MODULE_MIGRATE:
+		o.capability.Discover = o.disc.MigrateServer()
+
+	case types.CC_MODULE_PROC:
+		o.capability.Discover = o.disc.ProcServer()
+
+	case types.CC_MODULE_TOPO:
+		o.capability.Discover = o.disc.TopoServer()
+
+	case types.CC_MODULE_EVENTSERVER:
+		o.capability.Discover = o.disc.EventServer()
+
+	case types.CC_MODULE_OPERATION:
+		o.capability.Discover = o.disc.OperationServer()
+
+	case types.CC_MODULE_TASK:
+		o.capability.Discover = o.disc.TaskServer()
+
+	case types.CC_MODULE_CLOUD:
+		o.capability.Discover = o.disc.CloudServer()
+
+	case types.CC_MODULE_CACHESERVICE:
+		o.capability.Discover = o.disc.CacheService()
+
+	default:
+		return nil, fmt.Errorf("unsupported openapi module: %s", moduleName)
+	}
+
+	doc := new(openapi.Document)
+	client := rest.NewRESTClient(o.capability, "/")
+	err := client.Get().
+		WithContext(ctx).
+		SubResourcef(openapi.Path).
+		WithHeaders(h).
+		Body(nil).
+		Do().
+		Into(doc)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
@@ -309,6 +309,8 @@ func (r *Request) Do() *Result {
 			req.Header.Del("Accept-Encoding")
 			req.Header.Set("Content-Type", "application/json")
 			req.Header.Set("Accept", "application/json")
+			// tell the server which module the request comes from, the origin app code is kept as is
+			req.Header.Set(common.BKHTTPCallerModule, common.GetIdentification())
 
 			if retries > 0 {
 				r.tryThrottle(url)
@@ -15,6 +15,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/apiserver/app/options"
@@ -72,6 +73,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	}
 
 	svc.SetConfig(engine, client, engine.Discovery(), engine.CoreAPI, cache, limiter)
+	systemAppCodes, _ := cc.String("apiServer.tenant.systemAppCodes")
+	svc.SetSystemAppCodes(strings.Split(systemAppCodes, ","))
+	systemToken, _ := cc.String("apiServer.tenant.systemToken")
+	svc.SetSystemToken(systemToken)
 
 	ctnr := restful.NewContainer()
 	ctnr.Router(restful.CurlyRouter{})
@@ -0,0 +1,336 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/ac/parser"
+	"configcenter/src/ac/rbac"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/rdapi"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// apiTokenPaths are the paths to manage the api tokens, they can not be called with an api token
+var apiTokenPaths = map[string]bool{
+	rootPath + "/create/api_token":        true,
+	rootPath + "/findmany/api_token":      true,
+	rootPath + "/update/api_token/revoke": true,
+}
+
+// apiTokenHintLength is the length of the beginning of the token that is saved to recognize the token
+const apiTokenHintLength = 12
+
+// getBearerAPIToken returns the cmdb api token in the Authorization header, other bearer tokens are ignored
+// so that they can still be handled by the gateways in front of cmdb.
+func getBearerAPIToken(header http.Header) string {
+	authorization := strings.TrimSpace(header.Get("Authorization"))
+	if len(authorization) < 7 || !strings.EqualFold(authorization[:7], "Bearer ") {
+		return ""
+	}
+	token := strings.TrimSpace(authorization[7:])
+	if !strings.HasPrefix(token, metadata.APITokenPrefix) {
+		return ""
+	}
+	return token
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// APITokenFilter authenticate the requests with the api token in the Authorization header, the owner of the token
+// is set as the request user so that the following filters and servers treat the request as the user's request.
+// it must be the first filter, before the user headers are checked.
+func (s *service) APITokenFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request,
+	resp *restful.Response, fchain *restful.FilterChain) {
+
+	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+		token := getBearerAPIToken(req.Request.Header)
+		if token == "" {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		rdapi.GenerateHttpHeaderRID(req.Request, resp.ResponseWriter)
+		rid := util.GetHTTPCCRequestID(req.Request.Header)
+		defErr := errFunc().CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+
+		// the token is a credential of the user, it is not passed to the other servers
+		req.Request.Header.Del("Authorization")
+
+		if apiTokenPaths[req.Request.URL.Path] {
+			blog.Errorf("api token can not be used to manage api tokens, path: %s, rid: %s", req.Request.URL.Path,
+				rid)
+			writeFilterError(resp, http.StatusForbidden,
+				defErr.CCErrorf(common.CCErrAPITokenOutOfScope, req.Request.URL.Path))
+			return
+		}
+
+		header := util.BuildHeader(common.CCSystemOperatorUserName, common.BKDefaultOwnerID)
+		header.Set(common.BKHTTPCCRequestID, rid)
+		apiToken, err := s.clientSet.CoreService().Auth().VerifyAPIToken(req.Request.Context(), header,
+			&metadata.VerifyAPITokenOption{TokenHash: hashAPIToken(token)})
+		if err != nil {
+			blog.Errorf("verify api token failed, caller: %s, err: %v, rid: %s", req.Request.RemoteAddr, err, rid)
+			if err.GetCode() == common.CCErrAPITokenInvalid {
+				writeFilterError(resp, http.StatusUnauthorized, defErr.CCError(common.CCErrAPITokenInvalid))
+				return
+			}
+			writeFilterError(resp, http.StatusInternalServerError, err)
+			return
+		}
+
+		// the request is made by the token owner, the user headers set by the caller are overridden
+		req.Request.Header.Del(common.BKHTTPOwner)
+		req.Request.Header.Set(common.BKHTTPOwnerID, apiToken.SupplierAccount)
+		req.Request.Header.Set(common.BKHTTPHeaderUser, apiToken.User)
+		req.Request.Header.Set(common.BKHTTPRequestAppCode, metadata.APITokenAppCodePrefix+strconv.FormatInt(apiToken.ID, 10))
+
+		if reason := s.checkAPITokenScope(req, &apiToken.Scope); reason != "" {
+			blog.Errorf("api token %d of user %s is out of scope, %s, path: %s, rid: %s", apiToken.ID, apiToken.User,
+				reason, req.Request.URL.Path, rid)
+			writeFilterError(resp, http.StatusForbidden, defErr.CCErrorf(common.CCErrAPITokenOutOfScope, reason))
+			return
+		}
+
+		fchain.ProcessFilter(req, resp)
+	}
+}
+
+// writeFilterError writes the error response of the requests that are rejected by the filters
+func writeFilterError(resp *restful.Response, status int, err errors.CCErrorCoder) {
+	rsp := metadata.BaseResp{
+		Code:   err.GetCode(),
+		ErrMsg: err.Error(),
+		Result: false,
+	}
+	resp.WriteHeaderAndJson(status, rsp, restful.MIME_JSON)
+}
+
+// checkAPITokenScope check if the request is within the scope of the api token, returns the reason if not
+func (s *service) checkAPITokenScope(req *restful.Request, scope *metadata.APITokenScope) string {
+	if !scope.ReadOnly && len(scope.BizIDs) == 0 && len(scope.ModelIDs) == 0 {
+		return ""
+	}
+
+	attribute, err := parser.ParseAttribute(req, s.engine)
+	if err != nil {
+		return fmt.Sprintf("parse request resources failed, err: %v", err)
+	}
+
+	if scope.ReadOnly && !isReadOnlyRequest(req.Request, attribute.Resources) {
+		return "the token is read only"
+	}
+
+	if len(attribute.Resources) == 0 && (len(scope.BizIDs) > 0 || len(scope.ModelIDs) > 0) {
+		return "the request does not belong to any business or model"
+	}
+
+	for i := range attribute.Resources {
+		resource := &attribute.Resources[i]
+		if len(scope.BizIDs) > 0 && !util.InArray(rbac.GetBusinessID(resource), scope.BizIDs) {
+			return fmt.Sprintf("%s resource is not in the businesses of the token", resource.Type)
+		}
+		if len(scope.ModelIDs) > 0 && !util.InArray(rbac.GetModelID(resource), scope.ModelIDs) {
+			return fmt.Sprintf("%s resource is not in the models of the token", resource.Type)
+		}
+	}
+	return ""
+}
+
+// readActions are the actions that do not change any resources
+var readActions = map[meta.Action]bool{
+	meta.Find:                 true,
+	meta.FindMany:             true,
+	meta.ModelTopologyView:    true,
+	meta.ViewBusinessResource: true,
+	meta.WatchHost:            true,
+	meta.WatchHostRelation:    true,
+	meta.WatchBiz:             true,
+	meta.WatchSet:             true,
+	meta.WatchModule:          true,
+	meta.WatchSetTemplate:     true,
+	meta.WatchProcess:         true,
+}
+
+// readOnlySkippedAPI is a read only api that is skipped by the auth parser
+type readOnlySkippedAPI struct {
+	method string
+	regex  *regexp.Regexp
+}
+
+// readOnlySkippedAPIs are the read only apis that are skipped by the auth parser. the skipped requests have no
+// exact action to tell whether they change the resources, so only the ones in the list are regarded as read only.
+var readOnlySkippedAPIs = []readOnlySkippedAPI{
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloud/account/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloud/account/validity/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloud/account/vpc/[0-9]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloud/sync/task/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloud/sync/region/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/resource/directory/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloudarea/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/cloudarea/hostcount/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/event/subscribe/search/\S+/\d+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/host/lock/search/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/host/lock/list/?$`)},
+	{http.MethodGet, regexp.MustCompile(`^/api/v3/hosts/snapshot/[0-9]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/hosts/snapshot/batch/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/identifier/[^\s/]+/search/?$`)},
+	{http.MethodGet, regexp.MustCompile(`^/api/v3/find/host_apply_rule/[0-9]+/bk_biz_id/[0-9]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/host_apply_rule/bk_biz_id/[0-9]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/host_apply_rule/bk_biz_id/[0-9]+/host_related_rules/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/createmany/host_apply_plan/bk_biz_id/[0-9]+/preview/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/proc/proc_template/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/proc/proc_template/id/[0-9]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/deletemany/proc/service_instance/preview/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/instassociation/object/[^\s/]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/instance/object/[^\s/]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/instance/object/[^\s/]+/unique_fields/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/findmany/object/instances/names/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/objecttopo/scope_type/[^\s/]+/scope_id/[^\s/]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/topomodelmainline/?$`)},
+	{http.MethodGet, regexp.MustCompile(`^/api/v3/biz/with_reduced/?$`)},
+	{http.MethodGet, regexp.MustCompile(`^/api/v3/biz/simplify/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/biz/search/[^\s/]+/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/biz/default/[^\s/]+/search/?$`)},
+	{http.MethodGet, regexp.MustCompile(`^/api/v3/object/statistics/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/find/full_text/?$`)},
+	{http.MethodPost, regexp.MustCompile(`^/api/v3/graphql/?$`)},
+	{http.MethodGet, regexp.MustCompile(`^/api/v3/graphql/schema/?$`)},
+}
+
+// isReadOnlyRequest check if the request only reads the resources. all the actions of the resources must be
+// read actions, and the request that has no resources to authorize or has the skipped resources must be one of
+// the read only skipped apis.
+func isReadOnlyRequest(req *http.Request, resources []meta.ResourceAttribute) bool {
+	skipped := len(resources) == 0
+	for _, resource := range resources {
+		if resource.Action == meta.SkipAction {
+			skipped = true
+			continue
+		}
+		if !readActions[resource.Action] {
+			return false
+		}
+	}
+
+	if !skipped {
+		return true
+	}
+	for _, api := range readOnlySkippedAPIs {
+		if req.Method == api.method && api.regex.MatchString(req.URL.Path) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAPIToken create an api token of the request user, the token is returned only once
+func (s *service) CreateAPIToken(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	option := metadata.CreateAPITokenOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("create api token, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		blog.Errorf("create api token, but generate token failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{
+			Msg: defErr.Errorf(common.CCErrCommInternalServerError, common.GetIdentification())})
+		return
+	}
+	token := metadata.APITokenPrefix + base64.RawURLEncoding.EncodeToString(bytes)
+
+	saveOption := &metadata.SaveAPITokenOption{
+		CreateAPITokenOption: option,
+		TokenHash:            hashAPIToken(token),
+		TokenHint:            token[:apiTokenHintLength],
+	}
+	apiToken, err := s.clientSet.CoreService().Auth().CreateAPIToken(req.Request.Context(), header, saveOption)
+	if err != nil {
+		blog.Errorf("create api token failed, name: %s, err: %v, rid: %s", option.Name, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.CreatedAPIToken{APIToken: *apiToken, Token: token}))
+}
+
+// ListAPITokens list the api tokens of the request user
+func (s *service) ListAPITokens(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	option := metadata.ListAPITokensOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("list api tokens, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.clientSet.CoreService().Auth().ListAPITokens(req.Request.Context(), header, &option)
+	if err != nil {
+		blog.Errorf("list api tokens failed, option: %+v, err: %v, rid: %s", option, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// RevokeAPITokens revoke the api tokens of the request user, the revoked tokens can not be used any more
+func (s *service) RevokeAPITokens(req *restful.Request, resp *restful.Response) {
+	header := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
+	rid := util.GetHTTPCCRequestID(header)
+
+	option := metadata.RevokeAPITokensOption{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&option); err != nil {
+		blog.Errorf("revoke api tokens, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	if err := s.clientSet.CoreService().Auth().RevokeAPITokens(req.Request.Context(), header, &option); err != nil {
+		blog.Errorf("revoke api tokens failed, option: %+v, err: %v, rid: %s", option, err, rid)
+		resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
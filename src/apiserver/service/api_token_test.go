@@ -0,0 +1,143 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+func newAPITokenRequest(method, path string) *restful.Request {
+	req := httptest.NewRequest(method, path, strings.NewReader("{}"))
+	for key, values := range util.BuildHeader("tester", common.BKDefaultOwnerID) {
+		req.Header[key] = values
+	}
+	return restful.NewRequest(req)
+}
+
+func TestIsReadOnlyRequest(t *testing.T) {
+	findHost := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Find}}
+	updateHost := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update}}
+	skip := meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.SkipAction}}
+
+	cases := []struct {
+		name      string
+		method    string
+		path      string
+		resources []meta.ResourceAttribute
+		expect    bool
+	}{
+		{"read action", http.MethodPost, "/api/v3/hosts/search", []meta.ResourceAttribute{findHost}, true},
+		{"write action", http.MethodPost, "/api/v3/hosts/search", []meta.ResourceAttribute{findHost, updateHost},
+			false},
+		{"skipped read api", http.MethodPost, "/api/v3/find/full_text", []meta.ResourceAttribute{skip}, true},
+		{"skipped read api with wrong method", http.MethodPut, "/api/v3/find/full_text",
+			[]meta.ResourceAttribute{skip}, false},
+		{"skipped write api", http.MethodPost, "/api/v3/createmany/proc/proc_template",
+			[]meta.ResourceAttribute{skip}, false},
+		{"skipped write api looks like search", http.MethodPut, "/api/v3/host/lock/search/expire",
+			[]meta.ResourceAttribute{skip}, false},
+		{"skipped write api contains find", http.MethodPost, "/api/v3/createmany/findings",
+			[]meta.ResourceAttribute{skip}, false},
+		{"no resources", http.MethodGet, "/api/v3/limiter/usage", nil, false},
+		{"no resources read api", http.MethodGet, "/api/v3/object/statistics", nil, true},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.path, nil)
+		if got := isReadOnlyRequest(req, c.resources); got != c.expect {
+			t.Errorf("%s: expect %v, got %v", c.name, c.expect, got)
+		}
+	}
+}
+
+func TestCheckAPITokenScope(t *testing.T) {
+	s := &service{}
+
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		scope  metadata.APITokenScope
+		reason string
+	}{
+		{
+			name:   "full scope",
+			method: http.MethodDelete,
+			path:   "/api/v3/deletemany/host_apply_rule/bk_biz_id/2",
+		},
+		{
+			name:   "read only with skipped read api",
+			method: http.MethodPost,
+			path:   "/api/v3/findmany/proc/proc_template",
+			scope:  metadata.APITokenScope{ReadOnly: true},
+		},
+		{
+			name:   "read only with skipped write api",
+			method: http.MethodPost,
+			path:   "/api/v3/createmany/proc/proc_template",
+			scope:  metadata.APITokenScope{ReadOnly: true},
+			reason: "the token is read only",
+		},
+		{
+			name:   "read only with write api",
+			method: http.MethodDelete,
+			path:   "/api/v3/deletemany/host_apply_rule/bk_biz_id/2",
+			scope:  metadata.APITokenScope{ReadOnly: true},
+			reason: "the token is read only",
+		},
+		{
+			name:   "business in scope",
+			method: http.MethodDelete,
+			path:   "/api/v3/deletemany/host_apply_rule/bk_biz_id/2",
+			scope:  metadata.APITokenScope{BizIDs: []int64{1, 2}},
+		},
+		{
+			name:   "business out of scope",
+			method: http.MethodDelete,
+			path:   "/api/v3/deletemany/host_apply_rule/bk_biz_id/3",
+			scope:  metadata.APITokenScope{BizIDs: []int64{1, 2}},
+			reason: "hostApply resource is not in the businesses of the token",
+		},
+		{
+			name:   "read only business in scope",
+			method: http.MethodGet,
+			path:   "/api/v3/find/host_apply_rule/1/bk_biz_id/2",
+			scope:  metadata.APITokenScope{ReadOnly: true, BizIDs: []int64{2}},
+		},
+		{
+			name:   "model out of scope",
+			method: http.MethodGet,
+			path:   "/api/v3/find/host_apply_rule/1/bk_biz_id/2",
+			scope:  metadata.APITokenScope{ModelIDs: []int64{5}},
+			reason: "mainlineInstanceTopology resource is not in the models of the token",
+		},
+	}
+
+	for _, c := range cases {
+		scope := c.scope
+		got := s.checkAPITokenScope(newAPITokenRequest(c.method, c.path), &scope)
+		if got != c.reason {
+			t.Errorf("%s: expect reason %q, got %q", c.name, c.reason, got)
+		}
+	}
+}
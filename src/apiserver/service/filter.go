@@ -13,7 +13,6 @@
 package service
 
 import (
-	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -24,6 +23,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/util"
 
 	"github.com/emicklei/go-restful"
@@ -160,6 +160,17 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 			return
 		}
 
+		if path == rootPath+openapi.Path {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		// the api tokens are managed by the users themselves
+		if apiTokenPaths[path] {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
 		language := util.GetLanguage(req.Request.Header)
 		attribute, err := parser.ParseAttribute(req, s.engine)
 		if err != nil {
@@ -227,33 +238,6 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 	}
 }
 
-// KEYS[1] is the redis key to incr and expire
-// ARGV[1] is the ttl
-const setRequestCntTTLScript = `
-local cnt = redis.pcall('INCR', KEYS[1]);
-if type(cnt) ~= "number"
-then
-	return cnt
-end
-
-local rs = redis.pcall('TTL', KEYS[1]);
-if type(rs) ~= "number"
-then
-	return rs
-end
-
-if rs == -1
-then
-	rs = redis.pcall('EXPIRE', KEYS[1], ARGV[1]);
-	if type(rs) ~= "number"
-	then
-		return rs
-	end
-end
-
-return cnt
-`
-
 // LimiterFilter limit on a api request according to limiter rules
 func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
 	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
@@ -276,32 +260,28 @@ func (s *service) LimiterFilter() func(req *restful.Request, resp *restful.Respo
 				ErrMsg: "too many requests",
 				Result: false,
 			}
-			resp.WriteAsJson(rsp)
+			resp.WriteHeaderAndJson(http.StatusTooManyRequests, rsp, restful.MIME_JSON)
 			return
 		}
 
-		key := common.ApiCacheLimiterRulePrefix + rule.RuleName
-		result, err := s.cache.Eval(context.Background(), setRequestCntTTLScript, []string{key}, rule.TTL).Result()
+		quotaKey := getQuotaKey(req, rule)
+		result, err := s.takeQuota(req.Request.Context(), rule, quotaKey)
 		if err != nil {
-			blog.Errorf("redis Eval failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, err, rid)
-			fchain.ProcessFilter(req, resp)
-			return
-		}
-		cnt, ok := result.(int64)
-		if !ok {
-			blog.Errorf("execute setRequestCntTTLScript failed, key:%s, rule:%#v, err: %v, rid: %s", key, *rule, result, rid)
+			// the requests are not limited when redis is unavailable
+			blog.Errorf("take quota failed, rule: %#v, quota key: %s, err: %v, rid: %s", *rule, quotaKey, err, rid)
 			fchain.ProcessFilter(req, resp)
 			return
 		}
+		setRateLimitHeaders(resp, rule, result)
 
-		if cnt > rule.Limit {
-			blog.Errorf("too many requests, matched rule is %#v, rid: %s", *rule, rid)
+		if !result.allowed {
+			blog.Errorf("too many requests, matched rule is %#v, quota key: %s, rid: %s", *rule, quotaKey, rid)
 			rsp := metadata.BaseResp{
 				Code:   common.CCErrTooManyRequestErr,
 				ErrMsg: "too many requests",
 				Result: false,
 			}
-			resp.WriteAsJson(rsp)
+			resp.WriteHeaderAndJson(http.StatusTooManyRequests, rsp, restful.MIME_JSON)
 			return
 		}
 
@@ -28,61 +28,86 @@ import (
 	"configcenter/src/common/zkclient"
 
 	"github.com/emicklei/go-restful"
+	"github.com/samuel/go-zookeeper/zk"
 )
 
 type Limiter struct {
-	zkCli        *zkclient.ZkClient
-	rules        map[string]*metadata.LimiterRule
-	lock         sync.RWMutex
-	syncDuration time.Duration
+	zkCli *zkclient.ZkClient
+	rules map[string]*metadata.LimiterRule
+	lock  sync.RWMutex
+	// retryDuration is the interval to retry when watching the rules failed
+	retryDuration time.Duration
+	// resyncDuration is the interval to resync the rules in case any change is not notified
+	resyncDuration time.Duration
 }
 
 func NewLimiter(zkCli *zkclient.ZkClient) *Limiter {
 	return &Limiter{
-		zkCli:        zkCli,
-		syncDuration: 5 * time.Second,
+		zkCli:          zkCli,
+		retryDuration:  5 * time.Second,
+		resyncDuration: 10 * time.Minute,
 	}
 }
 
-// SyncLimiterRules sync the api limiter rules from zk
+// SyncLimiterRules sync the api limiter rules from zk, the rules are watched so that the changes
+// take effect as soon as they are set.
 func (l *Limiter) SyncLimiterRules() error {
 	blog.Info("begin SyncLimiterRules")
 	path := types.CC_SERVLIMITER_BASEPATH
 	go func() {
 		for {
-			err := l.syncLimiterRules(path)
+			events, err := l.watchLimiterRules(path)
 			if err != nil {
-				blog.Errorf("fail to syncLimiterRules for path:%s, err:%s", path, err.Error())
+				blog.Errorf("fail to watchLimiterRules for path:%s, err:%s", path, err.Error())
+				time.Sleep(l.retryDuration)
+				continue
 			}
-			time.Sleep(l.syncDuration)
+			waitLimiterRulesChange(events, l.resyncDuration)
 		}
 	}()
 	return nil
 }
 
-func (l *Limiter) syncLimiterRules(path string) error {
+// watchLimiterRules sync the limiter rules and watch the changes of them, returns the watch channels
+func (l *Limiter) watchLimiterRules(path string) ([]<-chan zk.Event, error) {
 	blog.V(5).Infof("syncing limiter rules for path:%s", path)
-	children, err := l.zkCli.GetChildren(path)
+	children, childrenEvent, err := l.zkCli.WatchChildren(path)
 	if err != nil {
-		if err == zkclient.ErrNoNode {
-			// if no rules, set rules to be empty
-			l.setRules(make(map[string]*metadata.LimiterRule))
-			return nil
+		if err != zkclient.ErrNoNode {
+			blog.Errorf("fail to WatchChildren for path:%s, err:%s", path, err.Error())
+			return nil, err
+		}
+
+		// if no rules, set rules to be empty and wait for the rules to be set
+		l.setRules(make(map[string]*metadata.LimiterRule))
+		exists, _, existEvent, err := l.zkCli.ExistW(path)
+		if err != nil {
+			blog.Errorf("fail to ExistW for path:%s, err:%s", path, err.Error())
+			return nil, err
 		}
-		blog.Errorf("fail to GetChildren for path:%s, err:%s", path, err.Error())
-		return err
+		if exists {
+			// the path is created just now, watch it again
+			return l.watchLimiterRules(path)
+		}
+		return []<-chan zk.Event{existEvent}, nil
 	}
 
+	events := []<-chan zk.Event{childrenEvent}
 	rules := make(map[string]*metadata.LimiterRule)
 	for _, child := range children {
-		data, err := l.zkCli.Get(path + "/" + child)
+		data, _, event, err := l.zkCli.GetW(path + "/" + child)
 		if err != nil {
-			blog.Errorf("fail to Get for path:%s, err:%s", path, err.Error())
-			continue
+			if err == zkclient.ErrNoNode {
+				// the rule is deleted, the children watch will be notified
+				continue
+			}
+			blog.Errorf("fail to GetW for path:%s, err:%s", path, err.Error())
+			return nil, err
 		}
+		events = append(events, event)
 
 		rule := new(metadata.LimiterRule)
-		err = json.Unmarshal([]byte(data), rule)
+		err = json.Unmarshal(data, rule)
 		if err != nil {
 			blog.Errorf("fail to Unmarshal for child:%s, data:%s, err:%s", child, data, err.Error())
 			continue
@@ -98,7 +123,20 @@ func (l *Limiter) syncLimiterRules(path string) error {
 	}
 
 	l.setRules(rules)
-	return nil
+	return events, nil
+}
+
+// waitLimiterRulesChange wait until any of the watches is notified or timeout
+func waitLimiterRulesChange(events []<-chan zk.Event, timeout time.Duration) {
+	cases := make([]reflect.SelectCase, 0, len(events)+1)
+	for _, event := range events {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(event)})
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(timeout))})
+	chosen, value, _ := reflect.Select(cases)
+	if chosen < len(events) {
+		blog.V(5).Infof("limiter rules changed, event: %+v", value.Interface())
+	}
 }
 
 func (l *Limiter) setRules(rules map[string]*metadata.LimiterRule) {
@@ -0,0 +1,174 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/openapi"
+	"configcenter/src/common/types"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// pathPrefix maps the path prefix of a scene service to the path prefix of the api server
+type pathPrefix struct {
+	scene string
+	api   string
+}
+
+// sceneOpenAPI is a service whose OpenAPI document is merged into the document of the api server,
+// the prefixes are the reverse of the url transforms in url.go, the more specific ones are first.
+type sceneOpenAPI struct {
+	module   string
+	kind     RequestType
+	prefixes []pathPrefix
+}
+
+var sceneOpenAPIs = []sceneOpenAPI{
+	{module: types.CC_MODULE_TOPO, kind: TopoType, prefixes: []pathPrefix{
+		{scene: "/topo/v3/app", api: rootPath + "/biz"},
+		{scene: "/topo/v3", api: rootPath},
+	}},
+	{module: types.CC_MODULE_HOST, kind: HostType, prefixes: []pathPrefix{{scene: "/host/v3", api: rootPath}}},
+	{module: types.CC_MODULE_PROC, kind: ProcType, prefixes: []pathPrefix{
+		{scene: "/process/v3", api: rootPath},
+		{scene: "/process/v3", api: rootPath + "/proc"},
+	}},
+	{module: types.CC_MODULE_EVENTSERVER, kind: EventType, prefixes: []pathPrefix{
+		{scene: "/event/v3", api: rootPath + "/event"},
+	}},
+	{module: types.CC_MODULE_DATACOLLECTION, kind: DataCollectType, prefixes: []pathPrefix{
+		{scene: "/collector/v3", api: rootPath + "/collector"},
+	}},
+	{module: types.CC_MODULE_OPERATION, kind: OperationType, prefixes: []pathPrefix{
+		{scene: "/operation/v3", api: rootPath},
+	}},
+	{module: types.CC_MODULE_TASK, kind: TaskType, prefixes: []pathPrefix{{scene: "/task/v3", api: rootPath}}},
+	{module: types.CC_MODULE_MIGRATE, kind: AdminType, prefixes: []pathPrefix{
+		{scene: "/migrate/v3", api: rootPath + "/admin"},
+	}},
+	{module: types.CC_MODULE_CLOUD, kind: CloudType, prefixes: []pathPrefix{{scene: "/cloud/v3", api: rootPath}}},
+	{module: types.CC_MODULE_CACHESERVICE, kind: CacheType, prefixes: []pathPrefix{
+		{scene: "/cache/v3", api: rootPath + "/cache"},
+	}},
+}
+
+// openAPIParamRegexp matches the path parameters of the OpenAPI paths
+var openAPIParamRegexp = regexp.MustCompile(`\{[^{}]+\}`)
+
+// openAPICacheTTL is the time that the merged OpenAPI document is cached, the documents of the scene services
+// only change when they are upgraded.
+const openAPICacheTTL = 5 * time.Minute
+
+// openAPICache caches the merged OpenAPI document so that every request does not need to get the documents
+// of all the scene services.
+type openAPICache struct {
+	lock     sync.Mutex
+	doc      *openapi.Document
+	expireAt time.Time
+}
+
+// GetOpenAPI returns the OpenAPI document of the api server, it's merged from the documents of the scene
+// services, only the operations that can be requested through the api server are kept.
+func (s *service) GetOpenAPI(req *restful.Request, resp *restful.Response) {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+
+	if err := resp.WriteHeaderAndJson(http.StatusOK, s.getOpenAPI(req), restful.MIME_JSON); err != nil {
+		blog.Errorf("write openapi document failed, err: %v, rid: %s", err, rid)
+	}
+}
+
+// getOpenAPI returns the cached merged document, the documents of the scene services are got concurrently
+// when the cache is expired, and the concurrent requests wait for the same merge.
+func (s *service) getOpenAPI(req *restful.Request) *openapi.Document {
+	s.openAPI.lock.Lock()
+	defer s.openAPI.lock.Unlock()
+	if s.openAPI.doc != nil && time.Now().Before(s.openAPI.expireAt) {
+		return s.openAPI.doc
+	}
+
+	header := req.Request.Header
+	rid := util.GetHTTPCCRequestID(header)
+
+	sceneDocs := make([]*openapi.Document, len(sceneOpenAPIs))
+	var wg sync.WaitGroup
+	for idx := range sceneOpenAPIs {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			scene := sceneOpenAPIs[idx]
+			doc, err := s.clientSet.OpenAPI().GetDocument(req.Request.Context(), header, scene.module)
+			if err != nil {
+				// the module may be not deployed, the document is returned without its apis
+				blog.Errorf("get openapi document of %s failed, err: %v, rid: %s", scene.module, err, rid)
+				return
+			}
+			sceneDocs[idx] = scene.toAPIDocument(doc)
+		}(idx)
+	}
+	wg.Wait()
+
+	// the documents are merged in the order of the scene services, so that the result is stable
+	docs := append([]*openapi.Document{s.apiDoc}, sceneDocs...)
+	info := openapi.Info{Title: "cmdb api server", Version: s.apiDoc.Info.Version}
+	s.openAPI.doc = openapi.Merge(info, docs...)
+	s.openAPI.expireAt = time.Now().Add(openAPICacheTTL)
+	return s.openAPI.doc
+}
+
+// toAPIDocument converts the paths of the scene service document to the paths of the api server
+func (o sceneOpenAPI) toAPIDocument(doc *openapi.Document) *openapi.Document {
+	apiDoc := &openapi.Document{
+		OpenAPI:    doc.OpenAPI,
+		Info:       doc.Info,
+		Paths:      make(map[string]*openapi.PathItem),
+		Components: doc.Components,
+	}
+	for scenePath, item := range doc.Paths {
+		apiPath, ok := o.toAPIPath(scenePath)
+		if !ok {
+			continue
+		}
+		apiDoc.Paths[apiPath] = item
+	}
+	return apiDoc
+}
+
+// toAPIPath returns the api server path of the scene service path, the path is verified by the url filter
+// chain so that the requests of the returned path are proxied to the same path of the scene service.
+func (o sceneOpenAPI) toAPIPath(scenePath string) (string, bool) {
+	sceneURL := openAPIParamRegexp.ReplaceAllString(scenePath, "1")
+	for _, prefix := range o.prefixes {
+		if !strings.HasPrefix(scenePath, prefix.scene+"/") {
+			continue
+		}
+		apiPath := prefix.api + strings.TrimPrefix(scenePath, prefix.scene)
+		apiURL := openAPIParamRegexp.ReplaceAllString(apiPath, "1")
+
+		req := restful.NewRequest(&http.Request{URL: &url.URL{Path: apiURL}, RequestURI: apiURL})
+		kind, err := URLPath(apiURL).FilterChain(req)
+		if err != nil || kind != o.kind || req.Request.URL.Path != sceneURL {
+			continue
+		}
+		return apiPath, true
+	}
+	return "", false
+}
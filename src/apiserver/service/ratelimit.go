@@ -0,0 +1,449 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+	"github.com/tidwall/gjson"
+)
+
+// the response headers that tell the caller the quota of the matched limiter rule
+const (
+	rateLimitLimitHeader     = "X-RateLimit-Limit"
+	rateLimitRemainingHeader = "X-RateLimit-Remaining"
+	rateLimitResetHeader     = "X-RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// the limiter scripts share the same keys and arguments, and return {allowed, remaining, wait milliseconds},
+// the wait milliseconds is the retry after time if the request is not allowed, or the reset time if allowed.
+// KEYS[1] is the redis key of the quota
+// KEYS[2] is the redis key of the sorted set of the quota keys of the rule, it's used for the usage report
+// ARGV[1] is the limit of the rule
+// ARGV[2] is the ttl of the rule in milliseconds
+// ARGV[3] is the current time in milliseconds
+// ARGV[4] is the quota key of the request
+
+// fixedWindowScript counts the requests in a window that starts from the first request
+const fixedWindowScript = `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+redis.call('ZADD', KEYS[2], ARGV[3], ARGV[4])
+redis.call('PEXPIRE', KEYS[2], window * 2)
+
+local count = redis.call('INCR', KEYS[1])
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], window)
+	ttl = window
+end
+
+if count > limit then
+	return {0, 0, ttl}
+end
+return {1, limit - count, ttl}
+`
+
+// slidingWindowScript counts the requests of the current window and the previous window, the previous count
+// is weighted by how much the previous window overlaps the sliding window that ends now.
+const slidingWindowScript = `
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+redis.call('ZADD', KEYS[2], now, ARGV[4])
+redis.call('PEXPIRE', KEYS[2], window * 2)
+
+local index = math.floor(now / window)
+local elapsed = now - index * window
+local data = redis.call('HMGET', KEYS[1], 'window', 'current', 'previous')
+local last = tonumber(data[1])
+local current = tonumber(data[2]) or 0
+local previous = tonumber(data[3]) or 0
+if last == nil or last < index then
+	if last == index - 1 then
+		previous = current
+	else
+		previous = 0
+	end
+	current = 0
+end
+
+local used = math.floor(previous * (window - elapsed) / window) + current
+if used >= limit then
+	local wait = window - elapsed
+	if current < limit and previous > 0 then
+		wait = math.ceil(window - (limit - current) * window / previous - elapsed)
+		if wait < 1 then
+			wait = 1
+		end
+	end
+	return {0, 0, wait}
+end
+
+redis.call('HMSET', KEYS[1], 'window', index, 'current', current + 1, 'previous', previous)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, limit - used - 1, window - elapsed}
+`
+
+// tokenBucketScript takes a token from the bucket, the bucket holds limit tokens at most and is refilled
+// with limit tokens in ttl.
+const tokenBucketScript = `
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+redis.call('ZADD', KEYS[2], now, ARGV[4])
+redis.call('PEXPIRE', KEYS[2], window * 2)
+
+local rate = capacity / window
+local data = redis.call('HMGET', KEYS[1], 'tokens', 'timestamp')
+local tokens = tonumber(data[1]) or capacity
+local timestamp = tonumber(data[2]) or now
+if now > timestamp then
+	tokens = math.min(capacity, tokens + (now - timestamp) * rate)
+	timestamp = now
+end
+
+if tokens < 1 then
+	return {0, 0, math.ceil((1 - tokens) / rate)}
+end
+
+tokens = tokens - 1
+redis.call('HMSET', KEYS[1], 'tokens', tokens, 'timestamp', timestamp)
+redis.call('PEXPIRE', KEYS[1], window * 2)
+return {1, math.floor(tokens), math.ceil((capacity - tokens) / rate)}
+`
+
+// quotaKeysScript removes the expired quota keys of a rule and returns the active ones
+// KEYS[1] is the redis key of the sorted set of the quota keys of the rule
+// ARGV[1] is the time in milliseconds before which the quota keys are expired
+const quotaKeysScript = `
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+return redis.call('ZRANGE', KEYS[1], 0, -1)
+`
+
+// quotaUsageScript returns the usage of the quotas of a rule without consuming them, each quota returns
+// {used, reset milliseconds} in the order of the keys. all the keys are passed in KEYS and share the hash tag
+// of the rule, so that the script works with redis cluster.
+// KEYS are the redis keys of the quotas
+// ARGV[1] is the algorithm of the rule
+// ARGV[2] is the limit of the rule
+// ARGV[3] is the ttl of the rule in milliseconds
+// ARGV[4] is the current time in milliseconds
+const quotaUsageScript = `
+local algorithm = ARGV[1]
+local limit = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local result = {}
+for _, key in ipairs(KEYS) do
+	local used = 0
+	local reset = 0
+	if algorithm == 'sliding_window' then
+		local index = math.floor(now / window)
+		local elapsed = now - index * window
+		local data = redis.call('HMGET', key, 'window', 'current', 'previous')
+		local last = tonumber(data[1])
+		local current = tonumber(data[2]) or 0
+		local previous = tonumber(data[3]) or 0
+		if last == nil or last < index then
+			if last == index - 1 then
+				previous = current
+			else
+				previous = 0
+			end
+			current = 0
+		end
+		used = math.floor(previous * (window - elapsed) / window) + current
+		if used > 0 then
+			reset = window - elapsed
+			if current > 0 then
+				reset = reset + window
+			end
+		end
+	elseif algorithm == 'token_bucket' then
+		local rate = limit / window
+		local data = redis.call('HMGET', key, 'tokens', 'timestamp')
+		local tokens = tonumber(data[1]) or limit
+		local timestamp = tonumber(data[2]) or now
+		if now > timestamp then
+			tokens = math.min(limit, tokens + (now - timestamp) * rate)
+		end
+		used = limit - math.floor(tokens)
+		reset = math.ceil((limit - tokens) / rate)
+	else
+		used = tonumber(redis.call('GET', key)) or 0
+		reset = redis.call('PTTL', key)
+		if reset < 0 then
+			reset = 0
+		end
+	end
+	table.insert(result, {used, reset})
+end
+return result
+`
+
+// quotaUsageBatchSize is the max number of the quotas whose usage are got in one script call
+const quotaUsageBatchSize = 100
+
+// quotaResult is the result of taking a quota of a limiter rule
+type quotaResult struct {
+	allowed   bool
+	remaining int64
+	// wait is the retry after time if the request is not allowed, or the reset time if allowed
+	wait time.Duration
+}
+
+// getRuleKeyPrefix returns the prefix of the redis keys of the rule quotas, the algorithm is a part of the
+// prefix so that the keys of different types are not mixed up when the algorithm of a rule is changed.
+// the rule name is the hash tag of the keys, so that all the keys of a rule are in the same redis cluster slot
+// and can be used in one script.
+func getRuleKeyPrefix(rule *metadata.LimiterRule) string {
+	prefix := common.ApiCacheLimiterRulePrefix + "{" + rule.RuleName + "}"
+	if algorithm := rule.GetAlgorithm(); algorithm != metadata.LimiterFixedWindow {
+		prefix += ":" + algorithm
+	}
+	return prefix
+}
+
+func getRuleQuotaKeysKey(rule *metadata.LimiterRule) string {
+	return getRuleKeyPrefix(rule) + ":quota_keys"
+}
+
+// getRuleQuotaRedisKey returns the redis key of the quota of the rule
+func getRuleQuotaRedisKey(rule *metadata.LimiterRule, quotaKey string) string {
+	key := getRuleKeyPrefix(rule)
+	if quotaKey != "" {
+		key += ":" + quotaKey
+	}
+	return key
+}
+
+// takeQuota consume a request of the quota of the rule, the quota is shared by all the api servers through redis
+func (s *service) takeQuota(ctx context.Context, rule *metadata.LimiterRule, quotaKey string) (*quotaResult,
+	error) {
+
+	script := fixedWindowScript
+	switch rule.GetAlgorithm() {
+	case metadata.LimiterSlidingWindow:
+		script = slidingWindowScript
+	case metadata.LimiterTokenBucket:
+		script = tokenBucketScript
+	}
+
+	keys := []string{getRuleQuotaRedisKey(rule, quotaKey), getRuleQuotaKeysKey(rule)}
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+
+	result, err := s.cache.Eval(ctx, script, keys, rule.Limit, rule.TTL*1000, now, quotaKey).Result()
+	if err != nil {
+		return nil, err
+	}
+	values, ok := result.([]interface{})
+	if !ok || len(values) != 3 {
+		return nil, fmt.Errorf("limiter script returns invalid result %v", result)
+	}
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		if numbers[i], ok = value.(int64); !ok {
+			return nil, fmt.Errorf("limiter script returns invalid result %v", result)
+		}
+	}
+
+	return &quotaResult{
+		allowed:   numbers[0] == 1,
+		remaining: numbers[1],
+		wait:      time.Duration(numbers[2]) * time.Millisecond,
+	}, nil
+}
+
+// getQuotaUsage get the current usage of the quotas of the rule
+func (s *service) getQuotaUsage(ctx context.Context, rule *metadata.LimiterRule) ([]metadata.LimiterQuotaUsage,
+	error) {
+
+	usages := make([]metadata.LimiterQuotaUsage, 0)
+	if rule.DenyAll {
+		return usages, nil
+	}
+
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	ttl := rule.TTL * 1000
+	result, err := s.cache.Eval(ctx, quotaKeysScript, []string{getRuleQuotaKeysKey(rule)}, now-ttl*2).Result()
+	if err != nil {
+		return nil, err
+	}
+	members, ok := result.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("quota keys script returns invalid result %v", result)
+	}
+	quotaKeys := make([]string, len(members))
+	for index, member := range members {
+		if quotaKeys[index], ok = member.(string); !ok {
+			return nil, fmt.Errorf("quota keys script returns invalid quota key %v", member)
+		}
+	}
+
+	for start := 0; start < len(quotaKeys); start += quotaUsageBatchSize {
+		end := start + quotaUsageBatchSize
+		if end > len(quotaKeys) {
+			end = len(quotaKeys)
+		}
+		keys := make([]string, 0, end-start)
+		for _, quotaKey := range quotaKeys[start:end] {
+			keys = append(keys, getRuleQuotaRedisKey(rule, quotaKey))
+		}
+
+		result, err := s.cache.Eval(ctx, quotaUsageScript, keys, rule.GetAlgorithm(), rule.Limit, ttl,
+			now).Result()
+		if err != nil {
+			return nil, err
+		}
+		quotas, ok := result.([]interface{})
+		if !ok || len(quotas) != len(keys) {
+			return nil, fmt.Errorf("quota usage script returns invalid result %v", result)
+		}
+
+		for index, quota := range quotas {
+			values, ok := quota.([]interface{})
+			if !ok || len(values) != 2 {
+				return nil, fmt.Errorf("quota usage script returns invalid quota %v", quota)
+			}
+			used, _ := values[0].(int64)
+			reset, _ := values[1].(int64)
+			if used <= 0 {
+				continue
+			}
+
+			remaining := rule.Limit - used
+			if remaining < 0 {
+				remaining = 0
+			}
+			usages = append(usages, metadata.LimiterQuotaUsage{
+				QuotaKey:     quotaKeys[start+index],
+				Used:         used,
+				Remaining:    remaining,
+				ResetSeconds: ceilSeconds(time.Duration(reset) * time.Millisecond),
+			})
+		}
+	}
+	return usages, nil
+}
+
+// getQuotaKey returns the values of the quota dimensions of the rule for the request
+func getQuotaKey(req *restful.Request, rule *metadata.LimiterRule) string {
+	header := req.Request.Header
+	values := make([]string, 0, len(rule.QuotaBy))
+	for _, dimension := range rule.QuotaBy {
+		value := ""
+		switch dimension {
+		case metadata.LimiterQuotaByAppCode:
+			value = header.Get(common.BKHTTPRequestAppCode)
+		case metadata.LimiterQuotaByUser:
+			value = header.Get(common.BKHTTPHeaderUser)
+		case metadata.LimiterQuotaByIP:
+			value = strings.TrimSpace(header.Get(common.BKHTTPRequestRealIP))
+		case metadata.LimiterQuotaByBiz:
+			value = strconv.FormatInt(getRequestBizID(req.Request), 10)
+		}
+		values = append(values, dimension+"="+value)
+	}
+	return strings.Join(values, ",")
+}
+
+var bizIDPathRegexp = regexp.MustCompile(`/(?:bk_biz_id|biz|app)/([0-9]+)(?:/|$)`)
+
+// getRequestBizID get the business id of the request from the query, url or the json body, returns 0 if the
+// request does not specify a business.
+func getRequestBizID(req *http.Request) int64 {
+	if value := req.URL.Query().Get(common.BKAppIDField); value != "" {
+		if bizID, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return bizID
+		}
+	}
+
+	if match := bizIDPathRegexp.FindStringSubmatch(req.URL.Path); len(match) == 2 {
+		if bizID, err := strconv.ParseInt(match[1], 10, 64); err == nil {
+			return bizID
+		}
+	}
+
+	if req.Method == http.MethodGet || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return 0
+	}
+	body, err := util.PeekRequest(req)
+	if err != nil {
+		return 0
+	}
+	return gjson.GetBytes(body, common.BKAppIDField).Int()
+}
+
+// setRateLimitHeaders set the quota of the rule to the response headers
+func setRateLimitHeaders(resp *restful.Response, rule *metadata.LimiterRule, result *quotaResult) {
+	header := resp.Header()
+	header.Set(rateLimitLimitHeader, strconv.FormatInt(rule.Limit, 10))
+	header.Set(rateLimitRemainingHeader, strconv.FormatInt(result.remaining, 10))
+	header.Set(rateLimitResetHeader, strconv.FormatInt(ceilSeconds(result.wait), 10))
+	if !result.allowed {
+		header.Set(retryAfterHeader, strconv.FormatInt(ceilSeconds(result.wait), 10))
+	}
+}
+
+func ceilSeconds(duration time.Duration) int64 {
+	return int64((duration + time.Second - 1) / time.Second)
+}
+
+// GetLimiterUsage returns the current consumption of the quotas of the limiter rules,
+// the rules can be filtered by the rulenames query parameter which is separated by comma.
+func (s *service) GetLimiterUsage(req *restful.Request, resp *restful.Response) {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+
+	ruleNames := make(map[string]bool)
+	for _, name := range strings.Split(req.QueryParameter("rulenames"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			ruleNames[name] = true
+		}
+	}
+
+	usages := make([]metadata.LimiterRuleUsage, 0)
+	for name, rule := range s.limiter.GetRules() {
+		if len(ruleNames) > 0 && !ruleNames[name] {
+			continue
+		}
+		quotas, err := s.getQuotaUsage(req.Request.Context(), rule)
+		if err != nil {
+			blog.Errorf("get quota usage of limiter rule %s failed, err: %v, rid: %s", name, err, rid)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommRedisOPErr)})
+			return
+		}
+		usages = append(usages, metadata.LimiterRuleUsage{Rule: *rule, Quotas: quotas})
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].Rule.RuleName < usages[j].Rule.RuleName
+	})
+
+	resp.WriteEntity(metadata.NewSuccessResp(usages))
+}
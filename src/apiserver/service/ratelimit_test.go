@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/alicebob/miniredis"
+	"github.com/emicklei/go-restful"
+)
+
+func TestGetQuotaKey(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/api/v3/findmany/module/biz/2", strings.NewReader("{}"))
+	req.Header.Set(common.BKHTTPRequestAppCode, "app")
+	req.Header.Set(common.BKHTTPHeaderUser, "tester")
+	req.Header.Set(common.BKHTTPRequestRealIP, " 127.0.0.1 ")
+
+	cases := []struct {
+		quotaBy []string
+		expect  string
+	}{
+		{nil, ""},
+		{[]string{metadata.LimiterQuotaByUser}, "user=tester"},
+		{[]string{metadata.LimiterQuotaByAppCode, metadata.LimiterQuotaByBiz, metadata.LimiterQuotaByIP},
+			"appcode=app,biz=2,ip=127.0.0.1"},
+	}
+	for _, c := range cases {
+		rule := &metadata.LimiterRule{RuleName: "rule", QuotaBy: c.quotaBy}
+		if got := getQuotaKey(restful.NewRequest(req), rule); got != c.expect {
+			t.Errorf("quota by %v: expect %q, got %q", c.quotaBy, c.expect, got)
+		}
+	}
+}
+
+func TestLimiterScripts(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	cache, err := redis.NewFromConfig(redis.Config{Address: server.Addr(), Database: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &service{cache: cache}
+	ctx := context.Background()
+
+	// the denied requests are counted by the fixed window, but not by the others
+	expectUsed := map[string]int64{
+		metadata.LimiterFixedWindow:   3,
+		metadata.LimiterSlidingWindow: 2,
+		metadata.LimiterTokenBucket:   2,
+	}
+	for algorithm, used := range expectUsed {
+		rule := &metadata.LimiterRule{RuleName: "rule_" + algorithm, Limit: 2, TTL: 60, Algorithm: algorithm}
+
+		for index, allowed := range []bool{true, true, false} {
+			result, err := s.takeQuota(ctx, rule, "user=a")
+			if err != nil {
+				t.Fatalf("%s: take quota failed, err: %v", algorithm, err)
+			}
+			if result.allowed != allowed || result.remaining != int64(1-index) && allowed {
+				t.Fatalf("%s: request %d expect allowed %v, got %+v", algorithm, index, allowed, result)
+			}
+			if !allowed && result.wait <= 0 {
+				t.Fatalf("%s: denied request should have retry after time, got %+v", algorithm, result)
+			}
+		}
+		if result, err := s.takeQuota(ctx, rule, "user=b"); err != nil || !result.allowed {
+			t.Fatalf("%s: quota of another user should not be shared, result: %+v, err: %v", algorithm, result, err)
+		}
+
+		usages, err := s.getQuotaUsage(ctx, rule)
+		if err != nil {
+			t.Fatalf("%s: get quota usage failed, err: %v", algorithm, err)
+		}
+		if len(usages) != 2 || usages[0].QuotaKey != "user=a" || usages[0].Used != used ||
+			usages[0].Remaining != 0 || usages[1].QuotaKey != "user=b" || usages[1].Used != 1 {
+			t.Fatalf("%s: unexpected quota usages %+v", algorithm, usages)
+		}
+	}
+
+	// all the keys of a rule share the hash tag of the rule so that the scripts work with redis cluster
+	for _, key := range server.Keys() {
+		if !strings.Contains(key, "{rule_") {
+			t.Errorf("redis key %s has no hash tag of the rule", key)
+		}
+	}
+}
@@ -13,14 +13,19 @@
 package service
 
 import (
+	"strings"
+
 	"configcenter/src/ac"
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac/authorizer"
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/types"
 	"configcenter/src/storage/dal/redis"
 
 	"github.com/emicklei/go-restful"
@@ -31,6 +36,8 @@ type Service interface {
 	WebServices() []*restful.WebService
 	SetConfig(engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface,
 		clientSet apimachinery.ClientSetInterface, cache redis.Client, limiter *Limiter)
+	SetSystemAppCodes(appCodes []string)
+	SetSystemToken(token string)
 }
 
 // NewService create a new service instance
@@ -46,6 +53,15 @@ type service struct {
 	authorizer ac.AuthorizeInterface
 	cache      redis.Client
 	limiter    *Limiter
+	tenants    tenantCache
+	// systemAppCodes are the app codes of the system apps, they can act for all the tenants
+	systemAppCodes map[string]bool
+	// systemToken is the shared secret of the trusted sources, the app code is only trusted when it matches
+	systemToken string
+	// apiDoc is the OpenAPI document of the routes of the api server itself
+	apiDoc *openapi.Document
+	// openAPI caches the OpenAPI document merged from the scene services
+	openAPI openAPICache
 }
 
 func (s *service) SetConfig(engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface,
@@ -56,7 +72,22 @@ func (s *service) SetConfig(engine *backbone.Engine, httpClient HTTPClient, disc
 	s.clientSet = clientSet
 	s.cache = cache
 	s.limiter = limiter
-	s.authorizer = iam.NewAuthorizer(clientSet)
+	s.authorizer = authorizer.NewAuthorizer(clientSet)
+}
+
+// SetSystemAppCodes set the app codes of the system apps that can use all the supplier accounts
+func (s *service) SetSystemAppCodes(appCodes []string) {
+	s.systemAppCodes = make(map[string]bool)
+	for _, appCode := range appCodes {
+		if appCode = strings.TrimSpace(appCode); len(appCode) > 0 {
+			s.systemAppCodes[appCode] = true
+		}
+	}
+}
+
+// SetSystemToken set the shared secret that the trusted sources send with the system app code
+func (s *service) SetSystemToken(token string) {
+	s.systemToken = strings.TrimSpace(token)
 }
 
 func (s *service) WebServices() []*restful.WebService {
@@ -67,21 +98,35 @@ func (s *service) WebServices() []*restful.WebService {
 	ws := &restful.WebService{}
 	ws.Path(rootPath)
 	ws.Filter(s.engine.Metric().RestfulMiddleWare)
+	ws.Filter(s.APITokenFilter(getErrFun))
 	ws.Filter(rdapi.AllGlobalFilter(getErrFun))
+	ws.Filter(s.TenantFilter(getErrFun))
 	ws.Filter(rdapi.RequestLogFilter())
 	ws.Filter(s.LimiterFilter())
 	ws.Produces(restful.MIME_JSON)
 	if auth.EnableAuthorize() {
 		ws.Filter(s.authFilter(getErrFun))
 	}
-	ws.Route(ws.POST("/auth/verify").To(s.AuthVerify))
-	ws.Route(ws.GET("/auth/business_list").To(s.GetAnyAuthorizedAppList))
-	ws.Route(ws.POST("/auth/skip_url").To(s.GetUserNoAuthSkipURL))
+	ws.Route(ws.POST("/auth/verify").To(s.AuthVerify).
+		Reads(metadata.AuthBathVerifyRequest{}).Writes([]metadata.AuthBathVerifyResult{}))
+	ws.Route(ws.GET("/auth/business_list").To(s.GetAnyAuthorizedAppList).Writes(metadata.InstResult{}))
+	ws.Route(ws.POST("/auth/skip_url").To(s.GetUserNoAuthSkipURL).Reads(metadata.IamPermission{}).Writes(""))
+	ws.Route(ws.POST("/create/api_token").To(s.CreateAPIToken).
+		Reads(metadata.CreateAPITokenOption{}).Writes(metadata.CreatedAPIToken{}))
+	ws.Route(ws.POST("/findmany/api_token").To(s.ListAPITokens).
+		Reads(metadata.ListAPITokensOption{}).Writes(metadata.MultipleAPIToken{}))
+	ws.Route(ws.PUT("/update/api_token/revoke").To(s.RevokeAPITokens).Reads(metadata.RevokeAPITokensOption{}))
+	ws.Route(ws.GET("/limiter/usage").To(s.GetLimiterUsage).Writes([]metadata.LimiterRuleUsage{}).
+		Param(ws.QueryParameter("rulenames", "the limiter rule names separated by comma")))
+	ws.Route(ws.GET(openapi.Path).To(s.GetOpenAPI))
 	ws.Route(ws.GET("{.*}").Filter(s.URLFilterChan).To(s.Get))
 	ws.Route(ws.POST("{.*}").Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.URLFilterChan).To(s.Put))
 	ws.Route(ws.DELETE("{.*}").Filter(s.URLFilterChan).To(s.Delete))
 
+	s.apiDoc = openapi.Build([]*restful.WebService{ws}, openapi.BuildOption{Title: "cmdb api server",
+		Tag: types.CC_MODULE_APISERVER})
+
 	allWebServices := make([]*restful.WebService, 0)
 	allWebServices = append(allWebServices, ws)
 	allWebServices = append(allWebServices, s.RootWebService())
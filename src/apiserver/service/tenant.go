@@ -0,0 +1,164 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// tenantCacheTTL is the time that a tenant's status is cached, a disabled tenant is rejected after at most this time
+const tenantCacheTTL = 30 * time.Second
+
+type tenantCacheItem struct {
+	status metadata.TenantStatus
+	exists bool
+	// users is the users bound to the tenant
+	users    map[string]bool
+	expireAt time.Time
+}
+
+// tenantCache caches the status of the tenants so that every request does not need to search the tenant
+type tenantCache struct {
+	lock   sync.RWMutex
+	values map[string]tenantCacheItem
+}
+
+func (c *tenantCache) get(supplierAccount string) (tenantCacheItem, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	item, ok := c.values[supplierAccount]
+	if !ok || time.Now().After(item.expireAt) {
+		return tenantCacheItem{}, false
+	}
+	return item, true
+}
+
+func (c *tenantCache) set(supplierAccount string, item tenantCacheItem) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.values == nil {
+		c.values = make(map[string]tenantCacheItem)
+	}
+	item.expireAt = time.Now().Add(tenantCacheTTL)
+	c.values[supplierAccount] = item
+}
+
+// TenantFilter rejects the requests of the tenants that are not exist or disabled, and the requests of the users
+// that are not bound to the tenant. the default supplier account is always allowed, so that the system still
+// works before any tenant is created. the super supplier account can read all the tenants' data, and the system
+// apps can act for all the tenants, so only the system apps can use the super supplier account and the tenants
+// that they are not bound to.
+func (s *service) TenantFilter(errFunc func() errors.CCErrorIf) func(req *restful.Request,
+	resp *restful.Response, fchain *restful.FilterChain) {
+
+	return func(req *restful.Request, resp *restful.Response, fchain *restful.FilterChain) {
+		isSystem := s.isSystemRequest(req.Request.Header)
+		// the system token is only used to verify the source of the request, it is not sent to the scene servers
+		req.Request.Header.Del(common.BKHTTPSystemToken)
+
+		supplierAccount := util.GetOwnerID(req.Request.Header)
+		if supplierAccount == "" || supplierAccount == common.BKDefaultOwnerID {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		rid := util.GetHTTPCCRequestID(req.Request.Header)
+		defErr := errFunc().CreateDefaultCCErrorIf(util.GetLanguage(req.Request.Header))
+		user := util.GetUser(req.Request.Header)
+
+		if supplierAccount == common.BKSuperOwnerID {
+			if !isSystem {
+				blog.Errorf("user %s of app %s can not use the super supplier account, rid: %s", user,
+					req.Request.Header.Get(common.BKHTTPRequestAppCode), rid)
+				writeFilterError(resp, http.StatusForbidden,
+					defErr.CCErrorf(common.CCErrTenantNotBound, user, supplierAccount))
+				return
+			}
+			fchain.ProcessFilter(req, resp)
+			return
+		}
+
+		item, ok := s.tenants.get(supplierAccount)
+		if !ok {
+			header := util.BuildHeader(common.CCSystemOperatorUserName, common.BKSuperOwnerID)
+			header.Set(common.BKHTTPCCRequestID, rid)
+			option := &metadata.ListTenantsOption{SupplierAccounts: []string{supplierAccount}}
+			result, err := s.clientSet.CoreService().System().SearchTenants(req.Request.Context(), header, option)
+			if err != nil {
+				blog.Errorf("search tenant %s failed, err: %v, rid: %s", supplierAccount, err, rid)
+				writeFilterError(resp, http.StatusInternalServerError, err)
+				return
+			}
+
+			item = tenantCacheItem{users: make(map[string]bool)}
+			if len(result.Info) > 0 {
+				item.exists = true
+				item.status = result.Info[0].Status
+				for _, tenantUser := range result.Info[0].Users {
+					item.users[tenantUser] = true
+				}
+			}
+			s.tenants.set(supplierAccount, item)
+		}
+
+		if !item.exists {
+			blog.Errorf("tenant %s does not exist, rid: %s", supplierAccount, rid)
+			writeFilterError(resp, http.StatusForbidden, defErr.CCErrorf(common.CCErrTenantNotExist, supplierAccount))
+			return
+		}
+
+		if item.status != metadata.TenantEnabled {
+			blog.Errorf("tenant %s is disabled, rid: %s", supplierAccount, rid)
+			writeFilterError(resp, http.StatusForbidden, defErr.CCErrorf(common.CCErrTenantDisabled, supplierAccount))
+			return
+		}
+
+		if !isSystem && !item.users[user] {
+			blog.Errorf("user %s of app %s is not bound to tenant %s, rid: %s", user,
+				req.Request.Header.Get(common.BKHTTPRequestAppCode), supplierAccount, rid)
+			writeFilterError(resp, http.StatusForbidden,
+				defErr.CCErrorf(common.CCErrTenantNotBound, user, supplierAccount))
+			return
+		}
+
+		fchain.ProcessFilter(req, resp)
+	}
+}
+
+// isSystemRequest returns if the request is made by a system app. the app code can be set by any caller, so it
+// is only trusted when the request carries the system token, which is only known by the trusted sources like the
+// api gateway and the web server. no request is a system one if the system token is not configured.
+func (s *service) isSystemRequest(header http.Header) bool {
+	if len(s.systemToken) == 0 {
+		return false
+	}
+
+	token := header.Get(common.BKHTTPSystemToken)
+	if subtle.ConstantTimeCompare([]byte(token), []byte(s.systemToken)) != 1 {
+		return false
+	}
+
+	appCode := header.Get(common.BKHTTPRequestAppCode)
+	return len(appCode) > 0 && s.systemAppCodes[appCode]
+}
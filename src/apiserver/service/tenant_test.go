@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+func TestTenantFilter(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../resources/errors/")
+	if err != nil {
+		t.Fatalf("new error factory failed, err: %v", err)
+	}
+
+	s := new(service)
+	s.SetSystemAppCodes([]string{"cc", " bk_sys "})
+	s.SetSystemToken("secret")
+	s.tenants.set("tenant_a", tenantCacheItem{exists: true, status: metadata.TenantEnabled,
+		users: map[string]bool{"alice": true}})
+	s.tenants.set("tenant_b", tenantCacheItem{exists: true, status: metadata.TenantEnabled,
+		users: map[string]bool{"bob": true}})
+	s.tenants.set("tenant_c", tenantCacheItem{exists: true, status: metadata.TenantDisabled,
+		users: map[string]bool{"alice": true}})
+	s.tenants.set("tenant_d", tenantCacheItem{exists: false})
+	filter := s.TenantFilter(func() errors.CCErrorIf { return errFactory })
+
+	cases := []struct {
+		name    string
+		user    string
+		account string
+		appCode string
+		token   string
+		code    int
+	}{
+		{"bound user", "alice", "tenant_a", "", "", 0},
+		{"other tenant", "alice", "tenant_b", "", "", common.CCErrTenantNotBound},
+		{"other tenant of an unknown app", "alice", "tenant_b", "bk_other", "secret", common.CCErrTenantNotBound},
+		{"super supplier account", "alice", common.BKSuperOwnerID, "", "", common.CCErrTenantNotBound},
+		{"super supplier account of an api token", "alice", common.BKSuperOwnerID,
+			metadata.APITokenAppCodePrefix + "1", "", common.CCErrTenantNotBound},
+		{"default supplier account", "bob", common.BKDefaultOwnerID, "", "", 0},
+		{"disabled tenant", "alice", "tenant_c", "", "", common.CCErrTenantDisabled},
+		{"not exist tenant", "alice", "tenant_d", "", "", common.CCErrTenantNotExist},
+		{"system app with other tenant", "alice", "tenant_b", "cc", "secret", 0},
+		{"system app with super supplier account", "alice", common.BKSuperOwnerID, "bk_sys", "secret", 0},
+		{"system app with disabled tenant", "alice", "tenant_c", "cc", "secret", common.CCErrTenantDisabled},
+		{"system app without token", "alice", common.BKSuperOwnerID, "cc", "", common.CCErrTenantNotBound},
+		{"system app with wrong token", "alice", "tenant_b", "cc", "guess", common.CCErrTenantNotBound},
+	}
+
+	for _, c := range cases {
+		httpReq := httptest.NewRequest(http.MethodPost, "/api/v3/hosts/search", nil)
+		for key, values := range util.BuildHeader(c.user, c.account) {
+			httpReq.Header[key] = values
+		}
+		if c.appCode != "" {
+			httpReq.Header.Set(common.BKHTTPRequestAppCode, c.appCode)
+		}
+		if c.token != "" {
+			httpReq.Header.Set(common.BKHTTPSystemToken, c.token)
+		}
+		recorder := httptest.NewRecorder()
+		processed := false
+		chain := &restful.FilterChain{
+			Filters: []restful.FilterFunction{filter},
+			Target:  func(*restful.Request, *restful.Response) { processed = true },
+		}
+		chain.ProcessFilter(restful.NewRequest(httpReq), restful.NewResponse(recorder))
+
+		if c.code == 0 {
+			if !processed {
+				t.Errorf("%s: the request should be allowed, response: %s", c.name, recorder.Body.String())
+			}
+			continue
+		}
+		if processed || recorder.Code != http.StatusForbidden {
+			t.Errorf("%s: the request should be rejected, status: %d", c.name, recorder.Code)
+			continue
+		}
+		rsp := new(metadata.BaseResp)
+		if err := json.Unmarshal(recorder.Body.Bytes(), rsp); err != nil || rsp.Code != c.code {
+			t.Errorf("%s: expect error code %d, got %s, err: %v", c.name, c.code, recorder.Body.String(), err)
+		}
+	}
+}
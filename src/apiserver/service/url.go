@@ -85,6 +85,9 @@ func (u *URLPath) WithTopo(req *restful.Request) (isHit bool) {
 	case strings.HasPrefix(string(*u), rootPath+"/identifier/"):
 		from, to, isHit = rootPath, topoRoot, true
 
+	case strings.HasPrefix(string(*u), rootPath+"/graphql"):
+		from, to, isHit = rootPath, topoRoot, true
+
 	case strings.HasPrefix(string(*u), rootPath+"/inst/"):
 		from, to, isHit = rootPath, topoRoot, true
 
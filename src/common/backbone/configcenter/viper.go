@@ -370,6 +370,9 @@ func Mongo(prefix string) (mongo.Config, error) {
 		c.MaxIdleConns = parser.getUint64(prefix + ".maxIdleConns")
 	}
 
+	c.SlowQueryThresholdMs = int64(parser.getInt(prefix + ".slowQuery.thresholdMs"))
+	c.SlowQueryLogSizeMB = int64(parser.getInt(prefix + ".slowQuery.logSizeMB"))
+
 	if !parser.isSet(prefix + ".socketTimeoutSeconds") {
 		blog.Errorf("can not find mongo.socketTimeoutSeconds config, use default value: %d", mongo.DefaultSocketTimeout)
 		c.SocketTimeout = mongo.DefaultSocketTimeout
@@ -0,0 +1,444 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calculator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+// ExpressionMaxLength is the max length of a calculated attribute's expression.
+const ExpressionMaxLength = 512
+
+// ErrVariableNotSet means a variable used by the expression has no value, the calculated
+// value should be cleared in this case.
+var ErrVariableNotSet = errors.New("expression variable not set")
+
+// Expression is a parsed arithmetic expression over the attributes of an instance, such as
+// "bk_cpu * bk_cpu_mhz / 1000". supported elements:
+// 1. number literals and attribute ids as variables.
+// 2. operators: + - * / % and parentheses.
+// 3. functions: abs(x), round(x), ceil(x), floor(x), min(x, y...), max(x, y...).
+type Expression struct {
+	raw  string
+	root node
+}
+
+// Parse parse the expression, returns error if the expression is invalid.
+func Parse(expr string) (*Expression, error) {
+	if len(expr) == 0 {
+		return nil, errors.New("expression is empty")
+	}
+
+	if len(expr) > ExpressionMaxLength {
+		return nil, fmt.Errorf("expression length exceeds max length %d", ExpressionMaxLength)
+	}
+
+	tokens, err := tokenize(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %s at position %d", p.tokens[p.pos].val, p.tokens[p.pos].pos)
+	}
+
+	return &Expression{raw: expr, root: root}, nil
+}
+
+// String returns the raw expression.
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// Variables returns the sorted unique variables used by this expression.
+func (e *Expression) Variables() []string {
+	vars := make(map[string]struct{})
+	e.root.variables(vars)
+
+	result := make([]string, 0, len(vars))
+	for v := range vars {
+		result = append(result, v)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Evaluate calculate the expression with the values of the variables.
+// returns ErrVariableNotSet if one of the variable's value is not set or is nil.
+func (e *Expression) Evaluate(values map[string]interface{}) (float64, error) {
+	result, err := e.root.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, fmt.Errorf("expression %s result is not a finite number", e.raw)
+	}
+	return result, nil
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenIdent
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	runes := []rune(expr)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenNumber, val: string(runes[start:i]), pos: start})
+		case r == '_' || (r < unicode.MaxASCII && unicode.IsLetter(r)):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || (runes[i] < unicode.MaxASCII &&
+				(unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])))) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, val: string(runes[start:i]), pos: start})
+		case r == '+' || r == '-' || r == '*' || r == '/' || r == '%':
+			tokens = append(tokens, token{kind: tokenOperator, val: string(r), pos: i})
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLeftParen, val: string(r), pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRightParen, val: string(r), pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, val: string(r), pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("invalid character %q at position %d", r, i)
+		}
+	}
+	return tokens, nil
+}
+
+// parser is a recursive descent parser with the following grammar:
+// expr   := term (('+' | '-') term)*
+// term   := unary (('*' | '/' | '%') unary)*
+// unary  := '-' unary | '+' unary | primary
+// primary:= number | ident | ident '(' expr (',' expr)* ')' | '(' expr ')'
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() *token {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t == nil || t.kind != tokenOperator || (t.val != "+" && t.val != "-") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.val, left: left, right: right}
+	}
+}
+
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		if t == nil || t.kind != tokenOperator || (t.val != "*" && t.val != "/" && t.val != "%") {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: t.val, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	t := p.peek()
+	if t != nil && t.kind == tokenOperator && (t.val == "-" || t.val == "+") {
+		p.pos++
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if t.val == "+" {
+			return operand, nil
+		}
+		return &negativeNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+	if t == nil {
+		return nil, errors.New("unexpected end of expression")
+	}
+	p.pos++
+
+	switch t.kind {
+	case tokenNumber:
+		val, err := strconv.ParseFloat(t.val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %s at position %d", t.val, t.pos)
+		}
+		return numberNode(val), nil
+	case tokenIdent:
+		next := p.peek()
+		if next == nil || next.kind != tokenLeftParen {
+			return variableNode(t.val), nil
+		}
+		return p.parseFunc(t)
+	case tokenLeftParen:
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		next := p.peek()
+		if next == nil || next.kind != tokenRightParen {
+			return nil, fmt.Errorf("missing ')' for '(' at position %d", t.pos)
+		}
+		p.pos++
+		return inner, nil
+	default:
+		return nil, fmt.Errorf("unexpected token %s at position %d", t.val, t.pos)
+	}
+}
+
+func (p *parser) parseFunc(name *token) (node, error) {
+	fn, exists := functions[name.val]
+	if !exists {
+		return nil, fmt.Errorf("unsupported function %s at position %d", name.val, name.pos)
+	}
+	// skip the left parenthesis
+	p.pos++
+
+	args := make([]node, 0)
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		next := p.peek()
+		if next == nil {
+			return nil, fmt.Errorf("missing ')' for function %s at position %d", name.val, name.pos)
+		}
+		p.pos++
+		if next.kind == tokenRightParen {
+			break
+		}
+		if next.kind != tokenComma {
+			return nil, fmt.Errorf("unexpected token %s at position %d", next.val, next.pos)
+		}
+	}
+
+	if len(args) < fn.minArgs || (fn.maxArgs > 0 && len(args) > fn.maxArgs) {
+		return nil, fmt.Errorf("function %s got invalid number of arguments %d", name.val, len(args))
+	}
+	return &funcNode{name: name.val, fn: fn.call, args: args}, nil
+}
+
+type function struct {
+	minArgs int
+	// maxArgs is the max number of arguments, 0 means no limit.
+	maxArgs int
+	call    func(args []float64) float64
+}
+
+var functions = map[string]function{
+	"abs":   {minArgs: 1, maxArgs: 1, call: func(args []float64) float64 { return math.Abs(args[0]) }},
+	"round": {minArgs: 1, maxArgs: 1, call: func(args []float64) float64 { return math.Round(args[0]) }},
+	"ceil":  {minArgs: 1, maxArgs: 1, call: func(args []float64) float64 { return math.Ceil(args[0]) }},
+	"floor": {minArgs: 1, maxArgs: 1, call: func(args []float64) float64 { return math.Floor(args[0]) }},
+	"min": {minArgs: 1, call: func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result
+	}},
+	"max": {minArgs: 1, call: func(args []float64) float64 {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result
+	}},
+}
+
+type node interface {
+	eval(values map[string]interface{}) (float64, error)
+	variables(vars map[string]struct{})
+}
+
+type numberNode float64
+
+func (n numberNode) eval(map[string]interface{}) (float64, error) {
+	return float64(n), nil
+}
+
+func (n numberNode) variables(map[string]struct{}) {}
+
+type variableNode string
+
+func (n variableNode) eval(values map[string]interface{}) (float64, error) {
+	val, exists := values[string(n)]
+	if !exists || val == nil {
+		return 0, ErrVariableNotSet
+	}
+
+	num, err := ToFloat64(val)
+	if err != nil {
+		return 0, fmt.Errorf("variable %s is not a number, err: %v", string(n), err)
+	}
+	return num, nil
+}
+
+func (n variableNode) variables(vars map[string]struct{}) {
+	vars[string(n)] = struct{}{}
+}
+
+type negativeNode struct {
+	operand node
+}
+
+func (n *negativeNode) eval(values map[string]interface{}) (float64, error) {
+	val, err := n.operand.eval(values)
+	if err != nil {
+		return 0, err
+	}
+	return -val, nil
+}
+
+func (n *negativeNode) variables(vars map[string]struct{}) {
+	n.operand.variables(vars)
+}
+
+type binaryNode struct {
+	op    string
+	left  node
+	right node
+}
+
+func (n *binaryNode) eval(values map[string]interface{}) (float64, error) {
+	left, err := n.left.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	right, err := n.right.eval(values)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return 0, errors.New("division by zero")
+		}
+		return math.Mod(left, right), nil
+	default:
+		return 0, fmt.Errorf("unsupported operator %s", n.op)
+	}
+}
+
+func (n *binaryNode) variables(vars map[string]struct{}) {
+	n.left.variables(vars)
+	n.right.variables(vars)
+}
+
+type funcNode struct {
+	name string
+	fn   func(args []float64) float64
+	args []node
+}
+
+func (n *funcNode) eval(values map[string]interface{}) (float64, error) {
+	args := make([]float64, len(n.args))
+	for idx, arg := range n.args {
+		val, err := arg.eval(values)
+		if err != nil {
+			return 0, err
+		}
+		args[idx] = val
+	}
+	return n.fn(args), nil
+}
+
+func (n *funcNode) variables(vars map[string]struct{}) {
+	for _, arg := range n.args {
+		arg.variables(vars)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calculator_test
+
+import (
+	"testing"
+
+	"configcenter/src/common/calculator"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpressionEvaluate(t *testing.T) {
+	values := map[string]interface{}{
+		"bk_cpu":     int64(8),
+		"bk_cpu_mhz": 2400,
+		"bk_mem":     "16384",
+		"ratio":      0.5,
+	}
+
+	tests := []struct {
+		expr   string
+		result float64
+	}{
+		{expr: "1 + 2 * 3", result: 7},
+		{expr: "(1 + 2) * 3", result: 9},
+		{expr: "-bk_cpu + 10", result: 2},
+		{expr: "bk_cpu * bk_cpu_mhz / 1000", result: 19.2},
+		{expr: "bk_mem / 1024 * ratio", result: 8},
+		{expr: "7 % 4", result: 3},
+		{expr: "max(bk_cpu, 16, 4) - min(1, 2)", result: 15},
+		{expr: "round(2.5) + abs(-1) + floor(1.7) + ceil(1.2)", result: 7},
+	}
+
+	for _, tt := range tests {
+		expr, err := calculator.Parse(tt.expr)
+		require.NoError(t, err, tt.expr)
+		result, err := expr.Evaluate(values)
+		require.NoError(t, err, tt.expr)
+		assert.InDelta(t, tt.result, result, 1e-9, tt.expr)
+	}
+}
+
+func TestExpressionVariables(t *testing.T) {
+	expr, err := calculator.Parse("bk_mem * 2 + max(bk_cpu, bk_mem)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bk_cpu", "bk_mem"}, expr.Variables())
+
+	_, err = expr.Evaluate(map[string]interface{}{"bk_mem": 1, "bk_cpu": nil})
+	assert.Equal(t, calculator.ErrVariableNotSet, err)
+}
+
+func TestExpressionInvalid(t *testing.T) {
+	for _, expr := range []string{"", "1 +", "(1 + 2", "1 + 2)", "foo(1)", "max()", "a $ b", "1..2", "a b"} {
+		_, err := calculator.Parse(expr)
+		assert.Error(t, err, expr)
+	}
+
+	expr, err := calculator.Parse("a / b")
+	require.NoError(t, err)
+	_, err = expr.Evaluate(map[string]interface{}{"a": 1, "b": 0})
+	assert.Error(t, err)
+}
+
+func TestOption(t *testing.T) {
+	opt, err := calculator.ParseOption(map[string]interface{}{
+		"type":        "aggregation",
+		"aggregation": map[string]interface{}{"bk_asst_obj_id": "host", "func": "sum", "field": "bk_cpu"},
+	})
+	require.NoError(t, err)
+	_, err = opt.Validate()
+	require.NoError(t, err)
+
+	result, err := opt.Aggregation.Aggregate([]interface{}{1, int64(2), nil, 3.5})
+	require.NoError(t, err)
+	assert.Equal(t, 6.5, result)
+
+	opt.Aggregation.Func = calculator.FunctionAvg
+	result, err = opt.Aggregation.Aggregate(nil)
+	require.NoError(t, err)
+	assert.Nil(t, result)
+
+	opt.Aggregation.Field = ""
+	_, err = opt.Validate()
+	assert.Error(t, err)
+
+	opt, err = calculator.ParseOption(`{"type": "expression", "expression": "a + b"}`)
+	require.NoError(t, err)
+	expr, err := opt.Validate()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, expr.Variables())
+}
@@ -0,0 +1,226 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package calculator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Type is the way how a calculated attribute's value is computed.
+type Type string
+
+const (
+	// Expression the value is computed with an expression over the other attributes of the same instance.
+	TypeExpression Type = "expression"
+	// Aggregation the value is computed with an aggregation over the associated instances.
+	TypeAggregation Type = "aggregation"
+)
+
+// Function is the aggregate function of an aggregation calculated attribute.
+type Function string
+
+const (
+	FunctionCount Function = "count"
+	FunctionSum   Function = "sum"
+	FunctionAvg   Function = "avg"
+	FunctionMin   Function = "min"
+	FunctionMax   Function = "max"
+)
+
+// Option is the option of a calculated attribute, it is saved in the attribute's option field.
+// e.g.
+// {"type": "expression", "expression": "bk_cpu * bk_cpu_mhz"}
+// {"type": "aggregation", "aggregation": {"bk_asst_obj_id": "host", "func": "sum", "field": "bk_cpu"}}
+type Option struct {
+	Type        Type         `json:"type" bson:"type"`
+	Expression  string       `json:"expression,omitempty" bson:"expression,omitempty"`
+	Aggregation *Aggregation `json:"aggregation,omitempty" bson:"aggregation,omitempty"`
+}
+
+// Aggregation defines an aggregation over the instances of the associated model.
+type Aggregation struct {
+	// AsstObjID is the associated model whose instances will be aggregated.
+	AsstObjID string `json:"bk_asst_obj_id" bson:"bk_asst_obj_id"`
+	// Func is the aggregate function.
+	Func Function `json:"func" bson:"func"`
+	// Field is the numeric attribute of the associated model to aggregate, not needed by count.
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+}
+
+// ParseOption parse the calculated attribute option, the option can be a json string,
+// a map or a bson document read from db.
+func ParseOption(option interface{}) (*Option, error) {
+	if option == nil {
+		return nil, errors.New("calculated attribute option is not set")
+	}
+
+	var raw []byte
+	var err error
+	switch opt := option.(type) {
+	case string:
+		raw = []byte(opt)
+	case []byte:
+		raw = opt
+	case bson.D:
+		raw, err = json.Marshal(convertBsonD(opt))
+	default:
+		raw, err = json.Marshal(opt)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("marshal calculated attribute option failed, err: %v", err)
+	}
+
+	result := new(Option)
+	if err := json.Unmarshal(raw, result); err != nil {
+		return nil, fmt.Errorf("unmarshal calculated attribute option failed, err: %v", err)
+	}
+	return result, nil
+}
+
+func convertBsonD(doc bson.D) map[string]interface{} {
+	result := doc.Map()
+	for key, val := range result {
+		if sub, ok := val.(bson.D); ok {
+			result[key] = convertBsonD(sub)
+		}
+	}
+	return result
+}
+
+// Validate validate the option, returns the parsed expression if the option is an expression.
+func (o *Option) Validate() (*Expression, error) {
+	switch o.Type {
+	case TypeExpression:
+		if o.Aggregation != nil {
+			return nil, errors.New("aggregation can not be set for expression type")
+		}
+		return Parse(o.Expression)
+
+	case TypeAggregation:
+		if len(o.Expression) != 0 {
+			return nil, errors.New("expression can not be set for aggregation type")
+		}
+		if o.Aggregation == nil {
+			return nil, errors.New("aggregation is not set")
+		}
+		if len(o.Aggregation.AsstObjID) == 0 {
+			return nil, errors.New("aggregation bk_asst_obj_id is not set")
+		}
+
+		switch o.Aggregation.Func {
+		case FunctionCount:
+		case FunctionSum, FunctionAvg, FunctionMin, FunctionMax:
+			if len(o.Aggregation.Field) == 0 {
+				return nil, fmt.Errorf("aggregation field is not set for func %s", o.Aggregation.Func)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported aggregation func %s", o.Aggregation.Func)
+		}
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported calculated attribute type %s", o.Type)
+	}
+}
+
+// Aggregate calculate the aggregation result with the associated instances' field values.
+// nil values are ignored, returns nil if there is no value to calculate avg, min or max.
+func (a *Aggregation) Aggregate(values []interface{}) (interface{}, error) {
+	if a.Func == FunctionCount {
+		return int64(len(values)), nil
+	}
+
+	nums := make([]float64, 0, len(values))
+	for _, val := range values {
+		if val == nil {
+			continue
+		}
+		num, err := ToFloat64(val)
+		if err != nil {
+			return nil, fmt.Errorf("aggregation field %s value is not a number, err: %v", a.Field, err)
+		}
+		nums = append(nums, num)
+	}
+
+	switch a.Func {
+	case FunctionSum:
+		var sum float64
+		for _, num := range nums {
+			sum += num
+		}
+		return sum, nil
+	case FunctionAvg:
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		var sum float64
+		for _, num := range nums {
+			sum += num
+		}
+		return sum / float64(len(nums)), nil
+	case FunctionMin, FunctionMax:
+		if len(nums) == 0 {
+			return nil, nil
+		}
+		result := nums[0]
+		for _, num := range nums[1:] {
+			if (a.Func == FunctionMin && num < result) || (a.Func == FunctionMax && num > result) {
+				result = num
+			}
+		}
+		return result, nil
+	default:
+		return nil, fmt.Errorf("unsupported aggregation func %s", a.Func)
+	}
+}
+
+// ToFloat64 convert a numeric value to float64, numeric strings are also accepted.
+func ToFloat64(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), nil
+	case int8:
+		return float64(v), nil
+	case int16:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint:
+		return float64(v), nil
+	case uint8:
+		return float64(v), nil
+	case uint16:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case json.Number:
+		return v.Float64()
+	case string:
+		return strconv.ParseFloat(v, 64)
+	default:
+		return 0, fmt.Errorf("unsupported value type %T", val)
+	}
+}
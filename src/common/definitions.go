@@ -272,6 +272,15 @@ const (
 	// BKHostNameField the host name field
 	BKHostNameField = "bk_host_name"
 
+	// BKHostLockUserField the user who locks the host
+	BKHostLockUserField = "bk_user"
+
+	// BKHostLockScopesField the operations that are blocked by the host lock
+	BKHostLockScopesField = "scopes"
+
+	// BKHostLockExpireTimeField the time that the host lock expires
+	BKHostLockExpireTimeField = "expire_time"
+
 	// BKAppNameField the app name field
 	BKAppNameField = "bk_biz_name"
 
@@ -660,6 +669,9 @@ const (
 
 	// LastTimeField the last time field
 	LastTimeField = "last_time"
+
+	// BKRevisionField the revision of an instance, which is increased on every update of it
+	BKRevisionField = "bk_revision"
 )
 
 const (
@@ -761,6 +773,12 @@ const (
 	// FieldTypeOrganization the organization field type
 	FieldTypeOrganization string = "organization"
 
+	// FieldTypeCalculated the calculated field type, its value is computed by the server
+	FieldTypeCalculated string = "calculated"
+
+	// FieldTypeInstRef the instance reference field type, its value is the id(s) of another model's instance(s)
+	FieldTypeInstRef string = "inst_ref"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -788,6 +806,14 @@ const (
 	FieldTypeLongCharRegexp string = `\S`
 )
 
+const (
+	// InstRefOnDeleteRestrict the referenced instance can not be deleted while it is still referenced
+	InstRefOnDeleteRestrict = "restrict"
+
+	// InstRefOnDeleteCascade the referencing instances are deleted along with the referenced instance
+	InstRefOnDeleteCascade = "cascade"
+)
+
 const (
 	// HostAddMethodExcel add a host method
 	HostAddMethodExcel = "1"
@@ -931,11 +957,24 @@ const (
 	// BKHTTPOtherRequestID esb request id  X-Bkapi-Request-Id
 	BKHTTPOtherRequestID = "X-Bkapi-Request-Id"
 
+	// BKHTTPIfMatch the expected revision of the instance to be updated
+	BKHTTPIfMatch = "If-Match"
+
+	// BKHTTPSystemToken the shared secret that the trusted sources like the web server and the api gateway send to
+	// the api server, only the app code of a request with it is trusted to be a system app
+	BKHTTPSystemToken = "Bk-System-Token"
+
+	// BKHTTPCallerModule the name of the cmdb module that sends the request to another cmdb module
+	BKHTTPCallerModule = "Bk-Caller-Module"
+
 	BKHTTPSecretsToken   = "BK-Secrets-Token"
 	BKHTTPSecretsProject = "BK-Secrets-Project"
 	BKHTTPSecretsEnv     = "BK-Secrets-Env"
 	// BKHTTPReadReference  query db use secondary node
 	BKHTTPReadReference = "Cc_Read_Preference"
+	// BKHTTPReadAfterWrite marks that the request follows a write and must read its own writes, so that
+	// the db reads of it are always sent to the primary node regardless of the read preference.
+	BKHTTPReadAfterWrite = "Cc_Read_After_Write"
 )
 
 type ReadPreferenceMode string
@@ -1055,6 +1094,8 @@ const (
 	BKBluekingLoginPluginVersion   = "blueking"
 	BKOpenSourceLoginPluginVersion = "opensource"
 	BKSkipLoginPluginVersion       = "skip-login"
+	BKLDAPLoginPluginVersion       = "ldap"
+	BKOIDCLoginPluginVersion       = "oidc"
 
 	// monitor plugin type
 	BKNoopMonitorPlugin     = "noop"
@@ -1184,9 +1225,11 @@ const (
 )
 
 const (
-	ContextRequestIDField    = "request_id"
-	ContextRequestUserField  = "request_user"
-	ContextRequestOwnerField = "request_owner"
+	ContextRequestIDField      = "request_id"
+	ContextRequestUserField    = "request_user"
+	ContextRequestOwnerField   = "request_owner"
+	ContextRequestCallerField  = "request_caller"
+	ContextRequestAppCodeField = "request_app_code"
 )
 
 const (
@@ -1208,6 +1251,10 @@ const (
 	OptionOther          = "其他"
 	TimerPattern         = "^[\\d]+\\:[\\d]+$"
 	SyncSetTaskName      = "sync-settemplate2set"
+	// SyncServiceTemplateRolloutTaskName the task that syncs service instances with the service template wave by wave
+	SyncServiceTemplateRolloutTaskName = "sync-servicetemplate-rollout"
+	// RecalculateModelInstanceTaskName the task that recalculates the calculated attributes of the model instances
+	RecalculateModelInstanceTaskName = "recalculate-model-instance"
 
 	BKHostState = "bk_state"
 )
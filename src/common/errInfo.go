@@ -229,6 +229,27 @@ const (
 	//CCIllegalRegularExpression the regular expression's type assertion failed
 	CCIllegalRegularExpression = 1199090
 
+	// CCErrAPITokenInvalid api token is invalid, expired or revoked
+	CCErrAPITokenInvalid = 1199091
+
+	// CCErrAPITokenOutOfScope api token is not allowed to access the resource, %s
+	CCErrAPITokenOutOfScope = 1199092
+
+	// CCErrTenantNotExist tenant %s does not exist
+	CCErrTenantNotExist = 1199093
+
+	// CCErrTenantDisabled tenant %s has been disabled
+	CCErrTenantDisabled = 1199094
+
+	// CCErrTenantDeleteEnabled tenant %s must be disabled before it is deleted
+	CCErrTenantDeleteEnabled = 1199095
+
+	// CCErrCommRevisionConflict the revision of the data is %d, not the expected %d, it has been changed by others
+	CCErrCommRevisionConflict = 1199096
+
+	// CCErrTenantNotBound user %s is not bound to tenant %s
+	CCErrTenantNotBound = 1199097
+
 	// too many requests
 	CCErrTooManyRequestErr = 1199997
 
@@ -623,6 +644,17 @@ const (
 
 	CCErrSyncServiceInstanceByTemplateFailed = 1108045
 
+	// CCErrProcServiceTemplateVersionNotExist 服务模板[%d]的版本[%d]不存在
+	CCErrProcServiceTemplateVersionNotExist = 1108046
+	// CCErrProcRolloutStatusInvalid 服务模板发布的当前状态为[%s]，不允许该操作
+	CCErrProcRolloutStatusInvalid = 1108047
+	// CCErrProcServiceTemplateChangedDuringRollout 服务模板在发布过程中被修改，当前版本[%d]与发布版本[%d]不一致
+	CCErrProcServiceTemplateChangedDuringRollout = 1108048
+	// CCErrProcRolloutNoPreviousVersion 服务模板没有可回滚的历史版本
+	CCErrProcRolloutNoPreviousVersion = 1108049
+	// CCErrProcRolloutProcessNameChanged 进程模板[%d]的进程名称或进程别名与目标版本不一致，不允许回滚
+	CCErrProcRolloutProcessNameChanged = 1108050
+
 	// audit log 1109XXX
 	CCErrAuditSaveLogFailed      = 1109001
 	CCErrAuditTakeSnapshotFailed = 1109002
@@ -697,6 +729,8 @@ const (
 	CCErrHostFindManyCloudAreaAddHostCountFieldFail           = 1110065
 	CCErrDeleteDefaultCloudAreaFail                           = 1110066
 	CCErrHostFindManyCloudAreaAddSyncTaskIDsFieldFail         = 1110067
+	// CCErrHostLocked host [%d] is locked, the operation is blocked by the lock
+	CCErrHostLocked = 1110068
 
 	// web 1111XXX
 	CCErrWebFileNoFound                 = 1111001
@@ -718,6 +752,8 @@ const (
 	CCErrWebUnknownLoginVersion         = 1111017
 	CCErrWebGetUsernameMapFail          = 1111018
 	CCErrWebHostCheckFail               = 1111019
+	CCErrWebGetUserListFail             = 1111020
+	CCErrWebLoginFailed                 = 1111021
 
 	// datacollection 1112xxx
 	CCErrCollectNetDeviceCreateFail            = 1112000
@@ -805,6 +841,22 @@ const (
 	CCErrCoreServiceResourceDirectoryNotExistErr = 1113033
 	// CCErrCoreServiceHostNotUnderAnyResourceDirectory 主机不在任意资源池目录下
 	CCErrCoreServiceHostNotUnderAnyResourceDirectory = 11130034
+	// CCErrCoreServiceAttributeReferredByCalculated 字段[%s]被计算字段[%s]引用，不允许删除
+	CCErrCoreServiceAttributeReferredByCalculated = 1113035
+	// CCErrCoreServiceInstRefNotExist 字段[%s]引用的实例[%v]不存在
+	CCErrCoreServiceInstRefNotExist = 1113036
+	// CCErrCoreServiceInstReferred 实例被模型[%s]的字段[%s]引用，不允许删除
+	CCErrCoreServiceInstReferred = 1113037
+	// CCErrCoreServiceModelSchemaVersionNotExist 模型[%s]的版本[%d]不存在
+	CCErrCoreServiceModelSchemaVersionNotExist = 1113038
+	// CCErrCoreServiceRollbackAttributeHasData 回滚将删除字段[%s]，该字段存在实例数据，请选择数据迁移模式
+	CCErrCoreServiceRollbackAttributeHasData = 1113039
+	// CCErrCoreServiceRolloutStatusConflict 服务模板发布[%d]的当前状态不允许该操作
+	CCErrCoreServiceRolloutStatusConflict = 1113040
+	// CCErrCoreServiceAuthRoleInUse 角色[%d]存在授权关系，不能删除
+	CCErrCoreServiceAuthRoleInUse = 1113041
+	// CCErrCoreServiceRolloutInProgress 服务模板[%d]已有进行中的发布
+	CCErrCoreServiceRolloutInProgress = 1113042
 
 	// synchronize data core service  11139xx
 	CCErrCoreServiceSyncError = 1113900
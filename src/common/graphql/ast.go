@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package graphql is a small read only graphql implementation, it parses and executes the queries against
+// a schema built at runtime. the fields are resolved in batches: the resolver of a field gets all the parent
+// values of the same level at once, so that the queries of the children can be merged like a dataloader.
+package graphql
+
+// Document is a parsed graphql document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query operation of the document, mutations and subscriptions are not supported
+type Operation struct {
+	Name         string
+	Variables    []*VariableDefinition
+	SelectionSet []Selection
+}
+
+// VariableDefinition is a variable declared by the operation
+type VariableDefinition struct {
+	Name    string
+	Type    string
+	Default Value
+}
+
+// Selection is a field, fragment spread or inline fragment in a selection set
+type Selection interface {
+	directives() []*Directive
+}
+
+// Field is a selected field
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Line         int
+}
+
+// ResponseKey is the key of the field in the result, it's the alias if set
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+func (f *Field) directives() []*Directive {
+	return f.Directives
+}
+
+// FragmentSpread is a spread of a named fragment, like ...hostFields
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+}
+
+func (f *FragmentSpread) directives() []*Directive {
+	return f.Directives
+}
+
+// InlineFragment is an inline fragment, like ... on Host { bk_host_id }
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+}
+
+func (f *InlineFragment) directives() []*Directive {
+	return f.Directives
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+}
+
+// Argument is an argument of a field or directive
+type Argument struct {
+	Name  string
+	Value Value
+}
+
+// Directive is a directive of a selection, only @skip and @include are supported
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Value is an input value literal
+type Value interface {
+	// Resolve returns the go value of the literal, the variables are replaced by their values
+	Resolve(variables map[string]interface{}) interface{}
+}
+
+// Variable is a reference of a variable, like $bizID
+type Variable struct {
+	Name string
+}
+
+func (v *Variable) Resolve(variables map[string]interface{}) interface{} {
+	return variables[v.Name]
+}
+
+// Scalar is an int, float, string, boolean or null literal, ints are int64 and floats are float64
+type Scalar struct {
+	Value interface{}
+}
+
+func (v *Scalar) Resolve(map[string]interface{}) interface{} {
+	return v.Value
+}
+
+// Enum is an enum literal, it's resolved to its name
+type Enum struct {
+	Name string
+}
+
+func (v *Enum) Resolve(map[string]interface{}) interface{} {
+	return v.Name
+}
+
+// List is a list literal
+type List struct {
+	Values []Value
+}
+
+func (v *List) Resolve(variables map[string]interface{}) interface{} {
+	values := make([]interface{}, len(v.Values))
+	for idx, value := range v.Values {
+		values[idx] = value.Resolve(variables)
+	}
+	return values
+}
+
+// Object is an input object literal
+type Object struct {
+	Fields []*Argument
+}
+
+func (v *Object) Resolve(variables map[string]interface{}) interface{} {
+	values := make(map[string]interface{}, len(v.Fields))
+	for _, field := range v.Fields {
+		values[field.Name] = field.Value.Resolve(variables)
+	}
+	return values
+}
@@ -0,0 +1,605 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DefaultMaxDepth is the default max nesting depth of the fields of a query
+const DefaultMaxDepth = 10
+
+// Request is the graphql request
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Response is the graphql response, Data is nil if the request can not be executed
+type Response struct {
+	Data   *OrderedMap `json:"data"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is an error of the request, Path is the response keys of the field that failed
+type Error struct {
+	Message string   `json:"message"`
+	Path    []string `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+	return strings.Join(e.Path, ".") + ": " + e.Message
+}
+
+// OrderedMap is the result of an object, the keys are kept in the order that they are selected
+type OrderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewOrderedMap returns an empty ordered map
+func NewOrderedMap() *OrderedMap {
+	return &OrderedMap{values: make(map[string]interface{})}
+}
+
+// Set sets the value of the key, the new keys are appended
+func (m *OrderedMap) Set(key string, value interface{}) {
+	if _, exist := m.values[key]; !exist {
+		m.keys = append(m.keys, key)
+	}
+	m.values[key] = value
+}
+
+// Get returns the value of the key
+func (m *OrderedMap) Get(key string) interface{} {
+	return m.values[key]
+}
+
+// Keys returns the keys in order
+func (m *OrderedMap) Keys() []string {
+	return m.keys
+}
+
+// MarshalJSON marshals the map with the keys in order
+func (m *OrderedMap) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for idx, key := range m.keys {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// ExecuteOption is the option of the execution
+type ExecuteOption struct {
+	// MaxDepth is the max nesting depth of the fields, DefaultMaxDepth is used if it's not set
+	MaxDepth int
+}
+
+// Execute parses, validates and executes the request. the query is validated before any resolver is called,
+// and the errors of the resolvers are returned with the partial data.
+func Execute(ctx context.Context, schema *Schema, req *Request, opt ExecuteOption) *Response {
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	op, err := getOperation(doc, req.OperationName)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	variables, err := coerceVariables(op, req.Variables)
+	if err != nil {
+		return &Response{Errors: []*Error{{Message: err.Error()}}}
+	}
+
+	if opt.MaxDepth <= 0 {
+		opt.MaxDepth = DefaultMaxDepth
+	}
+	e := &executor{
+		ctx:       ctx,
+		doc:       doc,
+		variables: variables,
+		maxDepth:  opt.MaxDepth,
+	}
+
+	if err := e.validate(schema.Query, op.SelectionSet, nil, 1, make(map[string]bool)); err != nil {
+		return &Response{Errors: []*Error{err}}
+	}
+
+	results := e.executeFields(schema.Query, []interface{}{nil}, op.SelectionSet, nil)
+	return &Response{Data: results[0], Errors: e.errors}
+}
+
+func getOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) != 1 {
+			return nil, fmt.Errorf("operationName is required when the document has multiple operations")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, op := range doc.Operations {
+		if op.Name == name {
+			return op, nil
+		}
+	}
+	return nil, fmt.Errorf("operation %s is not found", name)
+}
+
+// coerceVariables applies the default values of the variables, the values are coerced with the argument
+// types when they are used.
+func coerceVariables(op *Operation, input map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{})
+	for _, def := range op.Variables {
+		value, exist := input[def.Name]
+		if !exist && def.Default != nil {
+			value = def.Default.Resolve(nil)
+			exist = true
+		}
+		if (!exist || value == nil) && strings.HasSuffix(def.Type, "!") {
+			return nil, fmt.Errorf("variable $%s of type %s is required", def.Name, def.Type)
+		}
+		if exist {
+			variables[def.Name] = value
+		}
+	}
+	return variables, nil
+}
+
+type executor struct {
+	ctx       context.Context
+	doc       *Document
+	variables map[string]interface{}
+	maxDepth  int
+	errors    []*Error
+}
+
+// validate checks the fields, arguments and fragments of the selection set
+func (e *executor) validate(object *ObjectType, set []Selection, path []string, depth int,
+	visiting map[string]bool) *Error {
+
+	if depth > e.maxDepth {
+		return &Error{Message: fmt.Sprintf("query exceeds the max depth %d", e.maxDepth), Path: path}
+	}
+
+	for _, selection := range set {
+		switch s := selection.(type) {
+		case *Field:
+			fieldPath := append(append([]string{}, path...), s.ResponseKey())
+			if s.Name == "__typename" {
+				if len(s.SelectionSet) > 0 {
+					return &Error{Message: "__typename can not have a selection set", Path: fieldPath}
+				}
+				continue
+			}
+			def := object.Field(s.Name)
+			if def == nil {
+				return &Error{Message: fmt.Sprintf("field %s is not defined on type %s", s.Name, object.Name),
+					Path: fieldPath}
+			}
+			for _, arg := range s.Arguments {
+				if def.Arg(arg.Name) == nil {
+					return &Error{Message: fmt.Sprintf("unknown argument %s", arg.Name), Path: fieldPath}
+				}
+			}
+			switch t := namedType(def.Type).(type) {
+			case *ObjectType:
+				if len(s.SelectionSet) == 0 {
+					return &Error{Message: fmt.Sprintf("field of type %s must have a selection set",
+						def.Type.String()), Path: fieldPath}
+				}
+				if err := e.validate(t, s.SelectionSet, fieldPath, depth+1, visiting); err != nil {
+					return err
+				}
+			default:
+				if len(s.SelectionSet) > 0 {
+					return &Error{Message: fmt.Sprintf("field of type %s can not have a selection set",
+						def.Type.String()), Path: fieldPath}
+				}
+			}
+		case *FragmentSpread:
+			fragment, exist := e.doc.Fragments[s.Name]
+			if !exist {
+				return &Error{Message: fmt.Sprintf("fragment %s is not defined", s.Name), Path: path}
+			}
+			if visiting[s.Name] {
+				return &Error{Message: fmt.Sprintf("fragment %s spreads itself", s.Name), Path: path}
+			}
+			if fragment.TypeCondition != object.Name {
+				return &Error{Message: fmt.Sprintf("fragment %s on %s can not be spread on type %s", s.Name,
+					fragment.TypeCondition, object.Name), Path: path}
+			}
+			visiting[s.Name] = true
+			err := e.validate(object, fragment.SelectionSet, path, depth, visiting)
+			delete(visiting, s.Name)
+			if err != nil {
+				return err
+			}
+		case *InlineFragment:
+			if s.TypeCondition != "" && s.TypeCondition != object.Name {
+				return &Error{Message: fmt.Sprintf("fragment on %s can not be spread on type %s",
+					s.TypeCondition, object.Name), Path: path}
+			}
+			if err := e.validate(object, s.SelectionSet, path, depth, visiting); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// collectedField is the fields of the same response key, their selection sets are merged
+type collectedField struct {
+	key    string
+	fields []*Field
+}
+
+func (e *executor) collectFields(set []Selection, collected []*collectedField) []*collectedField {
+	for _, selection := range set {
+		if !e.shouldInclude(selection.directives()) {
+			continue
+		}
+		switch s := selection.(type) {
+		case *Field:
+			found := false
+			for _, one := range collected {
+				if one.key == s.ResponseKey() {
+					one.fields = append(one.fields, s)
+					found = true
+					break
+				}
+			}
+			if !found {
+				collected = append(collected, &collectedField{key: s.ResponseKey(), fields: []*Field{s}})
+			}
+		case *FragmentSpread:
+			collected = e.collectFields(e.doc.Fragments[s.Name].SelectionSet, collected)
+		case *InlineFragment:
+			collected = e.collectFields(s.SelectionSet, collected)
+		}
+	}
+	return collected
+}
+
+// shouldInclude evaluates the @skip and @include directives
+func (e *executor) shouldInclude(directives []*Directive) bool {
+	for _, directive := range directives {
+		var cond bool
+		for _, arg := range directive.Arguments {
+			if arg.Name == "if" {
+				cond, _ = arg.Value.Resolve(e.variables).(bool)
+			}
+		}
+		switch directive.Name {
+		case "skip":
+			if cond {
+				return false
+			}
+		case "include":
+			if !cond {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// executeFields executes the selection set on all the sources, returns the result of each source
+func (e *executor) executeFields(object *ObjectType, sources []interface{}, set []Selection,
+	path []string) []*OrderedMap {
+
+	results := make([]*OrderedMap, len(sources))
+	for idx := range results {
+		results[idx] = NewOrderedMap()
+	}
+
+	for _, collected := range e.collectFields(set, nil) {
+		field := collected.fields[0]
+		fieldPath := append(append([]string{}, path...), collected.key)
+
+		if field.Name == "__typename" {
+			for _, result := range results {
+				result.Set(collected.key, object.Name)
+			}
+			continue
+		}
+
+		def := object.Field(field.Name)
+		values, err := e.resolveField(def, field, collected, sources)
+		if err != nil {
+			e.errors = append(e.errors, &Error{Message: err.Error(), Path: fieldPath})
+			values = make([]interface{}, len(sources))
+		}
+
+		subSet := make([]Selection, 0)
+		for _, one := range collected.fields {
+			subSet = append(subSet, one.SelectionSet...)
+		}
+		completed := e.completeValues(def.Type, values, subSet, fieldPath)
+		for idx, result := range results {
+			result.Set(collected.key, completed[idx])
+		}
+	}
+	return results
+}
+
+func (e *executor) resolveField(def *FieldDef, field *Field, collected *collectedField,
+	sources []interface{}) ([]interface{}, error) {
+
+	args, err := e.coerceArguments(def, field)
+	if err != nil {
+		return nil, err
+	}
+
+	if def.Resolve == nil {
+		values := make([]interface{}, len(sources))
+		for idx, source := range sources {
+			values[idx] = defaultResolve(source, def.Name)
+		}
+		return values, nil
+	}
+
+	params := ResolveParams{Context: e.ctx, Sources: sources, Args: args}
+	if object, ok := namedType(def.Type).(*ObjectType); ok {
+		subSet := make([]Selection, 0)
+		for _, one := range collected.fields {
+			subSet = append(subSet, one.SelectionSet...)
+		}
+		selected := make(map[string]bool)
+		for _, sub := range e.collectFields(subSet, nil) {
+			name := sub.fields[0].Name
+			if object.Field(name) != nil && !selected[name] {
+				params.Fields = append(params.Fields, name)
+				selected[name] = true
+			}
+		}
+	}
+
+	values, err := def.Resolve(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) != len(sources) {
+		return nil, fmt.Errorf("resolver returns %d values for %d sources", len(values), len(sources))
+	}
+	return values, nil
+}
+
+func (e *executor) coerceArguments(def *FieldDef, field *Field) (map[string]interface{}, error) {
+	args := make(map[string]interface{})
+	for _, argDef := range def.Args {
+		var value interface{}
+		exist := false
+		for _, arg := range field.Arguments {
+			if arg.Name == argDef.Name {
+				value = arg.Value.Resolve(e.variables)
+				exist = true
+				if variable, ok := arg.Value.(*Variable); ok {
+					_, exist = e.variables[variable.Name]
+				}
+			}
+		}
+		if !exist {
+			value = argDef.Default
+		}
+
+		if value == nil {
+			if _, ok := argDef.Type.(*NonNullType); ok {
+				return nil, fmt.Errorf("argument %s of type %s is required", argDef.Name, argDef.Type.String())
+			}
+			continue
+		}
+
+		coerced, err := coerceInput(argDef.Type, value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %s is invalid, %v", argDef.Name, err)
+		}
+		args[argDef.Name] = coerced
+	}
+	return args, nil
+}
+
+// coerceInput coerces the input value to the type, a single value is accepted as a list of one element
+func coerceInput(typ Type, value interface{}) (interface{}, error) {
+	switch t := typ.(type) {
+	case *NonNullType:
+		if value == nil {
+			return nil, fmt.Errorf("null is not %s", t.String())
+		}
+		return coerceInput(t.OfType, value)
+	case *ListType:
+		if value == nil {
+			return nil, nil
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			items = []interface{}{value}
+		}
+		values := make([]interface{}, len(items))
+		for idx, item := range items {
+			coerced, err := coerceInput(t.OfType, item)
+			if err != nil {
+				return nil, err
+			}
+			values[idx] = coerced
+		}
+		return values, nil
+	case *ScalarType:
+		if value == nil {
+			return nil, nil
+		}
+		coerced, ok := t.Coerce(value)
+		if !ok {
+			return nil, fmt.Errorf("%v is not %s", value, t.Name)
+		}
+		return coerced, nil
+	}
+	return nil, fmt.Errorf("unsupported input type %s", typ.String())
+}
+
+// completeValues converts the resolved values to the result of the type, the elements of all the lists are
+// completed together so that the object fields of the next level are resolved in one batch.
+func (e *executor) completeValues(typ Type, values []interface{}, set []Selection, path []string) []interface{} {
+	switch t := typ.(type) {
+	case *NonNullType:
+		completed := e.completeValues(t.OfType, values, set, path)
+		for _, value := range completed {
+			if value == nil {
+				e.errors = append(e.errors, &Error{Message: "null value of non null type " + t.String(),
+					Path: path})
+				break
+			}
+		}
+		return completed
+
+	case *ListType:
+		items := make([]interface{}, 0)
+		lengths := make([]int, len(values))
+		for idx, value := range values {
+			list, ok := toSlice(value)
+			if !ok {
+				lengths[idx] = -1
+				if value != nil {
+					e.errors = append(e.errors, &Error{Message: "value is not a list", Path: path})
+				}
+				continue
+			}
+			lengths[idx] = len(list)
+			items = append(items, list...)
+		}
+		completedItems := e.completeValues(t.OfType, items, set, path)
+
+		completed := make([]interface{}, len(values))
+		offset := 0
+		for idx, length := range lengths {
+			if length < 0 {
+				continue
+			}
+			completed[idx] = completedItems[offset : offset+length]
+			offset += length
+		}
+		return completed
+
+	case *ObjectType:
+		sources := make([]interface{}, 0)
+		for _, value := range values {
+			if !isNil(value) {
+				sources = append(sources, value)
+			}
+		}
+		results := e.executeFields(t, sources, set, path)
+
+		completed := make([]interface{}, len(values))
+		offset := 0
+		for idx, value := range values {
+			if isNil(value) {
+				continue
+			}
+			completed[idx] = results[offset]
+			offset++
+		}
+		return completed
+
+	case *ScalarType:
+		completed := make([]interface{}, len(values))
+		for idx, value := range values {
+			if isNil(value) {
+				continue
+			}
+			coerced, ok := t.Coerce(value)
+			if !ok {
+				e.errors = append(e.errors, &Error{Message: fmt.Sprintf("%v is not %s", value, t.Name),
+					Path: path})
+				continue
+			}
+			completed[idx] = coerced
+		}
+		return completed
+	}
+	return make([]interface{}, len(values))
+}
+
+// defaultResolve gets the field value from the map or the FieldSource
+func defaultResolve(source interface{}, name string) interface{} {
+	if isNil(source) {
+		return nil
+	}
+	if fieldSource, ok := source.(FieldSource); ok {
+		return fieldSource.FieldValue(name)
+	}
+	if m, ok := source.(map[string]interface{}); ok {
+		return m[name]
+	}
+
+	value := reflect.ValueOf(source)
+	if value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String {
+		elem := value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key()))
+		if elem.IsValid() {
+			return elem.Interface()
+		}
+	}
+	return nil
+}
+
+func toSlice(value interface{}) ([]interface{}, bool) {
+	if list, ok := value.([]interface{}); ok {
+		return list, true
+	}
+	if isNil(value) {
+		return nil, false
+	}
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, false
+	}
+	list := make([]interface{}, rv.Len())
+	for idx := range list {
+		list[idx] = rv.Index(idx).Interface()
+	}
+	return list, true
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Ptr, reflect.Slice, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# comment
+		query hosts($biz: Int! = 2, $ids: [Int!]) {
+			h: host(bk_biz_id: $biz, ids: $ids, cond: {a: [1, 2.5, "x\n", true, null, ENUM]}) @include(if: true) {
+				...hostFields
+				... on Host { bk_host_name }
+			}
+		}
+		fragment hostFields on Host { bk_host_id }
+	`)
+	require.NoError(t, err)
+	require.Len(t, doc.Operations, 1)
+
+	op := doc.Operations[0]
+	require.Equal(t, "hosts", op.Name)
+	require.Len(t, op.Variables, 2)
+	require.Equal(t, "Int!", op.Variables[0].Type)
+	require.Equal(t, int64(2), op.Variables[0].Default.Resolve(nil))
+	require.Equal(t, "[Int!]", op.Variables[1].Type)
+
+	field := op.SelectionSet[0].(*Field)
+	require.Equal(t, "h", field.ResponseKey())
+	require.Equal(t, "host", field.Name)
+	require.Len(t, field.Arguments, 3)
+	require.Equal(t, int64(3), field.Arguments[0].Value.Resolve(map[string]interface{}{"biz": int64(3)}))
+	require.Equal(t, map[string]interface{}{"a": []interface{}{int64(1), 2.5, "x\n", true, nil, "ENUM"}},
+		field.Arguments[2].Value.Resolve(nil))
+	require.Equal(t, "include", field.Directives[0].Name)
+	require.Equal(t, "hostFields", field.SelectionSet[0].(*FragmentSpread).Name)
+	require.Equal(t, "Host", field.SelectionSet[1].(*InlineFragment).TypeCondition)
+	require.Equal(t, "Host", doc.Fragments["hostFields"].TypeCondition)
+
+	for _, query := range []string{
+		``,
+		`{`,
+		`{ }`,
+		`{ a(b: ) }`,
+		`{ a(b: "x) }`,
+		`mutation { a }`,
+		`query ($a: Int = $b) { a }`,
+		`fragment f on A { a } fragment f on A { a } { a }`,
+	} {
+		_, err := Parse(query)
+		require.Error(t, err, query)
+	}
+}
+
+type testHost struct {
+	id   int64
+	name string
+}
+
+func (h *testHost) FieldValue(name string) interface{} {
+	switch name {
+	case "id":
+		return h.id
+	case "name":
+		return h.name
+	}
+	return nil
+}
+
+func testSchema(t *testing.T, calls *[]ResolveParams) *Schema {
+	module := NewObject("Module", "")
+	module.AddField(&FieldDef{Name: "bk_module_id", Type: NewNonNull(Int)})
+	module.AddField(&FieldDef{Name: "bk_module_name", Type: String})
+
+	host := NewObject("Host", "host instance")
+	host.AddField(&FieldDef{Name: "id", Type: NewNonNull(ID)})
+	host.AddField(&FieldDef{Name: "name", Type: String, Description: "host name"})
+	host.AddField(&FieldDef{
+		Name: "modules",
+		Type: NewList(module),
+		Resolve: func(p ResolveParams) ([]interface{}, error) {
+			*calls = append(*calls, p)
+			values := make([]interface{}, len(p.Sources))
+			for idx, source := range p.Sources {
+				id := source.(*testHost).id
+				values[idx] = []map[string]interface{}{
+					{"bk_module_id": id * 10, "bk_module_name": "m"},
+					{"bk_module_id": id*10 + 1},
+				}
+			}
+			return values, nil
+		},
+	})
+
+	query := NewObject("Query", "")
+	query.AddField(&FieldDef{
+		Name: "hosts",
+		Type: NewList(NewNonNull(host)),
+		Args: []*ArgumentDef{
+			{Name: "ids", Type: NewNonNull(NewList(Int))},
+			{Name: "limit", Type: Int, Default: int64(10)},
+		},
+		Resolve: func(p ResolveParams) ([]interface{}, error) {
+			*calls = append(*calls, p)
+			hosts := make([]*testHost, 0)
+			for _, id := range p.Args["ids"].([]interface{}) {
+				hosts = append(hosts, &testHost{id: id.(int64), name: "host"})
+			}
+			return []interface{}{hosts}, nil
+		},
+	})
+	query.AddField(&FieldDef{Name: "info", Type: JSON,
+		Resolve: func(p ResolveParams) ([]interface{}, error) {
+			return []interface{}{map[string]interface{}{"a": 1}}, nil
+		},
+	})
+
+	schema, err := NewSchema(query)
+	require.NoError(t, err)
+	return schema
+}
+
+func TestExecute(t *testing.T) {
+	calls := make([]ResolveParams, 0)
+	schema := testSchema(t, &calls)
+
+	resp := Execute(context.Background(), schema, &Request{
+		Query: `query q($ids: [Int], $skip: Boolean!) {
+			info
+			hosts(ids: $ids) {
+				__typename
+				...f
+				name @skip(if: $skip)
+				mods: modules { bk_module_id }
+				modules { bk_module_id bk_module_name }
+			}
+		}
+		fragment f on Host { id }`,
+		Variables: map[string]interface{}{"ids": []interface{}{float64(1), json.Number("2")}, "skip": true},
+	}, ExecuteOption{})
+	require.Empty(t, resp.Errors)
+
+	js, err := json.Marshal(resp)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"data":{"info":{"a":1},"hosts":[
+		{"__typename":"Host","id":"1","mods":[{"bk_module_id":10},{"bk_module_id":11}],
+			"modules":[{"bk_module_id":10,"bk_module_name":"m"},{"bk_module_id":11,"bk_module_name":null}]},
+		{"__typename":"Host","id":"2","mods":[{"bk_module_id":20},{"bk_module_id":21}],
+			"modules":[{"bk_module_id":20,"bk_module_name":"m"},{"bk_module_id":21,"bk_module_name":null}]}
+	]}}`, string(js))
+	require.True(t, strings.HasPrefix(string(js), `{"data":{"info"`))
+
+	// the modules of all the hosts are resolved in one batch for each response key
+	require.Len(t, calls, 3)
+	require.Equal(t, int64(10), calls[0].Args["limit"])
+	require.Equal(t, []string{"id", "modules"}, calls[0].Fields)
+	require.Len(t, calls[1].Sources, 2)
+	require.Equal(t, []string{"bk_module_id"}, calls[1].Fields)
+	require.Equal(t, []string{"bk_module_id", "bk_module_name"}, calls[2].Fields)
+}
+
+func TestExecuteErrors(t *testing.T) {
+	calls := make([]ResolveParams, 0)
+	schema := testSchema(t, &calls)
+
+	for query, msg := range map[string]string{
+		`{ hosts(ids: [1]) { unknown } }`:                                    "field unknown is not defined on type Host",
+		`{ hosts(ids: [1], x: 1) { id } }`:                                   "unknown argument x",
+		`{ hosts(ids: [1]) }`:                                                "must have a selection set",
+		`{ hosts(ids: [1]) { id { a } } }`:                                   "can not have a selection set",
+		`{ hosts(ids: [1]) { ...f } }`:                                       "fragment f is not defined",
+		`{ hosts(ids: [1]) { ...f } } fragment f on Module { bk_module_id }`: "can not be spread",
+		`{ hosts(ids: [1]) { ...f } } fragment f on Host { ...f }`:           "spreads itself",
+		`{ hosts(ids: [1]) { modules { modules } } }`:                        "not defined on type Module",
+		`query a { info } query b { info }`:                                  "operationName is required",
+		`query ($a: Int!) { info }`:                                          "variable $a of type Int! is required",
+	} {
+		resp := Execute(context.Background(), schema, &Request{Query: query}, ExecuteOption{})
+		require.Nil(t, resp.Data, query)
+		require.Len(t, resp.Errors, 1, query)
+		require.Contains(t, resp.Errors[0].Message, msg, query)
+	}
+	require.Empty(t, calls)
+
+	resp := Execute(context.Background(), schema, &Request{Query: `{ hosts(ids: [1]) { modules { bk_module_id } } }`},
+		ExecuteOption{MaxDepth: 2})
+	require.Len(t, resp.Errors, 1)
+	require.Contains(t, resp.Errors[0].Message, "max depth")
+
+	// the argument errors are returned with the partial data
+	resp = Execute(context.Background(), schema, &Request{Query: `{ info hosts(ids: ["x"]) { id } }`},
+		ExecuteOption{})
+	require.Len(t, resp.Errors, 1)
+	require.Equal(t, []string{"hosts"}, resp.Errors[0].Path)
+	require.Nil(t, resp.Data.Get("hosts"))
+	require.NotNil(t, resp.Data.Get("info"))
+}
+
+func TestSchemaString(t *testing.T) {
+	calls := make([]ResolveParams, 0)
+	sdl := testSchema(t, &calls).String()
+	require.Contains(t, sdl, "schema {\n  query: Query\n}\n")
+	require.Contains(t, sdl, "\"host instance\"\ntype Host {\n  id: ID!\n  \"host name\"\n  name: String\n")
+	require.Contains(t, sdl, "hosts(ids: [Int]!, limit: Int = 10): [Host!]\n")
+	require.Contains(t, sdl, "scalar JSON\n")
+	require.NotContains(t, sdl, "scalar Int")
+
+	query := NewObject("Query", "")
+	query.AddField(&FieldDef{Name: "a", Type: NewObject("A", "")})
+	query.AddField(&FieldDef{Name: "b", Type: NewObject("A", "")})
+	_, err := NewSchema(query)
+	require.Error(t, err)
+}
@@ -0,0 +1,198 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunctuator
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+}
+
+// lexer splits the graphql source into tokens, the commas and comments are ignored as the spec says
+type lexer struct {
+	source string
+	pos    int
+	line   int
+}
+
+func newLexer(source string) *lexer {
+	return &lexer{source: source, line: 1}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	if l.pos >= len(l.source) {
+		return token{kind: tokenEOF, line: l.line}, nil
+	}
+
+	ch := l.source[l.pos]
+	switch {
+	case strings.IndexByte("!$():=@[]{}|&", ch) >= 0:
+		l.pos++
+		return token{kind: tokenPunctuator, value: string(ch), line: l.line}, nil
+	case ch == '.':
+		if strings.HasPrefix(l.source[l.pos:], "...") {
+			l.pos += 3
+			return token{kind: tokenPunctuator, value: "...", line: l.line}, nil
+		}
+		return token{}, fmt.Errorf("unexpected character '.' at line %d", l.line)
+	case ch == '_' || isLetter(ch):
+		start := l.pos
+		for l.pos < len(l.source) && (l.source[l.pos] == '_' || isLetter(l.source[l.pos]) ||
+			isDigit(l.source[l.pos])) {
+			l.pos++
+		}
+		return token{kind: tokenName, value: l.source[start:l.pos], line: l.line}, nil
+	case ch == '-' || isDigit(ch):
+		return l.readNumber()
+	case ch == '"':
+		return l.readString()
+	default:
+		return token{}, fmt.Errorf("unexpected character %q at line %d", ch, l.line)
+	}
+}
+
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.source) {
+		switch l.source[l.pos] {
+		case '\n':
+			l.line++
+			l.pos++
+		case ' ', '\t', '\r', ',':
+			l.pos++
+		case '#':
+			for l.pos < len(l.source) && l.source[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (l *lexer) readNumber() (token, error) {
+	start := l.pos
+	kind := tokenInt
+	if l.source[l.pos] == '-' {
+		l.pos++
+	}
+	l.readDigits()
+	if l.pos < len(l.source) && l.source[l.pos] == '.' {
+		kind = tokenFloat
+		l.pos++
+		l.readDigits()
+	}
+	if l.pos < len(l.source) && (l.source[l.pos] == 'e' || l.source[l.pos] == 'E') {
+		kind = tokenFloat
+		l.pos++
+		if l.pos < len(l.source) && (l.source[l.pos] == '+' || l.source[l.pos] == '-') {
+			l.pos++
+		}
+		l.readDigits()
+	}
+
+	value := l.source[start:l.pos]
+	var err error
+	if kind == tokenInt {
+		_, err = strconv.ParseInt(value, 10, 64)
+	} else {
+		_, err = strconv.ParseFloat(value, 64)
+	}
+	if err != nil {
+		return token{}, fmt.Errorf("invalid number %s at line %d", value, l.line)
+	}
+	return token{kind: kind, value: value, line: l.line}, nil
+}
+
+func (l *lexer) readDigits() {
+	for l.pos < len(l.source) && isDigit(l.source[l.pos]) {
+		l.pos++
+	}
+}
+
+// readString reads a quoted string, the block strings are not supported
+func (l *lexer) readString() (token, error) {
+	l.pos++
+	var builder strings.Builder
+	for l.pos < len(l.source) {
+		ch := l.source[l.pos]
+		switch ch {
+		case '"':
+			l.pos++
+			return token{kind: tokenString, value: builder.String(), line: l.line}, nil
+		case '\n':
+			return token{}, fmt.Errorf("unterminated string at line %d", l.line)
+		case '\\':
+			if l.pos+1 >= len(l.source) {
+				return token{}, fmt.Errorf("unterminated string at line %d", l.line)
+			}
+			l.pos++
+			switch escaped := l.source[l.pos]; escaped {
+			case '"', '\\', '/':
+				builder.WriteByte(escaped)
+			case 'b':
+				builder.WriteByte('\b')
+			case 'f':
+				builder.WriteByte('\f')
+			case 'n':
+				builder.WriteByte('\n')
+			case 'r':
+				builder.WriteByte('\r')
+			case 't':
+				builder.WriteByte('\t')
+			case 'u':
+				if l.pos+4 >= len(l.source) {
+					return token{}, fmt.Errorf("invalid unicode escape at line %d", l.line)
+				}
+				code, err := strconv.ParseUint(l.source[l.pos+1:l.pos+5], 16, 32)
+				if err != nil {
+					return token{}, fmt.Errorf("invalid unicode escape at line %d", l.line)
+				}
+				builder.WriteRune(rune(code))
+				l.pos += 4
+			default:
+				return token{}, fmt.Errorf("invalid escape \\%c at line %d", escaped, l.line)
+			}
+			l.pos++
+		default:
+			builder.WriteByte(ch)
+			l.pos++
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at line %d", l.line)
+}
+
+func isLetter(ch byte) bool {
+	return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isDigit(ch byte) bool {
+	return ch >= '0' && ch <= '9'
+}
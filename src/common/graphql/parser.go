@@ -0,0 +1,471 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Parse parses the graphql query document, only the executable definitions are supported
+func Parse(source string) (*Document, error) {
+	p := &parser{lexer: newLexer(source)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p.parseDocument()
+}
+
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func (p *parser) advance() error {
+	tok, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = tok
+	return nil
+}
+
+func (p *parser) peek(value string) bool {
+	return p.token.kind == tokenPunctuator && p.token.value == value
+}
+
+func (p *parser) expect(value string) error {
+	if !p.peek(value) {
+		return p.unexpected()
+	}
+	return p.advance()
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.token.kind != tokenName {
+		return "", p.unexpected()
+	}
+	name := p.token.value
+	return name, p.advance()
+}
+
+func (p *parser) unexpected() error {
+	if p.token.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of the document at line %d", p.token.line)
+	}
+	return fmt.Errorf("unexpected %q at line %d", p.token.value, p.token.line)
+}
+
+func (p *parser) parseDocument() (*Document, error) {
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+	for p.token.kind != tokenEOF {
+		if p.peek("{") {
+			set, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{SelectionSet: set})
+			continue
+		}
+
+		if p.token.kind != tokenName {
+			return nil, p.unexpected()
+		}
+		switch p.token.value {
+		case "query":
+			op, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, op)
+		case "fragment":
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, exist := doc.Fragments[fragment.Name]; exist {
+				return nil, fmt.Errorf("fragment %s is defined more than once", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		case "mutation", "subscription":
+			return nil, fmt.Errorf("%s is not supported, the api is read only", p.token.value)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("document has no operation")
+	}
+	return doc, nil
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	// skip the query keyword
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	op := new(Operation)
+	if p.token.kind == tokenName {
+		op.Name = p.token.value
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.peek("(") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		for !p.peek(")") {
+			def, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			op.Variables = append(op.Variables, def)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	// the directives of the operation are parsed and ignored
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	set, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	op.SelectionSet = set
+	return op, nil
+}
+
+func (p *parser) parseVariableDefinition() (*VariableDefinition, error) {
+	if err := p.expect("$"); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.parseTypeReference()
+	if err != nil {
+		return nil, err
+	}
+
+	def := &VariableDefinition{Name: name, Type: typ}
+	if p.peek("=") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(true)
+		if err != nil {
+			return nil, err
+		}
+		def.Default = value
+	}
+	return def, nil
+}
+
+// parseTypeReference parses the type of a variable, it's kept as the literal, like [Int!]!
+func (p *parser) parseTypeReference() (string, error) {
+	var typ string
+	if p.peek("[") {
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		elem, err := p.parseTypeReference()
+		if err != nil {
+			return "", err
+		}
+		if err := p.expect("]"); err != nil {
+			return "", err
+		}
+		typ = "[" + elem + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", err
+		}
+		typ = name
+	}
+
+	if p.peek("!") {
+		if err := p.advance(); err != nil {
+			return "", err
+		}
+		typ += "!"
+	}
+	return typ, nil
+}
+
+func (p *parser) parseFragment() (*Fragment, error) {
+	// skip the fragment keyword
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("fragment can not be named on at line %d", p.token.line)
+	}
+	if p.token.kind != tokenName || p.token.value != "on" {
+		return nil, p.unexpected()
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	typ, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	set, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &Fragment{Name: name, TypeCondition: typ, SelectionSet: set}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	set := make([]Selection, 0)
+	for !p.peek("}") {
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, selection)
+	}
+	if len(set) == 0 {
+		return nil, fmt.Errorf("empty selection set at line %d", p.token.line)
+	}
+	return set, p.advance()
+}
+
+func (p *parser) parseSelection() (Selection, error) {
+	if p.peek("...") {
+		return p.parseFragmentSelection()
+	}
+
+	field := &Field{Line: p.token.line}
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek(":") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		field.Alias = name
+		if name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+
+	if field.Arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peek("{") {
+		if field.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) parseFragmentSelection() (Selection, error) {
+	// skip the spread
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.token.kind == tokenName && p.token.value != "on" {
+		spread := &FragmentSpread{Name: p.token.value}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		directives, err := p.parseDirectives()
+		if err != nil {
+			return nil, err
+		}
+		spread.Directives = directives
+		return spread, nil
+	}
+
+	fragment := new(InlineFragment)
+	if p.token.kind == tokenName {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		typ, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		fragment.TypeCondition = typ
+	}
+	directives, err := p.parseDirectives()
+	if err != nil {
+		return nil, err
+	}
+	fragment.Directives = directives
+	if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) parseArguments() ([]*Argument, error) {
+	if !p.peek("(") {
+		return nil, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	args := make([]*Argument, 0)
+	for !p.peek(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(false)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, &Argument{Name: name, Value: value})
+	}
+	return args, p.advance()
+}
+
+func (p *parser) parseDirectives() ([]*Directive, error) {
+	directives := make([]*Directive, 0)
+	for p.peek("@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &Directive{Name: name, Arguments: args})
+	}
+	return directives, nil
+}
+
+// parseValue parses an input value, the variables can not be used in the constant values like the defaults
+func (p *parser) parseValue(constant bool) (Value, error) {
+	tok := p.token
+	switch tok.kind {
+	case tokenInt:
+		value, _ := strconv.ParseInt(tok.value, 10, 64)
+		return &Scalar{Value: value}, p.advance()
+	case tokenFloat:
+		value, _ := strconv.ParseFloat(tok.value, 64)
+		return &Scalar{Value: value}, p.advance()
+	case tokenString:
+		return &Scalar{Value: tok.value}, p.advance()
+	case tokenName:
+		switch tok.value {
+		case "true":
+			return &Scalar{Value: true}, p.advance()
+		case "false":
+			return &Scalar{Value: false}, p.advance()
+		case "null":
+			return &Scalar{Value: nil}, p.advance()
+		default:
+			return &Enum{Name: tok.value}, p.advance()
+		}
+	case tokenPunctuator:
+		switch tok.value {
+		case "$":
+			if constant {
+				return nil, fmt.Errorf("variable is not allowed in constant value at line %d", tok.line)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			return &Variable{Name: name}, nil
+		case "[":
+			return p.parseList(constant)
+		case "{":
+			return p.parseObject(constant)
+		}
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseList(constant bool) (Value, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	list := &List{Values: make([]Value, 0)}
+	for !p.peek("]") {
+		value, err := p.parseValue(constant)
+		if err != nil {
+			return nil, err
+		}
+		list.Values = append(list.Values, value)
+	}
+	return list, p.advance()
+}
+
+func (p *parser) parseObject(constant bool) (Value, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	object := &Object{Fields: make([]*Argument, 0)}
+	for !p.peek("}") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue(constant)
+		if err != nil {
+			return nil, err
+		}
+		object.Fields = append(object.Fields, &Argument{Name: name, Value: value})
+	}
+	return object, p.advance()
+}
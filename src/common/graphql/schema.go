@@ -0,0 +1,444 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Type is a graphql type, it's a *ScalarType, *ObjectType, *ListType or *NonNullType
+type Type interface {
+	// String returns the type reference, like [Host!]
+	String() string
+}
+
+// ScalarType is a leaf type, Coerce converts the resolved or input value to the value of the type,
+// it returns false if the value can not be converted.
+type ScalarType struct {
+	Name        string
+	Description string
+	Coerce      func(value interface{}) (interface{}, bool)
+}
+
+func (t *ScalarType) String() string {
+	return t.Name
+}
+
+// ObjectType is a type with fields, the fields are kept in the order they are added
+type ObjectType struct {
+	Name        string
+	Description string
+	fields      []*FieldDef
+	fieldMap    map[string]*FieldDef
+}
+
+// NewObject returns an object type without fields
+func NewObject(name, description string) *ObjectType {
+	return &ObjectType{Name: name, Description: description, fieldMap: make(map[string]*FieldDef)}
+}
+
+func (t *ObjectType) String() string {
+	return t.Name
+}
+
+// AddField adds a field to the object, the field with the same name is replaced
+func (t *ObjectType) AddField(field *FieldDef) {
+	if _, exist := t.fieldMap[field.Name]; exist {
+		for idx := range t.fields {
+			if t.fields[idx].Name == field.Name {
+				t.fields[idx] = field
+			}
+		}
+	} else {
+		t.fields = append(t.fields, field)
+	}
+	t.fieldMap[field.Name] = field
+}
+
+// Field returns the field of the name, returns nil if not exists
+func (t *ObjectType) Field(name string) *FieldDef {
+	return t.fieldMap[name]
+}
+
+// Fields returns the fields in the order they are added
+func (t *ObjectType) Fields() []*FieldDef {
+	return t.fields
+}
+
+// ListType is a list of the type
+type ListType struct {
+	OfType Type
+}
+
+// NewList returns the list type of the type
+func NewList(ofType Type) *ListType {
+	return &ListType{OfType: ofType}
+}
+
+func (t *ListType) String() string {
+	return "[" + t.OfType.String() + "]"
+}
+
+// NonNullType is the type that can not be null
+type NonNullType struct {
+	OfType Type
+}
+
+// NewNonNull returns the non null type of the type
+func NewNonNull(ofType Type) *NonNullType {
+	return &NonNullType{OfType: ofType}
+}
+
+func (t *NonNullType) String() string {
+	return t.OfType.String() + "!"
+}
+
+// namedType returns the scalar or object type under the list and non null wrappers
+func namedType(typ Type) Type {
+	for {
+		switch t := typ.(type) {
+		case *ListType:
+			typ = t.OfType
+		case *NonNullType:
+			typ = t.OfType
+		default:
+			return typ
+		}
+	}
+}
+
+// ArgumentDef is an argument of a field, Default is used if the argument is not given
+type ArgumentDef struct {
+	Name        string
+	Description string
+	Type        Type
+	Default     interface{}
+}
+
+// ResolveParams is the params of the resolver, the resolver is called once for all the parent values of
+// the same level.
+type ResolveParams struct {
+	Context context.Context
+	// Sources is the parent values of the field
+	Sources []interface{}
+	// Args is the coerced arguments of the field
+	Args map[string]interface{}
+	// Fields is the names of the selected fields of the field value if the field is an object or object list,
+	// it can be used to get only the required fields.
+	Fields []string
+}
+
+// ResolveFunc resolves the field values of the sources, it must return one value for each source in the same
+// order. the value of an object is a map or a struct that implements FieldSource, and the value of a list
+// is a slice.
+type ResolveFunc func(p ResolveParams) ([]interface{}, error)
+
+// FieldSource is the value of an object that gets the field values itself, it's used by the default resolver
+type FieldSource interface {
+	FieldValue(name string) interface{}
+}
+
+// FieldDef is a field of an object type, the value of the field is got from the parent value by the field
+// name if Resolve is nil.
+type FieldDef struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*ArgumentDef
+	Resolve     ResolveFunc
+}
+
+// Arg returns the argument of the name, returns nil if not exists
+func (f *FieldDef) Arg(name string) *ArgumentDef {
+	for _, arg := range f.Args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// Schema is the graphql schema, only query is supported
+type Schema struct {
+	Query *ObjectType
+	types map[string]Type
+}
+
+// NewSchema returns the schema of the query type, the types referenced by the query are collected and their
+// names must be unique.
+func NewSchema(query *ObjectType) (*Schema, error) {
+	s := &Schema{Query: query, types: make(map[string]Type)}
+	for _, scalar := range []*ScalarType{Int, Float, String, Boolean, ID} {
+		s.types[scalar.Name] = scalar
+	}
+	if err := s.collect(query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Schema) collect(typ Type) error {
+	typ = namedType(typ)
+	var name string
+	switch t := typ.(type) {
+	case *ScalarType:
+		name = t.Name
+	case *ObjectType:
+		name = t.Name
+	default:
+		return fmt.Errorf("unsupported type %v", typ)
+	}
+	if !isValidName(name) {
+		return fmt.Errorf("type name %s is invalid", name)
+	}
+
+	if exist, ok := s.types[name]; ok {
+		if exist != typ {
+			return fmt.Errorf("type %s is defined more than once", name)
+		}
+		return nil
+	}
+	s.types[name] = typ
+
+	object, ok := typ.(*ObjectType)
+	if !ok {
+		return nil
+	}
+	for _, field := range object.fields {
+		if !isValidName(field.Name) {
+			return fmt.Errorf("field name %s.%s is invalid", name, field.Name)
+		}
+		if err := s.collect(field.Type); err != nil {
+			return err
+		}
+		for _, arg := range field.Args {
+			if _, ok := namedType(arg.Type).(*ScalarType); !ok {
+				return fmt.Errorf("argument %s of %s.%s is not a scalar", arg.Name, name, field.Name)
+			}
+			if err := s.collect(arg.Type); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Type returns the named type, returns nil if not exists
+func (s *Schema) Type(name string) Type {
+	return s.types[name]
+}
+
+// String returns the schema definition language of the schema, the types are sorted by name
+func (s *Schema) String() string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	builder.WriteString("schema {\n  query: " + s.Query.Name + "\n}\n")
+	for _, name := range names {
+		switch t := s.types[name].(type) {
+		case *ScalarType:
+			if isBuiltinScalar(name) {
+				continue
+			}
+			builder.WriteString("\n")
+			writeDescription(&builder, t.Description, "")
+			builder.WriteString("scalar " + name + "\n")
+		case *ObjectType:
+			builder.WriteString("\n")
+			writeDescription(&builder, t.Description, "")
+			builder.WriteString("type " + name + " {\n")
+			for _, field := range t.fields {
+				writeDescription(&builder, field.Description, "  ")
+				builder.WriteString("  " + field.Name)
+				if len(field.Args) > 0 {
+					args := make([]string, len(field.Args))
+					for idx, arg := range field.Args {
+						args[idx] = arg.Name + ": " + arg.Type.String()
+						if arg.Default != nil {
+							def, _ := json.Marshal(arg.Default)
+							args[idx] += " = " + string(def)
+						}
+					}
+					builder.WriteString("(" + strings.Join(args, ", ") + ")")
+				}
+				builder.WriteString(": " + field.Type.String() + "\n")
+			}
+			builder.WriteString("}\n")
+		}
+	}
+	return builder.String()
+}
+
+func writeDescription(builder *strings.Builder, description, indent string) {
+	if description == "" {
+		return
+	}
+	builder.WriteString(indent + strconv.Quote(description) + "\n")
+}
+
+func isBuiltinScalar(name string) bool {
+	switch name {
+	case "Int", "Float", "String", "Boolean", "ID":
+		return true
+	}
+	return false
+}
+
+// isValidName checks the name matches /[_A-Za-z][_0-9A-Za-z]*/, and the names starts with __ are reserved
+func isValidName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "__") {
+		return false
+	}
+	for idx := 0; idx < len(name); idx++ {
+		ch := name[idx]
+		if ch == '_' || isLetter(ch) || (idx > 0 && isDigit(ch)) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+// the built in scalars, the numbers of the json values are accepted as the input and output values
+var (
+	Int = &ScalarType{
+		Name:        "Int",
+		Description: "The Int scalar type represents a signed 64 bit integer.",
+		Coerce:      coerceInt,
+	}
+	Float = &ScalarType{
+		Name:        "Float",
+		Description: "The Float scalar type represents a double precision floating point value.",
+		Coerce:      coerceFloat,
+	}
+	String = &ScalarType{
+		Name:        "String",
+		Description: "The String scalar type represents a textual data.",
+		Coerce:      coerceString,
+	}
+	Boolean = &ScalarType{
+		Name:        "Boolean",
+		Description: "The Boolean scalar type represents true or false.",
+		Coerce:      coerceBoolean,
+	}
+	ID = &ScalarType{
+		Name:        "ID",
+		Description: "The ID scalar type represents a unique identifier, it's serialized as a string.",
+		Coerce:      coerceID,
+	}
+	// JSON is not a built in scalar of the spec, it's any json value and is used for the fields with
+	// dynamic structure, like the conditions.
+	JSON = &ScalarType{
+		Name:        "JSON",
+		Description: "The JSON scalar type represents any json value.",
+		Coerce: func(value interface{}) (interface{}, bool) {
+			return value, true
+		},
+	}
+)
+
+func coerceInt(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, false
+		}
+		return int64(v), true
+	case float32:
+		return coerceInt(float64(v))
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt64 || v < math.MinInt64 {
+			return nil, false
+		}
+		return int64(v), true
+	case json.Number:
+		i, err := v.Int64()
+		if err != nil {
+			return nil, false
+		}
+		return i, true
+	}
+	return nil, false
+}
+
+func coerceFloat(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case float32:
+		return float64(v), true
+	case float64:
+		return v, true
+	case json.Number:
+		f, err := v.Float64()
+		if err != nil {
+			return nil, false
+		}
+		return f, true
+	}
+	if i, ok := coerceInt(value); ok {
+		return float64(i.(int64)), true
+	}
+	return nil, false
+}
+
+func coerceString(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case fmt.Stringer:
+		return v.String(), true
+	}
+	return nil, false
+}
+
+func coerceBoolean(value interface{}) (interface{}, bool) {
+	v, ok := value.(bool)
+	return v, ok
+}
+
+func coerceID(value interface{}) (interface{}, bool) {
+	if s, ok := value.(string); ok {
+		return s, true
+	}
+	if i, ok := coerceInt(value); ok {
+		return strconv.FormatInt(i.(int64), 10), true
+	}
+	return nil, false
+}
@@ -438,6 +438,11 @@ func (c *Contexts) SetReadPreference(mode common.ReadPreferenceMode) {
 	c.Kit.Ctx, c.Kit.Header = util.SetReadPreference(c.Kit.Ctx, c.Kit.Header, mode)
 }
 
+// SetReadAfterWrite marks the request as following a write, so that its reads go to the primary db node.
+func (c *Contexts) SetReadAfterWrite() {
+	c.Kit.Ctx, c.Kit.Header = util.SetReadAfterWrite(c.Kit.Ctx, c.Kit.Header)
+}
+
 // NewKit 产生一个新的kit， 一般用于在创建新的协程的时候，这个时候会对header 做处理，删除不必要的http header。
 func (kit *Kit) NewKit() *Kit {
 	newHeader := util.CCHeader(kit.Header)
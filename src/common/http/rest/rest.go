@@ -16,6 +16,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/errors"
@@ -29,6 +32,10 @@ type Action struct {
 	Verb    string
 	Path    string
 	Handler func(contexts *Contexts)
+	// Request and Response are the optional samples of the request body and the response data, their types
+	// are used to describe the action in the OpenAPI document.
+	Request  interface{}
+	Response interface{}
 }
 
 type RestfulConfig struct {
@@ -81,23 +88,40 @@ func (r *RestUtility) AddHandler(action Action) {
 func (r *RestUtility) AddToRestfulWebService(ws *restful.WebService) {
 
 	for _, action := range r.actions {
+		var builder *restful.RouteBuilder
 		switch action.Verb {
 		case http.MethodPost:
-			ws.Route(ws.POST(action.Path).To(r.wrapperAction(action)))
+			builder = ws.POST(action.Path)
 		case http.MethodDelete:
-			ws.Route(ws.DELETE(action.Path).To(r.wrapperAction(action)))
+			builder = ws.DELETE(action.Path)
 		case http.MethodPut:
-			ws.Route(ws.PUT(action.Path).To(r.wrapperAction(action)))
+			builder = ws.PUT(action.Path)
 		case http.MethodGet:
-			ws.Route(ws.GET(action.Path).To(r.wrapperAction(action)))
+			builder = ws.GET(action.Path)
 		default:
 			panic(fmt.Sprintf("rest utility add handler to webservice, but got unsupport verb: %s .", action.Verb))
 		}
 
+		builder.To(r.wrapperAction(action)).Operation(handlerName(action.Handler))
+		if action.Request != nil {
+			builder.Reads(action.Request)
+		}
+		if action.Response != nil {
+			builder.Writes(action.Response)
+		}
+		ws.Route(builder)
 	}
 	return
 }
 
+// handlerName returns the function name of the handler, like ListSubscriptions, it's used as the operation
+// name of the route because the handlers are wrapped.
+func handlerName(handler func(contexts *Contexts)) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
 func (r *RestUtility) wrapperAction(action Action) func(req *restful.Request, resp *restful.Response) {
 	return func(req *restful.Request, resp *restful.Response) {
 		restContexts := new(Contexts)
@@ -113,6 +137,8 @@ func (r *RestUtility) wrapperAction(action Action) func(req *restful.Request, re
 		ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
 		ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
 		ctx = context.WithValue(ctx, common.ContextRequestOwnerField, owner)
+		ctx = context.WithValue(ctx, common.ContextRequestCallerField, header.Get(common.BKHTTPCallerModule))
+		ctx = context.WithValue(ctx, common.ContextRequestAppCodeField, header.Get(common.BKHTTPRequestAppCode))
 		if txnID := header.Get(common.TransactionIdHeader); len(txnID) != 0 {
 			// we got a request with transaction info, which is only useful for coreservice.
 			ctx = context.WithValue(ctx, common.TransactionIdHeader, txnID)
@@ -122,6 +148,9 @@ func (r *RestUtility) wrapperAction(action Action) func(req *restful.Request, re
 			ctx = util.SetDBReadPreference(ctx, mode)
 			header = util.SetHTTPReadPreference(header, mode)
 		}
+		if util.IsHTTPReadAfterWrite(header) {
+			ctx, header = util.SetReadAfterWrite(ctx, header)
+		}
 
 		restContexts.Kit = &Kit{
 			Header:          header,
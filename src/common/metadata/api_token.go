@@ -0,0 +1,137 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// APITokenPrefix is the prefix of the api tokens, it makes the tokens easy to be recognized
+const APITokenPrefix = "cmdb_"
+
+// APITokenAppCodePrefix is the prefix of the app code of the requests authenticated by an api token,
+// the app code is recorded in the audit logs.
+const APITokenAppCodePrefix = "api_token:"
+
+// APITokenMaxValidDays is the max valid days of an api token
+const APITokenMaxValidDays = 366
+
+// APITokenScope limits the requests that an api token can be used for, the limits are all applied.
+type APITokenScope struct {
+	// ReadOnly token can only be used for the requests that do not change any resources
+	ReadOnly bool `field:"read_only" json:"read_only" bson:"read_only"`
+	// BizIDs is the businesses that the token can access, the token can only be used for the resources
+	// belongs to these businesses if it is set.
+	BizIDs []int64 `field:"bk_biz_ids" json:"bk_biz_ids,omitempty" bson:"bk_biz_ids"`
+	// ObjectIDs is the models that the token can access, the token can only be used for these models and
+	// their instances if it is set.
+	ObjectIDs []string `field:"bk_obj_ids" json:"bk_obj_ids,omitempty" bson:"bk_obj_ids"`
+	// ModelIDs is the ids of the models of ObjectIDs, it is filled when the token is saved.
+	ModelIDs []int64 `field:"model_ids" json:"model_ids,omitempty" bson:"model_ids"`
+}
+
+// APIToken is a personal credential to call the cmdb api as the user who creates it.
+// only the sha256 hash of the token is saved, the token itself is returned once when it is created.
+type APIToken struct {
+	ID          int64  `field:"id" json:"id" bson:"id"`
+	Name        string `field:"name" json:"name" bson:"name"`
+	Description string `field:"description" json:"description" bson:"description"`
+	User        string `field:"user" json:"user" bson:"user"`
+	TokenHash   string `field:"token_hash" json:"-" bson:"token_hash"`
+	// TokenHint is the beginning of the token, used to recognize the token
+	TokenHint       string        `field:"token_hint" json:"token_hint" bson:"token_hint"`
+	Scope           APITokenScope `field:"scope" json:"scope" bson:"scope"`
+	ExpireTime      time.Time     `field:"expire_time" json:"expire_time" bson:"expire_time"`
+	Revoked         bool          `field:"revoked" json:"revoked" bson:"revoked"`
+	RevokeTime      *time.Time    `field:"revoke_time" json:"revoke_time,omitempty" bson:"revoke_time"`
+	LastUsedTime    *time.Time    `field:"last_used_time" json:"last_used_time,omitempty" bson:"last_used_time"`
+	CreateTime      time.Time     `field:"create_time" json:"create_time" bson:"create_time"`
+	SupplierAccount string        `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// CreateAPITokenOption is the option to create an api token of the request user
+type CreateAPITokenOption struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	Scope       APITokenScope `json:"scope"`
+	// ValidDays is how many days the token is valid, it can not exceed APITokenMaxValidDays.
+	ValidDays int `json:"valid_days"`
+}
+
+// Validate check the option, returns the invalid field name
+func (o *CreateAPITokenOption) Validate() string {
+	if len(o.Name) == 0 {
+		return "name"
+	}
+	if o.ValidDays <= 0 || o.ValidDays > APITokenMaxValidDays {
+		return "valid_days"
+	}
+	for _, bizID := range o.Scope.BizIDs {
+		if bizID <= 0 {
+			return "scope.bk_biz_ids"
+		}
+	}
+	for _, objID := range o.Scope.ObjectIDs {
+		if len(objID) == 0 {
+			return "scope.bk_obj_ids"
+		}
+	}
+	return ""
+}
+
+// SaveAPITokenOption is the option to save an api token to core service, the token is generated by api server
+// and only its hash is saved.
+type SaveAPITokenOption struct {
+	CreateAPITokenOption `json:",inline"`
+	TokenHash            string `json:"token_hash"`
+	TokenHint            string `json:"token_hint"`
+}
+
+// CreatedAPIToken is the created api token with the token itself, the token can not be got again.
+type CreatedAPIToken struct {
+	APIToken `json:",inline"`
+	Token    string `json:"token"`
+}
+
+// ListAPITokensOption list the api tokens of the request user
+type ListAPITokensOption struct {
+	IDs []int64 `json:"ids,omitempty"`
+	// WithRevoked also returns the revoked tokens
+	WithRevoked bool     `json:"with_revoked"`
+	Page        BasePage `json:"page"`
+}
+
+// RevokeAPITokensOption revoke the api tokens of the request user
+type RevokeAPITokensOption struct {
+	IDs []int64 `json:"ids"`
+}
+
+// VerifyAPITokenOption verify the api token with its hash, it's used by api server
+type VerifyAPITokenOption struct {
+	TokenHash string `json:"token_hash"`
+}
+
+type OneAPITokenResult struct {
+	BaseResp `json:",inline"`
+	Data     APIToken `json:"data"`
+}
+
+type MultipleAPIToken struct {
+	Count uint64     `json:"count"`
+	Info  []APIToken `json:"info"`
+}
+
+type MultipleAPITokenResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleAPIToken `json:"data"`
+}
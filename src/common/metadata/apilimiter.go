@@ -19,6 +19,26 @@ import (
 	"configcenter/src/common/util"
 )
 
+// the algorithms of the api limiter rules, the counters are saved in redis and shared by all the api servers
+const (
+	// LimiterFixedWindow allows limit requests in each ttl seconds window, it's the default algorithm
+	LimiterFixedWindow = "fixed_window"
+	// LimiterSlidingWindow allows limit requests in any ttl seconds, the count of the previous window
+	// is weighted by its overlap with the sliding window
+	LimiterSlidingWindow = "sliding_window"
+	// LimiterTokenBucket allows bursts of limit requests, the bucket is refilled with limit tokens in ttl seconds
+	LimiterTokenBucket = "token_bucket"
+)
+
+// the request dimensions that a limiter rule can count the requests by, each value of the dimensions
+// has its own quota of the rule.
+const (
+	LimiterQuotaByAppCode = "appcode"
+	LimiterQuotaByUser    = "user"
+	LimiterQuotaByBiz     = "biz"
+	LimiterQuotaByIP      = "ip"
+)
+
 // LimiterRule is a rule for api limiter
 type LimiterRule struct {
 	RuleName string `json:"rulename"`
@@ -30,6 +50,19 @@ type LimiterRule struct {
 	Limit    int64  `json:"limit"`
 	TTL      int64  `json:"ttl"`
 	DenyAll  bool   `json:"denyall"`
+	// Algorithm is how the requests are limited, LimiterFixedWindow is used if it's not set
+	Algorithm string `json:"algorithm,omitempty"`
+	// QuotaBy is the dimensions that the requests are counted by, all the matched requests share one quota
+	// if it's not set.
+	QuotaBy []string `json:"quota_by,omitempty"`
+}
+
+// GetAlgorithm returns the algorithm of the rule
+func (r LimiterRule) GetAlgorithm() string {
+	if r.Algorithm == "" {
+		return LimiterFixedWindow
+	}
+	return r.Algorithm
 }
 
 // Verify to check the fields of LimiterRule
@@ -55,5 +88,35 @@ func (r LimiterRule) Verify() error {
 			return fmt.Errorf("both limit and ttl must be set and bigger than 0 when denyall is false")
 		}
 	}
+	switch r.GetAlgorithm() {
+	case LimiterFixedWindow, LimiterSlidingWindow, LimiterTokenBucket:
+	default:
+		return fmt.Errorf("algorithm must be one of %s,%s,%s", LimiterFixedWindow, LimiterSlidingWindow,
+			LimiterTokenBucket)
+	}
+	for _, dimension := range r.QuotaBy {
+		switch dimension {
+		case LimiterQuotaByAppCode, LimiterQuotaByUser, LimiterQuotaByBiz, LimiterQuotaByIP:
+		default:
+			return fmt.Errorf("quota_by must be some of %s,%s,%s,%s", LimiterQuotaByAppCode, LimiterQuotaByUser,
+				LimiterQuotaByBiz, LimiterQuotaByIP)
+		}
+	}
 	return nil
 }
+
+// LimiterQuotaUsage is the current consumption of a quota of a limiter rule
+type LimiterQuotaUsage struct {
+	// QuotaKey is the values of the quota dimensions of the rule, it's empty if the rule has no dimension
+	QuotaKey  string `json:"quota_key"`
+	Used      int64  `json:"used"`
+	Remaining int64  `json:"remaining"`
+	// ResetSeconds is how many seconds later the quota is fully available again
+	ResetSeconds int64 `json:"reset_seconds"`
+}
+
+// LimiterRuleUsage is the current consumption of the quotas of a limiter rule
+type LimiterRuleUsage struct {
+	Rule   LimiterRule         `json:"rule"`
+	Quotas []LimiterQuotaUsage `json:"quotas"`
+}
@@ -150,6 +150,10 @@ func (attribute *Attribute) Validate(ctx context.Context, data interface{}, key
 		rawError = attribute.validList(ctx, data, key)
 	case common.FieldTypeOrganization:
 		rawError = attribute.validOrganization(ctx, data, key)
+	case common.FieldTypeCalculated:
+		rawError = attribute.validCalculated(ctx, data, key)
+	case common.FieldTypeInstRef:
+		rawError = attribute.validInstRef(ctx, data, key)
 	case "foreignkey", "singleasst", "multiasst":
 		// TODO what validation should do on these types
 	case common.FieldTypeTable:
@@ -735,6 +739,70 @@ func (attribute *Attribute) validOrganization(ctx context.Context, val interface
 	return errors.RawErrorInfo{}
 }
 
+// validCalculated valid object attribute that is calculated type, the value is computed by the server,
+// so it can only be a number or null.
+func (attribute *Attribute) validCalculated(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	if nil == val {
+		return errors.RawErrorInfo{}
+	}
+
+	if !util.IsNumeric(val) {
+		blog.Errorf("params should be numeric, but its type is %T, rid: %s", val, util.ExtractRequestIDFromContext(ctx))
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedFloat,
+			Args:    []interface{}{key},
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// validInstRef valid object attribute that is inst_ref type, the value is the referenced instance's id,
+// or an array of the referenced instances' ids if the attribute can reference multiple instances.
+// whether the referenced instances exist is checked by the core service.
+func (attribute *Attribute) validInstRef(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	option, err := ParseInstRefOption(attribute.Option)
+	if err != nil {
+		blog.Errorf("parse inst_ref option %#v failed, err: %v, rid: %s", attribute.Option, err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{key},
+		}
+	}
+
+	instIDs, err := GetInstRefIDs(val)
+	if err != nil {
+		blog.Errorf("params %s value %#v is invalid, err: %v, rid: %s", key, val, err, rid)
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsNeedInt,
+			Args:    []interface{}{key},
+		}
+	}
+
+	if len(instIDs) == 0 {
+		if attribute.IsRequired {
+			blog.Errorf("params %s can not be empty, rid: %s", key, rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedSet,
+				Args:    []interface{}{key},
+			}
+		}
+		return errors.RawErrorInfo{}
+	}
+
+	if !option.Multiple {
+		switch val.(type) {
+		case []interface{}, bson.A, []int64:
+			blog.Errorf("params %s can only reference one instance, value: %#v, rid: %s", key, val, rid)
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsNeedInt,
+				Args:    []interface{}{key},
+			}
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
 // validTable valid object attribute that is bool type
 func (attribute *Attribute) validTable(ctx context.Context, val interface{}, key string) (rawError errors.RawErrorInfo) {
 	// rid := util.ExtractRequestIDFromContext(ctx)
@@ -799,6 +867,77 @@ func ParseIntOption(ctx context.Context, val interface{}) IntOption {
 // EnumOption enum option
 type EnumOption []EnumVal
 
+// InstRefOption inst_ref attribute's option
+type InstRefOption struct {
+	// ObjID the model whose instances are referenced
+	ObjID string `bson:"bk_obj_id" json:"bk_obj_id"`
+	// Multiple whether the attribute can reference multiple instances
+	Multiple bool `bson:"multiple" json:"multiple"`
+	// OnDelete the action taken when the referenced instance is deleted, restrict or cascade, default is restrict
+	OnDelete string `bson:"on_delete" json:"on_delete"`
+}
+
+// ParseInstRefOption parse inst_ref attribute's option
+func ParseInstRefOption(val interface{}) (*InstRefOption, error) {
+	var option map[string]interface{}
+	switch opt := val.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(opt), &option); err != nil {
+			return nil, err
+		}
+	case map[string]interface{}:
+		option = opt
+	case mapstr.MapStr:
+		option = opt
+	case bson.M:
+		option = opt
+	case bson.D:
+		option = opt.Map()
+	default:
+		return nil, fmt.Errorf("unknow inst_ref option type: %T", val)
+	}
+
+	instRefOption := &InstRefOption{
+		ObjID:    getString(option[common.BKObjIDField]),
+		Multiple: getBool(option["multiple"]),
+		OnDelete: getString(option["on_delete"]),
+	}
+	if instRefOption.ObjID == "" {
+		return nil, fmt.Errorf("inst_ref option %s is not set", common.BKObjIDField)
+	}
+
+	switch instRefOption.OnDelete {
+	case "":
+		instRefOption.OnDelete = common.InstRefOnDeleteRestrict
+	case common.InstRefOnDeleteRestrict, common.InstRefOnDeleteCascade:
+	default:
+		return nil, fmt.Errorf("inst_ref option on_delete %s is invalid", instRefOption.OnDelete)
+	}
+	return instRefOption, nil
+}
+
+// GetInstRefIDs get the referenced instance ids from the inst_ref attribute's value
+func GetInstRefIDs(val interface{}) ([]int64, error) {
+	if val == nil || val == "" {
+		return make([]int64, 0), nil
+	}
+
+	switch value := val.(type) {
+	case []int64:
+		return value, nil
+	case []interface{}:
+		return util.SliceInterfaceToInt64(value)
+	case bson.A:
+		return util.SliceInterfaceToInt64(value)
+	}
+
+	id, err := util.GetInt64ByInterface(val)
+	if err != nil {
+		return nil, err
+	}
+	return []int64{id}, nil
+}
+
 // IntOption integer option
 type IntOption struct {
 	Min string `bson:"min" json:"min"`
@@ -1043,6 +1182,22 @@ func (attribute Attribute) PrettyValue(ctx context.Context, val interface{}) (st
 			}
 		}
 		return "", fmt.Errorf("invalid value for list, value: %s, options: %+v", strVal, listOption)
+	case common.FieldTypeCalculated:
+		value, err := util.GetFloat64ByInterface(val)
+		if nil != err {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v, err: %+v", fieldType, val, err)
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case common.FieldTypeInstRef:
+		instIDs, err := GetInstRefIDs(val)
+		if nil != err {
+			return "", fmt.Errorf("invalid value type for %s, value: %+v, err: %+v", fieldType, val, err)
+		}
+		ids := make([]string, len(instIDs))
+		for idx, instID := range instIDs {
+			ids[idx] = strconv.FormatInt(instID, 10)
+		}
+		return strings.Join(ids, ","), nil
 	default:
 		blog.V(3).Infof("unexpected property type: %s", fieldType)
 		return fmt.Sprintf("%#v", val), nil
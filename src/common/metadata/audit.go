@@ -36,6 +36,10 @@ type CreateAuditLogParam struct {
 type AuditQueryInput struct {
 	Condition AuditQueryCondition `json:"condition"`
 	Page      BasePage            `json:"page,omitempty"`
+	// LastID is the id of the last audit log of the previous page, when it is set, the audit logs are paged
+	// by the id greater than it in ascending order instead of skipping the page start, which is used to
+	// walk through all the audit logs, e.g. to export them.
+	LastID int64 `json:"last_id,omitempty"`
 }
 
 // Validate validates the input param
@@ -53,6 +57,13 @@ func (input *AuditQueryInput) Validate() errors.RawErrorInfo {
 		}
 	}
 
+	if input.LastID < 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"last_id"},
+		}
+	}
+
 	if len(input.Condition.OperationTime.Start) == 0 && len(input.Condition.OperationTime.End) == 0 {
 		return errors.RawErrorInfo{
 			ErrCode: common.CCErrCommParamsNeedSet,
@@ -453,6 +464,9 @@ const (
 
 	// DynamicGroupType is dynamic grouping audit type.
 	DynamicGroupType AuditType = "dynamic_grouping"
+
+	// TenantType represent the tenant lifecycle operation audit, such as creating, disabling and purging a tenant.
+	TenantType AuditType = "tenant"
 )
 
 type ResourceType string
@@ -495,6 +509,8 @@ const (
 	HostRes ResourceType = "host"
 
 	ResourceDirRes ResourceType = "resource_directory"
+
+	TenantRes ResourceType = "tenant"
 )
 
 type OperateFromType string
@@ -595,7 +611,7 @@ func GetAuditTypesByCategory(category string) []AuditType {
 	case "host":
 		return []AuditType{HostType}
 	case "other":
-		return []AuditType{ModelType, AssociationKindType, EventPushType, DynamicGroupType}
+		return []AuditType{ModelType, AssociationKindType, EventPushType, DynamicGroupType, TenantType}
 	}
 	return []AuditType{}
 }
@@ -763,6 +779,15 @@ var auditDict = []resourceTypeInfo{
 			actionInfoMap[AuditDelete],
 		},
 	},
+	{
+		ID:   TenantRes,
+		Name: "租户",
+		Operations: []actionTypeInfo{
+			actionInfoMap[AuditCreate],
+			actionInfoMap[AuditUpdate],
+			actionInfoMap[AuditDelete],
+		},
+	},
 }
 
 var actionInfoMap = map[ActionType]actionTypeInfo{
@@ -0,0 +1,258 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+// AuthRoleAllActions is the role action that grants all the iam actions
+const AuthRoleAllActions = "*"
+
+// AuthSubjectType is the type of the subject that a role is bound to
+type AuthSubjectType string
+
+const (
+	AuthSubjectUser  AuthSubjectType = "user"
+	AuthSubjectGroup AuthSubjectType = "group"
+)
+
+// AuthScopeType is the range of resources that a role binding takes effect on
+type AuthScopeType string
+
+const (
+	// AuthScopeGlobal takes effect on all the resources
+	AuthScopeGlobal AuthScopeType = "global"
+	// AuthScopeBusiness takes effect on the business and the resources belongs to it
+	AuthScopeBusiness AuthScopeType = "business"
+	// AuthScopeModel takes effect on the model and its attributes, instances etc.
+	AuthScopeModel AuthScopeType = "model"
+	// AuthScopeInstance takes effect on the specified instances of a resource type
+	AuthScopeInstance AuthScopeType = "instance"
+)
+
+// AuthRole is a named set of iam actions, the actions are the ones defined in ac/iam/initial_actions.go
+type AuthRole struct {
+	ID              int64     `field:"id" json:"id" bson:"id"`
+	Name            string    `field:"name" json:"name" bson:"name"`
+	Description     string    `field:"description" json:"description" bson:"description"`
+	Actions         []string  `field:"actions" json:"actions" bson:"actions"`
+	Creator         string    `field:"creator" json:"creator" bson:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// Validate check the role, returns the invalid field name
+func (r *AuthRole) Validate() string {
+	if len(r.Name) == 0 {
+		return "name"
+	}
+	if len(r.Actions) == 0 {
+		return "actions"
+	}
+	for _, action := range r.Actions {
+		if len(action) == 0 {
+			return "actions"
+		}
+	}
+	return ""
+}
+
+// AuthScope describes which resources a role binding takes effect on.
+// ObjectID is used for model scope and optionally instance scope, ModelID is the id of the model
+// with this ObjectID, it is filled when the binding is saved.
+type AuthScope struct {
+	Type         AuthScopeType `field:"type" json:"type" bson:"type"`
+	BizID        int64         `field:"bk_biz_id" json:"bk_biz_id,omitempty" bson:"bk_biz_id"`
+	ObjectID     string        `field:"bk_obj_id" json:"bk_obj_id,omitempty" bson:"bk_obj_id"`
+	ModelID      int64         `field:"model_id" json:"model_id,omitempty" bson:"model_id"`
+	ResourceType string        `field:"resource_type" json:"resource_type,omitempty" bson:"resource_type"`
+	InstanceIDs  []int64       `field:"instance_ids" json:"instance_ids,omitempty" bson:"instance_ids"`
+}
+
+// Validate check the scope, returns the invalid field name
+func (s *AuthScope) Validate() string {
+	switch s.Type {
+	case AuthScopeGlobal:
+	case AuthScopeBusiness:
+		if s.BizID <= 0 {
+			return "scope.bk_biz_id"
+		}
+	case AuthScopeModel:
+		if len(s.ObjectID) == 0 {
+			return "scope.bk_obj_id"
+		}
+	case AuthScopeInstance:
+		if len(s.ResourceType) == 0 {
+			return "scope.resource_type"
+		}
+		if len(s.InstanceIDs) == 0 {
+			return "scope.instance_ids"
+		}
+	default:
+		return "scope.type"
+	}
+	return ""
+}
+
+// AuthRoleBinding grants a role to a user or a user group within a scope
+type AuthRoleBinding struct {
+	ID              int64           `field:"id" json:"id" bson:"id"`
+	RoleID          int64           `field:"role_id" json:"role_id" bson:"role_id"`
+	SubjectType     AuthSubjectType `field:"subject_type" json:"subject_type" bson:"subject_type"`
+	Subject         string          `field:"subject" json:"subject" bson:"subject"`
+	Scope           AuthScope       `field:"scope" json:"scope" bson:"scope"`
+	Creator         string          `field:"creator" json:"creator" bson:"creator"`
+	CreateTime      time.Time       `field:"create_time" json:"create_time" bson:"create_time"`
+	SupplierAccount string          `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// Validate check the role binding, returns the invalid field name
+func (b *AuthRoleBinding) Validate() string {
+	if b.RoleID <= 0 {
+		return "role_id"
+	}
+	if b.SubjectType != AuthSubjectUser && b.SubjectType != AuthSubjectGroup {
+		return "subject_type"
+	}
+	if len(b.Subject) == 0 {
+		return "subject"
+	}
+	return b.Scope.Validate()
+}
+
+// AuthUserGroup is a named set of users that roles can be bound to
+type AuthUserGroup struct {
+	ID              int64     `field:"id" json:"id" bson:"id"`
+	Name            string    `field:"name" json:"name" bson:"name"`
+	Description     string    `field:"description" json:"description" bson:"description"`
+	Members         []string  `field:"members" json:"members" bson:"members"`
+	Creator         string    `field:"creator" json:"creator" bson:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// Validate check the user group, returns the invalid field name
+func (g *AuthUserGroup) Validate() string {
+	if len(g.Name) == 0 {
+		return "name"
+	}
+	return ""
+}
+
+type ListAuthRolesOption struct {
+	IDs   []int64  `json:"ids,omitempty"`
+	Names []string `json:"names,omitempty"`
+	Page  BasePage `json:"page"`
+}
+
+type ListAuthRoleBindingsOption struct {
+	IDs      []int64  `json:"ids,omitempty"`
+	RoleIDs  []int64  `json:"role_ids,omitempty"`
+	Subjects []string `json:"subjects,omitempty"`
+	Page     BasePage `json:"page"`
+}
+
+type ListAuthUserGroupsOption struct {
+	IDs     []int64  `json:"ids,omitempty"`
+	Names   []string `json:"names,omitempty"`
+	Members []string `json:"members,omitempty"`
+	Page    BasePage `json:"page"`
+}
+
+// AuthUserGroupMembersOption is the option to add or remove members of the user groups with the names,
+// the members are changed atomically so that the concurrent changes of the same group are not overwritten.
+type AuthUserGroupMembersOption struct {
+	Names   []string `json:"names"`
+	Members []string `json:"members"`
+}
+
+// Validate check the option, returns the invalid field name
+func (o *AuthUserGroupMembersOption) Validate() string {
+	if len(o.Names) == 0 {
+		return "names"
+	}
+	if len(o.Members) == 0 {
+		return "members"
+	}
+	return ""
+}
+
+type DeleteAuthRBACOption struct {
+	IDs []int64 `json:"ids"`
+}
+
+type GetAuthPoliciesOption struct {
+	UserName string `json:"user_name"`
+}
+
+// AuthPolicy is the actions a user can do within a scope, it is resolved from the user's role bindings
+// and the role bindings of the user groups that the user belongs to.
+type AuthPolicy struct {
+	Actions []string  `json:"actions"`
+	Scope   AuthScope `json:"scope"`
+}
+
+type OneAuthRoleResult struct {
+	BaseResp `json:",inline"`
+	Data     AuthRole `json:"data"`
+}
+
+type MultipleAuthRole struct {
+	Count uint64     `json:"count"`
+	Info  []AuthRole `json:"info"`
+}
+
+type MultipleAuthRoleResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleAuthRole `json:"data"`
+}
+
+type OneAuthRoleBindingResult struct {
+	BaseResp `json:",inline"`
+	Data     AuthRoleBinding `json:"data"`
+}
+
+type MultipleAuthRoleBinding struct {
+	Count uint64            `json:"count"`
+	Info  []AuthRoleBinding `json:"info"`
+}
+
+type MultipleAuthRoleBindingResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleAuthRoleBinding `json:"data"`
+}
+
+type OneAuthUserGroupResult struct {
+	BaseResp `json:",inline"`
+	Data     AuthUserGroup `json:"data"`
+}
+
+type MultipleAuthUserGroup struct {
+	Count uint64          `json:"count"`
+	Info  []AuthUserGroup `json:"info"`
+}
+
+type MultipleAuthUserGroupResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleAuthUserGroup `json:"data"`
+}
+
+type AuthPoliciesResult struct {
+	BaseResp `json:",inline"`
+	Data     []AuthPolicy `json:"data"`
+}
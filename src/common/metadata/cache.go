@@ -12,7 +12,13 @@
 
 package metadata
 
-import "configcenter/src/common/watch"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"configcenter/src/common/watch"
+)
 
 type SearchHostWithInnerIPOption struct {
 	InnerIP string `json:"bk_host_innerip"`
@@ -34,6 +40,44 @@ type ListWithIDOption struct {
 	Fields []string `json:"fields"`
 }
 
+type SearchInstWithIDOption struct {
+	ObjID  string `json:"bk_obj_id"`
+	InstID int64  `json:"bk_inst_id"`
+	// only return these fields in instance.
+	Fields []string `json:"fields"`
+}
+
+type ListInstWithIDOption struct {
+	ObjID string `json:"bk_obj_id"`
+	// length range is [1,500]
+	IDs []int64 `json:"ids"`
+	// only return these fields in instances.
+	Fields []string `json:"fields"`
+}
+
+// SearchInstWithUniqueOption search an instance with the values of it's unique fields,
+// these fields and values must be able to identify one instance only.
+type SearchInstWithUniqueOption struct {
+	ObjID  string                 `json:"bk_obj_id"`
+	Unique map[string]interface{} `json:"unique"`
+	// only return these fields in instance.
+	Fields []string `json:"fields"`
+}
+
+type SearchInstAsstWithIDOption struct {
+	ID int64 `json:"id"`
+	// only return these fields in instance association.
+	Fields []string `json:"fields"`
+}
+
+type SearchInstAsstWithUniqueOption struct {
+	ObjAsstID  string `json:"bk_obj_asst_id"`
+	InstID     int64  `json:"bk_inst_id"`
+	AsstInstID int64  `json:"bk_asst_inst_id"`
+	// only return these fields in instance association.
+	Fields []string `json:"fields"`
+}
+
 type DeleteArchive struct {
 	Oid    string      `json:"oid" bson:"oid"`
 	Coll   string      `json:"coll" bson:"coll"`
@@ -96,3 +140,123 @@ type WatchEventResp struct {
 	BaseResp `json:",inline"`
 	Data     *watch.WatchResp `json:"data"`
 }
+
+// CacheResource is the resource type which is cached in cache service and can be verified or rebuilt.
+type CacheResource string
+
+const (
+	CacheResourceHost CacheResource = "host"
+	CacheResourceBiz  CacheResource = "biz"
+)
+
+// VerifyCacheOption is the option to verify a resource's cache with the data in mongodb.
+type VerifyCacheOption struct {
+	Resource CacheResource `json:"resource"`
+	// FullScan scan all the resources in mongodb and all the cached keys in redis,
+	// otherwise only SampleSize resources in mongodb is randomly sampled to verify.
+	FullScan bool `json:"full_scan"`
+	// SampleSize is the sample count when it's not full scan, range is [1,1000], default is 200.
+	SampleSize int64 `json:"sample_size"`
+	// Repair repairs the drifted keys when it's true.
+	Repair bool `json:"repair"`
+}
+
+// Validate validate verify cache option, and set the default sample size.
+func (v *VerifyCacheOption) Validate() error {
+	if err := v.Resource.Validate(); err != nil {
+		return err
+	}
+
+	if v.FullScan {
+		return nil
+	}
+
+	if v.SampleSize == 0 {
+		v.SampleSize = 200
+	}
+
+	if v.SampleSize < 0 || v.SampleSize > 1000 {
+		return errors.New("sample_size should be in range [1,1000]")
+	}
+	return nil
+}
+
+// Validate check whether the cache resource is supported to verify and rebuild.
+func (c CacheResource) Validate() error {
+	switch c {
+	case CacheResourceHost, CacheResourceBiz:
+		return nil
+	default:
+		return fmt.Errorf("unsupported cache resource: %s", c)
+	}
+}
+
+// RebuildCacheOption is the option to drop a resource's cache and rebuild it with the data in mongodb.
+type RebuildCacheOption struct {
+	Resource CacheResource `json:"resource"`
+}
+
+// VerifyCacheResult is the result of verifying or rebuilding a resource's cache.
+type VerifyCacheResult struct {
+	Resource CacheResource `json:"resource"`
+	// Checked is the count of resources which has been checked.
+	Checked int64 `json:"checked"`
+	// Mismatched is the count of the cached keys whose value is not same with mongodb.
+	Mismatched int64 `json:"mismatched"`
+	// Stale is the count of the cached keys whose resource is not exist in mongodb any more.
+	Stale int64 `json:"stale"`
+	// Repaired is the count of drifted keys which has been repaired.
+	Repaired int64 `json:"repaired"`
+	// Cost is the time cost of this verify in milliseconds.
+	Cost int64 `json:"cost"`
+}
+
+type VerifyCacheResponse struct {
+	BaseResp `json:",inline"`
+	Data     *VerifyCacheResult `json:"data"`
+}
+
+// HostSecondaryKey is a host attribute's value which is used as a secondary key to find hosts in cache.
+type HostSecondaryKey struct {
+	// Field is the host attribute, which must be configured as a secondary key in cache service.
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// ListHostWithSecondaryKeysOption find hosts with many secondary keys at once.
+type ListHostWithSecondaryKeysOption struct {
+	// length range is [1,500]
+	Keys []HostSecondaryKey `json:"keys"`
+	// only return these fields in hosts.
+	Fields []string `json:"fields"`
+}
+
+// Validate validate the secondary keys' length and values.
+func (l *ListHostWithSecondaryKeysOption) Validate() error {
+	if len(l.Keys) == 0 {
+		return errors.New("keys is empty")
+	}
+
+	if len(l.Keys) > 500 {
+		return errors.New("keys length is over limit 500")
+	}
+
+	for _, key := range l.Keys {
+		if len(key.Field) == 0 || len(key.Value) == 0 {
+			return errors.New("key's field and value can not be empty")
+		}
+	}
+	return nil
+}
+
+// HostWithSecondaryKey is the hosts found with a secondary key, a key may match more than one hosts.
+type HostWithSecondaryKey struct {
+	HostSecondaryKey `json:",inline"`
+	Hosts            []json.RawMessage `json:"hosts"`
+}
+
+// ListHostWithSecondaryKeysResult is the response of list hosts with secondary keys.
+type ListHostWithSecondaryKeysResult struct {
+	BaseResp `json:",inline"`
+	Data     []HostWithSecondaryKey `json:"data"`
+}
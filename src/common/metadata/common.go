@@ -122,6 +122,8 @@ type QueryInput struct {
 	Limit          int            `json:"limit,omitempty"`
 	Sort           string         `json:"sort,omitempty"`
 	DisableCounter bool           `json:"disable_counter,omitempty"`
+	// ExpandInstRef expand the inst_ref attributes' values with the referenced instances' names
+	ExpandInstRef bool `json:"expand_inst_ref,omitempty"`
 }
 
 type TimeConditionItem struct {
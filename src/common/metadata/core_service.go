@@ -95,6 +95,21 @@ type CreateManyModelInstance struct {
 	Datas []mapstr.MapStr `json:"datas"`
 }
 
+// RecalculateModelInstance recalculate the calculated attributes' values of the model instances.
+type RecalculateModelInstance struct {
+	// InstIDs the instances to be recalculated, all instances of the model are recalculated if not set.
+	InstIDs []int64 `json:"bk_inst_ids"`
+}
+
+// RecalculateModelInstanceTask is the background task to recalculate the calculated attributes of the model
+// instances, it recalculates the instances in InstIDs, or the page of instances whose ids are greater than AfterID
+// if InstIDs is not set, and the next page is handed over to a new task.
+type RecalculateModelInstanceTask struct {
+	ObjID   string  `json:"bk_obj_id"`
+	InstIDs []int64 `json:"bk_inst_ids"`
+	AfterID int64   `json:"after_id"`
+}
+
 type SetModelInstance CreateModelInstance
 type SetManyModelInstance CreateManyModelInstance
 
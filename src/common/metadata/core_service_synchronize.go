@@ -95,6 +95,10 @@ type SynchronizeFindInfoParameter struct {
 	Condition    mapstr.MapStr              `json:"condition"`
 	Start        uint64                     `json:"start"`
 	Limit        uint64                     `json:"limit"`
+	// LastID is the id of the last data of the previous page, when it is set, the data is paged by the id
+	// field which is greater than it instead of skipping start data, so that a deep page is as cheap as the
+	// first one. only the instance and model data supports it.
+	LastID uint64 `json:"last_id"`
 }
 
 // SynchronizeResult synchronize result
@@ -173,7 +173,7 @@ func getAttributeType(attributeType string) (string, error) {
 	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeTime,
 		common.FieldTypeTimeZone, common.FieldTypeUser, common.FieldTypeList, common.FieldTypeOrganization:
 		return stringType, nil
-	case common.FieldTypeInt, common.FieldTypeFloat:
+	case common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeCalculated, common.FieldTypeInstRef:
 		return numericType, nil
 	case common.FieldTypeBool:
 		return boolType, nil
@@ -16,18 +16,24 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"configcenter/src/common/querybuilder"
 	"configcenter/src/common/watch"
+
+	"go.mongodb.org/mongo-driver/bson"
 )
 
 type RspSubscriptionCreate struct {
 	BaseResp `json:",inline"`
-	Data     struct {
-		SubscriptionID int64 `json:"subscription_id"`
-	} `json:"data"`
+	Data     SubscriptionCreateResult `json:"data"`
+}
+
+type SubscriptionCreateResult struct {
+	SubscriptionID int64 `json:"subscription_id"`
 }
 
 type ParamSubscriptionSearch struct {
@@ -69,8 +75,42 @@ type Subscription struct {
 	OwnerID          string      `bson:"bk_supplier_account" json:"bk_supplier_account"`
 	LastTime         Time        `bson:"last_time" json:"last_time"`
 	Statistics       *Statistics `bson:"-" json:"statistics"`
+	// Secret is used to sign the callback requests with HMAC-SHA256, it's never returned once it's set,
+	// the secret is kept if it's empty when the subscription is updated, unless ClearSecret is set.
+	Secret    string `bson:"secret" json:"secret,omitempty"`
+	HasSecret bool   `bson:"-" json:"has_secret"`
+	// ClearSecret removes the secret of the subscription when it's updated
+	ClearSecret bool `bson:"-" json:"clear_secret,omitempty"`
+	// RetryTimes is how many times a failed callback is retried before the events are put into the
+	// dead letters, 0 means no retry. DefaultSubscriptionRetryTimes is used if it's not set, and the old
+	// value is kept if it's not set when the subscription is updated.
+	RetryTimes *int64 `bson:"retry_times,omitempty" json:"retry_times,omitempty"`
+	// BatchSize is the max number of events sent in one callback, the callback body is an array of the
+	// events if it's bigger than 1, or one event as before.
+	BatchSize int64 `bson:"batch_size" json:"batch_size"`
+	// Filter selects the events that are sent to the subscriber in the subscribed event types,
+	// all the events of the event types are sent if it's not set.
+	Filter SubscriptionFilter `bson:"filter" json:"filter"`
 }
 
+const (
+	// DefaultSubscriptionRetryTimes is the default retry times of the failed callbacks
+	DefaultSubscriptionRetryTimes = 3
+	// MaxSubscriptionRetryTimes is the max retry times of the failed callbacks
+	MaxSubscriptionRetryTimes = 10
+	// MaxSubscriptionBatchSize is the max number of events sent in one callback
+	MaxSubscriptionBatchSize = 100
+)
+
+// the headers of the callback requests, the signature is the hex encoded HMAC-SHA256 of
+// "{timestamp}.{body}" with the subscription secret, it's set only if the subscription has a secret.
+const (
+	EventCallbackSignatureHeader = "X-Bkcmdb-Signature"
+	EventCallbackTimestampHeader = "X-Bkcmdb-Timestamp"
+	EventCallbackDeliveryHeader  = "X-Bkcmdb-Delivery"
+	EventCallbackSignaturePrefix = "sha256="
+)
+
 // Report define sending statistic
 type Statistics struct {
 	Total   int64 `json:"total"`
@@ -92,6 +132,10 @@ func (s Subscription) GetCacheKey() string {
 		ConfirmPattern:   s.ConfirmPattern,
 		SubscriptionForm: s.SubscriptionForm,
 		TimeOutSeconds:   s.TimeOutSeconds,
+		Secret:           s.Secret,
+		RetryTimes:       s.RetryTimes,
+		BatchSize:        s.BatchSize,
+		Filter:           s.Filter,
 	}
 	b, _ := json.Marshal(ns)
 	return string(b)
@@ -101,6 +145,143 @@ func (s Subscription) GetTimeout() time.Duration {
 	return time.Second * time.Duration(s.TimeOutSeconds)
 }
 
+// GetRetryTimes returns the retry times of the failed callbacks
+func (s Subscription) GetRetryTimes() int64 {
+	if s.RetryTimes == nil {
+		return DefaultSubscriptionRetryTimes
+	}
+	if *s.RetryTimes < 0 {
+		return 0
+	}
+	if *s.RetryTimes > MaxSubscriptionRetryTimes {
+		return MaxSubscriptionRetryTimes
+	}
+	return *s.RetryTimes
+}
+
+// GetBatchSize returns the max number of events sent in one callback
+func (s Subscription) GetBatchSize() int64 {
+	if s.BatchSize <= 1 {
+		return 1
+	}
+	if s.BatchSize > MaxSubscriptionBatchSize {
+		return MaxSubscriptionBatchSize
+	}
+	return s.BatchSize
+}
+
+// SubscriptionFilter is the filter of the events sent to a subscriber, the event is sent only if it matches
+// all the filter options.
+type SubscriptionFilter struct {
+	// BizIDs is the businesses that the events belong to, the business of a host is got from its relations.
+	// the events that do not belong to any business do not match it if it's set.
+	BizIDs []int64 `json:"bk_biz_ids,omitempty"`
+	// Condition is the querybuilder condition that the event data must match, it's checked against the
+	// current data of the event, or the previous data of the delete event.
+	Condition *querybuilder.QueryFilter `json:"condition,omitempty"`
+	// Fields is the fields of the event data sent to the subscriber, all the fields are sent if it's not set.
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Validate the subscription filter, returns the invalid field name
+func (f *SubscriptionFilter) Validate() (string, error) {
+	for _, bizID := range f.BizIDs {
+		if bizID <= 0 {
+			return "filter.bk_biz_ids", fmt.Errorf("invalid business id %d", bizID)
+		}
+	}
+
+	if f.Condition != nil && f.Condition.Rule != nil {
+		if key, err := f.Condition.Validate(); err != nil {
+			return "filter.condition." + key, err
+		}
+		if f.Condition.GetDeep() > querybuilder.MaxDeep {
+			return "filter.condition.rules", fmt.Errorf("exceed max query condition deepth: %d",
+				querybuilder.MaxDeep)
+		}
+	}
+
+	for _, field := range f.Fields {
+		if len(field) == 0 {
+			return "filter.fields", errors.New("field can not be empty")
+		}
+	}
+	return "", nil
+}
+
+// bsonSubscriptionFilter is the subscription filter saved in db, the condition is saved as json
+// because it's an interface.
+type bsonSubscriptionFilter struct {
+	BizIDs    []int64  `bson:"bk_biz_ids"`
+	Condition string   `bson:"condition"`
+	Fields    []string `bson:"fields"`
+}
+
+func (f SubscriptionFilter) MarshalBSON() ([]byte, error) {
+	filter := bsonSubscriptionFilter{
+		BizIDs: f.BizIDs,
+		Fields: f.Fields,
+	}
+	if f.Condition != nil && f.Condition.Rule != nil {
+		condition, err := json.Marshal(f.Condition.Rule)
+		if err != nil {
+			return nil, err
+		}
+		filter.Condition = string(condition)
+	}
+	return bson.Marshal(filter)
+}
+
+func (f *SubscriptionFilter) UnmarshalBSON(data []byte) error {
+	filter := bsonSubscriptionFilter{}
+	if err := bson.Unmarshal(data, &filter); err != nil {
+		return err
+	}
+	f.BizIDs = filter.BizIDs
+	f.Fields = filter.Fields
+	f.Condition = nil
+	if len(filter.Condition) != 0 {
+		condition := new(querybuilder.QueryFilter)
+		if err := json.Unmarshal([]byte(filter.Condition), condition); err != nil {
+			return err
+		}
+		f.Condition = condition
+	}
+	return nil
+}
+
+// DeadLetter is the events that are failed to be sent to the subscriber after all the retries,
+// they can be replayed after the subscriber is recovered.
+type DeadLetter struct {
+	ID             string     `json:"id"`
+	SubscriptionID int64      `json:"subscription_id"`
+	Events         []DistInst `json:"events"`
+	Error          string     `json:"error"`
+	Attempts       int64      `json:"attempts"`
+	FailTime       Time       `json:"fail_time"`
+}
+
+// ParamDeadLetterSearch is the option to list the dead letters of a subscription, the latest ones are first
+type ParamDeadLetterSearch struct {
+	Page BasePage `json:"page"`
+}
+
+type RspDeadLetterSearch struct {
+	Count uint64       `json:"count"`
+	Info  []DeadLetter `json:"info"`
+}
+
+// ParamDeadLetterReplay is the option to replay the dead letters of a subscription, all the dead letters
+// are replayed if All is true.
+type ParamDeadLetterReplay struct {
+	IDs []string `json:"ids"`
+	All bool     `json:"all"`
+}
+
+type RspDeadLetterReplay struct {
+	Replayed int64 `json:"replayed"`
+}
+
 type EventInst struct {
 	ID            int64       `json:"event_id,omitempty"`
 	EventType     string      `json:"event_type"`
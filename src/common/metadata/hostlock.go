@@ -13,13 +13,64 @@
 package metadata
 
 import (
+	"fmt"
 	"time"
 
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/mapstr"
 )
 
+// HostLockScope is the kind of host operations that a host lock blocks
+type HostLockScope string
+
+const (
+	// HostLockScopeTransfer blocks transferring the host between modules, businesses and resource directories
+	HostLockScopeTransfer HostLockScope = "transfer"
+	// HostLockScopeAttribute blocks updating the host's attributes, including host apply, cloud sync and snapshot
+	HostLockScopeAttribute HostLockScope = "attribute"
+	// HostLockScopeDelete blocks deleting the host from cmdb
+	HostLockScopeDelete HostLockScope = "delete"
+)
+
+// AllHostLockScopes are all the host lock scopes, a lock without scopes blocks all of them
+var AllHostLockScopes = []HostLockScope{HostLockScopeTransfer, HostLockScopeAttribute, HostLockScopeDelete}
+
 type HostLockRequest struct {
 	IDS []int64 `json:"id_list"`
+	// Reason why the hosts are locked, it is returned to the blocked operations
+	Reason string `json:"reason"`
+	// Scopes are the operations that are blocked, all operations are blocked if it's empty
+	Scopes []HostLockScope `json:"scopes"`
+	// TTL is the seconds after which the lock expires, the lock never expires if it's 0
+	TTL int64 `json:"ttl"`
+}
+
+// Validate validates the lock options, the id list is checked by the caller
+func (h *HostLockRequest) Validate() (rawError errors.RawErrorInfo) {
+	for _, scope := range h.Scopes {
+		valid := false
+		for _, s := range AllHostLockScopes {
+			if scope == s {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.RawErrorInfo{
+				ErrCode: common.CCErrCommParamsInvalid,
+				Args:    []interface{}{"scopes"},
+			}
+		}
+	}
+
+	if h.TTL < 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommParamsInvalid,
+			Args:    []interface{}{"ttl"},
+		}
+	}
+	return errors.RawErrorInfo{}
 }
 
 type QueryHostLockRequest struct {
@@ -32,10 +83,74 @@ type HostLockResultResponse struct {
 }
 
 type HostLockData struct {
-	User       string    `json:"bk_user" bson:"bk_user"`
-	ID         int64     `json:"bk_host_id" bson:"bk_host_id"`
-	CreateTime time.Time `json:"create_time" bson:"create_time"`
-	OwnerID    string    `json:"-" bson:"bk_supplier_account"`
+	User       string          `json:"bk_user" bson:"bk_user"`
+	ID         int64           `json:"bk_host_id" bson:"bk_host_id"`
+	CreateTime time.Time       `json:"create_time" bson:"create_time"`
+	OwnerID    string          `json:"-" bson:"bk_supplier_account"`
+	Reason     string          `json:"reason" bson:"reason"`
+	Scopes     []HostLockScope `json:"scopes" bson:"scopes"`
+	ExpireTime *time.Time      `json:"expire_time,omitempty" bson:"expire_time,omitempty"`
+}
+
+// Blocks returns if the lock blocks the operation of the scope at the time, the locks created before the scopes
+// are supported have no scopes, they block all operations just like the locks that are created without scopes.
+func (h *HostLockData) Blocks(scope HostLockScope, now time.Time) bool {
+	if h.ExpireTime != nil && !h.ExpireTime.After(now) {
+		return false
+	}
+	if len(h.Scopes) == 0 {
+		return true
+	}
+	for _, s := range h.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the lock for the error of the blocked operation
+func (h *HostLockData) String() string {
+	if h.ExpireTime == nil {
+		return fmt.Sprintf("locked by %s, reason: %s", h.User, h.Reason)
+	}
+	return fmt.Sprintf("locked by %s until %s, reason: %s", h.User, h.ExpireTime.Format(time.RFC3339), h.Reason)
+}
+
+// ListHostLocksOption is the option to list the host locks, the expired locks are not returned
+type ListHostLocksOption struct {
+	IDS   []int64       `json:"id_list"`
+	User  string        `json:"bk_user"`
+	Scope HostLockScope `json:"scope"`
+	Page  BasePage      `json:"page"`
+}
+
+// Validate validates the list options
+func (l *ListHostLocksOption) Validate() (rawError errors.RawErrorInfo) {
+	if l.Page.Limit > common.BKMaxPageSize || l.Page.Limit == 0 {
+		return errors.RawErrorInfo{
+			ErrCode: common.CCErrCommPageLimitIsExceeded,
+		}
+	}
+	return errors.RawErrorInfo{}
+}
+
+// ExpireHostLocksOption is the option to expire the host locks immediately
+type ExpireHostLocksOption struct {
+	IDS []int64 `json:"id_list"`
+}
+
+type ListHostLocksResult struct {
+	BaseResp `json:",inline"`
+	Data     struct {
+		Info  []HostLockData `json:"info"`
+		Count int64          `json:"count"`
+	} `json:"data"`
+}
+
+type ExpireHostLocksResult struct {
+	BaseResp `json:",inline"`
+	Data     UpdatedCount `json:"data"`
 }
 
 type HostLockQueryResponse struct {
@@ -0,0 +1,76 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+)
+
+func TestHostLockDataBlocks(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Second)
+	notExpired := now.Add(time.Second)
+
+	cases := []struct {
+		name   string
+		lock   HostLockData
+		scope  HostLockScope
+		blocks bool
+	}{
+		{"no scopes", HostLockData{}, HostLockScopeTransfer, true},
+		{"no scopes expired", HostLockData{ExpireTime: &expired}, HostLockScopeTransfer, false},
+		{"expire now", HostLockData{ExpireTime: &now}, HostLockScopeDelete, false},
+		{"not expired", HostLockData{ExpireTime: &notExpired}, HostLockScopeDelete, true},
+		{"scope matched", HostLockData{Scopes: []HostLockScope{HostLockScopeTransfer, HostLockScopeDelete}},
+			HostLockScopeDelete, true},
+		{"scope not matched", HostLockData{Scopes: []HostLockScope{HostLockScopeTransfer}},
+			HostLockScopeAttribute, false},
+		{"scope matched but expired", HostLockData{Scopes: []HostLockScope{HostLockScopeAttribute},
+			ExpireTime: &expired}, HostLockScopeAttribute, false},
+	}
+
+	for _, c := range cases {
+		if blocks := c.lock.Blocks(c.scope, now); blocks != c.blocks {
+			t.Errorf("%s: expect blocks %v, got %v", c.name, c.blocks, blocks)
+		}
+	}
+}
+
+func TestHostLockRequestValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		req  HostLockRequest
+		arg  string
+	}{
+		{"empty", HostLockRequest{IDS: []int64{1}}, ""},
+		{"all scopes", HostLockRequest{Scopes: AllHostLockScopes, TTL: 60}, ""},
+		{"invalid scope", HostLockRequest{Scopes: []HostLockScope{HostLockScopeTransfer, "unknown"}}, "scopes"},
+		{"negative ttl", HostLockRequest{TTL: -1}, "ttl"},
+	}
+
+	for _, c := range cases {
+		rawErr := c.req.Validate()
+		if c.arg == "" {
+			if rawErr.ErrCode != 0 {
+				t.Errorf("%s: expect valid, got %v", c.name, rawErr)
+			}
+			continue
+		}
+		if rawErr.ErrCode != common.CCErrCommParamsInvalid || len(rawErr.Args) != 1 || rawErr.Args[0] != c.arg {
+			t.Errorf("%s: expect %s invalid, got %v", c.name, c.arg, rawErr)
+		}
+	}
+}
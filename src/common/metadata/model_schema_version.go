@@ -0,0 +1,238 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+)
+
+// ModelSchemaVersion is a snapshot of a model's schema, which includes the model's attributes,
+// attribute groups and unique rules. a new version is saved on every change of the schema.
+type ModelSchemaVersion struct {
+	ObjectID   string         `json:"bk_obj_id" bson:"bk_obj_id"`
+	Version    int64          `json:"version" bson:"version"`
+	Attributes []Attribute    `json:"attributes" bson:"attributes"`
+	Groups     []Group        `json:"groups" bson:"groups"`
+	Uniques    []ObjectUnique `json:"uniques" bson:"uniques"`
+	// Description describes the change that produces this version, such as "rollback to version 3"
+	Description string `json:"description" bson:"description"`
+	OwnerID     string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Creator     string `json:"creator" bson:"creator"`
+	CreateTime  Time   `json:"create_time" bson:"create_time"`
+}
+
+// QueryModelSchemaVersionResult model schema versions
+type QueryModelSchemaVersionResult struct {
+	Count uint64               `json:"count"`
+	Info  []ModelSchemaVersion `json:"info"`
+}
+
+// SearchModelSchemaVersionResult search model schema versions result
+type SearchModelSchemaVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryModelSchemaVersionResult `json:"data"`
+}
+
+// DiffModelSchemaOption diff two versions of a model's schema, 0 means the current schema
+type DiffModelSchemaOption struct {
+	FromVersion int64 `json:"from_version"`
+	ToVersion   int64 `json:"to_version"`
+}
+
+// ModelSchemaDiffAction the change of a schema item between two versions
+type ModelSchemaDiffAction string
+
+const (
+	ModelSchemaDiffAdd    ModelSchemaDiffAction = "add"
+	ModelSchemaDiffDelete ModelSchemaDiffAction = "delete"
+	ModelSchemaDiffUpdate ModelSchemaDiffAction = "update"
+)
+
+// ModelSchemaItemDiff is the difference of one attribute, attribute group or unique rule
+type ModelSchemaItemDiff struct {
+	Action ModelSchemaDiffAction `json:"action"`
+	// Key is the attribute's bk_property_id, the group's bk_group_id or the unique rule's id
+	Key   string `json:"key"`
+	BizID int64  `json:"bk_biz_id"`
+	// ChangedFields the changed fields of the updated item
+	ChangedFields []string    `json:"changed_fields,omitempty"`
+	From          interface{} `json:"from,omitempty"`
+	To            interface{} `json:"to,omitempty"`
+}
+
+// ModelSchemaDiff is the difference between two versions of a model's schema
+type ModelSchemaDiff struct {
+	FromVersion int64                 `json:"from_version"`
+	ToVersion   int64                 `json:"to_version"`
+	Attributes  []ModelSchemaItemDiff `json:"attributes"`
+	Groups      []ModelSchemaItemDiff `json:"groups"`
+	Uniques     []ModelSchemaItemDiff `json:"uniques"`
+}
+
+// IsEmpty returns if there is no change between the two versions
+func (d *ModelSchemaDiff) IsEmpty() bool {
+	return len(d.Attributes) == 0 && len(d.Groups) == 0 && len(d.Uniques) == 0
+}
+
+// ModelSchemaDiffResult diff model schema result
+type ModelSchemaDiffResult struct {
+	BaseResp `json:",inline"`
+	Data     *ModelSchemaDiff `json:"data"`
+}
+
+// RollbackDataMode defines how to deal with the instances' data when a rollback drops attributes
+type RollbackDataMode string
+
+const (
+	// RollbackDataModeRefuse refuse to rollback if a dropped attribute holds data, this is the default mode.
+	RollbackDataModeRefuse RollbackDataMode = ""
+	// RollbackDataModeDrop drop the data of the dropped attributes from the instances.
+	RollbackDataModeDrop RollbackDataMode = "drop"
+)
+
+// RollbackModelSchemaOption rollback a model's schema to the specified version
+type RollbackModelSchemaOption struct {
+	Version  int64            `json:"version"`
+	DataMode RollbackDataMode `json:"data_mode"`
+}
+
+// RollbackModelSchemaResult rollback model schema result, the data is the new version's diff from the old one
+type RollbackModelSchemaResult struct {
+	BaseResp `json:",inline"`
+	Data     *ModelSchemaDiff `json:"data"`
+}
+
+type schemaItem struct {
+	key    string
+	bizID  int64
+	fields map[string]interface{}
+	origin interface{}
+}
+
+// attributeSchemaFields are the attribute fields that are compared, the others like id, create time are ignored.
+var attributeSchemaFields = []string{AttributeFieldPropertyName, AttributeFieldPropertyGroup,
+	AttributeFieldPropertyIndex, AttributeFieldUnit, AttributeFieldPlaceHolder, AttributeFieldIsEditable,
+	AttributeFieldIsRequired, AttributeFieldIsReadOnly, AttributeFieldIsOnly, AttributeFieldIsSystem,
+	AttributeFieldIsAPI, AttributeFieldPropertyType, AttributeFieldOption, AttributeFieldDescription}
+
+// DiffModelSchema compare two versions of a model's schema, returns the changes from the "from" version
+// to the "to" version.
+func DiffModelSchema(from, to *ModelSchemaVersion) *ModelSchemaDiff {
+	diff := &ModelSchemaDiff{
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+	}
+
+	diff.Attributes = diffSchemaItems(attributeSchemaItems(from.Attributes), attributeSchemaItems(to.Attributes))
+	diff.Groups = diffSchemaItems(groupSchemaItems(from.Groups), groupSchemaItems(to.Groups))
+	diff.Uniques = diffSchemaItems(uniqueSchemaItems(from.Uniques), uniqueSchemaItems(to.Uniques))
+	return diff
+}
+
+func attributeSchemaItems(attributes []Attribute) []schemaItem {
+	items := make([]schemaItem, 0)
+	for idx := range attributes {
+		attr := attributes[idx]
+		data := attr.ToMapStr()
+		fields := make(map[string]interface{})
+		for _, field := range attributeSchemaFields {
+			fields[field] = data[field]
+		}
+		items = append(items, schemaItem{key: attr.PropertyID, bizID: attr.BizID, fields: fields, origin: attr})
+	}
+	return items
+}
+
+func groupSchemaItems(groups []Group) []schemaItem {
+	items := make([]schemaItem, 0)
+	for _, group := range groups {
+		fields := map[string]interface{}{
+			GroupFieldGroupName:  group.GroupName,
+			GroupFieldGroupIndex: group.GroupIndex,
+			"is_collapse":        group.IsCollapse,
+		}
+		items = append(items, schemaItem{key: group.GroupID, bizID: group.BizID, fields: fields, origin: group})
+	}
+	return items
+}
+
+func uniqueSchemaItems(uniques []ObjectUnique) []schemaItem {
+	items := make([]schemaItem, 0)
+	for _, unique := range uniques {
+		fields := map[string]interface{}{
+			"must_check": unique.MustCheck,
+			"keys":       unique.Keys,
+		}
+		items = append(items, schemaItem{key: strconv.FormatUint(unique.ID, 10), fields: fields, origin: unique})
+	}
+	return items
+}
+
+func diffSchemaItems(from, to []schemaItem) []ModelSchemaItemDiff {
+	type itemKey struct {
+		key   string
+		bizID int64
+	}
+
+	fromMap := make(map[itemKey]schemaItem)
+	for _, item := range from {
+		fromMap[itemKey{key: item.key, bizID: item.bizID}] = item
+	}
+
+	diffs := make([]ModelSchemaItemDiff, 0)
+	toMap := make(map[itemKey]bool)
+	for _, item := range to {
+		key := itemKey{key: item.key, bizID: item.bizID}
+		toMap[key] = true
+
+		fromItem, exists := fromMap[key]
+		if !exists {
+			diffs = append(diffs, ModelSchemaItemDiff{Action: ModelSchemaDiffAdd, Key: item.key, BizID: item.bizID,
+				To: item.origin})
+			continue
+		}
+
+		changed := make([]string, 0)
+		for field, val := range item.fields {
+			if !isSchemaValueEqual(fromItem.fields[field], val) {
+				changed = append(changed, field)
+			}
+		}
+		if len(changed) > 0 {
+			sort.Strings(changed)
+			diffs = append(diffs, ModelSchemaItemDiff{Action: ModelSchemaDiffUpdate, Key: item.key, BizID: item.bizID,
+				ChangedFields: changed, From: fromItem.origin, To: item.origin})
+		}
+	}
+
+	for _, item := range from {
+		if !toMap[itemKey{key: item.key, bizID: item.bizID}] {
+			diffs = append(diffs, ModelSchemaItemDiff{Action: ModelSchemaDiffDelete, Key: item.key, BizID: item.bizID,
+				From: item.origin})
+		}
+	}
+	return diffs
+}
+
+// isSchemaValueEqual compare the values by their json form, so that the same values decoded from json or db
+// with different go types are regarded as equal.
+func isSchemaValueEqual(a, b interface{}) bool {
+	aJson, aErr := json.Marshal(a)
+	bJson, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return string(aJson) == string(bJson)
+}
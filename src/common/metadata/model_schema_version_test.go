@@ -0,0 +1,77 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffModelSchema(t *testing.T) {
+	now := Now()
+	from := &ModelSchemaVersion{
+		Version: 1,
+		Attributes: []Attribute{
+			{ID: 1, PropertyID: "name", PropertyName: "name", PropertyType: "singlechar", CreateTime: &now},
+			{ID: 2, PropertyID: "vendor", PropertyName: "vendor", PropertyType: "singlechar"},
+			{ID: 3, PropertyID: "owner", PropertyName: "owner", BizID: 2},
+		},
+		Groups:  []Group{{ID: 1, GroupID: "default", GroupName: "default", GroupIndex: 1}},
+		Uniques: []ObjectUnique{{ID: 1, Keys: []UniqueKey{{Kind: UniqueKeyKindProperty, ID: 1}}}},
+	}
+	to := &ModelSchemaVersion{
+		Version: 2,
+		Attributes: []Attribute{
+			// only the id and time are changed, which are not a part of the schema.
+			{ID: 11, PropertyID: "name", PropertyName: "name", PropertyType: "singlechar"},
+			{ID: 2, PropertyID: "vendor", PropertyName: "brand", PropertyType: "enum", Option: []interface{}{}},
+			// the same property of another business is a different attribute.
+			{ID: 4, PropertyID: "owner", PropertyName: "owner", BizID: 3},
+		},
+		Groups:  []Group{{ID: 1, GroupID: "default", GroupName: "default", GroupIndex: 1}},
+		Uniques: []ObjectUnique{{ID: 1, MustCheck: true, Keys: []UniqueKey{{Kind: UniqueKeyKindProperty, ID: 1}}}},
+	}
+
+	diff := DiffModelSchema(from, to)
+	if diff.FromVersion != 1 || diff.ToVersion != 2 || diff.IsEmpty() {
+		t.Fatalf("diff versions are invalid, got: %+v", diff)
+	}
+
+	if len(diff.Attributes) != 3 {
+		t.Fatalf("attributes diff is invalid, got: %+v", diff.Attributes)
+	}
+	update := diff.Attributes[0]
+	if update.Action != ModelSchemaDiffUpdate || update.Key != "vendor" ||
+		!reflect.DeepEqual(update.ChangedFields, []string{AttributeFieldPropertyName, AttributeFieldPropertyType,
+			AttributeFieldOption}) {
+		t.Fatalf("updated attribute diff is invalid, got: %+v", update)
+	}
+	if add := diff.Attributes[1]; add.Action != ModelSchemaDiffAdd || add.Key != "owner" || add.BizID != 3 {
+		t.Fatalf("added attribute diff is invalid, got: %+v", add)
+	}
+	if del := diff.Attributes[2]; del.Action != ModelSchemaDiffDelete || del.Key != "owner" || del.BizID != 2 {
+		t.Fatalf("deleted attribute diff is invalid, got: %+v", del)
+	}
+
+	if len(diff.Groups) != 0 {
+		t.Fatalf("groups should not be changed, got: %+v", diff.Groups)
+	}
+	if len(diff.Uniques) != 1 || diff.Uniques[0].Key != "1" ||
+		!reflect.DeepEqual(diff.Uniques[0].ChangedFields, []string{"must_check"}) {
+		t.Fatalf("uniques diff is invalid, got: %+v", diff.Uniques)
+	}
+
+	if diff := DiffModelSchema(to, to); !diff.IsEmpty() {
+		t.Fatalf("the same schema should have no diff, got: %+v", diff)
+	}
+}
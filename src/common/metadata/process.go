@@ -403,6 +403,8 @@ func (o *UpdateProcessByIDsInput) Validate() (rawError cErr.RawErrorInfo) {
 type SyncServiceInstanceByTemplateOption struct {
 	BizID     int64   `json:"bk_biz_id"`
 	ModuleIDs []int64 `json:"bk_module_ids"`
+	// ServiceInstanceIDs only sync these service instances in the modules if set, used by staged rollout
+	ServiceInstanceIDs []int64 `json:"service_instance_ids,omitempty"`
 }
 
 // 用于同步单个模块的服务实例
@@ -39,6 +39,8 @@ type QueryCondition struct {
 	// 非必填，只能用来查时间，且与Condition是与关系
 	TimeCondition  *TimeCondition `json:"time_condition,omitempty"`
 	DisableCounter bool           `json:"disable_counter"`
+	// ExpandInstRef expand the inst_ref attributes' values with the referenced instances' names
+	ExpandInstRef bool `json:"expand_inst_ref,omitempty"`
 }
 
 // IsIllegal  limit is illegal, if limit = 0; change to default page size
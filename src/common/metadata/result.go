@@ -199,6 +199,8 @@ type CreatedCount struct {
 // UpdatedCount created count struct
 type UpdatedCount struct {
 	Count uint64 `json:"updated_count"`
+	// Current is the current data when the update is rejected because of revision conflict
+	Current mapstr.MapStr `json:"current,omitempty"`
 }
 
 // UpdateAttributeIndex created bk_property_index info struct
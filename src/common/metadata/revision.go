@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// PopExpectedRevision get the expected revision of the instance to be updated from the bk_revision field
+// of the update data, or from the If-Match header if the field is not set. the field is always removed
+// from the data since the revision is maintained by cmdb and can not be updated directly.
+func PopExpectedRevision(header http.Header, data mapstr.MapStr) (*int64, error) {
+	if value, exists := data[common.BKRevisionField]; exists {
+		data.Remove(common.BKRevisionField)
+		if value == nil {
+			return nil, nil
+		}
+
+		revision, err := util.GetInt64ByInterface(value)
+		if err != nil || revision < 0 {
+			return nil, fmt.Errorf("invalid %s value %v", common.BKRevisionField, value)
+		}
+		return &revision, nil
+	}
+
+	ifMatch := strings.TrimSpace(header.Get(common.BKHTTPIfMatch))
+	if len(ifMatch) == 0 || ifMatch == "*" {
+		return nil, nil
+	}
+
+	// the revision is used as an entity tag, weak or strong tags are both accepted
+	ifMatch = strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+	revision, err := strconv.ParseInt(ifMatch, 10, 64)
+	if err != nil || revision < 0 {
+		return nil, fmt.Errorf("invalid %s header value %s", common.BKHTTPIfMatch, ifMatch)
+	}
+	return &revision, nil
+}
+
+// GetRevision get the revision of the instance, the instance that has never been updated since the
+// revision is introduced has no revision field, its revision is 0.
+func GetRevision(data mapstr.MapStr) int64 {
+	value, exists := data[common.BKRevisionField]
+	if !exists || value == nil {
+		return 0
+	}
+
+	revision, err := util.GetInt64ByInterface(value)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// RevisionConflictError is returned when the instance to be updated is not in the expected revision,
+// it carries the current instance so that the caller can merge the changes and try again.
+type RevisionConflictError struct {
+	errors.CCErrorCoder
+	Current mapstr.MapStr
+}
+
+// NewRevisionConflictError convert the update result to a revision conflict error if it is rejected
+// because of revision conflict, otherwise, the result's error is returned.
+func NewRevisionConflictError(result *UpdatedOptionResult) errors.CCErrorCoder {
+	if result.Code != common.CCErrCommRevisionConflict {
+		return result.CCError()
+	}
+	return &RevisionConflictError{
+		CCErrorCoder: result.CCError(),
+		Current:      result.Data.Current,
+	}
+}
+
+// GetRevisionConflictData returns the current instance if the error is a revision conflict error,
+// which is used as the response data along with the error.
+func GetRevisionConflictData(err error) (mapstr.MapStr, bool) {
+	conflictErr, ok := err.(*RevisionConflictError)
+	if !ok {
+		return nil, false
+	}
+	return conflictErr.Current, true
+}
@@ -0,0 +1,107 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func TestPopExpectedRevision(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    mapstr.MapStr
+		ifMatch string
+		want    *int64
+		wantErr bool
+	}{
+		{
+			name: "no expected revision",
+			data: mapstr.MapStr{"bk_inst_name": "a"},
+		},
+		{
+			name:    "revision field takes precedence over header",
+			data:    mapstr.MapStr{common.BKRevisionField: float64(3)},
+			ifMatch: `"5"`,
+			want:    newInt64(3),
+		},
+		{
+			name: "null revision field",
+			data: mapstr.MapStr{common.BKRevisionField: nil},
+		},
+		{
+			name:    "invalid revision field",
+			data:    mapstr.MapStr{common.BKRevisionField: "abc"},
+			wantErr: true,
+		},
+		{
+			name:    "strong entity tag",
+			data:    mapstr.MapStr{},
+			ifMatch: `"5"`,
+			want:    newInt64(5),
+		},
+		{
+			name:    "weak entity tag",
+			data:    mapstr.MapStr{},
+			ifMatch: `W/"0"`,
+			want:    newInt64(0),
+		},
+		{
+			name:    "any entity tag",
+			data:    mapstr.MapStr{},
+			ifMatch: "*",
+		},
+		{
+			name:    "invalid entity tag",
+			data:    mapstr.MapStr{},
+			ifMatch: `"-1"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			if tt.ifMatch != "" {
+				header.Set(common.BKHTTPIfMatch, tt.ifMatch)
+			}
+
+			got, err := PopExpectedRevision(header, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PopExpectedRevision() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.data.Exists(common.BKRevisionField) {
+				t.Errorf("PopExpectedRevision() revision field is not removed from %v", tt.data)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("PopExpectedRevision() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRevision(t *testing.T) {
+	if got := GetRevision(mapstr.MapStr{}); got != 0 {
+		t.Errorf("GetRevision() of the data without revision = %d, want 0", got)
+	}
+	if got := GetRevision(mapstr.MapStr{common.BKRevisionField: int32(7)}); got != 7 {
+		t.Errorf("GetRevision() = %d, want 7", got)
+	}
+}
+
+func newInt64(i int64) *int64 {
+	return &i
+}
@@ -0,0 +1,312 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// ServiceTemplateVersion is a snapshot of a service template and its process templates,
+// a new version is saved every time the service template or its process templates changed.
+type ServiceTemplateVersion struct {
+	ID                int64             `field:"id" json:"id" bson:"id"`
+	BizID             int64             `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64             `field:"service_template_id" json:"service_template_id" bson:"service_template_id"`
+	Version           int64             `field:"version" json:"version" bson:"version"`
+	Name              string            `field:"name" json:"name" bson:"name"`
+	ServiceCategoryID int64             `field:"service_category_id" json:"service_category_id" bson:"service_category_id"`
+	ProcessTemplates  []ProcessTemplate `field:"process_templates" json:"process_templates" bson:"process_templates"`
+	Creator           string            `field:"creator" json:"creator" bson:"creator"`
+	CreateTime        time.Time         `field:"create_time" json:"create_time" bson:"create_time"`
+	SupplierAccount   string            `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+}
+
+// SameContent check if two versions have the same service template and process templates content,
+// the operators and times are not compared.
+func (v *ServiceTemplateVersion) SameContent(other *ServiceTemplateVersion) bool {
+	if v.Name != other.Name || v.ServiceCategoryID != other.ServiceCategoryID {
+		return false
+	}
+	if len(v.ProcessTemplates) != len(other.ProcessTemplates) {
+		return false
+	}
+
+	otherTemplates := make(map[int64]ProcessTemplate)
+	for _, template := range other.ProcessTemplates {
+		otherTemplates[template.ID] = template
+	}
+	for _, template := range v.ProcessTemplates {
+		otherTemplate, exist := otherTemplates[template.ID]
+		if !exist || template.ProcessName != otherTemplate.ProcessName {
+			return false
+		}
+		if !reflect.DeepEqual(template.Property, otherTemplate.Property) {
+			return false
+		}
+	}
+	return true
+}
+
+type CreateServiceTemplateVersionOption struct {
+	BizID             int64 `json:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id"`
+}
+
+type ListServiceTemplateVersionsOption struct {
+	BizID             int64    `json:"bk_biz_id"`
+	ServiceTemplateID int64    `json:"service_template_id"`
+	Versions          []int64  `json:"versions,omitempty"`
+	Page              BasePage `json:"page"`
+}
+
+type MultipleServiceTemplateVersion struct {
+	Count uint64                   `json:"count"`
+	Info  []ServiceTemplateVersion `json:"info"`
+}
+
+type OneServiceTemplateVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     ServiceTemplateVersion `json:"data"`
+}
+
+type MultipleServiceTemplateVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleServiceTemplateVersion `json:"data"`
+}
+
+type RolloutStatus string
+
+const (
+	RolloutStatusRunning    RolloutStatus = "running"
+	RolloutStatusPaused     RolloutStatus = "paused"
+	RolloutStatusFinished   RolloutStatus = "finished"
+	RolloutStatusFailed     RolloutStatus = "failed"
+	RolloutStatusRolledBack RolloutStatus = "rolled_back"
+)
+
+type RolloutWaveStatus string
+
+const (
+	RolloutWaveStatusPending RolloutWaveStatus = "pending"
+	RolloutWaveStatusSuccess RolloutWaveStatus = "success"
+	RolloutWaveStatusFailed  RolloutWaveStatus = "failed"
+)
+
+// ServiceTemplateRolloutWave is a batch of service instances that are synchronized with the template together
+type ServiceTemplateRolloutWave struct {
+	Index              int               `field:"index" json:"index" bson:"index"`
+	ModuleIDs          []int64           `field:"bk_module_ids" json:"bk_module_ids" bson:"bk_module_ids"`
+	ServiceInstanceIDs []int64           `field:"service_instance_ids" json:"service_instance_ids" bson:"service_instance_ids"`
+	Status             RolloutWaveStatus `field:"status" json:"status" bson:"status"`
+	Message            string            `field:"message" json:"message" bson:"message"`
+	LastTime           time.Time         `field:"last_time" json:"last_time" bson:"last_time"`
+}
+
+// ServiceTemplateRollout records the progress of applying a service template version to its service instances
+// wave by wave, the waves are executed as the sub tasks of the task server.
+type ServiceTemplateRollout struct {
+	ID                int64                        `field:"id" json:"id" bson:"id"`
+	BizID             int64                        `field:"bk_biz_id" json:"bk_biz_id" bson:"bk_biz_id"`
+	ServiceTemplateID int64                        `field:"service_template_id" json:"service_template_id" bson:"service_template_id"`
+	Version           int64                        `field:"version" json:"version" bson:"version"`
+	PreviousVersion   int64                        `field:"previous_version" json:"previous_version" bson:"previous_version"`
+	Status            RolloutStatus                `field:"status" json:"status" bson:"status"`
+	Waves             []ServiceTemplateRolloutWave `field:"waves" json:"waves" bson:"waves"`
+	// PauseAfterEachWave pause the rollout after each wave finished, so that it can be checked before resuming
+	PauseAfterEachWave bool     `field:"pause_after_each_wave" json:"pause_after_each_wave" bson:"pause_after_each_wave"`
+	TaskIDs            []string `field:"task_ids" json:"task_ids" bson:"task_ids"`
+	// RollbackOf the id of the rollout that this rollout rolls back
+	RollbackOf      int64     `field:"rollback_of" json:"rollback_of" bson:"rollback_of"`
+	Creator         string    `field:"creator" json:"creator" bson:"creator"`
+	Modifier        string    `field:"modifier" json:"modifier" bson:"modifier"`
+	CreateTime      time.Time `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime        time.Time `field:"last_time" json:"last_time" bson:"last_time"`
+	SupplierAccount string    `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// ActiveKey is unique among the rollouts, it is the service template id when the rollout is running or paused,
+	// which guarantees that a service template has at most one rollout in progress.
+	ActiveKey string `field:"active_key" json:"-" bson:"active_key"`
+}
+
+// RolloutActiveKey returns the active key of a rollout, it is unique in the rollout table, so that only one rollout
+// of a service template can be running or paused at the same time, the inactive ones are keyed by their own ids.
+func RolloutActiveKey(serviceTemplateID, rolloutID int64, status RolloutStatus) string {
+	if status == RolloutStatusRunning || status == RolloutStatusPaused {
+		return strconv.FormatInt(serviceTemplateID, 10)
+	}
+	return strconv.FormatInt(serviceTemplateID, 10) + ":" + strconv.FormatInt(rolloutID, 10)
+}
+
+// NextWave returns the index of the first wave that is not succeeded, returns -1 if all waves are succeeded
+func (r *ServiceTemplateRollout) NextWave() int {
+	for idx, wave := range r.Waves {
+		if wave.Status != RolloutWaveStatusSuccess {
+			return idx
+		}
+	}
+	return -1
+}
+
+// CreateServiceTemplateRolloutOption is the option to start a rollout of a service template version
+type CreateServiceTemplateRolloutOption struct {
+	BizID             int64 `json:"bk_biz_id"`
+	ServiceTemplateID int64 `json:"service_template_id"`
+	// Version the version to roll out, the latest version is used if not set,
+	// the service template is restored to this version first if it's not the latest one.
+	Version int64 `json:"version"`
+	// ModuleIDs the modules to roll out, all the modules bound with the service template are used if not set
+	ModuleIDs []int64 `json:"bk_module_ids"`
+	// Percentage the percentage of the service instances in the modules to roll out, 0 means all of them
+	Percentage int `json:"percentage"`
+	// WaveCount split the service instances into waves, each module is a wave if not set
+	WaveCount          int  `json:"wave_count"`
+	PauseAfterEachWave bool `json:"pause_after_each_wave"`
+}
+
+// Validate validates the rollout option, returns the invalid field
+func (o *CreateServiceTemplateRolloutOption) Validate() string {
+	if o.BizID <= 0 {
+		return "bk_biz_id"
+	}
+	if o.ServiceTemplateID <= 0 {
+		return "service_template_id"
+	}
+	if o.Version < 0 {
+		return "version"
+	}
+	if o.Percentage < 0 || o.Percentage > 100 {
+		return "percentage"
+	}
+	if o.WaveCount < 0 {
+		return "wave_count"
+	}
+	return ""
+}
+
+type UpdateServiceTemplateRolloutOption struct {
+	BizID int64 `json:"bk_biz_id"`
+	ID    int64 `json:"id"`
+	// FromStatus only update the rollout when its status is one of these, used to change the status exclusively
+	FromStatus []RolloutStatus `json:"from_status,omitempty"`
+	Status     RolloutStatus   `json:"status,omitempty"`
+	// Wave update the status of the wave with the same index
+	Wave    *ServiceTemplateRolloutWave `json:"wave,omitempty"`
+	TaskIDs []string                    `json:"task_ids,omitempty"`
+}
+
+type ListServiceTemplateRolloutsOption struct {
+	BizID             int64           `json:"bk_biz_id"`
+	ServiceTemplateID int64           `json:"service_template_id"`
+	IDs               []int64         `json:"ids,omitempty"`
+	Status            []RolloutStatus `json:"status,omitempty"`
+	Page              BasePage        `json:"page"`
+}
+
+type MultipleServiceTemplateRollout struct {
+	Count uint64                   `json:"count"`
+	Info  []ServiceTemplateRollout `json:"info"`
+}
+
+type OneServiceTemplateRolloutResult struct {
+	BaseResp `json:",inline"`
+	Data     ServiceTemplateRollout `json:"data"`
+}
+
+type MultipleServiceTemplateRolloutResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleServiceTemplateRollout `json:"data"`
+}
+
+// ServiceTemplateRolloutTaskData is the data of a rollout wave's sub task in the task server
+type ServiceTemplateRolloutTaskData struct {
+	BizID     int64 `json:"bk_biz_id"`
+	RolloutID int64 `json:"rollout_id"`
+	WaveIndex int   `json:"wave_index"`
+}
+
+// ServiceTemplateRolloutWaves split the service instances into waves.
+// instances is the service instance ids of each module, the modules are in the order of moduleIDs.
+// If percentage is between 0 and 100, only the first percentage of the instances in each module are
+// rolled out, at least one instance is chosen for a non empty module.
+// If waveCount is 0, each module is a wave, otherwise the chosen instances are split into waveCount
+// waves as evenly as possible.
+func ServiceTemplateRolloutWaves(moduleIDs []int64, instances map[int64][]int64, percentage int,
+	waveCount int) []ServiceTemplateRolloutWave {
+
+	type moduleInstance struct {
+		moduleID   int64
+		instanceID int64
+	}
+
+	chosen := make([]moduleInstance, 0)
+	chosenByModule := make(map[int64][]int64)
+	for _, moduleID := range moduleIDs {
+		ids := instances[moduleID]
+		count := len(ids)
+		if percentage > 0 && percentage < 100 && count > 0 {
+			count = count * percentage / 100
+			if count == 0 {
+				count = 1
+			}
+		}
+		for _, id := range ids[:count] {
+			chosen = append(chosen, moduleInstance{moduleID: moduleID, instanceID: id})
+			chosenByModule[moduleID] = append(chosenByModule[moduleID], id)
+		}
+	}
+
+	waves := make([]ServiceTemplateRolloutWave, 0)
+	if waveCount <= 0 {
+		for _, moduleID := range moduleIDs {
+			if len(chosenByModule[moduleID]) == 0 {
+				continue
+			}
+			waves = append(waves, ServiceTemplateRolloutWave{
+				Index:              len(waves),
+				ModuleIDs:          []int64{moduleID},
+				ServiceInstanceIDs: chosenByModule[moduleID],
+				Status:             RolloutWaveStatusPending,
+			})
+		}
+		return waves
+	}
+
+	if waveCount > len(chosen) {
+		waveCount = len(chosen)
+	}
+	start := 0
+	for idx := 0; idx < waveCount; idx++ {
+		// spread the remainder to the first waves
+		size := len(chosen) / waveCount
+		if idx < len(chosen)%waveCount {
+			size++
+		}
+
+		wave := ServiceTemplateRolloutWave{
+			Index:              idx,
+			ModuleIDs:          make([]int64, 0),
+			ServiceInstanceIDs: make([]int64, 0),
+			Status:             RolloutWaveStatusPending,
+		}
+		for _, item := range chosen[start : start+size] {
+			if len(wave.ModuleIDs) == 0 || wave.ModuleIDs[len(wave.ModuleIDs)-1] != item.moduleID {
+				wave.ModuleIDs = append(wave.ModuleIDs, item.moduleID)
+			}
+			wave.ServiceInstanceIDs = append(wave.ServiceInstanceIDs, item.instanceID)
+		}
+		waves = append(waves, wave)
+		start += size
+	}
+	return waves
+}
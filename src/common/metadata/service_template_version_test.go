@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestServiceTemplateRolloutWaves(t *testing.T) {
+	moduleIDs := []int64{1, 2, 3}
+	instances := map[int64][]int64{
+		1: {11, 12, 13, 14},
+		2: {21, 22},
+		3: {},
+	}
+
+	tests := []struct {
+		name       string
+		percentage int
+		waveCount  int
+		want       [][]int64
+	}{
+		{
+			name: "each module is a wave",
+			want: [][]int64{{11, 12, 13, 14}, {21, 22}},
+		},
+		{
+			name:       "half of the instances in each module",
+			percentage: 50,
+			want:       [][]int64{{11, 12}, {21}},
+		},
+		{
+			name:       "at least one instance of a module",
+			percentage: 10,
+			want:       [][]int64{{11}, {21}},
+		},
+		{
+			name:      "split into waves evenly",
+			waveCount: 4,
+			want:      [][]int64{{11, 12}, {13, 14}, {21}, {22}},
+		},
+		{
+			name:      "more waves than instances",
+			waveCount: 10,
+			want:      [][]int64{{11}, {12}, {13}, {14}, {21}, {22}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			waves := ServiceTemplateRolloutWaves(moduleIDs, instances, tt.percentage, tt.waveCount)
+			got := make([][]int64, 0)
+			for idx, wave := range waves {
+				if wave.Index != idx || wave.Status != RolloutWaveStatusPending {
+					t.Errorf("wave %d has invalid index %d or status %s", idx, wave.Index, wave.Status)
+				}
+				got = append(got, wave.ServiceInstanceIDs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ServiceTemplateRolloutWaves() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// a wave may contain the instances of several modules
+	waves := ServiceTemplateRolloutWaves(moduleIDs, instances, 0, 2)
+	if !reflect.DeepEqual(waves[0].ModuleIDs, []int64{1}) || !reflect.DeepEqual(waves[1].ModuleIDs, []int64{1, 2}) {
+		t.Errorf("ServiceTemplateRolloutWaves() modules = %v, %v", waves[0].ModuleIDs, waves[1].ModuleIDs)
+	}
+}
+
+func TestRolloutActiveKey(t *testing.T) {
+	running := RolloutActiveKey(1, 10, RolloutStatusRunning)
+	paused := RolloutActiveKey(1, 11, RolloutStatusPaused)
+	if running != paused {
+		t.Fatalf("running and paused rollouts of a service template should have the same key, got: %s, %s",
+			running, paused)
+	}
+	if running == RolloutActiveKey(2, 10, RolloutStatusRunning) {
+		t.Fatalf("rollouts of different service templates should have different keys")
+	}
+
+	inactive := []RolloutStatus{RolloutStatusFinished, RolloutStatusFailed, RolloutStatusRolledBack}
+	keys := map[string]bool{running: true}
+	for idx, status := range inactive {
+		key := RolloutActiveKey(1, int64(idx), status)
+		if keys[key] {
+			t.Fatalf("inactive rollout %d with status %s should have a distinct key, got: %s", idx, status, key)
+		}
+		keys[key] = true
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"time"
+)
+
+const (
+	// defaultSlowQueryShapeLimit is the default count of the slow query shapes to be returned
+	defaultSlowQueryShapeLimit = 20
+	// maxSlowQueryShapeLimit is the max count of the slow query shapes to be returned
+	maxSlowQueryShapeLimit = 200
+)
+
+// SlowQuery is a db operation that costs more than the slow query threshold. the values in the filter are replaced
+// with "?", so that the operations on the same fields with different values have the same shape. the caller is the
+// cmdb module that sends the request to the module which runs the operation, or the module itself if the operation
+// is not run for another module, the app code is the app that sends the origin request through esb or apigw.
+type SlowQuery struct {
+	Collection string    `json:"collection" bson:"collection"`
+	Operation  string    `json:"operation" bson:"operation"`
+	Filter     string    `json:"filter" bson:"filter"`
+	Sort       string    `json:"sort" bson:"sort"`
+	Limit      int64     `json:"limit" bson:"limit"`
+	DurationMs int64     `json:"duration_ms" bson:"duration_ms"`
+	Rid        string    `json:"rid" bson:"rid"`
+	Caller     string    `json:"caller" bson:"caller"`
+	AppCode    string    `json:"app_code" bson:"app_code"`
+	CreateTime time.Time `json:"create_time" bson:"create_time"`
+}
+
+// ListSlowQueryShapeOption is the option to list the slow query shapes that cost the most time in total
+type ListSlowQueryShapeOption struct {
+	// Collection only list the shapes of this collection if it's set
+	Collection string `json:"collection"`
+	// LastMinutes only count the slow queries in the last minutes if it's set
+	LastMinutes int64 `json:"last_minutes"`
+	Limit       int64 `json:"limit"`
+}
+
+// Validate check the option and set the default limit, returns the invalid field name
+func (o *ListSlowQueryShapeOption) Validate() string {
+	if o.LastMinutes < 0 {
+		return "last_minutes"
+	}
+	if o.Limit < 0 || o.Limit > maxSlowQueryShapeLimit {
+		return "limit"
+	}
+	if o.Limit == 0 {
+		o.Limit = defaultSlowQueryShapeLimit
+	}
+	return ""
+}
+
+// SlowQueryShape is the statistics of the slow queries with the same shape
+type SlowQueryShape struct {
+	Collection      string    `json:"collection" bson:"collection"`
+	Operation       string    `json:"operation" bson:"operation"`
+	Filter          string    `json:"filter" bson:"filter"`
+	Sort            string    `json:"sort" bson:"sort"`
+	Count           int64     `json:"count" bson:"count"`
+	TotalDurationMs int64     `json:"total_duration_ms" bson:"total_duration_ms"`
+	MaxDurationMs   int64     `json:"max_duration_ms" bson:"max_duration_ms"`
+	Callers         []string  `json:"callers" bson:"callers"`
+	AppCodes        []string  `json:"app_codes" bson:"app_codes"`
+	LastRid         string    `json:"last_rid" bson:"last_rid"`
+	LastTime        time.Time `json:"last_time" bson:"last_time"`
+}
+
+// ExplainSlowQueryOption is the slow query shape to be explained, the "?" values in the filter are explained as null,
+// which makes no difference to the query plan in most cases.
+type ExplainSlowQueryOption struct {
+	Collection string `json:"collection"`
+	Filter     string `json:"filter"`
+	Sort       string `json:"sort"`
+	Limit      int64  `json:"limit"`
+}
+
+// Validate check the option, returns the invalid field name
+func (o *ExplainSlowQueryOption) Validate() string {
+	if o.Collection == "" {
+		return "collection"
+	}
+	if o.Filter == "" {
+		return "filter"
+	}
+	if o.Limit < 0 {
+		return "limit"
+	}
+	return ""
+}
@@ -0,0 +1,128 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"regexp"
+	"time"
+
+	"configcenter/src/common"
+)
+
+// TenantStatus is the status of a tenant
+type TenantStatus string
+
+const (
+	// TenantEnabled the tenant can be used normally
+	TenantEnabled TenantStatus = "enabled"
+	// TenantDisabled the requests of the tenant are rejected, and the tenant's data can be purged
+	TenantDisabled TenantStatus = "disabled"
+)
+
+// tenantIDRegexp limits the supplier account of a tenant, it is used in the cache keys and http headers.
+var tenantIDRegexp = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,32}$`)
+
+// Tenant is an isolated supplier account, the data of a tenant can not be read by the other tenants.
+// only the users of the tenant and the system apps can request the api server with its supplier account.
+type Tenant struct {
+	SupplierAccount string       `field:"bk_supplier_account" json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Name            string       `field:"name" json:"name" bson:"name"`
+	Status          TenantStatus `field:"status" json:"status" bson:"status"`
+	Users           []string     `field:"users" json:"users" bson:"users"`
+	Creator         string       `field:"creator" json:"creator" bson:"creator"`
+	CreateTime      time.Time    `field:"create_time" json:"create_time" bson:"create_time"`
+	LastTime        time.Time    `field:"last_time" json:"last_time" bson:"last_time"`
+}
+
+// CreateTenantOption is the option to create a tenant, the tenant's models are initialized with the
+// default supplier account's models.
+type CreateTenantOption struct {
+	SupplierAccount string   `json:"bk_supplier_account"`
+	Name            string   `json:"name"`
+	Users           []string `json:"users"`
+}
+
+// Validate check the option, returns the invalid field name
+func (o *CreateTenantOption) Validate() string {
+	if !tenantIDRegexp.MatchString(o.SupplierAccount) || o.SupplierAccount == common.BKDefaultOwnerID ||
+		o.SupplierAccount == common.BKSuperOwnerID {
+		return common.BKOwnerIDField
+	}
+	if len(o.Name) == 0 {
+		return "name"
+	}
+	return ""
+}
+
+// UpdateTenantStatusOption enable or disable a tenant
+type UpdateTenantStatusOption struct {
+	SupplierAccount string       `json:"bk_supplier_account"`
+	Status          TenantStatus `json:"status"`
+}
+
+// Validate check the option, returns the invalid field name
+func (o *UpdateTenantStatusOption) Validate() string {
+	if len(o.SupplierAccount) == 0 || o.SupplierAccount == common.BKDefaultOwnerID {
+		return common.BKOwnerIDField
+	}
+	if o.Status != TenantEnabled && o.Status != TenantDisabled {
+		return "status"
+	}
+	return ""
+}
+
+// UpdateTenantUsersOption set the users bound to a tenant
+type UpdateTenantUsersOption struct {
+	SupplierAccount string   `json:"bk_supplier_account"`
+	Users           []string `json:"users"`
+}
+
+// Validate check the option, returns the invalid field name
+func (o *UpdateTenantUsersOption) Validate() string {
+	if len(o.SupplierAccount) == 0 || o.SupplierAccount == common.BKDefaultOwnerID {
+		return common.BKOwnerIDField
+	}
+	for _, user := range o.Users {
+		if len(user) == 0 {
+			return "users"
+		}
+	}
+	return ""
+}
+
+// DeleteTenantOption delete a disabled tenant and purge all of its data
+type DeleteTenantOption struct {
+	SupplierAccount string `json:"bk_supplier_account"`
+}
+
+// ListTenantsOption list the tenants
+type ListTenantsOption struct {
+	SupplierAccounts []string     `json:"bk_supplier_accounts,omitempty"`
+	Status           TenantStatus `json:"status,omitempty"`
+}
+
+type MultipleTenant struct {
+	Count uint64   `json:"count"`
+	Info  []Tenant `json:"info"`
+}
+
+type MultipleTenantResult struct {
+	BaseResp `json:",inline"`
+	Data     MultipleTenant `json:"data"`
+}
+
+// PurgeTenantResult is the count of the purged documents of each table when a tenant is deleted
+type PurgeTenantResult struct {
+	BaseResp `json:",inline"`
+	Data     map[string]uint64 `json:"data"`
+}
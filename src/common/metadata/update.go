@@ -19,6 +19,9 @@ type UpdateOption struct {
 	Condition mapstr.MapStr `json:"condition" mapstructure:"condition"`
 	// can edit all fields, including not editable properties, used by collectors
 	CanEditAll bool `json:"can_edit_all" mapstructure:"can_edit_all"`
+	// Revision is the expected revision of the instance to be updated, the update is rejected with a conflict
+	// error if the instance has been changed to another revision. it can only be used to update one instance.
+	Revision *int64 `json:"revision,omitempty" mapstructure:"revision"`
 }
 
 // UpdatedOptionResult common update result
@@ -40,6 +40,11 @@ type LoginUserInfo struct {
 	Language      string                      `json:"-"`
 	AvatarUrl     string                      `json:"avatar_url"`
 	MultiSupplier bool                        `json:"multi_supplier"`
+	// Groups is the cmdb user groups that the user belongs to, mapped from the groups of the login system
+	Groups []string `json:"-"`
+	// ManagedGroups is all the cmdb user groups that are mapped from the groups of the login system,
+	// the user's membership of these groups is synchronized when the user logs in.
+	ManagedGroups []string `json:"-"`
 }
 
 type LoginPluginInfo struct {
@@ -61,6 +66,20 @@ type LoginUserPluginInerface interface {
 	GetUserList(c *gin.Context, config map[string]string) ([]*LoginSystemUserInfo, *errors.RawErrorInfo)
 }
 
+// LoginPasswordPluginInterface is implemented by the login plugins that verify the user name and password
+// posted by the login page, such as ldap.
+type LoginPasswordPluginInterface interface {
+	VerifyPassword(c *gin.Context, config map[string]string, userName, password string) (*LoginUserInfo, error)
+}
+
+// LoginCallbackPluginInterface is implemented by the login plugins that redirect the user to an external
+// login system which calls back /login/callback after the user logs in, such as openid connect.
+// redirectURL is the url that the user is redirected to after the login succeeds.
+type LoginCallbackPluginInterface interface {
+	HandleLoginCallback(c *gin.Context, config map[string]string) (user *LoginUserInfo, redirectURL string,
+		err error)
+}
+
 type LoginSystemUserInfo struct {
 	CnName string `json:"chinese_name"`
 	EnName string `json:"english_name"`
@@ -0,0 +1,248 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/version"
+
+	"github.com/emicklei/go-restful"
+)
+
+// Path is the path of the OpenAPI document of a service
+const Path = "/openapi"
+
+// BuildOption is the option to build the OpenAPI document of a service
+type BuildOption struct {
+	Title string
+	// Version is the version of the document, the version of cmdb is used if it's not set
+	Version string
+	// Tag is set to all the operations of the service, it's usually the service name
+	Tag string
+}
+
+// pathParamRegexp matches the path parameters of the go-restful routes, like {bk_biz_id} or {name:regex}
+var pathParamRegexp = regexp.MustCompile(`\{([^{}:]+)(:[^{}]*)?\}`)
+
+// validParamNameRegexp is the valid path parameter name, the wildcard routes like {.*} are not documented
+var validParamNameRegexp = regexp.MustCompile(`^[A-Za-z_][\w\-]*$`)
+
+// Build builds the OpenAPI document from the routes of the web services, the request and response schemas are
+// reflected from the read and write samples of the routes, the response schema is wrapped in the common response
+// of the cmdb api.
+func Build(services []*restful.WebService, opt BuildOption) *Document {
+	if len(opt.Version) == 0 {
+		opt.Version = version.CCVersion
+	}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: opt.Title, Version: opt.Version},
+		Paths:   make(map[string]*PathItem),
+	}
+	reflector := NewReflector()
+	operationIDs := make(map[string]bool)
+
+	for _, ws := range services {
+		for _, route := range ws.Routes() {
+			routePath, params, ok := parsePath(route.Path)
+			if !ok {
+				continue
+			}
+
+			operation := &Operation{
+				OperationID: uniqueOperationID(operationIDs, route.Operation),
+				Summary:     route.Doc,
+				Description: route.Notes,
+				Parameters:  params,
+				Responses: map[string]*Response{
+					"200": {
+						Description: "the common response of the cmdb api, data is the result of the operation",
+						Content:     jsonContent(responseSchema(reflector, route.WriteSample)),
+					},
+				},
+				Deprecated: route.Deprecated,
+			}
+			if len(opt.Tag) != 0 {
+				operation.Tags = []string{opt.Tag}
+			}
+
+			for _, param := range route.ParameterDocs {
+				data := param.Data()
+				in := ""
+				switch param.Kind() {
+				case restful.QueryParameterKind:
+					in = InQuery
+				case restful.HeaderParameterKind:
+					in = InHeader
+				default:
+					// path parameters are parsed from the path, and the body is the read sample
+					continue
+				}
+				operation.Parameters = append(operation.Parameters, &Parameter{
+					Name:        data.Name,
+					In:          in,
+					Description: data.Description,
+					Required:    data.Required,
+					Schema:      &Schema{Type: parameterType(data.DataType)},
+				})
+			}
+
+			if route.ReadSample != nil {
+				operation.RequestBody = &RequestBody{
+					Required: true,
+					Content:  jsonContent(reflector.SchemaOf(route.ReadSample)),
+				}
+			}
+
+			item, exist := doc.Paths[routePath]
+			if !exist {
+				item = new(PathItem)
+			}
+			if !item.SetOperation(route.Method, operation) {
+				continue
+			}
+			doc.Paths[routePath] = item
+		}
+	}
+
+	doc.Components.Schemas = reflector.Schemas()
+	return doc
+}
+
+// parsePath converts the go-restful route path to the OpenAPI path and its path parameters
+func parsePath(routePath string) (string, []*Parameter, bool) {
+	params := make([]*Parameter, 0)
+	valid := true
+	openAPIPath := pathParamRegexp.ReplaceAllStringFunc(routePath, func(segment string) string {
+		name := pathParamRegexp.FindStringSubmatch(segment)[1]
+		if !validParamNameRegexp.MatchString(name) {
+			valid = false
+			return segment
+		}
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       InPath,
+			Required: true,
+			Schema:   &Schema{Type: TypeString},
+		})
+		return "{" + name + "}"
+	})
+	return openAPIPath, params, valid
+}
+
+// uniqueOperationID returns the unique operation id in the document, a number is appended if it's used
+func uniqueOperationID(used map[string]bool, operationID string) string {
+	if len(operationID) == 0 {
+		return ""
+	}
+	id := operationID
+	for idx := 2; used[id]; idx++ {
+		id = fmt.Sprintf("%s%d", operationID, idx)
+	}
+	used[id] = true
+	return id
+}
+
+func parameterType(dataType string) string {
+	switch dataType {
+	case "integer", "int", "int64":
+		return TypeInteger
+	case "boolean", "bool":
+		return TypeBoolean
+	case "number", "float", "double":
+		return TypeNumber
+	default:
+		return TypeString
+	}
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{restful.MIME_JSON: {Schema: schema}}
+}
+
+// responseSchema returns the schema of the common response of the cmdb api with the data of the sample
+func responseSchema(reflector *Reflector, sample interface{}) *Schema {
+	baseSchema := reflector.Schemas()[reflector.SchemaOf(metadata.BaseResp{}).RefName()]
+	schema := &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+	for name, property := range baseSchema.Properties {
+		schema.Properties[name] = property
+	}
+	schema.Properties["data"] = reflector.SchemaOf(sample)
+	return schema
+}
+
+// Merge merges the documents into one document, the first operation of the same path and method is kept,
+// and so is the component schema of the same name.
+func Merge(info Info, docs ...*Document) *Document {
+	merged := &Document{
+		OpenAPI:    Version,
+		Info:       info,
+		Paths:      make(map[string]*PathItem),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}
+	operationIDs := make(map[string]bool)
+
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		paths := make([]string, 0, len(doc.Paths))
+		for path := range doc.Paths {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			item, exist := merged.Paths[path]
+			if !exist {
+				item = new(PathItem)
+				merged.Paths[path] = item
+			}
+			existOperations := item.Operations()
+			for method, operation := range doc.Paths[path].Operations() {
+				if _, exist := existOperations[method]; exist {
+					continue
+				}
+				copied := *operation
+				copied.OperationID = uniqueOperationID(operationIDs, operation.OperationID)
+				item.SetOperation(method, &copied)
+			}
+		}
+
+		for name, schema := range doc.Components.Schemas {
+			if _, exist := merged.Components.Schemas[name]; !exist {
+				merged.Components.Schemas[name] = schema
+			}
+		}
+	}
+	return merged
+}
+
+// Handler returns the route function that serves the OpenAPI document of the routes in the container, it's
+// usually routed with the healthz api. the document is built when it's requested so that all the routes
+// are registered.
+func Handler(container *restful.Container, opt BuildOption) restful.RouteFunction {
+	return func(req *restful.Request, resp *restful.Response) {
+		doc := Build(container.RegisteredWebServices(), opt)
+		if err := resp.WriteHeaderAndJson(http.StatusOK, doc, restful.MIME_JSON); err != nil {
+			blog.Errorf("write openapi document failed, err: %v", err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/common/metadata"
+
+	"github.com/emicklei/go-restful"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testNode struct {
+	Name     string      `json:"name"`
+	Children []*testNode `json:"children,omitempty"`
+	Ignored  string      `json:"-"`
+	hidden   string
+}
+
+type testBase struct {
+	ID   int64  `json:"id"`
+	Name string `json:"base_name"`
+}
+
+type testOption struct {
+	testBase `json:",inline"`
+	ID       string            `json:"id"`
+	Labels   map[string]string `json:"labels"`
+	Root     testNode          `json:"root"`
+	Raw      []byte            `json:"raw"`
+}
+
+func TestReflector(t *testing.T) {
+	reflector := NewReflector()
+	schema := reflector.SchemaOf(&testOption{})
+	require.Equal(t, "openapi.testOption", schema.RefName())
+
+	option := reflector.Schemas()["openapi.testOption"]
+	require.NotNil(t, option)
+	// the outer field hides the field of the embedded struct
+	assert.Equal(t, TypeString, option.Properties["id"].Type)
+	assert.Equal(t, TypeString, option.Properties["base_name"].Type)
+	assert.Equal(t, TypeObject, option.Properties["labels"].Type)
+	assert.Equal(t, TypeString, option.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, "byte", option.Properties["raw"].Format)
+	assert.Equal(t, "openapi.testNode", option.Properties["root"].RefName())
+
+	node := reflector.Schemas()["openapi.testNode"]
+	require.NotNil(t, node)
+	assert.Len(t, node.Properties, 2)
+	assert.Equal(t, TypeArray, node.Properties["children"].Type)
+	assert.Equal(t, "openapi.testNode", node.Properties["children"].Items.RefName())
+
+	assert.Equal(t, TypeString, reflector.SchemaOf(metadata.Time{}).Type)
+	assert.Equal(t, &Schema{}, reflector.SchemaOf(nil))
+}
+
+func TestBuildAndMerge(t *testing.T) {
+	handler := func(req *restful.Request, resp *restful.Response) {}
+
+	ws := new(restful.WebService).Path("/event/v3")
+	ws.Route(ws.POST("/subscribe/search/{ownerID}/{appID:[0-9]+}").To(handler).Operation("ListSubscriptions").
+		Reads(testOption{}).Writes(testNode{}))
+	ws.Route(ws.GET("/subscribe/{ownerID}").To(handler).Operation("ListSubscriptions").
+		Param(ws.QueryParameter("limit", "page limit").DataType("integer")))
+	ws.Route(ws.GET("/{.*}").To(handler))
+
+	doc := Build([]*restful.WebService{ws}, BuildOption{Title: "event", Tag: "event"})
+	assert.Len(t, doc.Paths, 2)
+
+	search := doc.Paths["/event/v3/subscribe/search/{ownerID}/{appID}"]
+	require.NotNil(t, search)
+	require.NotNil(t, search.Post)
+	assert.Equal(t, "ListSubscriptions", search.Post.OperationID)
+	assert.Equal(t, []string{"event"}, search.Post.Tags)
+	assert.Len(t, search.Post.Parameters, 2)
+	assert.Equal(t, "openapi.testOption",
+		search.Post.RequestBody.Content[restful.MIME_JSON].Schema.RefName())
+	response := search.Post.Responses["200"].Content[restful.MIME_JSON].Schema
+	assert.Equal(t, "openapi.testNode", response.Properties["data"].RefName())
+	assert.NotNil(t, response.Properties["bk_error_code"])
+
+	get := doc.Paths["/event/v3/subscribe/{ownerID}"]
+	require.NotNil(t, get)
+	require.NotNil(t, get.Get)
+	assert.Equal(t, "ListSubscriptions2", get.Get.OperationID)
+	assert.Equal(t, InQuery, get.Get.Parameters[1].In)
+	assert.Equal(t, TypeInteger, get.Get.Parameters[1].Schema.Type)
+
+	other := &Document{
+		Paths: map[string]*PathItem{
+			"/event/v3/subscribe/{ownerID}": {
+				Get:    &Operation{OperationID: "Other"},
+				Delete: &Operation{OperationID: "ListSubscriptions"},
+			},
+		},
+		Components: Components{Schemas: map[string]*Schema{"openapi.testNode": {Type: TypeString}}},
+	}
+	merged := Merge(Info{Title: "cmdb"}, doc, nil, other)
+	item := merged.Paths["/event/v3/subscribe/{ownerID}"]
+	assert.Equal(t, "ListSubscriptions2", item.Get.OperationID)
+	assert.Equal(t, "ListSubscriptions3", item.Delete.OperationID)
+	assert.Equal(t, TypeObject, merged.Components.Schemas["openapi.testNode"].Type)
+	assert.Len(t, item.Operations(), 2)
+	assert.Contains(t, item.Operations(), http.MethodDelete)
+}
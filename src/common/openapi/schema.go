@@ -0,0 +1,188 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+// knownSchemas is the schemas of the types that are marshaled in their own way
+var knownSchemas = map[reflect.Type]*Schema{
+	reflect.TypeOf(time.Time{}):       {Type: TypeString, Format: "date-time"},
+	reflect.TypeOf(metadata.Time{}):   {Type: TypeString, Description: "time in format 2006-01-02 15:04:05"},
+	reflect.TypeOf(json.RawMessage{}): {},
+}
+
+var jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// Reflector reflects the go types to the schemas, the named struct types are added to the component schemas
+// and referenced by their names, so that the recursive types can be described.
+type Reflector struct {
+	schemas map[string]*Schema
+	// names is the component schema names of the reflected struct types
+	names map[reflect.Type]string
+}
+
+// NewReflector create a new schema reflector
+func NewReflector() *Reflector {
+	return &Reflector{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// Schemas returns the component schemas of the reflected struct types
+func (r *Reflector) Schemas() map[string]*Schema {
+	return r.schemas
+}
+
+// SchemaOf returns the schema of the json form of the sample value
+func (r *Reflector) SchemaOf(sample interface{}) *Schema {
+	if sample == nil {
+		return &Schema{}
+	}
+	return r.schemaOfType(reflect.TypeOf(sample))
+}
+
+func (r *Reflector) schemaOfType(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if known, exist := knownSchemas[t]; exist {
+		schema := *known
+		return &schema
+	}
+
+	// the json form of the types that marshal themselves is unknown
+	if t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType) {
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: TypeBoolean}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: TypeInteger, Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: TypeInteger, Format: "int32"}
+	case reflect.Float32:
+		return &Schema{Type: TypeNumber, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: TypeNumber, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: TypeString}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: TypeString, Format: "byte"}
+		}
+		return &Schema{Type: TypeArray, Items: r.schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: TypeObject, AdditionalProperties: r.schemaOfType(t.Elem())}
+	case reflect.Struct:
+		return r.schemaOfStruct(t)
+	default:
+		// interface and the other types can be any value
+		return &Schema{}
+	}
+}
+
+// schemaOfStruct returns the reference of the named struct type, or the inline schema of the anonymous struct
+func (r *Reflector) schemaOfStruct(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		schema := &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+		r.addProperties(schema, t)
+		return schema
+	}
+
+	if name, exist := r.names[t]; exist {
+		return &Schema{Ref: schemaRefPrefix + name}
+	}
+
+	name := r.schemaName(t)
+	r.names[t] = name
+	schema := &Schema{Type: TypeObject, Properties: make(map[string]*Schema)}
+	r.schemas[name] = schema
+	r.addProperties(schema, t)
+	return &Schema{Ref: schemaRefPrefix + name}
+}
+
+// schemaName returns the unique component schema name of the type, like metadata.Subscription
+func (r *Reflector) schemaName(t reflect.Type) string {
+	name := t.Name()
+	if pkg := path.Base(t.PkgPath()); pkg != "" && pkg != "." {
+		name = pkg + "." + name
+	}
+
+	if _, exist := r.schemas[name]; !exist {
+		return name
+	}
+	for idx := 2; ; idx++ {
+		candidate := fmt.Sprintf("%s%d", name, idx)
+		if _, exist := r.schemas[candidate]; !exist {
+			return candidate
+		}
+	}
+}
+
+// addProperties add the json fields of the struct type to the schema, the fields of the embedded structs
+// without json names are flattened like encoding/json does, and they are hidden by the outer fields.
+func (r *Reflector) addProperties(schema *Schema, t reflect.Type) {
+	embedded := make([]reflect.Type, 0)
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct && knownSchemas[fieldType] == nil &&
+				!fieldType.Implements(jsonMarshalerType) {
+				embedded = append(embedded, fieldType)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" && !field.Anonymous {
+			// unexported field
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = r.schemaOfType(field.Type)
+	}
+
+	for _, embeddedType := range embedded {
+		embeddedSchema := &Schema{Properties: make(map[string]*Schema)}
+		r.addProperties(embeddedSchema, embeddedType)
+		for name, property := range embeddedSchema.Properties {
+			if _, exist := schema.Properties[name]; !exist {
+				schema.Properties[name] = property
+			}
+		}
+	}
+}
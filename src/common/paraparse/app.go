@@ -27,6 +27,8 @@ type SearchParams struct {
 	Condition map[string]interface{} `json:"condition"`
 	Page      map[string]interface{} `json:"page,omitempty"`
 	Fields    []string               `json:"fields,omitempty"`
+	// ExpandInstRef expand the inst_ref attributes' values with the referenced instances' names
+	ExpandInstRef bool `json:"expand_inst_ref,omitempty"`
 }
 
 func ParseCommonParams(input []metadata.ConditionItem, output map[string]interface{}) error {
@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder
+
+import (
+	"reflect"
+	"strings"
+
+	"configcenter/src/common/util"
+)
+
+// MatchData returns the matcher that checks the atom rules against the data, the field of the rule is the key
+// of the data, and the nested fields are separated by dot. the string operators compare the value literally
+// instead of as a regular expression, contains is case-insensitive like the mongo filter. the datetime
+// operators are not supported and never match.
+func MatchData(data map[string]interface{}) Matcher {
+	return func(r AtomRule) bool {
+		value, exist := getFieldValue(data, r.Field)
+
+		switch r.Operator {
+		case OperatorExist:
+			return exist
+		case OperatorNotExist:
+			return !exist
+		case OperatorIsNull:
+			return value == nil
+		case OperatorIsNotNull:
+			return value != nil
+		case OperatorIsEmpty:
+			return isSlice(value) && reflect.ValueOf(value).Len() == 0
+		case OperatorIsNotEmpty:
+			return !isSlice(value) || reflect.ValueOf(value).Len() != 0
+		case OperatorEqual:
+			return matchAny(value, func(item interface{}) bool { return equalValue(item, r.Value) })
+		case OperatorNotEqual:
+			return !matchAny(value, func(item interface{}) bool { return equalValue(item, r.Value) })
+		case OperatorIn:
+			return matchAny(value, func(item interface{}) bool { return inValues(item, r.Value) })
+		case OperatorNotIn:
+			return !matchAny(value, func(item interface{}) bool { return inValues(item, r.Value) })
+		case OperatorLess, OperatorLessOrEqual, OperatorGreater, OperatorGreaterOrEqual:
+			return matchAny(value, func(item interface{}) bool { return compareNumeric(r.Operator, item, r.Value) })
+		case OperatorBeginsWith, OperatorContains, OperatorsEndsWith:
+			return matchAny(value, func(item interface{}) bool { return matchString(r.Operator, item, r.Value) })
+		case OperatorNotBeginsWith:
+			return !matchAny(value, func(item interface{}) bool {
+				return matchString(OperatorBeginsWith, item, r.Value)
+			})
+		case OperatorNotContains:
+			return !matchAny(value, func(item interface{}) bool {
+				return matchString(OperatorContains, item, r.Value)
+			})
+		case OperatorNotEndsWith:
+			return !matchAny(value, func(item interface{}) bool {
+				return matchString(OperatorsEndsWith, item, r.Value)
+			})
+		default:
+			return false
+		}
+	}
+}
+
+// getFieldValue get the value of the field in data, the nested fields are separated by dot
+func getFieldValue(data map[string]interface{}, field string) (interface{}, bool) {
+	current := data
+	fields := strings.Split(field, ".")
+	for idx, key := range fields {
+		value, exist := current[key]
+		if !exist {
+			return nil, false
+		}
+		if idx == len(fields)-1 {
+			return value, true
+		}
+		next, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return nil, false
+}
+
+func isSlice(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	kind := reflect.TypeOf(value).Kind()
+	return kind == reflect.Slice || kind == reflect.Array
+}
+
+// matchAny checks the value with the match function, if the value is an array, it's matched if any element
+// of it is matched, which is the same as the mongo filter.
+func matchAny(value interface{}, match func(item interface{}) bool) bool {
+	if !isSlice(value) {
+		return match(value)
+	}
+	v := reflect.ValueOf(value)
+	for i := 0; i < v.Len(); i++ {
+		if match(v.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalValue(value, expected interface{}) bool {
+	if getType(value) == TypeNumeric && getType(expected) == TypeNumeric {
+		a, err := util.GetFloat64ByInterface(value)
+		if err != nil {
+			return false
+		}
+		b, err := util.GetFloat64ByInterface(expected)
+		if err != nil {
+			return false
+		}
+		return a == b
+	}
+	if getType(value) == TypeUnknown || getType(expected) == TypeUnknown {
+		return false
+	}
+	return value == expected
+}
+
+func inValues(value, expected interface{}) bool {
+	if !isSlice(expected) {
+		return false
+	}
+	v := reflect.ValueOf(expected)
+	for i := 0; i < v.Len(); i++ {
+		if equalValue(value, v.Index(i).Interface()) {
+			return true
+		}
+	}
+	return false
+}
+
+func compareNumeric(op Operator, value, expected interface{}) bool {
+	if getType(value) != TypeNumeric || getType(expected) != TypeNumeric {
+		return false
+	}
+	a, err := util.GetFloat64ByInterface(value)
+	if err != nil {
+		return false
+	}
+	b, err := util.GetFloat64ByInterface(expected)
+	if err != nil {
+		return false
+	}
+
+	switch op {
+	case OperatorLess:
+		return a < b
+	case OperatorLessOrEqual:
+		return a <= b
+	case OperatorGreater:
+		return a > b
+	case OperatorGreaterOrEqual:
+		return a >= b
+	default:
+		return false
+	}
+}
+
+func matchString(op Operator, value, expected interface{}) bool {
+	str, ok := value.(string)
+	if !ok {
+		return false
+	}
+	expectedStr, ok := expected.(string)
+	if !ok {
+		return false
+	}
+
+	switch op {
+	case OperatorBeginsWith:
+		return strings.HasPrefix(str, expectedStr)
+	case OperatorContains:
+		return util.CaseInsensitiveContains(str, expectedStr)
+	case OperatorsEndsWith:
+		return strings.HasSuffix(str, expectedStr)
+	default:
+		return false
+	}
+}
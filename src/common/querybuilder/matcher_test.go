@@ -0,0 +1,79 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querybuilder_test
+
+import (
+	"testing"
+
+	"configcenter/src/common/querybuilder"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchData(t *testing.T) {
+	data := map[string]interface{}{
+		"bk_biz_id":       float64(2),
+		"bk_host_innerip": "127.0.0.1,127.0.0.2",
+		"bk_host_name":    "Web-Server",
+		"operator":        []interface{}{"admin", "user"},
+		"bk_comment":      nil,
+		"empty":           []interface{}{},
+		"detail": map[string]interface{}{
+			"level": 3,
+		},
+	}
+
+	cases := []struct {
+		rule    querybuilder.AtomRule
+		matched bool
+	}{
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorEqual, Value: 2}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorEqual, Value: "2"}, matched: false},
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorNotEqual, Value: 3}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorIn, Value: []interface{}{1, 2}}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorNotIn, Value: []interface{}{1, 2}}, matched: false},
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorLess, Value: 3}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorGreaterOrEqual, Value: 3}, matched: false},
+		{rule: querybuilder.AtomRule{Field: "bk_host_name", Operator: querybuilder.OperatorBeginsWith, Value: "Web"}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_host_name", Operator: querybuilder.OperatorContains, Value: "server"}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_host_name", Operator: querybuilder.OperatorNotEndsWith, Value: "Server"}, matched: false},
+		{rule: querybuilder.AtomRule{Field: "operator", Operator: querybuilder.OperatorEqual, Value: "user"}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "operator", Operator: querybuilder.OperatorNotIn, Value: []interface{}{"admin"}}, matched: false},
+		{rule: querybuilder.AtomRule{Field: "empty", Operator: querybuilder.OperatorIsEmpty}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "operator", Operator: querybuilder.OperatorIsNotEmpty}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_comment", Operator: querybuilder.OperatorIsNull}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "bk_comment", Operator: querybuilder.OperatorExist}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "not_exist", Operator: querybuilder.OperatorNotExist}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "detail.level", Operator: querybuilder.OperatorGreater, Value: 2}, matched: true},
+		{rule: querybuilder.AtomRule{Field: "detail.level.x", Operator: querybuilder.OperatorExist}, matched: false},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.matched, c.rule.Match(querybuilder.MatchData(data)), "rule: %+v", c.rule)
+	}
+
+	rule := querybuilder.CombinedRule{
+		Condition: querybuilder.ConditionAnd,
+		Rules: []querybuilder.Rule{
+			querybuilder.AtomRule{Field: "bk_biz_id", Operator: querybuilder.OperatorEqual, Value: 2},
+			querybuilder.CombinedRule{
+				Condition: querybuilder.ConditionOr,
+				Rules: []querybuilder.Rule{
+					querybuilder.AtomRule{Field: "bk_host_name", Operator: querybuilder.OperatorEqual, Value: "db"},
+					querybuilder.AtomRule{Field: "operator", Operator: querybuilder.OperatorIn, Value: []interface{}{"admin"}},
+				},
+			},
+		},
+	}
+	assert.True(t, rule.Match(querybuilder.MatchData(data)))
+}
@@ -29,6 +29,9 @@ const (
 	// BKTableNameObjAttDes the table name of the object attribute
 	BKTableNameObjAttDes = "cc_ObjAttDes"
 
+	// BKTableNameObjSchemaVersion the table name of the object schema versions
+	BKTableNameObjSchemaVersion = "cc_ObjSchemaVersion"
+
 	// BKTableNameObjClassification the table name of the object classification
 	BKTableNameObjClassification = "cc_ObjClassification"
 
@@ -78,6 +81,8 @@ const (
 	BKTableNameServiceInstance         = "cc_ServiceInstance"
 	BKTableNameProcessTemplate         = "cc_ProcessTemplate"
 	BKTableNameProcessInstanceRelation = "cc_ProcessInstanceRelation"
+	BKTableNameServiceTemplateVersion  = "cc_ServiceTemplateVersion"
+	BKTableNameServiceTemplateRollout  = "cc_ServiceTemplateRollout"
 
 	BKTableNameSetTemplate                = "cc_SetTemplate"
 	BKTableNameSetServiceTemplateRelation = "cc_SetServiceTemplateRelation"
@@ -93,8 +98,22 @@ const (
 	BKTableNameCloudAccount     = "cc_CloudAccount"
 	BKTableNameCloudSyncHistory = "cc_CloudSyncHistory"
 
+	// built-in rbac authorization tables
+	BKTableNameAuthRole        = "cc_AuthRole"
+	BKTableNameAuthRoleBinding = "cc_AuthRoleBinding"
+	BKTableNameAuthUserGroup   = "cc_AuthUserGroup"
+
+	// personal api tokens table
+	BKTableNameAPIToken = "cc_APIToken"
+
+	// BKTableNameTenant the table to store the tenants, which is isolated by supplier account
+	BKTableNameTenant = "cc_Tenant"
+
 	// BKTableNameWatchToken the table to store the latest watch token for collections
 	BKTableNameWatchToken = "cc_WatchToken"
+
+	// BKTableNameSlowQueryLog the capped table to store the slow db operations
+	BKTableNameSlowQueryLog = "cc_SlowQueryLog"
 )
 
 // AllTables alltables
@@ -149,6 +168,14 @@ var AllTables = []string{
 	BKTableNameCloudSyncTask,
 	BKTableNameCloudAccount,
 	BKTableNameCloudSyncHistory,
+	BKTableNameObjSchemaVersion,
+	BKTableNameServiceTemplateVersion,
+	BKTableNameServiceTemplateRollout,
+	BKTableNameAuthRole,
+	BKTableNameAuthRoleBinding,
+	BKTableNameAuthUserGroup,
+	BKTableNameAPIToken,
+	BKTableNameTenant,
 }
 
 // GetInstTableName returns inst data table name
@@ -99,6 +99,8 @@ func NewContextFromHTTPHeader(header http.Header) context.Context {
 	ctx = context.WithValue(ctx, common.ContextRequestIDField, rid)
 	ctx = context.WithValue(ctx, common.ContextRequestUserField, user)
 	ctx = context.WithValue(ctx, common.ContextRequestOwnerField, owner)
+	ctx = context.WithValue(ctx, common.ContextRequestCallerField, header.Get(common.BKHTTPCallerModule))
+	ctx = context.WithValue(ctx, common.ContextRequestAppCodeField, header.Get(common.BKHTTPRequestAppCode))
 	return ctx
 }
 
@@ -123,6 +125,24 @@ func ExtractRequestUserFromContext(ctx context.Context) string {
 	return ""
 }
 
+// ExtractRequestCallerFromContext returns the cmdb module that sends the request
+func ExtractRequestCallerFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	caller, _ := ctx.Value(common.ContextRequestCallerField).(string)
+	return caller
+}
+
+// ExtractRequestAppCodeFromContext returns the app code of the app that sends the request through esb or apigw
+func ExtractRequestAppCodeFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	appCode, _ := ctx.Value(common.ContextRequestAppCodeField).(string)
+	return appCode
+}
+
 type AtomicBool int32
 
 func NewBool(yes bool) *AtomicBool {
@@ -214,6 +234,7 @@ func CCHeader(header http.Header) http.Header {
 	newHeader.Add(common.BKHTTPRequestAppCode, header.Get(common.BKHTTPRequestAppCode))
 	newHeader.Add(common.BKHTTPRequestRealIP, header.Get(common.BKHTTPRequestRealIP))
 	newHeader.Add(common.BKHTTPReadReference, header.Get(common.BKHTTPReadReference))
+	newHeader.Add(common.BKHTTPReadAfterWrite, header.Get(common.BKHTTPReadAfterWrite))
 
 	return newHeader
 }
@@ -257,3 +278,42 @@ func GetHTTPReadPreference(header http.Header) common.ReadPreferenceMode {
 	}
 	return common.ReadPreferenceMode(mode)
 }
+
+// ParseReadPreference parse the read preference mode from its mongodb name, e.g. secondaryPreferred,
+// an empty name means the read preference is not set.
+func ParseReadPreference(name string) (common.ReadPreferenceMode, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return common.NilMode, nil
+	case "primary":
+		return common.PrimaryMode, nil
+	case "primarypreferred":
+		return common.PrimaryPreferredMode, nil
+	case "secondary":
+		return common.SecondaryMode, nil
+	case "secondarypreferred":
+		return common.SecondaryPreferredMode, nil
+	case "nearest":
+		return common.NearestMode, nil
+	default:
+		return common.NilMode, fmt.Errorf("invalid read preference %s", name)
+	}
+}
+
+// SetReadAfterWrite 在context，header 中标记请求需要读取自己的写入，给dal 使用，dal 会忽略read preference 从主节点读取
+func SetReadAfterWrite(ctx context.Context, header http.Header) (context.Context, http.Header) {
+	ctx = context.WithValue(ctx, common.BKHTTPReadAfterWrite, "true")
+	header.Set(common.BKHTTPReadAfterWrite, "true")
+	return ctx, header
+}
+
+// IsReadAfterWrite check if the request is marked as following a write in the context
+func IsReadAfterWrite(ctx context.Context) bool {
+	val, ok := ctx.Value(common.BKHTTPReadAfterWrite).(string)
+	return ok && val == "true"
+}
+
+// IsHTTPReadAfterWrite check if the request is marked as following a write in the header
+func IsHTTPReadAfterWrite(header http.Header) bool {
+	return header.Get(common.BKHTTPReadAfterWrite) == "true"
+}
@@ -16,8 +16,22 @@ import (
 	"configcenter/src/common"
 )
 
-// SetQueryOwner returns condition that in default ownerID and request ownerID
+// SetQueryOwner returns condition that only matches the data of the request ownerID,
+// the tenants are isolated with each other, only the super owner can read all the tenants' data.
 func SetQueryOwner(condition map[string]interface{}, ownerID string) map[string]interface{} {
+	if nil == condition {
+		condition = make(map[string]interface{})
+	}
+	if ownerID == common.BKSuperOwnerID {
+		return condition
+	}
+	condition[common.BKOwnerIDField] = ownerID
+	return condition
+}
+
+// SetSharedQueryOwner returns condition that in default ownerID and request ownerID,
+// it is used by the global resources which is shared by all the tenants, such as the cloud areas.
+func SetSharedQueryOwner(condition map[string]interface{}, ownerID string) map[string]interface{} {
 	if nil == condition {
 		condition = make(map[string]interface{})
 	}
@@ -33,7 +33,14 @@ func TestSetQueryOwner(t *testing.T) {
 			"",
 			args{nil, "ownerid"},
 			map[string]interface{}{
-				common.BKOwnerIDField: map[string]interface{}{common.BKDBIN: []string{common.BKDefaultOwnerID, "ownerid"}},
+				common.BKOwnerIDField: "ownerid",
+			},
+		},
+		{
+			"",
+			args{nil, common.BKDefaultOwnerID},
+			map[string]interface{}{
+				common.BKOwnerIDField: common.BKDefaultOwnerID,
 			},
 		},
 		{
@@ -53,7 +60,7 @@ func TestSetQueryOwner(t *testing.T) {
 			args{map[string]interface{}{"name": "haha"}, "ownerid"},
 			map[string]interface{}{
 				"name":                "haha",
-				common.BKOwnerIDField: map[string]interface{}{common.BKDBIN: []string{common.BKDefaultOwnerID, "ownerid"}},
+				common.BKOwnerIDField: "ownerid",
 			},
 		},
 	}
@@ -66,6 +73,60 @@ func TestSetQueryOwner(t *testing.T) {
 	}
 }
 
+func TestSetSharedQueryOwner(t *testing.T) {
+	type args struct {
+		condition map[string]interface{}
+		ownerID   string
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]interface{}
+	}{
+		{
+			"",
+			args{nil, "ownerid"},
+			map[string]interface{}{
+				common.BKOwnerIDField: map[string]interface{}{common.BKDBIN: []string{common.BKDefaultOwnerID, "ownerid"}},
+			},
+		},
+		{
+			"",
+			args{nil, common.BKDefaultOwnerID},
+			map[string]interface{}{
+				common.BKOwnerIDField: common.BKDefaultOwnerID,
+			},
+		},
+		{
+			"",
+			args{nil, common.BKSuperOwnerID},
+			map[string]interface{}{},
+		},
+		{
+			"",
+			args{map[string]interface{}{"name": "haha"}, common.BKSuperOwnerID},
+			map[string]interface{}{
+				"name": "haha",
+			},
+		},
+		{
+			"",
+			args{map[string]interface{}{"name": "haha"}, "ownerid"},
+			map[string]interface{}{
+				"name":                "haha",
+				common.BKOwnerIDField: map[string]interface{}{common.BKDBIN: []string{common.BKDefaultOwnerID, "ownerid"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SetSharedQueryOwner(tt.args.condition, tt.args.ownerID); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SetSharedQueryOwner() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSetModOwner(t *testing.T) {
 	type args struct {
 		condition interface{}
@@ -20,6 +20,7 @@ import (
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/calculator"
 	"configcenter/src/common/errors"
 )
 
@@ -34,6 +35,10 @@ func ValidPropertyOption(propertyType string, option interface{}, errProxy error
 		return ValidFieldTypeListOption(option, errProxy)
 	case common.FieldTypeLongChar, common.FieldTypeSingleChar:
 		return ValidFieldRegularExpressionOption(option, errProxy)
+	case common.FieldTypeCalculated:
+		return ValidFieldTypeCalculatedOption(option, errProxy)
+	case common.FieldTypeInstRef:
+		return ValidFieldTypeInstRefOption(option, errProxy)
 	}
 	return nil
 }
@@ -222,6 +227,87 @@ func ValidFieldRegularExpressionOption(option interface{}, errProxy errors.Defau
 	return nil
 }
 
+// ValidFieldTypeCalculatedOption valid calculated attribute's option, whether the referenced attributes
+// and models exist is checked by the core service.
+func ValidFieldTypeCalculatedOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	calcOption, err := calculator.ParseOption(option)
+	if err != nil {
+		blog.Errorf("parse calculated option %v failed, err: %v", option, err)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	if _, err := calcOption.Validate(); err != nil {
+		blog.Errorf("calculated option %v is invalid, err: %v", option, err)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	return nil
+}
+
+// ValidFieldTypeInstRefOption valid inst_ref attribute's option, whether the referenced model exists
+// is checked by the core service.
+func ValidFieldTypeInstRefOption(option interface{}, errProxy errors.DefaultCCErrorIf) error {
+	if nil == option {
+		return errProxy.Errorf(common.CCErrCommParamsLostField, "option")
+	}
+
+	mapOption, err := convertInstRefOption(option)
+	if err != nil {
+		blog.Errorf(" option %v not inst_ref option, err: %v", option, err)
+		return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option")
+	}
+
+	objID, ok := mapOption[common.BKObjIDField].(string)
+	if !ok || len(objID) == 0 {
+		return errProxy.Errorf(common.CCErrCommParamsNeedSet, "option."+common.BKObjIDField)
+	}
+
+	if multiple, exists := mapOption["multiple"]; exists {
+		if _, ok := multiple.(bool); !ok {
+			return errProxy.Errorf(common.CCErrCommParamsNeedBool, "option.multiple")
+		}
+	}
+
+	if onDelete, exists := mapOption["on_delete"]; exists {
+		switch onDelete {
+		case common.InstRefOnDeleteRestrict, common.InstRefOnDeleteCascade:
+		default:
+			return errProxy.Errorf(common.CCErrCommParamsIsInvalid, "option.on_delete")
+		}
+	}
+
+	return nil
+}
+
+// convertInstRefOption convert the inst_ref option to map, the option may be a json string, a map or any other
+// value that can be encoded as a json object, e.g. mapstr.MapStr, bson.M or the option struct.
+func convertInstRefOption(option interface{}) (map[string]interface{}, error) {
+	switch opt := option.(type) {
+	case map[string]interface{}:
+		return opt, nil
+	case string:
+		mapOption := make(map[string]interface{})
+		if err := json.Unmarshal([]byte(opt), &mapOption); err != nil {
+			return nil, err
+		}
+		return mapOption, nil
+	default:
+		js, err := json.Marshal(option)
+		if err != nil {
+			return nil, err
+		}
+		mapOption := make(map[string]interface{})
+		if err := json.Unmarshal(js, &mapOption); err != nil {
+			return nil, err
+		}
+		return mapOption, nil
+	}
+}
+
 // IsStrProperty  is string property
 func IsStrProperty(propertyType string) bool {
 	if common.FieldTypeLongChar == propertyType || common.FieldTypeSingleChar == propertyType {
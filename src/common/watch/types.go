@@ -65,6 +65,9 @@ type ChainNode struct {
 	Cursor string `json:"cursor" bson:"cursor"`
 	// InstanceID object instance's ID, preserved for latter event aggregation operation
 	InstanceID int64 `json:"inst_id,omitempty" bson:"inst_id,omitempty"`
+	// SupplierAccount the supplier account(tenant) which the event's document belongs to,
+	// used to isolate the events between tenants.
+	SupplierAccount string `json:"bk_supplier_account,omitempty" bson:"bk_supplier_account,omitempty"`
 }
 
 type LastChainNodeData struct {
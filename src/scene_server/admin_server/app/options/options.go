@@ -55,6 +55,13 @@ type Config struct {
 	SnapRedis  redis.Config
 	Iam        iam.AuthConfig
 	SnapDataID int64
+	RBAC       RBACConfig
+}
+
+// RBACConfig is the config of the built-in rbac authorizer
+type RBACConfig struct {
+	// AdminUser is the user that the built-in admin role is bound to when the rbac tables are initialized
+	AdminUser string
 }
 
 type LanguageConfig struct {
@@ -15,6 +15,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"configcenter/src/ac/iam"
@@ -27,6 +28,7 @@ import (
 	"configcenter/src/scene_server/admin_server/app/options"
 	"configcenter/src/scene_server/admin_server/configures"
 	svc "configcenter/src/scene_server/admin_server/service"
+	"configcenter/src/storage/dal/dbindex"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/redis"
 )
@@ -52,6 +54,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 	process.Config.Register.Address, _ = cc.String("registerServer.addrs")
 	snapDataID, _ := cc.Int("hostsnap.dataID")
 	process.Config.SnapDataID = int64(snapDataID)
+	process.Config.RBAC.AdminUser, _ = cc.String("rbac.adminUser")
+	if process.Config.RBAC.AdminUser == "" {
+		process.Config.RBAC.AdminUser = "admin"
+	}
 
 	// load mongodb, redis and common config from configure directory
 	mongodbPath := process.Config.Configures.Dir + "/" + types.CCConfigureMongo
@@ -69,6 +75,10 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 		return fmt.Errorf("parse common config from file[%s] failed, err: %v", commonPath, err)
 	}
 
+	// the secondary keys of the host cache are separated with comma, their indexes are checked with the others.
+	hostSecondaryKeys, _ := cc.String("cacheService.hostSecondaryKeys")
+	dbindex.RegisterHostSecondaryKeys(strings.Split(hostSecondaryKeys, ","))
+
 	mongoConf, err := cc.Mongo("mongodb")
 	if err != nil {
 		return err
@@ -123,4 +123,10 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202103231621"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202104011012"
 	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202104211151"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202105101500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202105201500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202106011500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202106081500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202106151500"
+	_ "configcenter/src/scene_server/admin_server/upgrader/y3.9.202106221500"
 )
@@ -0,0 +1,211 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/ac/iam"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CreateAuthRole create a role of the built-in rbac authorizer
+func (s *Service) CreateAuthRole(req *restful.Request, resp *restful.Response) {
+	role := new(metadata.AuthRole)
+	if !s.decodeRBACInput(req, resp, role) {
+		return
+	}
+	if !s.checkRoleActions(req, resp, role.Actions) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().CreateAuthRole(req.Request.Context(), req.Request.Header, role)
+	s.writeRBACResult(req, resp, "CreateAuthRole", result, err)
+}
+
+// UpdateAuthRole update a role of the built-in rbac authorizer
+func (s *Service) UpdateAuthRole(req *restful.Request, resp *restful.Response) {
+	role := new(metadata.AuthRole)
+	if !s.decodeRBACInput(req, resp, role) {
+		return
+	}
+	if !s.checkRoleActions(req, resp, role.Actions) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().UpdateAuthRole(req.Request.Context(), req.Request.Header, role)
+	s.writeRBACResult(req, resp, "UpdateAuthRole", result, err)
+}
+
+// DeleteAuthRoles delete roles of the built-in rbac authorizer
+func (s *Service) DeleteAuthRoles(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.DeleteAuthRBACOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	err := s.CoreAPI.CoreService().Auth().DeleteAuthRoles(req.Request.Context(), req.Request.Header, option)
+	s.writeRBACResult(req, resp, "DeleteAuthRoles", nil, err)
+}
+
+// ListAuthRoles list roles of the built-in rbac authorizer
+func (s *Service) ListAuthRoles(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.ListAuthRolesOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().ListAuthRoles(req.Request.Context(), req.Request.Header, option)
+	s.writeRBACResult(req, resp, "ListAuthRoles", result, err)
+}
+
+// CreateAuthRoleBinding bind a role to a user or user group within a scope
+func (s *Service) CreateAuthRoleBinding(req *restful.Request, resp *restful.Response) {
+	binding := new(metadata.AuthRoleBinding)
+	if !s.decodeRBACInput(req, resp, binding) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().CreateAuthRoleBinding(req.Request.Context(), req.Request.Header,
+		binding)
+	s.writeRBACResult(req, resp, "CreateAuthRoleBinding", result, err)
+}
+
+// DeleteAuthRoleBindings delete role bindings of the built-in rbac authorizer
+func (s *Service) DeleteAuthRoleBindings(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.DeleteAuthRBACOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	err := s.CoreAPI.CoreService().Auth().DeleteAuthRoleBindings(req.Request.Context(), req.Request.Header, option)
+	s.writeRBACResult(req, resp, "DeleteAuthRoleBindings", nil, err)
+}
+
+// ListAuthRoleBindings list role bindings of the built-in rbac authorizer
+func (s *Service) ListAuthRoleBindings(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.ListAuthRoleBindingsOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().ListAuthRoleBindings(req.Request.Context(), req.Request.Header,
+		option)
+	s.writeRBACResult(req, resp, "ListAuthRoleBindings", result, err)
+}
+
+// CreateAuthUserGroup create a user group of the built-in rbac authorizer
+func (s *Service) CreateAuthUserGroup(req *restful.Request, resp *restful.Response) {
+	group := new(metadata.AuthUserGroup)
+	if !s.decodeRBACInput(req, resp, group) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().CreateAuthUserGroup(req.Request.Context(), req.Request.Header, group)
+	s.writeRBACResult(req, resp, "CreateAuthUserGroup", result, err)
+}
+
+// UpdateAuthUserGroup update a user group of the built-in rbac authorizer
+func (s *Service) UpdateAuthUserGroup(req *restful.Request, resp *restful.Response) {
+	group := new(metadata.AuthUserGroup)
+	if !s.decodeRBACInput(req, resp, group) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().UpdateAuthUserGroup(req.Request.Context(), req.Request.Header, group)
+	s.writeRBACResult(req, resp, "UpdateAuthUserGroup", result, err)
+}
+
+// DeleteAuthUserGroups delete user groups of the built-in rbac authorizer
+func (s *Service) DeleteAuthUserGroups(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.DeleteAuthRBACOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	err := s.CoreAPI.CoreService().Auth().DeleteAuthUserGroups(req.Request.Context(), req.Request.Header, option)
+	s.writeRBACResult(req, resp, "DeleteAuthUserGroups", nil, err)
+}
+
+// ListAuthUserGroups list user groups of the built-in rbac authorizer
+func (s *Service) ListAuthUserGroups(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.ListAuthUserGroupsOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().ListAuthUserGroups(req.Request.Context(), req.Request.Header, option)
+	s.writeRBACResult(req, resp, "ListAuthUserGroups", result, err)
+}
+
+// GetAuthPolicies get the policies of a user resolved from its role bindings, used to check a user's permissions
+func (s *Service) GetAuthPolicies(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.GetAuthPoliciesOption)
+	if !s.decodeRBACInput(req, resp, option) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().Auth().GetAuthPolicies(req.Request.Context(), req.Request.Header, option)
+	s.writeRBACResult(req, resp, "GetAuthPolicies", result, err)
+}
+
+func (s *Service) decodeRBACInput(req *restful.Request, resp *restful.Response, input interface{}) bool {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("decode rbac input failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return false
+	}
+	return true
+}
+
+// checkRoleActions check if the role actions are all the actions registered to iam
+func (s *Service) checkRoleActions(req *restful.Request, resp *restful.Response, actions []string) bool {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	for _, action := range actions {
+		if action == metadata.AuthRoleAllActions {
+			continue
+		}
+		if _, exist := iam.ActionIDNameMap[iam.ActionID(action)]; !exist {
+			blog.Errorf("role action %s is invalid, rid: %s", action, rid)
+			_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid,
+				"actions")})
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Service) writeRBACResult(req *restful.Request, resp *restful.Response, operation string,
+	result interface{}, err errors.CCErrorCoder) {
+
+	if err != nil {
+		blog.Errorf("%s failed, err: %v, rid: %s", operation, err, util.GetHTTPCCRequestID(req.Request.Header))
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/dbindex"
+
+	"github.com/emicklei/go-restful"
+)
+
+// CheckDBIndexes diff the registered indexes and the indexes derived from the models' unique rules with the indexes
+// in db, and returns the missing, extra and mismatched indexes of the drifted tables.
+func (s *Service) CheckDBIndexes(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	option := new(dbindex.CheckOption)
+	if !s.decodeDBIndexInput(req, resp, option, &option.Tables) {
+		return
+	}
+
+	diffs, err := dbindex.Check(s.ctx, s.db, option.Tables)
+	if err != nil {
+		blog.Errorf("check db indexes failed, tables: %v, err: %v, rid: %s", option.Tables, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCError(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(diffs))
+}
+
+// ReconcileDBIndexes creates the missing indexes of the drifted tables online, the extra and mismatched indexes are
+// dropped and rebuilt only when the option asks for it.
+func (s *Service) ReconcileDBIndexes(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	option := new(dbindex.ReconcileOption)
+	if !s.decodeDBIndexInput(req, resp, option, &option.Tables) {
+		return
+	}
+
+	results, err := dbindex.ReconcileTables(s.ctx, s.db, *option)
+	if err != nil {
+		blog.Errorf("reconcile db indexes failed, option: %+v, reconciled: %+v, err: %v, rid: %s", option, results,
+			err, rid)
+		_ = resp.WriteError(http.StatusOK,
+			&metadata.RespError{Msg: defErr.CCErrorf(common.CCErrCommMigrateFailed, err.Error())})
+		return
+	}
+	blog.Infof("reconcile db indexes success, option: %+v, reconciled: %+v, rid: %s", option, results, rid)
+	_ = resp.WriteEntity(metadata.NewSuccessResp(results))
+}
+
+// decodeDBIndexInput decodes the option and checks that all the tables in it are registered.
+func (s *Service) decodeDBIndexInput(req *restful.Request, resp *restful.Response, option interface{},
+	tables *[]string) bool {
+
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("decode db index option failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return false
+	}
+
+	for _, table := range *tables {
+		if !dbindex.IsRegistered(table) {
+			blog.Errorf("table %s has no registered indexes, rid: %s", table, rid)
+			_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCErrorf(common.CCErrCommParamsInvalid,
+				"tables")})
+			return false
+		}
+	}
+	return true
+}
@@ -40,8 +40,9 @@ func (s *Service) migrate(req *restful.Request, resp *restful.Response) {
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
 	ownerID := common.BKDefaultOwnerID
 	updateCfg := &upgrader.Config{
-		OwnerID: ownerID,
-		User:    common.CCSystemOperatorUserName,
+		OwnerID:   ownerID,
+		User:      common.CCSystemOperatorUserName,
+		AdminUser: s.Config.RBAC.AdminUser,
 	}
 
 	if err := s.createWatchDBChainCollections(rid); err != nil {
@@ -63,6 +64,23 @@ func (s *Service) migrate(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	// migrate with a new supplier account initializes it as a tenant with the default models
+	if tenantID := req.PathParameter("ownerID"); tenantID != "" && tenantID != common.BKDefaultOwnerID {
+		if _, ccErr := s.getTenant(rHeader, tenantID); ccErr != nil {
+			if ccErr.GetCode() != common.CCErrTenantNotExist {
+				resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: ccErr})
+				return
+			}
+
+			option := &metadata.CreateTenantOption{SupplierAccount: tenantID, Name: tenantID}
+			if _, ccErr := s.createTenant(rHeader, option); ccErr != nil {
+				blog.Errorf("create tenant %s failed, err: %v, rid: %s", tenantID, ccErr, rid)
+				resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: ccErr})
+				return
+			}
+		}
+	}
+
 	currentVersion := preVersion
 	if len(finishedVersions) > 0 {
 		currentVersion = finishedVersions[len(finishedVersions)-1]
@@ -190,8 +208,9 @@ func (s *Service) migrateSpecifyVersion(req *restful.Request, resp *restful.Resp
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
 	ownerID := common.BKDefaultOwnerID
 	updateCfg := &upgrader.Config{
-		OwnerID: ownerID,
-		User:    common.CCSystemOperatorUserName,
+		OwnerID:   ownerID,
+		User:      common.CCSystemOperatorUserName,
+		AdminUser: s.Config.RBAC.AdminUser,
 	}
 
 	input := new(MigrateSpecifyVersionRequest)
@@ -21,6 +21,7 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metric"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/scene_server/admin_server/app/options"
@@ -82,15 +83,38 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/migrate/system/user_config/{key}/{can}").To(s.UserConfigSwitch))
 	api.Route(api.GET("/find/system/config_admin").To(s.SearchConfigAdmin))
 	api.Route(api.PUT("/update/system/config_admin").To(s.UpdateConfigAdmin))
+	api.Route(api.POST("/create/auth/rbac/role").To(s.CreateAuthRole))
+	api.Route(api.PUT("/update/auth/rbac/role").To(s.UpdateAuthRole))
+	api.Route(api.DELETE("/delete/auth/rbac/role").To(s.DeleteAuthRoles))
+	api.Route(api.POST("/findmany/auth/rbac/role").To(s.ListAuthRoles))
+	api.Route(api.POST("/create/auth/rbac/role_binding").To(s.CreateAuthRoleBinding))
+	api.Route(api.DELETE("/delete/auth/rbac/role_binding").To(s.DeleteAuthRoleBindings))
+	api.Route(api.POST("/findmany/auth/rbac/role_binding").To(s.ListAuthRoleBindings))
+	api.Route(api.POST("/create/auth/rbac/user_group").To(s.CreateAuthUserGroup))
+	api.Route(api.PUT("/update/auth/rbac/user_group").To(s.UpdateAuthUserGroup))
+	api.Route(api.DELETE("/delete/auth/rbac/user_group").To(s.DeleteAuthUserGroups))
+	api.Route(api.POST("/findmany/auth/rbac/user_group").To(s.ListAuthUserGroups))
+	api.Route(api.POST("/find/auth/rbac/policy").To(s.GetAuthPolicies))
+	api.Route(api.POST("/create/tenant").To(s.CreateTenant))
+	api.Route(api.PUT("/update/tenant/status").To(s.UpdateTenantStatus))
+	api.Route(api.PUT("/update/tenant/users").To(s.UpdateTenantUsers))
+	api.Route(api.DELETE("/delete/tenant").To(s.DeleteTenant))
+	api.Route(api.POST("/findmany/tenant").To(s.ListTenants))
 	api.Route(api.POST("/migrate/specify/version/{distribution}/{ownerID}").To(s.migrateSpecifyVersion))
 	api.Route(api.POST("/migrate/config/refresh").To(s.refreshConfig))
 	api.Route(api.POST("/migrate/dataid").To(s.migrateDataID))
+	api.Route(api.POST("/find/system/db/index").To(s.CheckDBIndexes))
+	api.Route(api.POST("/migrate/system/db/index").To(s.ReconcileDBIndexes))
+	api.Route(api.POST("/find/system/db/slow_query").To(s.ListSlowQueryShapes))
+	api.Route(api.POST("/find/system/db/slow_query/explain").To(s.ExplainSlowQuery))
 	api.Route(api.GET("/healthz").To(s.Healthz))
 
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
+	healthzAPI.Route(healthzAPI.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb admin server", Tag: types.CC_MODULE_MIGRATE})))
 	container.Add(healthzAPI)
 
 	return container
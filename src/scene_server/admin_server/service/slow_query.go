@@ -0,0 +1,136 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/mongo/local"
+
+	"github.com/emicklei/go-restful"
+)
+
+// ListSlowQueryShapes returns the slow query shapes that cost the most time in total, the slow queries are recorded
+// by the services whose slow query threshold is configured.
+func (s *Service) ListSlowQueryShapes(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	option := new(metadata.ListSlowQueryShapeOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("decode list slow query shape option failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if field := option.Validate(); field != "" {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCErrorf(common.CCErrCommParamsInvalid,
+			field)})
+		return
+	}
+
+	match := map[string]interface{}{}
+	if option.Collection != "" {
+		match["collection"] = option.Collection
+	}
+	if option.LastMinutes > 0 {
+		since := time.Now().Add(-time.Duration(option.LastMinutes) * time.Minute)
+		match[common.CreateTimeField] = map[string]interface{}{common.BKDBGTE: since}
+	}
+
+	// the slow query log table is capped, so the documents are in the order of insertion, the last rid is the
+	// rid of the latest slow query of the shape.
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: match},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id": map[string]interface{}{
+				"collection": "$collection",
+				"operation":  "$operation",
+				"filter":     "$filter",
+				"sort":       "$sort",
+			},
+			"count":             map[string]interface{}{common.BKDBSum: 1},
+			"total_duration_ms": map[string]interface{}{common.BKDBSum: "$duration_ms"},
+			"max_duration_ms":   map[string]interface{}{"$max": "$duration_ms"},
+			"callers":           map[string]interface{}{"$addToSet": "$caller"},
+			"app_codes":         map[string]interface{}{"$addToSet": "$app_code"},
+			"last_rid":          map[string]interface{}{"$last": "$rid"},
+			"last_time":         map[string]interface{}{"$max": "$" + common.CreateTimeField},
+		}},
+		{"$sort": map[string]interface{}{"total_duration_ms": -1}},
+		{"$limit": option.Limit},
+		{common.BKDBProject: map[string]interface{}{
+			"_id":               0,
+			"collection":        "$_id.collection",
+			"operation":         "$_id.operation",
+			"filter":            "$_id.filter",
+			"sort":              "$_id.sort",
+			"count":             1,
+			"total_duration_ms": 1,
+			"max_duration_ms":   1,
+			"callers":           1,
+			"app_codes":         1,
+			"last_rid":          1,
+			"last_time":         1,
+		}},
+	}
+
+	shapes := make([]metadata.SlowQueryShape, 0)
+	if err := s.db.Table(common.BKTableNameSlowQueryLog).AggregateAll(s.ctx, pipeline, &shapes); err != nil {
+		blog.Errorf("aggregate slow query shapes failed, option: %+v, err: %v, rid: %s", option, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCError(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(shapes))
+}
+
+// ExplainSlowQuery explains the query plan of a slow query shape on demand, the query is not executed.
+func (s *Service) ExplainSlowQuery(req *restful.Request, resp *restful.Response) {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	option := new(metadata.ExplainSlowQueryOption)
+	if err := json.NewDecoder(req.Request.Body).Decode(option); err != nil {
+		blog.Errorf("decode explain slow query option failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if field := option.Validate(); field != "" {
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCErrorf(common.CCErrCommParamsInvalid,
+			field)})
+		return
+	}
+
+	mongo, ok := s.db.(*local.Mongo)
+	if !ok {
+		blog.Errorf("db is not mongodb, can not explain slow query, rid: %s", rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCError(common.CCErrCommDBSelectFailed)})
+		return
+	}
+
+	plan, err := mongo.ExplainSlowQuery(s.ctx, option)
+	if err != nil {
+		blog.Errorf("explain slow query failed, option: %+v, err: %v, rid: %s", option, err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.CCErrorf(common.CCErrCommParamsInvalid,
+			"filter")})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(plan))
+}
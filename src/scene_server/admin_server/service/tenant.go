@@ -0,0 +1,344 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/admin_server/upgrader"
+
+	"github.com/emicklei/go-restful"
+)
+
+// tenantPurgeSkipTables are the tables that are not purged when a tenant is deleted, they are shared by all
+// the tenants, the tenant record itself is deleted after all the data is purged.
+var tenantPurgeSkipTables = map[string]bool{
+	common.BKTableNameSystem:      true,
+	common.BKTableNameIDgenerator: true,
+	common.BKTableNameTransaction: true,
+	common.BKTableNameTenant:      true,
+}
+
+// CreateTenant create a tenant and initialize its models with the default supplier account's models
+func (s *Service) CreateTenant(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.CreateTenantOption)
+	if !s.decodeTenantInput(req, resp, option) {
+		return
+	}
+
+	rHeader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if field := option.Validate(); field != "" {
+		s.writeTenantResult(req, resp, "CreateTenant", nil, defErr.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	tenant, err := s.createTenant(rHeader, option)
+	if err != nil {
+		s.writeTenantResult(req, resp, "CreateTenant", nil, err)
+		return
+	}
+
+	err = s.saveTenantAuditLog(rHeader, metadata.AuditCreate, tenant.SupplierAccount, tenant.Name, nil,
+		map[string]interface{}{"name": tenant.Name, "status": tenant.Status, "users": tenant.Users})
+	s.writeTenantResult(req, resp, "CreateTenant", tenant, err)
+}
+
+// createTenant initializes the tenant's models and saves the tenant, the tenant is saved after it is initialized,
+// so that the tenant can not be used before its models are ready.
+func (s *Service) createTenant(rHeader http.Header, option *metadata.CreateTenantOption) (*metadata.Tenant,
+	errors.CCErrorCoder) {
+
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	filter := map[string]interface{}{common.BKOwnerIDField: option.SupplierAccount}
+	count, err := s.db.Table(common.BKTableNameTenant).Find(filter).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count tenant %s failed, err: %v, rid: %s", option.SupplierAccount, err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return nil, defErr.CCErrorf(common.CCErrCommDuplicateItem, common.BKOwnerIDField)
+	}
+
+	user := util.GetUser(rHeader)
+	if len(user) == 0 {
+		user = common.CCSystemOperatorUserName
+	}
+	conf := &upgrader.Config{
+		OwnerID: option.SupplierAccount,
+		User:    user,
+	}
+	if err := upgrader.InitTenant(s.ctx, s.db, conf); err != nil {
+		blog.Errorf("init tenant %s failed, err: %v, rid: %s", option.SupplierAccount, err, rid)
+		return nil, defErr.CCErrorf(common.CCErrCommMigrateFailed, err.Error())
+	}
+
+	now := time.Now()
+	tenant := &metadata.Tenant{
+		SupplierAccount: option.SupplierAccount,
+		Name:            option.Name,
+		Status:          metadata.TenantEnabled,
+		Users:           util.StrArrayUnique(option.Users),
+		Creator:         user,
+		CreateTime:      now,
+		LastTime:        now,
+	}
+	if err := s.db.Table(common.BKTableNameTenant).Insert(s.ctx, tenant); err != nil {
+		blog.Errorf("save tenant %s failed, err: %v, rid: %s", option.SupplierAccount, err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return tenant, nil
+}
+
+// UpdateTenantStatus enable or disable a tenant, the requests of a disabled tenant are rejected by api server
+func (s *Service) UpdateTenantStatus(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.UpdateTenantStatusOption)
+	if !s.decodeTenantInput(req, resp, option) {
+		return
+	}
+
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if field := option.Validate(); field != "" {
+		s.writeTenantResult(req, resp, "UpdateTenantStatus", nil,
+			defErr.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	tenant, err := s.getTenant(rHeader, option.SupplierAccount)
+	if err != nil {
+		s.writeTenantResult(req, resp, "UpdateTenantStatus", nil, err)
+		return
+	}
+
+	filter := map[string]interface{}{common.BKOwnerIDField: option.SupplierAccount}
+	doc := map[string]interface{}{
+		"status":             option.Status,
+		common.LastTimeField: time.Now(),
+	}
+	if err := s.db.Table(common.BKTableNameTenant).Update(s.ctx, filter, doc); err != nil {
+		blog.Errorf("update tenant %s status failed, err: %v, rid: %s", option.SupplierAccount, err, rid)
+		s.writeTenantResult(req, resp, "UpdateTenantStatus", nil, defErr.CCError(common.CCErrCommDBUpdateFailed))
+		return
+	}
+
+	err = s.saveTenantAuditLog(rHeader, metadata.AuditUpdate, tenant.SupplierAccount, tenant.Name,
+		map[string]interface{}{"status": tenant.Status}, map[string]interface{}{"status": option.Status})
+	s.writeTenantResult(req, resp, "UpdateTenantStatus", nil, err)
+}
+
+// UpdateTenantUsers set the users bound to a tenant, only these users can use the tenant's supplier account
+func (s *Service) UpdateTenantUsers(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.UpdateTenantUsersOption)
+	if !s.decodeTenantInput(req, resp, option) {
+		return
+	}
+
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if field := option.Validate(); field != "" {
+		s.writeTenantResult(req, resp, "UpdateTenantUsers", nil, defErr.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	tenant, err := s.getTenant(rHeader, option.SupplierAccount)
+	if err != nil {
+		s.writeTenantResult(req, resp, "UpdateTenantUsers", nil, err)
+		return
+	}
+
+	filter := map[string]interface{}{common.BKOwnerIDField: option.SupplierAccount}
+	doc := map[string]interface{}{
+		"users":              util.StrArrayUnique(option.Users),
+		common.LastTimeField: time.Now(),
+	}
+	if err := s.db.Table(common.BKTableNameTenant).Update(s.ctx, filter, doc); err != nil {
+		blog.Errorf("update tenant %s users failed, err: %v, rid: %s", option.SupplierAccount, err, rid)
+		s.writeTenantResult(req, resp, "UpdateTenantUsers", nil, defErr.CCError(common.CCErrCommDBUpdateFailed))
+		return
+	}
+
+	err = s.saveTenantAuditLog(rHeader, metadata.AuditUpdate, tenant.SupplierAccount, tenant.Name,
+		map[string]interface{}{"users": tenant.Users}, map[string]interface{}{"users": doc["users"]})
+	s.writeTenantResult(req, resp, "UpdateTenantUsers", nil, err)
+}
+
+// DeleteTenant delete a disabled tenant and purge all of its data, returns the purged count of each table.
+func (s *Service) DeleteTenant(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.DeleteTenantOption)
+	if !s.decodeTenantInput(req, resp, option) {
+		return
+	}
+
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+	if len(option.SupplierAccount) == 0 || option.SupplierAccount == common.BKDefaultOwnerID ||
+		option.SupplierAccount == common.BKSuperOwnerID {
+		s.writeTenantResult(req, resp, "DeleteTenant", nil,
+			defErr.CCErrorf(common.CCErrCommParamsInvalid, common.BKOwnerIDField))
+		return
+	}
+
+	tenant, err := s.getTenant(rHeader, option.SupplierAccount)
+	if err != nil {
+		s.writeTenantResult(req, resp, "DeleteTenant", nil, err)
+		return
+	}
+	if tenant.Status != metadata.TenantDisabled {
+		s.writeTenantResult(req, resp, "DeleteTenant", nil,
+			defErr.CCErrorf(common.CCErrTenantDeleteEnabled, option.SupplierAccount))
+		return
+	}
+
+	filter := map[string]interface{}{common.BKOwnerIDField: option.SupplierAccount}
+	purged := make(map[string]uint64)
+	for _, table := range common.AllTables {
+		if tenantPurgeSkipTables[table] {
+			continue
+		}
+
+		count, err := s.db.Table(table).Find(filter).Count(s.ctx)
+		if err != nil {
+			blog.Errorf("count tenant %s data of table %s failed, err: %v, rid: %s", option.SupplierAccount, table,
+				err, rid)
+			s.writeTenantResult(req, resp, "DeleteTenant", nil, defErr.CCError(common.CCErrCommDBSelectFailed))
+			return
+		}
+		if count == 0 {
+			continue
+		}
+
+		if err := s.db.Table(table).Delete(s.ctx, filter); err != nil {
+			blog.Errorf("purge tenant %s data of table %s failed, err: %v, rid: %s", option.SupplierAccount, table,
+				err, rid)
+			s.writeTenantResult(req, resp, "DeleteTenant", nil, defErr.CCError(common.CCErrCommDBDeleteFailed))
+			return
+		}
+		purged[table] = count
+	}
+
+	if err := s.db.Table(common.BKTableNameTenant).Delete(s.ctx, filter); err != nil {
+		blog.Errorf("delete tenant %s failed, err: %v, rid: %s", option.SupplierAccount, err, rid)
+		s.writeTenantResult(req, resp, "DeleteTenant", nil, defErr.CCError(common.CCErrCommDBDeleteFailed))
+		return
+	}
+	blog.Infof("tenant %s is deleted by %s, purged: %v, rid: %s", option.SupplierAccount, util.GetUser(rHeader),
+		purged, rid)
+
+	purgedData := make(map[string]interface{}, len(purged))
+	for table, count := range purged {
+		purgedData[table] = count
+	}
+	err = s.saveTenantAuditLog(rHeader, metadata.AuditDelete, tenant.SupplierAccount, tenant.Name,
+		map[string]interface{}{"name": tenant.Name, "status": tenant.Status, "users": tenant.Users},
+		map[string]interface{}{"purged": purgedData})
+	s.writeTenantResult(req, resp, "DeleteTenant", purged, err)
+}
+
+// ListTenants list the tenants
+func (s *Service) ListTenants(req *restful.Request, resp *restful.Response) {
+	option := new(metadata.ListTenantsOption)
+	if !s.decodeTenantInput(req, resp, option) {
+		return
+	}
+
+	result, err := s.CoreAPI.CoreService().System().SearchTenants(req.Request.Context(), req.Request.Header, option)
+	s.writeTenantResult(req, resp, "ListTenants", result, err)
+}
+
+// saveTenantAuditLog saves the audit log of the tenant lifecycle operation, the audit log belongs to the
+// supplier account of the operator, so that the operations on a purged tenant can still be found.
+func (s *Service) saveTenantAuditLog(rHeader http.Header, action metadata.ActionType, supplierAccount, name string,
+	preData, curData map[string]interface{}) errors.CCErrorCoder {
+
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	auditLog := metadata.AuditLog{
+		AuditType:    metadata.TenantType,
+		ResourceType: metadata.TenantRes,
+		Action:       action,
+		ResourceID:   supplierAccount,
+		ResourceName: name,
+		OperateFrom:  metadata.FromUser,
+		OperationDetail: &metadata.BasicOpDetail{
+			Details: &metadata.BasicContent{
+				PreData: preData,
+				CurData: curData,
+			},
+		},
+	}
+	result, err := s.CoreAPI.CoreService().Audit().SaveAuditLog(s.ctx, rHeader, auditLog)
+	if err != nil {
+		blog.Errorf("save tenant %s %s audit log failed, err: %v, rid: %s", supplierAccount, action, err, rid)
+		return defErr.CCError(common.CCErrAuditSaveLogFailed)
+	}
+	if !result.Result {
+		blog.Errorf("save tenant %s %s audit log failed, err: %s, rid: %s", supplierAccount, action, result.ErrMsg,
+			rid)
+		return defErr.CCError(common.CCErrAuditSaveLogFailed)
+	}
+	return nil
+}
+
+func (s *Service) getTenant(rHeader http.Header, supplierAccount string) (*metadata.Tenant, errors.CCErrorCoder) {
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	filter := map[string]interface{}{common.BKOwnerIDField: supplierAccount}
+	tenant := new(metadata.Tenant)
+	if err := s.db.Table(common.BKTableNameTenant).Find(filter).One(s.ctx, tenant); err != nil {
+		if s.db.IsNotFoundError(err) {
+			return nil, defErr.CCErrorf(common.CCErrTenantNotExist, supplierAccount)
+		}
+		blog.Errorf("get tenant %s failed, err: %v, rid: %s", supplierAccount, err, rid)
+		return nil, defErr.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return tenant, nil
+}
+
+func (s *Service) decodeTenantInput(req *restful.Request, resp *restful.Response, input interface{}) bool {
+	rHeader := req.Request.Header
+	rid := util.GetHTTPCCRequestID(rHeader)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(rHeader))
+
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("decode tenant input failed, err: %v, rid: %s", err, rid)
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return false
+	}
+	return true
+}
+
+func (s *Service) writeTenantResult(req *restful.Request, resp *restful.Response, operation string,
+	result interface{}, err errors.CCErrorCoder) {
+
+	if err != nil {
+		blog.Errorf("%s failed, err: %v, rid: %s", operation, err, util.GetHTTPCCRequestID(req.Request.Header))
+		_ = resp.WriteError(http.StatusOK, &metadata.RespError{Msg: err})
+		return
+	}
+	_ = resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -33,6 +33,8 @@ import (
 type Config struct {
 	OwnerID string
 	User    string
+	// AdminUser is the user that the built-in admin role of the rbac authorizer is bound to
+	AdminUser string
 }
 
 // Upgrader define a version upgrader
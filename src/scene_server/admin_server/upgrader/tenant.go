@@ -0,0 +1,254 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package upgrader
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+// tenantCloneStep clones the default supplier account's documents of a table for a new tenant
+type tenantCloneStep struct {
+	table   string
+	idField string
+	// filter returns the filter of the documents to clone, the supplier account is set by the cloner
+	filter func(c *tenantCloner) map[string]interface{}
+	// remap replaces the ids referenced by the document with the cloned documents' new ids
+	remap func(c *tenantCloner, doc map[string]interface{}) error
+}
+
+// tenantCloner clones the default supplier account's models and built-in data for a new tenant, the cloned
+// documents get new ids, and the references between them are updated with the new ids.
+type tenantCloner struct {
+	db   dal.RDB
+	conf *Config
+	// ids is the map from the old id to the new id of the cloned documents, grouped by table name
+	ids map[string]map[int64]int64
+}
+
+// InitTenant initializes the models and the built-in data of the tenant conf.OwnerID with the default
+// supplier account's, so that the tenant can be used just like a new deployed cmdb.
+// the id sequences are shared by all the tenants, so the ids of different tenants never conflict.
+func InitTenant(ctx context.Context, db dal.RDB, conf *Config) error {
+	if conf.OwnerID == common.BKDefaultOwnerID || conf.OwnerID == common.BKSuperOwnerID {
+		return fmt.Errorf("supplier account %s can not be initialized as a tenant", conf.OwnerID)
+	}
+
+	filter := map[string]interface{}{common.BKOwnerIDField: conf.OwnerID}
+	count, err := db.Table(common.BKTableNameObjDes).Find(filter).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("tenant %s has already been initialized", conf.OwnerID)
+	}
+
+	c := &tenantCloner{db: db, conf: conf, ids: make(map[string]map[int64]int64)}
+	for _, step := range tenantCloneSteps {
+		if err := c.clone(ctx, step); err != nil {
+			blog.Errorf("init tenant %s, clone table %s failed, err: %v", conf.OwnerID, step.table, err)
+			return err
+		}
+	}
+
+	if err := c.cloneUniques(ctx); err != nil {
+		blog.Errorf("init tenant %s, clone table %s failed, err: %v", conf.OwnerID, common.BKTableNameObjUnique, err)
+		return err
+	}
+	return nil
+}
+
+var bizPublicFilter = func(c *tenantCloner) map[string]interface{} {
+	return map[string]interface{}{common.BKAppIDField: 0}
+}
+
+var tenantCloneSteps = []tenantCloneStep{
+	{table: common.BKTableNameObjClassification, idField: common.BKFieldID},
+	{table: common.BKTableNameAsstDes, idField: common.BKFieldID},
+	{table: common.BKTableNameObjDes, idField: common.BKFieldID},
+	{table: common.BKTableNameObjAttDes, idField: common.BKFieldID, filter: bizPublicFilter},
+	{table: common.BKTableNamePropertyGroup, idField: common.BKFieldID, filter: bizPublicFilter},
+	{table: common.BKTableNameObjAsst, idField: common.BKFieldID},
+	{
+		table:   common.BKTableNameServiceCategory,
+		idField: common.BKFieldID,
+		filter:  bizPublicFilter,
+		remap: func(c *tenantCloner, doc map[string]interface{}) error {
+			return c.remapFields(doc, common.BKTableNameServiceCategory, common.BKParentIDField, common.BKRootIDField)
+		},
+	},
+	// the resource pool business with its idle set and modules
+	{
+		table:   common.BKTableNameBaseApp,
+		idField: common.BKAppIDField,
+		filter: func(c *tenantCloner) map[string]interface{} {
+			return map[string]interface{}{common.BKDefaultField: common.DefaultAppFlag}
+		},
+	},
+	{
+		table:   common.BKTableNameBaseSet,
+		idField: common.BKSetIDField,
+		filter: func(c *tenantCloner) map[string]interface{} {
+			return map[string]interface{}{common.BKAppIDField: map[string]interface{}{
+				common.BKDBIN: c.clonedIDs(common.BKTableNameBaseApp)}}
+		},
+		remap: func(c *tenantCloner, doc map[string]interface{}) error {
+			return c.remapFields(doc, common.BKTableNameBaseApp, common.BKAppIDField, common.BKParentIDField)
+		},
+	},
+	{
+		table:   common.BKTableNameBaseModule,
+		idField: common.BKModuleIDField,
+		filter: func(c *tenantCloner) map[string]interface{} {
+			return map[string]interface{}{common.BKSetIDField: map[string]interface{}{
+				common.BKDBIN: c.clonedIDs(common.BKTableNameBaseSet)}}
+		},
+		remap: func(c *tenantCloner, doc map[string]interface{}) error {
+			if err := c.remapFields(doc, common.BKTableNameBaseApp, common.BKAppIDField); err != nil {
+				return err
+			}
+			if err := c.remapFields(doc, common.BKTableNameBaseSet, common.BKSetIDField,
+				common.BKParentIDField); err != nil {
+				return err
+			}
+			return c.remapFields(doc, common.BKTableNameServiceCategory, common.BKServiceCategoryIDField)
+		},
+	},
+}
+
+// clone copies the documents of the step's table in the id order, so that a document is always cloned
+// after the documents it references in the same table, such as the parent service categories.
+func (c *tenantCloner) clone(ctx context.Context, step tenantCloneStep) error {
+	filter := make(map[string]interface{})
+	if step.filter != nil {
+		filter = step.filter(c)
+	}
+	filter[common.BKOwnerIDField] = common.BKDefaultOwnerID
+
+	docs := make([]map[string]interface{}, 0)
+	if err := c.db.Table(step.table).Find(filter).Sort(step.idField).All(ctx, &docs); err != nil {
+		return err
+	}
+
+	ids := make(map[int64]int64)
+	c.ids[step.table] = ids
+	now := time.Now()
+	for _, doc := range docs {
+		oldID, err := util.GetInt64ByInterface(doc[step.idField])
+		if err != nil {
+			return fmt.Errorf("invalid %s %v, err: %v", step.idField, doc[step.idField], err)
+		}
+		newID, err := c.db.NextSequence(ctx, step.table)
+		if err != nil {
+			return err
+		}
+		ids[oldID] = int64(newID)
+
+		delete(doc, "_id")
+		doc[step.idField] = int64(newID)
+		doc[common.BKOwnerIDField] = c.conf.OwnerID
+		for _, timeField := range []string{common.CreateTimeField, common.LastTimeField} {
+			if _, exists := doc[timeField]; exists {
+				doc[timeField] = now
+			}
+		}
+		if step.remap != nil {
+			if err := step.remap(c, doc); err != nil {
+				return err
+			}
+		}
+
+		if err := c.db.Table(step.table).Insert(ctx, doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneUniques copies the model uniques with the cloned attributes' new ids, the uniques which contain
+// the attributes that are not cloned, such as the attributes of a business, are skipped.
+func (c *tenantCloner) cloneUniques(ctx context.Context) error {
+	filter := map[string]interface{}{common.BKOwnerIDField: common.BKDefaultOwnerID}
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := c.db.Table(common.BKTableNameObjUnique).Find(filter).All(ctx, &uniques); err != nil {
+		return err
+	}
+
+	attrIDs := c.ids[common.BKTableNameObjAttDes]
+	for _, unique := range uniques {
+		keys := make([]metadata.UniqueKey, 0, len(unique.Keys))
+		for _, key := range unique.Keys {
+			newID, exists := attrIDs[int64(key.ID)]
+			if !exists {
+				break
+			}
+			keys = append(keys, metadata.UniqueKey{Kind: key.Kind, ID: uint64(newID)})
+		}
+		if len(keys) != len(unique.Keys) {
+			continue
+		}
+
+		id, err := c.db.NextSequence(ctx, common.BKTableNameObjUnique)
+		if err != nil {
+			return err
+		}
+		unique.ID = id
+		unique.Keys = keys
+		unique.OwnerID = c.conf.OwnerID
+		unique.LastTime = metadata.Now()
+		if err := c.db.Table(common.BKTableNameObjUnique).Insert(ctx, unique); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remapFields replaces the id fields of the document with the new ids of the cloned documents in table,
+// the zero values are kept, they mean no reference.
+func (c *tenantCloner) remapFields(doc map[string]interface{}, table string, fields ...string) error {
+	for _, field := range fields {
+		value, exists := doc[field]
+		if !exists {
+			continue
+		}
+		oldID, err := util.GetInt64ByInterface(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s %v, err: %v", field, value, err)
+		}
+		if oldID == 0 {
+			continue
+		}
+		newID, exists := c.ids[table][oldID]
+		if !exists {
+			return fmt.Errorf("%s %d references a document of %s that is not cloned", field, oldID, table)
+		}
+		doc[field] = newID
+	}
+	return nil
+}
+
+// clonedIDs returns the default supplier account's ids of the cloned documents in table
+func (c *tenantCloner) clonedIDs(table string) []int64 {
+	ids := make([]int64, 0, len(c.ids[table]))
+	for oldID := range c.ids[table] {
+		ids = append(ids, oldID)
+	}
+	return ids
+}
@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202105101500
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+func addObjSchemaVersionTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+
+	tableName := common.BKTableNameObjSchemaVersion
+
+	exists, err := db.HasTable(ctx, tableName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = db.CreateTable(ctx, tableName); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	index := types.Index{
+		Keys:       map[string]int32{common.BKObjIDField: 1, "version": 1, common.BKOwnerIDField: 1},
+		Name:       "bk_obj_id_1_version_1_bk_supplier_account_1",
+		Unique:     true,
+		Background: true,
+	}
+
+	err := db.Table(common.BKTableNameObjSchemaVersion).CreateIndex(ctx, index)
+	if err != nil && !db.IsDuplicatedError(err) {
+		blog.ErrorJSON("add index %s for table %s failed, err:%s", index, common.BKTableNameObjSchemaVersion, err)
+		return err
+	}
+
+	return nil
+}
+
+// addBaselineSchemaVersions save the current schema of the existing models as their first versions, so that the
+// first schema change after the upgrade can be rolled back. models that already have versions are skipped.
+func addBaselineSchemaVersions(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	objs := make([]metadata.Object, 0)
+	err := db.Table(common.BKTableNameObjDes).Find(nil).Fields(common.BKObjIDField, common.BKOwnerIDField).
+		All(ctx, &objs)
+	if err != nil {
+		blog.Errorf("get models failed, err: %v", err)
+		return err
+	}
+
+	for _, obj := range objs {
+		cond := map[string]interface{}{
+			common.BKObjIDField:   obj.ObjectID,
+			common.BKOwnerIDField: obj.OwnerID,
+		}
+
+		cnt, err := db.Table(common.BKTableNameObjSchemaVersion).Find(cond).Count(ctx)
+		if err != nil {
+			blog.Errorf("count model %s schema versions failed, err: %v", obj.ObjectID, err)
+			return err
+		}
+		if cnt > 0 {
+			continue
+		}
+
+		schema := &metadata.ModelSchemaVersion{
+			ObjectID:    obj.ObjectID,
+			Version:     1,
+			Attributes:  make([]metadata.Attribute, 0),
+			Groups:      make([]metadata.Group, 0),
+			Uniques:     make([]metadata.ObjectUnique, 0),
+			Description: "baseline",
+			OwnerID:     obj.OwnerID,
+			Creator:     conf.User,
+			CreateTime:  metadata.Now(),
+		}
+
+		err = db.Table(common.BKTableNameObjAttDes).Find(cond).Sort(common.BKFieldID).All(ctx, &schema.Attributes)
+		if err != nil {
+			blog.Errorf("get model %s attributes failed, err: %v", obj.ObjectID, err)
+			return err
+		}
+
+		err = db.Table(common.BKTableNamePropertyGroup).Find(cond).Sort(common.BKFieldID).All(ctx, &schema.Groups)
+		if err != nil {
+			blog.Errorf("get model %s attribute groups failed, err: %v", obj.ObjectID, err)
+			return err
+		}
+
+		err = db.Table(common.BKTableNameObjUnique).Find(cond).Sort(common.BKFieldID).All(ctx, &schema.Uniques)
+		if err != nil {
+			blog.Errorf("get model %s uniques failed, err: %v", obj.ObjectID, err)
+			return err
+		}
+
+		err = db.Table(common.BKTableNameObjSchemaVersion).Insert(ctx, schema)
+		if err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("add model %s baseline schema version failed, err: %v", obj.ObjectID, err)
+			return err
+		}
+	}
+
+	return nil
+}
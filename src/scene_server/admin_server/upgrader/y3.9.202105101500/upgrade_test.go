@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202105101500
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestUpgradeBaselineSchemaVersions(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	conf := &upgrader.Config{OwnerID: common.BKDefaultOwnerID, User: "migrate"}
+
+	objs := []map[string]interface{}{
+		{common.BKFieldID: 1, common.BKObjIDField: "switch", common.BKOwnerIDField: common.BKDefaultOwnerID},
+		{common.BKFieldID: 2, common.BKObjIDField: "router", common.BKOwnerIDField: common.BKDefaultOwnerID},
+	}
+	if err := db.Table(common.BKTableNameObjDes).Insert(ctx, objs); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	attrs := []map[string]interface{}{
+		{common.BKFieldID: 2, common.BKObjIDField: "switch", common.BKPropertyIDField: "vendor",
+			common.BKOwnerIDField: common.BKDefaultOwnerID, common.CreateTimeField: now, common.LastTimeField: now},
+		{common.BKFieldID: 1, common.BKObjIDField: "switch", common.BKPropertyIDField: "name",
+			common.BKOwnerIDField: common.BKDefaultOwnerID, common.CreateTimeField: now, common.LastTimeField: now},
+		{common.BKFieldID: 3, common.BKObjIDField: "router", common.BKPropertyIDField: "name",
+			common.BKOwnerIDField: common.BKDefaultOwnerID, common.CreateTimeField: now, common.LastTimeField: now},
+	}
+	if err := db.Table(common.BKTableNameObjAttDes).Insert(ctx, attrs); err != nil {
+		t.Fatal(err)
+	}
+	groups := []map[string]interface{}{
+		{common.BKFieldID: 1, common.BKObjIDField: "switch", "bk_group_id": "default",
+			common.BKOwnerIDField: common.BKDefaultOwnerID},
+	}
+	if err := db.Table(common.BKTableNamePropertyGroup).Insert(ctx, groups); err != nil {
+		t.Fatal(err)
+	}
+
+	// router already has versions, its baseline must not be added again.
+	exist := metadata.ModelSchemaVersion{ObjectID: "router", Version: 3, OwnerID: common.BKDefaultOwnerID}
+	if err := db.Table(common.BKTableNameObjSchemaVersion).Insert(ctx, exist); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := upgrade(ctx, db, conf); err != nil {
+			t.Fatalf("upgrade failed, err: %v", err)
+		}
+	}
+
+	versions := make([]metadata.ModelSchemaVersion, 0)
+	if err := db.Table(common.BKTableNameObjSchemaVersion).Find(nil).Sort("bk_obj_id").All(ctx,
+		&versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 || versions[0].ObjectID != "router" || versions[0].Version != 3 {
+		t.Fatalf("exactly one baseline should be added for the model without versions, got: %+v", versions)
+	}
+
+	baseline := versions[1]
+	if baseline.ObjectID != "switch" || baseline.Version != 1 || baseline.Creator != conf.User {
+		t.Fatalf("baseline version is invalid, got: %+v", baseline)
+	}
+	if len(baseline.Attributes) != 2 || baseline.Attributes[0].PropertyID != "name" ||
+		baseline.Attributes[1].PropertyID != "vendor" || len(baseline.Groups) != 1 || len(baseline.Uniques) != 0 {
+		t.Fatalf("baseline should snapshot the model's own schema sorted by id, got: %+v", baseline)
+	}
+
+	// concurrent saves of the same version are rejected by the unique index.
+	dup := metadata.ModelSchemaVersion{ObjectID: "switch", Version: 1, OwnerID: common.BKDefaultOwnerID}
+	err := db.Table(common.BKTableNameObjSchemaVersion).Insert(ctx, dup)
+	if err == nil || !db.IsDuplicatedError(err) {
+		t.Fatalf("duplicated schema version should be rejected, err: %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202105201500
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+func addServiceTemplateVersionTables(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableNames := []string{common.BKTableNameServiceTemplateVersion, common.BKTableNameServiceTemplateRollout}
+	for _, tableName := range tableNames {
+		exists, err := db.HasTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err = db.CreateTable(ctx, tableName); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func addIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableIndexes := map[string][]types.Index{
+		common.BKTableNameServiceTemplateVersion: {
+			{
+				Keys:       map[string]int32{common.BKServiceTemplateIDField: 1, "version": 1, common.BKOwnerIDField: 1},
+				Name:       "service_template_id_1_version_1_bk_supplier_account_1",
+				Unique:     true,
+				Background: true,
+			},
+		},
+		common.BKTableNameServiceTemplateRollout: {
+			{
+				Keys:       map[string]int32{common.BKFieldID: 1},
+				Name:       "id_1",
+				Unique:     true,
+				Background: true,
+			},
+			{
+				Keys:       map[string]int32{common.BKAppIDField: 1, common.BKServiceTemplateIDField: 1},
+				Name:       "bk_biz_id_1_service_template_id_1",
+				Background: true,
+			},
+			{
+				// a service template can only have one running or paused rollout, see the rollout's ActiveKey
+				Keys:       map[string]int32{"active_key": 1},
+				Name:       "active_key_1",
+				Unique:     true,
+				Background: true,
+			},
+		},
+	}
+
+	for tableName, indexes := range tableIndexes {
+		for _, index := range indexes {
+			err := db.Table(tableName).CreateIndex(ctx, index)
+			if err != nil && !db.IsDuplicatedError(err) {
+				blog.ErrorJSON("add index %s for table %s failed, err:%s", index, tableName, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addBaselineServiceTemplateVersions save the current content of the existing service templates as their first
+// versions, so that the first rollout after the upgrade can be rolled back to it.
+func addBaselineServiceTemplateVersions(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	templates := make([]metadata.ServiceTemplate, 0)
+	if err := db.Table(common.BKTableNameServiceTemplate).Find(nil).All(ctx, &templates); err != nil {
+		blog.Errorf("get service templates failed, err: %v", err)
+		return err
+	}
+
+	for _, template := range templates {
+		filter := map[string]interface{}{common.BKServiceTemplateIDField: template.ID}
+		cnt, err := db.Table(common.BKTableNameServiceTemplateVersion).Find(filter).Count(ctx)
+		if err != nil {
+			blog.Errorf("count service template %d versions failed, err: %v", template.ID, err)
+			return err
+		}
+		if cnt > 0 {
+			continue
+		}
+
+		processTemplates := make([]metadata.ProcessTemplate, 0)
+		err = db.Table(common.BKTableNameProcessTemplate).Find(filter).Sort(common.BKFieldID).All(ctx,
+			&processTemplates)
+		if err != nil {
+			blog.Errorf("get service template %d process templates failed, err: %v", template.ID, err)
+			return err
+		}
+
+		id, err := db.NextSequence(ctx, common.BKTableNameServiceTemplateVersion)
+		if err != nil {
+			blog.Errorf("generate service template version id failed, err: %v", err)
+			return err
+		}
+
+		version := metadata.ServiceTemplateVersion{
+			ID:                int64(id),
+			BizID:             template.BizID,
+			ServiceTemplateID: template.ID,
+			Version:           1,
+			Name:              template.Name,
+			ServiceCategoryID: template.ServiceCategoryID,
+			ProcessTemplates:  processTemplates,
+			Creator:           conf.User,
+			CreateTime:        time.Now(),
+			SupplierAccount:   template.SupplierAccount,
+		}
+		err = db.Table(common.BKTableNameServiceTemplateVersion).Insert(ctx, &version)
+		if err != nil && !db.IsDuplicatedError(err) {
+			blog.Errorf("add service template %d baseline version failed, err: %v", template.ID, err)
+			return err
+		}
+	}
+
+	return nil
+}
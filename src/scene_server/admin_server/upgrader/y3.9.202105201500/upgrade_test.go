@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202105201500
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestUpgrade(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	conf := &upgrader.Config{OwnerID: common.BKDefaultOwnerID, User: "migrate"}
+
+	templates := []map[string]interface{}{
+		{common.BKFieldID: 1, common.BKAppIDField: 2, common.BKFieldName: "nginx", "service_category_id": 3,
+			common.BKOwnerIDField: common.BKDefaultOwnerID},
+		{common.BKFieldID: 2, common.BKAppIDField: 2, common.BKFieldName: "redis", "service_category_id": 3,
+			common.BKOwnerIDField: common.BKDefaultOwnerID},
+	}
+	if err := db.Table(common.BKTableNameServiceTemplate).Insert(ctx, templates); err != nil {
+		t.Fatal(err)
+	}
+	processTemplates := []map[string]interface{}{
+		{common.BKFieldID: 12, common.BKServiceTemplateIDField: 1, common.BKAppIDField: 2},
+		{common.BKFieldID: 11, common.BKServiceTemplateIDField: 1, common.BKAppIDField: 2},
+		{common.BKFieldID: 21, common.BKServiceTemplateIDField: 2, common.BKAppIDField: 2},
+	}
+	if err := db.Table(common.BKTableNameProcessTemplate).Insert(ctx, processTemplates); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := upgrade(ctx, db, conf); err != nil {
+			t.Fatalf("upgrade failed, err: %v", err)
+		}
+	}
+
+	versions := make([]metadata.ServiceTemplateVersion, 0)
+	if err := db.Table(common.BKTableNameServiceTemplateVersion).Find(nil).Sort(common.BKServiceTemplateIDField).
+		All(ctx, &versions); err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("each service template should have exactly one baseline version, got: %+v", versions)
+	}
+	baseline := versions[0]
+	if baseline.ServiceTemplateID != 1 || baseline.Version != 1 || baseline.Name != "nginx" ||
+		baseline.ServiceCategoryID != 3 || len(baseline.ProcessTemplates) != 2 ||
+		baseline.ProcessTemplates[0].ID != 11 || baseline.Creator != conf.User {
+		t.Fatalf("baseline version is invalid, got: %+v", baseline)
+	}
+
+	// a service template can only have one rollout in progress
+	rollouts := db.Table(common.BKTableNameServiceTemplateRollout)
+	insert := func(id int64, status metadata.RolloutStatus) error {
+		return rollouts.Insert(ctx, metadata.ServiceTemplateRollout{ID: id, ServiceTemplateID: 1, Status: status,
+			ActiveKey: metadata.RolloutActiveKey(1, id, status)})
+	}
+	if err := insert(1, metadata.RolloutStatusFinished); err != nil {
+		t.Fatal(err)
+	}
+	if err := insert(2, metadata.RolloutStatusFailed); err != nil {
+		t.Fatal(err)
+	}
+	if err := insert(3, metadata.RolloutStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if err := insert(4, metadata.RolloutStatusPaused); err == nil || !db.IsDuplicatedError(err) {
+		t.Fatalf("the second rollout in progress should be rejected, err: %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202106011500
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+func addAuthRBACTables(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableNames := []string{common.BKTableNameAuthRole, common.BKTableNameAuthRoleBinding,
+		common.BKTableNameAuthUserGroup}
+	for _, tableName := range tableNames {
+		exists, err := db.HasTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err = db.CreateTable(ctx, tableName); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func addIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	tableIndexes := map[string][]types.Index{
+		common.BKTableNameAuthRole: {
+			{
+				Keys:       map[string]int32{common.BKFieldID: 1},
+				Name:       "id_1",
+				Unique:     true,
+				Background: true,
+			},
+			{
+				Keys:       map[string]int32{common.BKFieldName: 1, common.BKOwnerIDField: 1},
+				Name:       "name_1_bk_supplier_account_1",
+				Unique:     true,
+				Background: true,
+			},
+		},
+		common.BKTableNameAuthRoleBinding: {
+			{
+				Keys:       map[string]int32{common.BKFieldID: 1},
+				Name:       "id_1",
+				Unique:     true,
+				Background: true,
+			},
+			{
+				Keys:       map[string]int32{"subject_type": 1, "subject": 1},
+				Name:       "subject_type_1_subject_1",
+				Background: true,
+			},
+			{
+				Keys:       map[string]int32{"role_id": 1},
+				Name:       "role_id_1",
+				Background: true,
+			},
+		},
+		common.BKTableNameAuthUserGroup: {
+			{
+				Keys:       map[string]int32{common.BKFieldID: 1},
+				Name:       "id_1",
+				Unique:     true,
+				Background: true,
+			},
+			{
+				Keys:       map[string]int32{common.BKFieldName: 1, common.BKOwnerIDField: 1},
+				Name:       "name_1_bk_supplier_account_1",
+				Unique:     true,
+				Background: true,
+			},
+			{
+				Keys:       map[string]int32{"members": 1},
+				Name:       "members_1",
+				Background: true,
+			},
+		},
+	}
+
+	for tableName, indexes := range tableIndexes {
+		for _, index := range indexes {
+			err := db.Table(tableName).CreateIndex(ctx, index)
+			if err != nil && !db.IsDuplicatedError(err) {
+				blog.ErrorJSON("add index %s for table %s failed, err:%s", index, tableName, err)
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// addDefaultAdminRole add a role with all the actions and bind it to the configured admin user globally,
+// so that the system can be managed when the rbac authorizer is enabled.
+func addDefaultAdminRole(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	count, err := db.Table(common.BKTableNameAuthRole).Find(map[string]interface{}{}).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	roleID, err := db.NextSequence(ctx, common.BKTableNameAuthRole)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	role := metadata.AuthRole{
+		ID:              int64(roleID),
+		Name:            "admin",
+		Description:     "built-in role with all the permissions",
+		Actions:         []string{metadata.AuthRoleAllActions},
+		Creator:         conf.User,
+		Modifier:        conf.User,
+		CreateTime:      now,
+		LastTime:        now,
+		SupplierAccount: conf.OwnerID,
+	}
+	if err := db.Table(common.BKTableNameAuthRole).Insert(ctx, role); err != nil {
+		return err
+	}
+
+	if conf.AdminUser == "" {
+		blog.Warnf("no admin user is configured, the built-in admin role %d is not bound to any user", role.ID)
+		return nil
+	}
+
+	bindingID, err := db.NextSequence(ctx, common.BKTableNameAuthRoleBinding)
+	if err != nil {
+		return err
+	}
+	binding := metadata.AuthRoleBinding{
+		ID:              int64(bindingID),
+		RoleID:          role.ID,
+		SubjectType:     metadata.AuthSubjectUser,
+		Subject:         conf.AdminUser,
+		Scope:           metadata.AuthScope{Type: metadata.AuthScopeGlobal},
+		Creator:         conf.User,
+		CreateTime:      now,
+		SupplierAccount: conf.OwnerID,
+	}
+	return db.Table(common.BKTableNameAuthRoleBinding).Insert(ctx, binding)
+}
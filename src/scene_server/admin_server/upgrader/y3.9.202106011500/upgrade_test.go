@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202106011500
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestAddDefaultAdminRole(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	conf := &upgrader.Config{OwnerID: common.BKDefaultOwnerID, User: "migrate", AdminUser: "ops"}
+
+	for i := 0; i < 2; i++ {
+		if err := addDefaultAdminRole(ctx, db, conf); err != nil {
+			t.Fatalf("add default admin role failed, err: %v", err)
+		}
+	}
+
+	roles := make([]metadata.AuthRole, 0)
+	if err := db.Table(common.BKTableNameAuthRole).Find(nil).All(ctx, &roles); err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 1 {
+		t.Fatalf("exactly one admin role should be added, got: %+v", roles)
+	}
+
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	if err := db.Table(common.BKTableNameAuthRoleBinding).Find(nil).All(ctx, &bindings); err != nil {
+		t.Fatal(err)
+	}
+	if len(bindings) != 1 || bindings[0].Subject != "ops" || bindings[0].RoleID != roles[0].ID {
+		t.Fatalf("the admin role should be bound to the configured admin user, got: %+v", bindings)
+	}
+}
+
+func TestAddDefaultAdminRoleWithoutAdminUser(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+	conf := &upgrader.Config{OwnerID: common.BKDefaultOwnerID, User: "migrate"}
+
+	if err := addDefaultAdminRole(ctx, db, conf); err != nil {
+		t.Fatalf("add default admin role failed, err: %v", err)
+	}
+
+	count, err := db.Table(common.BKTableNameAuthRoleBinding).Find(nil).Count(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("the admin role should not be bound when no admin user is configured, got %d bindings", count)
+	}
+}
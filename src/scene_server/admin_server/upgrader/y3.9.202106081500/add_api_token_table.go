@@ -0,0 +1,69 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202106081500
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+func addAPITokenTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	exists, err := db.HasTable(ctx, common.BKTableNameAPIToken)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = db.CreateTable(ctx, common.BKTableNameAPIToken); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addAPITokenIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	indexes := []types.Index{
+		{
+			Keys:       map[string]int32{common.BKFieldID: 1},
+			Name:       "id_1",
+			Unique:     true,
+			Background: true,
+		},
+		{
+			Keys:       map[string]int32{"token_hash": 1},
+			Name:       "token_hash_1",
+			Unique:     true,
+			Background: true,
+		},
+		{
+			Keys:       map[string]int32{"user": 1, common.BKOwnerIDField: 1},
+			Name:       "user_1_bk_supplier_account_1",
+			Background: true,
+		},
+	}
+
+	for _, index := range indexes {
+		err := db.Table(common.BKTableNameAPIToken).CreateIndex(ctx, index)
+		if err != nil && !db.IsDuplicatedError(err) {
+			blog.ErrorJSON("add index %s for table %s failed, err:%s", index, common.BKTableNameAPIToken, err)
+			return err
+		}
+	}
+
+	return nil
+}
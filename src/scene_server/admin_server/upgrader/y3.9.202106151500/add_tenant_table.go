@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202106151500
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+func addTenantTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	exists, err := db.HasTable(ctx, common.BKTableNameTenant)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		if err = db.CreateTable(ctx, common.BKTableNameTenant); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+
+	index := types.Index{
+		Keys:       map[string]int32{common.BKOwnerIDField: 1},
+		Name:       "bk_supplier_account_1",
+		Unique:     true,
+		Background: true,
+	}
+	err = db.Table(common.BKTableNameTenant).CreateIndex(ctx, index)
+	if err != nil && !db.IsDuplicatedError(err) {
+		blog.ErrorJSON("add index %s for table %s failed, err:%s", index, common.BKTableNameTenant, err)
+		return err
+	}
+	return nil
+}
+
+// addExistTenants records the supplier accounts that already have models as enabled tenants
+func addExistTenants(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	accounts, err := db.Table(common.BKTableNameObjDes).Distinct(ctx, common.BKOwnerIDField, map[string]interface{}{})
+	if err != nil {
+		return err
+	}
+	accounts = append(accounts, common.BKDefaultOwnerID)
+
+	now := time.Now()
+	for _, account := range accounts {
+		supplierAccount, ok := account.(string)
+		if !ok || len(supplierAccount) == 0 {
+			continue
+		}
+
+		filter := map[string]interface{}{common.BKOwnerIDField: supplierAccount}
+		count, err := db.Table(common.BKTableNameTenant).Find(filter).Count(ctx)
+		if err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		tenant := metadata.Tenant{
+			SupplierAccount: supplierAccount,
+			Name:            supplierAccount,
+			Status:          metadata.TenantEnabled,
+			Creator:         conf.User,
+			CreateTime:      now,
+			LastTime:        now,
+		}
+		if err := db.Table(common.BKTableNameTenant).Insert(ctx, tenant); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package y3_9_202106151500
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// tenantUniqueIndexes are the unique indexes which need to be unique in a tenant instead of the whole system,
+// so that each tenant can have its own models, their index keys are added with the supplier account.
+// the cloud areas are shared by all the tenants, so the indexes of them are not changed.
+var tenantUniqueIndexes = map[string][]types.Index{
+	common.BKTableNameObjClassification: {
+		{
+			Keys:   map[string]int32{common.BKClassificationIDField: 1},
+			Name:   "idx_unique_classificationID",
+			Unique: true,
+		},
+		{
+			Keys:   map[string]int32{common.BKClassificationNameField: 1},
+			Name:   "idx_unique_classificationName",
+			Unique: true,
+		},
+	},
+	common.BKTableNameObjDes: {
+		{
+			Keys:   map[string]int32{common.BKObjIDField: 1},
+			Name:   "idx_unique_objID",
+			Unique: true,
+		},
+	},
+	common.BKTableNameObjAttDes: {
+		{
+			Keys:   map[string]int32{common.BKObjIDField: 1, common.BKPropertyIDField: 1, common.BKAppIDField: 1},
+			Name:   "idx_unique_objID_propertyID_bizID",
+			Unique: true,
+		},
+		{
+			Keys:   map[string]int32{common.BKObjIDField: 1, common.BKPropertyNameField: 1, common.BKAppIDField: 1},
+			Name:   "idx_unique_objID_propertyName_bizID",
+			Unique: true,
+		},
+	},
+	common.BKTableNamePropertyGroup: {
+		{
+			Keys: map[string]int32{common.BKObjIDField: 1, common.BKAppIDField: 1,
+				common.BKPropertyGroupNameField: 1},
+			Name:   "idx_unique_objID_groupName",
+			Unique: true,
+		},
+	},
+	common.BKTableNameServiceCategory: {
+		{
+			Keys:   map[string]int32{common.BKFieldName: 1, common.BKParentIDField: 1, common.BKAppIDField: 1},
+			Name:   "idx_unique_Name_parentID_bizID",
+			Unique: true,
+		},
+	},
+	common.BKTableNameSubscription: {
+		{
+			Keys:   map[string]int32{common.BKSubscriptionNameField: 1},
+			Name:   "idx_unique_subscriptionName",
+			Unique: true,
+		},
+	},
+}
+
+func changeTenantUniqueIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	for tableName, indexes := range tenantUniqueIndexes {
+		exists, err := db.HasTable(ctx, tableName)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		dbIndexes, err := db.Table(tableName).Indexes(ctx)
+		if err != nil {
+			blog.ErrorJSON("find table(%s) index error. err: %s", tableName, err.Error())
+			return err
+		}
+		dbIndexMap := make(map[string]types.Index)
+		for _, index := range dbIndexes {
+			dbIndexMap[index.Name] = index
+		}
+
+		for _, index := range indexes {
+			if dbIndex, exists := dbIndexMap[index.Name]; exists {
+				// already unique in a tenant, no need to change it again
+				if _, ok := dbIndex.Keys[common.BKOwnerIDField]; ok {
+					continue
+				}
+
+				if err := db.Table(tableName).DropIndex(ctx, index.Name); err != nil {
+					blog.ErrorJSON("drop table(%s) index error. idx name: %s, err: %s", tableName, index.Name,
+						err.Error())
+					return err
+				}
+			}
+
+			keys := map[string]int32{common.BKOwnerIDField: 1}
+			for key, sort := range index.Keys {
+				keys[key] = sort
+			}
+			index.Keys = keys
+			index.Background = true
+			if err := db.Table(tableName).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+				blog.ErrorJSON("create table(%s) index error. index: %s, err: %s", tableName, index, err.Error())
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -18,7 +18,7 @@ import (
 	"fmt"
 	"time"
 
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac/authorizer"
 	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/backbone"
@@ -93,12 +93,12 @@ func Run(ctx context.Context, cancel context.CancelFunc, op *options.ServerOptio
 
 	process.Service.SetEncryptor(accountCryptor)
 
-	authorizer := iam.NewAuthorizer(engine.CoreAPI)
-	service.SetAuthorizer(authorizer)
+	authorize := authorizer.NewAuthorizer(engine.CoreAPI)
+	service.SetAuthorizer(authorize)
 
 	mongoConf := mongoConfig.GetMongoConf()
 
-	process.Service.Logics = logics.NewLogics(service.Engine, accountCryptor, authorizer)
+	process.Service.Logics = logics.NewLogics(service.Engine, accountCryptor, authorize)
 
 	process.setSyncPeriod()
 	syncConf := cloudsync.SyncConf{
@@ -9,6 +9,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/auditlog"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
@@ -788,6 +789,10 @@ func (h *HostSyncor) updateHosts(hosts []*metadata.CloudHost) (*metadata.SyncRes
 			blog.Errorf("updateHosts err:%v, rid:%s", err.Error(), h.readKit.Rid)
 			syncResult.FailInfo.Count++
 			syncResult.FailInfo.IPError[host.PrivateIp] = err.Error()
+			// the locked hosts are skipped so that the other hosts are still synchronized
+			if ccErr, ok := err.(errors.CCErrorCoder); ok && ccErr.GetCode() == common.CCErrHostLocked {
+				continue
+			}
 			return nil, err
 		} else {
 			syncResult.SuccessInfo.Count++
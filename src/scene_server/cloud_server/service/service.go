@@ -21,7 +21,10 @@ import (
 	"configcenter/src/common/cryptor"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/types"
 	"configcenter/src/scene_server/cloud_server/logics"
 	"github.com/emicklei/go-restful"
 )
@@ -66,6 +69,8 @@ func (s *Service) WebService() *restful.Container {
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
+	healthzAPI.Route(healthzAPI.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb cloud server", Tag: types.CC_MODULE_CLOUD})))
 	container.Add(healthzAPI)
 
 	return container
@@ -78,21 +83,32 @@ func (s *Service) initRoute(api *restful.WebService) {
 	})
 
 	// cloud account
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/cloud/account/verify", Handler: s.VerifyConnectivity})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account/validity", Handler: s.SearchAccountValidity})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/cloud/account", Handler: s.CreateAccount})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account", Handler: s.SearchAccount})
-	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/account/{bk_account_id}", Handler: s.UpdateAccount})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/cloud/account/verify", Handler: s.VerifyConnectivity,
+		Request: metadata.CloudAccountVerify{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account/validity", Handler: s.SearchAccountValidity,
+		Request: metadata.SearchAccountValidityOption{}, Response: []metadata.AccountValidityInfo{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/cloud/account", Handler: s.CreateAccount,
+		Request: metadata.CloudAccount{}, Response: metadata.CloudAccount{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account", Handler: s.SearchAccount,
+		Request: metadata.SearchCloudOption{}, Response: metadata.MultipleCloudAccount{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/account/{bk_account_id}", Handler: s.UpdateAccount,
+		Request: map[string]interface{}{}})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/account/{bk_account_id}", Handler: s.DeleteAccount})
 
 	// cloud sync task
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account/vpc/{bk_account_id}", Handler: s.SearchVpc})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/cloud/sync/task", Handler: s.CreateSyncTask})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/task", Handler: s.SearchSyncTask})
-	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/sync/task/{bk_task_id}", Handler: s.UpdateSyncTask})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/account/vpc/{bk_account_id}", Handler: s.SearchVpc,
+		Request: metadata.SearchVpcOption{}, Response: metadata.VpcHostCntResult{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/cloud/sync/task", Handler: s.CreateSyncTask,
+		Request: metadata.CloudSyncTask{}, Response: metadata.CloudSyncTask{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/task", Handler: s.SearchSyncTask,
+		Request: metadata.SearchSyncTaskOption{}, Response: metadata.MultipleCloudSyncTask{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/cloud/sync/task/{bk_task_id}", Handler: s.UpdateSyncTask,
+		Request: map[string]interface{}{}})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/cloud/sync/task/{bk_task_id}", Handler: s.DeleteSyncTask})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/history", Handler: s.SearchSyncHistory})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/region", Handler: s.SearchSyncRegion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/history", Handler: s.SearchSyncHistory,
+		Request: metadata.SearchSyncHistoryOption{}, Response: metadata.MultipleSyncHistory{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/cloud/sync/region", Handler: s.SearchSyncRegion,
+		Request: metadata.SearchSyncRegionOption{}, Response: []metadata.SyncRegion{}})
 
 	utility.AddToRestfulWebService(api)
 }
@@ -248,6 +248,12 @@ func (h *HostSnap) Analyze(msg *string) error {
 		blog.Errorf("snapshot changed, update host %d/%s snapshot failed, err: %v, rid: %s", hostID, innerIP, err, rid)
 		return err
 	}
+	if !res.Result && res.Code == common.CCErrHostLocked {
+		// the snapshot of the locked host is not updated until it's unlocked, it is not regarded as a failure
+		blog.V(4).Infof("snapshot changed, but host %d/%s is locked, skip update, err: %s, rid: %s", hostID, innerIP,
+			res.ErrMsg, rid)
+		return nil
+	}
 	if !res.Result {
 		blog.Errorf("snapshot changed, update host %d/%s snapshot failed, err: %s, rid: %s", hostID, innerIP, res.ErrMsg, rid)
 		return fmt.Errorf("update snapshot failed, err: %s", res.ErrMsg)
@@ -19,7 +19,7 @@ import (
 	"sync"
 	"time"
 
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac/authorizer"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
@@ -206,7 +206,7 @@ func (es *EventServer) initModules() error {
 	blog.Infof("init modules, connected to cc redis, %+v", es.config.Redis)
 
 	// initialize auth authorizer
-	es.service.SetAuthorizer(iam.NewAuthorizer(es.engine.CoreAPI))
+	es.service.SetAuthorizer(authorizer.NewAuthorizer(es.engine.CoreAPI))
 
 	// init subscription stream watcher.
 	subWatcher, err := reflector.NewReflector(es.config.MongoDB.GetMongoConf())
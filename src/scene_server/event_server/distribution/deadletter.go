@@ -0,0 +1,158 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+	"configcenter/src/storage/dal/redis"
+)
+
+// defaultDeadLetterMaxCount is the max count of dead letters kept for a subscriber, the oldest ones are dropped.
+const defaultDeadLetterMaxCount = 1000
+
+// saveDeadLetter saves the events that are failed to be sent after all the retries as a dead letter,
+// the dead letters are saved in a list with the latest one at the head.
+func saveDeadLetter(ctx context.Context, cache redis.Client, subid int64, id string, dists []*metadata.DistInst,
+	sendErr error, attempts int64) error {
+
+	letter := metadata.DeadLetter{
+		ID:             id,
+		SubscriptionID: subid,
+		Events:         make([]metadata.DistInst, len(dists)),
+		Error:          sendErr.Error(),
+		Attempts:       attempts,
+		FailTime:       metadata.Now(),
+	}
+	for index, dist := range dists {
+		letter.Events[index] = *dist
+	}
+
+	data, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	key := types.EventCacheSubscriberDeadLetterKeyPrefix + fmt.Sprint(subid)
+	pipe := cache.Pipeline()
+	pipe.LPush(key, data)
+	pipe.LTrim(key, 0, defaultDeadLetterMaxCount-1)
+	_, err = pipe.Exec()
+	return err
+}
+
+// getDeadLetters returns all the dead letters of the subscriber with their raw data, the latest ones are first.
+func getDeadLetters(ctx context.Context, cache redis.Client, subid int64) ([]metadata.DeadLetter, []string, error) {
+	key := types.EventCacheSubscriberDeadLetterKeyPrefix + fmt.Sprint(subid)
+	raws, err := cache.LRange(ctx, key, 0, -1).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	letters := make([]metadata.DeadLetter, 0, len(raws))
+	letterRaws := make([]string, 0, len(raws))
+	for _, raw := range raws {
+		letter := metadata.DeadLetter{}
+		if err := json.Unmarshal([]byte(raw), &letter); err != nil {
+			return nil, nil, fmt.Errorf("unmarshal dead letter %s failed, err: %v", raw, err)
+		}
+		letters = append(letters, letter)
+		letterRaws = append(letterRaws, raw)
+	}
+	return letters, letterRaws, nil
+}
+
+// ListDeadLetters lists the dead letters of the subscriber by page, the latest ones are first.
+func ListDeadLetters(ctx context.Context, cache redis.Client, subid int64, page metadata.BasePage) (
+	*metadata.RspDeadLetterSearch, error) {
+
+	letters, _, err := getDeadLetters(ctx, cache, subid)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &metadata.RspDeadLetterSearch{Count: uint64(len(letters)), Info: make([]metadata.DeadLetter, 0)}
+	if page.Start < 0 || page.Start >= len(letters) {
+		return result, nil
+	}
+	end := len(letters)
+	if page.Limit > 0 && page.Start+page.Limit < end {
+		end = page.Start + page.Limit
+	}
+	result.Info = letters[page.Start:end]
+	return result, nil
+}
+
+// replayDeadLetterScript removes the dead letter and pushes its events to the replay queue atomically, so that
+// the dead letter can not be replayed twice by concurrent requests, and it's not lost if the push is failed.
+// returns 1 if the dead letter is replayed, or 0 if it's already removed.
+// KEYS[1] is the dead letter list of the subscriber
+// KEYS[2] is the replay queue of the subscriber
+// ARGV[1] is the raw dead letter
+// ARGV[2:] are the events of the dead letter
+const replayDeadLetterScript = `
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+if #ARGV > 1 then
+	redis.call('LPUSH', KEYS[2], unpack(ARGV, 2))
+end
+return 1
+`
+
+// ReplayDeadLetters pushes the events of the dead letters to the replay queue of the subscriber in the order
+// they are failed, and removes the dead letters. returns the number of replayed dead letters.
+func ReplayDeadLetters(ctx context.Context, cache redis.Client, subid int64, opt *metadata.ParamDeadLetterReplay) (
+	int64, error) {
+
+	letters, raws, err := getDeadLetters(ctx, cache, subid)
+	if err != nil {
+		return 0, err
+	}
+
+	key := types.EventCacheSubscriberDeadLetterKeyPrefix + fmt.Sprint(subid)
+	replayQueueKey := types.EventCacheSubscriberReplayQueueKeyPrefix + fmt.Sprint(subid)
+
+	replayed := int64(0)
+	for index := len(letters) - 1; index >= 0; index-- {
+		letter := letters[index]
+		if !opt.All && !util.InStrArr(opt.IDs, letter.ID) {
+			continue
+		}
+
+		args := make([]interface{}, 0, len(letter.Events)+1)
+		args = append(args, raws[index])
+		for _, event := range letter.Events {
+			data, err := json.Marshal(event)
+			if err != nil {
+				return replayed, err
+			}
+			args = append(args, data)
+		}
+
+		moved, err := cache.Eval(ctx, replayDeadLetterScript, []string{key, replayQueueKey}, args...).Result()
+		if err != nil {
+			return replayed, err
+		}
+		if moved != int64(1) {
+			continue
+		}
+		replayed++
+	}
+	return replayed, nil
+}
@@ -352,8 +352,9 @@ func (d *Distributor) watchAndDistributeWithCursor(cursorType watch.CursorType,
 	blog.Info("start watching and distribute for resource[%+v] with opts[%+v]", cursorType, opts)
 	defer blog.Info("stop watching and distribute for resource[%+v] with opts[%+v]", cursorType, opts)
 
-	// build a resource watcher.
-	header := util.BuildHeader(common.GetIdentification(), common.BKDefaultOwnerID)
+	// build a resource watcher, watches all the supplier accounts' events, the events are
+	// dispatched to the subscribers of the same supplier account by the event handler.
+	header := util.BuildHeader(common.GetIdentification(), common.BKSuperOwnerID)
 	watcher := ewatcher.NewWatcher(d.ctx, header, d.cache, d.engine.CoreAPI.CacheService().Cache())
 
 	// start from this cursor.
@@ -195,6 +195,13 @@ func (h *EventHandler) Handle(nodes []*watch.ChainNode, eventDetailStrs []string
 			Cursor:        event.Cursor,
 			UpdateFields:  updateFields,
 			DeletedFields: deletedFields,
+			OwnerID:       nodes[idx].SupplierAccount,
+		}
+		if eventInst.OwnerID == "" {
+			eventInst.OwnerID = gjson.Get(*jsonDetailStr, common.BKOwnerIDField).String()
+		}
+		if eventInst.OwnerID == "" {
+			eventInst.OwnerID = common.BKDefaultOwnerID
 		}
 
 		cursor := &watch.Cursor{}
@@ -361,12 +368,28 @@ func (h *EventHandler) handleEvent(event *metadata.EventInst) error {
 
 	// find all subscribers on this event type.
 	subscribers := h.distributer.FindSubscribers(event.GetType())
+	filterCtx := &eventFilterContext{handler: h, event: event}
 
 	// distribute to subscribers.
 	for _, subscriber := range subscribers {
+		// skip the subscribers whose filter does not match the event.
+		subscription := h.distributer.FindSubscription(subscriber)
+		if subscription == nil {
+			continue
+		}
+		// subscribers can only receive the events of their own supplier account.
+		if subscription.OwnerID != event.OwnerID {
+			h.eventHandleTotal.WithLabelValues("FilteredOut").Inc()
+			continue
+		}
+		if !filterCtx.match(&subscription.Filter) {
+			h.eventHandleTotal.WithLabelValues("FilteredOut").Inc()
+			continue
+		}
+
 		// push to subscriber pusher.
 		cost := time.Now()
-		err := h.sendToPusher(subscriber, &metadata.DistInst{EventInst: *event})
+		err := h.sendToPusher(subscriber, &metadata.DistInst{EventInst: projectEvent(event, &subscription.Filter)})
 		h.eventHandleDuration.WithLabelValues("PushEventToPusher").Observe(time.Since(cost).Seconds())
 
 		if err != nil {
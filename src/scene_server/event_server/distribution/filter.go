@@ -0,0 +1,149 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/querybuilder"
+	"configcenter/src/common/util"
+)
+
+// eventFilterContext is the context of an event to check the subscription filters, the businesses of the
+// event are got only once when they are needed.
+type eventFilterContext struct {
+	handler *EventHandler
+	event   *metadata.EventInst
+
+	bizIDs       []int64
+	bizIDsLoaded bool
+}
+
+// getEventData returns the data of the event that the subscription filter is checked against, it's the
+// current data, or the previous data of the delete event.
+func getEventData(event *metadata.EventInst) map[string]interface{} {
+	if len(event.Data) == 0 {
+		return nil
+	}
+	data := event.Data[0].CurData
+	if data == nil {
+		data = event.Data[0].PreData
+	}
+	mapData, _ := data.(map[string]interface{})
+	return mapData
+}
+
+// getBizIDs returns the businesses that the event belongs to, the business of a host is got from its module
+// relations, so the host that has been deleted does not belong to any business.
+func (c *eventFilterContext) getBizIDs() []int64 {
+	if c.bizIDsLoaded {
+		return c.bizIDs
+	}
+	c.bizIDsLoaded = true
+
+	data := getEventData(c.event)
+	if data == nil {
+		return c.bizIDs
+	}
+
+	if bizID, exist := data[common.BKAppIDField]; exist {
+		id, err := util.GetInt64ByInterface(bizID)
+		if err == nil && id > 0 {
+			c.bizIDs = []int64{id}
+		}
+		return c.bizIDs
+	}
+
+	if c.event.ObjType != common.BKInnerObjIDHost {
+		return c.bizIDs
+	}
+	hostID, err := util.GetInt64ByInterface(data[common.BKHostIDField])
+	if err != nil {
+		return c.bizIDs
+	}
+
+	filter := map[string]interface{}{common.BKHostIDField: hostID}
+	bizIDs, err := c.handler.distributer.db.Table(common.BKTableNameModuleHostConfig).Distinct(c.handler.ctx,
+		common.BKAppIDField, filter)
+	if err != nil {
+		blog.Errorf("get businesses of host %d for event %d failed, err: %v", hostID, c.event.ID, err)
+		return c.bizIDs
+	}
+	for _, bizID := range bizIDs {
+		id, err := util.GetInt64ByInterface(bizID)
+		if err == nil {
+			c.bizIDs = append(c.bizIDs, id)
+		}
+	}
+	return c.bizIDs
+}
+
+// match checks if the event matches the subscription filter
+func (c *eventFilterContext) match(filter *metadata.SubscriptionFilter) bool {
+	if len(filter.BizIDs) > 0 {
+		matched := false
+		for _, bizID := range c.getBizIDs() {
+			if util.InArray(bizID, filter.BizIDs) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if filter.Condition != nil && filter.Condition.Rule != nil {
+		data := getEventData(c.event)
+		if data == nil {
+			return false
+		}
+		if !filter.Condition.Match(querybuilder.MatchData(data)) {
+			return false
+		}
+	}
+	return true
+}
+
+// projectEvent returns the event with only the fields of the subscription filter in its data
+func projectEvent(event *metadata.EventInst, filter *metadata.SubscriptionFilter) metadata.EventInst {
+	projected := *event
+	if len(filter.Fields) == 0 {
+		return projected
+	}
+
+	projected.Data = make([]metadata.EventData, len(event.Data))
+	for idx, data := range event.Data {
+		projected.Data[idx] = metadata.EventData{
+			CurData: projectData(data.CurData, filter.Fields),
+			PreData: projectData(data.PreData, filter.Fields),
+		}
+	}
+	return projected
+}
+
+func projectData(data interface{}, fields []string) interface{} {
+	mapData, ok := data.(map[string]interface{})
+	if !ok {
+		return data
+	}
+
+	projected := make(map[string]interface{})
+	for _, field := range fields {
+		if value, exist := mapData[field]; exist {
+			projected[field] = value
+		}
+	}
+	return projected
+}
@@ -15,6 +15,9 @@ package distribution
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,6 +35,7 @@ import (
 	"configcenter/src/storage/dal/redis"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/xid"
 )
 
 var httpCli = httpclient.NewHttpClient()
@@ -42,8 +46,17 @@ const (
 
 	// defaultEventCacheSubscriberCursorExpire is default expire duration for subscriber cursor.
 	defaultEventCacheSubscriberCursorExpire = 6 * time.Hour
+
+	// defaultRetryBackoff is the backoff before the first retry of a failed callback, it's doubled every retry.
+	defaultRetryBackoff = time.Second
+
+	// defaultMaxRetryBackoff is the max backoff between the retries of a failed callback.
+	defaultMaxRetryBackoff = time.Minute
 )
 
+// errSubscriptionNotFound means the subscription is deleted, the events are dropped.
+var errSubscriptionNotFound = errors.New("subscription not found")
+
 // EventPusher sends target events to subscribers in callback mode.
 type EventPusher struct {
 	ctx    context.Context
@@ -157,32 +170,161 @@ func (s *EventPusher) cleaning() {
 	}
 }
 
-// push sends new event to target subscriber base on callback url.
-func (s *EventPusher) push(dist *metadata.DistInst) error {
-	// try to find new subscription data everytime, and send event
-	// with newest http callback url.
+// delivery is a callback of the events to the subscriber, the retries of a failed delivery have the same id.
+type delivery struct {
+	ID       string               `json:"id"`
+	Attempts int64                `json:"attempts"`
+	Events   []*metadata.DistInst `json:"events"`
+}
+
+// popRetryScript pops the earliest failed delivery if its retry time is reached. returns {delivery, retry time}
+// if it's popped, or {empty string, retry time} if it's not reached, or an empty array if there is no failed delivery.
+// KEYS[1] is the sorted set of the failed deliveries of the subscriber, the score is the retry time in milliseconds
+// ARGV[1] is the current time in milliseconds
+const popRetryScript = `
+local items = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if #items == 0 then
+	return {}
+end
+if tonumber(items[2]) > tonumber(ARGV[1]) then
+	return {'', items[2]}
+end
+redis.call('ZREM', KEYS[1], items[1])
+return items
+`
+
+// addRetryScript adds a failed delivery to be retried at the retry time.
+// KEYS[1] is the sorted set of the failed deliveries of the subscriber
+// ARGV[1] is the retry time in milliseconds
+// ARGV[2] is the failed delivery
+const addRetryScript = `
+return redis.call('ZADD', KEYS[1], ARGV[1], ARGV[2])
+`
+
+// getRetryBackoff returns the backoff before the retry of a delivery that has been attempted the times,
+// it starts from defaultRetryBackoff and is doubled every retry, but not more than defaultMaxRetryBackoff.
+func getRetryBackoff(attempts int64) time.Duration {
+	backoff := defaultRetryBackoff
+	for i := int64(1); i < attempts && backoff < defaultMaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > defaultMaxRetryBackoff {
+		return defaultMaxRetryBackoff
+	}
+	return backoff
+}
+
+// deliver sends the events to target subscriber. the failed delivery is saved to be retried after the backoff
+// instead of waiting here, so that the following events are not blocked. the events are saved as a dead letter
+// after all the retries are failed.
+func (s *EventPusher) deliver(d *delivery) error {
 	subscription := s.distributer.FindSubscription(s.subid)
 	if subscription == nil {
-		return fmt.Errorf("subscription not found, %+v", s.subid)
+		return errSubscriptionNotFound
 	}
 
-	// setups ownerid here.
-	dist.OwnerID = subscription.OwnerID
+	// stats, the retries are counted as one delivery.
+	if d.Attempts == 0 {
+		s.increaseTotal(s.subid)
+	}
 
-	var errFinal error
+	d.Attempts++
+	err := s.push(d.Events, d.ID)
+	if err == nil || err == errSubscriptionNotFound {
+		return err
+	}
 
-	// stats.
-	s.increaseTotal(subscription.SubscriptionID)
-	defer func() {
-		if errFinal != nil {
-			s.increaseFailure(subscription.SubscriptionID)
+	if d.Attempts <= subscription.GetRetryTimes() {
+		backoff := getRetryBackoff(d.Attempts)
+		retryErr := s.addRetry(d, backoff)
+		if retryErr == nil {
+			blog.Warnf("send events to subscriber[%d] failed, retry after %s, attempts: %d, err: %v", s.subid,
+				backoff, d.Attempts, err)
+			s.pusherHandleTotal.WithLabelValues("RetryCallback").Inc()
+			return err
 		}
-	}()
+		blog.Errorf("save the failed delivery of subscriber[%d] to retry failed, err: %v", s.subid, retryErr)
+	}
+	s.increaseFailure(s.subid)
+
+	if saveErr := saveDeadLetter(s.ctx, s.cache, s.subid, d.ID, d.Events, err, d.Attempts); saveErr != nil {
+		blog.Errorf("save dead letter for subscriber[%d] failed, err: %v", s.subid, saveErr)
+	} else {
+		s.pusherHandleTotal.WithLabelValues("DeadLetter").Inc()
+	}
+	return err
+}
+
+// addRetry saves the failed delivery to be retried after the backoff
+func (s *EventPusher) addRetry(d *delivery, backoff time.Duration) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+
+	retryKey := types.EventCacheSubscriberRetryKeyPrefix + fmt.Sprint(s.subid)
+	retryTime := time.Now().Add(backoff).UnixNano() / int64(time.Millisecond)
+	return s.cache.Eval(s.ctx, addRetryScript, []string{retryKey}, retryTime, string(data)).Err()
+}
+
+// popRetry pops a failed delivery whose retry time is reached. if there is no such delivery, returns the
+// duration to wait until the earliest retry time, or 0 if there is no failed delivery.
+func (s *EventPusher) popRetry() (*delivery, time.Duration, error) {
+	retryKey := types.EventCacheSubscriberRetryKeyPrefix + fmt.Sprint(s.subid)
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	result, err := s.cache.Eval(s.ctx, popRetryScript, []string{retryKey}, now).Result()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items, ok := result.([]interface{})
+	if !ok {
+		return nil, 0, fmt.Errorf("pop retry script returns invalid result %v", result)
+	}
+	if len(items) != 2 {
+		return nil, 0, nil
+	}
+	data, _ := items[0].(string)
+	retryTimeStr, _ := items[1].(string)
+	retryTime, err := strconv.ParseFloat(retryTimeStr, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("pop retry script returns invalid retry time %v", items[1])
+	}
+	if data == "" {
+		return nil, time.Duration(int64(retryTime)-now) * time.Millisecond, nil
+	}
+
+	d := new(delivery)
+	if err := json.Unmarshal([]byte(data), d); err != nil {
+		return nil, 0, fmt.Errorf("unmarshal failed delivery %s failed, err: %v", data, err)
+	}
+	return d, 0, nil
+}
+
+// push sends new events to target subscriber base on callback url, the callback body is the event if the
+// subscription is not in batch mode, or the array of events.
+func (s *EventPusher) push(dists []*metadata.DistInst, deliveryID string) error {
+	// try to find new subscription data everytime, and send event
+	// with newest http callback url.
+	subscription := s.distributer.FindSubscription(s.subid)
+	if subscription == nil {
+		return errSubscriptionNotFound
+	}
+
+	// setups ownerid here.
+	for _, dist := range dists {
+		dist.OwnerID = subscription.OwnerID
+	}
 
 	// marshal message data.
-	distData, err := json.Marshal(dist)
+	var distData []byte
+	var err error
+	if subscription.GetBatchSize() > 1 {
+		distData, err = json.Marshal(dists)
+	} else {
+		distData, err = json.Marshal(dists[0])
+	}
 	if err != nil {
-		errFinal = err
 		return err
 	}
 
@@ -190,9 +332,15 @@ func (s *EventPusher) push(dist *metadata.DistInst) error {
 	body := bytes.NewBuffer(distData)
 	req, err := http.NewRequest("POST", subscription.CallbackURL, body)
 	if err != nil {
-		errFinal = err
 		return err
 	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(metadata.EventCallbackDeliveryHeader, deliveryID)
+	if len(subscription.Secret) != 0 {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(metadata.EventCallbackTimestampHeader, timestamp)
+		req.Header.Set(metadata.EventCallbackSignatureHeader, signCallback(subscription.Secret, timestamp, distData))
+	}
 
 	// callback timeout.
 	var duration time.Duration
@@ -205,7 +353,6 @@ func (s *EventPusher) push(dist *metadata.DistInst) error {
 	// send now.
 	resp, err := httpCli.DoWithTimeout(duration, req)
 	if err != nil {
-		errFinal = err
 		return err
 	}
 	defer resp.Body.Close()
@@ -213,25 +360,21 @@ func (s *EventPusher) push(dist *metadata.DistInst) error {
 	// read response.
 	respData, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		errFinal = err
 		return err
 	}
 
 	// confirm mode.
 	if subscription.ConfirmMode == metadata.ConfirmModeHTTPStatus {
 		if strconv.Itoa(resp.StatusCode) != subscription.ConfirmPattern {
-			errFinal = err
 			return fmt.Errorf("not confirm http pattern, received %s", respData)
 		}
 	} else if subscription.ConfirmMode == metadata.ConfirmModeRegular {
 		pattern, err := regexp.Compile(subscription.ConfirmPattern)
 		if err != nil {
-			errFinal = err
 			return fmt.Errorf("build regexp error, %+v", err)
 		}
 
 		if !pattern.Match(respData) {
-			errFinal = err
 			return fmt.Errorf("not confirm regular pattern, received %s", respData)
 		}
 	} else {
@@ -239,15 +382,96 @@ func (s *EventPusher) push(dist *metadata.DistInst) error {
 	}
 
 	// mark resource type and action cursor.
-	eventType := dist.EventInst.GetType()
-	suberCursorKey := types.EventCacheSubscriberCursorKey(eventType, s.subid)
+	for _, dist := range dists {
+		eventType := dist.EventInst.GetType()
+		suberCursorKey := types.EventCacheSubscriberCursorKey(eventType, s.subid)
 
-	if err := s.cache.Set(s.ctx, suberCursorKey, dist.Cursor, defaultEventCacheSubscriberCursorExpire).Err(); err != nil {
-		blog.Warnf("save subscriber[%d] cursor for action[%s] failed, %+v", s.subid, eventType, err)
+		if err := s.cache.Set(s.ctx, suberCursorKey, dist.Cursor, defaultEventCacheSubscriberCursorExpire).Err(); err != nil {
+			blog.Warnf("save subscriber[%d] cursor for action[%s] failed, %+v", s.subid, eventType, err)
+		}
 	}
 	return nil
 }
 
+// signCallback returns the signature of the callback body, it's the hex encoded HMAC-SHA256 of
+// "{timestamp}.{body}" with the subscription secret.
+func signCallback(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return metadata.EventCallbackSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// pop pops the delivery to be sent. the failed deliveries whose retry time is reached are popped first, then
+// the replayed events before the new events, and at most batch size events are popped once. the replayed
+// events are not expired.
+func (s *EventPusher) pop() (*delivery, error) {
+	retry, wait, err := s.popRetry()
+	if err != nil {
+		blog.Errorf("pop failed delivery to retry for subscriber[%d] failed, err: %v", s.subid, err)
+	}
+	if retry != nil {
+		return retry, nil
+	}
+
+	// do not block longer than the earliest retry time.
+	timeout := defaultTransTimeout
+	if wait > 0 && wait < timeout {
+		timeout = wait
+	}
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+
+	replayQueueKey := types.EventCacheSubscriberReplayQueueKeyPrefix + fmt.Sprint(s.subid)
+	eventQueueKey := types.EventCacheSubscriberEventQueueKeyPrefix + fmt.Sprint(s.subid)
+
+	// distDatas is redis brpop results, and you can parse it base on CMD
+	// formats, https://redis.io/commands/brpop.
+	distDatas := s.cache.BRPop(s.ctx, timeout, replayQueueKey, eventQueueKey).Val()
+	if len(distDatas) == 0 || distDatas[1] == types.NilStr || len(distDatas[1]) == 0 {
+		return nil, nil
+	}
+	queueKey := distDatas[0]
+	rawDatas := []string{distDatas[1]}
+
+	batchSize := int64(1)
+	if subscription := s.distributer.FindSubscription(s.subid); subscription != nil {
+		batchSize = subscription.GetBatchSize()
+	}
+	for int64(len(rawDatas)) < batchSize {
+		distData, err := s.cache.RPop(s.ctx, queueKey).Result()
+		if err != nil {
+			if !redis.IsNilErr(err) {
+				blog.Errorf("pop event for subscriber[%d] batch failed, err: %v", s.subid, err)
+			}
+			break
+		}
+		rawDatas = append(rawDatas, distData)
+	}
+
+	dists := make([]*metadata.DistInst, 0)
+	for _, distData := range rawDatas {
+		dist := &metadata.DistInst{}
+		if err := json.Unmarshal([]byte(distData), dist); err != nil {
+			blog.Errorf("unmarshal new event dist inst for subscriber[%d] failed, %+v", s.subid, err)
+			continue
+		}
+
+		if queueKey != replayQueueKey && time.Now().Unix()-dist.EventInst.ActionTime.Unix() > defaultFusingEventExpireSec {
+			// old event, expire it.
+			s.pusherHandleTotal.WithLabelValues("ExpireEventNum").Inc()
+			continue
+		}
+		dists = append(dists, dist)
+	}
+	if len(dists) == 0 {
+		return nil, nil
+	}
+	return &delivery{ID: xid.New().String(), Events: dists}, nil
+}
+
 func (s *EventPusher) run() {
 	// keep cleaning.
 	go s.cleaning()
@@ -261,36 +485,21 @@ func (s *EventPusher) run() {
 
 		// keep sending.
 		cost := time.Now()
-		distDatas := s.cache.BRPop(s.ctx, defaultTransTimeout, types.EventCacheSubscriberEventQueueKeyPrefix+fmt.Sprint(s.subid)).Val()
+		d, _ := s.pop()
 		s.pusherHandleDuration.WithLabelValues("PopSubscriberEvent").Observe(time.Since(cost).Seconds())
 
-		// distDatas is redis brpop results, and you can parse it base on CMD
-		// formats, https://redis.io/commands/brpop.
-		if len(distDatas) == 0 || distDatas[1] == types.NilStr || len(distDatas[1]) == 0 {
-			continue
-		}
-		distData := distDatas[1]
-
-		dist := &metadata.DistInst{}
-		if err := json.Unmarshal([]byte(distData), dist); err != nil {
-			blog.Errorf("unmarshal new event dist inst for subscriber[%d] failed, %+v", s.subid, err)
-			continue
-		}
-
-		if time.Now().Unix()-dist.EventInst.ActionTime.Unix() > defaultFusingEventExpireSec {
-			// old event, expire it.
-			s.pusherHandleTotal.WithLabelValues("ExpireEventNum").Inc()
+		if d == nil {
 			continue
 		}
 
 		// send message to subscriber.
 		cost = time.Now()
-		err := s.push(dist)
+		err := s.deliver(d)
 		s.pusherHandleDuration.WithLabelValues("SendSubscriberEvent").Observe(time.Since(cost).Seconds())
 
 		if err != nil {
 			s.pusherHandleTotal.WithLabelValues("SendCallbackFailed").Inc()
-			blog.Errorf("send event failed, err: %+v, data=[%+v]", err, dist)
+			blog.Errorf("send event failed, err: %+v, delivery: %s, data=[%+v]", err, d.ID, d.Events)
 			continue
 		}
 		s.pusherHandleTotal.WithLabelValues("Success").Inc()
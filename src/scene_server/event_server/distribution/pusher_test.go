@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/alicebob/miniredis"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSignCallback(t *testing.T) {
+	body := []byte(`{"event_type":"instdata"}`)
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1600000000." + string(body)))
+	expect := metadata.EventCallbackSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+
+	if got := signCallback("secret", "1600000000", body); got != expect {
+		t.Fatalf("expect signature %s, got %s", expect, got)
+	}
+	if signCallback("secret", "1600000001", body) == expect {
+		t.Fatalf("the timestamp should be signed")
+	}
+	if signCallback("another", "1600000000", body) == expect {
+		t.Fatalf("the secret should be used to sign")
+	}
+}
+
+func TestGetRetryBackoff(t *testing.T) {
+	expects := map[int64]time.Duration{1: time.Second, 2: 2 * time.Second, 3: 4 * time.Second,
+		7: defaultMaxRetryBackoff, 100: defaultMaxRetryBackoff}
+	for attempts, expect := range expects {
+		if got := getRetryBackoff(attempts); got != expect {
+			t.Errorf("attempts %d: expect backoff %s, got %s", attempts, expect, got)
+		}
+	}
+}
+
+func newTestPusher(t *testing.T, subscription *metadata.Subscription) (*EventPusher, *miniredis.Miniredis) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, err := redis.NewFromConfig(redis.Config{Address: server.Addr(), Database: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	distributer := &Distributor{
+		subscriptions: map[int64]*metadata.Subscription{subscription.SubscriptionID: subscription},
+	}
+	total := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "test_duration"}, []string{"status"})
+	return NewEventPusher(context.Background(), nil, subscription.SubscriptionID, cache, distributer, total,
+		duration), server
+}
+
+func TestDeliverRetryAndDeadLetter(t *testing.T) {
+	var calls, failed int32
+	atomic.StoreInt32(&failed, 1)
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		body, _ := ioutil.ReadAll(r.Body)
+		timestamp := r.Header.Get(metadata.EventCallbackTimestampHeader)
+		if r.Header.Get(metadata.EventCallbackSignatureHeader) != signCallback("secret", timestamp, body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if atomic.LoadInt32(&failed) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer callback.Close()
+
+	retryTimes := int64(1)
+	subscription := &metadata.Subscription{
+		SubscriptionID: 1,
+		CallbackURL:    callback.URL,
+		ConfirmMode:    metadata.ConfirmModeHTTPStatus,
+		ConfirmPattern: "200",
+		Secret:         "secret",
+		RetryTimes:     &retryTimes,
+	}
+	pusher, server := newTestPusher(t, subscription)
+	defer server.Close()
+
+	events := []*metadata.DistInst{{EventInst: metadata.EventInst{ID: 1, Cursor: "cursor"}, SubscriptionID: 1}}
+	first := &delivery{ID: "delivery", Events: events}
+	if err := pusher.deliver(first); err == nil {
+		t.Fatalf("the first delivery should fail")
+	}
+
+	// the failed delivery is not retried before the backoff.
+	retry, wait, err := pusher.popRetry()
+	if err != nil || retry != nil || wait <= 0 || wait > defaultRetryBackoff {
+		t.Fatalf("the retry should wait for the backoff, retry: %+v, wait: %s, err: %v", retry, wait, err)
+	}
+	server.FastForward(defaultRetryBackoff)
+	time.Sleep(defaultRetryBackoff)
+	retry, _, err = pusher.popRetry()
+	if err != nil || retry == nil || retry.ID != "delivery" || retry.Attempts != 1 || len(retry.Events) != 1 {
+		t.Fatalf("the failed delivery should be popped to retry, retry: %+v, err: %v", retry, err)
+	}
+
+	// the retry is failed again and the retry times are used up, the events are saved as a dead letter.
+	if err := pusher.deliver(retry); err == nil {
+		t.Fatalf("the retry should fail")
+	}
+	if retry, wait, err := pusher.popRetry(); err != nil || retry != nil || wait != 0 {
+		t.Fatalf("the failed delivery should not be retried again, retry: %+v, wait: %s, err: %v", retry, wait, err)
+	}
+	letters, err := ListDeadLetters(pusher.ctx, pusher.cache, 1, metadata.BasePage{})
+	if err != nil || letters.Count != 1 || letters.Info[0].ID != "delivery" || letters.Info[0].Attempts != 2 {
+		t.Fatalf("the events should be saved as a dead letter, letters: %+v, err: %v", letters, err)
+	}
+
+	// the dead letter is replayed once, and the replayed events are sent before the new events.
+	option := &metadata.ParamDeadLetterReplay{IDs: []string{"delivery"}}
+	for i, expect := range []int64{1, 0} {
+		replayed, err := ReplayDeadLetters(pusher.ctx, pusher.cache, 1, option)
+		if err != nil || replayed != expect {
+			t.Fatalf("replay %d: expect %d replayed dead letters, got %d, err: %v", i, expect, replayed, err)
+		}
+	}
+	if letters, err := ListDeadLetters(pusher.ctx, pusher.cache, 1, metadata.BasePage{}); err != nil ||
+		letters.Count != 0 {
+		t.Fatalf("the replayed dead letter should be removed, letters: %+v, err: %v", letters, err)
+	}
+
+	atomic.StoreInt32(&failed, 0)
+	replay, err := pusher.pop()
+	if err != nil || replay == nil || len(replay.Events) != 1 || replay.Events[0].ID != 1 || replay.Attempts != 0 {
+		t.Fatalf("the replayed events should be popped, delivery: %+v, err: %v", replay, err)
+	}
+	if err := pusher.deliver(replay); err != nil {
+		t.Fatalf("the replayed events should be sent, err: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("expect 3 callbacks, got %d", calls)
+	}
+}
+
+func TestDeliverWithoutRetry(t *testing.T) {
+	callback := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer callback.Close()
+
+	retryTimes := int64(0)
+	subscription := &metadata.Subscription{
+		SubscriptionID: 2,
+		CallbackURL:    callback.URL,
+		ConfirmMode:    metadata.ConfirmModeHTTPStatus,
+		ConfirmPattern: "200",
+		RetryTimes:     &retryTimes,
+	}
+	pusher, server := newTestPusher(t, subscription)
+	defer server.Close()
+
+	d := &delivery{ID: "no_retry", Events: []*metadata.DistInst{{SubscriptionID: 2}}}
+	if err := pusher.deliver(d); err == nil {
+		t.Fatalf("the delivery should fail")
+	}
+	if retry, wait, err := pusher.popRetry(); err != nil || retry != nil || wait != 0 {
+		t.Fatalf("the delivery should not be retried, retry: %+v, wait: %s, err: %v", retry, wait, err)
+	}
+	letters, err := ListDeadLetters(pusher.ctx, pusher.cache, 2, metadata.BasePage{})
+	if err != nil || letters.Count != 1 || letters.Info[0].Attempts != 1 {
+		t.Fatalf("the events should be saved as a dead letter at once, letters: %+v, err: %v", letters, err)
+	}
+}
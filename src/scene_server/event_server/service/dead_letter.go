@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/distribution"
+)
+
+// getSubscriptionForDeadLetter checks that the subscription in path exists, returns the subscription id.
+func (s *Service) getSubscriptionForDeadLetter(ctx *rest.Contexts) (int64, bool) {
+	id, err := strconv.ParseInt(ctx.Request.PathParameter("subscribeID"), 10, 64)
+	if err != nil {
+		// 400, invalid subscribeID parameter.
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, "subscribeID"))
+		return 0, false
+	}
+
+	cond := map[string]interface{}{common.BKSubscriptionIDField: id}
+	option := &metadata.ParamSubscriptionSearch{
+		Condition: cond,
+		Fields:    []string{common.BKSubscriptionIDField},
+		Page:      metadata.BasePage{Limit: 1},
+	}
+	res, err := s.engine.CoreAPI.CoreService().Event().ListSubscriptions(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("get subscription %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return 0, false
+	}
+	if res.Count == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommNotFound))
+		return 0, false
+	}
+	return id, true
+}
+
+// ListDeadLetters lists the events that are failed to be sent to the subscriber after all the retries.
+func (s *Service) ListDeadLetters(ctx *rest.Contexts) {
+	data := metadata.ParamDeadLetterSearch{}
+	if err := ctx.DecodeInto(&data); err != nil {
+		blog.Errorf("list dead letters decode request body failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Error(common.CCErrCommJSONUnmarshalFailed))
+		return
+	}
+	if data.Page.Limit > common.BKMaxPageSize {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommPageLimitIsExceeded))
+		return
+	}
+
+	id, ok := s.getSubscriptionForDeadLetter(ctx)
+	if !ok {
+		return
+	}
+
+	res, err := distribution.ListDeadLetters(ctx.Kit.Ctx, s.cache, id, data.Page)
+	if err != nil {
+		blog.Errorf("list dead letters of subscription %d failed, err: %v, rid: %s", id, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommRedisOPErr))
+		return
+	}
+
+	ctx.RespEntity(res)
+}
+
+// ReplayDeadLetters sends the events of the dead letters to the subscriber again.
+func (s *Service) ReplayDeadLetters(ctx *rest.Contexts) {
+	data := metadata.ParamDeadLetterReplay{}
+	if err := ctx.DecodeInto(&data); err != nil {
+		blog.Errorf("replay dead letters decode request body failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Error(common.CCErrCommJSONUnmarshalFailed))
+		return
+	}
+	if !data.All && len(data.IDs) == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsNeedSet, "ids"))
+		return
+	}
+
+	id, ok := s.getSubscriptionForDeadLetter(ctx)
+	if !ok {
+		return
+	}
+
+	replayed, err := distribution.ReplayDeadLetters(ctx.Kit.Ctx, s.cache, id, &data)
+	if err != nil {
+		blog.Errorf("replay dead letters of subscription %d failed, replayed: %d, err: %v, rid: %s", id, replayed,
+			err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommRedisOPErr))
+		return
+	}
+
+	ctx.RespEntity(metadata.RspDeadLetterReplay{Replayed: replayed})
+}
@@ -23,8 +23,10 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metric"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/common/watch"
 	"configcenter/src/scene_server/event_server/distribution"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/redis"
@@ -93,6 +95,8 @@ func (s *Service) WebService() *restful.Container {
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
+	healthzAPI.Route(healthzAPI.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb event server", Tag: types.CC_MODULE_EVENTSERVER})))
 	container.Add(healthzAPI)
 
 	return container
@@ -105,13 +109,23 @@ func (s *Service) initService(web *restful.WebService) {
 		Language: s.engine.Language,
 	})
 
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/search/{ownerID}/{appID}", Handler: s.ListSubscriptions})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/{ownerID}/{appID}", Handler: s.Subscribe})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/search/{ownerID}/{appID}", Handler: s.ListSubscriptions,
+		Request: metadata.ParamSubscriptionSearch{}, Response: metadata.RspSubscriptionSearch{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/{ownerID}/{appID}", Handler: s.Subscribe,
+		Request: metadata.Subscription{}, Response: metadata.SubscriptionCreateResult{}})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/subscribe/{ownerID}/{appID}/{subscribeID}", Handler: s.UnSubscribe})
-	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/subscribe/{ownerID}/{appID}/{subscribeID}", Handler: s.UpdateSubscription})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/ping", Handler: s.Ping})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/telnet", Handler: s.Telnet})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/watch/resource/{resource}", Handler: s.WatchEvent})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/subscribe/{ownerID}/{appID}/{subscribeID}", Handler: s.UpdateSubscription,
+		Request: metadata.Subscription{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/dead_letter/search/{ownerID}/{appID}/{subscribeID}", Handler: s.ListDeadLetters,
+		Request: metadata.ParamDeadLetterSearch{}, Response: metadata.RspDeadLetterSearch{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/dead_letter/replay/{ownerID}/{appID}/{subscribeID}", Handler: s.ReplayDeadLetters,
+		Request: metadata.ParamDeadLetterReplay{}, Response: metadata.RspDeadLetterReplay{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/ping", Handler: s.Ping,
+		Request: metadata.ParamSubscriptionTestCallback{}, Response: metadata.RspSubscriptionTestCallback{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/subscribe/telnet", Handler: s.Telnet,
+		Request: metadata.ParamSubscriptionTelnet{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/watch/resource/{resource}", Handler: s.WatchEvent,
+		Request: watch.WatchEventOptions{}, Response: watch.WatchResp{}})
 
 	utility.AddToRestfulWebService(web)
 
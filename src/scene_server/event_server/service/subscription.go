@@ -34,6 +34,9 @@ import (
 const (
 	// defaultSubTimeoutSeconds is default seconds num for new subscription.
 	defaultSubTimeoutSeconds = 10
+
+	// maxSubSecretLength is the max length of the subscription secret.
+	maxSubSecretLength = 256
 )
 
 // Subscribe subscribes target resource event in callback mode.
@@ -80,6 +83,17 @@ func (s *Service) Subscribe(ctx *rest.Contexts) {
 	if sub.ConfirmMode == metadata.ConfirmModeHTTPStatus && len(sub.ConfirmPattern) == 0 {
 		sub.ConfirmPattern = strconv.FormatInt(http.StatusOK, 10)
 	}
+	if field := validateCallbackOptions(sub); len(field) != 0 {
+		// 400, invalid callback options.
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+	if field, err := sub.Filter.Validate(); err != nil {
+		// 400, invalid subscription filter.
+		blog.Errorf("subscription filter is invalid, field: %s, err: %v, rid: %s", field, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
 
 	sub.LastTime = metadata.Now()
 	sub.OwnerID = ctx.Kit.SupplierAccount
@@ -110,9 +124,7 @@ func (s *Service) Subscribe(ctx *rest.Contexts) {
 		}
 	}
 
-	data := struct {
-		SubscriptionID int64 `json:"subscription_id"`
-	}{SubscriptionID: res.SubscriptionID}
+	data := metadata.SubscriptionCreateResult{SubscriptionID: res.SubscriptionID}
 
 	ctx.RespEntity(data)
 }
@@ -178,6 +190,17 @@ func (s *Service) UpdateSubscription(ctx *rest.Contexts) {
 	if sub.ConfirmMode == metadata.ConfirmModeHTTPStatus && len(sub.ConfirmPattern) == 0 {
 		sub.ConfirmPattern = strconv.FormatInt(http.StatusOK, 10)
 	}
+	if field := validateCallbackOptions(sub); len(field) != 0 {
+		// 400, invalid callback options.
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+	if field, err := sub.Filter.Validate(); err != nil {
+		// 400, invalid subscription filter.
+		blog.Errorf("subscription filter is invalid, field: %s, err: %v, rid: %s", field, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
 	sub.Operator = ctx.Kit.User
 
 	// trim subscription form.
@@ -314,6 +337,21 @@ func (s *Service) Telnet(ctx *rest.Contexts) {
 	ctx.RespEntity(nil)
 }
 
+// validateCallbackOptions validates the signing, retry and batching options of the subscription,
+// returns the invalid field name.
+func validateCallbackOptions(sub *metadata.Subscription) string {
+	if len(sub.Secret) > maxSubSecretLength || (sub.ClearSecret && len(sub.Secret) != 0) {
+		return "secret"
+	}
+	if sub.RetryTimes != nil && (*sub.RetryTimes < 0 || *sub.RetryTimes > metadata.MaxSubscriptionRetryTimes) {
+		return "retry_times"
+	}
+	if sub.BatchSize < 0 || sub.BatchSize > metadata.MaxSubscriptionBatchSize {
+		return "batch_size"
+	}
+	return ""
+}
+
 // trimSubscriptionForm trims space on subscription form.
 func (s *Service) trimSubscriptionForm(subscriptionForm string) string {
 	subscriptionFormStr := strings.Replace(subscriptionForm, " ", "", -1)
@@ -51,6 +51,18 @@ const (
 
 	// EventCacheDistCallBackCountPrefix is prefix of event callback stats key in cache.
 	EventCacheDistCallBackCountPrefix = common.BKCacheKeyV3Prefix + "event:dist_callback_"
+
+	// EventCacheSubscriberDeadLetterKeyPrefix is prefix of subscriber dead letter list key in cache, the events
+	// that are failed to be sent after all the retries are saved in it.
+	EventCacheSubscriberDeadLetterKeyPrefix = common.BKCacheKeyV3Prefix + "event:subscriber_dead_letter_"
+
+	// EventCacheSubscriberReplayQueueKeyPrefix is prefix of subscriber replay event queue key in cache, the
+	// replayed dead letter events are pushed to it, and sent before the events in subscriber event queue.
+	EventCacheSubscriberReplayQueueKeyPrefix = common.BKCacheKeyV3Prefix + "event:subscriber_replay_queue_"
+
+	// EventCacheSubscriberRetryKeyPrefix is prefix of subscriber retry sorted set key in cache, the failed
+	// callbacks are saved in it with the retry time as the score, and sent again when the time is reached.
+	EventCacheSubscriberRetryKeyPrefix = common.BKCacheKeyV3Prefix + "event:subscriber_retry_"
 )
 
 // EventCacheSubscriberCursorKey returns redis key for subscriber cursor cache.
@@ -69,3 +69,32 @@ func (lgc *Logics) QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLockReq
 
 	return hostLockMap, nil
 }
+
+func (lgc *Logics) ListHostLocks(kit *rest.Kit, input *metadata.ListHostLocksOption) (*metadata.ListHostLocksResult,
+	errors.CCError) {
+
+	result, err := lgc.CoreAPI.CoreService().Host().ListHostLocks(kit.Ctx, kit.Header, input)
+	if nil != err {
+		blog.Errorf("list host locks, http request error, error:%s,input:%+v,rid:%s", err.Error(), input, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("list host locks error, error code:%d error message:%s,input:%+v,rid:%s", result.Code, result.ErrMsg, input, kit.Rid)
+		return nil, kit.CCError.New(result.Code, result.ErrMsg)
+	}
+	return result, nil
+}
+
+func (lgc *Logics) ExpireHostLocks(kit *rest.Kit, input *metadata.ExpireHostLocksOption) (uint64, errors.CCError) {
+
+	result, err := lgc.CoreAPI.CoreService().Host().ExpireHostLocks(kit.Ctx, kit.Header, input)
+	if nil != err {
+		blog.Errorf("expire host locks, http request error, error:%s,input:%+v,rid:%s", err.Error(), input, kit.Rid)
+		return 0, kit.CCError.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("expire host locks error, error code:%d error message:%s,input:%+v,rid:%s", result.Code, result.ErrMsg, input, kit.Rid)
+		return 0, kit.CCError.New(result.Code, result.ErrMsg)
+	}
+	return result.Data.Count, nil
+}
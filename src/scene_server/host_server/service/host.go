@@ -547,6 +547,9 @@ func (s *Service) AddHostToResourcePool(ctx *rest.Contexts) {
 		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommParamsNeedSet))
 		return
 	}
+
+	// the created hosts are read back to generate the audit logs, so they must be read from the primary db node
+	ctx.SetReadAfterWrite()
 	_, retData, err := s.Logic.AddHostToResourcePool(ctx.Kit, *hostList)
 
 	if err != nil {
@@ -702,6 +705,14 @@ func (s *Service) UpdateHostBatch(ctx *rest.Contexts) {
 	data.Remove(common.BKHostIDField)
 	data.Remove(common.BKCloudIDField)
 
+	// the expected revision of the host to be updated, it is optional
+	revision, err := meta.PopExpectedRevision(ctx.Kit.Header, data)
+	if err != nil {
+		blog.Errorf("update host batch failed, parse expected revision failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKRevisionField))
+		return
+	}
+
 	// check authorization
 	hostIDArr := make([]int64, 0)
 	for _, id := range strings.Split(hostIDStr, ",") {
@@ -752,6 +763,7 @@ func (s *Service) UpdateHostBatch(ctx *rest.Contexts) {
 		opt := &meta.UpdateOption{
 			Condition: mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: hostIDArr}},
 			Data:      mapstr.NewFromMap(data),
+			Revision:  revision,
 		}
 		result, err := s.CoreAPI.CoreService().Instance().UpdateInstance(ctx.Kit.Ctx, ctx.Kit.Header, common.BKInnerObjIDHost, opt)
 		if err != nil {
@@ -761,7 +773,7 @@ func (s *Service) UpdateHostBatch(ctx *rest.Contexts) {
 		}
 		if !result.Result {
 			blog.ErrorJSON("UpdateHostBatch failed, UpdateObject failed, param:%s, response: %s, rid:%s", opt, result, ctx.Kit.Rid)
-			return result.CCError()
+			return meta.NewRevisionConflictError(result)
 		}
 
 		// save audit log.
@@ -774,6 +786,11 @@ func (s *Service) UpdateHostBatch(ctx *rest.Contexts) {
 	})
 
 	if txnErr != nil {
+		// returns the current host along with the error if it is changed by others
+		if current, conflict := meta.GetRevisionConflictData(txnErr); conflict {
+			ctx.RespEntityWithError(current, txnErr)
+			return
+		}
 		ctx.RespAutoError(txnErr)
 		return
 	}
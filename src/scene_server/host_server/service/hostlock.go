@@ -36,6 +36,12 @@ func (s *Service) LockHost(ctx *rest.Contexts) {
 		return
 	}
 
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("lock host, input is invalid, input: %+v, rid: %s", input, ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
 	// auth: check authorization
 	if err := s.AuthManager.AuthorizeByHostsIDs(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update, input.IDS...); err != nil {
 		if err != ac.NoAuthorizeError {
@@ -153,3 +159,68 @@ func (s *Service) QueryHostLock(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(hostLockInfos)
 }
+
+// ListHostLocks list the host locks that are not expired with the lock reason, user, scopes and expire time
+func (s *Service) ListHostLocks(ctx *rest.Contexts) {
+
+	input := &metadata.ListHostLocksOption{}
+	if err := ctx.DecodeInto(&input); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if rawErr := input.Validate(); rawErr.ErrCode != 0 {
+		blog.Errorf("list host locks, input is invalid, input: %+v, rid: %s", input, ctx.Kit.Rid)
+		ctx.RespAutoError(rawErr.ToCCError(ctx.Kit.CCError))
+		return
+	}
+
+	result, err := s.Logic.ListHostLocks(ctx.Kit, input)
+	if nil != err {
+		blog.Errorf("list host locks failed, err: %v, input: %+v, rid: %s", err, input, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result.Data)
+}
+
+// ExpireHostLocks expire the host locks immediately, the hosts can be operated once the locks are expired
+func (s *Service) ExpireHostLocks(ctx *rest.Contexts) {
+
+	input := &metadata.ExpireHostLocksOption{}
+	if err := ctx.DecodeInto(&input); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if 0 == len(input.IDS) {
+		blog.Errorf("expire host locks, id_list is empty, input:%+v,rid:%s", input, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.Errorf(common.CCErrCommParamsNeedSet, "id_list"))
+		return
+	}
+
+	// auth: check authorization
+	if err := s.AuthManager.AuthorizeByHostsIDs(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update, input.IDS...); err != nil {
+		if err != ac.NoAuthorizeError {
+			blog.Errorf("check host authorization failed, hosts: %+v, err: %v, rid: %s", input.IDS, err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.Error(common.CCErrCommAuthorizeFailed))
+			return
+		}
+		perm, err := s.AuthManager.GenEditBizHostNoPermissionResp(ctx.Kit.Ctx, ctx.Kit.Header, input.IDS)
+		if err != nil {
+			blog.Errorf("gen no permission response failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+			ctx.RespAutoError(ctx.Kit.CCError.Error(common.CCErrCommAuthorizeFailed))
+			return
+		}
+		ctx.RespEntityWithError(perm, ac.NoAuthorizeError)
+		return
+	}
+
+	count, err := s.Logic.ExpireHostLocks(ctx.Kit, input)
+	if nil != err {
+		blog.Errorf("expire host locks failed, err: %v, input: %+v, rid: %s", err, input, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.UpdatedCount{Count: count})
+}
@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metric"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/scene_server/host_server/app/options"
@@ -57,6 +58,8 @@ func (s *Service) WebService() *restful.Container {
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
+	healthzAPI.Route(healthzAPI.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb host server", Tag: types.CC_MODULE_HOST})))
 	container.Add(healthzAPI)
 
 	return container
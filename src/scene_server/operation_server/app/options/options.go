@@ -13,6 +13,7 @@
 package options
 
 import (
+	"configcenter/src/common"
 	"configcenter/src/common/auth"
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
@@ -28,6 +29,8 @@ type Config struct {
 	ConfigMap map[string]string
 	Mongo     mongo.Config
 	Timer     string
+	// ReadPreference the db read preference of the statistics queries
+	ReadPreference common.ReadPreferenceMode
 }
 
 func (c *Config) Ready() bool {
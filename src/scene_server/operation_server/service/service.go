@@ -28,8 +28,10 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/language"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/metric"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
 	"configcenter/src/common/util"
@@ -93,6 +95,8 @@ func (o *OperationServer) WebService() *restful.Container {
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(o.Healthz))
+	healthzAPI.Route(healthzAPI.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb operation server", Tag: types.CC_MODULE_OPERATION})))
 	container.Add(healthzAPI)
 
 	return container
@@ -105,12 +109,17 @@ func (o *OperationServer) newOperationService(web *restful.WebService) {
 	})
 
 	// service category
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/operation/chart", Handler: o.CreateOperationChart})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/operation/chart", Handler: o.CreateOperationChart,
+		Request: metadata.ChartConfig{}, Response: metadata.CommonSearchChart{}})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/operation/chart/{id}", Handler: o.DeleteOperationChart})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart", Handler: o.UpdateOperationChart})
-	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/findmany/operation/chart", Handler: o.SearchOperationChart})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/chart/data", Handler: o.SearchChartData})
-	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart/position", Handler: o.UpdateChartPosition})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart", Handler: o.UpdateOperationChart,
+		Request: mapstr.MapStr{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/findmany/operation/chart", Handler: o.SearchOperationChart,
+		Response: metadata.SearchChartConfig{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/operation/chart/data", Handler: o.SearchChartData,
+		Request: mapstr.MapStr{}})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/operation/chart/position", Handler: o.UpdateChartPosition,
+		Request: metadata.ChartPosition{}})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -168,6 +177,26 @@ func (o *OperationServer) OnOperationConfigUpdate(previous, current cc.ProcessCo
 		blog.Errorf("parse timer config failed, err: %v", err)
 		return
 	}
+	o.Config.ReadPreference = o.parseReadPreferenceConfig("operationServer.readPreference")
+}
+
+// parseReadPreferenceConfig parse the read preference of the statistics queries, they read from the secondaries
+// by default to reduce the load of the primary.
+func (o *OperationServer) parseReadPreferenceConfig(key string) common.ReadPreferenceMode {
+	if !cc.IsExist(key) {
+		return common.SecondaryPreferredMode
+	}
+	name, err := cc.String(key)
+	if err != nil {
+		blog.Errorf("get %s config failed, use secondaryPreferred instead, err: %v", key, err)
+		return common.SecondaryPreferredMode
+	}
+	mode, err := util.ParseReadPreference(name)
+	if err != nil {
+		blog.Errorf("parse %s config failed, use secondaryPreferred instead, err: %v", key, err)
+		return common.SecondaryPreferredMode
+	}
+	return mode
 }
 
 func (o *OperationServer) ParseTimerConfigFromKV(prefix string, configMap map[string]string) (string, error) {
@@ -60,6 +60,10 @@ func (ps *ProcServer) CreateProcessTemplateBatch(ctx *rest.Contexts) {
 
 			ids = append(ids, temp.ID)
 		}
+
+		if _, err := ps.saveServiceTemplateVersion(ctx, input.BizID, input.ServiceTemplateID); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -106,6 +110,10 @@ func (ps *ProcServer) DeleteProcessTemplateBatch(ctx *rest.Contexts) {
 			blog.Errorf("delete process template: %v failed", input.ProcessTemplates)
 			return ctx.Kit.CCError.CCError(common.CCErrProcDeleteTemplateFail)
 		}
+
+		if _, err := ps.saveServiceTemplateVersion(ctx, input.BizID, serviceTemplateIDs...); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -159,6 +167,10 @@ func (ps *ProcServer) UpdateProcessTemplate(ctx *rest.Contexts) {
 			blog.Errorf("update process template: %v failed.", input)
 			return ctx.Kit.CCError.CCError(common.CCErrProcUpdateProcessTemplateFailed)
 		}
+
+		if _, err := ps.saveServiceTemplateVersion(ctx, input.BizID, template.ServiceTemplateID); err != nil {
+			return err
+		}
 		return nil
 	})
 
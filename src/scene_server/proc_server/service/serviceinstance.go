@@ -1267,8 +1267,9 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 	// step 0:
 	// find service instances
 	serviceInstanceOption := &metadata.ListServiceInstanceOption{
-		BusinessID: bizID,
-		ModuleIDs:  syncOption.ModuleIDs,
+		BusinessID:         bizID,
+		ModuleIDs:          syncOption.ModuleIDs,
+		ServiceInstanceIDs: syncOption.ServiceInstanceIDs,
 		Page: metadata.BasePage{
 			Limit: common.BKNoLimit,
 		},
@@ -1507,6 +1508,27 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 		}
 	}
 
+	// step 8:
+	// update module service category and name field, they belong to the whole module, so they are not synced
+	// when only a part of the service instances are synced, a rollout syncs them after all instances are synced.
+	if len(syncOption.ServiceInstanceIDs) > 0 {
+		return nil
+	}
+	return ps.syncModulesWithServiceTemplate(ctx, bizID, modules)
+}
+
+// syncModulesWithServiceTemplate update the service category and name of the modules to their service templates'
+func (ps *ProcServer) syncModulesWithServiceTemplate(ctx *rest.Contexts, bizID int64,
+	modules []*metadata.ModuleInst) errors.CCErrorCoder {
+
+	rid := ctx.Kit.Rid
+	serviceTemplateIDs := make([]int64, 0)
+	serviceTemplateModuleMap := make(map[int64][]*metadata.ModuleInst)
+	for _, module := range modules {
+		serviceTemplateIDs = append(serviceTemplateIDs, module.ServiceTemplateID)
+		serviceTemplateModuleMap[module.ServiceTemplateID] = append(serviceTemplateModuleMap[module.ServiceTemplateID], module)
+	}
+
 	// get service templates
 	serviceTemplates, err := ps.CoreAPI.CoreService().Process().ListServiceTemplates(ctx.Kit.Ctx, ctx.Kit.Header, &metadata.ListServiceTemplateOption{
 		BusinessID:         bizID,
@@ -1520,8 +1542,6 @@ func (ps *ProcServer) syncServiceInstanceByTemplate(ctx *rest.Contexts, syncOpti
 		return err
 	}
 
-	// step 8:
-	// update module service category and name field
 	for _, serviceTemplate := range serviceTemplates.Info {
 		updateModules := make([]int64, 0)
 		for _, module := range serviceTemplateModuleMap[serviceTemplate.ID] {
@@ -62,6 +62,9 @@ func (ps *ProcServer) CreateServiceTemplate(ctx *rest.Contexts) {
 			}
 		}
 
+		if _, err := ps.saveServiceTemplateVersion(ctx, tpl.BizID, tpl.ID); err != nil {
+			return err
+		}
 		return nil
 	})
 
@@ -126,6 +129,10 @@ func (ps *ProcServer) UpdateServiceTemplate(ctx *rest.Contexts) {
 			blog.Errorf("update service template failed, err: %v", err)
 			return err
 		}
+
+		if _, err := ps.saveServiceTemplateVersion(ctx, tpl.BizID, tpl.ID); err != nil {
+			return err
+		}
 		return nil
 	})
 
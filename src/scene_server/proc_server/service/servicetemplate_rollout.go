@@ -0,0 +1,876 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// saveServiceTemplateVersion save the current content of the service templates as new versions,
+// it must be called in the same transaction as the change of the service template or its process templates.
+func (ps *ProcServer) saveServiceTemplateVersion(ctx *rest.Contexts, bizID int64, serviceTemplateIDs ...int64) (
+	*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+
+	var version *metadata.ServiceTemplateVersion
+	for _, serviceTemplateID := range util.IntArrayUnique(serviceTemplateIDs) {
+		option := &metadata.CreateServiceTemplateVersionOption{
+			BizID:             bizID,
+			ServiceTemplateID: serviceTemplateID,
+		}
+		var err errors.CCErrorCoder
+		version, err = ps.CoreAPI.CoreService().Process().CreateServiceTemplateVersion(ctx.Kit.Ctx, ctx.Kit.Header, option)
+		if err != nil {
+			blog.Errorf("save service template version failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+			return nil, err
+		}
+	}
+	return version, nil
+}
+
+// ListServiceTemplateVersions list the saved versions of a service template, the latest version is the first one
+func (ps *ProcServer) ListServiceTemplateVersions(ctx *rest.Contexts) {
+	serviceTemplateID, err := util.GetInt64ByInterface(ctx.Request.PathParameter(common.BKServiceTemplateIDField))
+	if err != nil {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKServiceTemplateIDField))
+		return
+	}
+
+	option := new(metadata.ListServiceTemplateVersionsOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if option.BizID == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+	option.ServiceTemplateID = serviceTemplateID
+
+	versions, ccErr := ps.CoreAPI.CoreService().Process().ListServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if ccErr != nil {
+		ctx.RespWithError(ccErr, common.CCErrCommHTTPDoRequestFailed, "list service template versions failed, err: %v",
+			ccErr)
+		return
+	}
+	ctx.RespEntity(versions)
+}
+
+// ListServiceTemplateRollouts list the rollouts of a service template with their waves' progress
+func (ps *ProcServer) ListServiceTemplateRollouts(ctx *rest.Contexts) {
+	option := new(metadata.ListServiceTemplateRolloutsOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if option.BizID == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	rollouts, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateRollouts(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		ctx.RespWithError(err, common.CCErrCommHTTPDoRequestFailed, "list service template rollouts failed, err: %v", err)
+		return
+	}
+	ctx.RespEntity(rollouts)
+}
+
+// CreateServiceTemplateRollout apply a version of the service template to the service instances in waves,
+// each wave is a sub task of the task server, which syncs the service instances in the wave with the template.
+func (ps *ProcServer) CreateServiceTemplateRollout(ctx *rest.Contexts) {
+	option := new(metadata.CreateServiceTemplateRolloutOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if field := option.Validate(); field != "" {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field))
+		return
+	}
+
+	if err := ps.AuthManager.AuthorizeByServiceTemplateID(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update,
+		option.ServiceTemplateID); err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommCheckAuthorizeFailed, "authorize by service template id failed, id: %d, "+
+			"err: %v", option.ServiceTemplateID, err)
+		return
+	}
+
+	// only one rollout of a service template can be in progress at the same time, which is guaranteed by
+	// the core service when the rollout is created.
+	var rollout *metadata.ServiceTemplateRollout
+	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		latest, err := ps.saveServiceTemplateVersion(ctx, option.BizID, option.ServiceTemplateID)
+		if err != nil {
+			return err
+		}
+
+		// restore the service template to the version to roll out, it is saved as a new version
+		if option.Version != 0 && option.Version != latest.Version {
+			target, err := ps.getServiceTemplateVersion(ctx, option.BizID, option.ServiceTemplateID, option.Version)
+			if err != nil {
+				return err
+			}
+			if err := ps.restoreServiceTemplateVersion(ctx, target); err != nil {
+				return err
+			}
+			if latest, err = ps.saveServiceTemplateVersion(ctx, option.BizID, option.ServiceTemplateID); err != nil {
+				return err
+			}
+		}
+
+		waves, err := ps.generateRolloutWaves(ctx, option)
+		if err != nil {
+			return err
+		}
+
+		previousVersion, err := ps.getRolledOutVersion(ctx, option.BizID, option.ServiceTemplateID)
+		if err != nil {
+			return err
+		}
+
+		newRollout := &metadata.ServiceTemplateRollout{
+			BizID:              option.BizID,
+			ServiceTemplateID:  option.ServiceTemplateID,
+			Version:            latest.Version,
+			PreviousVersion:    previousVersion,
+			Status:             metadata.RolloutStatusRunning,
+			Waves:              waves,
+			PauseAfterEachWave: option.PauseAfterEachWave,
+		}
+		rollout, err = ps.CoreAPI.CoreService().Process().CreateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header,
+			newRollout)
+		if err != nil {
+			blog.Errorf("create service template rollout failed, rollout: %+v, err: %v, rid: %s", newRollout, err,
+				ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	// dispatch the waves after the rollout is committed, so that the task can find it
+	rollout, err := ps.dispatchRolloutWaves(ctx, rollout)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(rollout)
+}
+
+// PauseServiceTemplateRollout pause a running rollout, the wave in progress is finished,
+// and the following waves are not executed until the rollout is resumed.
+func (ps *ProcServer) PauseServiceTemplateRollout(ctx *rest.Contexts) {
+	rollout, err := ps.getRolloutForUpdate(ctx, metadata.RolloutStatusRunning)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	option := &metadata.UpdateServiceTemplateRolloutOption{
+		BizID:      rollout.BizID,
+		ID:         rollout.ID,
+		FromStatus: []metadata.RolloutStatus{metadata.RolloutStatusRunning},
+		Status:     metadata.RolloutStatusPaused,
+	}
+	rollout, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("pause service template rollout failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(rollout)
+}
+
+// ResumeServiceTemplateRollout resume a paused or failed rollout, the waves that are not succeeded are executed again
+func (ps *ProcServer) ResumeServiceTemplateRollout(ctx *rest.Contexts) {
+	rollout, err := ps.getRolloutForUpdate(ctx, metadata.RolloutStatusPaused, metadata.RolloutStatusFailed)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the service template must not be changed since the rollout started
+	if err := ps.checkRolloutVersion(ctx, rollout); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	option := &metadata.UpdateServiceTemplateRolloutOption{
+		BizID:      rollout.BizID,
+		ID:         rollout.ID,
+		FromStatus: []metadata.RolloutStatus{rollout.Status},
+		Status:     metadata.RolloutStatusRunning,
+	}
+	rollout, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("resume service template rollout failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+
+	rollout, err = ps.dispatchRolloutWaves(ctx, rollout)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(rollout)
+}
+
+// RollbackServiceTemplateRollout restore the service template to the version before the rollout,
+// and start a new rollout of the restored version on the waves that have been applied.
+func (ps *ProcServer) RollbackServiceTemplateRollout(ctx *rest.Contexts) {
+	rollout, err := ps.getRolloutForUpdate(ctx, metadata.RolloutStatusRunning, metadata.RolloutStatusPaused,
+		metadata.RolloutStatusFailed, metadata.RolloutStatusFinished)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if rollout.PreviousVersion <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrProcRolloutNoPreviousVersion))
+		return
+	}
+
+	// the waves that have been synced, including the failed ones which may be partially synced
+	waves := make([]metadata.ServiceTemplateRolloutWave, 0)
+	for _, wave := range rollout.Waves {
+		if wave.Status == metadata.RolloutWaveStatusPending {
+			continue
+		}
+		waves = append(waves, metadata.ServiceTemplateRolloutWave{
+			Index:              len(waves),
+			ModuleIDs:          wave.ModuleIDs,
+			ServiceInstanceIDs: wave.ServiceInstanceIDs,
+			Status:             metadata.RolloutWaveStatusPending,
+		})
+	}
+
+	var rollbackRollout *metadata.ServiceTemplateRollout
+	txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		updateOption := &metadata.UpdateServiceTemplateRolloutOption{
+			BizID:      rollout.BizID,
+			ID:         rollout.ID,
+			FromStatus: []metadata.RolloutStatus{rollout.Status},
+			Status:     metadata.RolloutStatusRolledBack,
+		}
+		_, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header,
+			updateOption)
+		if err != nil {
+			blog.Errorf("update rollout status failed, option: %+v, err: %v, rid: %s", updateOption, err, ctx.Kit.Rid)
+			return err
+		}
+
+		previous, err := ps.getServiceTemplateVersion(ctx, rollout.BizID, rollout.ServiceTemplateID,
+			rollout.PreviousVersion)
+		if err != nil {
+			return err
+		}
+		if err := ps.restoreServiceTemplateVersion(ctx, previous); err != nil {
+			return err
+		}
+		latest, err := ps.saveServiceTemplateVersion(ctx, rollout.BizID, rollout.ServiceTemplateID)
+		if err != nil {
+			return err
+		}
+
+		if len(waves) == 0 {
+			return nil
+		}
+		newRollout := &metadata.ServiceTemplateRollout{
+			BizID:              rollout.BizID,
+			ServiceTemplateID:  rollout.ServiceTemplateID,
+			Version:            latest.Version,
+			PreviousVersion:    rollout.Version,
+			Status:             metadata.RolloutStatusRunning,
+			Waves:              waves,
+			PauseAfterEachWave: false,
+			RollbackOf:         rollout.ID,
+		}
+		rollbackRollout, err = ps.CoreAPI.CoreService().Process().CreateServiceTemplateRollout(ctx.Kit.Ctx,
+			ctx.Kit.Header, newRollout)
+		if err != nil {
+			blog.Errorf("create rollback rollout failed, rollout: %+v, err: %v, rid: %s", newRollout, err, ctx.Kit.Rid)
+			return err
+		}
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+
+	// no service instance has been synced, restoring the service template is enough
+	if rollbackRollout == nil {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	rollbackRollout, err = ps.dispatchRolloutWaves(ctx, rollbackRollout)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(rollbackRollout)
+}
+
+// ServiceTemplateRolloutTaskHandler sync the service instances of a rollout wave, it is called by the task server.
+func (ps *ProcServer) ServiceTemplateRolloutTaskHandler(ctx *rest.Contexts) {
+	task := new(metadata.ServiceTemplateRolloutTaskData)
+	if err := ctx.DecodeInto(task); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	rollout, err := ps.getServiceTemplateRollout(ctx, task.BizID, task.RolloutID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the rollout is paused, failed or rolled back, the wave is skipped and will be dispatched again when resuming
+	if rollout.Status != metadata.RolloutStatusRunning {
+		blog.Infof("rollout %d is %s, skip wave %d, rid: %s", rollout.ID, rollout.Status, task.WaveIndex, ctx.Kit.Rid)
+		ctx.RespEntity(nil)
+		return
+	}
+	if task.WaveIndex < 0 || task.WaveIndex >= len(rollout.Waves) {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "wave_index"))
+		return
+	}
+	wave := rollout.Waves[task.WaveIndex]
+	if wave.Status == metadata.RolloutWaveStatusSuccess {
+		ctx.RespEntity(nil)
+		return
+	}
+
+	syncErr := ps.checkRolloutVersion(ctx, rollout)
+	if syncErr == nil {
+		syncOption := metadata.SyncServiceInstanceByTemplateOption{
+			BizID:              rollout.BizID,
+			ModuleIDs:          wave.ModuleIDs,
+			ServiceInstanceIDs: wave.ServiceInstanceIDs,
+		}
+		txnErr := ps.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+			return ps.syncServiceInstanceByTemplate(ctx, syncOption)
+		})
+		if txnErr != nil {
+			blog.Errorf("sync rollout %d wave %d failed, err: %v, rid: %s", rollout.ID, wave.Index, txnErr,
+				ctx.Kit.Rid)
+			syncErr = ctx.Kit.CCError.CCError(common.CCErrSyncServiceInstanceByTemplateFailed)
+			if ccErr, ok := txnErr.(errors.CCErrorCoder); ok {
+				syncErr = ccErr
+			}
+		}
+	}
+
+	if err := ps.finishRolloutWave(ctx, rollout, wave.Index, syncErr); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if syncErr != nil {
+		ctx.RespAutoError(syncErr)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+// finishRolloutWave record the result of a wave, and change the rollout's status if it's finished, failed
+// or needs to be paused after this wave.
+func (ps *ProcServer) finishRolloutWave(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout, index int,
+	syncErr errors.CCErrorCoder) errors.CCErrorCoder {
+
+	wave := &metadata.ServiceTemplateRolloutWave{Index: index, Status: metadata.RolloutWaveStatusSuccess}
+	status := metadata.RolloutStatus("")
+	if syncErr != nil {
+		wave.Status = metadata.RolloutWaveStatusFailed
+		wave.Message = syncErr.Error()
+		status = metadata.RolloutStatusFailed
+	}
+
+	option := &metadata.UpdateServiceTemplateRolloutOption{
+		BizID: rollout.BizID,
+		ID:    rollout.ID,
+		Wave:  wave,
+	}
+	updated, err := ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("update rollout wave failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		return err
+	}
+
+	if status == "" {
+		if updated.NextWave() == -1 {
+			status = metadata.RolloutStatusFinished
+		} else if updated.PauseAfterEachWave {
+			status = metadata.RolloutStatusPaused
+		} else {
+			return nil
+		}
+	}
+
+	// the status is only changed when the rollout is still running, it may be paused or rolled back meanwhile
+	statusOption := &metadata.UpdateServiceTemplateRolloutOption{
+		BizID:      rollout.BizID,
+		ID:         rollout.ID,
+		FromStatus: []metadata.RolloutStatus{metadata.RolloutStatusRunning},
+		Status:     status,
+	}
+	if status == metadata.RolloutStatusFinished {
+		statusOption.FromStatus = append(statusOption.FromStatus, metadata.RolloutStatusPaused)
+	}
+	_, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header, statusOption)
+	if err != nil {
+		if err.GetCode() == common.CCErrCoreServiceRolloutStatusConflict {
+			return nil
+		}
+		blog.Errorf("update rollout status failed, option: %+v, err: %v, rid: %s", statusOption, err, ctx.Kit.Rid)
+		return err
+	}
+
+	if status == metadata.RolloutStatusFinished {
+		return ps.syncRolloutModules(ctx, updated)
+	}
+	return nil
+}
+
+// syncRolloutModules sync the service category and name of the modules whose service instances are all synced
+// by the finished rollout, the modules that are partially rolled out keep their former values.
+func (ps *ProcServer) syncRolloutModules(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout) errors.CCErrorCoder {
+	moduleIDs := make([]int64, 0)
+	synced := make(map[int64]bool)
+	for _, wave := range rollout.Waves {
+		moduleIDs = append(moduleIDs, wave.ModuleIDs...)
+		for _, id := range wave.ServiceInstanceIDs {
+			synced[id] = true
+		}
+	}
+	moduleIDs = util.IntArrayUnique(moduleIDs)
+
+	instanceOption := &metadata.ListServiceInstanceOption{
+		BusinessID:        rollout.BizID,
+		ServiceTemplateID: rollout.ServiceTemplateID,
+		ModuleIDs:         moduleIDs,
+		Page:              metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	instances, err := ps.CoreAPI.CoreService().Process().ListServiceInstance(ctx.Kit.Ctx, ctx.Kit.Header,
+		instanceOption)
+	if err != nil {
+		blog.Errorf("list service instances failed, option: %+v, err: %v, rid: %s", instanceOption, err, ctx.Kit.Rid)
+		return err
+	}
+
+	partial := make(map[int64]bool)
+	for _, instance := range instances.Info {
+		if !synced[instance.ID] {
+			partial[instance.ModuleID] = true
+		}
+	}
+	fullModuleIDs := make([]int64, 0)
+	for _, moduleID := range moduleIDs {
+		if !partial[moduleID] {
+			fullModuleIDs = append(fullModuleIDs, moduleID)
+		}
+	}
+	if len(fullModuleIDs) == 0 {
+		return nil
+	}
+
+	modules, err := ps.getModules(ctx, fullModuleIDs)
+	if err != nil {
+		blog.Errorf("get rollout %d modules failed, ids: %v, err: %v, rid: %s", rollout.ID, fullModuleIDs, err,
+			ctx.Kit.Rid)
+		return err
+	}
+	return ps.syncModulesWithServiceTemplate(ctx, rollout.BizID, modules)
+}
+
+// dispatchRolloutWaves create a task with the waves that are not succeeded in the rollout
+func (ps *ProcServer) dispatchRolloutWaves(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout) (
+	*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+
+	tasksData := make([]interface{}, 0)
+	for _, wave := range rollout.Waves {
+		if wave.Status == metadata.RolloutWaveStatusSuccess {
+			continue
+		}
+		tasksData = append(tasksData, metadata.ServiceTemplateRolloutTaskData{
+			BizID:     rollout.BizID,
+			RolloutID: rollout.ID,
+			WaveIndex: wave.Index,
+		})
+	}
+	if len(tasksData) == 0 {
+		return rollout, nil
+	}
+
+	flag := fmt.Sprintf("service_template_rollout:%d", rollout.ID)
+	taskResult, err := ps.CoreAPI.TaskServer().Task().Create(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.SyncServiceTemplateRolloutTaskName, flag, tasksData)
+	if err != nil {
+		blog.Errorf("dispatch rollout %d task failed, err: %v, rid: %s", rollout.ID, err, ctx.Kit.Rid)
+		return nil, errors.CCHttpError
+	}
+	if ccErr := taskResult.CCError(); ccErr != nil {
+		blog.ErrorJSON("dispatch rollout %d task failed, result: %s, rid: %s", rollout.ID, taskResult, ctx.Kit.Rid)
+		return nil, ccErr
+	}
+
+	option := &metadata.UpdateServiceTemplateRolloutOption{
+		BizID:   rollout.BizID,
+		ID:      rollout.ID,
+		TaskIDs: []string{taskResult.Data.TaskID},
+	}
+	updated, ccErr := ps.CoreAPI.CoreService().Process().UpdateServiceTemplateRollout(ctx.Kit.Ctx, ctx.Kit.Header,
+		option)
+	if ccErr != nil {
+		blog.Errorf("record rollout task failed, option: %+v, err: %v, rid: %s", option, ccErr, ctx.Kit.Rid)
+		return nil, ccErr
+	}
+	return updated, nil
+}
+
+// generateRolloutWaves split the service instances of the rollout modules into waves
+func (ps *ProcServer) generateRolloutWaves(ctx *rest.Contexts, option *metadata.CreateServiceTemplateRolloutOption) (
+	[]metadata.ServiceTemplateRolloutWave, errors.CCErrorCoder) {
+
+	moduleFilter := &metadata.QueryCondition{
+		Fields: []string{common.BKModuleIDField},
+		Condition: map[string]interface{}{
+			common.BKAppIDField:             option.BizID,
+			common.BKServiceTemplateIDField: option.ServiceTemplateID,
+		},
+		Page: metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	modules, err := ps.CoreAPI.CoreService().Instance().ReadInstance(ctx.Kit.Ctx, ctx.Kit.Header,
+		common.BKInnerObjIDModule, moduleFilter)
+	if err != nil {
+		blog.Errorf("get modules of service template failed, filter: %+v, err: %v, rid: %s", moduleFilter, err,
+			ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrTopoGetModuleFailed, err)
+	}
+
+	boundModuleIDs := make([]int64, 0)
+	for _, module := range modules.Data.Info {
+		moduleID, err := util.GetInt64ByInterface(module[common.BKModuleIDField])
+		if err != nil {
+			blog.Errorf("parse module id failed, module: %+v, err: %v, rid: %s", module, err, ctx.Kit.Rid)
+			return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParseDataFailed)
+		}
+		boundModuleIDs = append(boundModuleIDs, moduleID)
+	}
+	sort.Slice(boundModuleIDs, func(i, j int) bool { return boundModuleIDs[i] < boundModuleIDs[j] })
+
+	moduleIDs := boundModuleIDs
+	if len(option.ModuleIDs) > 0 {
+		moduleIDs = util.IntArrayUnique(option.ModuleIDs)
+		for _, moduleID := range moduleIDs {
+			if !util.InArray(moduleID, boundModuleIDs) {
+				blog.Errorf("module %d is not bound with service template %d, rid: %s", moduleID,
+					option.ServiceTemplateID, ctx.Kit.Rid)
+				return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_module_ids")
+			}
+		}
+	}
+	if len(moduleIDs) == 0 {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_module_ids")
+	}
+
+	instanceOption := &metadata.ListServiceInstanceOption{
+		BusinessID:        option.BizID,
+		ServiceTemplateID: option.ServiceTemplateID,
+		ModuleIDs:         moduleIDs,
+		Page:              metadata.BasePage{Limit: common.BKNoLimit, Sort: common.BKFieldID},
+	}
+	instances, ccErr := ps.CoreAPI.CoreService().Process().ListServiceInstance(ctx.Kit.Ctx, ctx.Kit.Header,
+		instanceOption)
+	if ccErr != nil {
+		blog.Errorf("list service instances failed, option: %+v, err: %v, rid: %s", instanceOption, ccErr, ctx.Kit.Rid)
+		return nil, ccErr
+	}
+
+	moduleInstances := make(map[int64][]int64)
+	for _, instance := range instances.Info {
+		moduleInstances[instance.ModuleID] = append(moduleInstances[instance.ModuleID], instance.ID)
+	}
+
+	waves := metadata.ServiceTemplateRolloutWaves(moduleIDs, moduleInstances, option.Percentage, option.WaveCount)
+	if len(waves) == 0 {
+		blog.Errorf("no service instance to roll out, option: %+v, rid: %s", option, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "bk_module_ids")
+	}
+	return waves, nil
+}
+
+// restoreServiceTemplateVersion make the service template and its process templates the same as the version
+func (ps *ProcServer) restoreServiceTemplateVersion(ctx *rest.Contexts, version *metadata.ServiceTemplateVersion) error {
+	listOption := &metadata.ListProcessTemplatesOption{
+		BusinessID:         version.BizID,
+		ServiceTemplateIDs: []int64{version.ServiceTemplateID},
+		Page:               metadata.BasePage{Limit: common.BKNoLimit},
+	}
+	current, err := ps.CoreAPI.CoreService().Process().ListProcessTemplates(ctx.Kit.Ctx, ctx.Kit.Header, listOption)
+	if err != nil {
+		blog.Errorf("list process templates failed, option: %+v, err: %v, rid: %s", listOption, err, ctx.Kit.Rid)
+		return err
+	}
+	currentMap := make(map[int64]metadata.ProcessTemplate)
+	for _, processTemplate := range current.Info {
+		currentMap[processTemplate.ID] = processTemplate
+	}
+
+	// the process name can not be updated, and deleting a process template detaches all of its process instances,
+	// so a version whose process names differ from the current process templates can not be restored
+	for _, processTemplate := range version.ProcessTemplates {
+		currentTemplate, exist := currentMap[processTemplate.ID]
+		if exist && !isSameProcessName(currentTemplate.Property, processTemplate.Property) {
+			blog.Errorf("process template %d name changed since version %d, rid: %s", processTemplate.ID,
+				version.Version, ctx.Kit.Rid)
+			return ctx.Kit.CCError.CCErrorf(common.CCErrProcRolloutProcessNameChanged, processTemplate.ID)
+		}
+	}
+
+	template := &metadata.ServiceTemplate{
+		ID:                version.ServiceTemplateID,
+		Name:              version.Name,
+		ServiceCategoryID: version.ServiceCategoryID,
+	}
+	_, err = ps.CoreAPI.CoreService().Process().UpdateServiceTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+		version.ServiceTemplateID, template)
+	if err != nil {
+		blog.Errorf("restore service template failed, template: %+v, err: %v, rid: %s", template, err, ctx.Kit.Rid)
+		return err
+	}
+
+	// process templates deleted after the version are recreated, they have no process instances any more
+	removedIDs := make([]int64, 0)
+	kept := make(map[int64]bool)
+	for _, processTemplate := range version.ProcessTemplates {
+		if _, exist := currentMap[processTemplate.ID]; exist {
+			kept[processTemplate.ID] = true
+			property, err := processPropertyToMap(processTemplate.Property)
+			if err != nil {
+				blog.Errorf("convert process property failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+				return ctx.Kit.CCError.CCError(common.CCErrCommJSONMarshalFailed)
+			}
+			_, ccErr := ps.CoreAPI.CoreService().Process().UpdateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+				processTemplate.ID, property)
+			if ccErr != nil {
+				blog.Errorf("restore process template %d failed, err: %v, rid: %s", processTemplate.ID, ccErr,
+					ctx.Kit.Rid)
+				return ccErr
+			}
+			continue
+		}
+
+		newTemplate := &metadata.ProcessTemplate{
+			BizID:             version.BizID,
+			ServiceTemplateID: version.ServiceTemplateID,
+			Property:          processTemplate.Property,
+		}
+		if _, ccErr := ps.CoreAPI.CoreService().Process().CreateProcessTemplate(ctx.Kit.Ctx, ctx.Kit.Header,
+			newTemplate); ccErr != nil {
+			blog.Errorf("recreate process template failed, template: %+v, err: %v, rid: %s", newTemplate, ccErr,
+				ctx.Kit.Rid)
+			return ccErr
+		}
+	}
+
+	for id := range currentMap {
+		if !kept[id] {
+			removedIDs = append(removedIDs, id)
+		}
+	}
+	if len(removedIDs) > 0 {
+		if ccErr := ps.CoreAPI.CoreService().Process().DeleteProcessTemplateBatch(ctx.Kit.Ctx, ctx.Kit.Header,
+			removedIDs); ccErr != nil {
+			blog.Errorf("delete process templates %v failed, err: %v, rid: %s", removedIDs, ccErr, ctx.Kit.Rid)
+			return ccErr
+		}
+	}
+	return nil
+}
+
+// isSameProcessName check if the two process properties have the same process name and function name
+func isSameProcessName(a, b *metadata.ProcessProperty) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return reflect.DeepEqual(a.ProcessName, b.ProcessName) && reflect.DeepEqual(a.FuncName, b.FuncName)
+}
+
+func processPropertyToMap(property *metadata.ProcessProperty) (map[string]interface{}, error) {
+	content, err := json.Marshal(property)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// getRolledOutVersion get the service template version that the service instances are synced to, which is the
+// version of the last finished rollout, or the first version of the service template if it is never rolled out.
+func (ps *ProcServer) getRolledOutVersion(ctx *rest.Contexts, bizID, serviceTemplateID int64) (int64,
+	errors.CCErrorCoder) {
+
+	rolloutOption := &metadata.ListServiceTemplateRolloutsOption{
+		BizID:             bizID,
+		ServiceTemplateID: serviceTemplateID,
+		Status:            []metadata.RolloutStatus{metadata.RolloutStatusFinished},
+		Page:              metadata.BasePage{Limit: 1, Sort: "-" + common.BKFieldID},
+	}
+	rollouts, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateRollouts(ctx.Kit.Ctx, ctx.Kit.Header,
+		rolloutOption)
+	if err != nil {
+		blog.Errorf("get last finished rollout failed, option: %+v, err: %v, rid: %s", rolloutOption, err, ctx.Kit.Rid)
+		return 0, err
+	}
+	if len(rollouts.Info) > 0 {
+		return rollouts.Info[0].Version, nil
+	}
+
+	versionOption := &metadata.ListServiceTemplateVersionsOption{
+		BizID:             bizID,
+		ServiceTemplateID: serviceTemplateID,
+		Page:              metadata.BasePage{Limit: 1, Sort: "version"},
+	}
+	versions, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header,
+		versionOption)
+	if err != nil {
+		blog.Errorf("get first service template version failed, option: %+v, err: %v, rid: %s", versionOption, err,
+			ctx.Kit.Rid)
+		return 0, err
+	}
+	if len(versions.Info) == 0 {
+		return 0, nil
+	}
+	return versions.Info[0].Version, nil
+}
+
+func (ps *ProcServer) getServiceTemplateVersion(ctx *rest.Contexts, bizID, serviceTemplateID, version int64) (
+	*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+
+	option := &metadata.ListServiceTemplateVersionsOption{
+		BizID:             bizID,
+		ServiceTemplateID: serviceTemplateID,
+		Versions:          []int64{version},
+		Page:              metadata.BasePage{Limit: 1},
+	}
+	versions, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("get service template version failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		return nil, err
+	}
+	if len(versions.Info) == 0 {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateVersionNotExist, serviceTemplateID, version)
+	}
+	return &versions.Info[0], nil
+}
+
+// checkRolloutVersion check if the service template is still the version of the rollout
+func (ps *ProcServer) checkRolloutVersion(ctx *rest.Contexts, rollout *metadata.ServiceTemplateRollout) errors.CCErrorCoder {
+	option := &metadata.ListServiceTemplateVersionsOption{
+		BizID:             rollout.BizID,
+		ServiceTemplateID: rollout.ServiceTemplateID,
+		Page:              metadata.BasePage{Limit: 1},
+	}
+	versions, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateVersions(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("get latest service template version failed, option: %+v, err: %v, rid: %s", option, err,
+			ctx.Kit.Rid)
+		return err
+	}
+	if len(versions.Info) == 0 || versions.Info[0].Version != rollout.Version {
+		latest := int64(0)
+		if len(versions.Info) > 0 {
+			latest = versions.Info[0].Version
+		}
+		return ctx.Kit.CCError.CCErrorf(common.CCErrProcServiceTemplateChangedDuringRollout, latest, rollout.Version)
+	}
+	return nil
+}
+
+func (ps *ProcServer) getServiceTemplateRollout(ctx *rest.Contexts, bizID, rolloutID int64) (
+	*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+
+	option := &metadata.ListServiceTemplateRolloutsOption{
+		BizID: bizID,
+		IDs:   []int64{rolloutID},
+		Page:  metadata.BasePage{Limit: 1},
+	}
+	rollouts, err := ps.CoreAPI.CoreService().Process().ListServiceTemplateRollouts(ctx.Kit.Ctx, ctx.Kit.Header, option)
+	if err != nil {
+		blog.Errorf("get service template rollout failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		return nil, err
+	}
+	if len(rollouts.Info) == 0 {
+		return nil, ctx.Kit.CCError.CCError(common.CCErrCommNotFound)
+	}
+	return &rollouts.Info[0], nil
+}
+
+// getRolloutForUpdate get the rollout in the path, authorize it and check if its status is one of the allowed status
+func (ps *ProcServer) getRolloutForUpdate(ctx *rest.Contexts, allowed ...metadata.RolloutStatus) (
+	*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+
+	rolloutID, err := util.GetInt64ByInterface(ctx.Request.PathParameter("rollout_id"))
+	if err != nil {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "rollout_id")
+	}
+
+	option := new(struct {
+		BizID int64 `json:"bk_biz_id"`
+	})
+	if err := ctx.DecodeInto(option); err != nil {
+		return nil, ctx.Kit.CCError.CCError(common.CCErrCommJSONUnmarshalFailed)
+	}
+	if option.BizID == 0 {
+		return nil, ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+
+	rollout, ccErr := ps.getServiceTemplateRollout(ctx, option.BizID, rolloutID)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+
+	if err := ps.AuthManager.AuthorizeByServiceTemplateID(ctx.Kit.Ctx, ctx.Kit.Header, meta.Update,
+		rollout.ServiceTemplateID); err != nil {
+		blog.Errorf("authorize by service template id failed, id: %d, err: %v, rid: %s", rollout.ServiceTemplateID,
+			err, ctx.Kit.Rid)
+		return nil, ctx.Kit.CCError.CCError(common.CCErrCommCheckAuthorizeFailed)
+	}
+
+	for _, status := range allowed {
+		if rollout.Status == status {
+			return rollout, nil
+		}
+	}
+	return nil, ctx.Kit.CCError.CCErrorf(common.CCErrProcRolloutStatusInvalid, rollout.Status)
+}
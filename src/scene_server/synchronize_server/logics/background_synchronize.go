@@ -169,15 +169,18 @@ func (s *synchronizeItem) synchronizeInstanceTask(ctx context.Context) (errorInf
 }
 
 func (s *synchronizeItem) synchronizeInstance(ctx context.Context, objID string, inst *FetchInst) ([]metadata.ExceptionResult, error) {
-	var start int64 = 0
+	var lastID uint64
 	limit := int64(defaultLimit)
 	var errorInfoArr []metadata.ExceptionResult
 
 	for {
-		info, err := inst.Fetch(ctx, objID, start, limit)
+		info, err := inst.Fetch(ctx, objID, lastID, limit)
 		if err != nil {
 			return nil, err
 		}
+		if info == nil {
+			break
+		}
 
 		input := &metadata.SynchronizeDataInfo{}
 		input.OperateDataType = metadata.SynchronizeOperateDataTypeInstance
@@ -194,10 +197,15 @@ func (s *synchronizeItem) synchronizeInstance(ctx context.Context, objID string,
 			errorInfoArr = append(errorInfoArr, pageErrInfoArr...)
 		}
 
-		start += limit
-		if start >= int64(info.Count) {
+		if int64(len(info.Info)) < limit {
 			break
 		}
+		id, err := info.Info[len(info.Info)-1].Int64(common.GetInstIDField(objID))
+		if err != nil {
+			blog.Errorf("synchronize instance get last id failed, objID: %s, err: %v, rid: %s", objID, err, s.lgc.rid)
+			return nil, err
+		}
+		lastID = uint64(id)
 	}
 	if common.BKInnerObjIDApp == objID {
 		inst.SetAppIDArr(s.appIDArr)
@@ -315,13 +323,13 @@ func (s *synchronizeItem) synchronizeModelTask(ctx context.Context) ([]metadata.
 }
 
 func (s *synchronizeItem) synchronizeModel(ctx context.Context, model *FetchModel, cond mapstr.MapStr, dataClassify string) ([]metadata.ExceptionResult, error) {
-	var start int64 = 0
+	var lastID uint64
 	limit := int64(defaultLimit)
 	var errorInfoArr []metadata.ExceptionResult
 
 	for {
 
-		info, err := model.Fetch(ctx, dataClassify, cond, start, limit)
+		info, err := model.Fetch(ctx, dataClassify, cond, lastID, limit)
 		if err != nil {
 			return nil, err
 		}
@@ -340,10 +348,15 @@ func (s *synchronizeItem) synchronizeModel(ctx context.Context, model *FetchMode
 		if len(pageErrInfoArr) > 0 {
 			errorInfoArr = append(errorInfoArr, pageErrInfoArr...)
 		}
-		start += limit
-		if start >= int64(info.Count) {
+		if int64(len(info.Info)) < limit {
 			break
 		}
+		id, err := info.Info[len(info.Info)-1].Int64(common.BKFieldID)
+		if err != nil {
+			blog.Errorf("synchronize model get last id failed, type: %s, err: %v, rid: %s", dataClassify, err, s.lgc.rid)
+			return nil, err
+		}
+		lastID = uint64(id)
 	}
 
 	return errorInfoArr, nil
@@ -18,6 +18,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 )
 
@@ -77,5 +78,17 @@ func SynchronizeFindInfoParameterToQuerycondition(input *metadata.SynchronizeFin
 		//  limit
 		ret.Page.Limit = defaultLimit
 	}
+
+	// sort by the id field so that the page can be continued with the last id
+	idField := common.GetInstIDField(input.DataClassify)
+	ret.Page.Sort = idField
+	if input.LastID > 0 {
+		if ret.Condition == nil {
+			ret.Condition = mapstr.New()
+		}
+		ret.Condition = mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{ret.Condition,
+			{idField: mapstr.MapStr{common.BKDBGT: input.LastID}}}}
+		ret.Page.Start = 0
+	}
 	return ret
 }
@@ -63,13 +63,13 @@ func (fi *FetchInst) Pretreatment() errors.CCError {
 	return nil
 }
 
-// Fetch fetch instance data
-func (fi *FetchInst) Fetch(ctx context.Context, objID string, start, limit int64) (*metadata.InstDataInfo, errors.CCError) {
+// Fetch fetch instance data whose id is greater than lastID
+func (fi *FetchInst) Fetch(ctx context.Context, objID string, lastID uint64, limit int64) (*metadata.InstDataInfo, errors.CCError) {
 	input := &metadata.SynchronizeFindInfoParameter{
 		Condition: mapstr.New(),
 	}
 	input.Limit = uint64(limit)
-	input.Start = uint64(start)
+	input.LastID = lastID
 	switch objID {
 	case common.BKInnerObjIDApp:
 		conds := condition.CreateCondition()
@@ -45,15 +45,15 @@ func (fm *FetchModel) Pretreatment() errors.CCError {
 	return nil
 }
 
-// Fetch  get model info
-func (fm *FetchModel) Fetch(ctx context.Context, dataClassify string, cond mapstr.MapStr, start, limit int64) (*metadata.InstDataInfo, errors.CCError) {
+// Fetch  get model info whose id is greater than lastID
+func (fm *FetchModel) Fetch(ctx context.Context, dataClassify string, cond mapstr.MapStr, lastID uint64, limit int64) (*metadata.InstDataInfo, errors.CCError) {
 	input := &metadata.SynchronizeFindInfoParameter{
 		Condition: mapstr.New(),
 	}
 	input.DataClassify = dataClassify
 	input.DataType = metadata.SynchronizeOperateDataTypeModel
 	input.Limit = uint64(limit)
-	input.Start = uint64(start)
+	input.LastID = lastID
 	input.Condition.Merge(fm.baseCondition)
 	input.Condition.Merge(cond)
 	result, err := fm.lgc.synchronizeSrv.SynchronizeSrv(fm.syncConfig.Name).Find(ctx, fm.lgc.header, input)
@@ -377,6 +377,8 @@ func (s *Service) initCodeTaskConfig() map[string]TaskInfo {
 			ti.Addr = s.Engine.Discovery().TopoServer().GetServers
 		case types.CC_MODULE_TASK:
 			ti.Addr = s.Engine.Discovery().TaskServer().GetServers
+		case types.CC_MODULE_CORESERVICE:
+			ti.Addr = s.Engine.Discovery().CoreService().GetServers
 		default:
 			panicErr := fmt.Sprintf("task code init. task:%s, svrType:%s, not exist", ti.Name, codeTaskConfig.SvrType)
 			panic(panicErr)
@@ -36,6 +36,10 @@ var (
 // init for auto task
 func init() {
 	AddCodeTaskConfig("sync-settemplate2set", types.CC_MODULE_TOPO, "/topo/v3/internal/task", 1)
+	AddCodeTaskConfig("sync-servicetemplate-rollout", types.CC_MODULE_PROC,
+		"/process/v3/internal/task/service_template_rollout", 1)
+	AddCodeTaskConfig("recalculate-model-instance", types.CC_MODULE_CORESERVICE,
+		"/api/v3/update/model/instance/calculated/task", 3)
 }
 
 // AddCodeTaskConfig add task
@@ -349,7 +349,8 @@ func (a *attribute) SetSupplierAccount(supplierAccount string) {
 
 func (a *attribute) isPropertyTypeIntEnumListSingleLong(propertyType string) bool {
 	switch propertyType {
-	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList:
+	case common.FieldTypeInt, common.FieldTypeEnum, common.FieldTypeList, common.FieldTypeCalculated,
+		common.FieldTypeInstRef:
 		return true
 	case common.FieldTypeSingleChar, common.FieldTypeLongChar:
 		return true
@@ -1152,7 +1152,8 @@ func (c *commonInst) FindOriginInst(kit *rest.Kit, objID string, cond *metadata.
 
 	default:
 		queryCond, err := mapstr.NewFromInterface(cond.Condition)
-		input := &metadata.QueryCondition{Condition: queryCond, TimeCondition: cond.TimeCondition}
+		input := &metadata.QueryCondition{Condition: queryCond, TimeCondition: cond.TimeCondition,
+			ExpandInstRef: cond.ExpandInstRef}
 		input.Page.Start = cond.Start
 		input.Page.Limit = cond.Limit
 		input.Page.Sort = cond.Sort
@@ -1196,10 +1197,18 @@ func (c *commonInst) UpdateInst(kit *rest.Kit, data mapstr.MapStr, obj model.Obj
 		query.Condition = innerCond.ToMapStr()
 	}
 
+	// the expected revision of the instance to be updated, it is optional
+	revision, err := metadata.PopExpectedRevision(kit.Header, data)
+	if err != nil {
+		blog.Errorf("update inst, parse expected revision failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKRevisionField)
+	}
+
 	fCond := cond.ToMapStr()
 	inputParams := metadata.UpdateOption{
 		Data:      data,
 		Condition: fCond,
+		Revision:  revision,
 	}
 
 	// generate audit log of instance.
@@ -1219,7 +1228,7 @@ func (c *commonInst) UpdateInst(kit *rest.Kit, data mapstr.MapStr, obj model.Obj
 	}
 	if !rsp.Result {
 		blog.Errorf("[operation-inst] failed to set the object(%s) inst by the condition(%#v), err: %s, rid: %s", obj.Object().ObjectID, fCond, rsp.ErrMsg, kit.Rid)
-		return kit.CCError.New(rsp.Code, rsp.ErrMsg)
+		return metadata.NewRevisionConflictError(rsp)
 	}
 
 	// save audit log.
@@ -116,6 +116,14 @@ func (s *Service) SearchAuditList(ctx *rest.Contexts) {
 		cond[common.BKAuditTypeField] = auditTypeCond
 	}
 
+	// page by the id instead of skipping the previous pages when the last id is set, so that a deep page is
+	// as cheap as the first one, and no audit log is missed or duplicated when new ones are created.
+	if query.LastID > 0 {
+		cond[common.BKFieldID] = map[string]interface{}{common.BKDBGT: query.LastID}
+		query.Page.Sort = common.BKFieldID
+		query.Page.Start = 0
+	}
+
 	auditQuery := metadata.QueryCondition{
 		Condition: cond,
 		Fields:    fields,
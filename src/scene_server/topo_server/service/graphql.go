@@ -0,0 +1,280 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/graphql"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	// graphqlDefaultLimit is the default page size of the instances and the associated instances
+	graphqlDefaultLimit = 20
+	// graphqlParentField is the field of the mainline parent instance
+	graphqlParentField = "parent"
+	// graphqlChildrenField is the field of the mainline child instances
+	graphqlChildrenField = "children"
+	// graphqlReverseSuffix is the suffix of the field of the instances that associate to the instance
+	graphqlReverseSuffix = "_reverse"
+)
+
+var graphqlNameRegexp = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// GraphQLQuery executes a read only graphql query on the models, instances and topology
+func (s *Service) GraphQLQuery(ctx *rest.Contexts) {
+	req := new(graphql.Request)
+	if err := ctx.DecodeInto(req); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if len(req.Query) == 0 {
+		ctx.RespErrorCodeOnly(common.CCErrCommParamsNeedSet, "query")
+		return
+	}
+
+	schema, err := s.newGraphQLSchema(ctx.Kit)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	resp := graphql.Execute(ctx.Kit.Ctx, schema, req, graphql.ExecuteOption{})
+	for _, err := range resp.Errors {
+		blog.Warnf("execute graphql query failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+	}
+	ctx.RespEntity(resp)
+}
+
+// GraphQLSchema returns the schema definition language of the graphql schema
+func (s *Service) GraphQLSchema(ctx *rest.Contexts) {
+	schema, err := s.newGraphQLSchema(ctx.Kit)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(schema.String())
+}
+
+// graphqlObject is the graphql types of a model
+type graphqlObject struct {
+	model metadata.Object
+	typ   *graphql.ObjectType
+	page  *graphql.ObjectType
+	// mainline is whether the model is a custom level of the mainline topology
+	mainline bool
+}
+
+// newGraphQLSchema builds the schema from the models, attributes and associations. each model is a type with
+// its attributes as the fields, and the associations are the fields of the associated instances.
+func (s *Service) newGraphQLSchema(kit *rest.Kit) (*graphql.Schema, error) {
+	models, err := s.Engine.CoreAPI.CoreService().Model().ReadModel(kit.Ctx, kit.Header, &metadata.QueryCondition{})
+	if err != nil {
+		blog.Errorf("read models failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+	if err := models.CCError(); err != nil {
+		blog.Errorf("read models failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	assts, err := s.Engine.CoreAPI.CoreService().Association().ReadModelAssociation(kit.Ctx, kit.Header,
+		&metadata.QueryCondition{})
+	if err != nil {
+		blog.Errorf("read model associations failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+	if err := assts.CCError(); err != nil {
+		blog.Errorf("read model associations failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, err
+	}
+
+	r := newGraphQLResolver(s, kit)
+	query := graphql.NewObject("Query", "The models of cmdb, query the instances of a model by its bk_obj_id.")
+	objects := make(map[string]*graphqlObject)
+	typeNames := map[string]bool{"Query": true, "Int": true, "Float": true, "String": true, "Boolean": true,
+		"ID": true, "JSON": true}
+
+	sort.Slice(models.Data.Info, func(i, j int) bool {
+		return models.Data.Info[i].Spec.ObjectID < models.Data.Info[j].Spec.ObjectID
+	})
+	for _, model := range models.Data.Info {
+		objID := model.Spec.ObjectID
+		if !graphqlNameRegexp.MatchString(objID) {
+			continue
+		}
+
+		name := graphqlTypeName(objID)
+		for typeNames[name] || typeNames[name+"Page"] {
+			name += "Model"
+		}
+		typeNames[name], typeNames[name+"Page"] = true, true
+
+		obj := &graphqlObject{model: model.Spec, typ: graphql.NewObject(name, model.Spec.ObjectName)}
+		idField := common.GetInstIDField(objID)
+		obj.typ.AddField(&graphql.FieldDef{Name: idField, Type: graphql.NewNonNull(graphql.Int)})
+		for _, attr := range model.Attributes {
+			if attr.PropertyID == idField || !graphqlNameRegexp.MatchString(attr.PropertyID) {
+				continue
+			}
+			obj.typ.AddField(&graphql.FieldDef{
+				Name:        attr.PropertyID,
+				Description: attr.PropertyName,
+				Type:        graphqlAttributeType(attr.PropertyType),
+			})
+		}
+
+		obj.page = graphql.NewObject(name+"Page", "")
+		obj.page.AddField(&graphql.FieldDef{
+			Name:        "count",
+			Description: "the count of the matched instances, including the unauthorized ones",
+			Type:        graphql.NewNonNull(graphql.Int),
+		})
+		obj.page.AddField(&graphql.FieldDef{
+			Name:        "info",
+			Description: "the authorized instances of the page",
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(obj.typ))),
+		})
+		objects[objID] = obj
+
+		query.AddField(&graphql.FieldDef{
+			Name:        objID,
+			Description: model.Spec.ObjectName,
+			Type:        graphql.NewNonNull(obj.page),
+			Args: []*graphql.ArgumentDef{
+				{Name: "condition", Description: "the condition of the instances", Type: graphql.JSON},
+				{Name: common.BKAppIDField, Description: "the business of the instances", Type: graphql.Int},
+				{Name: "start", Type: graphql.Int, Default: int64(0)},
+				{Name: "limit", Type: graphql.Int, Default: int64(graphqlDefaultLimit)},
+				{Name: "sort", Type: graphql.String},
+			},
+			Resolve: r.resolveInstances(objID),
+		})
+	}
+
+	// the fields of the associations, a field is not added if it conflicts with an attribute
+	addEdge := func(from, to *graphqlObject, name, description string, single bool, edge *graphqlEdge) {
+		if from.typ.Field(name) != nil {
+			blog.V(4).Infof("graphql field %s of %s conflicts, skip it, rid: %s", name, from.model.ObjectID, kit.Rid)
+			return
+		}
+		edge.from, edge.to = from.model.ObjectID, to.model.ObjectID
+		field := &graphql.FieldDef{Name: name, Description: description, Resolve: r.resolveEdge(edge)}
+		if single {
+			field.Type = to.typ
+		} else {
+			field.Type = graphql.NewList(graphql.NewNonNull(to.typ))
+			field.Args = []*graphql.ArgumentDef{
+				{Name: "start", Type: graphql.Int, Default: int64(0)},
+				{Name: "limit", Type: graphql.Int, Default: int64(graphqlDefaultLimit)},
+			}
+		}
+		from.typ.AddField(field)
+	}
+
+	host, module := objects[common.BKInnerObjIDHost], objects[common.BKInnerObjIDModule]
+	if host != nil && module != nil {
+		addEdge(host, module, "modules", "the modules of the host", false, &graphqlEdge{kind: edgeHostModule})
+		addEdge(module, host, "hosts", "the hosts of the module", false, &graphqlEdge{kind: edgeModuleHost})
+	}
+
+	for _, asst := range assts.Data.Info {
+		from, to := objects[asst.ObjectID], objects[asst.AsstObjID]
+		if from == nil || to == nil {
+			continue
+		}
+
+		if asst.AsstKindID == common.AssociationKindMainline {
+			// the relations of the hosts and modules are not saved in bk_parent_id
+			if asst.ObjectID == common.BKInnerObjIDHost {
+				continue
+			}
+			from.mainline, to.mainline = !isInnerMainline(from.model.ObjectID), !isInnerMainline(to.model.ObjectID)
+			addEdge(from, to, graphqlParentField, "the mainline parent instance", true,
+				&graphqlEdge{kind: edgeParent})
+			addEdge(to, from, graphqlChildrenField, "the mainline child instances", false,
+				&graphqlEdge{kind: edgeChildren})
+			continue
+		}
+
+		if !graphqlNameRegexp.MatchString(asst.AssociationName) {
+			continue
+		}
+		addEdge(from, to, asst.AssociationName, asst.AssociationAliasName, false,
+			&graphqlEdge{kind: edgeAssociation, asstID: asst.AssociationName})
+		addEdge(to, from, asst.AssociationName+graphqlReverseSuffix, asst.AssociationAliasName, false,
+			&graphqlEdge{kind: edgeAssociationReverse, asstID: asst.AssociationName})
+	}
+
+	r.objects = objects
+	return graphql.NewSchema(query)
+}
+
+func isInnerMainline(objID string) bool {
+	switch objID {
+	case common.BKInnerObjIDApp, common.BKInnerObjIDSet, common.BKInnerObjIDModule, common.BKInnerObjIDHost:
+		return true
+	}
+	return false
+}
+
+// graphqlTypeName converts the object id to the type name, like bk_switch to BkSwitch
+func graphqlTypeName(objID string) string {
+	parts := strings.Split(objID, "_")
+	for idx, part := range parts {
+		if len(part) > 0 {
+			parts[idx] = strings.ToUpper(part[:1]) + part[1:]
+		}
+	}
+	name := strings.Join(parts, "")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "M" + name
+	}
+	return name
+}
+
+// graphqlAttributeType returns the graphql type of the attribute, the attributes with structured values
+// are JSON.
+func graphqlAttributeType(propertyType string) graphql.Type {
+	switch propertyType {
+	case common.FieldTypeInt:
+		return graphql.Int
+	case common.FieldTypeFloat:
+		return graphql.Float
+	case common.FieldTypeBool:
+		return graphql.Boolean
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeEnum, common.FieldTypeDate,
+		common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeTimeZone, common.FieldTypeList:
+		return graphql.String
+	default:
+		return graphql.JSON
+	}
+}
+
+// graphqlCondition converts the condition argument to the instance condition
+func graphqlCondition(args map[string]interface{}) mapstr.MapStr {
+	cond := mapstr.New()
+	if value, ok := args["condition"].(map[string]interface{}); ok {
+		for key, val := range value {
+			cond[key] = val
+		}
+	}
+	return cond
+}
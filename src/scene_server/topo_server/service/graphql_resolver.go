@@ -0,0 +1,518 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"sort"
+
+	"configcenter/src/ac/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/auth"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/graphql"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+type graphqlEdgeKind int
+
+const (
+	// edgeAssociation is the instances that the instance associates to
+	edgeAssociation graphqlEdgeKind = iota
+	// edgeAssociationReverse is the instances that associate to the instance
+	edgeAssociationReverse
+	// edgeParent is the mainline parent instance, it's saved in bk_parent_id of the instance
+	edgeParent
+	// edgeChildren is the mainline child instances
+	edgeChildren
+	// edgeHostModule is the modules of the host
+	edgeHostModule
+	// edgeModuleHost is the hosts of the module
+	edgeModuleHost
+)
+
+const (
+	// graphqlMaxReadRecords is the max number of the records that a read of the edges can get, the edges of all
+	// the source instances are read at once, so the limit is set to the database query.
+	graphqlMaxReadRecords = common.BKMaxPageSize - 1
+	// graphqlMaxLoadedRecords is the max number of the records that a graphql query can load, it limits the cost
+	// of a query together with the max depth of the fields.
+	graphqlMaxLoadedRecords = 10 * common.BKMaxPageSize
+)
+
+// graphqlReadPage is the page of the reads of the edges, one more record is read to find the exceeding
+var graphqlReadPage = metadata.BasePage{Limit: graphqlMaxReadRecords + 1}
+
+// graphqlEdge is a field of the instances of model "to" on the type of model "from"
+type graphqlEdge struct {
+	kind   graphqlEdgeKind
+	from   string
+	to     string
+	asstID string
+}
+
+// graphqlResolver loads the instances of a graphql query. the resolvers get the instances of all the parents
+// at once, and the loaded instances are cached so that an instance is read and authorized only once.
+type graphqlResolver struct {
+	s       *Service
+	kit     *rest.Kit
+	objects map[string]*graphqlObject
+	// cache is the loaded instances of the model by id, the unauthorized and not found ones are nil
+	cache map[string]map[int64]mapstr.MapStr
+	// loaded is the number of the records that the query has loaded
+	loaded int
+}
+
+func newGraphQLResolver(s *Service, kit *rest.Kit) *graphqlResolver {
+	return &graphqlResolver{s: s, kit: kit, cache: make(map[string]map[int64]mapstr.MapStr)}
+}
+
+// resolveInstances resolves the page of the instances of the model
+func (r *graphqlResolver) resolveInstances(objID string) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) ([]interface{}, error) {
+		page, err := r.parsePage(p.Args)
+		if err != nil {
+			return nil, err
+		}
+		if sortField, ok := p.Args["sort"].(string); ok {
+			page.Sort = sortField
+		}
+
+		cond := graphqlCondition(p.Args)
+		if bizID, ok := p.Args[common.BKAppIDField].(int64); ok {
+			if objID == common.BKInnerObjIDHost {
+				hostIDs, err := r.readBizHostIDs(bizID)
+				if err != nil {
+					return nil, err
+				}
+				cond[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: hostIDs}
+			} else {
+				cond[common.BKAppIDField] = bizID
+			}
+		}
+
+		count, instances, err := r.readInstances(objID, cond, page)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.countLoaded(len(instances)); err != nil {
+			return nil, err
+		}
+		authorized, err := r.authorize(objID, instances)
+		if err != nil {
+			return nil, err
+		}
+		// count is the total of the matched instances including the unauthorized ones, authorizing all of them
+		// to count is too expensive, it's declared in the schema.
+		return []interface{}{mapstr.MapStr{"count": count, "info": authorized}}, nil
+	}
+}
+
+// resolveEdge resolves the associated instances of all the source instances
+func (r *graphqlResolver) resolveEdge(edge *graphqlEdge) graphql.ResolveFunc {
+	return func(p graphql.ResolveParams) ([]interface{}, error) {
+		sourceIDs := make([]int64, len(p.Sources))
+		for idx, source := range p.Sources {
+			sourceIDs[idx] = instanceID(edge.from, source)
+		}
+
+		targets, err := r.readEdgeTargets(edge, p.Sources, util.IntArrayUnique(sourceIDs))
+		if err != nil {
+			return nil, err
+		}
+
+		targetIDs := make([]int64, 0)
+		for _, ids := range targets {
+			targetIDs = append(targetIDs, ids...)
+		}
+		instances, err := r.readByIDs(edge.to, util.IntArrayUnique(targetIDs))
+		if err != nil {
+			return nil, err
+		}
+
+		if edge.kind == edgeParent {
+			values := make([]interface{}, len(p.Sources))
+			for idx, id := range sourceIDs {
+				for _, targetID := range targets[id] {
+					if inst := instances[targetID]; inst != nil {
+						values[idx] = inst
+					}
+				}
+			}
+			return values, nil
+		}
+
+		page, err := r.parsePage(p.Args)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]interface{}, len(p.Sources))
+		for idx, id := range sourceIDs {
+			list := make([]mapstr.MapStr, 0)
+			for _, targetID := range targets[id] {
+				if inst := instances[targetID]; inst != nil {
+					list = append(list, inst)
+				}
+			}
+			if page.Start >= len(list) {
+				list = list[:0]
+			} else {
+				list = list[page.Start:]
+			}
+			if len(list) > page.Limit {
+				list = list[:page.Limit]
+			}
+			values[idx] = list
+		}
+		return values, nil
+	}
+}
+
+func (r *graphqlResolver) parsePage(args map[string]interface{}) (metadata.BasePage, error) {
+	start, _ := args["start"].(int64)
+	limit, _ := args["limit"].(int64)
+	if start < 0 {
+		return metadata.BasePage{}, r.kit.CCError.Errorf(common.CCErrCommParamsInvalid, "start")
+	}
+	if limit <= 0 || limit > common.BKMaxInstanceLimit {
+		return metadata.BasePage{}, r.kit.CCError.CCErrorf(common.CCErrCommPageLimitIsExceeded)
+	}
+	return metadata.BasePage{Start: int(start), Limit: int(limit)}, nil
+}
+
+// countLoaded counts the records that are read by the query, returns error if a read or the query loads
+// too many records.
+func (r *graphqlResolver) countLoaded(count int) error {
+	if count > graphqlMaxReadRecords {
+		blog.Errorf("graphql read %d records, exceeds the limit %d, rid: %s", count, graphqlMaxReadRecords, r.kit.Rid)
+		return r.kit.CCError.CCErrorf(common.CCErrCommXXExceedLimit, "graphql read records", graphqlMaxReadRecords)
+	}
+	r.loaded += count
+	if r.loaded > graphqlMaxLoadedRecords {
+		blog.Errorf("graphql query loaded %d records, exceeds the limit %d, rid: %s", r.loaded,
+			graphqlMaxLoadedRecords, r.kit.Rid)
+		return r.kit.CCError.CCErrorf(common.CCErrCommXXExceedLimit, "graphql query records",
+			graphqlMaxLoadedRecords)
+	}
+	return nil
+}
+
+// readEdgeTargets returns the ids of the target instances of each source instance, sorted by id
+func (r *graphqlResolver) readEdgeTargets(edge *graphqlEdge, sources []interface{}, sourceIDs []int64) (
+	map[int64][]int64, error) {
+
+	targets := make(map[int64][]int64)
+	switch edge.kind {
+	case edgeAssociation, edgeAssociationReverse:
+		cond := mapstr.MapStr{common.AssociationObjAsstIDField: edge.asstID}
+		if edge.kind == edgeAssociation {
+			cond[common.BKObjIDField] = edge.from
+			cond[common.BKInstIDField] = mapstr.MapStr{common.BKDBIN: sourceIDs}
+		} else {
+			cond[common.BKAsstObjIDField] = edge.from
+			cond[common.BKAsstInstIDField] = mapstr.MapStr{common.BKDBIN: sourceIDs}
+		}
+		input := &metadata.QueryCondition{
+			Condition:      cond,
+			Page:           graphqlReadPage,
+			DisableCounter: true,
+		}
+		result, err := r.s.Engine.CoreAPI.CoreService().Association().ReadInstAssociation(r.kit.Ctx, r.kit.Header,
+			input)
+		if err != nil {
+			blog.Errorf("read instance associations failed, cond: %v, err: %v, rid: %s", cond, err, r.kit.Rid)
+			return nil, err
+		}
+		if err := result.CCError(); err != nil {
+			blog.Errorf("read instance associations failed, cond: %v, err: %v, rid: %s", cond, err, r.kit.Rid)
+			return nil, err
+		}
+		if err := r.countLoaded(len(result.Data.Info)); err != nil {
+			return nil, err
+		}
+		for _, asst := range result.Data.Info {
+			if edge.kind == edgeAssociation {
+				targets[asst.InstID] = append(targets[asst.InstID], asst.AsstInstID)
+			} else {
+				targets[asst.AsstInstID] = append(targets[asst.AsstInstID], asst.InstID)
+			}
+		}
+
+	case edgeParent:
+		for _, source := range sources {
+			parentID, err := util.GetInt64ByInterface(source.(mapstr.MapStr)[common.BKInstParentStr])
+			if err != nil {
+				continue
+			}
+			id := instanceID(edge.from, source)
+			targets[id] = []int64{parentID}
+		}
+
+	case edgeChildren:
+		cond := mapstr.MapStr{common.BKInstParentStr: mapstr.MapStr{common.BKDBIN: sourceIDs}}
+		_, children, err := r.readInstances(edge.to, cond, graphqlReadPage)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.countLoaded(len(children)); err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			parentID, err := util.GetInt64ByInterface(child[common.BKInstParentStr])
+			if err != nil {
+				continue
+			}
+			targets[parentID] = append(targets[parentID], instanceID(edge.to, child))
+		}
+
+	case edgeHostModule, edgeModuleHost:
+		req := &metadata.HostModuleRelationRequest{HostIDArr: sourceIDs}
+		if edge.kind == edgeModuleHost {
+			req = &metadata.HostModuleRelationRequest{ModuleIDArr: sourceIDs}
+		}
+		relations, err := r.readHostRelations(req, graphqlReadPage)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.countLoaded(len(relations)); err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			if edge.kind == edgeHostModule {
+				targets[relation.HostID] = append(targets[relation.HostID], relation.ModuleID)
+			} else {
+				targets[relation.ModuleID] = append(targets[relation.ModuleID], relation.HostID)
+			}
+		}
+	}
+
+	for id := range targets {
+		ids := util.IntArrayUnique(targets[id])
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		targets[id] = ids
+	}
+	return targets, nil
+}
+
+// readByIDs returns the authorized instances of the ids, the cached ones are not read again
+func (r *graphqlResolver) readByIDs(objID string, ids []int64) (map[int64]mapstr.MapStr, error) {
+	cache, exist := r.cache[objID]
+	if !exist {
+		cache = make(map[int64]mapstr.MapStr)
+		r.cache[objID] = cache
+	}
+
+	missing := make([]int64, 0)
+	for _, id := range ids {
+		if _, exist := cache[id]; !exist {
+			missing = append(missing, id)
+		}
+	}
+
+	if len(missing) > 0 {
+		cond := mapstr.MapStr{common.GetInstIDField(objID): mapstr.MapStr{common.BKDBIN: missing}}
+		_, instances, err := r.readInstances(objID, cond, graphqlReadPage)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.countLoaded(len(instances)); err != nil {
+			return nil, err
+		}
+		if _, err := r.authorize(objID, instances); err != nil {
+			return nil, err
+		}
+		for _, id := range missing {
+			if _, exist := cache[id]; !exist {
+				cache[id] = nil
+			}
+		}
+	}
+
+	instances := make(map[int64]mapstr.MapStr)
+	for _, id := range ids {
+		if inst := cache[id]; inst != nil {
+			instances[id] = inst
+		}
+	}
+	return instances, nil
+}
+
+func (r *graphqlResolver) readInstances(objID string, cond mapstr.MapStr, page metadata.BasePage) (int,
+	[]mapstr.MapStr, error) {
+
+	input := &metadata.QueryCondition{Condition: cond, Page: page}
+	result, err := r.s.Engine.CoreAPI.CoreService().Instance().ReadInstance(r.kit.Ctx, r.kit.Header, objID, input)
+	if err != nil {
+		blog.Errorf("read %s instances failed, cond: %v, err: %v, rid: %s", objID, cond, err, r.kit.Rid)
+		return 0, nil, err
+	}
+	if err := result.CCError(); err != nil {
+		blog.Errorf("read %s instances failed, cond: %v, err: %v, rid: %s", objID, cond, err, r.kit.Rid)
+		return 0, nil, err
+	}
+	return result.Data.Count, result.Data.Info, nil
+}
+
+// readBizHostIDs reads the ids of the hosts in the business page by page, the relations are counted as the loaded
+// records, so the query of a business with too many hosts exceeds the limit.
+func (r *graphqlResolver) readBizHostIDs(bizID int64) ([]int64, error) {
+	hostIDs := make([]int64, 0)
+	page := metadata.BasePage{
+		Limit: graphqlMaxReadRecords,
+		Sort:  common.BKHostIDField + "," + common.BKModuleIDField,
+	}
+	for {
+		req := &metadata.HostModuleRelationRequest{
+			ApplicationID: bizID,
+			Fields:        []string{common.BKHostIDField, common.BKModuleIDField},
+		}
+		relations, err := r.readHostRelations(req, page)
+		if err != nil {
+			return nil, err
+		}
+		if err := r.countLoaded(len(relations)); err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			hostIDs = append(hostIDs, relation.HostID)
+		}
+		if len(relations) < page.Limit {
+			break
+		}
+		page.Start += page.Limit
+	}
+	return util.IntArrayUnique(hostIDs), nil
+}
+
+func (r *graphqlResolver) readHostRelations(req *metadata.HostModuleRelationRequest,
+	page metadata.BasePage) ([]metadata.ModuleHost, error) {
+
+	req.Page = page
+	result, err := r.s.Engine.CoreAPI.CoreService().Host().GetHostModuleRelation(r.kit.Ctx, r.kit.Header, req)
+	if err != nil {
+		blog.Errorf("read host module relations failed, err: %v, rid: %s", err, r.kit.Rid)
+		return nil, err
+	}
+	if err := result.CCError(); err != nil {
+		blog.Errorf("read host module relations failed, err: %v, rid: %s", err, r.kit.Rid)
+		return nil, err
+	}
+	return result.Data.Info, nil
+}
+
+// authorize returns the instances that the user can find, the results are cached
+func (r *graphqlResolver) authorize(objID string, instances []mapstr.MapStr) ([]mapstr.MapStr, error) {
+	authorized := instances
+	if len(instances) > 0 && auth.EnableAuthorize() && !r.s.AuthManager.SkipReadAuthorization {
+		resources, err := r.makeResources(objID, instances)
+		if err != nil {
+			return nil, err
+		}
+		user := meta.UserInfo{UserName: r.kit.User, SupplierAccount: r.kit.SupplierAccount}
+		decisions, err := r.s.AuthManager.Authorizer.AuthorizeBatch(r.kit.Ctx, r.kit.Header, user, resources...)
+		if err != nil {
+			blog.Errorf("authorize %s instances failed, err: %v, rid: %s", objID, err, r.kit.Rid)
+			return nil, r.kit.CCError.CCError(common.CCErrCommCheckAuthorizeFailed)
+		}
+
+		authorized = make([]mapstr.MapStr, 0)
+		for idx, decision := range decisions {
+			if decision.Authorized {
+				authorized = append(authorized, instances[idx])
+			}
+		}
+	}
+
+	cache, exist := r.cache[objID]
+	if !exist {
+		cache = make(map[int64]mapstr.MapStr)
+		r.cache[objID] = cache
+	}
+	for _, inst := range instances {
+		cache[instanceID(objID, inst)] = nil
+	}
+	for _, inst := range authorized {
+		cache[instanceID(objID, inst)] = inst
+	}
+	return authorized, nil
+}
+
+// makeResources makes the resources to find the instances
+func (r *graphqlResolver) makeResources(objID string, instances []mapstr.MapStr) ([]meta.ResourceAttribute,
+	error) {
+
+	hostBizIDs := make(map[int64]int64)
+	if objID == common.BKInnerObjIDHost {
+		hostIDs := make([]int64, len(instances))
+		for idx, inst := range instances {
+			hostIDs[idx] = instanceID(objID, inst)
+		}
+		relations, err := r.readHostRelations(&metadata.HostModuleRelationRequest{HostIDArr: hostIDs},
+			metadata.BasePage{Limit: common.BKNoLimit})
+		if err != nil {
+			return nil, err
+		}
+		for _, relation := range relations {
+			hostBizIDs[relation.HostID] = relation.AppID
+		}
+	}
+
+	resources := make([]meta.ResourceAttribute, len(instances))
+	for idx, inst := range instances {
+		id := instanceID(objID, inst)
+		bizID, _ := util.GetInt64ByInterface(inst[common.BKAppIDField])
+		resource := meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Action:     meta.Find,
+				Name:       util.GetStrByInterface(inst[common.GetInstNameField(objID)]),
+				InstanceID: id,
+			},
+			SupplierAccount: r.kit.SupplierAccount,
+			BusinessID:      bizID,
+		}
+
+		switch objID {
+		case common.BKInnerObjIDApp:
+			resource.Type = meta.Business
+		case common.BKInnerObjIDSet:
+			resource.Type = meta.ModelSet
+		case common.BKInnerObjIDModule:
+			resource.Type = meta.ModelModule
+		case common.BKInnerObjIDHost:
+			resource.Type = meta.HostInstance
+			resource.BusinessID = hostBizIDs[id]
+			resource.Layers = []meta.Item{{Type: meta.Business, InstanceID: hostBizIDs[id]}}
+		default:
+			resource.Type = meta.ModelInstance
+			if obj := r.objects[objID]; obj != nil {
+				if obj.mainline {
+					resource.Type = meta.MainlineInstance
+				}
+				resource.Layers = []meta.Item{{Type: meta.Model, InstanceID: obj.model.ID}}
+			}
+		}
+		resources[idx] = resource
+	}
+	return resources, nil
+}
+
+func instanceID(objID string, inst interface{}) int64 {
+	data, ok := inst.(mapstr.MapStr)
+	if !ok {
+		return 0
+	}
+	id, _ := util.GetInt64ByInterface(data[common.GetInstIDField(objID)])
+	return id
+}
@@ -415,6 +415,11 @@ func (s *Service) UpdateInst(ctx *rest.Contexts) {
 	})
 
 	if txnErr != nil {
+		// returns the current instance along with the error if it is changed by others
+		if current, conflict := metadata.GetRevisionConflictData(txnErr); conflict {
+			ctx.RespEntityWithError(current, txnErr)
+			return
+		}
 		ctx.RespAutoError(txnErr)
 		return
 	}
@@ -461,6 +466,7 @@ func (s *Service) SearchInsts(ctx *rest.Contexts) {
 	query.Limit = page.Limit
 	query.Sort = page.Sort
 	query.Start = page.Start
+	query.ExpandInstRef = queryCond.ExpandInstRef
 
 	cnt, instItems, err := s.Core.InstOperation().FindInst(ctx.Kit, obj, query, false)
 	if nil != err {
@@ -508,6 +514,7 @@ func (s *Service) SearchInstAndAssociationDetail(ctx *rest.Contexts) {
 	query.Limit = page.Limit
 	query.Sort = page.Sort
 	query.Start = page.Start
+	query.ExpandInstRef = queryCond.ExpandInstRef
 
 	result, err := s.Core.InstOperation().FindOriginInst(ctx.Kit, objID, query)
 	if nil != err {
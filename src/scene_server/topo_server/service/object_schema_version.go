@@ -0,0 +1,124 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// RollbackMainlineObjectSchemaWhiteList is the mainline objects whose schema can still be rolled back, host is
+// associated with the mainline topology, but its attributes, groups and uniques are maintained by the user.
+var RollbackMainlineObjectSchemaWhiteList = []string{
+	common.BKInnerObjIDHost,
+}
+
+// SearchObjectSchemaVersions search the schema versions of the object
+func (s *Service) SearchObjectSchemaVersions(ctx *rest.Contexts) {
+	input := metadata.QueryCondition{}
+	if err := ctx.DecodeInto(&input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if input.Page.IsIllegal() {
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommPageLimitIsExceeded))
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	result, err := s.Engine.CoreAPI.CoreService().Model().SearchModelSchemaVersions(ctx.Kit.Ctx, ctx.Kit.Header, objID, input)
+	if err != nil {
+		blog.Errorf("search object %s schema versions failed, err: %v, rid: %s", objID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+	if err := result.CCError(); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result.Data)
+}
+
+// DiffObjectSchema get the changes between two schema versions of the object, version 0 means the current schema
+func (s *Service) DiffObjectSchema(ctx *rest.Contexts) {
+	input := metadata.DiffModelSchemaOption{}
+	if err := ctx.DecodeInto(&input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+	result, err := s.Engine.CoreAPI.CoreService().Model().DiffModelSchema(ctx.Kit.Ctx, ctx.Kit.Header, objID, input)
+	if err != nil {
+		blog.Errorf("diff object %s schema failed, err: %v, rid: %s", objID, err, ctx.Kit.Rid)
+		ctx.RespAutoError(ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed))
+		return
+	}
+	if err := result.CCError(); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntity(result.Data)
+}
+
+// RollbackObjectSchema rollback the schema of the object to the specified version
+func (s *Service) RollbackObjectSchema(ctx *rest.Contexts) {
+	input := metadata.RollbackModelSchemaOption{}
+	if err := ctx.DecodeInto(&input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	objID := ctx.Request.PathParameter(common.BKObjIDField)
+
+	// mainline object's schema is maintained by the topology, it can not be rolled back.
+	yes, err := s.Core.AssociationOperation().IsMainlineObject(ctx.Kit, objID)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	if yes {
+		if util.InStrArr(RollbackMainlineObjectSchemaWhiteList, objID) == false {
+			ctx.RespAutoError(ctx.Kit.CCError.Error(common.CCErrorTopoMainlineObjectCanNotBeChanged))
+			return
+		}
+	}
+
+	var diff *metadata.ModelSchemaDiff
+	txnErr := s.Engine.CoreAPI.CoreService().Txn().AutoRunTxn(ctx.Kit.Ctx, ctx.Kit.Header, func() error {
+		result, err := s.Engine.CoreAPI.CoreService().Model().RollbackModelSchema(ctx.Kit.Ctx, ctx.Kit.Header, objID, input)
+		if err != nil {
+			blog.Errorf("rollback object %s schema failed, err: %v, rid: %s", objID, err, ctx.Kit.Rid)
+			return ctx.Kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if err := result.CCError(); err != nil {
+			blog.Errorf("rollback object %s schema to version %d failed, err: %v, rid: %s", objID, input.Version, err,
+				ctx.Kit.Rid)
+			return err
+		}
+		diff = result.Data
+		return nil
+	})
+
+	if txnErr != nil {
+		ctx.RespAutoError(txnErr)
+		return
+	}
+	ctx.RespEntity(diff)
+}
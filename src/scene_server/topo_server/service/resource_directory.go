@@ -56,6 +56,9 @@ func (s *Service) CreateResourceDirectory(ctx *rest.Contexts) {
 
 	// 设置资源池自定义目录的default值
 	data[common.BKDefaultField] = common.DefaultResSelfDefinedModuleFlag
+
+	// the created directory is read back to generate the audit log, so it must be read from the primary db node
+	ctx.SetReadAfterWrite()
 	input := &metadata.CreateModelInstance{Data: data}
 	rsp, err := s.Engine.CoreAPI.CoreService().Instance().CreateInstance(ctx.Kit.Ctx, ctx.Kit.Header, common.BKInnerObjIDModule, input)
 	if err != nil {
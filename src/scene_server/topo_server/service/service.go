@@ -20,7 +20,9 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
 	"configcenter/src/common/mapstr"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/types"
 	"configcenter/src/scene_server/topo_server/app/options"
 	"configcenter/src/scene_server/topo_server/core"
 	"configcenter/src/thirdparty/elasticsearch"
@@ -54,6 +56,8 @@ func (s *Service) WebService() *restful.Container {
 	healthz := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthz.Route(healthz.GET("/healthz").To(s.Healthz))
 	container := restful.NewContainer().Add(api)
+	healthz.Route(healthz.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb topo server", Tag: types.CC_MODULE_TOPO})))
 	container.Add(healthz)
 
 	return container
@@ -30,6 +30,14 @@ type Config struct {
 	Mongo      mongo.Config
 	WatchMongo mongo.Config
 	Redis      redis.Config
+	// InstanceCacheObjects is the objects whose instances and instance associations are cached.
+	InstanceCacheObjects []string
+	// HostSecondaryKeys is the host attributes which can be used as secondary keys to find hosts in cache.
+	HostSecondaryKeys []string
+	// VerifyIntervalMinutes is the interval to verify the caches, it's disabled when it's 0.
+	VerifyIntervalMinutes int
+	// VerifySampleSize is the sample count of each resource in one verify.
+	VerifySampleSize int64
 }
 
 //NewServerOption create a ServerOption object
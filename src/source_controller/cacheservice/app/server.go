@@ -15,6 +15,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"configcenter/src/common"
@@ -22,6 +23,7 @@ import (
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/types"
+	"configcenter/src/common/util"
 	"configcenter/src/source_controller/cacheservice/app/options"
 	cachesvr "configcenter/src/source_controller/cacheservice/service"
 	"configcenter/src/storage/driver/mongodb"
@@ -121,6 +123,35 @@ func initResource(cacheSvr *CacheServer) error {
 		return err
 	}
 
+	// the objects are separated with comma, and the instance cache is disabled when it's empty.
+	objects, _ := cc.String("cacheService.instanceCacheObjects")
+	for _, obj := range strings.Split(objects, ",") {
+		if obj = strings.TrimSpace(obj); len(obj) != 0 {
+			cacheSvr.Config.InstanceCacheObjects = append(cacheSvr.Config.InstanceCacheObjects, obj)
+		}
+	}
+
+	// the host attributes are separated with comma, inner ip and cloud id is already cached with ip relation keys.
+	fields, _ := cc.String("cacheService.hostSecondaryKeys")
+	for _, field := range strings.Split(fields, ",") {
+		field = strings.TrimSpace(field)
+		if len(field) == 0 || field == common.BKHostInnerIPField || field == common.BKCloudIDField ||
+			util.InStrArr(cacheSvr.Config.HostSecondaryKeys, field) {
+			continue
+		}
+		cacheSvr.Config.HostSecondaryKeys = append(cacheSvr.Config.HostSecondaryKeys, field)
+	}
+
+	// verify caches every 30 minutes with 200 samples by default.
+	cacheSvr.Config.VerifyIntervalMinutes = 30
+	if interval, err := cc.Int("cacheService.verify.intervalMinutes"); err == nil {
+		cacheSvr.Config.VerifyIntervalMinutes = interval
+	}
+	cacheSvr.Config.VerifySampleSize = 200
+	if size, err := cc.Int("cacheService.verify.sampleSize"); err == nil && size > 0 && size <= 1000 {
+		cacheSvr.Config.VerifySampleSize = int64(size)
+	}
+
 	dbErr := mongodb.InitClient("", &cacheSvr.Config.Mongo)
 	if dbErr != nil {
 		blog.Errorf("failed to connect the db server, error info is %s", dbErr.Error())
@@ -95,21 +95,23 @@ func (b *business) onUpsert(e *types.Event) {
 		return
 	}
 
-	forUpsert := forUpsertCache{
+	upsertListCache(newBizUpsertCache(bizID, bizName, e.DocBytes))
+}
+
+func newBizUpsertCache(bizID int64, bizName string, doc []byte) *forUpsertCache {
+	return &forUpsertCache{
 		instID:            bizID,
 		parentID:          0,
 		name:              bizName,
-		doc:               e.DocBytes,
+		doc:               doc,
 		listKey:           bizKey.listKeyWithBiz(bizID),
 		listExpireKey:     bizKey.listExpireKeyWithBiz(bizID),
 		detailKey:         bizKey.detailKey(bizID),
 		detailExpireKey:   bizKey.detailExpireKey(bizID),
 		parseListKeyValue: bizKey.parseListKeyValue,
 		genListKeyValue:   bizKey.genListKeyValue,
-		getInstName:       b.getBusinessName,
+		getInstName:       getBusinessName,
 	}
-
-	upsertListCache(&forUpsert)
 }
 
 func (b *business) onDelete(e *types.Event) {
@@ -126,7 +128,7 @@ func (b *business) onListDone() {
 	blog.Info("list business data to cache and list done")
 }
 
-func (b *business) getBusinessName(bizID int64) (string, error) {
+func getBusinessName(bizID int64) (string, error) {
 	bizInfo := new(BizBaseInfo)
 	filter := mapstr.MapStr{
 		common.BKAppIDField: bizID,
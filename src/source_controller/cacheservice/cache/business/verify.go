@@ -0,0 +1,358 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package business
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+)
+
+// verifyStep is the count of business verified in one batch.
+const verifyStep = 500
+
+// VerifyBusiness compare the business list and detail caches with the business in mongodb,
+// and repair the drifted keys if required.
+func (c *Client) VerifyBusiness(ctx context.Context, opt *metadata.VerifyCacheOption) (*metadata.VerifyCacheResult,
+	error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	start := time.Now()
+	result := &metadata.VerifyCacheResult{Resource: metadata.CacheResourceBiz}
+
+	members, err := redis.Client().SMembers(ctx, bizKey.listKeyWithBiz(0)).Result()
+	if err != nil {
+		blog.Errorf("verify business cache, but get business list failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	// business list member with business id.
+	cachedList := make(map[int64]string)
+	for _, member := range members {
+		id, _, _, err := bizKey.parseListKeyValue(member)
+		if err != nil {
+			continue
+		}
+		cachedList[id] = member
+	}
+
+	if !opt.FullScan {
+		bizs := make([]mapstr.MapStr, 0)
+		pipeline := []map[string]interface{}{{"$sample": map[string]interface{}{"size": opt.SampleSize}}}
+		err := mongodb.Client().Table(common.BKTableNameBaseApp).AggregateAll(ctx, pipeline, &bizs)
+		if err != nil {
+			blog.Errorf("verify business cache, but sample business failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+
+		if err := c.verifyBusiness(ctx, bizs, cachedList, opt.Repair, result); err != nil {
+			return nil, err
+		}
+		result.Cost = time.Since(start).Milliseconds()
+		return result, nil
+	}
+
+	exists := make(map[int64]bool)
+	lastID := int64(0)
+	for {
+		bizs, err := listBusinessAfterID(ctx, lastID)
+		if err != nil {
+			blog.Errorf("verify business cache, but list business after %d failed, err: %v, rid: %s", lastID, err,
+				rid)
+			return nil, err
+		}
+
+		if len(bizs) == 0 {
+			break
+		}
+
+		if err := c.verifyBusiness(ctx, bizs, cachedList, opt.Repair, result); err != nil {
+			return nil, err
+		}
+
+		for _, biz := range bizs {
+			lastID, _ = util.GetInt64ByInterface(biz[common.BKAppIDField])
+			exists[lastID] = true
+		}
+	}
+
+	staleIDs := make([]int64, 0)
+	for id := range cachedList {
+		if !exists[id] {
+			staleIDs = append(staleIDs, id)
+		}
+	}
+
+	// check with the primary, the business created recently may not be replicated to the secondaries.
+	exists, err = getExistBusinessIDs(util.SetDBReadPreference(ctx, common.PrimaryMode), staleIDs)
+	if err != nil {
+		blog.Errorf("verify business cache, but get exist business ids failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	// the business which is cached but not exist in mongodb.
+	for _, id := range staleIDs {
+		if exists[id] {
+			continue
+		}
+
+		member := cachedList[id]
+		result.Stale++
+		if !opt.Repair {
+			continue
+		}
+
+		blog.Warnf("verify business cache, remove stale business %d, rid: %s", id, rid)
+		pipe := redis.Client().Pipeline()
+		pipe.SRem(bizKey.listKeyWithBiz(0), member)
+		pipe.Del(bizKey.detailKey(id), bizKey.detailExpireKey(id))
+		if _, err := pipe.Exec(); err != nil {
+			blog.Errorf("verify business cache, but remove stale business %d failed, err: %v, rid: %s", id, err, rid)
+			return nil, err
+		}
+		result.Repaired++
+	}
+
+	result.Cost = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// RebuildBusiness drop all the business list and detail cache, and load them from mongodb again.
+// the business are read from the primary, and the business refreshed by the event watcher after the cache is
+// dropped is not overwritten, because it may be newer than the business read by the rebuild.
+func (c *Client) RebuildBusiness(ctx context.Context) (*metadata.VerifyCacheResult, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ctx = util.SetDBReadPreference(ctx, common.PrimaryMode)
+	start := time.Now()
+	result := &metadata.VerifyCacheResult{Resource: metadata.CacheResourceBiz}
+
+	err := tools.ScanKeys(ctx, bizNamespace+":"+string(bizKeyName)+"_detail:*", verifyStep,
+		func(keys []string) error {
+			return redis.Client().Del(ctx, keys...).Err()
+		})
+	if err != nil {
+		blog.Errorf("rebuild business cache, but delete business details failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	if err := redis.Client().Del(ctx, bizKey.listKeyWithBiz(0)).Err(); err != nil {
+		blog.Errorf("rebuild business cache, but delete business list failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	lastID := int64(0)
+	for {
+		bizs, err := listBusinessAfterID(ctx, lastID)
+		if err != nil {
+			blog.Errorf("rebuild business cache, but list business after %d failed, err: %v, rid: %s", lastID, err,
+				rid)
+			return nil, err
+		}
+
+		if len(bizs) == 0 {
+			break
+		}
+
+		for _, biz := range bizs {
+			lastID, _ = util.GetInt64ByInterface(biz[common.BKAppIDField])
+			result.Checked++
+			doc, err := json.Marshal(biz)
+			if err != nil {
+				continue
+			}
+
+			name := util.GetStrByInterface(biz[common.BKAppNameField])
+			set, err := compareAndSetBusiness(ctx, lastID, name, doc, "", "")
+			if err != nil {
+				blog.Errorf("rebuild business cache, but set business %d failed, err: %v, rid: %s", lastID, err, rid)
+				return nil, err
+			}
+			if set {
+				result.Repaired++
+			}
+		}
+	}
+
+	result.Cost = time.Since(start).Milliseconds()
+	blog.Infof("rebuild business cache success, result: %+v, rid: %s", *result, rid)
+	return result, nil
+}
+
+// verifyBusiness verify the business's list member and detail cache.
+func (c *Client) verifyBusiness(ctx context.Context, bizs []mapstr.MapStr, cachedList map[int64]string, repair bool,
+	result *metadata.VerifyCacheResult) error {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	for _, biz := range bizs {
+		bizID, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil || bizID <= 0 {
+			continue
+		}
+		bizName := util.GetStrByInterface(biz[common.BKAppNameField])
+		result.Checked++
+
+		doc, err := json.Marshal(biz)
+		if err != nil {
+			continue
+		}
+
+		cached, err := redis.Client().Get(ctx, bizKey.detailKey(bizID)).Result()
+		if err != nil && !redis.IsNilErr(err) {
+			blog.Errorf("verify business cache, but get business %d detail failed, err: %v, rid: %s", bizID, err, rid)
+			return err
+		}
+
+		// the business list and detail is fully cached, so a business not in cache is also a drift.
+		drifted := cachedList[bizID] != bizKey.genListKeyValue(bizID, 0, bizName) || len(cached) == 0 ||
+			tools.IsDetailDrifted(cached, string(doc))
+		if !drifted {
+			continue
+		}
+
+		result.Mismatched++
+		if !repair {
+			continue
+		}
+
+		blog.Warnf("verify business cache, business %d is drifted, rid: %s", bizID, rid)
+		repaired, err := repairBusiness(ctx, bizID, cached, cachedList[bizID])
+		if err != nil {
+			blog.Errorf("verify business cache, but repair business %d failed, err: %v, rid: %s", bizID, err, rid)
+			return err
+		}
+		if repaired {
+			result.Repaired++
+		}
+	}
+	return nil
+}
+
+// repairBusiness repair the drifted business cache with the business read from the primary, because the verified
+// business may be read from a secondary which is not up to date. the cache is repaired only if it is not changed
+// after it's verified, otherwise it's refreshed by the event watcher with a newer business.
+func repairBusiness(ctx context.Context, bizID int64, cached, member string) (bool, error) {
+	ctx = util.SetDBReadPreference(ctx, common.PrimaryMode)
+	filter := mapstr.MapStr{common.BKAppIDField: bizID}
+	bizs := make([]mapstr.MapStr, 0)
+	if err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).All(ctx, &bizs); err != nil {
+		return false, err
+	}
+
+	if len(bizs) == 0 {
+		return false, nil
+	}
+
+	doc, err := json.Marshal(bizs[0])
+	if err != nil {
+		return false, err
+	}
+
+	name := util.GetStrByInterface(bizs[0][common.BKAppNameField])
+	return compareAndSetBusiness(ctx, bizID, name, doc, cached, member)
+}
+
+// compareAndSetBusinessScript set the business detail and list member only when the cached detail is still the
+// expected one, which avoids overwriting the newer cache refreshed by the event watcher.
+// KEYS[1]: business detail key
+// KEYS[2]: business list key
+// KEYS[3]: business detail expire key
+// KEYS[4]: business list expire key
+// ARGV[1]: the expected cached detail, empty if the detail is not cached
+// ARGV[2]: business detail
+// ARGV[3]: the cached list member of the business, empty if it is not cached
+// ARGV[4]: the list member of the business
+// ARGV[5]: current unix time
+const compareAndSetBusinessScript = `
+local cached = redis.call('get', KEYS[1]);
+if (cached == false) then
+	cached = ''
+end;
+
+if (cached ~= ARGV[1]) then
+	return 0
+end;
+
+redis.call('set', KEYS[1], ARGV[2]);
+if (ARGV[3] ~= '' and ARGV[3] ~= ARGV[4]) then
+	redis.call('srem', KEYS[2], ARGV[3])
+end;
+redis.call('sadd', KEYS[2], ARGV[4]);
+redis.call('set', KEYS[3], ARGV[5]);
+redis.call('set', KEYS[4], ARGV[5]);
+return 1
+`
+
+// compareAndSetBusiness set the business detail and list member cache if the cached detail is still the expected
+// one, returns if the cache is set.
+func compareAndSetBusiness(ctx context.Context, bizID int64, name string, doc []byte, expected, member string) (bool,
+	error) {
+
+	keys := []string{bizKey.detailKey(bizID), bizKey.listKeyWithBiz(bizID), bizKey.detailExpireKey(bizID),
+		bizKey.listExpireKeyWithBiz(bizID)}
+	result, err := redis.Client().Eval(ctx, compareAndSetBusinessScript, keys, expected, string(doc), member,
+		bizKey.genListKeyValue(bizID, 0, name), time.Now().Unix()).Result()
+	if err != nil {
+		return false, fmt.Errorf("run compareAndSetBusinessScript in redis failed, err: %v", err)
+	}
+
+	set, ok := result.(int64)
+	if !ok {
+		return false, fmt.Errorf("run compareAndSetBusinessScript in redis, but get invalid result data: %v", result)
+	}
+	return set == 1, nil
+}
+
+func getExistBusinessIDs(ctx context.Context, ids []int64) (map[int64]bool, error) {
+	exists := make(map[int64]bool)
+	if len(ids) == 0 {
+		return exists, nil
+	}
+
+	filter := mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBIN: ids}}
+	bizs := make([]mapstr.MapStr, 0)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).Fields(common.BKAppIDField).All(ctx, &bizs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, biz := range bizs {
+		id, err := util.GetInt64ByInterface(biz[common.BKAppIDField])
+		if err != nil {
+			continue
+		}
+		exists[id] = true
+	}
+	return exists, nil
+}
+
+// listBusinessAfterID list a batch of business whose id is greater than the id, sorted with business id.
+func listBusinessAfterID(ctx context.Context, id int64) ([]mapstr.MapStr, error) {
+	filter := mapstr.MapStr{common.BKAppIDField: mapstr.MapStr{common.BKDBGT: id}}
+	bizs := make([]mapstr.MapStr, 0)
+	err := mongodb.Client().Table(common.BKTableNameBaseApp).Find(filter).Sort(common.BKAppIDField).
+		Limit(verifyStep).All(ctx, &bizs)
+	if err != nil {
+		return nil, err
+	}
+	return bizs, nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package business
+
+import (
+	"context"
+	"testing"
+
+	ccRedis "configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/redis"
+
+	"github.com/alicebob/miniredis"
+)
+
+func TestCompareAndSetBusiness(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	if err := redis.InitClient("redis", &ccRedis.Config{Address: server.Addr(), Database: "0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	listKey := bizKey.listKeyWithBiz(2)
+	detailKey := bizKey.detailKey(2)
+	previous := `{"bk_biz_id":2,"bk_biz_name":"old"}`
+	previousMember := bizKey.genListKeyValue(2, 0, "old")
+	latest := `{"bk_biz_id":2,"bk_biz_name":"new"}`
+	latestMember := bizKey.genListKeyValue(2, 0, "new")
+
+	// the business is not cached.
+	set, err := compareAndSetBusiness(ctx, 2, "old", []byte(previous), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail, _ := server.Get(detailKey); !set || detail != previous {
+		t.Fatalf("expect the business is cached, set: %v, detail: %s", set, detail)
+	}
+	if ok, _ := server.IsMember(listKey, previousMember); !ok || !server.Exists(bizKey.detailExpireKey(2)) ||
+		!server.Exists(bizKey.listExpireKeyWithBiz(2)) {
+		t.Fatalf("expect the business list member and expire keys are cached")
+	}
+
+	// the business is refreshed by the event watcher, which should not be overwritten by the rebuild.
+	set, err = compareAndSetBusiness(ctx, 2, "new", []byte(latest), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail, _ := server.Get(detailKey); set || detail != previous {
+		t.Fatalf("expect the cached business is not overwritten, set: %v, detail: %s", set, detail)
+	}
+
+	// the business name is changed, the list member is replaced.
+	set, err = compareAndSetBusiness(ctx, 2, "new", []byte(latest), previous, previousMember)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail, _ := server.Get(detailKey); !set || detail != latest {
+		t.Fatalf("expect the business is repaired, set: %v, detail: %s", set, detail)
+	}
+	if members, _ := server.Members(listKey); len(members) != 1 || members[0] != latestMember {
+		t.Fatalf("expect the list member is replaced, got %v", members)
+	}
+
+	// the business is changed after it's verified.
+	set, err = compareAndSetBusiness(ctx, 2, "old", []byte(previous), previous, latestMember)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail, _ := server.Get(detailKey); set || detail != latest {
+		t.Fatalf("expect the changed business is not repaired, set: %v, detail: %s", set, detail)
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/source_controller/cacheservice/cache/business"
 	"configcenter/src/source_controller/cacheservice/cache/host"
+	"configcenter/src/source_controller/cacheservice/cache/instance"
 	"configcenter/src/source_controller/cacheservice/cache/topo_tree"
 	"configcenter/src/source_controller/cacheservice/cache/topology"
 	"configcenter/src/source_controller/cacheservice/event/watch"
@@ -29,13 +30,13 @@ import (
 )
 
 func NewCache(reflector reflector.Interface, loopW stream.LoopInterface, isMaster discovery.ServiceManageInterface,
-	watchDB dal.DB) (*ClientSet, error) {
+	watchDB dal.DB, instObjects, hostSecondaryKeys []string, verifyOpt VerifyOption) (*ClientSet, error) {
 
 	if err := business.NewCache(reflector); err != nil {
 		return nil, fmt.Errorf("new business cache failed, err: %v", err)
 	}
 
-	if err := host.NewCache(reflector); err != nil {
+	if err := host.NewCache(reflector, hostSecondaryKeys); err != nil {
 		return nil, fmt.Errorf("new host cache failed, err: %v", err)
 	}
 
@@ -44,14 +45,26 @@ func NewCache(reflector reflector.Interface, loopW stream.LoopInterface, isMaste
 		return nil, err
 	}
 
+	inst, err := instance.NewCache(loopW, instObjects)
+	if err != nil {
+		return nil, fmt.Errorf("new instance cache failed, err: %v", err)
+	}
+
 	bizClient := business.NewClient()
 	hostClient := host.NewClient()
 
+	tree, err := topo_tree.NewTopologyTree(bizClient, loopW)
+	if err != nil {
+		return nil, fmt.Errorf("new topology tree failed, err: %v", err)
+	}
+
 	cache := &ClientSet{
-		Tree:     topo_tree.NewTopologyTree(bizClient),
+		Tree:     tree,
 		Host:     hostClient,
 		Business: bizClient,
 		Topology: topo,
+		Instance: inst,
+		Verifier: newVerifier(hostClient, bizClient, isMaster, verifyOpt),
 		Event:    watch.NewClient(watchDB, mongodb.Client(), redis.Client()),
 	}
 	return cache, nil
@@ -62,5 +75,7 @@ type ClientSet struct {
 	Topology *topology.Topology
 	Host     *host.Client
 	Business *business.Client
+	Instance *instance.Client
+	Verifier *Verifier
 	Event    *watch.Client
 }
@@ -40,12 +40,15 @@ func NewClient() *Client {
 }
 
 // Attention, it can only be called for once.
-func NewCache(event reflector.Interface) error {
+// secondaryKeys is the host attributes which is used as secondary keys to find hosts in cache.
+func NewCache(event reflector.Interface, secondaryKeys []string) error {
 
 	if cache != nil {
 		return nil
 	}
 
+	secondaryFields = secondaryKeys
+
 	// cache has not been initialized.
 	host := &hostCache{
 		key:   hostKey,
@@ -150,7 +150,7 @@ func (c *Client) GetHostWithInnerIP(ctx context.Context, opt *metadata.SearchHos
 		return "", errors.New("invalid ip address with multiple ip")
 	}
 
-	detail, err := c.getHostDetailWithIP(opt.InnerIP, opt.CloudID)
+	detail, err := c.getHostDetailWithIP(util.ExtractOwnerFromContext(ctx), opt.InnerIP, opt.CloudID)
 	if err != nil {
 		blog.Errorf("get host with inner ip: %s failed, err：%v, rid: %s", opt.InnerIP, err, rid)
 		return "", err
@@ -167,10 +167,12 @@ func (c *Client) GetHostWithInnerIP(ctx context.Context, opt *metadata.SearchHos
 // this id list has a ttl life cycle, and triggered with update with user's request.
 func (c *Client) ListHostsWithPage(ctx context.Context, opt *metadata.ListHostWithPage) (int64, []string, error) {
 	rid := util.ExtractRequestIDFromContext(ctx)
+	ownerID := util.ExtractOwnerFromContext(ctx)
 
 	if len(opt.HostIDs) != 0 {
 		// find with host id directly.
-		total, err := c.countHost(ctx, map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: opt.HostIDs}})
+		filter := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: opt.HostIDs}}
+		total, err := c.countHost(ctx, util.SetQueryOwner(filter, ownerID))
 		if err != nil {
 			blog.Errorf("list host with page, but count failed, err: %v, rid: %v", err, rid)
 			return 0, nil, err
@@ -190,6 +192,12 @@ func (c *Client) ListHostsWithPage(ctx context.Context, opt *metadata.ListHostWi
 		return 0, nil, errors.New("page size is over limit")
 	}
 
+	// the host id list in cache contains all the tenants' hosts, so only the super owner can page
+	// hosts with it, the others get hosts of their own from mongodb.
+	if ownerID != common.BKSuperOwnerID {
+		return c.getHostsWithPage(ctx, opt, util.SetQueryOwner(nil, ownerID))
+	}
+
 	cnt, idList, details, err := c.getPagedHostDetailList(opt.Page)
 	if err != nil {
 		if err != keyNotExistError {
@@ -200,7 +208,7 @@ func (c *Client) ListHostsWithPage(ctx context.Context, opt *metadata.ListHostWi
 		c.forceRefreshHostIDList(ctx)
 
 		// zset key is not exist, then we get it from mongodb.
-		return c.getHostsWithPage(ctx, opt)
+		return c.getHostsWithPage(ctx, opt, nil)
 	}
 
 	// try to refresh host id list in cache.
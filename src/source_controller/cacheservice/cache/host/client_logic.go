@@ -231,17 +231,19 @@ func (c *Client) refreshHostIDListCache(rid string) error {
 	return nil
 }
 
-func (c *Client) getHostsWithPage(ctx context.Context, opt *metadata.ListHostWithPage) (int64, []string, error) {
+func (c *Client) getHostsWithPage(ctx context.Context, opt *metadata.ListHostWithPage,
+	filter map[string]interface{}) (int64, []string, error) {
+
 	rid := ctx.Value(common.ContextRequestIDField)
 
-	total, err := c.countHost(ctx, nil)
+	total, err := c.countHost(ctx, filter)
 	if err != nil {
 		blog.Errorf("get host with page, but count failed, err: %v, rid: %v", err, rid)
 		return 0, nil, err
 	}
 
 	list := make([]metadata.HostMapStr, 0)
-	if err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(nil).Start(uint64(opt.Page.Start)).
+	if err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).Start(uint64(opt.Page.Start)).
 		Limit(uint64(opt.Page.Limit)).Sort(common.BKHostIDField).Fields(opt.Fields...).All(ctx, &list); err != nil {
 
 		blog.Errorf("get host id list with page failed, err: %v, rid: %v", err, rid)
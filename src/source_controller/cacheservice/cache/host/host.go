@@ -128,15 +128,22 @@ func (h *hostCache) onDelete(e *types.Event) {
 		return
 	}
 
-	elements := gjson.GetManyBytes(byt, common.BKCloudIDField, common.BKHostInnerIPField, common.BKHostIDField)
+	elements := gjson.GetManyBytes(byt, common.BKCloudIDField, common.BKHostInnerIPField, common.BKHostIDField,
+		common.BKOwnerIDField)
 	cloudID := elements[0].Int()
 	ips := elements[1].Array()
 	hostID := elements[2].Int()
+	ownerID := elements[3].String()
 
 	pipe := redis.Client().Pipeline()
 	// delete cloud id and ip pair
 	for _, ip := range ips {
-		pipe.Del(h.key.IPCloudIDKey(ip.String(), cloudID))
+		pipe.Del(h.key.IPCloudIDKey(ownerID, ip.String(), cloudID))
+	}
+
+	// remove host from it's secondary keys
+	for _, key := range secondaryKeys(byt) {
+		pipe.SRem(key, hostID)
 	}
 
 	// delete host details
@@ -186,8 +193,18 @@ func refreshHostDetailCache(hostID int64, ips string, cloudID int64, hostDetail
 	// upsert host ip and cloud id relation
 	// a host can have multiple host inner ips
 	ttl := hostKey.WithRandomExpireSeconds()
+	ownerID := gjson.GetBytes(hostDetail, common.BKOwnerIDField).String()
 	for _, ip := range strings.Split(ips, ",") {
-		pipeline.Set(hostKey.IPCloudIDKey(ip, cloudID), hostID, ttl)
+		pipeline.Set(hostKey.IPCloudIDKey(ownerID, ip, cloudID), hostID, ttl)
+	}
+
+	// upsert host secondary keys, the previous detail is used to remove the changed values.
+	if len(secondaryFields) != 0 {
+		previous, err := redis.Client().Get(context.Background(), hostKey.HostDetailKey(hostID)).Result()
+		if err != nil && !redis.IsNilErr(err) {
+			blog.Errorf("upsert host: %d %s secondary keys, but get previous detail failed, err: %v", hostID, ips, err)
+		}
+		upsertSecondaryKeys(pipeline, hostID, []byte(previous), hostDetail)
 	}
 
 	// update host details
@@ -259,40 +276,49 @@ func listHostDetailsFromMongoWithHostID(hostID []int64) (list []*hostBase, err e
 	}
 
 	for _, h := range host {
-		ips, ok := h[common.BKHostInnerIPField].(string)
-		if !ok {
-			blog.Errorf("get host: %v data from mongodb for cache, but got invalid ip, host: %v", hostID, h)
-			return nil, errors.New("invalid host innerip")
+		base, err := toHostBase(h)
+		if err != nil {
+			return nil, err
 		}
+		list = append(list, base)
+	}
+	return list, nil
+}
 
-		js, _ := json.Marshal(h)
-		ele := gjson.GetManyBytes(js, common.BKCloudIDField, common.BKHostIDField)
-		if !ele[0].Exists() {
-			blog.Errorf("get host from mongodb for cache, but cloud id not exist, host: %v", h)
-			return nil, errors.New("host cloud id not exist")
-		}
-		if !ele[1].Exists() {
-			blog.Errorf("get host from mongodb for cache, but host id not exist, host: %v", h)
-			return nil, errors.New("host id not exist")
-		}
+// toHostBase convert the host read from mongodb to the host base which is used to refresh cache.
+func toHostBase(h metadata.HostMapStr) (*hostBase, error) {
+	ips, ok := h[common.BKHostInnerIPField].(string)
+	if !ok {
+		blog.Errorf("get host data from mongodb for cache, but got invalid ip, host: %v", h)
+		return nil, errors.New("invalid host innerip")
+	}
 
-		id := ele[1].Int()
-		if id == 0 {
-			blog.Errorf("get host from mongodb for cache, but host id is 0, host: %v", h)
-			return nil, errors.New("host id is 0")
-		}
+	js, _ := json.Marshal(h)
+	ele := gjson.GetManyBytes(js, common.BKCloudIDField, common.BKHostIDField)
+	if !ele[0].Exists() {
+		blog.Errorf("get host from mongodb for cache, but cloud id not exist, host: %v", h)
+		return nil, errors.New("host cloud id not exist")
+	}
+	if !ele[1].Exists() {
+		blog.Errorf("get host from mongodb for cache, but host id not exist, host: %v", h)
+		return nil, errors.New("host id not exist")
+	}
 
-		list = append(list, &hostBase{
-			id:      id,
-			ip:      ips,
-			cloudID: ele[0].Int(),
-			detail:  string(js),
-		})
+	id := ele[1].Int()
+	if id == 0 {
+		blog.Errorf("get host from mongodb for cache, but host id is 0, host: %v", h)
+		return nil, errors.New("host id is 0")
 	}
-	return list, nil
+
+	return &hostBase{
+		id:      id,
+		ip:      ips,
+		cloudID: ele[0].Int(),
+		detail:  string(js),
+	}, nil
 }
 
-func getHostDetailsFromMongoWithIP(innerIP string, cloudID int64) (hostID int64, detail []byte, err error) {
+func getHostDetailsFromMongoWithIP(ownerID, innerIP string, cloudID int64) (hostID int64, detail []byte, err error) {
 	innerIPArr := strings.Split(innerIP, ",")
 	filter := mapstr.MapStr{
 		common.BKHostInnerIPField: map[string]interface{}{
@@ -300,6 +326,7 @@ func getHostDetailsFromMongoWithIP(innerIP string, cloudID int64) (hostID int64,
 		},
 		common.BKCloudIDField: cloudID,
 	}
+	filter = util.SetQueryOwner(filter, ownerID)
 	host := make(metadata.HostMapStr)
 	err = mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).One(context.Background(), &host)
 	if err != nil {
@@ -15,6 +15,7 @@ package host
 import (
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 
 	"configcenter/src/common"
@@ -54,11 +55,25 @@ func (h hostKeyGenerator) HostDetailLockKey(hostID int64) string {
 }
 
 // key to store the relation with ip and host id:
-// key: bk_host_innerip:bk_cloud_id
+// key: bk_supplier_account:bk_host_innerip:bk_cloud_id
 // value: bk_host_id
 // this key has a ttl, which is h.expireSeconds
-func (h hostKeyGenerator) IPCloudIDKey(ip string, cloudID int64) string {
-	return h.namespace + ":ip_cloud_id:" + ip + ":" + strconv.FormatInt(cloudID, 10)
+func (h hostKeyGenerator) IPCloudIDKey(ownerID, ip string, cloudID int64) string {
+	return h.namespace + ":ip_cloud_id:" + ownerID + ":" + ip + ":" + strconv.FormatInt(cloudID, 10)
+}
+
+// key to store the relation with a host attribute's value and the hosts which have this value:
+// key: attribute:bk_supplier_account:value
+// value: a set of bk_host_id, because the value of an attribute may not be unique.
+// this key has a ttl, which is h.expireSeconds
+func (h hostKeyGenerator) SecondaryKey(field, ownerID, value string) string {
+	return h.namespace + ":attr:" + field + ":" + ownerID + ":" + value
+}
+
+// key to mark that no host has the attribute value, which is generated with the secondary key of the value.
+// it has a short ttl to avoid finding the value in mongodb repeatedly, and it's removed once a host has the value.
+func (h hostKeyGenerator) SecondaryNotExistKey(secondaryKey string) string {
+	return h.namespace + ":attr_none:" + strings.TrimPrefix(secondaryKey, h.namespace+":attr:")
 }
 
 func (h hostKeyGenerator) ListDoneKey() string {
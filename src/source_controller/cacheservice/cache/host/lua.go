@@ -18,10 +18,15 @@ import (
 	"fmt"
 	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
+	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/metadata"
 	"configcenter/src/storage/driver/redis"
+
+	"github.com/tidwall/gjson"
 )
 
 // NOTE: this script is fragile, the key depends on the way that host key
@@ -48,8 +53,8 @@ return detail
 const hostCloudIdRelationNotExitError = "host cloud id relation not exist"
 const hostDetailNotExitError = "host detail not exist"
 
-func (c *Client) getHostDetailWithIP(innerIP string, cloudID int64) (*string, error) {
-	keys := hostKey.IPCloudIDKey(innerIP, cloudID)
+func (c *Client) getHostDetailWithIP(ownerID, innerIP string, cloudID int64) (*string, error) {
+	keys := hostKey.IPCloudIDKey(ownerID, innerIP, cloudID)
 	result, err := redis.Client().Eval(context.Background(), getHostWithIpScript, []string{keys}, hostCloudIdRelationNotExitError,
 		hostDetailNotExitError).Result()
 
@@ -75,7 +80,7 @@ func (c *Client) getHostDetailWithIP(innerIP string, cloudID int64) (*string, er
 	}
 
 	// now, we need to refresh the cache.
-	hostID, detail, err := getHostDetailsFromMongoWithIP(innerIP, cloudID)
+	hostID, detail, err := getHostDetailsFromMongoWithIP(ownerID, innerIP, cloudID)
 	if err != nil {
 		return nil, fmt.Errorf("get host detail with ip failed, err: %v", err)
 	}
@@ -227,3 +232,84 @@ func (c *Client) getPagedHostDetailList(page metadata.BasePage) (int64, []int64,
 	}
 
 }
+
+const (
+	// compareAndSetHostScript set the host detail and ip relation keys only when the cached detail is still the
+	// expected one, which avoids overwriting the newer cache refreshed by the event watcher.
+	// KEYS[1]: host detail key
+	// KEYS[2...]: host ip relation keys
+	// ARGV[1]: the expected cached detail, empty if the detail is not cached
+	// ARGV[2]: host detail
+	// ARGV[3]: expire seconds
+	// ARGV[4]: host id
+	compareAndSetHostScript = `
+local cached = redis.call('get', KEYS[1]);
+if (cached == false) then
+	cached = ''
+end;
+
+if (cached ~= ARGV[1]) then
+	return 0
+end;
+
+redis.call('set', KEYS[1], ARGV[2], 'EX', ARGV[3]);
+for i = 2, #KEYS do
+	redis.call('set', KEYS[i], ARGV[4], 'EX', ARGV[3]);
+end;
+return 1
+`
+
+	// compareAndDeleteScript delete the keys whose value is still the expected one.
+	// KEYS: the keys to be deleted
+	// ARGV[1]: the expected value
+	compareAndDeleteScript = `
+local deleted = 0;
+for i = 1, #KEYS do
+	if (redis.call('get', KEYS[i]) == ARGV[1]) then
+		deleted = deleted + redis.call('del', KEYS[i])
+	end;
+end;
+return deleted
+`
+)
+
+// compareAndSetHost set the host detail and ip relation cache if the cached detail is still the expected one,
+// returns if the cache is set.
+func compareAndSetHost(ctx context.Context, base *hostBase, expected string) (bool, error) {
+	ownerID := gjson.Get(base.detail, common.BKOwnerIDField).String()
+	keys := []string{hostKey.HostDetailKey(base.id)}
+	for _, ip := range strings.Split(base.ip, ",") {
+		keys = append(keys, hostKey.IPCloudIDKey(ownerID, ip, base.cloudID))
+	}
+
+	ttl := int64(hostKey.WithRandomExpireSeconds() / time.Second)
+	result, err := redis.Client().Eval(ctx, compareAndSetHostScript, keys, expected, base.detail, ttl,
+		base.id).Result()
+	if err != nil {
+		return false, fmt.Errorf("run compareAndSetHostScript in redis failed, err: %v", err)
+	}
+
+	set, ok := result.(int64)
+	if !ok {
+		return false, fmt.Errorf("run compareAndSetHostScript in redis, but get invalid result data: %v", result)
+	}
+	return set == 1, nil
+}
+
+// compareAndDelete delete the keys whose value is still the expected one, returns the deleted count.
+func compareAndDelete(ctx context.Context, keys []string, expected string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	result, err := redis.Client().Eval(ctx, compareAndDeleteScript, keys, expected).Result()
+	if err != nil {
+		return 0, fmt.Errorf("run compareAndDeleteScript in redis failed, err: %v", err)
+	}
+
+	deleted, ok := result.(int64)
+	if !ok {
+		return 0, fmt.Errorf("run compareAndDeleteScript in redis, but get invalid result data: %v", result)
+	}
+	return deleted, nil
+}
@@ -0,0 +1,330 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	ccRedis "configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+
+	rawRedis "github.com/go-redis/redis/v7"
+	"github.com/tidwall/gjson"
+)
+
+// secondaryFields is the host attributes which is used as secondary keys to find hosts in cache.
+// it's set when the cache is launched, and it's read only after that.
+var secondaryFields []string
+
+// secondaryNotExistTTL is the ttl of the key which marks that no host has the attribute value.
+const secondaryNotExistTTL = time.Minute
+
+// ErrSecondaryKeysExceedLimit is returned when the hosts found in mongodb with the missed secondary keys exceed
+// the max page size, the keys should be queried in smaller batches.
+var ErrSecondaryKeysExceedLimit = fmt.Errorf("hosts found with the secondary keys exceed the limit %d",
+	common.BKMaxPageSize)
+
+// ListHostWithSecondaryKeys find hosts with many secondary keys at once, the keys which can not be
+// found in cache will be found from mongodb and refreshed to cache. a key may match multiple hosts,
+// and a key without any host is also returned with empty hosts, which is cached for a short while.
+func (c *Client) ListHostWithSecondaryKeys(ctx context.Context, opt *metadata.ListHostWithSecondaryKeysOption) (
+	[]*HostsWithKey, error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ownerID := util.ExtractOwnerFromContext(ctx)
+	for _, key := range opt.Keys {
+		if !util.InStrArr(secondaryFields, key.Field) {
+			return nil, fmt.Errorf("host attribute %s is not a secondary key", key.Field)
+		}
+	}
+
+	pipe := redis.Client().Pipeline()
+	for _, key := range opt.Keys {
+		secondaryKey := hostKey.SecondaryKey(key.Field, ownerID, key.Value)
+		pipe.SMembers(secondaryKey)
+		pipe.Exists(hostKey.SecondaryNotExistKey(secondaryKey))
+	}
+	cmds, err := pipe.Exec()
+	if err != nil {
+		blog.Errorf("list host with secondary keys, but get keys from redis failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	// the host ids of each key, and the keys which need to be found from mongodb.
+	keyHostIDs := make([][]string, len(opt.Keys))
+	missed := make(map[string][]string)
+	allHostIDs := make([]string, 0)
+	for idx := range opt.Keys {
+		ids, err := cmds[2*idx].(*rawRedis.StringSliceCmd).Result()
+		if err != nil {
+			return nil, err
+		}
+
+		if len(ids) == 0 {
+			notExist, err := cmds[2*idx+1].(*rawRedis.IntCmd).Result()
+			if err != nil {
+				return nil, err
+			}
+
+			// the value is not found in mongodb a moment ago.
+			if notExist == 0 {
+				missed[opt.Keys[idx].Field] = append(missed[opt.Keys[idx].Field], opt.Keys[idx].Value)
+			}
+			continue
+		}
+		keyHostIDs[idx] = ids
+		allHostIDs = append(allHostIDs, ids...)
+	}
+
+	details, err := c.getHostDetailsWithIDs(ctx, util.StrArrayUnique(allHostIDs))
+	if err != nil {
+		blog.Errorf("list host with secondary keys, but get host details failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	result := make([]*HostsWithKey, len(opt.Keys))
+	for idx, key := range opt.Keys {
+		result[idx] = &HostsWithKey{HostSecondaryKey: key, Hosts: make([]string, 0)}
+		for _, id := range keyHostIDs[idx] {
+			detail, exist := details[id]
+			// the host's value may be changed, or the host is deleted, then it's a stale relation.
+			if !exist || !util.InStrArr(secondaryValues(gjson.Get(detail, key.Field)), key.Value) {
+				c.removeStaleSecondaryKey(hostKey.SecondaryKey(key.Field, ownerID, key.Value), id, rid)
+				continue
+			}
+			result[idx].Hosts = append(result[idx].Hosts, cutHostFields(detail, opt.Fields))
+		}
+
+		if len(keyHostIDs[idx]) != 0 && len(result[idx].Hosts) == 0 {
+			// all the relations is stale, find it from mongodb.
+			missed[key.Field] = append(missed[key.Field], key.Value)
+		}
+	}
+
+	if len(missed) == 0 {
+		return result, nil
+	}
+
+	hosts, err := listHostsWithSecondaryValues(ctx, ownerID, missed)
+	if err != nil {
+		blog.Errorf("list host with secondary keys, but get hosts from mongodb failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		c.tryRefreshHostDetail(host.id, host.ip, host.cloudID, []byte(host.detail))
+	}
+
+	notExistPipe := redis.Client().Pipeline()
+	defer notExistPipe.Close()
+	for idx, key := range opt.Keys {
+		if len(result[idx].Hosts) != 0 || !util.InStrArr(missed[key.Field], key.Value) {
+			continue
+		}
+
+		for _, host := range hosts {
+			if util.InStrArr(secondaryValues(gjson.Get(host.detail, key.Field)), key.Value) {
+				result[idx].Hosts = append(result[idx].Hosts, cutHostFields(host.detail, opt.Fields))
+			}
+		}
+
+		if len(result[idx].Hosts) == 0 {
+			secondaryKey := hostKey.SecondaryKey(key.Field, ownerID, key.Value)
+			notExistPipe.Set(hostKey.SecondaryNotExistKey(secondaryKey), 1, secondaryNotExistTTL)
+		}
+	}
+
+	if _, err := notExistPipe.Exec(); err != nil {
+		blog.Errorf("list host with secondary keys, but set not exist keys failed, err: %v, rid: %s", err, rid)
+	}
+
+	return result, nil
+}
+
+// HostsWithKey is the hosts details found with a secondary key.
+type HostsWithKey struct {
+	metadata.HostSecondaryKey
+	Hosts []string
+}
+
+// getHostDetailsWithIDs get host details from cache, and returns the details with host id.
+func (c *Client) getHostDetailsWithIDs(ctx context.Context, ids []string) (map[string]string, error) {
+	details := make(map[string]string)
+	if len(ids) == 0 {
+		return details, nil
+	}
+
+	keys := make([]string, len(ids))
+	for idx, id := range ids {
+		keys[idx] = hostKey.HostDetailKeyPrefix() + id
+	}
+
+	values, err := redis.Client().MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	missed := make([]int64, 0)
+	for idx, value := range values {
+		detail, ok := value.(string)
+		if !ok {
+			id, err := util.GetInt64ByInterface(ids[idx])
+			if err == nil {
+				missed = append(missed, id)
+			}
+			continue
+		}
+		details[ids[idx]] = detail
+	}
+
+	if len(missed) == 0 {
+		return details, nil
+	}
+
+	hosts, err := listHostDetailsFromMongoWithHostID(missed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		c.tryRefreshHostDetail(host.id, host.ip, host.cloudID, []byte(host.detail))
+		details[fmt.Sprint(host.id)] = host.detail
+	}
+	return details, nil
+}
+
+func (c *Client) removeStaleSecondaryKey(key, hostID, rid string) {
+	if err := redis.Client().SRem(context.Background(), key, hostID).Err(); err != nil {
+		blog.Errorf("remove stale host %s from secondary key %s failed, err: %v, rid: %s", hostID, key, err, rid)
+	}
+}
+
+// listHostsWithSecondaryValues list the hosts whose attribute contains any of the values. because an
+// attribute like bk_host_outerip may have multiple values separated with comma, the values is matched
+// with the exact value or an element of the value.
+func listHostsWithSecondaryValues(ctx context.Context, ownerID string, values map[string][]string) ([]*hostBase,
+	error) {
+
+	cond := make([]mapstr.MapStr, 0)
+	for field, vals := range values {
+		quoted := make([]string, len(vals))
+		for idx, val := range vals {
+			quoted[idx] = regexp.QuoteMeta(val)
+		}
+
+		cond = append(cond, mapstr.MapStr{field: mapstr.MapStr{common.BKDBIN: vals}})
+		cond = append(cond, mapstr.MapStr{field: mapstr.MapStr{
+			common.BKDBLIKE: "(^|,)(" + strings.Join(quoted, "|") + ")(,|$)",
+		}})
+	}
+	filter := util.SetQueryOwner(mapstr.MapStr{common.BKDBOR: cond}, ownerID)
+
+	hosts := make([]metadata.HostMapStr, 0)
+	err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).Limit(common.BKMaxPageSize+1).
+		All(ctx, &hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(hosts) > common.BKMaxPageSize {
+		return nil, ErrSecondaryKeysExceedLimit
+	}
+
+	list := make([]*hostBase, 0, len(hosts))
+	for _, h := range hosts {
+		base, err := toHostBase(h)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, base)
+	}
+	return list, nil
+}
+
+// upsertSecondaryKeys add the host to it's secondary keys, and remove the host from the keys of the
+// values which is changed compared with the previous detail.
+func upsertSecondaryKeys(pipe ccRedis.Pipeliner, hostID int64, previous, detail []byte) {
+	keys := secondaryKeys(detail)
+	current := make(map[string]bool)
+	for _, key := range keys {
+		current[key] = true
+		pipe.SAdd(key, hostID)
+		pipe.Expire(key, hostKey.WithRandomExpireSeconds())
+		pipe.Del(hostKey.SecondaryNotExistKey(key))
+	}
+
+	for _, key := range secondaryKeys(previous) {
+		if !current[key] {
+			pipe.SRem(key, hostID)
+		}
+	}
+}
+
+// secondaryKeys returns all the secondary keys of the host detail.
+func secondaryKeys(detail []byte) []string {
+	if len(secondaryFields) == 0 || len(detail) == 0 {
+		return nil
+	}
+
+	elements := gjson.GetManyBytes(detail, append([]string{common.BKOwnerIDField}, secondaryFields...)...)
+	ownerID := elements[0].String()
+	keys := make([]string, 0)
+	for idx, field := range secondaryFields {
+		for _, value := range secondaryValues(elements[idx+1]) {
+			keys = append(keys, hostKey.SecondaryKey(field, ownerID, value))
+		}
+	}
+	return keys
+}
+
+// secondaryValues returns the values of a host attribute, a string value is split with comma, because
+// the attribute like bk_host_outerip and bk_mac may have multiple values.
+func secondaryValues(ele gjson.Result) []string {
+	values := make([]string, 0)
+	switch {
+	case !ele.Exists() || ele.Type == gjson.Null:
+	case ele.IsArray():
+		for _, one := range ele.Array() {
+			if value := one.String(); len(value) != 0 {
+				values = append(values, value)
+			}
+		}
+	case ele.Type == gjson.String:
+		for _, value := range strings.Split(ele.String(), ",") {
+			if value = strings.TrimSpace(value); len(value) != 0 {
+				values = append(values, value)
+			}
+		}
+	default:
+		values = append(values, ele.String())
+	}
+	return values
+}
+
+func cutHostFields(detail string, fields []string) string {
+	if len(fields) == 0 {
+		return detail
+	}
+	return *json.CutJsonDataWithFields(&detail, fields)
+}
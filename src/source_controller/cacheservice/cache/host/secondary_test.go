@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/driver/redis"
+)
+
+func TestSecondaryNotExistKey(t *testing.T) {
+	server := initTestRedis(t)
+	secondaryFields = []string{"bk_sn"}
+	defer func() {
+		secondaryFields = nil
+	}()
+
+	// the value which is not found in mongodb a moment ago is returned without finding it in mongodb again.
+	secondaryKey := hostKey.SecondaryKey("bk_sn", "0", "sn-1")
+	notExistKey := hostKey.SecondaryNotExistKey(secondaryKey)
+	server.Set(notExistKey, "1")
+	ctx := context.WithValue(context.Background(), common.ContextRequestOwnerField, "0")
+	opt := &metadata.ListHostWithSecondaryKeysOption{Keys: []metadata.HostSecondaryKey{{Field: "bk_sn", Value: "sn-1"}}}
+	result, err := NewClient().ListHostWithSecondaryKeys(ctx, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || len(result[0].Hosts) != 0 {
+		t.Fatalf("expect the key has no hosts, got %+v", result)
+	}
+
+	// the mark is removed once a host has the value.
+	pipe := redis.Client().Pipeline()
+	upsertSecondaryKeys(pipe, 1, nil, []byte(`{"bk_host_id":1,"bk_sn":"sn-1,sn-2","bk_supplier_account":"0"}`))
+	if _, err := pipe.Exec(); err != nil {
+		t.Fatal(err)
+	}
+	if server.Exists(notExistKey) {
+		t.Fatalf("the not exist key should be removed after a host has the value")
+	}
+	if members, _ := server.Members(secondaryKey); len(members) != 1 || members[0] != "1" {
+		t.Fatalf("expect the host is added to the secondary key, got %v", members)
+	}
+}
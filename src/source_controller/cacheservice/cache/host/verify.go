@@ -0,0 +1,457 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/driver/redis"
+
+	"github.com/tidwall/gjson"
+)
+
+// verifyStep is the count of hosts or keys verified in one batch.
+const verifyStep = 500
+
+// Verify compare the host caches with the hosts in mongodb, and repair the drifted keys if required.
+// the detail key which is not cached is not a drift, because it's loaded when it's used.
+func (c *Client) Verify(ctx context.Context, opt *metadata.VerifyCacheOption) (*metadata.VerifyCacheResult, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	start := time.Now()
+	result := &metadata.VerifyCacheResult{Resource: metadata.CacheResourceHost}
+
+	if !opt.FullScan {
+		hosts := make([]metadata.HostMapStr, 0)
+		pipeline := []map[string]interface{}{{"$sample": map[string]interface{}{"size": opt.SampleSize}}}
+		err := mongodb.Client().Table(common.BKTableNameBaseHost).AggregateAll(ctx, pipeline, &hosts)
+		if err != nil {
+			blog.Errorf("verify host cache, but sample hosts failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+
+		if err := c.verifyHosts(ctx, hosts, opt.Repair, result); err != nil {
+			return nil, err
+		}
+		result.Cost = time.Since(start).Milliseconds()
+		return result, nil
+	}
+
+	lastID := int64(0)
+	for {
+		hosts, err := listHostsAfterID(ctx, lastID)
+		if err != nil {
+			blog.Errorf("verify host cache, but list hosts after %d failed, err: %v, rid: %s", lastID, err, rid)
+			return nil, err
+		}
+
+		if len(hosts) == 0 {
+			break
+		}
+
+		if err := c.verifyHosts(ctx, hosts, opt.Repair, result); err != nil {
+			return nil, err
+		}
+
+		lastID, _ = util.GetInt64ByInterface(hosts[len(hosts)-1][common.BKHostIDField])
+	}
+
+	// find the keys whose host is already deleted.
+	if err := c.verifyStaleHostKeys(ctx, opt.Repair, result); err != nil {
+		return nil, err
+	}
+
+	result.Cost = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// Rebuild drop all the host details and ip relations cache, and load them from mongodb again.
+// the hosts are read from the primary, and the keys refreshed by the event watcher after they are dropped
+// are not overwritten, because they may be newer than the hosts read by the rebuild.
+func (c *Client) Rebuild(ctx context.Context) (*metadata.VerifyCacheResult, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	ctx = util.SetDBReadPreference(ctx, common.PrimaryMode)
+	start := time.Now()
+	result := &metadata.VerifyCacheResult{Resource: metadata.CacheResourceHost}
+
+	for _, match := range []string{hostKey.HostDetailKeyPrefix() + "*", hostKey.namespace + ":ip_cloud_id:*",
+		hostKey.namespace + ":attr:*", hostKey.namespace + ":attr_none:*"} {
+		err := tools.ScanKeys(ctx, match, verifyStep, func(keys []string) error {
+			return redis.Client().Del(ctx, keys...).Err()
+		})
+		if err != nil {
+			blog.Errorf("rebuild host cache, but delete keys %s failed, err: %v, rid: %s", match, err, rid)
+			return nil, err
+		}
+	}
+
+	lastID := int64(0)
+	for {
+		hosts, err := listHostsAfterID(ctx, lastID)
+		if err != nil {
+			blog.Errorf("rebuild host cache, but list hosts after %d failed, err: %v, rid: %s", lastID, err, rid)
+			return nil, err
+		}
+
+		if len(hosts) == 0 {
+			break
+		}
+
+		bases := make([]*hostBase, 0, len(hosts))
+		for _, h := range hosts {
+			base, err := toHostBase(h)
+			if err != nil {
+				continue
+			}
+			bases = append(bases, base)
+		}
+
+		if err := rebuildHosts(bases, result); err != nil {
+			blog.Errorf("rebuild host cache, but set host details failed, err: %v, rid: %s", err, rid)
+			return nil, err
+		}
+
+		result.Checked += int64(len(hosts))
+		lastID, _ = util.GetInt64ByInterface(hosts[len(hosts)-1][common.BKHostIDField])
+	}
+
+	if err := c.refreshHostIDListCache(rid); err != nil {
+		return nil, err
+	}
+
+	result.Cost = time.Since(start).Milliseconds()
+	blog.Infof("rebuild host cache success, result: %+v, rid: %s", *result, rid)
+	return result, nil
+}
+
+// rebuildHosts add the hosts' detail and ip relation cache if they are not cached.
+func rebuildHosts(bases []*hostBase, result *metadata.VerifyCacheResult) error {
+	pipe := redis.Client().Pipeline()
+	defer pipe.Close()
+
+	for _, base := range bases {
+		ttl := hostKey.WithRandomExpireSeconds()
+		ownerID := gjson.Get(base.detail, common.BKOwnerIDField).String()
+		for _, ip := range strings.Split(base.ip, ",") {
+			pipe.SetNX(hostKey.IPCloudIDKey(ownerID, ip, base.cloudID), base.id, ttl)
+		}
+		upsertSecondaryKeys(pipe, base.id, nil, []byte(base.detail))
+		pipe.SetNX(hostKey.HostDetailKey(base.id), base.detail, ttl)
+		result.Repaired++
+	}
+
+	_, err := pipe.Exec()
+	return err
+}
+
+// verifyHosts verify the hosts' detail and ip relation cache.
+func (c *Client) verifyHosts(ctx context.Context, hosts []metadata.HostMapStr, repair bool,
+	result *metadata.VerifyCacheResult) error {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	bases := make([]*hostBase, 0, len(hosts))
+	detailKeys := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		base, err := toHostBase(h)
+		if err != nil {
+			continue
+		}
+		bases = append(bases, base)
+		detailKeys = append(detailKeys, hostKey.HostDetailKey(base.id))
+	}
+	result.Checked += int64(len(bases))
+
+	if len(bases) == 0 {
+		return nil
+	}
+
+	details, err := redis.Client().MGet(ctx, detailKeys...).Result()
+	if err != nil {
+		blog.Errorf("verify host cache, but get host details failed, err: %v, rid: %s", err, rid)
+		return err
+	}
+
+	for idx, base := range bases {
+		cached, _ := details[idx].(string)
+		ownerID := gjson.Get(base.detail, common.BKOwnerIDField).String()
+
+		drifted := len(cached) != 0 && tools.IsDetailDrifted(cached, base.detail)
+
+		// the ip relation keys which should point to this host.
+		ipKeys := make(map[string]bool)
+		for _, ip := range strings.Split(base.ip, ",") {
+			ipKeys[hostKey.IPCloudIDKey(ownerID, ip, base.cloudID)] = true
+		}
+
+		// the ip relation keys of the cached detail, which may be changed.
+		if len(cached) != 0 {
+			ele := gjson.GetMany(cached, common.BKHostInnerIPField, common.BKCloudIDField, common.BKOwnerIDField)
+			for _, ip := range strings.Split(ele[0].String(), ",") {
+				key := hostKey.IPCloudIDKey(ele[2].String(), ip, ele[1].Int())
+				if _, exist := ipKeys[key]; !exist {
+					ipKeys[key] = false
+				}
+			}
+		}
+
+		staleKeys := make([]string, 0)
+		for key, valid := range ipKeys {
+			id, err := redis.Client().Get(ctx, key).Result()
+			if err != nil {
+				if redis.IsNilErr(err) {
+					continue
+				}
+				blog.Errorf("verify host cache, but get ip key %s failed, err: %v, rid: %s", key, err, rid)
+				return err
+			}
+
+			if valid && id != strconv.FormatInt(base.id, 10) {
+				// the ip relation points to another host.
+				drifted = true
+			}
+
+			if !valid && id == strconv.FormatInt(base.id, 10) {
+				// the host's ip has been changed, but the old relation is still exist.
+				staleKeys = append(staleKeys, key)
+			}
+		}
+
+		if drifted {
+			result.Mismatched++
+		}
+		result.Stale += int64(len(staleKeys))
+
+		if !repair || (!drifted && len(staleKeys) == 0) {
+			continue
+		}
+
+		blog.Warnf("verify host cache, host %d is drifted, stale ip keys: %v, rid: %s", base.id, staleKeys, rid)
+		repaired, err := repairHost(ctx, base.id, cached, drifted, staleKeys)
+		if err != nil {
+			blog.Errorf("verify host cache, but repair host %d failed, err: %v, rid: %s", base.id, err, rid)
+			return err
+		}
+		result.Repaired += repaired
+	}
+
+	return nil
+}
+
+// repairHost repair the drifted host cache with the host read from the primary, because the verified host may be
+// read from a secondary which is not up to date. the cache is repaired only if it is not changed after it's
+// verified, otherwise it's refreshed by the event watcher with a newer host. returns the repaired keys count.
+func repairHost(ctx context.Context, hostID int64, cached string, drifted bool, staleKeys []string) (int64, error) {
+	ctx = util.SetDBReadPreference(ctx, common.PrimaryMode)
+	filter := mapstr.MapStr{common.BKHostIDField: hostID}
+	hosts := make([]metadata.HostMapStr, 0)
+	if err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).All(ctx, &hosts); err != nil {
+		return 0, err
+	}
+
+	if len(hosts) == 0 {
+		// the host is deleted, its keys are removed by the event watcher or the stale keys verify.
+		return 0, nil
+	}
+
+	base, err := toHostBase(hosts[0])
+	if err != nil {
+		return 0, err
+	}
+
+	return repairHostCache(ctx, base, cached, drifted, staleKeys)
+}
+
+// repairHostCache repair the host cache with the latest host, the cached detail is the one that is verified.
+func repairHostCache(ctx context.Context, base *hostBase, cached string, drifted bool, staleKeys []string) (int64,
+	error) {
+
+	// the stale ip relation keys may be used by the latest host again.
+	ownerID := gjson.Get(base.detail, common.BKOwnerIDField).String()
+	ipKeys := make(map[string]bool)
+	for _, ip := range strings.Split(base.ip, ",") {
+		ipKeys[hostKey.IPCloudIDKey(ownerID, ip, base.cloudID)] = true
+	}
+
+	deleteKeys := make([]string, 0)
+	for _, key := range staleKeys {
+		if !ipKeys[key] {
+			deleteKeys = append(deleteKeys, key)
+		}
+	}
+
+	// the ip relation keys which are pointed to another host after they are verified are not deleted.
+	repaired, err := compareAndDelete(ctx, deleteKeys, strconv.FormatInt(base.id, 10))
+	if err != nil {
+		return 0, err
+	}
+
+	if !drifted {
+		return repaired, nil
+	}
+
+	set, err := compareAndSetHost(ctx, base, cached)
+	if err != nil {
+		return repaired, err
+	}
+
+	if !set {
+		blog.V(4).Infof("repair host %d cache, but it is changed after verified, skip, rid: %s", base.id,
+			util.ExtractRequestIDFromContext(ctx))
+		return repaired, nil
+	}
+
+	if len(secondaryFields) != 0 {
+		pipe := redis.Client().Pipeline()
+		defer pipe.Close()
+		upsertSecondaryKeys(pipe, base.id, []byte(cached), []byte(base.detail))
+		if _, err := pipe.Exec(); err != nil {
+			return repaired + 1, err
+		}
+	}
+	return repaired + 1, nil
+}
+
+// verifyStaleHostKeys scan all the host detail and ip relation keys, and find the keys whose host is not exist.
+func (c *Client) verifyStaleHostKeys(ctx context.Context, repair bool, result *metadata.VerifyCacheResult) error {
+	rid := util.ExtractRequestIDFromContext(ctx)
+
+	// the keys and the host ids they belong to, the ip relation keys are deleted only if they still point to the
+	// deleted host, a deleted host's detail key can be deleted directly because host id is never reused.
+	checkStale := func(keys []string, ids []int64, isIPKey bool) error {
+		if len(ids) == 0 {
+			return nil
+		}
+
+		// check with the primary, the hosts created recently may not be replicated to the secondaries.
+		exists, err := getExistHostIDs(util.SetDBReadPreference(ctx, common.PrimaryMode), ids)
+		if err != nil {
+			blog.Errorf("verify host cache, but get exist host ids failed, err: %v, rid: %s", err, rid)
+			return err
+		}
+
+		staleKeys := make([]string, 0)
+		staleIDs := make([]interface{}, 0)
+		for idx, id := range ids {
+			if !exists[id] {
+				staleKeys = append(staleKeys, keys[idx])
+				staleIDs = append(staleIDs, id)
+			}
+		}
+		result.Stale += int64(len(staleKeys))
+
+		if !repair || len(staleKeys) == 0 {
+			return nil
+		}
+
+		blog.Warnf("verify host cache, remove stale keys %v, rid: %s", staleKeys, rid)
+		pipe := redis.Client().Pipeline()
+		defer pipe.Close()
+		pipe.ZRem(hostKey.HostIDListKey(), staleIDs...)
+		if !isIPKey {
+			pipe.Del(staleKeys...)
+		}
+		if _, err := pipe.Exec(); err != nil {
+			blog.Errorf("verify host cache, but remove stale keys failed, err: %v, rid: %s", err, rid)
+			return err
+		}
+
+		if !isIPKey {
+			result.Repaired += int64(len(staleKeys))
+			return nil
+		}
+
+		for idx, key := range staleKeys {
+			deleted, err := compareAndDelete(ctx, []string{key}, strconv.FormatInt(staleIDs[idx].(int64), 10))
+			if err != nil {
+				blog.Errorf("verify host cache, but remove stale key %s failed, err: %v, rid: %s", key, err, rid)
+				return err
+			}
+			result.Repaired += deleted
+		}
+		return nil
+	}
+
+	err := tools.ScanKeys(ctx, hostKey.HostDetailKeyPrefix()+"*", verifyStep, func(keys []string) error {
+		detailKeys := make([]string, 0)
+		ids := make([]int64, 0)
+		for _, key := range keys {
+			// skip the detail lock keys.
+			id, err := strconv.ParseInt(strings.TrimPrefix(key, hostKey.HostDetailKeyPrefix()), 10, 64)
+			if err != nil {
+				continue
+			}
+			detailKeys = append(detailKeys, key)
+			ids = append(ids, id)
+		}
+		return checkStale(detailKeys, ids, false)
+	})
+	if err != nil {
+		return err
+	}
+
+	return tools.ScanKeys(ctx, hostKey.namespace+":ip_cloud_id:*", verifyStep, func(keys []string) error {
+		values, err := redis.Client().MGet(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+
+		ipKeys := make([]string, 0)
+		ids := make([]int64, 0)
+		for idx, value := range values {
+			id, err := util.GetInt64ByInterface(value)
+			if err != nil {
+				continue
+			}
+			ipKeys = append(ipKeys, keys[idx])
+			ids = append(ids, id)
+		}
+		return checkStale(ipKeys, ids, true)
+	})
+}
+
+// listHostsAfterID list a batch of hosts whose id is greater than the id, sorted with host id.
+func listHostsAfterID(ctx context.Context, id int64) ([]metadata.HostMapStr, error) {
+	filter := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBGT: id}}
+	hosts := make([]metadata.HostMapStr, 0)
+	err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).Sort(common.BKHostIDField).
+		Limit(verifyStep).All(ctx, &hosts)
+	if err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+func getExistHostIDs(ctx context.Context, ids []int64) (map[int64]bool, error) {
+	filter := mapstr.MapStr{common.BKHostIDField: mapstr.MapStr{common.BKDBIN: ids}}
+	hosts := make([]hostID, 0)
+	err := mongodb.Client().Table(common.BKTableNameBaseHost).Find(filter).Fields(common.BKHostIDField).
+		All(ctx, &hosts)
+	if err != nil {
+		return nil, err
+	}
+
+	exists := make(map[int64]bool)
+	for _, h := range hosts {
+		exists[h.ID] = true
+	}
+	return exists, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package host
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"configcenter/src/common/metadata"
+	ccRedis "configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/redis"
+
+	"github.com/alicebob/miniredis"
+)
+
+var (
+	testRedis     *miniredis.Miniredis
+	testRedisOnce sync.Once
+)
+
+// initTestRedis init the default redis client with a mock redis server, which is shared by all the tests
+// because the default client can only be initialized once, so it's flushed before each test.
+func initTestRedis(t *testing.T) *miniredis.Miniredis {
+	testRedisOnce.Do(func() {
+		server, err := miniredis.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := redis.InitClient("redis", &ccRedis.Config{Address: server.Addr(), Database: "0"}); err != nil {
+			t.Fatal(err)
+		}
+		testRedis = server
+	})
+	testRedis.FlushAll()
+	return testRedis
+}
+
+func TestRepairHostCache(t *testing.T) {
+	server := initTestRedis(t)
+	ctx := context.Background()
+
+	previous := `{"bk_host_id":1,"bk_host_innerip":"127.0.0.3,127.0.0.1","bk_cloud_id":0,"bk_supplier_account":"0"}`
+	base := &hostBase{
+		id:      1,
+		ip:      "127.0.0.1,127.0.0.2",
+		cloudID: 0,
+		detail:  `{"bk_host_id":1,"bk_host_innerip":"127.0.0.1,127.0.0.2","bk_cloud_id":0,"bk_supplier_account":"0"}`,
+	}
+	detailKey := hostKey.HostDetailKey(1)
+	staleKey := hostKey.IPCloudIDKey("0", "127.0.0.3", 0)
+	newKey := hostKey.IPCloudIDKey("0", "127.0.0.2", 0)
+
+	// the cache is refreshed by the event watcher after it's verified, only the stale ip key is removed.
+	server.Set(detailKey, "newer")
+	server.Set(staleKey, "1")
+	repaired, err := repairHostCache(ctx, base, previous, true, []string{staleKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired != 1 || server.Exists(staleKey) || server.Exists(newKey) {
+		t.Fatalf("expect only the stale ip key is repaired, repaired: %d", repaired)
+	}
+	if detail, _ := server.Get(detailKey); detail != "newer" {
+		t.Fatalf("the newer detail should not be overwritten, got %s", detail)
+	}
+
+	// the stale ip key points to another host after it's verified, it should not be removed.
+	server.Set(detailKey, previous)
+	server.Set(staleKey, "2")
+	repaired, err = repairHostCache(ctx, base, previous, true, []string{staleKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired != 1 {
+		t.Fatalf("expect the detail is repaired, repaired: %d", repaired)
+	}
+	if id, _ := server.Get(staleKey); id != "2" {
+		t.Fatalf("the ip key of another host should not be removed, got %s", id)
+	}
+	if detail, _ := server.Get(detailKey); detail != base.detail {
+		t.Fatalf("expect detail %s, got %s", base.detail, detail)
+	}
+	for _, key := range []string{newKey, hostKey.IPCloudIDKey("0", "127.0.0.1", 0)} {
+		if id, _ := server.Get(key); id != "1" || server.TTL(key) <= 0 {
+			t.Fatalf("ip key %s should point to the host with ttl, got %s", key, id)
+		}
+	}
+
+	// the detail is not cached.
+	server.Del(detailKey)
+	repaired, err = repairHostCache(ctx, base, "", true, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail, _ := server.Get(detailKey); repaired != 1 || detail != base.detail {
+		t.Fatalf("expect the detail is cached, repaired: %d, detail: %s", repaired, detail)
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	server := initTestRedis(t)
+	server.Set("key1", "1")
+	server.Set("key2", "2")
+
+	deleted, err := compareAndDelete(context.Background(), []string{"key1", "key2", "key3"}, "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != 1 || server.Exists("key1") || !server.Exists("key2") {
+		t.Fatalf("expect only key1 is deleted, deleted: %d", deleted)
+	}
+}
+
+func TestRebuildHosts(t *testing.T) {
+	server := initTestRedis(t)
+	server.Set(hostKey.HostDetailKey(1), "newer")
+
+	bases := []*hostBase{
+		{id: 1, ip: "127.0.0.1", detail: `{"bk_host_id":1,"bk_host_innerip":"127.0.0.1","bk_cloud_id":0}`},
+		{id: 2, ip: "127.0.0.2", detail: `{"bk_host_id":2,"bk_host_innerip":"127.0.0.2","bk_cloud_id":0}`},
+	}
+	result := new(metadata.VerifyCacheResult)
+	if err := rebuildHosts(bases, result); err != nil {
+		t.Fatal(err)
+	}
+
+	if detail, _ := server.Get(hostKey.HostDetailKey(1)); detail != "newer" {
+		t.Fatalf("the detail refreshed by the event watcher should not be overwritten, got %s", detail)
+	}
+	if !server.Exists(hostKey.HostDetailKey(2)) {
+		t.Fatalf("the detail of host 2 should be rebuilt")
+	}
+	if id, _ := server.Get(hostKey.IPCloudIDKey("", "127.0.0.2", 0)); id != "2" {
+		t.Fatalf("the ip key of host 2 should be rebuilt, got %s", id)
+	}
+	if result.Repaired != 2 {
+		t.Fatalf("expect 2 repaired hosts, got %d", result.Repaired)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"errors"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/mongodb"
+	drvRedis "configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/stream"
+)
+
+// ErrObjectNotCached is returned when the object's instances are not opted into the instance cache.
+var ErrObjectNotCached = errors.New("object's instances is not cached")
+
+// NewCache launch the instance and instance association cache of the given objects.
+// only the common objects which instances are stored in cc_ObjectBase can be cached,
+// the inner objects have their own cache or can not be cached.
+// Attention, it can only be called for once.
+func NewCache(loopW stream.LoopInterface, objects []string) (*Client, error) {
+	c := &Client{
+		db:      mongodb.Client(),
+		rds:     drvRedis.Client(),
+		loopW:   loopW,
+		lock:    tools.NewRefreshingLock(),
+		objects: make(map[string]bool),
+	}
+
+	for _, obj := range objects {
+		if len(obj) == 0 {
+			continue
+		}
+
+		if common.IsInnerModel(obj) {
+			blog.Warnf("object %s's instances can not be cached with instance cache, skip", obj)
+			continue
+		}
+		c.objects[obj] = true
+	}
+
+	if len(c.objects) == 0 {
+		blog.Info("no object is opted into the instance cache, skip watch instances.")
+		return c, nil
+	}
+
+	if err := c.watchInstance(); err != nil {
+		blog.Errorf("instance cache watch instance failed, err: %v", err)
+		return nil, err
+	}
+
+	if err := c.watchInstAsst(); err != nil {
+		blog.Errorf("instance cache watch instance association failed, err: %v", err)
+		return nil, err
+	}
+
+	blog.Infof("instance cache is enabled with objects: %v", objects)
+	return c, nil
+}
+
+type Client struct {
+	db    dal.DB
+	rds   redis.Client
+	loopW stream.LoopInterface
+	lock  tools.RefreshingLock
+	// objects is the objects which is opted into the instance cache.
+	// it's read only after the cache is launched.
+	objects map[string]bool
+}
+
+// IsCached check whether the object's instances is cached.
+func (c *Client) IsCached(objID string) bool {
+	return c.objects[objID]
+}
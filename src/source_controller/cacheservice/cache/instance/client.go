@@ -0,0 +1,352 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/redis"
+
+	"github.com/tidwall/gjson"
+)
+
+// GetInstWithID get an object's instance with instance id.
+// fields allows you can specify which fields you need only.
+func (c *Client) GetInstWithID(ctx context.Context, objID string, instID int64, fields []string) (string, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if !c.IsCached(objID) {
+		return "", ErrObjectNotCached
+	}
+
+	data, err := c.rds.Get(ctx, instKey.InstDetailKey(objID, instID)).Result()
+	if err == nil {
+		return cutFields(data, fields), nil
+	}
+
+	if !redis.IsNilErr(err) {
+		// return directly to avoid cache penetration
+		blog.Errorf("get %s instance %d from redis failed, err: %v, rid: %s", objID, instID, err, rid)
+		return "", err
+	}
+
+	// do not exist in cache, need to refresh from db.
+	list, err := c.listInstFromMongo(ctx, objID, mapstr.MapStr{common.BKInstIDField: instID})
+	if err != nil {
+		blog.Errorf("get %s instance %d from mongodb failed, err: %v, rid: %s", objID, instID, err, rid)
+		return "", err
+	}
+
+	if len(list) == 0 {
+		return "", fmt.Errorf("%s instance %d not exist", objID, instID)
+	}
+
+	c.tryRefreshDetail(instKey.InstDetailLockKey(objID, instID), instKey.InstDetailKey(objID, instID), list[0].detail)
+	return cutFields(list[0].detail, fields), nil
+}
+
+// ListInstWithIDs list an object's instances with instance id list.
+// if an instance is not exist in cache and still can not find in mongodb, then it will not be
+// return. so the returned array may not equal to the request ids length and the sequence is also may not same.
+func (c *Client) ListInstWithIDs(ctx context.Context, objID string, ids []int64, fields []string) ([]string, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if !c.IsCached(objID) {
+		return nil, ErrObjectNotCached
+	}
+
+	if len(ids) > 500 {
+		return nil, errors.New("instance id length is over limit")
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("instance id array is empty")
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = instKey.InstDetailKey(objID, id)
+	}
+
+	list, missed, err := c.mgetDetails(ctx, keys, ids, fields)
+	if err != nil {
+		blog.Errorf("list %s instances with ids, but get from redis failed, err: %v, rid: %s", objID, err, rid)
+		return nil, err
+	}
+
+	if len(missed) == 0 {
+		return list, nil
+	}
+
+	// can not found in the cache, need refresh the cache
+	filter := mapstr.MapStr{common.BKInstIDField: mapstr.MapStr{common.BKDBIN: missed}}
+	toAdd, err := c.listInstFromMongo(ctx, objID, filter)
+	if err != nil {
+		blog.Errorf("list %s instances with ids, but get from db failed, ids: %v, rid: %s", objID, missed, rid)
+		return nil, err
+	}
+
+	for _, inst := range toAdd {
+		c.tryRefreshDetail(instKey.InstDetailLockKey(objID, inst.id), instKey.InstDetailKey(objID, inst.id),
+			inst.detail)
+		list = append(list, cutFields(inst.detail, fields))
+	}
+	return list, nil
+}
+
+// GetInstWithUnique get an object's instance with it's unique fields and values, these fields
+// and values must be able to identify one instance only, otherwise an error is returned.
+func (c *Client) GetInstWithUnique(ctx context.Context, objID string, unique map[string]interface{},
+	fields []string) (string, error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if !c.IsCached(objID) {
+		return "", ErrObjectNotCached
+	}
+
+	if len(unique) == 0 {
+		return "", errors.New("unique fields is empty")
+	}
+
+	ownerID := util.ExtractOwnerFromContext(ctx)
+	uniqueKey := instKey.InstUniqueKey(objID, ownerID, unique)
+	instID, err := c.getID(ctx, uniqueKey)
+	if err != nil && !redis.IsNilErr(err) {
+		blog.Errorf("get %s instance with unique %v from redis failed, err: %v, rid: %s", objID, unique, err, rid)
+		return "", err
+	}
+
+	if err == nil {
+		detail, err := c.rds.Get(ctx, instKey.InstDetailKey(objID, instID)).Result()
+		if err != nil && !redis.IsNilErr(err) {
+			blog.Errorf("get %s instance %d from redis failed, err: %v, rid: %s", objID, instID, err, rid)
+			return "", err
+		}
+
+		// the instance's unique fields may be changed or the instance is deleted, so the relation
+		// is valid only when the detail still matches the unique values.
+		if err == nil && matchUnique(detail, unique) {
+			return cutFields(detail, fields), nil
+		}
+
+		if err := c.rds.Del(ctx, uniqueKey).Err(); err != nil {
+			blog.Errorf("delete %s invalid unique key %s failed, err: %v, rid: %s", objID, uniqueKey, err, rid)
+		}
+	}
+
+	filter := mapstr.MapStr{}
+	for field, value := range unique {
+		filter[field] = value
+	}
+	list, err := c.listInstFromMongo(ctx, objID, util.SetQueryOwner(filter, ownerID), 2)
+	if err != nil {
+		blog.Errorf("get %s instance with unique %v from mongodb failed, err: %v, rid: %s", objID, unique, err, rid)
+		return "", err
+	}
+
+	switch len(list) {
+	case 0:
+		return "", fmt.Errorf("%s instance with unique %v not exist", objID, unique)
+	case 1:
+	default:
+		return "", fmt.Errorf("%s instance with unique %v is not unique", objID, unique)
+	}
+
+	inst := list[0]
+	c.tryRefreshDetail(instKey.InstDetailLockKey(objID, inst.id), instKey.InstDetailKey(objID, inst.id), inst.detail)
+	if err := c.rds.Set(ctx, uniqueKey, inst.id, instKey.WithRandomExpireSeconds()).Err(); err != nil {
+		blog.Errorf("set %s unique key %s failed, err: %v, rid: %s", objID, uniqueKey, err, rid)
+	}
+
+	return cutFields(inst.detail, fields), nil
+}
+
+// GetInstAsstWithID get an instance association with it's id.
+func (c *Client) GetInstAsstWithID(ctx context.Context, id int64, fields []string) (string, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if len(c.objects) == 0 {
+		return "", ErrObjectNotCached
+	}
+
+	data, err := c.rds.Get(ctx, instKey.AsstDetailKey(id)).Result()
+	if err == nil {
+		return cutFields(data, fields), nil
+	}
+
+	if !redis.IsNilErr(err) {
+		blog.Errorf("get instance association %d from redis failed, err: %v, rid: %s", id, err, rid)
+		return "", err
+	}
+
+	list, err := c.listAsstFromMongo(ctx, mapstr.MapStr{common.BKFieldID: id})
+	if err != nil {
+		blog.Errorf("get instance association %d from mongodb failed, err: %v, rid: %s", id, err, rid)
+		return "", err
+	}
+
+	if len(list) == 0 {
+		return "", fmt.Errorf("instance association %d not exist", id)
+	}
+
+	c.tryRefreshAsst(list[0])
+	return cutFields(list[0].detail, fields), nil
+}
+
+// ListInstAsstWithIDs list instance associations with their ids, like ListInstWithIDs, the associations
+// which can not be found will not be returned.
+func (c *Client) ListInstAsstWithIDs(ctx context.Context, ids []int64, fields []string) ([]string, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if len(c.objects) == 0 {
+		return nil, ErrObjectNotCached
+	}
+
+	if len(ids) > 500 {
+		return nil, errors.New("instance association id length is over limit")
+	}
+
+	if len(ids) == 0 {
+		return nil, errors.New("instance association id array is empty")
+	}
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = instKey.AsstDetailKey(id)
+	}
+
+	list, missed, err := c.mgetDetails(ctx, keys, ids, fields)
+	if err != nil {
+		blog.Errorf("list instance associations with ids, but get from redis failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	if len(missed) == 0 {
+		return list, nil
+	}
+
+	toAdd, err := c.listAsstFromMongo(ctx, mapstr.MapStr{common.BKFieldID: mapstr.MapStr{common.BKDBIN: missed}})
+	if err != nil {
+		blog.Errorf("list instance associations with ids, but get from db failed, ids: %v, rid: %s", missed, rid)
+		return nil, err
+	}
+
+	for _, asst := range toAdd {
+		c.tryRefreshAsst(asst)
+		list = append(list, cutFields(asst.detail, fields))
+	}
+	return list, nil
+}
+
+// GetInstAsstWithUnique get an instance association with it's association kind and the two
+// instances it associated.
+func (c *Client) GetInstAsstWithUnique(ctx context.Context, objAsstID string, instID, asstInstID int64,
+	fields []string) (string, error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	if len(c.objects) == 0 {
+		return "", ErrObjectNotCached
+	}
+
+	if len(objAsstID) == 0 || instID <= 0 || asstInstID <= 0 {
+		return "", errors.New("invalid instance association unique values")
+	}
+
+	id, err := c.getID(ctx, instKey.AsstUniqueKey(objAsstID, instID, asstInstID))
+	if err == nil {
+		return c.GetInstAsstWithID(ctx, id, fields)
+	}
+
+	if !redis.IsNilErr(err) {
+		blog.Errorf("get instance association %s %d-%d from redis failed, err: %v, rid: %s", objAsstID, instID,
+			asstInstID, err, rid)
+		return "", err
+	}
+
+	filter := mapstr.MapStr{
+		common.AssociationObjAsstIDField: objAsstID,
+		common.BKInstIDField:             instID,
+		common.BKAsstInstIDField:         asstInstID,
+	}
+	list, err := c.listAsstFromMongo(ctx, filter)
+	if err != nil {
+		blog.Errorf("get instance association %s %d-%d from mongodb failed, err: %v, rid: %s", objAsstID, instID,
+			asstInstID, err, rid)
+		return "", err
+	}
+
+	if len(list) == 0 {
+		return "", fmt.Errorf("instance association %s %d-%d not exist", objAsstID, instID, asstInstID)
+	}
+
+	c.tryRefreshAsst(list[0])
+	return cutFields(list[0].detail, fields), nil
+}
+
+// mgetDetails get the details with keys from redis, returns the details which is found with cut fields,
+// and the ids which is not found in the cache.
+func (c *Client) mgetDetails(ctx context.Context, keys []string, ids []int64, fields []string) ([]string,
+	[]int64, error) {
+
+	details, err := c.rds.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make([]string, 0)
+	missed := make([]int64, 0)
+	for idx, d := range details {
+		if d == nil {
+			missed = append(missed, ids[idx])
+			continue
+		}
+
+		detail, ok := d.(string)
+		if !ok {
+			return nil, nil, errors.New("invalid detail type, not string")
+		}
+		list = append(list, cutFields(detail, fields))
+	}
+	return list, missed, nil
+}
+
+// getID get the id which is stored in the unique key.
+func (c *Client) getID(ctx context.Context, key string) (int64, error) {
+	id, err := c.rds.Get(ctx, key).Result()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(id, 10, 64)
+}
+
+// matchUnique check whether the json detail's fields value is same with the unique values.
+func matchUnique(detail string, unique map[string]interface{}) bool {
+	for field, value := range unique {
+		ele := gjson.Get(detail, field)
+		if !ele.Exists() || ele.String() != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func cutFields(detail string, fields []string) string {
+	if len(fields) == 0 {
+		return detail
+	}
+	return *json.CutJsonDataWithFields(&detail, fields)
+}
@@ -0,0 +1,181 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+type instBase struct {
+	id     int64
+	detail string
+}
+
+type asstBase struct {
+	id         int64
+	objAsstID  string
+	instID     int64
+	asstInstID int64
+	detail     string
+}
+
+// tryRefreshDetail refresh a detail cache in background, the same key is refreshed only once at the same time.
+func (c *Client) tryRefreshDetail(lockKey, detailKey string, detail string) {
+	if !c.lock.CanRefresh(detailKey) {
+		return
+	}
+	// set refreshing status
+	c.lock.SetRefreshing(detailKey)
+
+	go func() {
+		defer c.lock.SetUnRefreshing(detailKey)
+
+		// get refresh lock to avoid concurrent with the other cache service.
+		success, err := c.rds.SetNX(context.Background(), lockKey, 1, 10*time.Second).Result()
+		if err != nil {
+			blog.Errorf("refresh instance cache %s, but got redis lock failed, err: %v", detailKey, err)
+			return
+		}
+
+		if !success {
+			blog.V(4).Infof("refresh instance cache %s, but do not get redis lock, skip", detailKey)
+			return
+		}
+
+		defer func() {
+			if err := c.rds.Del(context.Background(), lockKey).Err(); err != nil {
+				blog.Errorf("refresh instance cache %s, but delete redis lock failed, err: %v", detailKey, err)
+			}
+		}()
+
+		err = c.rds.Set(context.Background(), detailKey, detail, instKey.WithRandomExpireSeconds()).Err()
+		if err != nil {
+			blog.Errorf("refresh instance cache %s failed, err: %v", detailKey, err)
+		}
+	}()
+}
+
+// tryRefreshAsst refresh the instance association's detail and it's unique key cache.
+func (c *Client) tryRefreshAsst(asst *asstBase) {
+	c.tryRefreshDetail(instKey.AsstDetailLockKey(asst.id), instKey.AsstDetailKey(asst.id), asst.detail)
+
+	uniqueKey := instKey.AsstUniqueKey(asst.objAsstID, asst.instID, asst.asstInstID)
+	if err := c.rds.Set(context.Background(), uniqueKey, asst.id, instKey.WithRandomExpireSeconds()).Err(); err != nil {
+		blog.Errorf("refresh instance association unique key %s failed, err: %v", uniqueKey, err)
+	}
+}
+
+// listInstFromMongo list the object's instances with filter from mongodb, limit is optional.
+func (c *Client) listInstFromMongo(ctx context.Context, objID string, filter mapstr.MapStr,
+	limit ...uint64) ([]*instBase, error) {
+
+	filter[common.BKObjIDField] = objID
+	find := c.db.Table(common.BKTableNameBaseInst).Find(filter).Sort(common.BKInstIDField)
+	if len(limit) != 0 {
+		find = find.Limit(limit[0])
+	}
+
+	insts := make([]mapstr.MapStr, 0)
+	if err := find.All(ctx, &insts); err != nil {
+		return nil, err
+	}
+
+	list := make([]*instBase, 0, len(insts))
+	for _, inst := range insts {
+		id, err := util.GetInt64ByInterface(inst[common.BKInstIDField])
+		if err != nil || id <= 0 {
+			blog.Errorf("get %s instance from mongodb for cache, but got invalid instance id, inst: %v", objID, inst)
+			return nil, errors.New("invalid instance id")
+		}
+
+		delete(inst, "_id")
+		js, err := json.Marshal(inst)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &instBase{id: id, detail: string(js)})
+	}
+	return list, nil
+}
+
+// listAsstFromMongo list the cached objects' instance associations with filter from mongodb.
+func (c *Client) listAsstFromMongo(ctx context.Context, filter mapstr.MapStr) ([]*asstBase, error) {
+	objects := make([]string, 0, len(c.objects))
+	for obj := range c.objects {
+		objects = append(objects, obj)
+	}
+
+	// only the associations related with the cached objects can be returned.
+	filter[common.BKDBOR] = []mapstr.MapStr{
+		{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objects}},
+		{common.BKAsstObjIDField: mapstr.MapStr{common.BKDBIN: objects}},
+	}
+
+	assts := make([]mapstr.MapStr, 0)
+	err := c.db.Table(common.BKTableNameInstAsst).Find(filter).Sort(common.BKFieldID).All(ctx, &assts)
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]*asstBase, 0, len(assts))
+	for _, asst := range assts {
+		base, err := parseAsstBase(asst)
+		if err != nil {
+			blog.Errorf("get instance association from mongodb for cache, but it's invalid, err: %v, asst: %v",
+				err, asst)
+			return nil, err
+		}
+
+		delete(asst, "_id")
+		js, err := json.Marshal(asst)
+		if err != nil {
+			return nil, err
+		}
+		base.detail = string(js)
+		list = append(list, base)
+	}
+	return list, nil
+}
+
+// parseAsstBase parse the instance association's unique fields.
+func parseAsstBase(asst map[string]interface{}) (*asstBase, error) {
+	id, err := util.GetInt64ByInterface(asst[common.BKFieldID])
+	if err != nil || id <= 0 {
+		return nil, errors.New("invalid association id")
+	}
+
+	instID, err := util.GetInt64ByInterface(asst[common.BKInstIDField])
+	if err != nil {
+		return nil, errors.New("invalid association bk_inst_id")
+	}
+
+	asstInstID, err := util.GetInt64ByInterface(asst[common.BKAsstInstIDField])
+	if err != nil {
+		return nil, errors.New("invalid association bk_asst_inst_id")
+	}
+
+	return &asstBase{
+		id:         id,
+		objAsstID:  util.GetStrByInterface(asst[common.AssociationObjAsstIDField]),
+		instID:     instID,
+		asstInstID: asstInstID,
+	}, nil
+}
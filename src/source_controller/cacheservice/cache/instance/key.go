@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"crypto/md5"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+)
+
+const instKeyNamespace = common.BKCacheKeyV3Prefix + "instance"
+
+var instKey = instKeyGenerator{
+	namespace: instKeyNamespace,
+	// 30 minutes
+	expireSeconds:      30 * 60 * time.Second,
+	expireRangeSeconds: [2]int{-600, 600},
+}
+
+type instKeyGenerator struct {
+	namespace string
+	// expireSeconds is defined how long is the ttl for the key, it's always used with the
+	// expireRangeSeconds to avoid the keys is expired at same time.
+	expireSeconds time.Duration
+	// min:[0], max:[1]
+	expireRangeSeconds [2]int
+}
+
+// InstDetailKey is the key to store an instance's detail of the object.
+func (k instKeyGenerator) InstDetailKey(objID string, instID int64) string {
+	return k.namespace + ":detail:" + objID + ":" + strconv.FormatInt(instID, 10)
+}
+
+func (k instKeyGenerator) InstDetailLockKey(objID string, instID int64) string {
+	return k.namespace + ":detail:lock:" + objID + ":" + strconv.FormatInt(instID, 10)
+}
+
+// key to store the relation with an instance's unique fields' value and it's instance id:
+// key: object id:bk_supplier_account:md5 of the sorted field=value pairs
+// value: bk_inst_id
+// this key has a ttl, which is k.expireSeconds
+func (k instKeyGenerator) InstUniqueKey(objID, ownerID string, unique map[string]interface{}) string {
+	return k.namespace + ":unique:" + objID + ":" + ownerID + ":" + uniqueHash(unique)
+}
+
+// AsstDetailKey is the key to store an instance association's detail.
+func (k instKeyGenerator) AsstDetailKey(id int64) string {
+	return k.namespace + ":asst:detail:" + strconv.FormatInt(id, 10)
+}
+
+func (k instKeyGenerator) AsstDetailLockKey(id int64) string {
+	return k.namespace + ":asst:detail:lock:" + strconv.FormatInt(id, 10)
+}
+
+// key to store the relation with an instance association's unique fields and it's id:
+// key: bk_obj_asst_id:bk_inst_id:bk_asst_inst_id
+// value: id
+func (k instKeyGenerator) AsstUniqueKey(objAsstID string, instID, asstInstID int64) string {
+	return k.namespace + ":asst:unique:" + objAsstID + ":" + strconv.FormatInt(instID, 10) + ":" +
+		strconv.FormatInt(asstInstID, 10)
+}
+
+func (k instKeyGenerator) WithRandomExpireSeconds() time.Duration {
+	rand.Seed(time.Now().UnixNano())
+	seconds := rand.Intn(k.expireRangeSeconds[1]-k.expireRangeSeconds[0]) + k.expireRangeSeconds[0]
+	return k.expireSeconds + time.Duration(seconds)*time.Second
+}
+
+// uniqueHash generate a stable hash with the unique fields and values, which is not related
+// with the fields' order.
+func uniqueHash(unique map[string]interface{}) string {
+	fields := make([]string, 0, len(unique))
+	for field := range unique {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	pairs := make([]string, len(fields))
+	for idx, field := range fields {
+		pairs[idx] = fmt.Sprintf("%s=%v", field, unique[field])
+	}
+
+	return fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(pairs, "&"))))
+}
@@ -0,0 +1,272 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instance
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/json"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/stream/types"
+)
+
+// the instance and instance association is watched without filter, because the delete event
+// do not have the full document, a filter on the document will drop all the delete events.
+func (c *Client) watchInstance() error {
+	watchOpts := &types.WatchOptions{
+		Options: types.Options{
+			EventStruct: new(map[string]interface{}),
+			Collection:  common.BKTableNameBaseInst,
+			Filter:      mapstr.MapStr{},
+		},
+	}
+
+	tokenHandler := newTokenHandler("instance")
+	startAtTime, err := tokenHandler.getStartWatchTime(context.Background())
+	if err != nil {
+		blog.Errorf("get start watch time for %s failed, err: %v", watchOpts.Collection, err)
+		return err
+	}
+	watchOpts.StartAtTime = startAtTime
+	watchOpts.WatchFatalErrorCallback = tokenHandler.resetWatchToken
+
+	loopOptions := &types.LoopBatchOptions{
+		LoopOptions: types.LoopOptions{
+			Name:         "instance cache with instance",
+			WatchOpt:     watchOpts,
+			TokenHandler: tokenHandler,
+			RetryOptions: &types.RetryOptions{
+				MaxRetryCount: 10,
+				RetryDuration: 1 * time.Second,
+			},
+		},
+		EventHandler: &types.BatchHandler{
+			DoBatch: c.onInstanceChange,
+		},
+		BatchSize: 50,
+	}
+
+	return c.loopW.WithBatch(loopOptions)
+}
+
+func (c *Client) onInstanceChange(es []*types.Event) (retry bool) {
+	if len(es) == 0 {
+		return false
+	}
+
+	rid := es[0].ID()
+	pipeline := c.rds.Pipeline()
+	changed := 0
+	for idx := range es {
+		one := es[idx]
+
+		var inst map[string]interface{}
+		switch one.OperationType {
+		case types.Insert, types.Update, types.Replace:
+			inst = *one.Document.(*map[string]interface{})
+
+		case types.Delete:
+			detail, err := c.getDeletedDetail(one.Oid, common.BKTableNameBaseInst)
+			if err != nil {
+				blog.Errorf("instance cache, get deleted instance %s failed, err: %v, rid: %s", one.Oid, err, rid)
+				if c.db.IsNotFoundError(err) {
+					continue
+				}
+				return true
+			}
+			inst = detail
+
+		default:
+			continue
+		}
+
+		objID := util.GetStrByInterface(inst[common.BKObjIDField])
+		if !c.objects[objID] {
+			continue
+		}
+
+		instID, err := util.GetInt64ByInterface(inst[common.BKInstIDField])
+		if err != nil || instID <= 0 {
+			blog.Errorf("instance cache, received invalid %s instance id, doc: %s, rid: %s", objID, one.DocBytes, rid)
+			continue
+		}
+
+		if one.OperationType == types.Delete {
+			// the unique key is verified when it's used, so only the detail need to be deleted.
+			pipeline.Del(instKey.InstDetailKey(objID, instID))
+			changed++
+			continue
+		}
+
+		delete(inst, "_id")
+		detail, err := json.Marshal(inst)
+		if err != nil {
+			blog.Errorf("instance cache, marshal %s instance %d failed, err: %v, rid: %s", objID, instID, err, rid)
+			continue
+		}
+		pipeline.Set(instKey.InstDetailKey(objID, instID), string(detail), instKey.WithRandomExpireSeconds())
+		changed++
+	}
+
+	if changed == 0 {
+		return false
+	}
+
+	if _, err := pipeline.Exec(); err != nil {
+		blog.Errorf("instance cache, refresh %d instances failed, err: %v, rid: %s", changed, err, rid)
+		return true
+	}
+
+	blog.V(4).Infof("instance cache, refresh %d instances success, rid: %s", changed, rid)
+	return false
+}
+
+func (c *Client) watchInstAsst() error {
+	watchOpts := &types.WatchOptions{
+		Options: types.Options{
+			EventStruct: new(map[string]interface{}),
+			Collection:  common.BKTableNameInstAsst,
+			Filter:      mapstr.MapStr{},
+		},
+	}
+
+	tokenHandler := newTokenHandler("inst_asst")
+	startAtTime, err := tokenHandler.getStartWatchTime(context.Background())
+	if err != nil {
+		blog.Errorf("get start watch time for %s failed, err: %v", watchOpts.Collection, err)
+		return err
+	}
+	watchOpts.StartAtTime = startAtTime
+	watchOpts.WatchFatalErrorCallback = tokenHandler.resetWatchToken
+
+	loopOptions := &types.LoopBatchOptions{
+		LoopOptions: types.LoopOptions{
+			Name:         "instance cache with instance association",
+			WatchOpt:     watchOpts,
+			TokenHandler: tokenHandler,
+			RetryOptions: &types.RetryOptions{
+				MaxRetryCount: 10,
+				RetryDuration: 1 * time.Second,
+			},
+		},
+		EventHandler: &types.BatchHandler{
+			DoBatch: c.onInstAsstChange,
+		},
+		BatchSize: 50,
+	}
+
+	return c.loopW.WithBatch(loopOptions)
+}
+
+func (c *Client) onInstAsstChange(es []*types.Event) (retry bool) {
+	if len(es) == 0 {
+		return false
+	}
+
+	rid := es[0].ID()
+	pipeline := c.rds.Pipeline()
+	changed := 0
+	for idx := range es {
+		one := es[idx]
+
+		var asst map[string]interface{}
+		switch one.OperationType {
+		case types.Insert, types.Update, types.Replace:
+			asst = *one.Document.(*map[string]interface{})
+
+		case types.Delete:
+			detail, err := c.getDeletedDetail(one.Oid, common.BKTableNameInstAsst)
+			if err != nil {
+				blog.Errorf("instance cache, get deleted association %s failed, err: %v, rid: %s", one.Oid, err, rid)
+				if c.db.IsNotFoundError(err) {
+					continue
+				}
+				return true
+			}
+			asst = detail
+
+		default:
+			continue
+		}
+
+		if !c.isAsstCached(asst) {
+			continue
+		}
+
+		base, err := parseAsstBase(asst)
+		if err != nil {
+			blog.Errorf("instance cache, received invalid association, err: %v, doc: %s, rid: %s", err, one.DocBytes, rid)
+			continue
+		}
+
+		uniqueKey := instKey.AsstUniqueKey(base.objAsstID, base.instID, base.asstInstID)
+		if one.OperationType == types.Delete {
+			pipeline.Del(instKey.AsstDetailKey(base.id), uniqueKey)
+			changed++
+			continue
+		}
+
+		delete(asst, "_id")
+		detail, err := json.Marshal(asst)
+		if err != nil {
+			blog.Errorf("instance cache, marshal association %d failed, err: %v, rid: %s", base.id, err, rid)
+			continue
+		}
+		ttl := instKey.WithRandomExpireSeconds()
+		pipeline.Set(instKey.AsstDetailKey(base.id), string(detail), ttl)
+		pipeline.Set(uniqueKey, base.id, ttl)
+		changed++
+	}
+
+	if changed == 0 {
+		return false
+	}
+
+	if _, err := pipeline.Exec(); err != nil {
+		blog.Errorf("instance cache, refresh %d associations failed, err: %v, rid: %s", changed, err, rid)
+		return true
+	}
+
+	blog.V(4).Infof("instance cache, refresh %d associations success, rid: %s", changed, rid)
+	return false
+}
+
+// isAsstCached check whether the association is cached, an association is cached when any of it's
+// two side objects is opted into the instance cache.
+func (c *Client) isAsstCached(asst map[string]interface{}) bool {
+	return c.objects[util.GetStrByInterface(asst[common.BKObjIDField])] ||
+		c.objects[util.GetStrByInterface(asst[common.BKAsstObjIDField])]
+}
+
+// getDeletedDetail get the deleted document's detail from the delete archive table.
+func (c *Client) getDeletedDetail(oid, collection string) (map[string]interface{}, error) {
+	filter := mapstr.MapStr{
+		"oid":  oid,
+		"coll": collection,
+	}
+	archive := new(deleteArchive)
+	err := c.db.Table(common.BKTableNameDelArchive).Find(filter).One(context.Background(), archive)
+	if err != nil {
+		return nil, err
+	}
+	return archive.Detail, nil
+}
+
+type deleteArchive struct {
+	Oid    string                 `bson:"oid"`
+	Detail map[string]interface{} `bson:"detail"`
+}
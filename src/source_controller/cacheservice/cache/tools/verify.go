@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tools
+
+import (
+	"context"
+	"reflect"
+
+	"configcenter/src/common"
+	"configcenter/src/common/json"
+	"configcenter/src/storage/driver/redis"
+)
+
+// verifyIgnoredFields is the fields which is not compared when verify the cache, because their
+// format may be different between the watched event and the document read from mongodb.
+var verifyIgnoredFields = map[string]bool{
+	"_id":                  true,
+	common.CreateTimeField: true,
+	common.LastTimeField:   true,
+}
+
+// IsDetailDrifted check whether the cached json detail is drifted from the latest json detail in mongodb.
+func IsDetailDrifted(cached, latest string) bool {
+	cachedMap := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(cached), &cachedMap); err != nil {
+		// invalid cached detail is always drifted.
+		return true
+	}
+
+	latestMap := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(latest), &latestMap); err != nil {
+		return false
+	}
+
+	for field := range verifyIgnoredFields {
+		delete(cachedMap, field)
+		delete(latestMap, field)
+	}
+
+	return !reflect.DeepEqual(cachedMap, latestMap)
+}
+
+// ScanKeys scan the redis keys which matches the pattern with count keys at a time, and handle them
+// with the handler. a key may be handled more than once, so the handler should be idempotent.
+func ScanKeys(ctx context.Context, match string, count int64, handler func(keys []string) error) error {
+	cursor := uint64(0)
+	for {
+		keys, next, err := redis.Client().Scan(ctx, cursor, match, count).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) != 0 {
+			if err := handler(keys); err != nil {
+				return err
+			}
+		}
+
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
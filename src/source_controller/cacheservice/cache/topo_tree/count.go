@@ -0,0 +1,429 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topo_tree
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/business"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/driver/mongodb"
+	drvRedis "configcenter/src/storage/driver/redis"
+	"configcenter/src/storage/stream"
+
+	rawRedis "github.com/go-redis/redis/v7"
+)
+
+var countKey = countKeyGenerator{
+	namespace: common.BKCacheKeyV3Prefix + "topo_count",
+	// 6 hours
+	expireSeconds:      6 * 60 * 60 * time.Second,
+	expireRangeSeconds: [2]int{-1800, 1800},
+}
+
+// countKeyGenerator generate the keys to store the hosts and service instances of each module, the
+// host count and service instance count of the upper nodes are rolled up with their modules.
+type countKeyGenerator struct {
+	namespace string
+	// expireSeconds is defined how long is the ttl for the key, it's always used with the
+	// expireRangeSeconds to avoid the keys is expired at same time.
+	expireSeconds time.Duration
+	// min:[0], max:[1]
+	expireRangeSeconds [2]int
+}
+
+// ModuleHostKey is a redis set to store the host ids in this module.
+func (k countKeyGenerator) ModuleHostKey(moduleID int64) string {
+	return k.namespace + ":module:host:" + strconv.FormatInt(moduleID, 10)
+}
+
+// ModuleServiceInstanceKey is a redis set to store the service instance ids in this module.
+func (k countKeyGenerator) ModuleServiceInstanceKey(moduleID int64) string {
+	return k.namespace + ":module:service_instance:" + strconv.FormatInt(moduleID, 10)
+}
+
+// HostUnionTempKey is a temporary redis set to union the hosts of the modules under a node, the count of
+// the distinct hosts is returned when it's stored, and it's deleted after all the nodes are counted.
+func (k countKeyGenerator) HostUnionTempKey(rid string) string {
+	return k.namespace + ":host_union:" + rid
+}
+
+// ModuleSyncedKey is used to mark the module's hosts and service instances is loaded from mongodb,
+// it has the same ttl with the module's keys, the module is loaded again when it's expired.
+func (k countKeyGenerator) ModuleSyncedKey(moduleID int64) string {
+	return k.namespace + ":module:synced:" + strconv.FormatInt(moduleID, 10)
+}
+
+func (k countKeyGenerator) WithRandomExpireSeconds() time.Duration {
+	rand.Seed(time.Now().UnixNano())
+	seconds := rand.Intn(k.expireRangeSeconds[1]-k.expireRangeSeconds[0]) + k.expireRangeSeconds[0]
+	return k.expireSeconds + time.Duration(seconds)*time.Second
+}
+
+// nodeCounter keeps the hosts and service instances of each module in cache, it's updated with the
+// module host relation and service instance events.
+type nodeCounter struct {
+	db    dal.DB
+	rds   redis.Client
+	loopW stream.LoopInterface
+}
+
+func newNodeCounter(loopW stream.LoopInterface) (*nodeCounter, error) {
+	c := &nodeCounter{
+		db:    mongodb.Client(),
+		rds:   drvRedis.Client(),
+		loopW: loopW,
+	}
+
+	if err := c.watchModuleHostRelation(); err != nil {
+		blog.Errorf("topology tree count watch module host relation failed, err: %v", err)
+		return nil, err
+	}
+
+	if err := c.watchServiceInstance(); err != nil {
+		blog.Errorf("topology tree count watch service instance failed, err: %v", err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// moduleCount is the hosts and service instance count of a module.
+type moduleCount struct {
+	hosts            int64
+	serviceInstances int64
+}
+
+// getModuleCounts get the modules' hosts and service instance count, the modules which is not synced
+// will be loaded from mongodb at first.
+func (c *nodeCounter) getModuleCounts(ctx context.Context, moduleIDs []int64) (map[int64]*moduleCount, error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	moduleIDs = util.IntArrayUnique(moduleIDs)
+	if len(moduleIDs) == 0 {
+		return make(map[int64]*moduleCount), nil
+	}
+
+	pipe := c.rds.Pipeline()
+	for _, id := range moduleIDs {
+		pipe.Exists(countKey.ModuleSyncedKey(id))
+	}
+	cmds, err := pipe.Exec()
+	if err != nil {
+		blog.Errorf("check module count synced keys failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	notSynced := make([]int64, 0)
+	for idx, cmd := range cmds {
+		if cmd.(*rawRedis.IntCmd).Val() == 0 {
+			notSynced = append(notSynced, moduleIDs[idx])
+		}
+	}
+
+	if err := c.syncModules(ctx, notSynced); err != nil {
+		blog.Errorf("sync modules %v host and service instance failed, err: %v, rid: %s", notSynced, err, rid)
+		return nil, err
+	}
+
+	pipe = c.rds.Pipeline()
+	for _, id := range moduleIDs {
+		pipe.SCard(countKey.ModuleHostKey(id))
+		pipe.SCard(countKey.ModuleServiceInstanceKey(id))
+	}
+	cmds, err = pipe.Exec()
+	if err != nil {
+		blog.Errorf("get module hosts and service instance count failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	counts := make(map[int64]*moduleCount)
+	for idx, id := range moduleIDs {
+		counts[id] = &moduleCount{
+			hosts:            cmds[2*idx].(*rawRedis.IntCmd).Val(),
+			serviceInstances: cmds[2*idx+1].(*rawRedis.IntCmd).Val(),
+		}
+	}
+	return counts, nil
+}
+
+// countHosts count the distinct hosts of each group of modules, a host in multiple modules of the group is only
+// counted once. the hosts of the modules are unioned in redis, so that they are not loaded for each request.
+func (c *nodeCounter) countHosts(ctx context.Context, counts map[int64]*moduleCount, groups [][]int64) ([]int64,
+	error) {
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	hostCounts := make([]int64, len(groups))
+	tempKey := countKey.HostUnionTempKey(util.GenerateRID())
+	pipe := c.rds.Pipeline()
+	defer pipe.Close()
+
+	// the index of the groups whose hosts need to be unioned.
+	unionGroups := make([]int, 0)
+	for idx, moduleIDs := range groups {
+		moduleIDs = util.IntArrayUnique(moduleIDs)
+		switch len(moduleIDs) {
+		case 0:
+		case 1:
+			if count, exist := counts[moduleIDs[0]]; exist {
+				hostCounts[idx] = count.hosts
+			}
+		default:
+			keys := make([]string, len(moduleIDs))
+			for i, id := range moduleIDs {
+				keys[i] = countKey.ModuleHostKey(id)
+			}
+			pipe.SUnionStore(tempKey, keys...)
+			unionGroups = append(unionGroups, idx)
+		}
+	}
+
+	if len(unionGroups) == 0 {
+		return hostCounts, nil
+	}
+
+	pipe.Del(tempKey)
+	cmds, err := pipe.Exec()
+	if err != nil {
+		blog.Errorf("union module hosts to count the nodes' hosts failed, err: %v, rid: %s", err, rid)
+		return nil, err
+	}
+
+	for i, idx := range unionGroups {
+		hostCounts[idx] = cmds[i].(*rawRedis.IntCmd).Val()
+	}
+	return hostCounts, nil
+}
+
+// moduleRelation is the module related fields of the module host relation and service instance.
+type moduleRelation struct {
+	ID       int64 `bson:"id"`
+	HostID   int64 `bson:"bk_host_id"`
+	ModuleID int64 `bson:"bk_module_id"`
+}
+
+// syncModules load the modules' hosts and service instances from mongodb, and reset them to cache.
+func (c *nodeCounter) syncModules(ctx context.Context, moduleIDs []int64) error {
+	if len(moduleIDs) == 0 {
+		return nil
+	}
+
+	filter := mapstr.MapStr{common.BKModuleIDField: mapstr.MapStr{common.BKDBIN: moduleIDs}}
+	relations := make([]moduleRelation, 0)
+	err := c.db.Table(common.BKTableNameModuleHostConfig).Find(filter).
+		Fields(common.BKHostIDField, common.BKModuleIDField).All(ctx, &relations)
+	if err != nil {
+		return err
+	}
+
+	serviceInstances := make([]moduleRelation, 0)
+	err = c.db.Table(common.BKTableNameServiceInstance).Find(filter).
+		Fields(common.BKFieldID, common.BKModuleIDField).All(ctx, &serviceInstances)
+	if err != nil {
+		return err
+	}
+
+	hosts := make(map[int64][]interface{})
+	for _, relation := range relations {
+		hosts[relation.ModuleID] = append(hosts[relation.ModuleID], relation.HostID)
+	}
+
+	instances := make(map[int64][]interface{})
+	for _, inst := range serviceInstances {
+		instances[inst.ModuleID] = append(instances[inst.ModuleID], inst.ID)
+	}
+
+	pipe := c.rds.Pipeline()
+	for _, id := range moduleIDs {
+		ttl := countKey.WithRandomExpireSeconds()
+		hostKey, instKey := countKey.ModuleHostKey(id), countKey.ModuleServiceInstanceKey(id)
+		pipe.Del(hostKey, instKey)
+		if len(hosts[id]) != 0 {
+			pipe.SAdd(hostKey, hosts[id]...)
+			pipe.Expire(hostKey, ttl)
+		}
+		if len(instances[id]) != 0 {
+			pipe.SAdd(instKey, instances[id]...)
+			pipe.Expire(instKey, ttl)
+		}
+		pipe.Set(countKey.ModuleSyncedKey(id), time.Now().Unix(), ttl)
+	}
+
+	_, err = pipe.Exec()
+	return err
+}
+
+// fillCount fill the host count and service instance count of the business and each node in the
+// topology tree. the counts of the upper nodes are rolled up with all the modules under them, and a
+// host in multiple modules of the node is only counted once.
+func (t *TopologyTree) fillCount(ctx context.Context, topo *Topology) error {
+	filler := &countFiller{
+		bizCache:       t.bizCache,
+		bizID:          topo.BusinessID,
+		modulesOfSet:   make(map[int64][]int64),
+		setsOfParent:   make(map[int64][]int64),
+		customOfParent: make(map[string]map[int64][]int64),
+	}
+	if err := filler.prepare(); err != nil {
+		return err
+	}
+
+	counts, err := t.counter.getModuleCounts(ctx, filler.allModules)
+	if err != nil {
+		return err
+	}
+
+	// the business and all the tree nodes with the modules under them, the nodes are counted at once.
+	nodes := make([]*Tree, 0)
+	groups := [][]int64{filler.allModules}
+	if err := filler.collectNodes(topo.Trees, &nodes, &groups); err != nil {
+		return err
+	}
+
+	hostCounts, err := t.counter.countHosts(ctx, counts, groups)
+	if err != nil {
+		return err
+	}
+
+	topo.HostCount, topo.ServiceInstanceCount = &hostCounts[0], countServiceInstances(counts, groups[0])
+	for idx, node := range nodes {
+		node.HostCount = &hostCounts[idx+1]
+		node.ServiceInstanceCount = countServiceInstances(counts, groups[idx+1])
+	}
+	return nil
+}
+
+// countServiceInstances count the service instances in these modules.
+func countServiceInstances(counts map[int64]*moduleCount, moduleIDs []int64) *int64 {
+	var instances int64
+	for _, id := range moduleIDs {
+		if count, exist := counts[id]; exist {
+			instances += count.serviceInstances
+		}
+	}
+	return &instances
+}
+
+// countFiller find out the modules under each node of a business's topology.
+type countFiller struct {
+	bizCache *business.Client
+	bizID    int64
+	// mainline topology from biz to host
+	topology     []string
+	allModules   []int64
+	modulesOfSet map[int64][]int64
+	setsOfParent map[int64][]int64
+	// custom object -> parent id -> instance ids, it's loaded when it's used.
+	customOfParent map[string]map[int64][]int64
+}
+
+func (f *countFiller) prepare() error {
+	topology, err := f.bizCache.GetTopology()
+	if err != nil {
+		return fmt.Errorf("get mainline topology failed, err: %v", err)
+	}
+	f.topology = topology
+
+	modules, err := f.bizCache.GetModuleBaseList(f.bizID)
+	if err != nil {
+		return fmt.Errorf("get module base list failed, err: %v", err)
+	}
+	for _, mod := range modules {
+		f.allModules = append(f.allModules, mod.ModuleID)
+		f.modulesOfSet[mod.SetID] = append(f.modulesOfSet[mod.SetID], mod.ModuleID)
+	}
+
+	sets, err := f.bizCache.GetSetBaseList(f.bizID)
+	if err != nil {
+		return fmt.Errorf("get set base list failed, err: %v", err)
+	}
+	for _, set := range sets {
+		f.setsOfParent[set.ParentID] = append(f.setsOfParent[set.ParentID], set.SetID)
+	}
+	return nil
+}
+
+// collectNodes collect the tree nodes and all their children, with the module ids under each of them.
+func (f *countFiller) collectNodes(trees []Tree, nodes *[]*Tree, groups *[][]int64) error {
+	for idx := range trees {
+		moduleIDs, err := f.getModuleIDs(trees[idx].Object, trees[idx].InstID)
+		if err != nil {
+			return err
+		}
+		*nodes = append(*nodes, &trees[idx])
+		*groups = append(*groups, moduleIDs)
+
+		if err := f.collectNodes(trees[idx].Children, nodes, groups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getModuleIDs get all the module ids under the node.
+func (f *countFiller) getModuleIDs(object string, instID int64) ([]int64, error) {
+	switch object {
+	case common.BKInnerObjIDModule:
+		return []int64{instID}, nil
+	case common.BKInnerObjIDSet:
+		return f.modulesOfSet[instID], nil
+	}
+
+	// custom level, find it's children with the next level in mainline topology.
+	next := ""
+	for idx, level := range f.topology {
+		if level == object && idx+1 < len(f.topology) {
+			next = f.topology[idx+1]
+		}
+	}
+
+	switch next {
+	case common.BKInnerObjIDSet:
+		moduleIDs := make([]int64, 0)
+		for _, setID := range f.setsOfParent[instID] {
+			moduleIDs = append(moduleIDs, f.modulesOfSet[setID]...)
+		}
+		return moduleIDs, nil
+	case "", common.BKInnerObjIDApp, common.BKInnerObjIDModule, common.BKInnerObjIDHost:
+		return nil, fmt.Errorf("custom level %s got invalid topo %v", object, f.topology)
+	}
+
+	if _, exist := f.customOfParent[next]; !exist {
+		list, err := f.bizCache.GetCustomLevelBaseList(next, f.bizID)
+		if err != nil {
+			return nil, fmt.Errorf("get object %s base list failed, err: %v", next, err)
+		}
+		f.customOfParent[next] = make(map[int64][]int64)
+		for _, inst := range list {
+			f.customOfParent[next][inst.ParentID] = append(f.customOfParent[next][inst.ParentID], inst.InstanceID)
+		}
+	}
+
+	moduleIDs := make([]int64, 0)
+	for _, childID := range f.customOfParent[next][instID] {
+		ids, err := f.getModuleIDs(next, childID)
+		if err != nil {
+			return nil, err
+		}
+		moduleIDs = append(moduleIDs, ids...)
+	}
+	return moduleIDs, nil
+}
@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topo_tree
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"configcenter/src/storage/dal/redis"
+
+	"github.com/alicebob/miniredis"
+)
+
+func TestCountHosts(t *testing.T) {
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	rds, err := redis.NewFromConfig(redis.Config{Address: server.Addr(), Database: "0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.SetAdd(countKey.ModuleHostKey(1), "1", "2")
+	server.SetAdd(countKey.ModuleHostKey(2), "2", "3")
+	counts := map[int64]*moduleCount{
+		1: {hosts: 2, serviceInstances: 1},
+		2: {hosts: 2, serviceInstances: 2},
+		3: {hosts: 0, serviceInstances: 0},
+	}
+
+	c := &nodeCounter{rds: rds}
+	groups := [][]int64{{1, 2, 3}, {1}, {2, 3}, {}, {3}, {2, 2}}
+	hostCounts, err := c.countHosts(context.Background(), counts, groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expect := []int64{3, 2, 2, 0, 0, 2}; !reflect.DeepEqual(hostCounts, expect) {
+		t.Fatalf("expect host counts %v, got %v", expect, hostCounts)
+	}
+	for _, key := range server.Keys() {
+		if strings.Contains(key, ":host_union:") {
+			t.Fatalf("the temporary union key %s should be deleted", key)
+		}
+	}
+
+	if instances := countServiceInstances(counts, groups[0]); *instances != 3 {
+		t.Fatalf("expect 3 service instances, got %d", *instances)
+	}
+}
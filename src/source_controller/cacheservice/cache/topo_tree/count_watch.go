@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topo_tree
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/stream/types"
+)
+
+func (c *nodeCounter) watchModuleHostRelation() error {
+	return c.watch(common.BKTableNameModuleHostConfig, "module_host_relation", c.onModuleHostRelationChange)
+}
+
+func (c *nodeCounter) watchServiceInstance() error {
+	return c.watch(common.BKTableNameServiceInstance, "service_instance", c.onServiceInstanceChange)
+}
+
+func (c *nodeCounter) watch(collection, key string, handler func(es []*types.Event) bool) error {
+	watchOpts := &types.WatchOptions{
+		Options: types.Options{
+			EventStruct: new(moduleRelation),
+			Collection:  collection,
+			Filter:      mapstr.MapStr{},
+		},
+	}
+
+	tokenHandler := newTokenHandler(key)
+	startAtTime, err := tokenHandler.getStartWatchTime(context.Background())
+	if err != nil {
+		blog.Errorf("get start watch time for %s failed, err: %v", watchOpts.Collection, err)
+		return err
+	}
+	watchOpts.StartAtTime = startAtTime
+	watchOpts.WatchFatalErrorCallback = tokenHandler.resetWatchToken
+
+	loopOptions := &types.LoopBatchOptions{
+		LoopOptions: types.LoopOptions{
+			Name:         "topology tree count with " + key,
+			WatchOpt:     watchOpts,
+			TokenHandler: tokenHandler,
+			RetryOptions: &types.RetryOptions{
+				MaxRetryCount: 10,
+				RetryDuration: 1 * time.Second,
+			},
+		},
+		EventHandler: &types.BatchHandler{
+			DoBatch: handler,
+		},
+		BatchSize: 50,
+	}
+
+	return c.loopW.WithBatch(loopOptions)
+}
+
+// onModuleHostRelationChange add or remove the host from the module's hosts. the relation is never
+// updated, a host is transferred with deleting the old relations and inserting the new ones.
+func (c *nodeCounter) onModuleHostRelationChange(es []*types.Event) (retry bool) {
+	return c.onRelationChange(common.BKTableNameModuleHostConfig, es, func(relation *moduleRelation) (string, int64) {
+		return countKey.ModuleHostKey(relation.ModuleID), relation.HostID
+	})
+}
+
+// onServiceInstanceChange add or remove the service instance from the module's service instances.
+func (c *nodeCounter) onServiceInstanceChange(es []*types.Event) (retry bool) {
+	return c.onRelationChange(common.BKTableNameServiceInstance, es, func(inst *moduleRelation) (string, int64) {
+		return countKey.ModuleServiceInstanceKey(inst.ModuleID), inst.ID
+	})
+}
+
+// onRelationChange add the inserted relation's member to the module's key, and remove the deleted
+// relation's member from it. both of the operations are idempotent, so the events can be retried.
+func (c *nodeCounter) onRelationChange(collection string, es []*types.Event,
+	keyMember func(relation *moduleRelation) (key string, member int64)) (retry bool) {
+
+	if len(es) == 0 {
+		return false
+	}
+
+	rid := es[0].ID()
+	pipe := c.rds.Pipeline()
+	changed := 0
+	for idx := range es {
+		one := es[idx]
+
+		switch one.OperationType {
+		case types.Insert:
+			key, member := keyMember(one.Document.(*moduleRelation))
+			pipe.SAdd(key, member)
+			changed++
+
+		case types.Delete:
+			filter := mapstr.MapStr{
+				"oid":  one.Oid,
+				"coll": collection,
+			}
+			archive := new(moduleRelationArchive)
+			err := c.db.Table(common.BKTableNameDelArchive).Find(filter).One(context.Background(), archive)
+			if err != nil {
+				if c.db.IsNotFoundError(err) {
+					blog.Errorf("topology tree count, can not find deleted %s %s detail, skip, rid: %s",
+						collection, one.Oid, rid)
+					continue
+				}
+				blog.Errorf("topology tree count, get deleted %s %s failed, err: %v, rid: %s", collection,
+					one.Oid, err, rid)
+				return true
+			}
+
+			key, member := keyMember(archive.Detail)
+			pipe.SRem(key, member)
+			changed++
+
+		default:
+			// the module of a relation or a service instance is never changed.
+			continue
+		}
+	}
+
+	if changed == 0 {
+		return false
+	}
+
+	if _, err := pipe.Exec(); err != nil {
+		blog.Errorf("topology tree count, refresh %d module counts failed, err: %v, rid: %s", changed, err, rid)
+		return true
+	}
+
+	blog.V(4).Infof("topology tree count, refresh %d module counts success, rid: %s", changed, rid)
+	return false
+}
+
+type moduleRelationArchive struct {
+	Oid    string          `bson:"oid"`
+	Detail *moduleRelation `bson:"detail"`
+}
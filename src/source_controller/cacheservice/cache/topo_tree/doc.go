@@ -17,4 +17,6 @@ This package is used to support the business topology tree search.
 It use event watch mechanism to cache the business topology related resources, like business list,
 mainline instance list, set list, module list etc.
 It use redis to store the cache, so that we can provide a high performance topology query api.
+It also keeps the hosts and service instances of each module in cache, so that the topology tree can
+be returned with the host count and service instance count of each node.
 */
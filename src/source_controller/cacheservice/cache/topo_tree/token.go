@@ -0,0 +1,106 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package topo_tree
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/mongodb"
+	"configcenter/src/storage/stream/types"
+)
+
+func newTokenHandler(key string) *tokenHandler {
+	return &tokenHandler{
+		doc: "topo_tree_count_watch_token",
+		key: key,
+		db:  mongodb.Client(),
+	}
+}
+
+type tokenHandler struct {
+	doc string
+	key string
+	db  dal.DB
+}
+
+func (w *tokenHandler) SetLastWatchToken(ctx context.Context, token string) error {
+	var err error
+	// do with retry
+	filter := map[string]interface{}{"_id": w.doc}
+	tokenData := mapstr.MapStr{w.key: token}
+
+	for try := 0; try < 5; try++ {
+		err = w.db.Table(common.BKTableNameSystem).Upsert(ctx, filter, tokenData)
+		if err != nil {
+			time.Sleep(time.Duration(try/2+1) * time.Second)
+			continue
+		}
+		return nil
+	}
+
+	return err
+}
+
+// get the former watched token.
+// if Key is not exist, then token is "".
+func (w *tokenHandler) GetStartWatchToken(ctx context.Context) (token string, err error) {
+	// do with retry
+	filter := map[string]interface{}{"_id": w.doc}
+	for try := 0; try < 5; try++ {
+		tokenData := make(map[string]string)
+		err = w.db.Table(common.BKTableNameSystem).Find(filter).Fields(w.key).One(ctx, &tokenData)
+		if err != nil {
+			blog.Errorf("get %s start token failed, err: %v", w.key, err)
+			if !w.db.IsNotFoundError(err) {
+				time.Sleep(time.Duration(try/2+1) * time.Second)
+				continue
+			}
+			return "", nil
+		}
+		return tokenData[w.key], nil
+	}
+
+	return "", err
+}
+
+// resetWatchToken set watch token to empty and set the start watch time to the given one for next watch
+func (w *tokenHandler) resetWatchToken(startAtTime types.TimeStamp) error {
+	filter := map[string]interface{}{"_id": w.doc}
+	tokenData := mapstr.MapStr{
+		w.key:                 "",
+		w.key + "_start_time": startAtTime,
+	}
+
+	return w.db.Table(common.BKTableNameSystem).Upsert(context.Background(), filter, tokenData)
+}
+
+func (w *tokenHandler) getStartWatchTime(ctx context.Context) (*types.TimeStamp, error) {
+	filter := map[string]interface{}{"_id": w.doc}
+
+	data := make(map[string]types.TimeStamp)
+	err := w.db.Table(common.BKTableNameSystem).Find(filter).Fields(w.key+"_start_time").One(ctx, &data)
+	if err != nil {
+		if !w.db.IsNotFoundError(err) {
+			blog.Errorf("get %s start time failed, err: %v", w.key, err)
+			return nil, err
+		}
+		return new(types.TimeStamp), nil
+	}
+	startTime := data[w.key+"_start_time"]
+	return &startTime, nil
+}
@@ -13,25 +13,33 @@
 package topo_tree
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"regexp"
 	"strings"
 
 	"configcenter/src/source_controller/cacheservice/cache/business"
+	"configcenter/src/storage/stream"
 
 	"github.com/tidwall/gjson"
 )
 
-func NewTopologyTree(client *business.Client) *TopologyTree {
-	return &TopologyTree{bizCache: client}
+func NewTopologyTree(client *business.Client, loopW stream.LoopInterface) (*TopologyTree, error) {
+	counter, err := newNodeCounter(loopW)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TopologyTree{bizCache: client, counter: counter}, nil
 }
 
 type TopologyTree struct {
 	bizCache *business.Client
+	counter  *nodeCounter
 }
 
-func (t *TopologyTree) SearchTopologyTree(opt *SearchOption) ([]*Topology, error) {
+func (t *TopologyTree) SearchTopologyTree(ctx context.Context, opt *SearchOption) ([]*Topology, error) {
 	if err := opt.Validate(); err != nil {
 		return nil, err
 	}
@@ -97,9 +105,16 @@ func (t *TopologyTree) SearchTopologyTree(opt *SearchOption) ([]*Topology, error
 		if err != nil {
 			return nil, err
 		}
-		if topo != nil {
-			allTopology = append(allTopology, topo)
+		if topo == nil {
+			continue
+		}
+
+		if opt.WithCount {
+			if err := t.fillCount(ctx, topo); err != nil {
+				return nil, fmt.Errorf("get business %d topology count failed, err: %v", biz.BusinessID, err)
+			}
 		}
+		allTopology = append(allTopology, topo)
 	}
 
 	return allTopology, nil
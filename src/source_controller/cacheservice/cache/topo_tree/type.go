@@ -30,6 +30,8 @@ type SearchOption struct {
 	SetName      string      `json:"bk_set_name"`
 	ModuleName   string      `json:"bk_module_name"`
 	Level        CustomLevel `json:"bk_level"`
+	// WithCount is whether to return the host count and service instance count of each node.
+	WithCount bool `json:"with_count"`
 }
 
 func (s SearchOption) Validate() error {
@@ -82,6 +84,9 @@ type Topology struct {
 	BusinessID   int64  `json:"bk_biz_id"`
 	BusinessName string `json:"bk_biz_name"`
 	Trees        []Tree `json:"bk_topo_tree"`
+	// the counts is only returned when the search option's with_count is true.
+	HostCount            *int64 `json:"host_count,omitempty"`
+	ServiceInstanceCount *int64 `json:"service_instance_count,omitempty"`
 }
 
 type Tree struct {
@@ -89,6 +94,10 @@ type Tree struct {
 	InstName string `json:"bk_inst_name"`
 	InstID   int64  `json:"bk_inst_id"`
 	Children []Tree `json:"children"`
+	// the host count and service instance count of all the modules under this node, a host in multiple
+	// modules is only counted once.
+	HostCount            *int64 `json:"host_count,omitempty"`
+	ServiceInstanceCount *int64 `json:"service_instance_count,omitempty"`
 }
 
 type instance struct {
@@ -0,0 +1,248 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/metrics"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/cacheservice/cache/business"
+	"configcenter/src/source_controller/cacheservice/cache/host"
+	"configcenter/src/source_controller/cacheservice/cache/tools"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrVerifyRunning is returned when the resource's cache is being verified or rebuilt.
+var ErrVerifyRunning = errors.New("cache is being verified or rebuilt, please try again later")
+
+// VerifyOption is the option of the background cache verifier.
+type VerifyOption struct {
+	// IntervalMinutes is the interval to verify caches, the background verifier is disabled when it's 0.
+	IntervalMinutes int
+	// SampleSize is the sample count of each resource in one verify.
+	SampleSize int64
+}
+
+// Verifier verify the caches with the data in mongodb to find the drifted keys, which may be caused by
+// lost watch token or redis data loss, and repair them.
+type Verifier struct {
+	host     *host.Client
+	biz      *business.Client
+	isMaster discovery.ServiceManageInterface
+	lock     tools.RefreshingLock
+	metrics  *verifyMetrics
+}
+
+func newVerifier(hostClient *host.Client, bizClient *business.Client, isMaster discovery.ServiceManageInterface,
+	opt VerifyOption) *Verifier {
+
+	v := &Verifier{
+		host:     hostClient,
+		biz:      bizClient,
+		isMaster: isMaster,
+		lock:     tools.NewRefreshingLock(),
+		metrics:  initVerifyMetrics(),
+	}
+
+	if opt.IntervalMinutes > 0 {
+		go v.loopVerify(opt)
+	}
+	return v
+}
+
+// Verify verify a resource's cache, it can not be run concurrently with the same resource.
+func (v *Verifier) Verify(ctx context.Context, opt *metadata.VerifyCacheOption) (*metadata.VerifyCacheResult, error) {
+	lockKey := string(opt.Resource)
+	if !v.lock.CanRefresh(lockKey) {
+		return nil, ErrVerifyRunning
+	}
+	v.lock.SetRefreshing(lockKey)
+	defer v.lock.SetUnRefreshing(lockKey)
+
+	var result *metadata.VerifyCacheResult
+	var err error
+	switch opt.Resource {
+	case metadata.CacheResourceHost:
+		result, err = v.host.Verify(ctx, opt)
+	case metadata.CacheResourceBiz:
+		result, err = v.biz.VerifyBusiness(ctx, opt)
+	default:
+		return nil, opt.Resource.Validate()
+	}
+
+	if err != nil {
+		v.metrics.collectError(opt.Resource)
+		return nil, err
+	}
+
+	v.metrics.collectResult(result)
+	return result, nil
+}
+
+// Rebuild drop a resource's cache and rebuild it with the data in mongodb.
+func (v *Verifier) Rebuild(ctx context.Context, resource metadata.CacheResource) (*metadata.VerifyCacheResult, error) {
+	lockKey := string(resource)
+	if !v.lock.CanRefresh(lockKey) {
+		return nil, ErrVerifyRunning
+	}
+	v.lock.SetRefreshing(lockKey)
+	defer v.lock.SetUnRefreshing(lockKey)
+
+	var result *metadata.VerifyCacheResult
+	var err error
+	switch resource {
+	case metadata.CacheResourceHost:
+		result, err = v.host.Rebuild(ctx)
+	case metadata.CacheResourceBiz:
+		result, err = v.biz.RebuildBusiness(ctx)
+	default:
+		return nil, resource.Validate()
+	}
+
+	if err != nil {
+		v.metrics.collectError(resource)
+		return nil, err
+	}
+
+	v.metrics.repairedCount.With(prometheus.Labels{"resource": string(resource)}).Add(float64(result.Repaired))
+	return result, nil
+}
+
+// loopVerify sample the resources to verify and repair the caches every interval minutes.
+func (v *Verifier) loopVerify(opt VerifyOption) {
+	blog.Infof("loop verify cache task every %d minutes, sample size: %d.", opt.IntervalMinutes, opt.SampleSize)
+	for {
+		time.Sleep(time.Duration(opt.IntervalMinutes) * time.Minute)
+
+		if !v.isMaster.IsMaster() {
+			blog.V(4).Infof("loop verify cache, but not master, skip.")
+			continue
+		}
+
+		for _, resource := range []metadata.CacheResource{metadata.CacheResourceHost, metadata.CacheResourceBiz} {
+			rid := util.GenerateRID()
+			ctx := context.WithValue(context.Background(), common.ContextRequestIDField, rid)
+			// sample from secondary in mongodb cluster, the drifted keys are repaired with the data in primary.
+			ctx = util.SetDBReadPreference(ctx, common.SecondaryPreferredMode)
+
+			verifyOpt := &metadata.VerifyCacheOption{
+				Resource:   resource,
+				SampleSize: opt.SampleSize,
+				Repair:     true,
+			}
+			result, err := v.Verify(ctx, verifyOpt)
+			if err != nil {
+				blog.Errorf("loop verify %s cache failed, err: %v, rid: %s", resource, err, rid)
+				continue
+			}
+
+			blog.Infof("loop verify %s cache success, result: %+v, rid: %s", resource, *result, rid)
+		}
+	}
+}
+
+func initVerifyMetrics() *verifyMetrics {
+	m := new(verifyMetrics)
+	m.checkedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cache",
+		Name:      "verify_checked_total",
+		Help:      "the total count of resources which has been verified with it's cache",
+	}, []string{"resource"})
+	metrics.Register().MustRegister(m.checkedCount)
+
+	m.driftCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cache",
+		Name:      "verify_drift_total",
+		Help:      "the total count of drifted cache keys which is found by verify",
+	}, []string{"resource", "drift_type"})
+	metrics.Register().MustRegister(m.driftCount)
+
+	m.lastDrift = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cache",
+		Name:      "last_verify_drift_keys",
+		Help:      "the count of drifted cache keys which is found by the last verify",
+	}, []string{"resource", "drift_type"})
+	metrics.Register().MustRegister(m.lastDrift)
+
+	m.repairedCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cache",
+		Name:      "repaired_keys_total",
+		Help:      "the total count of cache keys which is repaired by verify or rebuild",
+	}, []string{"resource"})
+	metrics.Register().MustRegister(m.repairedCount)
+
+	m.lastVerifyTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cache",
+		Name:      "last_verify_unix_time_seconds",
+		Help:      "records the time that the last verify is finished at unix time seconds",
+	}, []string{"resource"})
+	metrics.Register().MustRegister(m.lastVerifyTime)
+
+	m.errorCount = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "cache",
+		Name:      "verify_error_total",
+		Help:      "the total count of failed verify or rebuild",
+	}, []string{"resource"})
+	metrics.Register().MustRegister(m.errorCount)
+
+	return m
+}
+
+type verifyMetrics struct {
+	// record the total resources count which has been verified.
+	checkedCount *prometheus.CounterVec
+	// record the total drifted keys count, drift type is mismatched or stale.
+	driftCount *prometheus.CounterVec
+	// record the drifted keys count of the last verify.
+	lastDrift *prometheus.GaugeVec
+	// record the total repaired keys count.
+	repairedCount *prometheus.CounterVec
+	// record when the last verify is finished.
+	lastVerifyTime *prometheus.GaugeVec
+	// record the failed verify and rebuild count.
+	errorCount *prometheus.CounterVec
+}
+
+func (m *verifyMetrics) collectResult(r *metadata.VerifyCacheResult) {
+	resource := string(r.Resource)
+	m.checkedCount.With(prometheus.Labels{"resource": resource}).Add(float64(r.Checked))
+
+	mismatched := prometheus.Labels{"resource": resource, "drift_type": "mismatched"}
+	m.driftCount.With(mismatched).Add(float64(r.Mismatched))
+	m.lastDrift.With(mismatched).Set(float64(r.Mismatched))
+
+	stale := prometheus.Labels{"resource": resource, "drift_type": "stale"}
+	m.driftCount.With(stale).Add(float64(r.Stale))
+	m.lastDrift.With(stale).Set(float64(r.Stale))
+
+	m.repairedCount.With(prometheus.Labels{"resource": resource}).Add(float64(r.Repaired))
+	m.lastVerifyTime.With(prometheus.Labels{"resource": resource}).Set(float64(time.Now().Unix()))
+}
+
+func (m *verifyMetrics) collectError(resource metadata.CacheResource) {
+	m.errorCount.With(prometheus.Labels{"resource": string(resource)}).Inc()
+}
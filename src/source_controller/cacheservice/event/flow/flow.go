@@ -223,12 +223,13 @@ func (f *Flow) doBatch(es []*types.Event) (retry bool) {
 		if instanceID := f.key.InstanceID(e.DocBytes); instanceID > 0 {
 			chainNode.InstanceID = instanceID
 		}
-		chainNodes = append(chainNodes, chainNode)
 
 		docBytes := e.DocBytes
 		if e.OperationType == types.Delete {
 			docBytes = oidDetailMap[e.Oid]
 		}
+		chainNode.SupplierAccount = f.key.SupplierAccount(docBytes)
+		chainNodes = append(chainNodes, chainNode)
 
 		detail := types.EventDetail{
 			Detail:        types.JsonString(docBytes),
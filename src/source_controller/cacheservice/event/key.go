@@ -268,6 +268,11 @@ func (k Key) InstanceID(doc []byte) int64 {
 	return 0
 }
 
+// SupplierAccount returns the supplier account(tenant) which the event's document belongs to.
+func (k Key) SupplierAccount(doc []byte) string {
+	return gjson.GetBytes(doc, common.BKOwnerIDField).String()
+}
+
 func (k Key) Collection() string {
 	return k.collection
 }
@@ -281,7 +281,7 @@ func (c *Client) searchFollowingEventChainNodes(kit *rest.Kit, startCursor strin
 			return false, nil, 0, err
 		}
 
-		if c.isNodeWithEventType(node, types) {
+		if c.isNodeWithEventType(node, types) && isTenantNode(kit, node) {
 			return true, append([]*watch.ChainNode{node}, nodes...), node.ID, nil
 		}
 		return true, nodes, node.ID, nil
@@ -342,6 +342,7 @@ func (c *Client) searchFollowingEventChainNodesByID(kit *rest.Kit, id uint64, li
 	if len(types) > 0 {
 		filter[common.BKEventTypeField] = map[string]interface{}{common.BKDBIN: types}
 	}
+	addTenantFilter(kit, filter)
 
 	nodes := make([]*watch.ChainNode, 0)
 	if err := c.watchDB.Table(key.ChainCollection()).Find(filter).Sort(common.BKFieldID).Limit(limit).
@@ -0,0 +1,46 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package watch
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/watch"
+)
+
+// addTenantFilter limits the chain node filter to the events of the request's supplier account.
+// the super owner can watch all the tenants' events, and the chain nodes created before the tenant
+// isolation which has no supplier account belongs to the default supplier account.
+func addTenantFilter(kit *rest.Kit, filter map[string]interface{}) {
+	switch kit.SupplierAccount {
+	case common.BKSuperOwnerID:
+		return
+	case common.BKDefaultOwnerID:
+		filter[common.BKOwnerIDField] = map[string]interface{}{common.BKDBIN: []interface{}{nil, common.BKDefaultOwnerID}}
+	default:
+		filter[common.BKOwnerIDField] = kit.SupplierAccount
+	}
+}
+
+// isTenantNode checks whether the chain node's event belongs to the request's supplier account.
+func isTenantNode(kit *rest.Kit, node *watch.ChainNode) bool {
+	if kit.SupplierAccount == common.BKSuperOwnerID {
+		return true
+	}
+
+	owner := node.SupplierAccount
+	if owner == "" {
+		owner = common.BKDefaultOwnerID
+	}
+	return owner == kit.SupplierAccount
+}
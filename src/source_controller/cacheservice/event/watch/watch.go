@@ -69,7 +69,7 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 
 	// start from is ahead of the latest's event time, watch from now.
 	if int64(tailNode.ClusterTime.Sec) <= opts.StartFrom {
-		if !c.isNodeWithEventType(tailNode, opts.EventTypes) {
+		if !c.isNodeWithEventType(tailNode, opts.EventTypes) || !isTenantNode(kit, tailNode) {
 			// not matched, set to no event cursor with empty detail
 			return []*watch.WatchEventDetail{{
 				Cursor:    watch.NoEventCursor,
@@ -128,7 +128,7 @@ func (c *Client) WatchWithStartFrom(kit *rest.Kit, key event.Key, opts *watch.Wa
 	}
 
 	// since the first node is after the start time, we need to include it in the nodes after the start time
-	if c.isNodeWithEventType(node, opts.EventTypes) {
+	if c.isNodeWithEventType(node, opts.EventTypes) && isTenantNode(kit, node) {
 		nodes = append([]*watch.ChainNode{node}, nodes...)
 	}
 
@@ -241,7 +241,7 @@ func (c *Client) WatchFromNow(kit *rest.Kit, key event.Key, opts *watch.WatchEve
 		}, nil
 	}
 
-	if !c.isNodeWithEventType(node, opts.EventTypes) {
+	if !c.isNodeWithEventType(node, opts.EventTypes) || !isTenantNode(kit, node) {
 		// not matched, set to no event cursor with empty detail
 		return &watch.WatchEventDetail{
 			Cursor:    watch.NoEventCursor,
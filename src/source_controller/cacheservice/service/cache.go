@@ -13,6 +13,7 @@
 package service
 
 import (
+	"encoding/json"
 	"strconv"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/cache/host"
 	"configcenter/src/source_controller/cacheservice/cache/topo_tree"
 	"configcenter/src/source_controller/cacheservice/event"
 	"configcenter/src/storage/driver/redis"
@@ -39,7 +41,7 @@ func (s *cacheService) SearchTopologyTreeInCache(ctx *rest.Contexts) {
 		return
 	}
 
-	topo, err := s.cacheSet.Tree.SearchTopologyTree(opt)
+	topo, err := s.cacheSet.Tree.SearchTopologyTree(ctx.Kit.Ctx, opt)
 	if err != nil {
 		if err == topo_tree.OverHeadError {
 			ctx.RespWithError(err, common.SearchTopoTreeScanTooManyData, "search topology tree failed, err: %v", err)
@@ -48,7 +50,19 @@ func (s *cacheService) SearchTopologyTreeInCache(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search topology tree failed, err: %v", err)
 		return
 	}
-	ctx.RespEntity(topo)
+
+	tenantTopo := make([]*topo_tree.Topology, 0, len(topo))
+	for _, t := range topo {
+		isTenant, err := s.isTenantBiz(ctx.Kit, t.BusinessID)
+		if err != nil {
+			ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search topology tree failed, err: %v", err)
+			return
+		}
+		if isTenant {
+			tenantTopo = append(tenantTopo, t)
+		}
+	}
+	ctx.RespEntity(tenantTopo)
 }
 
 func (s *cacheService) SearchHostWithInnerIPInCache(ctx *rest.Contexts) {
@@ -57,11 +71,16 @@ func (s *cacheService) SearchHostWithInnerIPInCache(ctx *rest.Contexts) {
 		ctx.RespAutoError(err)
 		return
 	}
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	host, err := s.cacheSet.Host.GetHostWithInnerIP(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search host with inner ip in cache, but get host failed, err: %v", err)
 		return
 	}
+	if !isTenantDetail(ctx.Kit, host) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search host with inner ip in cache, but host not exist")
+		return
+	}
 	ctx.RespString(&host)
 }
 
@@ -72,11 +91,16 @@ func (s *cacheService) SearchHostWithHostIDInCache(ctx *rest.Contexts) {
 		return
 	}
 
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	host, err := s.cacheSet.Host.GetHostWithID(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search host with id in cache, but get host failed, err: %v", err)
 		return
 	}
+	if !isTenantDetail(ctx.Kit, host) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search host with id in cache, but host not exist")
+		return
+	}
 	ctx.RespString(&host)
 }
 
@@ -91,12 +115,13 @@ func (s *cacheService) ListHostWithHostIDInCache(ctx *rest.Contexts) {
 		return
 	}
 
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	host, err := s.cacheSet.Host.ListHostWithHostIDs(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list host with id in cache, but get host failed, err: %v", err)
 		return
 	}
-	ctx.RespStringArray(host)
+	ctx.RespStringArray(filterTenantDetails(ctx.Kit, host))
 }
 
 func (s *cacheService) ListHostWithPageInCache(ctx *rest.Contexts) {
@@ -106,12 +131,51 @@ func (s *cacheService) ListHostWithPageInCache(ctx *rest.Contexts) {
 		return
 	}
 
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	cnt, host, err := s.cacheSet.Host.ListHostsWithPage(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list host with id in cache, but get host failed, err: %v", err)
 		return
 	}
-	ctx.RespCountInfoString(cnt, host)
+	ctx.RespCountInfoString(cnt, filterTenantDetails(ctx.Kit, host))
+}
+
+// ListHostWithSecondaryKeysInCache find hosts with many secondary keys at once, such as asset id, sn.
+// the result is in the same sequence with the request keys, and a key may match multiple hosts.
+func (s *cacheService) ListHostWithSecondaryKeysInCache(ctx *rest.Contexts) {
+	opt := new(metadata.ListHostWithSecondaryKeysOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid,
+			"list host with secondary keys, but request parameter is invalid: %v", err)
+		return
+	}
+
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
+	hosts, err := s.cacheSet.Host.ListHostWithSecondaryKeys(ctx.Kit.Ctx, opt)
+	if err != nil {
+		if err == host.ErrSecondaryKeysExceedLimit {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommXXExceedLimit, "hosts", common.BKMaxPageSize))
+			return
+		}
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed,
+			"list host with secondary keys in cache, but get host failed, err: %v", err)
+		return
+	}
+
+	result := make([]metadata.HostWithSecondaryKey, len(hosts))
+	for idx, host := range hosts {
+		result[idx].HostSecondaryKey = host.HostSecondaryKey
+		result[idx].Hosts = make([]json.RawMessage, 0)
+		for _, detail := range filterTenantDetails(ctx.Kit, host.Hosts) {
+			result[idx].Hosts = append(result[idx].Hosts, json.RawMessage(detail))
+		}
+	}
+	ctx.RespEntity(result)
 }
 
 // GetHostSnap get one host snap
@@ -185,12 +249,13 @@ func (s *cacheService) ListBusinessInCache(ctx *rest.Contexts) {
 		return
 	}
 
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	details, err := s.cacheSet.Business.ListBusiness(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list business with id in cache failed, err: %v", err)
 		return
 	}
-	ctx.RespStringArray(details)
+	ctx.RespStringArray(filterTenantDetails(ctx.Kit, details))
 }
 
 // ListModules list modules with id from cache, if not exist in cache, then get from mongodb directly.
@@ -201,12 +266,13 @@ func (s *cacheService) ListModulesInCache(ctx *rest.Contexts) {
 		return
 	}
 
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	details, err := s.cacheSet.Business.ListModules(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list modules with id in cache failed, err: %v", err)
 		return
 	}
-	ctx.RespStringArray(details)
+	ctx.RespStringArray(filterTenantDetails(ctx.Kit, details))
 }
 
 // ListSets list sets with id from cache, if not exist in cache, then get from mongodb directly.
@@ -217,12 +283,13 @@ func (s *cacheService) ListSetsInCache(ctx *rest.Contexts) {
 		return
 	}
 
+	opt.Fields = withTenantField(ctx.Kit, opt.Fields)
 	details, err := s.cacheSet.Business.ListSets(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "list sets with id in cache failed, err: %v", err)
 		return
 	}
-	ctx.RespStringArray(details)
+	ctx.RespStringArray(filterTenantDetails(ctx.Kit, details))
 }
 
 func (s *cacheService) SearchBusinessInCache(ctx *rest.Contexts) {
@@ -236,6 +303,10 @@ func (s *cacheService) SearchBusinessInCache(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search biz with id in cache, but get biz failed, err: %v", err)
 		return
 	}
+	if !isTenantDetail(ctx.Kit, biz) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search biz with id in cache, but biz not exist")
+		return
+	}
 	ctx.RespString(&biz)
 }
 
@@ -251,6 +322,10 @@ func (s *cacheService) SearchSetInCache(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search set with id in cache failed, err: %v", err)
 		return
 	}
+	if !isTenantDetail(ctx.Kit, set) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search set with id in cache, but set not exist")
+		return
+	}
 	ctx.RespString(&set)
 }
 
@@ -266,6 +341,10 @@ func (s *cacheService) SearchModuleInCache(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search module with id in cache failed, err: %v", err)
 		return
 	}
+	if !isTenantDetail(ctx.Kit, module) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search module with id in cache, but module not exist")
+		return
+	}
 	ctx.RespString(&module)
 }
 
@@ -283,6 +362,10 @@ func (s *cacheService) SearchCustomLayerInCache(ctx *rest.Contexts) {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search custom layer with id in cache failed, err: %v", err)
 		return
 	}
+	if !isTenantDetail(ctx.Kit, inst) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search custom layer with id in cache, but instance not exist")
+		return
+	}
 	ctx.RespString(&inst)
 }
 
@@ -308,6 +391,16 @@ func (s *cacheService) SearchBizTopologyNodePath(ctx *rest.Contexts) {
 
 	opt.Business = bizID
 
+	isTenant, err := s.isTenantBiz(ctx.Kit, bizID)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search biz topology node path failed, err: %v", err)
+		return
+	}
+	if !isTenant {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search biz topology node path, but biz %d not exist", bizID)
+		return
+	}
+
 	paths, err := s.cacheSet.Tree.SearchNodePath(ctx.Kit.Ctx, opt)
 	if err != nil {
 		ctx.RespAutoError(err)
@@ -327,6 +420,16 @@ func (s *cacheService) SearchBusinessBriefTopology(ctx *rest.Contexts) {
 		return
 	}
 
+	isTenant, err := s.isTenantBiz(ctx.Kit, bizID)
+	if err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search biz topology, select db failed, err: %v", err)
+		return
+	}
+	if !isTenant {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search biz topology, but biz %d not exist", bizID)
+		return
+	}
+
 	topo, err := s.cacheSet.Topology.GetBizTopology(ctx.Kit, bizID)
 	if err != nil {
 		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "search biz topology, select db failed, err: %v", err)
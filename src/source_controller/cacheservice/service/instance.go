@@ -0,0 +1,148 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/cacheservice/cache/instance"
+)
+
+func (s *cacheService) SearchInstWithIDInCache(ctx *rest.Contexts) {
+	opt := new(metadata.SearchInstWithIDOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	fields := withTenantField(ctx.Kit, opt.Fields)
+	inst, err := s.cacheSet.Instance.GetInstWithID(ctx.Kit.Ctx, opt.ObjID, opt.InstID, fields)
+	if err != nil {
+		respInstCacheError(ctx, err, "search %s instance with id in cache, but get instance failed, err: %v",
+			opt.ObjID, err)
+		return
+	}
+	if !isTenantDetail(ctx.Kit, inst) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search instance with id in cache, but instance not exist")
+		return
+	}
+	ctx.RespString(&inst)
+}
+
+// ListInstWithIDInCache list an object's instances from redis with instance id list.
+// like ListHostWithHostIDInCache, the returned array may not equal to the request ids length.
+func (s *cacheService) ListInstWithIDInCache(ctx *rest.Contexts) {
+	opt := new(metadata.ListInstWithIDOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	fields := withTenantField(ctx.Kit, opt.Fields)
+	insts, err := s.cacheSet.Instance.ListInstWithIDs(ctx.Kit.Ctx, opt.ObjID, opt.IDs, fields)
+	if err != nil {
+		respInstCacheError(ctx, err, "list %s instance with id in cache, but get instance failed, err: %v",
+			opt.ObjID, err)
+		return
+	}
+	ctx.RespStringArray(filterTenantDetails(ctx.Kit, insts))
+}
+
+func (s *cacheService) SearchInstWithUniqueInCache(ctx *rest.Contexts) {
+	opt := new(metadata.SearchInstWithUniqueOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	fields := withTenantField(ctx.Kit, opt.Fields)
+	inst, err := s.cacheSet.Instance.GetInstWithUnique(ctx.Kit.Ctx, opt.ObjID, opt.Unique, fields)
+	if err != nil {
+		respInstCacheError(ctx, err, "search %s instance with unique in cache, but get instance failed, err: %v",
+			opt.ObjID, err)
+		return
+	}
+	if !isTenantDetail(ctx.Kit, inst) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search instance with unique in cache, but instance not exist")
+		return
+	}
+	ctx.RespString(&inst)
+}
+
+func (s *cacheService) SearchInstAsstWithIDInCache(ctx *rest.Contexts) {
+	opt := new(metadata.SearchInstAsstWithIDOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	fields := withTenantField(ctx.Kit, opt.Fields)
+	asst, err := s.cacheSet.Instance.GetInstAsstWithID(ctx.Kit.Ctx, opt.ID, fields)
+	if err != nil {
+		respInstCacheError(ctx, err, "search instance association with id in cache, but get failed, err: %v", err)
+		return
+	}
+	if !isTenantDetail(ctx.Kit, asst) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search instance association with id in cache, but not exist")
+		return
+	}
+	ctx.RespString(&asst)
+}
+
+func (s *cacheService) ListInstAsstWithIDInCache(ctx *rest.Contexts) {
+	opt := new(metadata.ListWithIDOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	fields := withTenantField(ctx.Kit, opt.Fields)
+	assts, err := s.cacheSet.Instance.ListInstAsstWithIDs(ctx.Kit.Ctx, opt.IDs, fields)
+	if err != nil {
+		respInstCacheError(ctx, err, "list instance association with id in cache, but get failed, err: %v", err)
+		return
+	}
+	ctx.RespStringArray(filterTenantDetails(ctx.Kit, assts))
+}
+
+func (s *cacheService) SearchInstAsstWithUniqueInCache(ctx *rest.Contexts) {
+	opt := new(metadata.SearchInstAsstWithUniqueOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	fields := withTenantField(ctx.Kit, opt.Fields)
+	asst, err := s.cacheSet.Instance.GetInstAsstWithUnique(ctx.Kit.Ctx, opt.ObjAsstID, opt.InstID, opt.AsstInstID,
+		fields)
+	if err != nil {
+		respInstCacheError(ctx, err, "search instance association with unique in cache, but get failed, err: %v", err)
+		return
+	}
+	if !isTenantDetail(ctx.Kit, asst) {
+		ctx.RespErrorCodeOnly(common.CCErrCommNotFound, "search instance association with unique in cache, but not exist")
+		return
+	}
+	ctx.RespString(&asst)
+}
+
+// respInstCacheError response the instance cache's error, the request for an object which is not
+// opted into the instance cache is an invalid request.
+func respInstCacheError(ctx *rest.Contexts, err error, format string, args ...interface{}) {
+	if err == instance.ErrObjectNotCached {
+		ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, format, args...)
+		return
+	}
+	ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, format, args...)
+}
@@ -21,7 +21,9 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
+	"configcenter/src/common/openapi"
 	"configcenter/src/common/rdapi"
+	"configcenter/src/common/types"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/cacheservice/app/options"
 	"configcenter/src/source_controller/cacheservice/cache"
@@ -90,7 +92,11 @@ func (s *cacheService) SetConfig(cfg options.Config, engine *backbone.Engine, er
 		return dbErr
 	}
 
-	c, cacheErr := cacheop.NewCache(event, loopW, engine.ServiceManageInterface, watchDB)
+	c, cacheErr := cacheop.NewCache(event, loopW, engine.ServiceManageInterface, watchDB,
+		s.cfg.InstanceCacheObjects, s.cfg.HostSecondaryKeys, cacheop.VerifyOption{
+			IntervalMinutes: s.cfg.VerifyIntervalMinutes,
+			SampleSize:      s.cfg.VerifySampleSize,
+		})
 	if cacheErr != nil {
 		blog.Errorf("new cache instance failed, err: %v", cacheErr)
 		return cacheErr
@@ -135,6 +141,8 @@ func (s *cacheService) WebService() *restful.Container {
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
 	healthzAPI.Route(healthzAPI.GET("/healthz").To(s.Healthz))
+	healthzAPI.Route(healthzAPI.GET(openapi.Path).To(openapi.Handler(container,
+		openapi.BuildOption{Title: "cmdb cache service", Tag: types.CC_MODULE_CACHESERVICE})))
 	container.Add(healthzAPI)
 
 	return container
@@ -16,6 +16,9 @@ import (
 	"net/http"
 
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/watch"
+	"configcenter/src/source_controller/cacheservice/cache/topo_tree"
 
 	"github.com/emicklei/go-restful"
 )
@@ -26,39 +29,105 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Language: s.engine.Language,
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodPost,
-		Path:    "/find/cache/topo/topotree",
-		Handler: s.SearchTopologyTreeInCache,
+		Verb:     http.MethodPost,
+		Path:     "/find/cache/topo/topotree",
+		Handler:  s.SearchTopologyTreeInCache,
+		Request:  topo_tree.SearchOption{},
+		Response: []*topo_tree.Topology{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
 		Path:    "/find/cache/host/with_inner_ip",
 		Handler: s.SearchHostWithInnerIPInCache,
+		Request: metadata.SearchHostWithInnerIPOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
 		Path:    "/find/cache/host/with_host_id",
 		Handler: s.SearchHostWithHostIDInCache,
+		Request: metadata.SearchHostWithIDOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
 		Path:    "/findmany/cache/host/with_host_id",
 		Handler: s.ListHostWithHostIDInCache,
+		Request: metadata.ListWithIDOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
 		Path:    "/findmany/cache/host/with_page",
 		Handler: s.ListHostWithPageInCache,
+		Request: metadata.ListHostWithPage{},
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodGet,
-		Path:    "/find/cache/host/snapshot/{bk_host_id}",
-		Handler: s.GetHostSnap,
+		Verb:     http.MethodPost,
+		Path:     "/findmany/cache/host/with_secondary_keys",
+		Handler:  s.ListHostWithSecondaryKeysInCache,
+		Request:  metadata.ListHostWithSecondaryKeysOption{},
+		Response: []metadata.HostWithSecondaryKey{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:     http.MethodPost,
+		Path:     "/verify/cache",
+		Handler:  s.VerifyCache,
+		Request:  metadata.VerifyCacheOption{},
+		Response: metadata.VerifyCacheResult{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:     http.MethodPost,
+		Path:     "/rebuild/cache",
+		Handler:  s.RebuildCache,
+		Request:  metadata.RebuildCacheOption{},
+		Response: metadata.VerifyCacheResult{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/find/cache/instance/with_id",
+		Handler: s.SearchInstWithIDInCache,
+		Request: metadata.SearchInstWithIDOption{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/instance/with_id",
+		Handler: s.ListInstWithIDInCache,
+		Request: metadata.ListInstWithIDOption{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/find/cache/instance/with_unique",
+		Handler: s.SearchInstWithUniqueInCache,
+		Request: metadata.SearchInstWithUniqueOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
-		Path:    "/findmany/cache/host/snapshot/batch",
-		Handler: s.GetHostSnapBatch,
+		Path:    "/find/cache/inst_asst/with_id",
+		Handler: s.SearchInstAsstWithIDInCache,
+		Request: metadata.SearchInstAsstWithIDOption{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/findmany/cache/inst_asst/with_id",
+		Handler: s.ListInstAsstWithIDInCache,
+		Request: metadata.ListWithIDOption{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:    http.MethodPost,
+		Path:    "/find/cache/inst_asst/with_unique",
+		Handler: s.SearchInstAsstWithUniqueInCache,
+		Request: metadata.SearchInstAsstWithUniqueOption{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:     http.MethodGet,
+		Path:     "/find/cache/host/snapshot/{bk_host_id}",
+		Handler:  s.GetHostSnap,
+		Response: metadata.HostSnap{},
+	})
+	utility.AddHandler(rest.Action{
+		Verb:     http.MethodPost,
+		Path:     "/findmany/cache/host/snapshot/batch",
+		Handler:  s.GetHostSnapBatch,
+		Request:  metadata.HostSnapBatchInput{},
+		Response: map[int64]string{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
@@ -69,6 +138,7 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Verb:    http.MethodPost,
 		Path:    "/findmany/cache/biz",
 		Handler: s.ListBusinessInCache,
+		Request: metadata.ListWithIDOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
@@ -79,6 +149,7 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Verb:    http.MethodPost,
 		Path:    "/findmany/cache/set",
 		Handler: s.ListSetsInCache,
+		Request: metadata.ListWithIDOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
@@ -89,6 +160,7 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Verb:    http.MethodPost,
 		Path:    "/findmany/cache/module",
 		Handler: s.ListModulesInCache,
+		Request: metadata.ListWithIDOption{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodPost,
@@ -96,9 +168,11 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Handler: s.SearchCustomLayerInCache,
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodPost,
-		Path:    "find/cache/topo/node_path/biz/{bk_biz_id}",
-		Handler: s.SearchBizTopologyNodePath,
+		Verb:     http.MethodPost,
+		Path:     "find/cache/topo/node_path/biz/{bk_biz_id}",
+		Handler:  s.SearchBizTopologyNodePath,
+		Request:  topo_tree.SearchNodePathOption{},
+		Response: []topo_tree.NodePaths{},
 	})
 	utility.AddHandler(rest.Action{
 		Verb:    http.MethodGet,
@@ -106,24 +180,32 @@ func (s *cacheService) initCache(web *restful.WebService) {
 		Handler: s.SearchBusinessBriefTopology,
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodPost,
-		Path:    "/find/cache/event/latest",
-		Handler: s.GetLatestEvent,
+		Verb:     http.MethodPost,
+		Path:     "/find/cache/event/latest",
+		Handler:  s.GetLatestEvent,
+		Request:  metadata.GetLatestEventOption{},
+		Response: metadata.EventNode{},
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodPost,
-		Path:    "/findmany/cache/event/node/with_start_from",
-		Handler: s.SearchFollowingEventChainNodes,
+		Verb:     http.MethodPost,
+		Path:     "/findmany/cache/event/node/with_start_from",
+		Handler:  s.SearchFollowingEventChainNodes,
+		Request:  metadata.SearchEventNodesOption{},
+		Response: metadata.EventNodes{},
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodPost,
-		Path:    "/findmany/cache/event/detail",
-		Handler: s.SearchEventDetails,
+		Verb:     http.MethodPost,
+		Path:     "/findmany/cache/event/detail",
+		Handler:  s.SearchEventDetails,
+		Request:  metadata.SearchEventDetailsOption{},
+		Response: []string{},
 	})
 	utility.AddHandler(rest.Action{
-		Verb:    http.MethodPost,
-		Path:    "/watch/cache/event",
-		Handler: s.WatchEvent,
+		Verb:     http.MethodPost,
+		Path:     "/watch/cache/event",
+		Handler:  s.WatchEvent,
+		Request:  watch.WatchEventOptions{},
+		Response: watch.WatchResp{},
 	})
 
 	utility.AddToRestfulWebService(web)
@@ -0,0 +1,73 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/util"
+
+	"github.com/tidwall/gjson"
+)
+
+// withTenantField makes sure the supplier account field is returned with the details when the
+// details are cut with fields, so that the details can be filtered with tenant.
+func withTenantField(kit *rest.Kit, fields []string) []string {
+	if len(fields) == 0 || kit.SupplierAccount == common.BKSuperOwnerID {
+		return fields
+	}
+
+	if util.InStrArr(fields, common.BKOwnerIDField) {
+		return fields
+	}
+	return append(fields, common.BKOwnerIDField)
+}
+
+// isTenantDetail checks whether the cached json detail belongs to the request's supplier account.
+func isTenantDetail(kit *rest.Kit, detail string) bool {
+	if kit.SupplierAccount == common.BKSuperOwnerID {
+		return true
+	}
+
+	return gjson.Get(detail, common.BKOwnerIDField).String() == kit.SupplierAccount
+}
+
+// filterTenantDetails drops the cached json details which do not belong to the request's supplier account.
+func filterTenantDetails(kit *rest.Kit, details []string) []string {
+	if kit.SupplierAccount == common.BKSuperOwnerID {
+		return details
+	}
+
+	filtered := make([]string, 0, len(details))
+	for _, detail := range details {
+		if isTenantDetail(kit, detail) {
+			filtered = append(filtered, detail)
+		}
+	}
+	return filtered
+}
+
+// isTenantBiz checks whether the business belongs to the request's supplier account.
+func (s *cacheService) isTenantBiz(kit *rest.Kit, bizID int64) (bool, error) {
+	if kit.SupplierAccount == common.BKSuperOwnerID {
+		return true, nil
+	}
+
+	biz, err := s.cacheSet.Business.GetBusiness(bizID)
+	if err != nil {
+		blog.Errorf("get business %d from cache failed, err: %v, rid: %s", bizID, err, kit.Rid)
+		return false, err
+	}
+	return isTenantDetail(kit, biz), nil
+}
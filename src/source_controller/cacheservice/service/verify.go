@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	cacheop "configcenter/src/source_controller/cacheservice/cache"
+)
+
+// VerifyCache verify a resource's cache with the data in mongodb, and repair the drifted keys if required.
+func (s *cacheService) VerifyCache(ctx *rest.Contexts) {
+	opt := new(metadata.VerifyCacheOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Validate(); err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "verify cache, but request parameter is invalid: %v", err)
+		return
+	}
+
+	result, err := s.cacheSet.Verifier.Verify(ctx.Kit.Ctx, opt)
+	if err != nil {
+		if err == cacheop.ErrVerifyRunning {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommOPInProgressErr, "verify "+string(opt.Resource)))
+			return
+		}
+		ctx.RespErrorCodeOnly(common.CCErrCommDBSelectFailed, "verify %s cache failed, err: %v", opt.Resource, err)
+		return
+	}
+
+	blog.Infof("verify %s cache success, option: %+v, result: %+v, rid: %s", opt.Resource, *opt, *result, ctx.Kit.Rid)
+	ctx.RespEntity(result)
+}
+
+// RebuildCache drop a resource's cache and rebuild it with the data in mongodb.
+func (s *cacheService) RebuildCache(ctx *rest.Contexts) {
+	opt := new(metadata.RebuildCacheOption)
+	if err := ctx.DecodeInto(&opt); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := opt.Resource.Validate(); err != nil {
+		ctx.RespErrorCodeOnly(common.CCErrCommHTTPInputInvalid, "rebuild cache, but request parameter is invalid: %v", err)
+		return
+	}
+
+	result, err := s.cacheSet.Verifier.Rebuild(ctx.Kit.Ctx, opt.Resource)
+	if err != nil {
+		if err == cacheop.ErrVerifyRunning {
+			ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommOPInProgressErr, "rebuild "+string(opt.Resource)))
+			return
+		}
+		ctx.RespErrorCodeOnly(common.CCErrCommDBUpdateFailed, "rebuild %s cache failed, err: %v", opt.Resource, err)
+		return
+	}
+
+	ctx.RespEntity(result)
+}
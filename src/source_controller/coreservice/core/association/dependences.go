@@ -20,4 +20,6 @@ import "configcenter/src/common/http/rest"
 type OperationDependencies interface {
 	// IsInstanceExist used to check if the  instances exist
 	IsInstanceExist(kit *rest.Kit, objID string, instID uint64) (exists bool, err error)
+	// RecalculateInstances used to recalculate the calculated attributes of the instances
+	RecalculateInstances(kit *rest.Kit, objID string, instIDs []int64) error
 }
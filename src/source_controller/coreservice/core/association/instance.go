@@ -124,11 +124,17 @@ func (m *associationInstance) CreateOneInstanceAssociation(kit *rest.Kit, inputP
 		return nil, kit.CCError.Error(common.CCErrorInstToAsstIsNotExist)
 	}
 	id, err := m.save(kit, inputParam.Data)
-	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	if err != nil {
+		return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	}
+
+	m.recalculateAssociatedInstances(kit, []metadata.InstAsst{inputParam.Data})
+	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
 }
 
 func (m *associationInstance) CreateManyInstanceAssociation(kit *rest.Kit, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error) {
 	dataResult := &metadata.CreateManyDataResult{}
+	createdAssts := make([]metadata.InstAsst, 0)
 	for itemIdx, item := range inputParam.Datas {
 		item.OwnerID = kit.SupplierAccount
 		//check is exist
@@ -225,9 +231,10 @@ func (m *associationInstance) CreateManyInstanceAssociation(kit *rest.Kit, input
 		dataResult.Created = append(dataResult.Created, metadata.CreatedDataResult{
 			ID: id,
 		})
-
+		createdAssts = append(createdAssts, item)
 	}
 
+	m.recalculateAssociatedInstances(kit, createdAssts)
 	return dataResult, nil
 }
 
@@ -265,10 +272,39 @@ func (m *associationInstance) DeleteInstanceAssociation(kit *rest.Kit, inputPara
 		return &metadata.DeletedCount{}, err
 	}
 
+	// get the associations to be deleted, the instances on both sides need to be recalculated after deleted
+	deletedAssts := make([]metadata.InstAsst, 0)
+	err = mongodb.Client().Table(common.BKTableNameInstAsst).Find(inputParam.Condition).All(kit.Ctx, &deletedAssts)
+	if nil != err {
+		blog.Errorf("get inst association [%#v] to delete err [%#v], rid: %s", inputParam.Condition, err, kit.Rid)
+		return &metadata.DeletedCount{}, err
+	}
+
 	err = mongodb.Client().Table(common.BKTableNameInstAsst).Delete(kit.Ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete inst association [%#v] err [%#v], rid: %s", inputParam.Condition, err, kit.Rid)
 		return &metadata.DeletedCount{}, err
 	}
+
+	m.recalculateAssociatedInstances(kit, deletedAssts)
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
+
+// recalculateAssociatedInstances recalculate the instances on both sides of the associations, so that
+// the aggregation calculated attributes' values are up to date. the failed recalculation is retried by
+// a background task, it is only logged if the task can not be created either, because the association
+// has already been changed, the values can be corrected with the recalculate api then.
+func (m *associationInstance) recalculateAssociatedInstances(kit *rest.Kit, assts []metadata.InstAsst) {
+	objInstIDs := make(map[string][]int64)
+	for _, asst := range assts {
+		objInstIDs[asst.ObjectID] = append(objInstIDs[asst.ObjectID], asst.InstID)
+		objInstIDs[asst.AsstObjectID] = append(objInstIDs[asst.AsstObjectID], asst.AsstInstID)
+	}
+
+	for objID, instIDs := range objInstIDs {
+		if err := m.dependent.RecalculateInstances(kit, objID, util.IntArrayUnique(instIDs)); err != nil {
+			blog.Errorf("recalculate instances after association changed failed, obj: %s, inst ids: %v, err: %v, rid: %s",
+				objID, instIDs, err, kit.Rid)
+		}
+	}
+}
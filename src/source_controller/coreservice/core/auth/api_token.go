@@ -0,0 +1,199 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+// apiTokenUsedInterval is the min interval to update the last used time of an api token,
+// so that a busy token does not write the db on every request.
+const apiTokenUsedInterval = time.Minute
+
+// CreateAPIToken save an api token of the request user, the token name must be unique for the user
+func (a *authOperation) CreateAPIToken(kit *rest.Kit, option metadata.SaveAPITokenOption) (*metadata.APIToken,
+	errors.CCErrorCoder) {
+
+	if field := option.Validate(); field != "" {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	if len(option.TokenHash) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "token_hash")
+	}
+
+	nameFilter := map[string]interface{}{
+		common.BKFieldName:    option.Name,
+		"user":                kit.User,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	count, err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(nameFilter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, count failed, filter: %+v, err: %v, rid: %s", nameFilter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, common.BKFieldName)
+	}
+
+	scope := option.Scope
+	modelIDs, ccErr := a.getAPITokenModelIDs(kit, scope.ObjectIDs)
+	if ccErr != nil {
+		return nil, ccErr
+	}
+	scope.ModelIDs = modelIDs
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAPIToken)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	token := metadata.APIToken{
+		ID:              int64(id),
+		Name:            option.Name,
+		Description:     option.Description,
+		User:            kit.User,
+		TokenHash:       option.TokenHash,
+		TokenHint:       option.TokenHint,
+		Scope:           scope,
+		ExpireTime:      now.AddDate(0, 0, option.ValidDays),
+		CreateTime:      now,
+		SupplierAccount: kit.SupplierAccount,
+	}
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Insert(kit.Ctx, &token); err != nil {
+		blog.Errorf("CreateAPIToken failed, insert failed, name: %s, user: %s, err: %v, rid: %s", token.Name,
+			token.User, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &token, nil
+}
+
+// getAPITokenModelIDs get the ids of the models, all the models must exist
+func (a *authOperation) getAPITokenModelIDs(kit *rest.Kit, objIDs []string) ([]int64, errors.CCErrorCoder) {
+	if len(objIDs) == 0 {
+		return make([]int64, 0), nil
+	}
+
+	filter := map[string]interface{}{
+		common.BKObjIDField: map[string]interface{}{common.BKDBIN: objIDs},
+	}
+	models := make([]metadata.Object, 0)
+	if err := a.dbProxy.Table(common.BKTableNameObjDes).Find(filter).Fields(common.BKFieldID,
+		common.BKObjIDField).All(kit.Ctx, &models); err != nil {
+		blog.Errorf("get api token models failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	modelIDs := make([]int64, 0)
+	found := make(map[string]bool)
+	for _, model := range models {
+		modelIDs = append(modelIDs, model.ID)
+		found[model.ObjectID] = true
+	}
+	for _, objID := range objIDs {
+		if !found[objID] {
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "scope.bk_obj_ids")
+		}
+	}
+	return modelIDs, nil
+}
+
+// ListAPITokens list the api tokens of the request user
+func (a *authOperation) ListAPITokens(kit *rest.Kit, option metadata.ListAPITokensOption) (*metadata.MultipleAPIToken,
+	errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		"user":                kit.User,
+		common.BKOwnerIDField: kit.SupplierAccount,
+	}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+	if !option.WithRevoked {
+		filter["revoked"] = false
+	}
+
+	tokens := make([]metadata.APIToken, 0)
+	total, err := a.listRBACData(kit, common.BKTableNameAPIToken, filter, option.Page, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.MultipleAPIToken{Count: total, Info: tokens}, nil
+}
+
+// RevokeAPITokens revoke the api tokens of the request user, the revoked tokens are kept for auditing
+func (a *authOperation) RevokeAPITokens(kit *rest.Kit, option metadata.RevokeAPITokensOption) errors.CCErrorCoder {
+	if len(option.IDs) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	filter := map[string]interface{}{
+		common.BKFieldID:      map[string]interface{}{common.BKDBIN: option.IDs},
+		"user":                kit.User,
+		common.BKOwnerIDField: kit.SupplierAccount,
+		"revoked":             false,
+	}
+	doc := map[string]interface{}{
+		"revoked":     true,
+		"revoke_time": time.Now(),
+	}
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Update(kit.Ctx, filter, doc); err != nil {
+		blog.Errorf("RevokeAPITokens failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// VerifyAPIToken find the valid api token by its hash and record that the token is used
+func (a *authOperation) VerifyAPIToken(kit *rest.Kit, option metadata.VerifyAPITokenOption) (*metadata.APIToken,
+	errors.CCErrorCoder) {
+
+	if len(option.TokenHash) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "token_hash")
+	}
+
+	filter := map[string]interface{}{"token_hash": option.TokenHash}
+	token := metadata.APIToken{}
+	if err := a.dbProxy.Table(common.BKTableNameAPIToken).Find(filter).One(kit.Ctx, &token); err != nil {
+		if a.dbProxy.IsNotFoundError(err) {
+			return nil, kit.CCError.CCError(common.CCErrAPITokenInvalid)
+		}
+		blog.Errorf("VerifyAPIToken failed, find token failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	now := time.Now()
+	if token.Revoked || now.After(token.ExpireTime) {
+		return nil, kit.CCError.CCError(common.CCErrAPITokenInvalid)
+	}
+
+	if token.LastUsedTime == nil || now.Sub(*token.LastUsedTime) > apiTokenUsedInterval {
+		idFilter := map[string]interface{}{common.BKFieldID: token.ID}
+		doc := map[string]interface{}{"last_used_time": now}
+		if err := a.dbProxy.Table(common.BKTableNameAPIToken).Update(kit.Ctx, idFilter, doc); err != nil {
+			// the token is still valid even if the used time is not recorded
+			blog.Errorf("VerifyAPIToken update last used time failed, id: %d, err: %v, rid: %s", token.ID, err,
+				kit.Rid)
+		} else {
+			token.LastUsedTime = &now
+		}
+	}
+	return &token, nil
+}
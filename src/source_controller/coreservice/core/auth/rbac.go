@@ -0,0 +1,537 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/types"
+)
+
+// CreateAuthRole create a role, the role name must be unique
+func (a *authOperation) CreateAuthRole(kit *rest.Kit, role metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder) {
+	if field := role.Validate(); field != "" {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	if err := a.checkNameUnique(kit, common.BKTableNameAuthRole, 0, role.Name); err != nil {
+		return nil, err
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAuthRole)
+	if err != nil {
+		blog.Errorf("CreateAuthRole failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	role.ID = int64(id)
+	role.Creator = kit.User
+	role.Modifier = kit.User
+	role.CreateTime = now
+	role.LastTime = now
+	role.SupplierAccount = kit.SupplierAccount
+
+	if err := a.dbProxy.Table(common.BKTableNameAuthRole).Insert(kit.Ctx, &role); err != nil {
+		blog.Errorf("CreateAuthRole failed, insert failed, role: %+v, err: %v, rid: %s", role, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &role, nil
+}
+
+// UpdateAuthRole update the name, description and actions of a role
+func (a *authOperation) UpdateAuthRole(kit *rest.Kit, id int64, role metadata.AuthRole) (*metadata.AuthRole,
+	errors.CCErrorCoder) {
+
+	if field := role.Validate(); field != "" {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{common.BKFieldID: id}
+	origin := metadata.AuthRole{}
+	if err := a.dbProxy.Table(common.BKTableNameAuthRole).Find(filter).One(kit.Ctx, &origin); err != nil {
+		if a.dbProxy.IsNotFoundError(err) {
+			return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("UpdateAuthRole failed, get role failed, id: %d, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if err := a.checkNameUnique(kit, common.BKTableNameAuthRole, id, role.Name); err != nil {
+		return nil, err
+	}
+
+	origin.Name = role.Name
+	origin.Description = role.Description
+	origin.Actions = role.Actions
+	origin.Modifier = kit.User
+	origin.LastTime = time.Now()
+	if err := a.dbProxy.Table(common.BKTableNameAuthRole).Update(kit.Ctx, filter, &origin); err != nil {
+		blog.Errorf("UpdateAuthRole failed, update failed, role: %+v, err: %v, rid: %s", origin, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return &origin, nil
+}
+
+// DeleteAuthRoles delete roles, a role that is still bound can not be deleted
+func (a *authOperation) DeleteAuthRoles(kit *rest.Kit, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	if len(option.IDs) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	bindingFilter := map[string]interface{}{"role_id": map[string]interface{}{common.BKDBIN: option.IDs}}
+	if err := a.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(bindingFilter).Fields("role_id").Limit(1).
+		All(kit.Ctx, &bindings); err != nil {
+		blog.Errorf("DeleteAuthRoles failed, find bindings failed, filter: %+v, err: %v, rid: %s", bindingFilter, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(bindings) > 0 {
+		return kit.CCError.CCErrorf(common.CCErrCoreServiceAuthRoleInUse, bindings[0].RoleID)
+	}
+
+	filter := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: option.IDs}}
+	if err := a.dbProxy.Table(common.BKTableNameAuthRole).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("DeleteAuthRoles failed, delete failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func (a *authOperation) ListAuthRoles(kit *rest.Kit, option metadata.ListAuthRolesOption) (*metadata.MultipleAuthRole,
+	errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+	if len(option.Names) > 0 {
+		filter[common.BKFieldName] = map[string]interface{}{common.BKDBIN: option.Names}
+	}
+
+	roles := make([]metadata.AuthRole, 0)
+	total, err := a.listRBACData(kit, common.BKTableNameAuthRole, filter, option.Page, &roles)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.MultipleAuthRole{Count: total, Info: roles}, nil
+}
+
+// CreateAuthRoleBinding bind a role to a user or user group, the model id of the scope is filled by its object id
+func (a *authOperation) CreateAuthRoleBinding(kit *rest.Kit, binding metadata.AuthRoleBinding) (
+	*metadata.AuthRoleBinding, errors.CCErrorCoder) {
+
+	if field := binding.Validate(); field != "" {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	roleFilter := map[string]interface{}{common.BKFieldID: binding.RoleID}
+	count, err := a.dbProxy.Table(common.BKTableNameAuthRole).Find(roleFilter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, count role failed, id: %d, err: %v, rid: %s", binding.RoleID, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "role_id")
+	}
+
+	binding.Scope.ModelID = 0
+	if len(binding.Scope.ObjectID) > 0 {
+		model := metadata.Object{}
+		modelFilter := map[string]interface{}{common.BKObjIDField: binding.Scope.ObjectID}
+		if err := a.dbProxy.Table(common.BKTableNameObjDes).Find(modelFilter).Fields(common.BKFieldID).
+			One(kit.Ctx, &model); err != nil {
+			if a.dbProxy.IsNotFoundError(err) {
+				return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "scope.bk_obj_id")
+			}
+			blog.Errorf("CreateAuthRoleBinding failed, get model failed, filter: %+v, err: %v, rid: %s", modelFilter,
+				err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		binding.Scope.ModelID = model.ID
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAuthRoleBinding)
+	if err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+	binding.ID = int64(id)
+	binding.Creator = kit.User
+	binding.CreateTime = time.Now()
+	binding.SupplierAccount = kit.SupplierAccount
+
+	if err := a.dbProxy.Table(common.BKTableNameAuthRoleBinding).Insert(kit.Ctx, &binding); err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, insert failed, binding: %+v, err: %v, rid: %s", binding, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &binding, nil
+}
+
+func (a *authOperation) DeleteAuthRoleBindings(kit *rest.Kit, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	return a.deleteRBACData(kit, common.BKTableNameAuthRoleBinding, option)
+}
+
+func (a *authOperation) ListAuthRoleBindings(kit *rest.Kit, option metadata.ListAuthRoleBindingsOption) (
+	*metadata.MultipleAuthRoleBinding, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+	if len(option.RoleIDs) > 0 {
+		filter["role_id"] = map[string]interface{}{common.BKDBIN: option.RoleIDs}
+	}
+	if len(option.Subjects) > 0 {
+		filter["subject"] = map[string]interface{}{common.BKDBIN: option.Subjects}
+	}
+
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	total, err := a.listRBACData(kit, common.BKTableNameAuthRoleBinding, filter, option.Page, &bindings)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.MultipleAuthRoleBinding{Count: total, Info: bindings}, nil
+}
+
+// CreateAuthUserGroup create a user group, the group name must be unique
+func (a *authOperation) CreateAuthUserGroup(kit *rest.Kit, group metadata.AuthUserGroup) (*metadata.AuthUserGroup,
+	errors.CCErrorCoder) {
+
+	if field := group.Validate(); field != "" {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+	if err := a.checkNameUnique(kit, common.BKTableNameAuthUserGroup, 0, group.Name); err != nil {
+		return nil, err
+	}
+
+	id, err := a.dbProxy.NextSequence(kit.Ctx, common.BKTableNameAuthUserGroup)
+	if err != nil {
+		blog.Errorf("CreateAuthUserGroup failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	group.ID = int64(id)
+	if group.Members == nil {
+		group.Members = make([]string, 0)
+	}
+	group.Creator = kit.User
+	group.Modifier = kit.User
+	group.CreateTime = now
+	group.LastTime = now
+	group.SupplierAccount = kit.SupplierAccount
+
+	if err := a.dbProxy.Table(common.BKTableNameAuthUserGroup).Insert(kit.Ctx, &group); err != nil {
+		blog.Errorf("CreateAuthUserGroup failed, insert failed, group: %+v, err: %v, rid: %s", group, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &group, nil
+}
+
+// UpdateAuthUserGroup update the name, description and members of a user group.
+// the role bindings of the group are bound by group name, so they are renamed along with the group.
+func (a *authOperation) UpdateAuthUserGroup(kit *rest.Kit, id int64, group metadata.AuthUserGroup) (
+	*metadata.AuthUserGroup, errors.CCErrorCoder) {
+
+	if field := group.Validate(); field != "" {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{common.BKFieldID: id}
+	origin := metadata.AuthUserGroup{}
+	if err := a.dbProxy.Table(common.BKTableNameAuthUserGroup).Find(filter).One(kit.Ctx, &origin); err != nil {
+		if a.dbProxy.IsNotFoundError(err) {
+			return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+		}
+		blog.Errorf("UpdateAuthUserGroup failed, get group failed, id: %d, err: %v, rid: %s", id, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if err := a.checkNameUnique(kit, common.BKTableNameAuthUserGroup, id, group.Name); err != nil {
+		return nil, err
+	}
+
+	if origin.Name != group.Name {
+		bindingFilter := map[string]interface{}{
+			"subject_type": metadata.AuthSubjectGroup,
+			"subject":      origin.Name,
+		}
+		doc := map[string]interface{}{"subject": group.Name}
+		if err := a.dbProxy.Table(common.BKTableNameAuthRoleBinding).Update(kit.Ctx, bindingFilter, doc); err != nil {
+			blog.Errorf("UpdateAuthUserGroup failed, rename bindings failed, filter: %+v, err: %v, rid: %s",
+				bindingFilter, err, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+		}
+	}
+
+	origin.Name = group.Name
+	origin.Description = group.Description
+	origin.Members = group.Members
+	if origin.Members == nil {
+		origin.Members = make([]string, 0)
+	}
+	origin.Modifier = kit.User
+	origin.LastTime = time.Now()
+	if err := a.dbProxy.Table(common.BKTableNameAuthUserGroup).Update(kit.Ctx, filter, &origin); err != nil {
+		blog.Errorf("UpdateAuthUserGroup failed, update failed, group: %+v, err: %v, rid: %s", origin, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return &origin, nil
+}
+
+// AddAuthUserGroupMembers add the members to the user groups with $addToSet, the groups that already
+// contain all the members are not updated.
+func (a *authOperation) AddAuthUserGroupMembers(kit *rest.Kit,
+	option metadata.AuthUserGroupMembersOption) errors.CCErrorCoder {
+
+	if field := option.Validate(); field != "" {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{
+		common.BKFieldName: map[string]interface{}{common.BKDBIN: option.Names},
+		"members": map[string]interface{}{
+			common.BKDBNot: map[string]interface{}{common.BKDBAll: option.Members},
+		},
+	}
+	return a.updateAuthUserGroupMembers(kit, filter, types.ModeUpdate{
+		Op:  "addToSet",
+		Doc: map[string]interface{}{"members": map[string]interface{}{"$each": option.Members}},
+	})
+}
+
+// RemoveAuthUserGroupMembers remove the members from the user groups with $pull, the groups that contain
+// none of the members are not updated.
+func (a *authOperation) RemoveAuthUserGroupMembers(kit *rest.Kit,
+	option metadata.AuthUserGroupMembersOption) errors.CCErrorCoder {
+
+	if field := option.Validate(); field != "" {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, field)
+	}
+
+	filter := map[string]interface{}{
+		common.BKFieldName: map[string]interface{}{common.BKDBIN: option.Names},
+		"members":          map[string]interface{}{common.BKDBIN: option.Members},
+	}
+	return a.updateAuthUserGroupMembers(kit, filter, types.ModeUpdate{
+		Op:  "pull",
+		Doc: map[string]interface{}{"members": map[string]interface{}{common.BKDBIN: option.Members}},
+	})
+}
+
+func (a *authOperation) updateAuthUserGroupMembers(kit *rest.Kit, filter map[string]interface{},
+	update types.ModeUpdate) errors.CCErrorCoder {
+
+	set := types.ModeUpdate{
+		Op:  "set",
+		Doc: map[string]interface{}{common.ModifierField: kit.User, common.LastTimeField: time.Now()},
+	}
+	if err := a.dbProxy.Table(common.BKTableNameAuthUserGroup).UpdateMultiModel(kit.Ctx, filter, update,
+		set); err != nil {
+		blog.Errorf("update user group members failed, filter: %+v, update: %+v, err: %v, rid: %s", filter, update,
+			err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// DeleteAuthUserGroups delete user groups and the role bindings of them
+func (a *authOperation) DeleteAuthUserGroups(kit *rest.Kit, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	if len(option.IDs) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	groups := make([]metadata.AuthUserGroup, 0)
+	filter := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: option.IDs}}
+	if err := a.dbProxy.Table(common.BKTableNameAuthUserGroup).Find(filter).Fields(common.BKFieldName).
+		All(kit.Ctx, &groups); err != nil {
+		blog.Errorf("DeleteAuthUserGroups failed, find groups failed, filter: %+v, err: %v, rid: %s", filter, err,
+			kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(groups) > 0 {
+		names := make([]string, len(groups))
+		for index, group := range groups {
+			names[index] = group.Name
+		}
+		bindingFilter := map[string]interface{}{
+			"subject_type": metadata.AuthSubjectGroup,
+			"subject":      map[string]interface{}{common.BKDBIN: names},
+		}
+		if err := a.dbProxy.Table(common.BKTableNameAuthRoleBinding).Delete(kit.Ctx, bindingFilter); err != nil {
+			blog.Errorf("DeleteAuthUserGroups failed, delete bindings failed, filter: %+v, err: %v, rid: %s",
+				bindingFilter, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+		}
+	}
+
+	return a.deleteRBACData(kit, common.BKTableNameAuthUserGroup, option)
+}
+
+func (a *authOperation) ListAuthUserGroups(kit *rest.Kit, option metadata.ListAuthUserGroupsOption) (
+	*metadata.MultipleAuthUserGroup, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+	if len(option.Names) > 0 {
+		filter[common.BKFieldName] = map[string]interface{}{common.BKDBIN: option.Names}
+	}
+	if len(option.Members) > 0 {
+		filter["members"] = map[string]interface{}{common.BKDBIN: option.Members}
+	}
+
+	groups := make([]metadata.AuthUserGroup, 0)
+	total, err := a.listRBACData(kit, common.BKTableNameAuthUserGroup, filter, option.Page, &groups)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata.MultipleAuthUserGroup{Count: total, Info: groups}, nil
+}
+
+// GetAuthPolicies resolve the policies of a user from the role bindings of the user and the user's groups
+func (a *authOperation) GetAuthPolicies(kit *rest.Kit, option metadata.GetAuthPoliciesOption) ([]metadata.AuthPolicy,
+	errors.CCErrorCoder) {
+
+	if len(option.UserName) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "user_name")
+	}
+
+	groups := make([]metadata.AuthUserGroup, 0)
+	groupFilter := map[string]interface{}{"members": option.UserName}
+	if err := a.dbProxy.Table(common.BKTableNameAuthUserGroup).Find(groupFilter).Fields(common.BKFieldName).
+		All(kit.Ctx, &groups); err != nil {
+		blog.Errorf("GetAuthPolicies failed, find groups failed, filter: %+v, err: %v, rid: %s", groupFilter, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	subjectFilters := []map[string]interface{}{{
+		"subject_type": metadata.AuthSubjectUser,
+		"subject":      option.UserName,
+	}}
+	if len(groups) > 0 {
+		names := make([]string, len(groups))
+		for index, group := range groups {
+			names[index] = group.Name
+		}
+		subjectFilters = append(subjectFilters, map[string]interface{}{
+			"subject_type": metadata.AuthSubjectGroup,
+			"subject":      map[string]interface{}{common.BKDBIN: names},
+		})
+	}
+
+	bindings := make([]metadata.AuthRoleBinding, 0)
+	bindingFilter := map[string]interface{}{common.BKDBOR: subjectFilters}
+	if err := a.dbProxy.Table(common.BKTableNameAuthRoleBinding).Find(bindingFilter).All(kit.Ctx, &bindings); err != nil {
+		blog.Errorf("GetAuthPolicies failed, find bindings failed, filter: %+v, err: %v, rid: %s", bindingFilter, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if len(bindings) == 0 {
+		return make([]metadata.AuthPolicy, 0), nil
+	}
+
+	roleIDs := make([]int64, 0)
+	for _, binding := range bindings {
+		roleIDs = append(roleIDs, binding.RoleID)
+	}
+	roles := make([]metadata.AuthRole, 0)
+	roleFilter := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: roleIDs}}
+	if err := a.dbProxy.Table(common.BKTableNameAuthRole).Find(roleFilter).All(kit.Ctx, &roles); err != nil {
+		blog.Errorf("GetAuthPolicies failed, find roles failed, filter: %+v, err: %v, rid: %s", roleFilter, err,
+			kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	roleActions := make(map[int64][]string)
+	for _, role := range roles {
+		roleActions[role.ID] = role.Actions
+	}
+
+	policies := make([]metadata.AuthPolicy, 0)
+	for _, binding := range bindings {
+		actions, exist := roleActions[binding.RoleID]
+		if !exist {
+			continue
+		}
+		policies = append(policies, metadata.AuthPolicy{
+			Actions: actions,
+			Scope:   binding.Scope,
+		})
+	}
+	return policies, nil
+}
+
+// checkNameUnique check if the name is used by another role or user group except the one with the id
+func (a *authOperation) checkNameUnique(kit *rest.Kit, table string, id int64, name string) errors.CCErrorCoder {
+	filter := map[string]interface{}{common.BKFieldName: name}
+	if id > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBNE: id}
+	}
+	count, err := a.dbProxy.Table(table).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("check name unique failed, table: %s, filter: %+v, err: %v, rid: %s", table, filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count > 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, common.BKFieldName)
+	}
+	return nil
+}
+
+func (a *authOperation) listRBACData(kit *rest.Kit, table string, filter map[string]interface{},
+	page metadata.BasePage, result interface{}) (uint64, errors.CCErrorCoder) {
+
+	if page.IsIllegal() {
+		return 0, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "page.limit")
+	}
+
+	total, err := a.dbProxy.Table(table).Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("list rbac data failed, count failed, table: %s, filter: %+v, err: %v, rid: %s", table, filter, err,
+			kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := common.BKFieldID
+	if len(page.Sort) > 0 {
+		sort = page.Sort
+	}
+	if err := a.dbProxy.Table(table).Find(filter).Start(uint64(page.Start)).Limit(uint64(page.Limit)).Sort(sort).
+		All(kit.Ctx, result); err != nil {
+		blog.Errorf("list rbac data failed, find failed, table: %s, filter: %+v, err: %v, rid: %s", table, filter, err,
+			kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return total, nil
+}
+
+func (a *authOperation) deleteRBACData(kit *rest.Kit, table string, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder {
+	if len(option.IDs) == 0 {
+		return kit.CCError.CCErrorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	filter := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: option.IDs}}
+	if err := a.dbProxy.Table(table).Delete(kit.Ctx, filter); err != nil {
+		blog.Errorf("delete rbac data failed, table: %s, filter: %+v, err: %v, rid: %s", table, filter, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
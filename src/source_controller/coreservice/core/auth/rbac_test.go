@@ -0,0 +1,87 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package auth
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestAuthUserGroupMembers(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../../../resources/errors/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kit := &rest.Kit{
+		Rid:             "test_rid",
+		Ctx:             context.Background(),
+		CCError:         errFactory.CreateDefaultCCErrorIf("en"),
+		User:            "login",
+		SupplierAccount: common.BKDefaultOwnerID,
+	}
+
+	db := memory.NewMemory()
+	groups := []map[string]interface{}{
+		{common.BKFieldID: 1, common.BKFieldName: "dev", "members": []string{"alice"},
+			common.ModifierField: "admin"},
+		{common.BKFieldID: 2, common.BKFieldName: "ops", "members": []string{"alice", "bob"},
+			common.ModifierField: "admin"},
+	}
+	if err := db.Table(common.BKTableNameAuthUserGroup).Insert(kit.Ctx, groups); err != nil {
+		t.Fatal(err)
+	}
+	op := &authOperation{dbProxy: db}
+
+	getGroup := func(name string) metadata.AuthUserGroup {
+		group := metadata.AuthUserGroup{}
+		if err := db.Table(common.BKTableNameAuthUserGroup).Find(map[string]interface{}{
+			common.BKFieldName: name}).One(kit.Ctx, &group); err != nil {
+			t.Fatal(err)
+		}
+		sort.Strings(group.Members)
+		return group
+	}
+
+	option := metadata.AuthUserGroupMembersOption{Names: []string{"dev", "ops"}, Members: []string{"bob"}}
+	if err := op.AddAuthUserGroupMembers(kit, option); err != nil {
+		t.Fatalf("add members failed, err: %v", err)
+	}
+	if dev := getGroup("dev"); len(dev.Members) != 2 || dev.Members[1] != "bob" || dev.Modifier != "login" {
+		t.Fatalf("bob should be added to dev, got: %+v", dev)
+	}
+	if ops := getGroup("ops"); len(ops.Members) != 2 || ops.Modifier != "admin" {
+		t.Fatalf("ops already contains bob and should not be updated, got: %+v", ops)
+	}
+
+	option = metadata.AuthUserGroupMembersOption{Names: []string{"ops"}, Members: []string{"alice"}}
+	if err := op.RemoveAuthUserGroupMembers(kit, option); err != nil {
+		t.Fatalf("remove members failed, err: %v", err)
+	}
+	if ops := getGroup("ops"); len(ops.Members) != 1 || ops.Members[0] != "bob" {
+		t.Fatalf("alice should be removed from ops, got: %+v", ops)
+	}
+	if dev := getGroup("dev"); len(dev.Members) != 2 {
+		t.Fatalf("dev should not be changed, got: %+v", dev)
+	}
+
+	if err := op.AddAuthUserGroupMembers(kit, metadata.AuthUserGroupMembersOption{Names: []string{"dev"}}); err == nil {
+		t.Fatalf("add members without members should fail")
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	hostutil "configcenter/src/source_controller/coreservice/core/host/util"
+	"configcenter/src/storage/dal/types"
 )
 
 func (c *cloudOperation) CreateSyncTask(kit *rest.Kit, task *metadata.CloudSyncTask) (*metadata.CloudSyncTask, errors.CCErrorCoder) {
@@ -208,6 +210,10 @@ func (c *cloudOperation) getSyncTaskCloudVendor(kit *rest.Kit, accountID int64)
 }
 
 func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metadata.DeleteDestroyedHostRelatedOption) errors.CCErrorCoder {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeAttribute, option.HostIDs); err != nil {
+		return err
+	}
+
 	// update destroyed host
 	updateHostCond := map[string]interface{}{
 		common.BKHostIDField: map[string]interface{}{
@@ -222,7 +228,14 @@ func (c *cloudOperation) DeleteDestroyedHostRelated(kit *rest.Kit, option *metad
 		common.LastTimeField:          time.Now(),
 		common.BKLastEditor:           kit.User,
 	}
-	err := c.dbProxy.Table(common.BKTableNameBaseHost).Update(kit.Ctx, updateHostCond, updateHostData)
+	revision := types.ModeUpdate{Op: "inc", Doc: mapstr.MapStr{common.BKRevisionField: 1}}
+	err := c.dbProxy.Table(common.BKTableNameBaseHost).UpdateMultiModel(kit.Ctx, updateHostCond,
+		types.ModeUpdate{Op: "set", Doc: updateHostData}, revision)
+	if err != nil {
+		blog.ErrorJSON("DeleteDestroyedHostRelated failed, update destroyed hosts err:%s, filter: %#v, rid: %s", err,
+			updateHostCond, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommDBUpdateFailed)
+	}
 
 	// get all service instance IDs that need to be removed
 	serviceInstanceFilter := map[string]interface{}{
@@ -80,6 +80,9 @@ type ModelOperation interface {
 	CascadeDeleteModel(kit *rest.Kit, modelID int64) (*metadata.DeletedCount, error)
 	SearchModel(kit *rest.Kit, inputParam metadata.QueryCondition) (*metadata.QueryModelDataResult, error)
 	SearchModelWithAttribute(kit *rest.Kit, inputParam metadata.QueryCondition) (*metadata.QueryModelWithAttributeDataResult, error)
+	SearchModelSchemaVersions(kit *rest.Kit, objID string, inputParam metadata.QueryCondition) (*metadata.QueryModelSchemaVersionResult, error)
+	DiffModelSchema(kit *rest.Kit, objID string, inputParam metadata.DiffModelSchemaOption) (*metadata.ModelSchemaDiff, error)
+	RollbackModelSchema(kit *rest.Kit, objID string, inputParam metadata.RollbackModelSchemaOption) (*metadata.ModelSchemaDiff, error)
 }
 
 // InstanceOperation instance methods
@@ -90,6 +93,11 @@ type InstanceOperation interface {
 	SearchModelInstance(kit *rest.Kit, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	RecalculateModelInstance(kit *rest.Kit, objID string, inputParam metadata.RecalculateModelInstance) (*metadata.UpdatedCount, error)
+	RecalculateModelInstanceTask(kit *rest.Kit, task metadata.RecalculateModelInstanceTask) error
+	DeferRecalculateModelInstance(kit *rest.Kit, objID string, instIDs []int64) error
+	RecalculateOrDeferModelInstance(kit *rest.Kit, objID string, instIDs []int64) error
+	HandleInstRefBeforeDelete(kit *rest.Kit, objID string, instIDs []int64) error
 }
 
 // AssociationKind association kind methods
@@ -154,6 +162,8 @@ type HostOperation interface {
 	LockHost(kit *rest.Kit, input *metadata.HostLockRequest) errors.CCError
 	UnlockHost(kit *rest.Kit, input *metadata.HostLockRequest) errors.CCError
 	QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLockRequest) ([]metadata.HostLockData, errors.CCError)
+	ListHostLocks(kit *rest.Kit, input *metadata.ListHostLocksOption) (int64, []metadata.HostLockData, errors.CCErrorCoder)
+	ExpireHostLocks(kit *rest.Kit, input *metadata.ExpireHostLocksOption) (uint64, errors.CCErrorCoder)
 
 	// host search
 	ListHosts(kit *rest.Kit, input metadata.ListHosts) (*metadata.ListHostResult, error)
@@ -227,6 +237,13 @@ type ProcessOperation interface {
 	ListServiceTemplates(kit *rest.Kit, option metadata.ListServiceTemplateOption) (*metadata.MultipleServiceTemplate, errors.CCErrorCoder)
 	DeleteServiceTemplate(kit *rest.Kit, serviceTemplateID int64) errors.CCErrorCoder
 
+	// service template version and rollout
+	CreateServiceTemplateVersion(kit *rest.Kit, bizID int64, serviceTemplateID int64) (*metadata.ServiceTemplateVersion, errors.CCErrorCoder)
+	ListServiceTemplateVersions(kit *rest.Kit, option metadata.ListServiceTemplateVersionsOption) (*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder)
+	CreateServiceTemplateRollout(kit *rest.Kit, rollout metadata.ServiceTemplateRollout) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	UpdateServiceTemplateRollout(kit *rest.Kit, option metadata.UpdateServiceTemplateRolloutOption) (*metadata.ServiceTemplateRollout, errors.CCErrorCoder)
+	ListServiceTemplateRollouts(kit *rest.Kit, option metadata.ListServiceTemplateRolloutsOption) (*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder)
+
 	// process template
 	CreateProcessTemplate(kit *rest.Kit, template metadata.ProcessTemplate) (*metadata.ProcessTemplate, errors.CCErrorCoder)
 	GetProcessTemplate(kit *rest.Kit, templateID int64) (*metadata.ProcessTemplate, errors.CCErrorCoder)
@@ -309,10 +326,36 @@ type CloudOperation interface {
 type SystemOperation interface {
 	GetSystemUserConfig(kit *rest.Kit) (map[string]interface{}, errors.CCErrorCoder)
 	SearchConfigAdmin(kit *rest.Kit) (*metadata.ConfigAdmin, errors.CCErrorCoder)
+	SearchTenants(kit *rest.Kit, option *metadata.ListTenantsOption) (*metadata.MultipleTenant, errors.CCErrorCoder)
 }
 
 type AuthOperation interface {
 	SearchAuthResource(kit *rest.Kit, param metadata.PullResourceParam) (int64, []map[string]interface{}, errors.CCErrorCoder)
+
+	// built-in rbac authorization
+	CreateAuthRole(kit *rest.Kit, role metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder)
+	UpdateAuthRole(kit *rest.Kit, id int64, role metadata.AuthRole) (*metadata.AuthRole, errors.CCErrorCoder)
+	DeleteAuthRoles(kit *rest.Kit, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder
+	ListAuthRoles(kit *rest.Kit, option metadata.ListAuthRolesOption) (*metadata.MultipleAuthRole, errors.CCErrorCoder)
+	CreateAuthRoleBinding(kit *rest.Kit, binding metadata.AuthRoleBinding) (*metadata.AuthRoleBinding, errors.CCErrorCoder)
+	DeleteAuthRoleBindings(kit *rest.Kit, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder
+	ListAuthRoleBindings(kit *rest.Kit, option metadata.ListAuthRoleBindingsOption) (*metadata.MultipleAuthRoleBinding,
+		errors.CCErrorCoder)
+	CreateAuthUserGroup(kit *rest.Kit, group metadata.AuthUserGroup) (*metadata.AuthUserGroup, errors.CCErrorCoder)
+	UpdateAuthUserGroup(kit *rest.Kit, id int64, group metadata.AuthUserGroup) (*metadata.AuthUserGroup,
+		errors.CCErrorCoder)
+	DeleteAuthUserGroups(kit *rest.Kit, option metadata.DeleteAuthRBACOption) errors.CCErrorCoder
+	ListAuthUserGroups(kit *rest.Kit, option metadata.ListAuthUserGroupsOption) (*metadata.MultipleAuthUserGroup,
+		errors.CCErrorCoder)
+	AddAuthUserGroupMembers(kit *rest.Kit, option metadata.AuthUserGroupMembersOption) errors.CCErrorCoder
+	RemoveAuthUserGroupMembers(kit *rest.Kit, option metadata.AuthUserGroupMembersOption) errors.CCErrorCoder
+	GetAuthPolicies(kit *rest.Kit, option metadata.GetAuthPoliciesOption) ([]metadata.AuthPolicy, errors.CCErrorCoder)
+
+	// personal api tokens
+	CreateAPIToken(kit *rest.Kit, option metadata.SaveAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder)
+	ListAPITokens(kit *rest.Kit, option metadata.ListAPITokensOption) (*metadata.MultipleAPIToken, errors.CCErrorCoder)
+	RevokeAPITokens(kit *rest.Kit, option metadata.RevokeAPITokensOption) errors.CCErrorCoder
+	VerifyAPIToken(kit *rest.Kit, option metadata.VerifyAPITokenOption) (*metadata.APIToken, errors.CCErrorCoder)
 }
 
 type EventOperation interface {
@@ -18,6 +18,7 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -30,6 +31,7 @@ type associationFindData struct {
 	dataType     metadata.SynchronizeOperateDataType
 	start        uint64
 	limit        uint64
+	lastID       uint64
 	condition    mapstr.MapStr
 }
 
@@ -40,6 +42,7 @@ func NewSynchronizeFindAdapter(input *metadata.SynchronizeFindInfoParameter) ass
 		dataType:     input.DataType,
 		start:        input.Start,
 		limit:        input.Limit,
+		lastID:       input.LastID,
 		condition:    input.Condition,
 	}
 }
@@ -71,10 +74,32 @@ func (a *associationFindData) findModel(kit *rest.Kit) ([]mapstr.MapStr, uint64,
 
 func (a *associationFindData) dbQueryModel(kit *rest.Kit, tableName string) ([]mapstr.MapStr, uint64, errors.CCError) {
 	info := make([]mapstr.MapStr, 0)
-	err := mongodb.Client().Table(tableName).Find(a.condition).Start(a.start).Limit(a.limit).All(kit.Ctx, &info)
-	if err != nil {
-		blog.Errorf("dbQueryModel info error. error:%s,rid:%s", err.Error(), kit.Rid)
-		return nil, 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	if a.start > 0 && a.lastID == 0 {
+		err := mongodb.Client().Table(tableName).Find(a.condition).Start(a.start).Limit(a.limit).All(kit.Ctx, &info)
+		if err != nil {
+			blog.Errorf("dbQueryModel info error. error:%s,rid:%s", err.Error(), kit.Rid)
+			return nil, 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+		}
+	} else {
+		// page with the id field instead of skipping the previous data
+		iter := types.NewKeysetIter(mongodb.Client().Table(tableName), a.condition, common.BKFieldID, a.limit)
+		if a.lastID > 0 {
+			iter.After(a.lastID)
+		}
+		defer iter.Close(kit.Ctx)
+
+		for (a.limit == 0 || uint64(len(info)) < a.limit) && iter.Next(kit.Ctx) {
+			item := make(mapstr.MapStr)
+			if err := iter.Decode(&item); err != nil {
+				blog.Errorf("dbQueryModel decode info error. error:%s,rid:%s", err.Error(), kit.Rid)
+				return nil, 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+			}
+			info = append(info, item)
+		}
+		if err := iter.Err(); err != nil {
+			blog.Errorf("dbQueryModel info error. error:%s,rid:%s", err.Error(), kit.Rid)
+			return nil, 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+		}
 	}
 	cnt, err := mongodb.Client().Table(tableName).Find(nil).Count(kit.Ctx)
 	if err != nil {
@@ -90,7 +90,10 @@ func (e *eventOperation) UnSubscribe(kit *rest.Kit, subscribeID int64) errors.CC
 
 	e.cache.Del(context.Background(), types.EventCacheDistIDPrefix+fmt.Sprint(sub.SubscriptionID),
 		types.EventCacheSubscriberEventQueueKeyPrefix+fmt.Sprint(sub.SubscriptionID),
-		types.EventCacheDistCallBackCountPrefix+fmt.Sprint(sub.SubscriptionID))
+		types.EventCacheDistCallBackCountPrefix+fmt.Sprint(sub.SubscriptionID),
+		types.EventCacheSubscriberDeadLetterKeyPrefix+fmt.Sprint(sub.SubscriptionID),
+		types.EventCacheSubscriberReplayQueueKeyPrefix+fmt.Sprint(sub.SubscriptionID),
+		types.EventCacheSubscriberRetryKeyPrefix+fmt.Sprint(sub.SubscriptionID))
 
 	return nil
 }
@@ -131,6 +134,11 @@ func (e *eventOperation) UpdateSubscription(kit *rest.Kit, subscribeID int64, su
 	sub.LastTime = metadata.Now()
 	sub.OwnerID = kit.SupplierAccount
 
+	// secret is never returned, keep the old one if it's not reset or cleared.
+	if len(sub.Secret) == 0 && !sub.ClearSecret {
+		sub.Secret = oldSub.Secret
+	}
+
 	filter := map[string]interface{}{
 		common.BKSubscriptionIDField: subscribeID,
 		common.BKOwnerIDField:        kit.SupplierAccount,
@@ -179,6 +187,10 @@ func (e *eventOperation) ListSubscriptions(kit *rest.Kit, data *metadata.ParamSu
 			Total:   total,
 			Failure: failure,
 		}
+
+		// do not return the secret, only tell if it's set.
+		results[index].HasSecret = len(results[index].Secret) > 0
+		results[index].Secret = ""
 	}
 
 	info := make(map[string]interface{})
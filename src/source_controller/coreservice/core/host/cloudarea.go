@@ -22,6 +22,8 @@ import (
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	hostutil "configcenter/src/source_controller/coreservice/core/host/util"
+	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -34,6 +36,10 @@ func (hm *hostManager) UpdateHostCloudAreaField(kit *rest.Kit, input metadata.Up
 	}
 	input.HostIDs = util.IntArrayUnique(input.HostIDs)
 
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeAttribute, input.HostIDs); err != nil {
+		return err
+	}
+
 	// step1. validate bk_cloud_id
 	cloudIDFiler := map[string]interface{}{
 		common.BKCloudIDField: input.CloudID,
@@ -115,7 +121,9 @@ func (hm *hostManager) UpdateHostCloudAreaField(kit *rest.Kit, input metadata.Up
 	updateDoc := map[string]interface{}{
 		common.BKCloudIDField: input.CloudID,
 	}
-	if err := mongodb.Client().Table(common.BKTableNameBaseHost).Update(context, updateFilter, updateDoc); err != nil {
+	revision := types.ModeUpdate{Op: "inc", Doc: map[string]interface{}{common.BKRevisionField: 1}}
+	if err := mongodb.Client().Table(common.BKTableNameBaseHost).UpdateMultiModel(context, updateFilter,
+		types.ModeUpdate{Op: "set", Doc: updateDoc}, revision); err != nil {
 		blog.ErrorJSON("UpdateHostCloudAreaField failed, db update failed, table: %s, filter: %s, doc: %s, err: %s, rid: %s", common.BKTableNameBaseHost, updateFilter, updateDoc, err.Error(), rid)
 		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
 	}
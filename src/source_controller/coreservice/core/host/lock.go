@@ -49,6 +49,24 @@ func (hm *hostManager) LockHost(kit *rest.Kit, input *metadata.HostLockRequest)
 	user := util.GetUser(kit.Header)
 	var insertDataArr []interface{}
 	ts := time.Now().UTC()
+
+	// the expired locks may not be removed by the ttl index yet, remove them so that the hosts can be locked again
+	expiredCond := mapstr.MapStr{
+		common.BKHostIDField:             mapstr.MapStr{common.BKDBIN: input.IDS},
+		common.BKHostLockExpireTimeField: mapstr.MapStr{common.BKDBLTE: ts},
+	}
+	expiredCond = util.SetModOwner(expiredCond, kit.SupplierAccount)
+	if err := mongodb.Client().Table(common.BKTableNameHostLock).Delete(kit.Ctx, expiredCond); err != nil {
+		blog.Errorf("lock host, delete expired host lock failed, err: %v, rid: %s", err, kit.Rid)
+		return kit.CCError.Errorf(common.CCErrCommDBDeleteFailed)
+	}
+
+	var expireTime *time.Time
+	if input.TTL > 0 {
+		expireAt := ts.Add(time.Duration(input.TTL) * time.Second)
+		expireTime = &expireAt
+	}
+
 	for _, id := range input.IDS {
 		conds := mapstr.MapStr{
 			common.BKHostIDField: id,
@@ -65,6 +83,9 @@ func (hm *hostManager) LockHost(kit *rest.Kit, input *metadata.HostLockRequest)
 				ID:         id,
 				CreateTime: ts,
 				OwnerID:    util.GetOwnerID(kit.Header),
+				Reason:     input.Reason,
+				Scopes:     input.Scopes,
+				ExpireTime: expireTime,
 			})
 		}
 	}
@@ -98,6 +119,7 @@ func (hm *hostManager) QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLoc
 	conds := mapstr.MapStr{
 		common.BKHostIDField: mapstr.MapStr{common.BKDBIN: input.IDS},
 	}
+	conds.Merge(notExpiredHostLockCond(time.Now()))
 	conds = util.SetModOwner(conds, kit.SupplierAccount)
 	limit := uint64(len(input.IDS))
 	err := mongodb.Client().Table(common.BKTableNameHostLock).Find(conds).Limit(limit).All(kit.Ctx, &hostLockInfoArr)
@@ -108,6 +130,86 @@ func (hm *hostManager) QueryHostLock(kit *rest.Kit, input *metadata.QueryHostLoc
 	return hostLockInfoArr, nil
 }
 
+// ListHostLocks list the host locks that are not expired
+func (hm *hostManager) ListHostLocks(kit *rest.Kit, input *metadata.ListHostLocksOption) (int64,
+	[]metadata.HostLockData, errors.CCErrorCoder) {
+
+	conds := notExpiredHostLockCond(time.Now())
+	if len(input.IDS) > 0 {
+		conds[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: input.IDS}
+	}
+	if len(input.User) > 0 {
+		conds[common.BKHostLockUserField] = input.User
+	}
+	if len(input.Scope) > 0 {
+		// the locks without scopes block all the operations
+		conds[common.BKDBAND] = []mapstr.MapStr{{
+			common.BKDBOR: []mapstr.MapStr{
+				{common.BKHostLockScopesField: input.Scope},
+				{common.BKHostLockScopesField: mapstr.MapStr{common.BKDBSize: 0}},
+				{common.BKHostLockScopesField: mapstr.MapStr{common.BKDBExists: false}},
+			},
+		}}
+	}
+	conds = util.SetQueryOwner(conds, kit.SupplierAccount)
+
+	count, err := mongodb.Client().Table(common.BKTableNameHostLock).Find(conds).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("list host locks, count host locks failed, cond: %+v, err: %v, rid: %s", conds, err, kit.Rid)
+		return 0, nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	locks := make([]metadata.HostLockData, 0)
+	sort := input.Page.Sort
+	if len(sort) == 0 {
+		sort = common.CreateTimeField
+	}
+	err = mongodb.Client().Table(common.BKTableNameHostLock).Find(conds).Start(uint64(input.Page.Start)).
+		Limit(uint64(input.Page.Limit)).Sort(sort).All(kit.Ctx, &locks)
+	if err != nil {
+		blog.Errorf("list host locks failed, cond: %+v, err: %v, rid: %s", conds, err, kit.Rid)
+		return 0, nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return int64(count), locks, nil
+}
+
+// ExpireHostLocks expires the host locks immediately, the expired locks are removed by the ttl index later
+func (hm *hostManager) ExpireHostLocks(kit *rest.Kit, input *metadata.ExpireHostLocksOption) (uint64,
+	errors.CCErrorCoder) {
+
+	now := time.Now().UTC()
+	conds := notExpiredHostLockCond(now)
+	conds[common.BKHostIDField] = mapstr.MapStr{common.BKDBIN: util.IntArrayUnique(input.IDS)}
+	conds = util.SetModOwner(conds, kit.SupplierAccount)
+
+	count, err := mongodb.Client().Table(common.BKTableNameHostLock).Find(conds).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("expire host locks, count host locks failed, cond: %+v, err: %v, rid: %s", conds, err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if count == 0 {
+		return 0, nil
+	}
+
+	data := mapstr.MapStr{common.BKHostLockExpireTimeField: now}
+	if err := mongodb.Client().Table(common.BKTableNameHostLock).Update(kit.Ctx, conds, data); err != nil {
+		blog.Errorf("expire host locks failed, cond: %+v, err: %v, rid: %s", conds, err, kit.Rid)
+		return 0, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return count, nil
+}
+
+// notExpiredHostLockCond returns the condition of the locks that are not expired at the time, the locks without
+// expire time never expire.
+func notExpiredHostLockCond(now time.Time) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKHostLockExpireTimeField: mapstr.MapStr{common.BKDBExists: false}},
+			{common.BKHostLockExpireTimeField: mapstr.MapStr{common.BKDBGT: now}},
+		},
+	}
+}
+
 func diffHostLockID(ids []int64, hostInfos []metadata.HostMapStr, rid string) []int64 {
 	mapInnerID := make(map[int64]bool)
 	for _, hostInfo := range hostInfos {
@@ -16,11 +16,15 @@ import (
 	"configcenter/src/common/errors"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
+	hostutil "configcenter/src/source_controller/coreservice/core/host/util"
 )
 
 // TransferHostToInnerModule transfer host to inner module
 // 转移到空闲机/故障机模块
 func (hm *hostManager) TransferToInnerModule(kit *rest.Kit, input *metadata.TransferHostToInnerModule) error {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeTransfer, input.HostID); err != nil {
+		return err
+	}
 	return hm.hostTransfer.TransferToInnerModule(kit, input)
 }
 
@@ -29,21 +33,33 @@ func (hm *hostManager) TransferToInnerModule(kit *rest.Kit, input *metadata.Tran
 // 将主机转移到 input 表示的目标模块中
 // IsIncrement 控制增量更新还是覆盖更新
 func (hm *hostManager) TransferToNormalModule(kit *rest.Kit, input *metadata.HostsModuleRelation) error {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeTransfer, input.HostID); err != nil {
+		return err
+	}
 	return hm.hostTransfer.TransferToNormalModule(kit, input)
 }
 
 // TransferToAnotherBusiness transfer host to another business module
 func (hm *hostManager) TransferToAnotherBusiness(kit *rest.Kit, input *metadata.TransferHostsCrossBusinessRequest) error {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeTransfer, input.HostIDArr); err != nil {
+		return err
+	}
 	return hm.hostTransfer.TransferToAnotherBusiness(kit, input)
 }
 
 // DeleteHost delete host from cmdb
 func (hm *hostManager) DeleteFromSystem(kit *rest.Kit, input *metadata.DeleteHostRequest) error {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeDelete, input.HostIDArr); err != nil {
+		return err
+	}
 	return hm.hostTransfer.DeleteFromSystem(kit, input)
 }
 
 // RemoveFromModule remove from one of original modules
 func (hm *hostManager) RemoveFromModule(kit *rest.Kit, input *metadata.RemoveHostsFromModuleOption) error {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeTransfer, []int64{input.HostID}); err != nil {
+		return err
+	}
 	return hm.hostTransfer.RemoveFromModule(kit, input)
 }
 
@@ -57,5 +73,8 @@ func (hm *hostManager) GetDistinctHostIDsByTopoRelation(kit *rest.Kit, input *me
 }
 
 func (hm *hostManager) TransferResourceDirectory(kit *rest.Kit, input *metadata.TransferHostResourceDirectory) errors.CCErrorCoder {
+	if err := hostutil.CheckHostLock(kit, metadata.HostLockScopeTransfer, input.HostID); err != nil {
+		return err
+	}
 	return hm.hostTransfer.TransferResourceDirectory(kit, input)
 }
@@ -34,6 +34,8 @@ type OperationDependence interface {
 	AutoCreateServiceInstanceModuleHost(kit *rest.Kit, hostIDs []int64, moduleIDs []int64) errors.CCErrorCoder
 	SelectObjectAttWithParams(kit *rest.Kit, objID string, bizID int64) (attribute []metadata.Attribute, err error)
 	UpdateModelInstance(kit *rest.Kit, objID string, param metadata.UpdateOption) (*metadata.UpdatedCount, error)
+	RecalculateInstances(kit *rest.Kit, objID string, instIDs []int64) error
+	HandleInstRefBeforeDelete(kit *rest.Kit, objID string, instIDs []int64) error
 }
 
 type HostApplyRuleDependence interface {
@@ -290,7 +292,12 @@ func (manager *TransferManager) clearLegacyPrivateField(kit *rest.Kit, attribute
 			common.BKDBIN: hostIDs,
 		},
 	}
-	if err := mongodb.Client().Table(common.BKTableNameBaseHost).UpdateMultiModel(kit.Ctx, filter, reset); err != nil {
+	revision := types.ModeUpdate{
+		Op:  "inc",
+		Doc: map[string]interface{}{common.BKRevisionField: 1},
+	}
+	if err := mongodb.Client().Table(common.BKTableNameBaseHost).UpdateMultiModel(kit.Ctx, filter, reset,
+		revision); err != nil {
 		blog.ErrorJSON("clearLegacyPrivateField failed. table: %s, filter: %s, doc: %s, err: %s, rid:%s", common.BKTableNameBaseHost, filter, doc, err.Error(), kit.Rid)
 		return kit.CCError.CCErrorf(common.CCErrCommDBUpdateFailed)
 	}
@@ -80,6 +80,11 @@ func (t *genericTransfer) Transfer(kit *rest.Kit, hostIDs []int64) errors.CCErro
 		return err
 	}
 
+	originRelations, err := t.getHostModuleRelations(kit, hostIDs)
+	if err != nil {
+		return err
+	}
+
 	// remove service instance if necessary
 	if err := t.removeHostServiceInstance(kit, hostIDs); err != nil {
 		return err
@@ -101,6 +106,12 @@ func (t *genericTransfer) Transfer(kit *rest.Kit, hostIDs []int64) errors.CCErro
 		return err
 	}
 
+	currentRelations, err := t.getHostModuleRelations(kit, hostIDs)
+	if err != nil {
+		return err
+	}
+	t.recalculateHostTopo(kit, append(originRelations, currentRelations...))
+
 	return nil
 }
 
@@ -119,6 +130,16 @@ func (t *genericTransfer) DeleteHosts(kit *rest.Kit, hostIDs []int64) error {
 		return err
 	}
 
+	// handle the instances that reference the hosts
+	if err := t.dependent.HandleInstRefBeforeDelete(kit, common.BKInnerObjIDHost, hostIDs); err != nil {
+		return err
+	}
+
+	originRelations, err := t.getHostModuleRelations(kit, hostIDs)
+	if err != nil {
+		return err
+	}
+
 	// remove service instances
 	if err := t.removeHostServiceInstance(kit, hostIDs); err != nil {
 		return err
@@ -136,9 +157,46 @@ func (t *genericTransfer) DeleteHosts(kit *rest.Kit, hostIDs []int64) error {
 		return kit.CCError.CCErrorf(common.CCErrCommDBDeleteFailed)
 	}
 
+	t.recalculateHostTopo(kit, originRelations)
+
 	return nil
 }
 
+func (t *genericTransfer) getHostModuleRelations(kit *rest.Kit, hostIDs []int64) ([]metadata.ModuleHost, errors.CCErrorCoder) {
+	relations := make([]metadata.ModuleHost, 0)
+	cond := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: hostIDs}}
+	err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Find(cond).All(kit.Ctx, &relations)
+	if err != nil {
+		blog.Errorf("get host module relations failed, err: %v, host ID: %+v, rid: %s", err, hostIDs, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommDBSelectFailed)
+	}
+	return relations, nil
+}
+
+// recalculateHostTopo recalculate the business, set and module instances whose hosts are changed, so that the
+// aggregation calculated attributes over hosts are up to date. the failed recalculation is retried by a background
+// task, it is only logged if the task can not be created either, because the hosts are already transferred, the
+// values can be corrected with the recalculate api then.
+func (t *genericTransfer) recalculateHostTopo(kit *rest.Kit, relations []metadata.ModuleHost) {
+	objInstIDs := map[string][]int64{
+		common.BKInnerObjIDApp:    make([]int64, 0),
+		common.BKInnerObjIDSet:    make([]int64, 0),
+		common.BKInnerObjIDModule: make([]int64, 0),
+	}
+	for _, relation := range relations {
+		objInstIDs[common.BKInnerObjIDApp] = append(objInstIDs[common.BKInnerObjIDApp], relation.AppID)
+		objInstIDs[common.BKInnerObjIDSet] = append(objInstIDs[common.BKInnerObjIDSet], relation.SetID)
+		objInstIDs[common.BKInnerObjIDModule] = append(objInstIDs[common.BKInnerObjIDModule], relation.ModuleID)
+	}
+
+	for objID, instIDs := range objInstIDs {
+		if err := t.dependent.RecalculateInstances(kit, objID, util.IntArrayUnique(instIDs)); err != nil {
+			blog.Errorf("recalculate %s instances after host transfer failed, inst ids: %v, err: %v, rid: %s",
+				objID, instIDs, err, kit.Rid)
+		}
+	}
+}
+
 // validParameterInst  validate module, biz, srcBiz must be exist
 func (t *genericTransfer) validParameterInst(kit *rest.Kit) errors.CCErrorCoder {
 
@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CheckHostLock checks if the operation of the scope on the hosts is blocked by the host locks,
+// returns the error that describes the lock which blocks the operation.
+func CheckHostLock(kit *rest.Kit, scope metadata.HostLockScope, hostIDs []int64) errors.CCErrorCoder {
+	return checkHostLock(kit, mongodb.Client(), scope, hostIDs)
+}
+
+func checkHostLock(kit *rest.Kit, db dal.RDB, scope metadata.HostLockScope, hostIDs []int64) errors.CCErrorCoder {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+
+	cond := map[string]interface{}{
+		common.BKHostIDField: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(hostIDs)},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	locks := make([]metadata.HostLockData, 0)
+	if err := db.Table(common.BKTableNameHostLock).Find(cond).All(kit.Ctx, &locks); err != nil {
+		blog.Errorf("get host locks failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	now := time.Now()
+	for _, lock := range locks {
+		if lock.Blocks(scope, now) {
+			blog.Errorf("host %d is %s, %s operation is blocked, rid: %s", lock.ID, lock.String(), scope, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrHostLocked, lock.ID, lock.String(), scope)
+		}
+	}
+	return nil
+}
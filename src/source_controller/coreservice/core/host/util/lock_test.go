@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package util
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+)
+
+func TestCheckHostLock(t *testing.T) {
+	errFactory, err := errors.NewFactory("../../../../../../resources/errors/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	kit := &rest.Kit{
+		Rid:             "test_rid",
+		Ctx:             context.Background(),
+		CCError:         errFactory.CreateDefaultCCErrorIf("en"),
+		User:            "tester",
+		SupplierAccount: common.BKDefaultOwnerID,
+	}
+
+	now := time.Now()
+	expired := now.Add(-time.Minute)
+	notExpired := now.Add(time.Hour)
+	db := memory.NewMemory()
+	locks := []metadata.HostLockData{
+		// locked without scopes, all the operations are blocked
+		{ID: 1, User: "admin", OwnerID: common.BKDefaultOwnerID},
+		{ID: 2, User: "admin", OwnerID: common.BKDefaultOwnerID, Scopes: []metadata.HostLockScope{
+			metadata.HostLockScopeTransfer}, ExpireTime: &notExpired},
+		{ID: 3, User: "admin", OwnerID: common.BKDefaultOwnerID, Scopes: []metadata.HostLockScope{
+			metadata.HostLockScopeTransfer, metadata.HostLockScopeDelete}, ExpireTime: &expired},
+		// the lock of the other tenant does not block the operations of this tenant
+		{ID: 4, User: "admin", OwnerID: "tenant"},
+	}
+	if err := db.Table(common.BKTableNameHostLock).Insert(kit.Ctx, locks); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		scope   metadata.HostLockScope
+		hostIDs []int64
+		blocked bool
+	}{
+		{metadata.HostLockScopeAttribute, []int64{1}, true},
+		{metadata.HostLockScopeDelete, []int64{1}, true},
+		{metadata.HostLockScopeTransfer, []int64{2}, true},
+		{metadata.HostLockScopeAttribute, []int64{2}, false},
+		{metadata.HostLockScopeTransfer, []int64{3}, false},
+		{metadata.HostLockScopeDelete, []int64{3, 4, 5}, false},
+		{metadata.HostLockScopeTransfer, []int64{3, 2}, true},
+		{metadata.HostLockScopeTransfer, nil, false},
+	}
+	for _, c := range cases {
+		err := checkHostLock(kit, db, c.scope, c.hostIDs)
+		if c.blocked != (err != nil) {
+			t.Errorf("scope %s on hosts %v: expect blocked %v, err: %v", c.scope, c.hostIDs, c.blocked, err)
+			continue
+		}
+		if err != nil && err.GetCode() != common.CCErrHostLocked {
+			t.Errorf("scope %s on hosts %v: expect host locked error, got %v", c.scope, c.hostIDs, err)
+		}
+	}
+}
@@ -25,6 +25,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
+	hostutil "configcenter/src/source_controller/coreservice/core/host/util"
 	"configcenter/src/storage/driver/mongodb"
 	"configcenter/src/thirdparty/hooks"
 )
@@ -73,6 +74,7 @@ func (m *instanceManager) CreateModelInstance(kit *rest.Kit, objID string, input
 		blog.Errorf("CreateModelInstance failed, validCreateInstanceData error:%v, objID:%s, data:%#v, rid:%s", err, objID, inputParam.Data, rid)
 		return nil, err
 	}
+	m.fillCalculatedFields(kit, objID, inputParam.Data, validator)
 
 	id, err := m.save(kit, objID, inputParam.Data)
 	if err != nil {
@@ -111,6 +113,7 @@ func (m *instanceManager) CreateManyModelInstance(kit *rest.Kit, objID string, i
 			blog.Errorf("CreateManyModelInstance failed, validCreateInstanceData err:%v, objID:%s, item:%#v, rid:%s", err, objID, item, kit.Rid)
 			return nil, err
 		}
+		m.fillCalculatedFields(kit, objID, item, allValidators[bizID])
 
 		id, err := m.save(kit, objID, item)
 		if nil != err {
@@ -141,7 +144,29 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		return nil, kit.CCError.Error(common.CCErrCommNotFound)
 	}
 
+	if err := m.checkHostLock(kit, objID, metadata.HostLockScopeAttribute, origins); err != nil {
+		return nil, err
+	}
+
+	// the revision is maintained by cmdb, it can not be updated directly
+	inputParam.Data.Remove(common.BKRevisionField)
+	if inputParam.Revision != nil {
+		if len(origins) != 1 {
+			blog.Errorf("UpdateModelInstance failed, revision is set but %d instances matched, objID: %s, rid: %s",
+				len(origins), objID, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "revision")
+		}
+
+		if current := metadata.GetRevision(origins[0]); current != *inputParam.Revision {
+			blog.Errorf("UpdateModelInstance failed, revision %d not match the expected %d, objID: %s, rid: %s",
+				current, *inputParam.Revision, objID, kit.Rid)
+			return &metadata.UpdatedCount{Current: origins[0]},
+				kit.CCError.CCErrorf(common.CCErrCommRevisionConflict, current, *inputParam.Revision)
+		}
+	}
+
 	allValidators := make(map[int64]*validator)
+	originValidators := make([]*validator, len(origins))
 	for idx, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
@@ -158,6 +183,7 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 			}
 			allValidators[bizID] = validator
 		}
+		originValidators[idx] = allValidators[bizID]
 
 		// it is not allowed to update multiple records if the updateData has a unique field
 		if idx == 0 && len(origins) > 1 {
@@ -181,22 +207,94 @@ func (m *instanceManager) UpdateModelInstance(kit *rest.Kit, objID string, input
 		}
 	}
 
-	err = m.update(kit, objID, inputParam.Data, inputParam.Condition)
+	updated, err := m.update(kit, objID, inputParam.Data, inputParam.Condition, inputParam.Revision)
 	if err != nil {
 		blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, data:%#v, condition:%s, rid:%s",
 			objID, err, inputParam.Condition, inputParam.Data, kit.Rid)
 		return nil, kit.CCError.Error(common.CCErrCommDBUpdateFailed)
 	}
 
+	// the instance is changed by others after it is read, returns the latest one
+	if inputParam.Revision != nil && updated == 0 {
+		return m.revisionConflict(kit, objID, inputParam.Condition, *inputParam.Revision)
+	}
+
 	if objID == common.BKInnerObjIDHost {
 		if err := m.updateHostProcessBindIP(kit, inputParam.Data, origins); err != nil {
 			return nil, err
 		}
 	}
 
+	// the instances are already updated, the calculated values that can not be refreshed now are corrected
+	// by the background task, so the update is not reported as failed.
+	instIDs := make([]int64, 0)
+	for _, origin := range origins {
+		instID, _ := util.GetInt64ByInterface(origin[instIDFieldName])
+		instIDs = append(instIDs, instID)
+	}
+	if err := m.updateCalculatedFields(kit, objID, inputParam.Data, origins, originValidators); err != nil {
+		blog.Errorf("UpdateModelInstance update calculated fields failed, err: %v, objID: %s, rid: %s", err, objID, kit.Rid)
+		if err := m.DeferRecalculateModelInstance(kit, objID, instIDs); err != nil {
+			blog.Errorf("UpdateModelInstance defer recalculating failed, err: %v, objID: %s, instIDs: %v, rid: %s",
+				err, objID, instIDs, kit.Rid)
+		}
+	}
+
+	updateFields := make([]string, 0)
+	for field := range inputParam.Data {
+		updateFields = append(updateFields, field)
+	}
+	if err := m.refreshAggregatedInstances(kit, objID, instIDs, updateFields); err != nil {
+		blog.Errorf("UpdateModelInstance refresh aggregated instances failed, err: %v, objID: %s, rid: %s", err, objID, kit.Rid)
+	}
+
 	return &metadata.UpdatedCount{Count: uint64(len(origins))}, nil
 }
 
+// revisionConflict returns the revision conflict error with the current instance
+func (m *instanceManager) revisionConflict(kit *rest.Kit, objID string, cond mapstr.MapStr, expected int64) (
+	*metadata.UpdatedCount, error) {
+
+	currents, _, err := m.getInsts(kit, objID, cond)
+	if err != nil {
+		blog.Errorf("get the current instance failed, err: %v, objID: %s, rid: %s", err, objID, kit.Rid)
+		return nil, kit.CCError.Error(common.CCErrCommDBSelectFailed)
+	}
+	if len(currents) == 0 {
+		return nil, kit.CCError.Error(common.CCErrCommNotFound)
+	}
+
+	current := metadata.GetRevision(currents[0])
+	blog.Errorf("update instance failed, revision %d not match the expected %d, objID: %s, rid: %s", current,
+		expected, objID, kit.Rid)
+	return &metadata.UpdatedCount{Current: currents[0]},
+		kit.CCError.CCErrorf(common.CCErrCommRevisionConflict, current, expected)
+}
+
+// checkHostLock checks if the operation on the hosts is blocked by the host locks, other instances are not locked
+func (m *instanceManager) checkHostLock(kit *rest.Kit, objID string, scope metadata.HostLockScope,
+	origins []mapstr.MapStr) error {
+
+	if objID != common.BKInnerObjIDHost {
+		return nil
+	}
+
+	hostIDs := make([]int64, 0, len(origins))
+	for _, origin := range origins {
+		hostID, err := util.GetInt64ByInterface(origin[common.BKHostIDField])
+		if err != nil {
+			blog.Errorf("parse host id failed, host: %+v, err: %v, rid: %s", origin, err, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKHostIDField)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+
+	if err := hostutil.CheckHostLock(kit, scope, hostIDs); err != nil {
+		return err
+	}
+	return nil
+}
+
 // updateHostProcessBindIP if hosts' ips are updated, update processes which binds the changed ip
 func (m *instanceManager) updateHostProcessBindIP(kit *rest.Kit, updateData mapstr.MapStr, origins []mapstr.MapStr) error {
 	innerIP, innerIPExist := updateData[common.BKHostInnerIPField]
@@ -366,7 +464,7 @@ func (m *instanceManager) SearchModelInstance(kit *rest.Kit, objID string, input
 		}
 		inputParam.Condition[common.BKObjIDField] = objID
 	}
-	inputParam.Condition = util.SetQueryOwner(inputParam.Condition, kit.SupplierAccount)
+	inputParam.Condition = setInstanceQueryOwner(kit, objID, inputParam.Condition)
 
 	if inputParam.TimeCondition != nil {
 		var err error
@@ -417,6 +515,22 @@ func (m *instanceManager) SearchModelInstance(kit *rest.Kit, objID string, input
 		return nil, instErr
 	}
 
+	if inputParam.ExpandInstRef {
+		if err := m.expandInstRef(kit, objID, instItems); err != nil {
+			return nil, err
+		}
+	}
+
+	// return the revision of the instances that have never been updated since the revision is introduced,
+	// so that the caller can always use it as the expected revision to update the instance.
+	if len(fields) == 0 || util.InStrArr(fields, common.BKRevisionField) {
+		for _, item := range instItems {
+			if !item.Exists(common.BKRevisionField) {
+				item[common.BKRevisionField] = 0
+			}
+		}
+	}
+
 	dataResult := &metadata.QueryResult{
 		Count: finalCount,
 		Info:  instItems,
@@ -436,6 +550,11 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 		return &metadata.DeletedCount{}, err
 	}
 
+	if err := m.checkHostLock(kit, objID, metadata.HostLockScopeDelete, origins); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
+
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
@@ -448,6 +567,11 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 		if exists {
 			return &metadata.DeletedCount{}, kit.CCError.Error(common.CCErrorInstHasAsst)
 		}
+		instIDs = append(instIDs, instID)
+	}
+
+	if err := m.HandleInstRefBeforeDelete(kit, objID, instIDs); err != nil {
+		return &metadata.DeletedCount{}, err
 	}
 
 	err = mongodb.Client().Table(tableName).Delete(kit.Ctx, inputParam.Condition)
@@ -460,6 +584,14 @@ func (m *instanceManager) DeleteModelInstance(kit *rest.Kit, objID string, input
 }
 
 func (m *instanceManager) CascadeDeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
+	return m.cascadeDeleteModelInstance(kit, objID, inputParam, true)
+}
+
+// cascadeDeleteModelInstance delete the instances with their associations, handleRef indicates whether the
+// instances that reference them need to be handled, it's false when they are already handled by the caller.
+func (m *instanceManager) cascadeDeleteModelInstance(kit *rest.Kit, objID string, inputParam metadata.DeleteOption,
+	handleRef bool) (*metadata.DeletedCount, error) {
+
 	tableName := common.GetInstTableName(objID)
 	instIDFieldName := common.GetInstIDField(objID)
 	origins, _, err := m.getInsts(kit, objID, inputParam.Condition)
@@ -469,11 +601,26 @@ func (m *instanceManager) CascadeDeleteModelInstance(kit *rest.Kit, objID string
 		return &metadata.DeletedCount{}, err
 	}
 
+	if err := m.checkHostLock(kit, objID, metadata.HostLockScopeDelete, origins); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
+
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
+		instIDs = append(instIDs, instID)
+	}
+
+	if handleRef {
+		if err := m.HandleInstRefBeforeDelete(kit, objID, instIDs); err != nil {
+			return &metadata.DeletedCount{}, err
+		}
+	}
+
+	for _, instID := range instIDs {
 		err = m.dependent.DeleteInstAsst(kit, objID, uint64(instID))
 		if nil != err {
 			return &metadata.DeletedCount{}, err
@@ -0,0 +1,567 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"reflect"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/calculator"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// recalculatePageSize is the page size to recalculate all instances of a model.
+const recalculatePageSize = 500
+
+// calculatedAttribute is an attribute whose value is computed by the server.
+type calculatedAttribute struct {
+	metadata.Attribute
+	option     *calculator.Option
+	expression *calculator.Expression
+}
+
+// parseCalculatedAttributes parse the calculated attributes in the attributes, the ones with invalid option
+// are ignored, because the option has been validated when the attribute is created or updated.
+func parseCalculatedAttributes(kit *rest.Kit, attributes []metadata.Attribute) []calculatedAttribute {
+	calcAttrs := make([]calculatedAttribute, 0)
+	for _, attr := range attributes {
+		if attr.PropertyType != common.FieldTypeCalculated {
+			continue
+		}
+
+		option, err := calculator.ParseOption(attr.Option)
+		if err != nil {
+			blog.Errorf("parse calculated attribute %s option failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			continue
+		}
+
+		expression, err := option.Validate()
+		if err != nil {
+			blog.Errorf("calculated attribute %s option is invalid, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			continue
+		}
+
+		calcAttrs = append(calcAttrs, calculatedAttribute{Attribute: attr, option: option, expression: expression})
+	}
+	return calcAttrs
+}
+
+// getCalculatedAttributes get all the calculated attributes of the model, including the business private ones.
+func (m *instanceManager) getCalculatedAttributes(kit *rest.Kit, objID string) ([]calculatedAttribute, error) {
+	cond := map[string]interface{}{
+		common.BKObjIDField:        objID,
+		common.BKPropertyTypeField: common.FieldTypeCalculated,
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	attributes := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attributes); err != nil {
+		blog.Errorf("get calculated attributes failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return parseCalculatedAttributes(kit, attributes), nil
+}
+
+// fillCalculatedFields set the calculated attributes' values of the instance to be created, a new instance
+// has no associated instances yet, so the aggregations are calculated with no values.
+func (m *instanceManager) fillCalculatedFields(kit *rest.Kit, objID string, instanceData mapstr.MapStr, valid *validator) {
+	for _, attr := range valid.calculatedAttrs {
+		switch attr.option.Type {
+		case calculator.TypeExpression:
+			instanceData[attr.PropertyID] = m.evaluateExpression(kit, attr, instanceData)
+		case calculator.TypeAggregation:
+			value, err := attr.option.Aggregation.Aggregate(nil)
+			if err != nil {
+				blog.Errorf("aggregate calculated attribute %s failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			}
+			instanceData[attr.PropertyID] = value
+		}
+	}
+}
+
+// evaluateExpression evaluate the calculated expression with the instance, returns nil if the expression can not
+// be calculated, so that the instance write will not be blocked by the calculated attributes.
+func (m *instanceManager) evaluateExpression(kit *rest.Kit, attr calculatedAttribute, inst mapstr.MapStr) interface{} {
+	value, err := attr.expression.Evaluate(inst)
+	if err != nil {
+		if err != calculator.ErrVariableNotSet {
+			blog.Warnf("evaluate calculated attribute %s expression %s failed, err: %v, rid: %s", attr.PropertyID,
+				attr.expression, err, kit.Rid)
+		}
+		return nil
+	}
+	return value
+}
+
+// updateCalculatedFields recalculate the expression attributes of the updated instances if their inputs are changed.
+func (m *instanceManager) updateCalculatedFields(kit *rest.Kit, objID string, updateData mapstr.MapStr,
+	origins []mapstr.MapStr, validators []*validator) error {
+
+	instIDField := common.GetInstIDField(objID)
+	for idx, origin := range origins {
+		changes := make(mapstr.MapStr)
+		for _, attr := range validators[idx].calculatedAttrs {
+			if attr.option.Type != calculator.TypeExpression || !isExpressionInputChanged(attr, updateData) {
+				continue
+			}
+
+			inst := origin.Clone()
+			inst.Merge(updateData)
+			value := m.evaluateExpression(kit, attr, inst)
+			if !isCalculatedValueEqual(origin[attr.PropertyID], value) {
+				changes[attr.PropertyID] = value
+			}
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		instID, err := util.GetInt64ByInterface(origin[instIDField])
+		if err != nil {
+			blog.Errorf("get instance id failed, err: %v, inst: %#v, rid: %s", err, origin, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, instIDField)
+		}
+
+		if err := m.saveCalculatedFields(kit, objID, instID, changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isExpressionInputChanged(attr calculatedAttribute, updateData mapstr.MapStr) bool {
+	for _, variable := range attr.expression.Variables() {
+		if _, exists := updateData[variable]; exists {
+			return true
+		}
+	}
+	return false
+}
+
+func isCalculatedValueEqual(origin, value interface{}) bool {
+	if origin == nil || value == nil {
+		return origin == nil && value == nil
+	}
+
+	originNum, err := calculator.ToFloat64(origin)
+	if err != nil {
+		return reflect.DeepEqual(origin, value)
+	}
+
+	num, err := calculator.ToFloat64(value)
+	if err != nil {
+		return false
+	}
+	return originNum == num
+}
+
+// saveCalculatedFields save the calculated values to the instance, last time is not changed because the instance
+// itself is not edited.
+func (m *instanceManager) saveCalculatedFields(kit *rest.Kit, objID string, instID int64, changes mapstr.MapStr) error {
+	cond := map[string]interface{}{common.GetInstIDField(objID): instID}
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+
+	err := mongodb.Client().Table(common.GetInstTableName(objID)).Update(kit.Ctx, cond, changes)
+	if err != nil {
+		blog.Errorf("save calculated fields failed, err: %v, objID: %s, instID: %d, data: %#v, rid: %s", err, objID,
+			instID, changes, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	return nil
+}
+
+// RecalculateModelInstance recalculate the calculated attributes of the model's instances, returns the number
+// of instances whose calculated values are changed.
+func (m *instanceManager) RecalculateModelInstance(kit *rest.Kit, objID string,
+	inputParam metadata.RecalculateModelInstance) (*metadata.UpdatedCount, error) {
+
+	calcAttrs, err := m.getCalculatedAttributes(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(calcAttrs) == 0 {
+		return &metadata.UpdatedCount{}, nil
+	}
+
+	result := &metadata.UpdatedCount{}
+	for afterID := int64(0); ; {
+		lastID, count, err := m.recalculatePage(kit, objID, inputParam.InstIDs, afterID, calcAttrs)
+		if err != nil {
+			return nil, err
+		}
+		result.Count += count
+
+		if lastID == 0 {
+			break
+		}
+		afterID = lastID
+	}
+
+	return result, nil
+}
+
+// recalculatePage recalculate a page of the instances whose ids are greater than afterID, instIDs limits the
+// instances to be recalculated if it is set. returns the id of the last instance in the page if the page is full,
+// otherwise returns 0 which means there is no more instances.
+func (m *instanceManager) recalculatePage(kit *rest.Kit, objID string, instIDs []int64, afterID int64,
+	calcAttrs []calculatedAttribute) (int64, uint64, error) {
+
+	instIDField := common.GetInstIDField(objID)
+	idCond := map[string]interface{}{common.BKDBGT: afterID}
+	if len(instIDs) > 0 {
+		idCond[common.BKDBIN] = instIDs
+	}
+	cond := map[string]interface{}{instIDField: idCond}
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = objID
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	insts := make([]mapstr.MapStr, 0)
+	err := mongodb.Client().Table(common.GetInstTableName(objID)).Find(cond).Sort(instIDField).
+		Limit(recalculatePageSize).All(kit.Ctx, &insts)
+	if err != nil {
+		blog.Errorf("get instances to recalculate failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return 0, 0, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	var count uint64
+	for _, inst := range insts {
+		changed, err := m.recalculateInstance(kit, objID, inst, calcAttrs)
+		if err != nil {
+			return 0, 0, err
+		}
+		if changed {
+			count++
+		}
+	}
+
+	if len(insts) < recalculatePageSize {
+		return 0, count, nil
+	}
+
+	lastID, err := util.GetInt64ByInterface(insts[len(insts)-1][instIDField])
+	if err != nil {
+		blog.Errorf("get instance id failed, err: %v, inst: %#v, rid: %s", err, insts[len(insts)-1], kit.Rid)
+		return 0, 0, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, instIDField)
+	}
+	return lastID, count, nil
+}
+
+// RecalculateModelInstanceTask execute the background recalculation task. if the task has no instances, only
+// the page of instances after the task's AfterID is recalculated and the next page is handed over to a new task,
+// so that each task takes a bounded time no matter how many instances the model has.
+func (m *instanceManager) RecalculateModelInstanceTask(kit *rest.Kit, task metadata.RecalculateModelInstanceTask) error {
+	if len(task.InstIDs) > 0 {
+		_, err := m.RecalculateModelInstance(kit, task.ObjID, metadata.RecalculateModelInstance{InstIDs: task.InstIDs})
+		return err
+	}
+
+	calcAttrs, err := m.getCalculatedAttributes(kit, task.ObjID)
+	if err != nil {
+		return err
+	}
+
+	if len(calcAttrs) == 0 {
+		return nil
+	}
+
+	lastID, _, err := m.recalculatePage(kit, task.ObjID, nil, task.AfterID, calcAttrs)
+	if err != nil {
+		return err
+	}
+
+	if lastID == 0 {
+		return nil
+	}
+
+	next := metadata.RecalculateModelInstanceTask{ObjID: task.ObjID, AfterID: lastID}
+	return m.createRecalculateTask(kit, task.ObjID, []interface{}{next})
+}
+
+// DeferRecalculateModelInstance hands the recalculation of the instances over to a background task, all the
+// instances of the model are recalculated page by page if instIDs is empty.
+func (m *instanceManager) DeferRecalculateModelInstance(kit *rest.Kit, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return m.createRecalculateTask(kit, objID, []interface{}{metadata.RecalculateModelInstanceTask{ObjID: objID}})
+	}
+
+	tasks := make([]interface{}, 0)
+	for start := 0; start < len(instIDs); start += recalculatePageSize {
+		end := start + recalculatePageSize
+		if end > len(instIDs) {
+			end = len(instIDs)
+		}
+		tasks = append(tasks, metadata.RecalculateModelInstanceTask{ObjID: objID, InstIDs: instIDs[start:end]})
+	}
+	return m.createRecalculateTask(kit, objID, tasks)
+}
+
+func (m *instanceManager) createRecalculateTask(kit *rest.Kit, objID string, tasks []interface{}) error {
+	// the transaction headers are removed, the task is executed after the request is finished
+	header := util.CCHeader(kit.Header)
+	flag := "recalculate:" + objID
+	result, err := m.clientSet.TaskServer().Task().Create(kit.Ctx, header, common.RecalculateModelInstanceTaskName,
+		flag, tasks)
+	if err != nil {
+		blog.Errorf("create recalculate task failed, err: %v, objID: %s, rid: %s", err, objID, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if err := result.CCError(); err != nil {
+		blog.Errorf("create recalculate task failed, err: %v, objID: %s, rid: %s", err, objID, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+// RecalculateOrDeferModelInstance recalculate the instances whose calculated values are affected by a change that
+// is already saved. if the recalculation fails, it is handed over to a background task instead of failing the
+// change, so that the calculated values are still corrected eventually.
+func (m *instanceManager) RecalculateOrDeferModelInstance(kit *rest.Kit, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	_, err := m.RecalculateModelInstance(kit, objID, metadata.RecalculateModelInstance{InstIDs: instIDs})
+	if err == nil {
+		return nil
+	}
+
+	blog.Warnf("recalculate %s instances %v failed, hand over to background task, err: %v, rid: %s", objID,
+		instIDs, err, kit.Rid)
+	if err := m.DeferRecalculateModelInstance(kit, objID, instIDs); err != nil {
+		blog.Errorf("defer recalculating %s instances %v failed, err: %v, rid: %s", objID, instIDs, err, kit.Rid)
+		return err
+	}
+	return nil
+}
+
+func (m *instanceManager) recalculateInstance(kit *rest.Kit, objID string, inst mapstr.MapStr,
+	calcAttrs []calculatedAttribute) (bool, error) {
+
+	instIDField := common.GetInstIDField(objID)
+	instID, err := util.GetInt64ByInterface(inst[instIDField])
+	if err != nil {
+		blog.Errorf("get instance id failed, err: %v, inst: %#v, rid: %s", err, inst, kit.Rid)
+		return false, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, instIDField)
+	}
+
+	bizID := int64(-1)
+	changes := make(mapstr.MapStr)
+	for _, attr := range calcAttrs {
+		// business private attribute only takes effect on the instances of the business
+		if attr.BizID > 0 {
+			if bizID < 0 {
+				bizID, err = m.fetchBizIDFromInstance(kit, objID, inst, common.ValidUpdate, instID)
+				if err != nil {
+					return false, err
+				}
+			}
+			if attr.BizID != bizID {
+				continue
+			}
+		}
+
+		var value interface{}
+		switch attr.option.Type {
+		case calculator.TypeExpression:
+			value = m.evaluateExpression(kit, attr, inst)
+		case calculator.TypeAggregation:
+			value, err = m.aggregate(kit, objID, instID, attr)
+			if err != nil {
+				return false, err
+			}
+		}
+
+		if !isCalculatedValueEqual(inst[attr.PropertyID], value) {
+			changes[attr.PropertyID] = value
+		}
+	}
+
+	if len(changes) == 0 {
+		return false, nil
+	}
+
+	if err := m.saveCalculatedFields(kit, objID, instID, changes); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// aggregate calculate the aggregation attribute of the instance with its associated instances.
+func (m *instanceManager) aggregate(kit *rest.Kit, objID string, instID int64, attr calculatedAttribute) (
+	interface{}, error) {
+
+	aggregation := attr.option.Aggregation
+	asstInstIDs, err := m.getAssociatedInstIDs(kit, objID, []int64{instID}, aggregation.AsstObjID)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]interface{}, 0)
+	if aggregation.Func == calculator.FunctionCount {
+		for range asstInstIDs {
+			values = append(values, nil)
+		}
+		return aggregation.Aggregate(values)
+	}
+
+	if len(asstInstIDs) > 0 {
+		cond := map[string]interface{}{
+			common.GetInstIDField(aggregation.AsstObjID): map[string]interface{}{common.BKDBIN: asstInstIDs},
+		}
+		insts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(common.GetInstTableName(aggregation.AsstObjID)).Find(cond).
+			Fields(aggregation.Field).All(kit.Ctx, &insts)
+		if err != nil {
+			blog.Errorf("get associated instances failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		for _, inst := range insts {
+			values = append(values, inst[aggregation.Field])
+		}
+	}
+
+	value, err := aggregation.Aggregate(values)
+	if err != nil {
+		blog.Warnf("aggregate calculated attribute %s failed, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+		return nil, nil
+	}
+	return value, nil
+}
+
+// getAssociatedInstIDs get the ids of the instances of model asstObjID which are associated with the instances.
+// host is associated with the business, set and module by the host module relations, the other models are
+// associated with each other by the instance associations.
+func (m *instanceManager) getAssociatedInstIDs(kit *rest.Kit, objID string, instIDs []int64, asstObjID string) (
+	[]int64, error) {
+
+	if len(instIDs) == 0 {
+		return make([]int64, 0), nil
+	}
+
+	if isHostTopoRelation(objID, asstObjID) {
+		cond := map[string]interface{}{
+			common.GetInstIDField(objID): map[string]interface{}{common.BKDBIN: instIDs},
+		}
+		ids, err := mongodb.Client().Table(common.BKTableNameModuleHostConfig).Distinct(kit.Ctx,
+			common.GetInstIDField(asstObjID), cond)
+		if err != nil {
+			blog.Errorf("get host topo relations failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+		return util.SliceInterfaceToInt64(ids)
+	}
+
+	cond := map[string]interface{}{
+		common.BKDBOR: []map[string]interface{}{
+			{
+				common.BKObjIDField:     objID,
+				common.BKInstIDField:    map[string]interface{}{common.BKDBIN: instIDs},
+				common.BKAsstObjIDField: asstObjID,
+			},
+			{
+				common.BKAsstObjIDField:  objID,
+				common.BKAsstInstIDField: map[string]interface{}{common.BKDBIN: instIDs},
+				common.BKObjIDField:      asstObjID,
+			},
+		},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	assts := make([]metadata.InstAsst, 0)
+	if err := mongodb.Client().Table(common.BKTableNameInstAsst).Find(cond).All(kit.Ctx, &assts); err != nil {
+		blog.Errorf("get instance associations failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	idMap := make(map[int64]struct{})
+	asstInstIDs := make([]int64, 0)
+	for _, asst := range assts {
+		id := asst.InstID
+		if asst.ObjectID == objID && asst.AsstObjectID == asstObjID && util.InArray(asst.InstID, instIDs) {
+			id = asst.AsstInstID
+		}
+		if _, exists := idMap[id]; exists {
+			continue
+		}
+		idMap[id] = struct{}{}
+		asstInstIDs = append(asstInstIDs, id)
+	}
+	return asstInstIDs, nil
+}
+
+// isHostTopoRelation returns if the relation between the two models is the host module relation.
+func isHostTopoRelation(objID, asstObjID string) bool {
+	return (objID == common.BKInnerObjIDHost && common.IsInnerMainlineModel(asstObjID)) ||
+		(asstObjID == common.BKInnerObjIDHost && common.IsInnerMainlineModel(objID))
+}
+
+// refreshAggregatedInstances recalculate the instances which aggregate the fields of the updated instances of
+// model objID. the instances created or deleted have no associations, the aggregations are refreshed by the
+// association and host transfer hooks when their associations are changed.
+func (m *instanceManager) refreshAggregatedInstances(kit *rest.Kit, objID string, instIDs []int64,
+	fields []string) error {
+
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	cond := map[string]interface{}{
+		common.BKPropertyTypeField:          common.FieldTypeCalculated,
+		"option.type":                       calculator.TypeAggregation,
+		"option.aggregation.bk_asst_obj_id": objID,
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	attributes := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attributes); err != nil {
+		blog.Errorf("get aggregation attributes failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	ownerObjIDs := make([]string, 0)
+	for _, attr := range parseCalculatedAttributes(kit, attributes) {
+		if attr.option.Aggregation.Func == calculator.FunctionCount ||
+			!util.InStrArr(fields, attr.option.Aggregation.Field) {
+			continue
+		}
+		if !util.InStrArr(ownerObjIDs, attr.ObjectID) {
+			ownerObjIDs = append(ownerObjIDs, attr.ObjectID)
+		}
+	}
+
+	for _, ownerObjID := range ownerObjIDs {
+		ownerInstIDs, err := m.getAssociatedInstIDs(kit, objID, instIDs, ownerObjID)
+		if err != nil {
+			return err
+		}
+
+		if err := m.RecalculateOrDeferModelInstance(kit, ownerObjID, ownerInstIDs); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
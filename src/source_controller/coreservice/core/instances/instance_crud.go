@@ -22,6 +22,7 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/types"
 	"configcenter/src/storage/driver/mongodb"
 )
 
@@ -43,11 +44,16 @@ func (m *instanceManager) save(kit *rest.Kit, objID string, inputParam mapstr.Ma
 	inputParam.Set(common.BKOwnerIDField, kit.SupplierAccount)
 	inputParam.Set(common.CreateTimeField, ts)
 	inputParam.Set(common.LastTimeField, ts)
+	inputParam.Set(common.BKRevisionField, 1)
 	err = mongodb.Client().Table(tableName).Insert(kit.Ctx, inputParam)
 	return id, err
 }
 
-func (m *instanceManager) update(kit *rest.Kit, objID string, data mapstr.MapStr, cond mapstr.MapStr) error {
+// update the instances and increase their revision, if the expected revision is set, only the instances
+// in that revision are updated. returns the number of the updated instances.
+func (m *instanceManager) update(kit *rest.Kit, objID string, data mapstr.MapStr, cond mapstr.MapStr,
+	revision *int64) (uint64, error) {
+
 	if objID == common.BKInnerObjIDHost {
 		data = metadata.ConvertHostSpecialStringToArray(data)
 	}
@@ -58,7 +64,21 @@ func (m *instanceManager) update(kit *rest.Kit, objID string, data mapstr.MapStr
 	ts := time.Now()
 	data.Set(common.LastTimeField, ts)
 	data.Remove(common.BKObjIDField)
-	return mongodb.Client().Table(tableName).Update(kit.Ctx, cond, data)
+	data.Remove(common.BKRevisionField)
+
+	filter := cond
+	if revision != nil {
+		revisionCond := mapstr.MapStr{common.BKRevisionField: *revision}
+		if *revision == 0 {
+			// the instance that has never been updated since the revision is introduced has no revision field
+			revisionCond = mapstr.MapStr{common.BKRevisionField: mapstr.MapStr{common.BKDBIN: []interface{}{0, nil}}}
+		}
+		filter = mapstr.MapStr{common.BKDBAND: []mapstr.MapStr{cond, revisionCond}}
+	}
+
+	return mongodb.Client().Table(tableName).UpdateMultiModelCount(kit.Ctx, filter,
+		types.ModeUpdate{Op: "set", Doc: data},
+		types.ModeUpdate{Op: "inc", Doc: mapstr.MapStr{common.BKRevisionField: 1}})
 }
 
 func (m *instanceManager) getInsts(kit *rest.Kit, objID string, cond mapstr.MapStr) (origins []mapstr.MapStr, exists bool, err error) {
@@ -110,8 +130,17 @@ func (m *instanceManager) countInstance(kit *rest.Kit, objID string, cond mapstr
 		cond[common.BKObjIDField] = objID
 	}
 
-	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+	cond = setInstanceQueryOwner(kit, objID, cond)
 	count, err = mongodb.Client().Table(tableName).Find(cond).Count(kit.Ctx)
 
 	return count, err
 }
+
+// setInstanceQueryOwner limits the instance query to the request's supplier account, the cloud areas
+// are global resources, so the default supplier account's cloud areas are shared to all the tenants.
+func setInstanceQueryOwner(kit *rest.Kit, objID string, cond mapstr.MapStr) mapstr.MapStr {
+	if objID == common.BKInnerObjIDPlat {
+		return util.SetSharedQueryOwner(cond, kit.SupplierAccount)
+	}
+	return util.SetQueryOwner(cond, kit.SupplierAccount)
+}
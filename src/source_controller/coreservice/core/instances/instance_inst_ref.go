@@ -0,0 +1,222 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core/instances/instref"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// instRefAttribute is an inst_ref attribute with its parsed option
+type instRefAttribute struct {
+	metadata.Attribute
+	option *metadata.InstRefOption
+}
+
+// getInstRefAttributes get the inst_ref attributes, cond is the extra condition to filter the attributes
+func (m *instanceManager) getInstRefAttributes(kit *rest.Kit, cond map[string]interface{}) ([]instRefAttribute, error) {
+	cond[common.BKPropertyTypeField] = common.FieldTypeInstRef
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	attrs := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attrs); err != nil {
+		blog.Errorf("get inst_ref attributes failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	refAttrs := make([]instRefAttribute, 0)
+	for _, attr := range attrs {
+		option, err := metadata.ParseInstRefOption(attr.Option)
+		if err != nil {
+			blog.Warnf("parse inst_ref attribute %s option failed, skip it, err: %v, rid: %s", attr.PropertyID, err, kit.Rid)
+			continue
+		}
+		refAttrs = append(refAttrs, instRefAttribute{Attribute: attr, option: option})
+	}
+	return refAttrs, nil
+}
+
+// HandleInstRefBeforeDelete handle the instances that reference the instances to be deleted. the instances which
+// reference them by cascade attributes, directly or indirectly, are deleted; if any of them is referenced by a
+// restrict attribute of an instance that is not deleted, the deletion is not allowed.
+func (m *instanceManager) HandleInstRefBeforeDelete(kit *rest.Kit, objID string, instIDs []int64) error {
+	if len(instIDs) == 0 {
+		return nil
+	}
+
+	refAttrs, err := m.getInstRefAttributes(kit, make(map[string]interface{}))
+	if err != nil {
+		return err
+	}
+	if len(refAttrs) == 0 {
+		return nil
+	}
+
+	attrs := make([]instref.Attribute, len(refAttrs))
+	for idx, attr := range refAttrs {
+		attrs[idx] = instref.Attribute{
+			ObjID:        attr.ObjectID,
+			PropertyID:   attr.PropertyID,
+			PropertyName: attr.PropertyName,
+			RefObjID:     attr.option.ObjID,
+			OnDelete:     attr.option.OnDelete,
+		}
+	}
+
+	cascades, err := instref.Resolve(objID, instIDs, attrs, &instRefFinder{kit: kit})
+	if err != nil {
+		if restrictErr, ok := err.(*instref.RestrictError); ok {
+			blog.Errorf("%s instances %v are referenced by %s attribute %s of instances %v, rid: %s", objID, instIDs,
+				restrictErr.Attribute.ObjID, restrictErr.Attribute.PropertyID, restrictErr.InstIDs, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCoreServiceInstReferred, restrictErr.Attribute.ObjID,
+				restrictErr.Attribute.PropertyName)
+		}
+		return err
+	}
+
+	// all the referencing instances are resolved, so they are deleted without handling their references again
+	for refObjID, refInstIDs := range cascades {
+		cond := mapstr.MapStr{common.GetInstIDField(refObjID): map[string]interface{}{common.BKDBIN: refInstIDs}}
+		if common.GetInstTableName(refObjID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = refObjID
+		}
+
+		if _, err := m.cascadeDeleteModelInstance(kit, refObjID, metadata.DeleteOption{Condition: cond}, false); err != nil {
+			blog.Errorf("cascade delete referencing %s instances %v failed, err: %v, rid: %s", refObjID, refInstIDs,
+				err, kit.Rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// instRefFinder finds the referencing instances in db
+type instRefFinder struct {
+	kit *rest.Kit
+}
+
+// FindReferencing find the ids of the instances whose inst_ref attribute references the instances
+func (f *instRefFinder) FindReferencing(attr instref.Attribute, instIDs []int64) ([]int64, error) {
+	cond := map[string]interface{}{attr.PropertyID: map[string]interface{}{common.BKDBIN: instIDs}}
+	if common.GetInstTableName(attr.ObjID) == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = attr.ObjID
+	}
+	cond = util.SetQueryOwner(cond, f.kit.SupplierAccount)
+
+	ids, err := mongodb.Client().Table(common.GetInstTableName(attr.ObjID)).Distinct(f.kit.Ctx,
+		common.GetInstIDField(attr.ObjID), cond)
+	if err != nil {
+		blog.Errorf("get referencing instances failed, err: %v, cond: %#v, rid: %s", err, cond, f.kit.Rid)
+		return nil, f.kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	instIDList, err := util.SliceInterfaceToInt64(ids)
+	if err != nil {
+		blog.Errorf("parse referencing instance ids %v failed, err: %v, rid: %s", ids, err, f.kit.Rid)
+		return nil, f.kit.CCError.CCError(common.CCErrCommParseDBFailed)
+	}
+	return instIDList, nil
+}
+
+// expandInstRef replace the inst_ref attributes' values with the referenced instances' ids and names.
+// single reference value is expanded to {"bk_inst_id": 1, "bk_inst_name": "name"}, multiple one to an array of it.
+func (m *instanceManager) expandInstRef(kit *rest.Kit, objID string, insts []mapstr.MapStr) error {
+	if len(insts) == 0 {
+		return nil
+	}
+
+	refAttrs, err := m.getInstRefAttributes(kit, map[string]interface{}{common.BKObjIDField: objID})
+	if err != nil {
+		return err
+	}
+
+	// referenced model -> referenced instance ids
+	refInstIDs := make(map[string][]int64)
+	for _, attr := range refAttrs {
+		for _, inst := range insts {
+			instIDs, err := metadata.GetInstRefIDs(inst[attr.PropertyID])
+			if err != nil {
+				blog.Warnf("inst_ref attribute %s value %#v invalid, rid: %s", attr.PropertyID, inst[attr.PropertyID], kit.Rid)
+				continue
+			}
+			refInstIDs[attr.option.ObjID] = append(refInstIDs[attr.option.ObjID], instIDs...)
+		}
+	}
+
+	// referenced model -> referenced instance id -> referenced instance name
+	refInstNames := make(map[string]map[int64]interface{})
+	for refObjID, instIDs := range refInstIDs {
+		if len(instIDs) == 0 {
+			continue
+		}
+
+		instIDField := common.GetInstIDField(refObjID)
+		instNameField := common.GetInstNameField(refObjID)
+		cond := map[string]interface{}{instIDField: map[string]interface{}{common.BKDBIN: util.IntArrayUnique(instIDs)}}
+		if common.GetInstTableName(refObjID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = refObjID
+		}
+		cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+		refInsts := make([]mapstr.MapStr, 0)
+		err := mongodb.Client().Table(common.GetInstTableName(refObjID)).Find(cond).Fields(instIDField,
+			instNameField).All(kit.Ctx, &refInsts)
+		if err != nil {
+			blog.Errorf("get referenced instances failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		refInstNames[refObjID] = make(map[int64]interface{})
+		for _, refInst := range refInsts {
+			instID, err := util.GetInt64ByInterface(refInst[instIDField])
+			if err != nil {
+				continue
+			}
+			refInstNames[refObjID][instID] = refInst[instNameField]
+		}
+	}
+
+	for _, attr := range refAttrs {
+		for _, inst := range insts {
+			if _, exists := inst[attr.PropertyID]; !exists {
+				continue
+			}
+
+			instIDs, err := metadata.GetInstRefIDs(inst[attr.PropertyID])
+			if err != nil {
+				continue
+			}
+
+			expanded := make([]mapstr.MapStr, 0)
+			for _, instID := range instIDs {
+				expanded = append(expanded, mapstr.MapStr{
+					common.BKInstIDField:   instID,
+					common.BKInstNameField: refInstNames[attr.option.ObjID][instID],
+				})
+			}
+
+			if attr.option.Multiple {
+				inst[attr.PropertyID] = expanded
+			} else if len(expanded) > 0 {
+				inst[attr.PropertyID] = expanded[0]
+			}
+		}
+	}
+	return nil
+}
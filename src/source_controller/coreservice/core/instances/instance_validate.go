@@ -189,7 +189,8 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 			continue
 		}
 		property, ok := valid.properties[key]
-		if !ok {
+		if !ok || property.PropertyType == common.FieldTypeCalculated {
+			// calculated attribute's value is computed by the server, can not be set by the user
 			delete(instanceData, key)
 			continue
 		}
@@ -205,6 +206,10 @@ func (m *instanceManager) validCreateInstanceData(kit *rest.Kit, objID string, i
 		}
 	}
 
+	if err := valid.validInstRef(kit, instanceData); err != nil {
+		return err
+	}
+
 	skip, err := hooks.IsSkipValidateHook(kit, objID, instanceData)
 	if err != nil {
 		blog.Errorf("check is skip validate %s hook failed, err: %v, rid: %s", objID, err, kit.Rid)
@@ -315,7 +320,7 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, u
 		}
 
 		property, ok := valid.properties[key]
-		if !ok || (!property.IsEditable && !canEditAll) {
+		if !ok || (!property.IsEditable && !canEditAll) || property.PropertyType == common.FieldTypeCalculated {
 			delete(updateData, key)
 			continue
 		}
@@ -331,6 +336,10 @@ func (m *instanceManager) validUpdateInstanceData(kit *rest.Kit, objID string, u
 		}
 	}
 
+	if err := valid.validInstRef(kit, updateData); err != nil {
+		return err
+	}
+
 	if err := m.changeStringToTime(updateData, valid.propertySlice); err != nil {
 		blog.Errorf("there is an error in converting the time type string to the time type, err: %s, rid: %s", err, kit.Rid)
 		return err
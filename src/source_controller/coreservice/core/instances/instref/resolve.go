@@ -0,0 +1,142 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package instref resolves the instances affected by deleting the instances that are referenced by the
+// inst_ref attributes of the other instances.
+package instref
+
+import (
+	"fmt"
+	"sort"
+
+	"configcenter/src/common"
+)
+
+// Attribute is an inst_ref attribute of model ObjID which references the instances of model RefObjID.
+type Attribute struct {
+	ObjID        string
+	PropertyID   string
+	PropertyName string
+	RefObjID     string
+	OnDelete     string
+}
+
+// Finder finds the ids of the instances of the attribute's model whose attribute references the instances.
+type Finder interface {
+	FindReferencing(attr Attribute, instIDs []int64) ([]int64, error)
+}
+
+// RestrictError is returned when the instances to be deleted are referenced by a restrict attribute.
+type RestrictError struct {
+	Attribute Attribute
+	// InstIDs the referencing instances of the attribute's model which are not deleted
+	InstIDs []int64
+}
+
+// Error implements the error interface
+func (e *RestrictError) Error() string {
+	return fmt.Sprintf("instances are referenced by %s attribute %s of instances %v", e.Attribute.ObjID,
+		e.Attribute.PropertyID, e.InstIDs)
+}
+
+// Resolve resolves all the instances that are deleted along with the instances of model objID, they are the
+// instances which reference the deleted instances by cascade attributes, directly or indirectly. every instance
+// is only visited once, so the models that reference each other are resolved without endless recursion.
+// RestrictError is returned if any of the deleted instances is referenced by a restrict attribute of an instance
+// that is not deleted. returns model -> the ids of the instances to be deleted, excluding the given ones.
+func Resolve(objID string, instIDs []int64, attrs []Attribute, finder Finder) (map[string][]int64, error) {
+	type item struct {
+		objID   string
+		instIDs []int64
+	}
+
+	deleted := make(map[string]map[int64]struct{})
+	addDeleted := func(objID string, ids []int64) []int64 {
+		if deleted[objID] == nil {
+			deleted[objID] = make(map[int64]struct{})
+		}
+		added := make([]int64, 0)
+		for _, id := range ids {
+			if _, exists := deleted[objID][id]; exists {
+				continue
+			}
+			deleted[objID][id] = struct{}{}
+			added = append(added, id)
+		}
+		return added
+	}
+
+	queue := []item{{objID: objID, instIDs: addDeleted(objID, instIDs)}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, attr := range attrs {
+			if attr.RefObjID != current.objID || attr.OnDelete != common.InstRefOnDeleteCascade {
+				continue
+			}
+
+			refIDs, err := finder.FindReferencing(attr, current.instIDs)
+			if err != nil {
+				return nil, err
+			}
+
+			if added := addDeleted(attr.ObjID, refIDs); len(added) > 0 {
+				queue = append(queue, item{objID: attr.ObjID, instIDs: added})
+			}
+		}
+	}
+
+	// check the restrict attributes after all the deleted instances are resolved, an instance referenced by
+	// a restrict attribute can still be deleted if the referencing instance is deleted too.
+	for _, attr := range attrs {
+		if attr.OnDelete == common.InstRefOnDeleteCascade || len(deleted[attr.RefObjID]) == 0 {
+			continue
+		}
+
+		refIDs, err := finder.FindReferencing(attr, sortedIDs(deleted[attr.RefObjID]))
+		if err != nil {
+			return nil, err
+		}
+
+		remains := make([]int64, 0)
+		for _, id := range refIDs {
+			if _, exists := deleted[attr.ObjID][id]; !exists {
+				remains = append(remains, id)
+			}
+		}
+		if len(remains) > 0 {
+			return nil, &RestrictError{Attribute: attr, InstIDs: remains}
+		}
+	}
+
+	for _, id := range instIDs {
+		delete(deleted[objID], id)
+	}
+
+	result := make(map[string][]int64)
+	for delObjID, ids := range deleted {
+		if len(ids) > 0 {
+			result[delObjID] = sortedIDs(ids)
+		}
+	}
+	return result, nil
+}
+
+func sortedIDs(idMap map[int64]struct{}) []int64 {
+	ids := make([]int64, 0, len(idMap))
+	for id := range idMap {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
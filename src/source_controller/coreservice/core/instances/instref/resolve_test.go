@@ -0,0 +1,145 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instref
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+)
+
+// fakeFinder stores the references as model -> attribute -> instance id -> referenced instance ids
+type fakeFinder struct {
+	refs  map[string]map[string]map[int64][]int64
+	calls int
+}
+
+func (f *fakeFinder) FindReferencing(attr Attribute, instIDs []int64) ([]int64, error) {
+	f.calls++
+	if f.calls > 100 {
+		panic("too many calls, the resolving does not terminate")
+	}
+
+	result := make([]int64, 0)
+	for id, refIDs := range f.refs[attr.ObjID][attr.PropertyID] {
+		for _, refID := range refIDs {
+			if containsID(instIDs, refID) {
+				result = append(result, id)
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func TestResolve(t *testing.T) {
+	cascadeAB := Attribute{ObjID: "a", PropertyID: "ref_b", RefObjID: "b", OnDelete: common.InstRefOnDeleteCascade}
+	cascadeBA := Attribute{ObjID: "b", PropertyID: "ref_a", RefObjID: "a", OnDelete: common.InstRefOnDeleteCascade}
+	cascadeAA := Attribute{ObjID: "a", PropertyID: "parent", RefObjID: "a", OnDelete: common.InstRefOnDeleteCascade}
+	cascadeCB := Attribute{ObjID: "c", PropertyID: "ref_b", RefObjID: "b", OnDelete: common.InstRefOnDeleteCascade}
+	restrictCA := Attribute{ObjID: "c", PropertyID: "ref_a", RefObjID: "a", OnDelete: common.InstRefOnDeleteRestrict}
+
+	tests := []struct {
+		name     string
+		attrs    []Attribute
+		refs     map[string]map[string]map[int64][]int64
+		instIDs  []int64
+		expected map[string][]int64
+		restrict bool
+	}{
+		{
+			name:  "models cascade reference each other",
+			attrs: []Attribute{cascadeAB, cascadeBA},
+			refs: map[string]map[string]map[int64][]int64{
+				"a": {"ref_b": {1: {10}, 2: {11}}},
+				"b": {"ref_a": {10: {1}, 11: {2}, 12: {3}}},
+			},
+			instIDs:  []int64{1},
+			expected: map[string][]int64{"b": {10}},
+		},
+		{
+			name:  "model references itself",
+			attrs: []Attribute{cascadeAA},
+			refs: map[string]map[string]map[int64][]int64{
+				"a": {"parent": {1: {2}, 2: {1}, 3: {2}, 4: {5}}},
+			},
+			instIDs:  []int64{1},
+			expected: map[string][]int64{"a": {2, 3}},
+		},
+		{
+			name:  "indirect cascade",
+			attrs: []Attribute{cascadeBA, cascadeCB},
+			refs: map[string]map[string]map[int64][]int64{
+				"b": {"ref_a": {10: {1}, 11: {2}}},
+				"c": {"ref_b": {20: {10, 11}, 21: {11}}},
+			},
+			instIDs:  []int64{1},
+			expected: map[string][]int64{"b": {10}, "c": {20}},
+		},
+		{
+			name:  "referenced by restrict attribute",
+			attrs: []Attribute{cascadeBA, restrictCA},
+			refs: map[string]map[string]map[int64][]int64{
+				"b": {"ref_a": {10: {1}}},
+				"c": {"ref_a": {20: {1}}},
+			},
+			instIDs:  []int64{1},
+			restrict: true,
+		},
+		{
+			name:  "restrict referencing instance is deleted too",
+			attrs: []Attribute{cascadeBA, cascadeCB, restrictCA},
+			refs: map[string]map[string]map[int64][]int64{
+				"b": {"ref_a": {10: {1}}},
+				"c": {"ref_b": {20: {10}}, "ref_a": {20: {1}}},
+			},
+			instIDs:  []int64{1},
+			expected: map[string][]int64{"b": {10}, "c": {20}},
+		},
+		{
+			name:     "not referenced",
+			attrs:    []Attribute{cascadeBA, restrictCA},
+			refs:     map[string]map[string]map[int64][]int64{},
+			instIDs:  []int64{1},
+			expected: map[string][]int64{},
+		},
+	}
+
+	for _, test := range tests {
+		result, err := Resolve("a", test.instIDs, test.attrs, &fakeFinder{refs: test.refs})
+		if test.restrict {
+			if _, ok := err.(*RestrictError); !ok {
+				t.Errorf("%s: expect restrict error, got %v", test.name, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: resolve failed, err: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("%s: expect %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
@@ -32,6 +32,8 @@ type validator struct {
 	dependent     OperationDependences
 	objID         string
 	language      language.CCLanguageIf
+	// calculatedAttrs the attributes whose values are computed by the server
+	calculatedAttrs []calculatedAttribute
 }
 
 // Init init
@@ -61,6 +63,7 @@ func NewValidator(kit *rest.Kit, dependent OperationDependences, objID string, b
 			valid.requireFields = append(valid.requireFields, attr.PropertyID)
 		}
 	}
+	valid.calculatedAttrs = parseCalculatedAttributes(kit, valid.propertySlice)
 
 	uniqueAttrs, err := valid.dependent.SearchUnique(kit, valid.objID)
 	if nil != err {
@@ -0,0 +1,94 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// validInstRef check that the instances referenced by the inst_ref attributes exist, and normalize the values,
+// the value of a single reference attribute is saved as an id, the multiple one is saved as an id array.
+func (valid *validator) validInstRef(kit *rest.Kit, data mapstr.MapStr) error {
+	for _, property := range valid.propertySlice {
+		if property.PropertyType != common.FieldTypeInstRef {
+			continue
+		}
+
+		val, exists := data[property.PropertyID]
+		if !exists {
+			continue
+		}
+
+		option, err := metadata.ParseInstRefOption(property.Option)
+		if err != nil {
+			blog.Errorf("parse inst_ref attribute %s option failed, err: %v, rid: %s", property.PropertyID, err, kit.Rid)
+			return valid.errIf.CCErrorf(common.CCErrCommParamsInvalid, property.PropertyID)
+		}
+
+		instIDs, err := metadata.GetInstRefIDs(val)
+		if err != nil {
+			blog.Errorf("inst_ref attribute %s value %#v invalid, err: %v, rid: %s", property.PropertyID, val, err, kit.Rid)
+			return valid.errIf.CCErrorf(common.CCErrCommParamsNeedInt, property.PropertyID)
+		}
+		instIDs = util.IntArrayUnique(instIDs)
+
+		if len(instIDs) == 0 {
+			if option.Multiple {
+				data[property.PropertyID] = instIDs
+			} else {
+				data[property.PropertyID] = nil
+			}
+			continue
+		}
+
+		if !option.Multiple && len(instIDs) > 1 {
+			blog.Errorf("inst_ref attribute %s is single but referenced instances %v, rid: %s", property.PropertyID,
+				instIDs, kit.Rid)
+			return valid.errIf.CCErrorf(common.CCErrCommParamsInvalid, property.PropertyID)
+		}
+
+		cond := map[string]interface{}{
+			common.GetInstIDField(option.ObjID): map[string]interface{}{common.BKDBIN: instIDs},
+		}
+		if common.GetInstTableName(option.ObjID) == common.BKTableNameBaseInst {
+			cond[common.BKObjIDField] = option.ObjID
+		}
+		cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+		cnt, err := mongodb.Client().Table(common.GetInstTableName(option.ObjID)).Find(cond).Count(kit.Ctx)
+		if err != nil {
+			blog.Errorf("count referenced instances failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+			return valid.errIf.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		if cnt != uint64(len(instIDs)) {
+			blog.Errorf("inst_ref attribute %s referenced %s instances %v not all exist, rid: %s", property.PropertyID,
+				option.ObjID, instIDs, kit.Rid)
+			return valid.errIf.CCErrorf(common.CCErrCoreServiceInstRefNotExist, property.PropertyID, instIDs)
+		}
+
+		if option.Multiple {
+			data[property.PropertyID] = instIDs
+		} else {
+			data[property.PropertyID] = instIDs[0]
+		}
+	}
+
+	return nil
+}
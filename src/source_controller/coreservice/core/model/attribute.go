@@ -113,6 +113,11 @@ func (m *modelAttribute) CreateModelAttributes(kit *rest.Kit, objID string, inpu
 		})
 	}
 
+	if len(dataResult.CreateManyInfoResult.Created) > 0 {
+		if err := saveSchemaVersion(kit, "create attribute", objID); err != nil {
+			return dataResult, err
+		}
+	}
 	return dataResult, nil
 }
 
@@ -176,6 +181,11 @@ func (m *modelAttribute) SetModelAttributes(kit *rest.Kit, objID string, inputPa
 
 	}
 
+	if len(dataResult.Created) > 0 || len(dataResult.Updated) > 0 {
+		if err := saveSchemaVersion(kit, "set attribute", objID); err != nil {
+			return dataResult, err
+		}
+	}
 	return dataResult, nil
 }
 func (m *modelAttribute) UpdateModelAttributes(kit *rest.Kit, objID string, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
@@ -196,6 +206,9 @@ func (m *modelAttribute) UpdateModelAttributes(kit *rest.Kit, objID string, inpu
 		blog.ErrorJSON("UpdateModelAttributes failed, update attributes failed, model:%s, attributes:%s, condition: %s, err: %s, rid: %s", inputParam.Data, objID, cond, err.Error(), kit.Rid)
 		return &metadata.UpdatedCount{}, err
 	}
+	if err := saveSchemaVersion(kit, "update attribute", objID); err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
 
 	return &metadata.UpdatedCount{Count: cnt}, nil
 }
@@ -242,6 +255,9 @@ func (m *modelAttribute) UpdateModelAttributesIndex(kit *rest.Kit, objID string,
 			return result, kit.CCError.Error(common.CCErrCommDBSelectFailed)
 		}
 
+		if err := saveSchemaVersion(kit, "update attribute index", objID); err != nil {
+			return result, err
+		}
 		result, err := m.buildUpdateAttrIndexReturn(kit, objID, propertyGroupStr)
 		if err != nil {
 			blog.Errorf("UpdateModelAttributesIndex, update index success, but build return data failed, rid: %s, err: %s", kit.Rid, err.Error())
@@ -283,6 +299,9 @@ func (m *modelAttribute) UpdateModelAttributesIndex(kit *rest.Kit, objID string,
 		return result, kit.CCError.Error(common.CCErrCommDBSelectFailed)
 	}
 
+	if err := saveSchemaVersion(kit, "update attribute index", objID); err != nil {
+		return result, err
+	}
 	result, err = m.buildUpdateAttrIndexReturn(kit, objID, propertyGroupStr)
 	if err != nil {
 		blog.Errorf("UpdateModelAttributesIndex, update index success, but build return data failed, rid: %s, err: %s", kit.Rid, err.Error())
@@ -300,11 +319,19 @@ func (m *modelAttribute) UpdateModelAttributesByCondition(kit *rest.Kit, inputPa
 		return &metadata.UpdatedCount{}, err
 	}
 
+	objIDs, err := getAttributeObjIDs(kit, cond.ToMapStr())
+	if err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
+
 	cnt, err := m.update(kit, inputParam.Data, cond)
 	if nil != err {
 		blog.Errorf("UpdateModelAttributesByCondition failed, failed to update fields (%#v) by condition(%#v), err: %s, rid: %s", inputParam.Data, cond.ToMapStr(), err.Error(), kit.Rid)
 		return &metadata.UpdatedCount{}, err
 	}
+	if err := saveSchemaVersion(kit, "update attribute", objIDs...); err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
 
 	return &metadata.UpdatedCount{Count: cnt}, nil
 }
@@ -324,7 +351,14 @@ func (m *modelAttribute) DeleteModelAttributes(kit *rest.Kit, objID string, inpu
 
 	cond.Element(&mongo.Eq{Key: metadata.AttributeFieldSupplierAccount, Val: kit.SupplierAccount})
 	cnt, err := m.delete(kit, cond)
-	return &metadata.DeletedCount{Count: cnt}, err
+	if err != nil {
+		return &metadata.DeletedCount{Count: cnt}, err
+	}
+
+	if err := saveSchemaVersion(kit, "delete attribute", objID); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
+	return &metadata.DeletedCount{Count: cnt}, nil
 }
 
 func (m *modelAttribute) SearchModelAttributes(kit *rest.Kit, objID string, inputParam metadata.QueryCondition) (*metadata.QueryModelAttributeDataResult, error) {
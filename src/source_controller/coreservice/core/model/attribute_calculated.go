@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/calculator"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// checkCalculatedOption check that the attributes used by the calculated attribute exist and are numeric.
+// calculated attributes can not be used by another calculated attribute, so that there is no calculate order.
+func (m *modelAttribute) checkCalculatedOption(kit *rest.Kit, objID string, bizID int64, option interface{}) error {
+	opt, err := calculator.ParseOption(option)
+	if err != nil {
+		blog.Errorf("parse calculated attribute option %#v failed, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	expr, err := opt.Validate()
+	if err != nil {
+		blog.Errorf("calculated attribute option %#v is invalid, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	if opt.Type == calculator.TypeExpression {
+		return m.checkCalculatedFields(kit, objID, bizID, expr.Variables())
+	}
+
+	cond := map[string]interface{}{common.BKObjIDField: opt.Aggregation.AsstObjID}
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count aggregation model failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		blog.Errorf("aggregation model %s not exists, rid: %s", opt.Aggregation.AsstObjID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "bk_asst_obj_id")
+	}
+
+	if opt.Aggregation.Func == calculator.FunctionCount {
+		return nil
+	}
+	return m.checkCalculatedFields(kit, opt.Aggregation.AsstObjID, bizID, []string{opt.Aggregation.Field})
+}
+
+// checkCalculatedFields check that the fields are int or float attributes of the model
+func (m *modelAttribute) checkCalculatedFields(kit *rest.Kit, objID string, bizID int64, fields []string) error {
+	cond := map[string]interface{}{
+		common.BKObjIDField:      objID,
+		common.BKPropertyIDField: map[string]interface{}{common.BKDBIN: fields},
+		common.BKAppIDField:      map[string]interface{}{common.BKDBIN: []int64{0, bizID}},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	attrs := make([]metadata.Attribute, 0)
+	err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &attrs)
+	if err != nil {
+		blog.Errorf("get calculated fields failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	attrMap := make(map[string]metadata.Attribute)
+	for _, attr := range attrs {
+		attrMap[attr.PropertyID] = attr
+	}
+
+	for _, field := range fields {
+		attr, exists := attrMap[field]
+		if !exists {
+			blog.Errorf("calculated field %s not exists in model %s, rid: %s", field, objID, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, field)
+		}
+		if attr.PropertyType != common.FieldTypeInt && attr.PropertyType != common.FieldTypeFloat {
+			blog.Errorf("calculated field %s type %s is not numeric, rid: %s", field, attr.PropertyType, kit.Rid)
+			return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, field)
+		}
+	}
+	return nil
+}
+
+// checkAttributeReferredByCalculated check if the attributes to be deleted are used by calculated attributes
+func (m *modelAttribute) checkAttributeReferredByCalculated(kit *rest.Kit, attrs []metadata.Attribute) error {
+	objFields := make(map[string][]string)
+	for _, attr := range attrs {
+		objFields[attr.ObjectID] = append(objFields[attr.ObjectID], attr.PropertyID)
+	}
+
+	objIDs := make([]string, 0)
+	for objID := range objFields {
+		objIDs = append(objIDs, objID)
+	}
+
+	cond := map[string]interface{}{
+		common.BKPropertyTypeField: common.FieldTypeCalculated,
+		common.BKDBOR: []map[string]interface{}{
+			{common.BKObjIDField: map[string]interface{}{common.BKDBIN: objIDs}},
+			{"option.aggregation.bk_asst_obj_id": map[string]interface{}{common.BKDBIN: objIDs}},
+		},
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	calcAttrs := make([]metadata.Attribute, 0)
+	if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).All(kit.Ctx, &calcAttrs); err != nil {
+		blog.Errorf("get calculated attributes failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	deleted := make(map[int64]bool)
+	for _, attr := range attrs {
+		deleted[attr.ID] = true
+	}
+
+	for _, calcAttr := range calcAttrs {
+		// the calculated attribute is deleted too
+		if deleted[calcAttr.ID] {
+			continue
+		}
+
+		opt, err := calculator.ParseOption(calcAttr.Option)
+		if err != nil {
+			blog.Warnf("parse calculated attribute %s option failed, err: %v, rid: %s", calcAttr.PropertyID, err, kit.Rid)
+			continue
+		}
+
+		refObjID, refFields := calcAttr.ObjectID, make([]string, 0)
+		switch opt.Type {
+		case calculator.TypeExpression:
+			expr, err := calculator.Parse(opt.Expression)
+			if err != nil {
+				continue
+			}
+			refFields = expr.Variables()
+		case calculator.TypeAggregation:
+			if opt.Aggregation == nil {
+				continue
+			}
+			refObjID = opt.Aggregation.AsstObjID
+			refFields = append(refFields, opt.Aggregation.Field)
+		}
+
+		for _, field := range refFields {
+			if util.InStrArr(objFields[refObjID], field) {
+				blog.Errorf("attribute %s.%s is referred by calculated attribute %s.%s, rid: %s", refObjID, field,
+					calcAttr.ObjectID, calcAttr.PropertyID, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCoreServiceAttributeReferredByCalculated, field,
+					calcAttr.PropertyName)
+			}
+		}
+	}
+	return nil
+}
+
+// recalculateCalculatedAttributes recalculate the instances of the models whose calculated attributes are changed
+// in background tasks, because a model may have too many instances to be recalculated in the request. failure is
+// only logged because the attributes are already saved, the values can be corrected with the recalculate api.
+func (m *modelAttribute) recalculateCalculatedAttributes(kit *rest.Kit, attrs []metadata.Attribute) {
+	objIDs := make([]string, 0)
+	for _, attr := range attrs {
+		if attr.PropertyType == common.FieldTypeCalculated {
+			objIDs = append(objIDs, attr.ObjectID)
+		}
+	}
+
+	for _, objID := range util.StrArrayUnique(objIDs) {
+		if err := m.model.dependent.RecalculateAllInstances(kit, objID); err != nil {
+			blog.Errorf("defer recalculating model %s instances failed, err: %v, rid: %s", objID, err, kit.Rid)
+		}
+	}
+}
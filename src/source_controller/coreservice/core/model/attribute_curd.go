@@ -77,6 +77,12 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 	attribute.ID = int64(id)
 	attribute.OwnerID = kit.SupplierAccount
 
+	// calculated attribute's value is computed by the server, it can not be edited or required
+	if attribute.PropertyType == common.FieldTypeCalculated {
+		attribute.IsEditable = false
+		attribute.IsRequired = false
+	}
+
 	if nil == attribute.CreateTime {
 		attribute.CreateTime = &metadata.Time{}
 		attribute.CreateTime.Time = time.Now()
@@ -92,7 +98,12 @@ func (m *modelAttribute) save(kit *rest.Kit, attribute metadata.Attribute) (id u
 	}
 
 	err = mongodb.Client().Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, attribute)
-	return id, err
+	if err != nil {
+		return id, err
+	}
+
+	m.recalculateCalculatedAttributes(kit, []metadata.Attribute{attribute})
+	return id, nil
 }
 
 func (m *modelAttribute) checkUnique(kit *rest.Kit, isCreate bool, objID, propertyID, propertyName string, modelBizID int64) error {
@@ -216,7 +227,8 @@ func (m *modelAttribute) checkAttributeValidity(kit *rest.Kit, attribute metadat
 	if attribute.PropertyType != "" {
 		switch attribute.PropertyType {
 		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat, common.FieldTypeEnum,
-			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList:
+			common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeUser, common.FieldTypeOrganization, common.FieldTypeTimeZone, common.FieldTypeBool, common.FieldTypeList,
+			common.FieldTypeCalculated, common.FieldTypeInstRef:
 		default:
 			return kit.CCError.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldPropertyType)
 		}
@@ -243,6 +255,15 @@ func (m *modelAttribute) update(kit *rest.Kit, data mapstr.MapStr, cond universa
 		return 0, err
 	}
 
+	if data.Exists(metadata.AttributeFieldOption) {
+		attrs, err := m.search(kit, cond)
+		if err != nil {
+			blog.Errorf("search updated attributes failed, err: %v, cond: %#v, rid: %s", err, cond.ToMapStr(), kit.Rid)
+			return cnt, nil
+		}
+		m.recalculateCalculatedAttributes(kit, attrs)
+	}
+
 	return cnt, err
 }
 
@@ -277,7 +298,8 @@ func (m *modelAttribute) searchReturnMapStr(kit *rest.Kit, cond universalsql.Con
 func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition) (cnt uint64, err error) {
 
 	resultAttrs := make([]metadata.Attribute, 0)
-	fields := []string{common.BKFieldID, common.BKPropertyIDField, common.BKObjIDField, common.BKAppIDField}
+	fields := []string{common.BKFieldID, common.BKPropertyIDField, common.BKObjIDField, common.BKAppIDField,
+		common.BKPropertyNameField}
 
 	condMap := util.SetQueryOwner(cond.ToMapStr(), kit.SupplierAccount)
 	err = mongodb.Client().Table(common.BKTableNameObjAttDes).Find(condMap).Fields(fields...).All(kit.Ctx, &resultAttrs)
@@ -296,6 +318,10 @@ func (m *modelAttribute) delete(kit *rest.Kit, cond universalsql.Condition) (cnt
 		objIDArrMap[attr.ObjectID] = append(objIDArrMap[attr.ObjectID], attr.ID)
 	}
 
+	if err := m.checkAttributeReferredByCalculated(kit, resultAttrs); err != nil {
+		return 0, err
+	}
+
 	if err := m.cleanAttributeFieldInInstances(kit.Ctx, kit.SupplierAccount, resultAttrs); err != nil {
 		blog.ErrorJSON("delete object attributes with cond: %s, but delete these attribute in instance failed, err: %v, rid:%s", condMap, err, kit.Rid)
 		return 0, err
@@ -611,6 +637,18 @@ func (m *modelAttribute) saveCheck(kit *rest.Kit, attribute metadata.Attribute)
 		return err
 	}
 
+	if attribute.PropertyType == common.FieldTypeCalculated {
+		if err := m.checkCalculatedOption(kit, attribute.ObjectID, attribute.BizID, attribute.Option); err != nil {
+			return err
+		}
+	}
+
+	if attribute.PropertyType == common.FieldTypeInstRef {
+		if err := m.checkInstRefOption(kit, attribute.ObjectID, attribute.Option); err != nil {
+			return err
+		}
+	}
+
 	// check name duplicate
 	if err := m.checkUnique(kit, true, attribute.ObjectID, attribute.PropertyID, attribute.PropertyName, attribute.BizID); err != nil {
 		blog.ErrorJSON("save attribute check unique err:%s, input:%s, rid:%s", err.Error(), attribute, kit.Rid)
@@ -669,6 +707,26 @@ func (m *modelAttribute) checkUpdate(kit *rest.Kit, data mapstr.MapStr, cond uni
 			blog.ErrorJSON("valid property option failed, err: %s, data: %s, rid:%s", err, data, kit.Ctx)
 			return changeRow, err
 		}
+		if propertyType == common.FieldTypeCalculated {
+			for _, dbAttribute := range dbAttributeArr {
+				if err := m.checkCalculatedOption(kit, dbAttribute.ObjectID, dbAttribute.BizID, option); err != nil {
+					return changeRow, err
+				}
+			}
+		}
+		if propertyType == common.FieldTypeInstRef {
+			for _, dbAttribute := range dbAttributeArr {
+				if err := m.checkInstRefOptionUpdate(kit, dbAttribute, option); err != nil {
+					return changeRow, err
+				}
+			}
+		}
+	}
+
+	// calculated attribute can not be changed to editable or required
+	if dbAttributeArr[0].PropertyType == common.FieldTypeCalculated {
+		data.Remove(metadata.AttributeFieldIsEditable)
+		data.Remove(metadata.AttributeFieldIsRequired)
 	}
 
 	// 删除不可更新字段， 避免由于传入数据，修改字段
@@ -0,0 +1,89 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// checkInstRefOption check that the referenced model exists, and that the instances of inner or mainline models
+// are not deleted by cascade, they must be deleted with their own apis.
+func (m *modelAttribute) checkInstRefOption(kit *rest.Kit, objID string, option interface{}) error {
+	opt, err := metadata.ParseInstRefOption(option)
+	if err != nil {
+		blog.Errorf("parse inst_ref attribute option %#v failed, err: %v, rid: %s", option, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	cond := map[string]interface{}{common.BKObjIDField: opt.ObjID}
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjDes).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count referenced model failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if cnt == 0 {
+		blog.Errorf("referenced model %s not exists, rid: %s", opt.ObjID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "option."+common.BKObjIDField)
+	}
+
+	if opt.OnDelete != common.InstRefOnDeleteCascade {
+		return nil
+	}
+
+	if common.IsInnerModel(objID) {
+		blog.Errorf("inner model %s attribute can not be deleted by cascade, rid: %s", objID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "option.on_delete")
+	}
+
+	mainlineCond := map[string]interface{}{
+		common.AssociationKindIDField: common.AssociationKindMainline,
+		common.BKObjIDField:           objID,
+	}
+	cnt, err = mongodb.Client().Table(common.BKTableNameObjAsst).Find(mainlineCond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count mainline association failed, err: %v, cond: %#v, rid: %s", err, mainlineCond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	if cnt > 0 {
+		blog.Errorf("mainline model %s attribute can not be deleted by cascade, rid: %s", objID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "option.on_delete")
+	}
+
+	return nil
+}
+
+// checkInstRefOptionUpdate check the updated inst_ref option, the referenced model and cardinality can not be
+// changed, because the values of the existing instances would become invalid.
+func (m *modelAttribute) checkInstRefOptionUpdate(kit *rest.Kit, dbAttribute metadata.Attribute, option interface{}) error {
+	if err := m.checkInstRefOption(kit, dbAttribute.ObjectID, option); err != nil {
+		return err
+	}
+
+	dbOpt, err := metadata.ParseInstRefOption(dbAttribute.Option)
+	if err != nil {
+		blog.Errorf("parse inst_ref attribute %s option failed, err: %v, rid: %s", dbAttribute.PropertyID, err, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	opt, _ := metadata.ParseInstRefOption(option)
+	if opt.ObjID != dbOpt.ObjID || opt.Multiple != dbOpt.Multiple {
+		blog.Errorf("inst_ref attribute %s can not change referenced model or cardinality, rid: %s",
+			dbAttribute.PropertyID, kit.Rid)
+		return kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+	return nil
+}
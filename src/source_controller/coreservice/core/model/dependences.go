@@ -32,4 +32,7 @@ type OperationDependences interface {
 
 	// CascadeDeleteInstances cascade delete all instances(included instances, instance association) associated with modelObjID
 	CascadeDeleteInstances(kit *rest.Kit, objIDS []string) error
+
+	// RecalculateAllInstances recalculate the calculated attributes of all the instances of the model in background
+	RecalculateAllInstances(kit *rest.Kit, objID string) error
 }
@@ -65,6 +65,9 @@ func (g *modelAttributeGroup) CreateModelAttributeGroup(kit *rest.Kit, objID str
 		return dataResult, err
 	}
 	dataResult.Created.ID = id
+	if err := saveSchemaVersion(kit, "create attribute group", objID); err != nil {
+		return dataResult, err
+	}
 	return dataResult, err
 }
 
@@ -106,6 +109,9 @@ func (g *modelAttributeGroup) SetModelAttributeGroup(kit *rest.Kit, objID string
 			return &metadata.SetDataResult{}, err
 		}
 
+		if err := saveSchemaVersion(kit, "create attribute group", objID); err != nil {
+			return &metadata.SetDataResult{}, err
+		}
 		dataResult.CreatedCount.Count++
 		dataResult.Created = []metadata.CreatedDataResult{
 			{
@@ -125,6 +131,9 @@ func (g *modelAttributeGroup) SetModelAttributeGroup(kit *rest.Kit, objID string
 		blog.Errorf("request(%s): it is failed to update the model attribute group (%#v) by the condition (%#v), err: %s", kit.Rid, g, cond, err)
 		return dataResult, err
 	}
+	if err := saveSchemaVersion(kit, "update attribute group", objID); err != nil {
+		return dataResult, err
+	}
 	dataResult.UpdatedCount.Count = cnt
 	dataResult.Updated = []metadata.UpdatedDataResult{
 		{
@@ -184,6 +193,9 @@ func (g *modelAttributeGroup) UpdateModelAttributeGroup(kit *rest.Kit, objID str
 		return &metadata.UpdatedCount{}, err
 	}
 
+	if err := saveSchemaVersion(kit, "update attribute group", objID); err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
 	return &metadata.UpdatedCount{Count: cnt}, nil
 }
 
@@ -226,12 +238,20 @@ func (g *modelAttributeGroup) UpdateModelAttributeGroupByCondition(kit *rest.Kit
 		}
 	}
 
+	objIDs, err := getGroupObjIDs(kit, cond.ToMapStr())
+	if err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
+
 	cnt, err := g.update(kit, inputParam.Data, cond)
 	if nil != err {
 		blog.Errorf("request(%s): it is failed to update the data (%s) by the condition (%#v), error info is %s", kit.Rid, inputParam.Data, err.Error())
 		return &metadata.UpdatedCount{}, err
 	}
 
+	if err := saveSchemaVersion(kit, "update attribute group", objIDs...); err != nil {
+		return &metadata.UpdatedCount{}, err
+	}
 	return &metadata.UpdatedCount{Count: cnt}, nil
 }
 
@@ -330,6 +350,13 @@ func (g *modelAttributeGroup) DeleteModelAttributeGroupByCondition(kit *rest.Kit
 		return &metadata.DeletedCount{}, err
 	}
 
+	objIDs := make([]string, 0)
+	for _, grp := range grps {
+		objIDs = append(objIDs, grp.ObjectID)
+	}
+	if err := saveSchemaVersion(kit, "delete attribute group", objIDs...); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
 
@@ -374,5 +401,8 @@ func (g *modelAttributeGroup) DeleteModelAttributeGroup(kit *rest.Kit, objID str
 		return &metadata.DeletedCount{}, err
 	}
 
+	if err := saveSchemaVersion(kit, "delete attribute group", objID); err != nil {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
@@ -206,6 +206,11 @@ func (m *modelManager) cascadeDelete(kit *rest.Kit, cond universalsql.Condition)
 		return 0, kit.CCError.Error(common.CCErrCommDBSelectFailed)
 	}
 
+	// delete model schema versions
+	if err := deleteSchemaVersions(kit, targetObjIDS); err != nil {
+		return 0, err
+	}
+
 	// delete model
 	if err := mongodb.Client().Table(common.BKTableNameObjDes).Delete(kit.Ctx, delCondMap); err != nil {
 		blog.ErrorJSON("delete model unique error. err:%s, cond:%s, rid:%s", err.Error(), delCondMap, kit.Rid)
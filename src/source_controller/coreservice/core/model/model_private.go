@@ -75,5 +75,10 @@ func (m *modelManager) deleteModelAndAttributes(kit *rest.Kit, targetObjIDS []st
 		return 0, kit.CCError.New(common.CCErrObjectDBOpErrno, err.Error())
 	}
 
+	// delete the schema versions of the model
+	if err := deleteSchemaVersions(kit, targetObjIDS); err != nil {
+		return 0, err
+	}
+
 	return cnt, nil
 }
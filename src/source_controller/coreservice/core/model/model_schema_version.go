@@ -0,0 +1,489 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+const schemaVersionField = "version"
+
+// SearchModelSchemaVersions search the schema versions of the model, the latest version is returned first by default
+func (m *modelManager) SearchModelSchemaVersions(kit *rest.Kit, objID string, inputParam metadata.QueryCondition) (
+	*metadata.QueryModelSchemaVersionResult, error) {
+
+	cond := util.SetQueryOwner(mapstr.MapStr{}, kit.SupplierAccount)
+	for key, val := range inputParam.Condition {
+		cond[key] = val
+	}
+	cond[common.BKObjIDField] = objID
+
+	cnt, err := mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count model schema versions failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := inputParam.Page.Sort
+	if sort == "" {
+		sort = "-" + schemaVersionField
+	}
+
+	versions := make([]metadata.ModelSchemaVersion, 0)
+	err = mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(cond).Fields(inputParam.Fields...).
+		Start(uint64(inputParam.Page.Start)).Limit(uint64(inputParam.Page.Limit)).Sort(sort).All(kit.Ctx, &versions)
+	if err != nil {
+		blog.Errorf("search model schema versions failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.QueryModelSchemaVersionResult{Count: cnt, Info: versions}, nil
+}
+
+// DiffModelSchema get the changes between two schema versions of the model
+func (m *modelManager) DiffModelSchema(kit *rest.Kit, objID string, inputParam metadata.DiffModelSchemaOption) (
+	*metadata.ModelSchemaDiff, error) {
+
+	from, err := getSchemaVersion(kit, objID, inputParam.FromVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := getSchemaVersion(kit, objID, inputParam.ToVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return metadata.DiffModelSchema(from, to), nil
+}
+
+// RollbackModelSchema rollback the schema of the model to the specified version, and save the result as a new
+// version. if an attribute to be deleted or whose type is to be changed holds data, the rollback is refused
+// unless the data mode is drop, in which case the data of the attribute is cleaned from the instances.
+func (m *modelManager) RollbackModelSchema(kit *rest.Kit, objID string, inputParam metadata.RollbackModelSchemaOption) (
+	*metadata.ModelSchemaDiff, error) {
+
+	if err := m.isValid(kit, objID); err != nil {
+		blog.Errorf("rollback model schema failed, validate model(%s) failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, err
+	}
+
+	if inputParam.Version <= 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, schemaVersionField)
+	}
+
+	switch inputParam.DataMode {
+	case metadata.RollbackDataModeRefuse, metadata.RollbackDataModeDrop:
+	default:
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsIsInvalid, "data_mode")
+	}
+
+	target, err := getSchemaVersion(kit, objID, inputParam.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := getCurrentSchema(kit, objID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := metadata.DiffModelSchema(current, target)
+
+	// check all the data to be lost before any change is made
+	droppedAttrs, err := m.getRollbackDroppedAttributes(kit, diff, inputParam.DataMode)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.rollbackGroups(kit, diff, false); err != nil {
+		return nil, err
+	}
+
+	if err := m.rollbackUniques(kit, objID, diff, false); err != nil {
+		return nil, err
+	}
+
+	changedAttrs, err := m.rollbackAttributes(kit, diff, droppedAttrs)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.rollbackUniques(kit, objID, diff, true); err != nil {
+		return nil, err
+	}
+
+	if err := m.rollbackGroups(kit, diff, true); err != nil {
+		return nil, err
+	}
+
+	if err := saveSchemaVersion(kit, fmt.Sprintf("rollback to version %d", inputParam.Version), objID); err != nil {
+		return nil, err
+	}
+	m.modelAttribute.recalculateCalculatedAttributes(kit, changedAttrs)
+
+	diff.ToVersion = inputParam.Version
+	return diff, nil
+}
+
+// getRollbackDroppedAttributes get the attributes that are deleted or whose type are changed by the rollback.
+// the data of these attributes would be lost, so the rollback is refused if they hold data in refuse mode.
+func (m *modelManager) getRollbackDroppedAttributes(kit *rest.Kit, diff *metadata.ModelSchemaDiff,
+	mode metadata.RollbackDataMode) ([]metadata.Attribute, error) {
+
+	droppedAttrs := make([]metadata.Attribute, 0)
+	for _, item := range diff.Attributes {
+		switch item.Action {
+		case metadata.ModelSchemaDiffDelete:
+			droppedAttrs = append(droppedAttrs, item.From.(metadata.Attribute))
+		case metadata.ModelSchemaDiffUpdate:
+			if util.InStrArr(item.ChangedFields, metadata.AttributeFieldPropertyType) {
+				droppedAttrs = append(droppedAttrs, item.From.(metadata.Attribute))
+			}
+		}
+	}
+
+	if mode == metadata.RollbackDataModeDrop {
+		return droppedAttrs, nil
+	}
+
+	for _, attr := range droppedAttrs {
+		hasData, err := hasAttributeData(kit, attr)
+		if err != nil {
+			return nil, err
+		}
+		if hasData {
+			blog.Errorf("rollback will drop attribute %s.%s which holds data, rid: %s", attr.ObjectID, attr.PropertyID,
+				kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceRollbackAttributeHasData, attr.PropertyName)
+		}
+	}
+	return droppedAttrs, nil
+}
+
+// rollbackAttributes delete, create and update the attributes to the target version, returns the changed attributes
+func (m *modelManager) rollbackAttributes(kit *rest.Kit, diff *metadata.ModelSchemaDiff,
+	droppedAttrs []metadata.Attribute) ([]metadata.Attribute, error) {
+
+	changedAttrs := make([]metadata.Attribute, 0)
+	for _, item := range diff.Attributes {
+		switch item.Action {
+		case metadata.ModelSchemaDiffDelete:
+			from := item.From.(metadata.Attribute)
+			cond := mongo.NewCondition()
+			cond.Element(&mongo.Eq{Key: metadata.AttributeFieldID, Val: from.ID})
+			if _, err := m.modelAttribute.delete(kit, cond); err != nil {
+				blog.Errorf("rollback delete attribute %s failed, err: %v, rid: %s", from.PropertyID, err, kit.Rid)
+				return nil, err
+			}
+
+		case metadata.ModelSchemaDiffAdd:
+			to := item.To.(metadata.Attribute)
+			to.OwnerID = kit.SupplierAccount
+			to.LastTime = &metadata.Time{Time: time.Now()}
+			if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Insert(kit.Ctx, to); err != nil {
+				blog.Errorf("rollback create attribute %s failed, err: %v, rid: %s", to.PropertyID, err, kit.Rid)
+				return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+			}
+			changedAttrs = append(changedAttrs, to)
+
+		case metadata.ModelSchemaDiffUpdate:
+			from, to := item.From.(metadata.Attribute), item.To.(metadata.Attribute)
+			for _, attr := range droppedAttrs {
+				if attr.ID != from.ID {
+					continue
+				}
+				err := m.modelAttribute.cleanAttributeFieldInInstances(kit.Ctx, kit.SupplierAccount,
+					[]metadata.Attribute{attr})
+				if err != nil {
+					blog.Errorf("rollback clean attribute %s data failed, err: %v, rid: %s", attr.PropertyID, err,
+						kit.Rid)
+					return nil, err
+				}
+			}
+
+			toData := to.ToMapStr()
+			data := mapstr.MapStr{metadata.AttributeFieldLastTime: time.Now()}
+			for _, field := range item.ChangedFields {
+				data[field] = toData[field]
+			}
+
+			cond := util.SetModOwner(mapstr.MapStr{metadata.AttributeFieldID: from.ID}, kit.SupplierAccount)
+			if err := mongodb.Client().Table(common.BKTableNameObjAttDes).Update(kit.Ctx, cond, data); err != nil {
+				blog.Errorf("rollback update attribute %s failed, err: %v, rid: %s", from.PropertyID, err, kit.Rid)
+				return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+			}
+			changedAttrs = append(changedAttrs, to)
+		}
+	}
+	return changedAttrs, nil
+}
+
+// rollbackGroups create and update the groups before the attributes are changed, and delete the groups after it,
+// so that the attributes always belong to an existing group.
+func (m *modelManager) rollbackGroups(kit *rest.Kit, diff *metadata.ModelSchemaDiff, isDelete bool) error {
+	for _, item := range diff.Groups {
+		switch {
+		case isDelete && item.Action == metadata.ModelSchemaDiffDelete:
+			from := item.From.(metadata.Group)
+			cond := util.SetModOwner(mapstr.MapStr{metadata.GroupFieldID: from.ID}, kit.SupplierAccount)
+			if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Delete(kit.Ctx, cond); err != nil {
+				blog.Errorf("rollback delete group %s failed, err: %v, rid: %s", from.GroupID, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+			}
+
+		case !isDelete && item.Action == metadata.ModelSchemaDiffAdd:
+			to := item.To.(metadata.Group)
+			to.OwnerID = kit.SupplierAccount
+			if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Insert(kit.Ctx, to); err != nil {
+				blog.Errorf("rollback create group %s failed, err: %v, rid: %s", to.GroupID, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+			}
+
+		case !isDelete && item.Action == metadata.ModelSchemaDiffUpdate:
+			from, to := item.From.(metadata.Group), item.To.(metadata.Group)
+			data := mapstr.MapStr{
+				metadata.GroupFieldGroupName:  to.GroupName,
+				metadata.GroupFieldGroupIndex: to.GroupIndex,
+				"is_collapse":                 to.IsCollapse,
+			}
+			cond := util.SetModOwner(mapstr.MapStr{metadata.GroupFieldID: from.ID}, kit.SupplierAccount)
+			if err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Update(kit.Ctx, cond, data); err != nil {
+				blog.Errorf("rollback update group %s failed, err: %v, rid: %s", from.GroupID, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+			}
+		}
+	}
+	return nil
+}
+
+// rollbackUniques delete the unique rules before the attributes are deleted, and create and update the unique
+// rules after the attributes are created, because the unique rules refer to the attributes.
+func (m *modelManager) rollbackUniques(kit *rest.Kit, objID string, diff *metadata.ModelSchemaDiff, isAfterAttr bool) error {
+	for _, item := range diff.Uniques {
+		switch {
+		case !isAfterAttr && item.Action == metadata.ModelSchemaDiffDelete:
+			from := item.From.(metadata.ObjectUnique)
+			cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: from.ID}, kit.SupplierAccount)
+			if err := mongodb.Client().Table(common.BKTableNameObjUnique).Delete(kit.Ctx, cond); err != nil {
+				blog.Errorf("rollback delete unique %d failed, err: %v, rid: %s", from.ID, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+			}
+
+		case isAfterAttr && item.Action != metadata.ModelSchemaDiffDelete:
+			to := item.To.(metadata.ObjectUnique)
+			properties, err := m.modelAttrUnique.getUniqueProperties(kit, objID, to.Keys, to.MustCheck)
+			if err != nil {
+				return err
+			}
+
+			err = m.modelAttrUnique.recheckUniqueForExistsInstances(kit, objID, properties, to.MustCheck)
+			if err != nil {
+				blog.Errorf("rollback unique %d is not satisfied by the instances, err: %v, rid: %s", to.ID, err, kit.Rid)
+				return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, "instance")
+			}
+
+			to.OwnerID = kit.SupplierAccount
+			to.LastTime = metadata.Now()
+			if item.Action == metadata.ModelSchemaDiffAdd {
+				err = mongodb.Client().Table(common.BKTableNameObjUnique).Insert(kit.Ctx, to)
+			} else {
+				cond := util.SetModOwner(mapstr.MapStr{common.BKFieldID: to.ID}, kit.SupplierAccount)
+				err = mongodb.Client().Table(common.BKTableNameObjUnique).Update(kit.Ctx, cond, to)
+			}
+			if err != nil {
+				blog.Errorf("rollback save unique %d failed, err: %v, rid: %s", to.ID, err, kit.Rid)
+				return kit.CCError.CCError(common.CCErrObjectDBOpErrno)
+			}
+		}
+	}
+	return nil
+}
+
+// hasAttributeData check if any instance of the attribute's model holds data of the attribute
+func hasAttributeData(kit *rest.Kit, attr metadata.Attribute) (bool, error) {
+	cond := mapstr.MapStr{
+		attr.PropertyID: mapstr.MapStr{common.BKDBExists: true, common.BKDBNIN: []interface{}{nil, ""}},
+	}
+	if common.GetInstTableName(attr.ObjectID) == common.BKTableNameBaseInst {
+		cond[common.BKObjIDField] = attr.ObjectID
+	}
+	cond = util.SetQueryOwner(cond, kit.SupplierAccount)
+
+	cnt, err := mongodb.Client().Table(common.GetInstTableName(attr.ObjectID)).Find(cond).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("count instances with attribute %s data failed, err: %v, cond: %#v, rid: %s", attr.PropertyID,
+			err, cond, kit.Rid)
+		return false, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return cnt > 0, nil
+}
+
+// getCurrentSchema get the current schema of the model, its version is 0
+func getCurrentSchema(kit *rest.Kit, objID string) (*metadata.ModelSchemaVersion, error) {
+	cond := util.SetQueryOwner(mapstr.MapStr{common.BKObjIDField: objID}, kit.SupplierAccount)
+
+	schema := &metadata.ModelSchemaVersion{
+		ObjectID:   objID,
+		Attributes: make([]metadata.Attribute, 0),
+		Groups:     make([]metadata.Group, 0),
+		Uniques:    make([]metadata.ObjectUnique, 0),
+		OwnerID:    kit.SupplierAccount,
+	}
+
+	err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).Sort(common.BKFieldID).All(kit.Ctx,
+		&schema.Attributes)
+	if err != nil {
+		blog.Errorf("get model %s attributes failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	err = mongodb.Client().Table(common.BKTableNamePropertyGroup).Find(cond).Sort(common.BKFieldID).All(kit.Ctx,
+		&schema.Groups)
+	if err != nil {
+		blog.Errorf("get model %s attribute groups failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	err = mongodb.Client().Table(common.BKTableNameObjUnique).Find(cond).Sort(common.BKFieldID).All(kit.Ctx,
+		&schema.Uniques)
+	if err != nil {
+		blog.Errorf("get model %s uniques failed, err: %v, rid: %s", objID, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return schema, nil
+}
+
+// getSchemaVersion get the specified schema version of the model, version 0 means the current schema
+func getSchemaVersion(kit *rest.Kit, objID string, version int64) (*metadata.ModelSchemaVersion, error) {
+	if version == 0 {
+		return getCurrentSchema(kit, objID)
+	}
+
+	cond := util.SetQueryOwner(mapstr.MapStr{
+		common.BKObjIDField: objID,
+		schemaVersionField:  version,
+	}, kit.SupplierAccount)
+
+	schema := new(metadata.ModelSchemaVersion)
+	err := mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(cond).One(kit.Ctx, schema)
+	if err != nil {
+		if mongodb.Client().IsNotFoundError(err) {
+			blog.Errorf("model %s schema version %d not exists, rid: %s", objID, version, kit.Rid)
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceModelSchemaVersionNotExist, objID, version)
+		}
+		blog.Errorf("get model %s schema version %d failed, err: %v, rid: %s", objID, version, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return schema, nil
+}
+
+// saveSchemaVersion save the current schema of the models as their new versions if the schema is changed.
+// it must run in the same transaction as the schema change, a concurrent change that takes the same version
+// is rejected by the unique index, so the transaction is aborted instead of leaving a lost or mixed version.
+func saveSchemaVersion(kit *rest.Kit, description string, objIDs ...string) error {
+	for _, objID := range util.StrArrayUnique(objIDs) {
+		current, err := getCurrentSchema(kit, objID)
+		if err != nil {
+			return err
+		}
+
+		cond := util.SetQueryOwner(mapstr.MapStr{common.BKObjIDField: objID}, kit.SupplierAccount)
+		latest := make([]metadata.ModelSchemaVersion, 0)
+		err = mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Find(cond).Sort("-"+schemaVersionField).
+			Limit(1).All(kit.Ctx, &latest)
+		if err != nil {
+			blog.Errorf("get model %s latest schema version failed, err: %v, rid: %s", objID, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+		}
+
+		current.Version = 1
+		if len(latest) > 0 {
+			if metadata.DiffModelSchema(&latest[0], current).IsEmpty() {
+				continue
+			}
+			current.Version = latest[0].Version + 1
+		}
+
+		current.Description = description
+		current.Creator = kit.User
+		current.CreateTime = metadata.Now()
+		if err := mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Insert(kit.Ctx, current); err != nil {
+			blog.Errorf("save model %s schema version %d failed, err: %v, rid: %s", objID, current.Version, err,
+				kit.Rid)
+			if mongodb.Client().IsDuplicatedError(err) {
+				return kit.CCError.CCErrorf(common.CCErrCommDuplicateItem, schemaVersionField)
+			}
+			return kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+		}
+	}
+	return nil
+}
+
+// deleteSchemaVersions delete all the schema versions of the models
+func deleteSchemaVersions(kit *rest.Kit, objIDs []string) error {
+	cond := util.SetModOwner(mapstr.MapStr{
+		common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs},
+	}, kit.SupplierAccount)
+
+	if err := mongodb.Client().Table(common.BKTableNameObjSchemaVersion).Delete(kit.Ctx, cond); err != nil {
+		blog.Errorf("delete model schema versions failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+// getAttributeObjIDs get the models of the attributes matched by the condition
+func getAttributeObjIDs(kit *rest.Kit, cond mapstr.MapStr) ([]string, error) {
+	attrs := make([]metadata.Attribute, 0)
+	err := mongodb.Client().Table(common.BKTableNameObjAttDes).Find(cond).Fields(common.BKObjIDField).All(kit.Ctx,
+		&attrs)
+	if err != nil {
+		blog.Errorf("get attributes models failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	objIDs := make([]string, 0)
+	for _, attr := range attrs {
+		objIDs = append(objIDs, attr.ObjectID)
+	}
+	return objIDs, nil
+}
+
+// getGroupObjIDs get the models of the attribute groups matched by the condition
+func getGroupObjIDs(kit *rest.Kit, cond mapstr.MapStr) ([]string, error) {
+	groups := make([]metadata.Group, 0)
+	err := mongodb.Client().Table(common.BKTableNamePropertyGroup).Find(cond).Fields(common.BKObjIDField).All(kit.Ctx,
+		&groups)
+	if err != nil {
+		blog.Errorf("get attribute groups models failed, err: %v, cond: %#v, rid: %s", err, cond, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	objIDs := make([]string, 0)
+	for _, group := range groups {
+		objIDs = append(objIDs, group.ObjectID)
+	}
+	return objIDs, nil
+}
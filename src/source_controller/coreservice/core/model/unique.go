@@ -25,6 +25,9 @@ func (m *modelAttrUnique) CreateModelAttrUnique(kit *rest.Kit, objID string, dat
 	if err != nil {
 		return nil, err
 	}
+	if err := saveSchemaVersion(kit, "create unique", objID); err != nil {
+		return nil, err
+	}
 	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
 }
 
@@ -33,6 +36,9 @@ func (m *modelAttrUnique) UpdateModelAttrUnique(kit *rest.Kit, objID string, id
 	if err != nil {
 		return nil, err
 	}
+	if err := saveSchemaVersion(kit, "update unique", objID); err != nil {
+		return nil, err
+	}
 	return &metadata.UpdatedCount{Count: 1}, nil
 }
 
@@ -41,6 +47,9 @@ func (m *modelAttrUnique) DeleteModelAttrUnique(kit *rest.Kit, objID string, id
 	if err != nil {
 		return nil, err
 	}
+	if err := saveSchemaVersion(kit, "delete unique", objID); err != nil {
+		return nil, err
+	}
 	return &metadata.DeletedCount{Count: 1}, nil
 }
 
@@ -412,6 +412,8 @@ func getBasicDataType(propertyType string) (interface{}, error) {
 		return nil, nil
 	case common.FieldTypeOrganization:
 		return nil, nil
+	case common.FieldTypeInstRef:
+		return nil, nil
 	default:
 		return nil, fmt.Errorf("unsupported type: %s", propertyType)
 	}
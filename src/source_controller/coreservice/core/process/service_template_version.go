@@ -0,0 +1,328 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package process
+
+import (
+	"fmt"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/types"
+	"configcenter/src/storage/driver/mongodb"
+)
+
+// CreateServiceTemplateVersion save the current content of the service template and its process templates
+// as a new version, the latest version is returned directly if the content is not changed since then.
+func (p *processOperation) CreateServiceTemplateVersion(kit *rest.Kit, bizID int64, serviceTemplateID int64) (
+	*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+
+	template, err := p.GetServiceTemplate(kit, serviceTemplateID)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, GetServiceTemplate failed, templateID: %d, err: %v, rid: %s",
+			serviceTemplateID, err, kit.Rid)
+		return nil, err
+	}
+	if template.BizID != bizID {
+		blog.Errorf("CreateServiceTemplateVersion failed, service template %d not belongs to biz %d, rid: %s",
+			serviceTemplateID, bizID, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+
+	processTemplates := make([]metadata.ProcessTemplate, 0)
+	processFilter := map[string]interface{}{common.BKServiceTemplateIDField: serviceTemplateID}
+	if err := mongodb.Client().Table(common.BKTableNameProcessTemplate).Find(processFilter).Sort(common.BKFieldID).
+		All(kit.Ctx, &processTemplates); err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, list process templates failed, filter: %+v, err: %v, rid: %s",
+			processFilter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	version := metadata.ServiceTemplateVersion{
+		BizID:             template.BizID,
+		ServiceTemplateID: template.ID,
+		Name:              template.Name,
+		ServiceCategoryID: template.ServiceCategoryID,
+		ProcessTemplates:  processTemplates,
+		Creator:           kit.User,
+		CreateTime:        time.Now(),
+		SupplierAccount:   kit.SupplierAccount,
+	}
+
+	latest, err := p.getLatestServiceTemplateVersion(kit, serviceTemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil {
+		if latest.SameContent(&version) {
+			return latest, nil
+		}
+		version.Version = latest.Version + 1
+	} else {
+		version.Version = 1
+	}
+
+	id, e := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameServiceTemplateVersion)
+	if e != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, generate id failed, err: %v, rid: %s", e, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+	version.ID = int64(id)
+
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersion).Insert(kit.Ctx, &version); err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, insert version failed, version: %+v, err: %v, rid: %s",
+			version, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &version, nil
+}
+
+func (p *processOperation) getLatestServiceTemplateVersion(kit *rest.Kit, serviceTemplateID int64) (
+	*metadata.ServiceTemplateVersion, errors.CCErrorCoder) {
+
+	versions := make([]metadata.ServiceTemplateVersion, 0)
+	filter := map[string]interface{}{common.BKServiceTemplateIDField: serviceTemplateID}
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateVersion).Find(filter).Sort("-version").
+		Limit(1).All(kit.Ctx, &versions); err != nil {
+		blog.Errorf("get latest service template version failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	if len(versions) == 0 {
+		return nil, nil
+	}
+	return &versions[0], nil
+}
+
+func (p *processOperation) ListServiceTemplateVersions(kit *rest.Kit, option metadata.ListServiceTemplateVersionsOption) (
+	*metadata.MultipleServiceTemplateVersion, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		common.BKAppIDField:             option.BizID,
+		common.BKServiceTemplateIDField: option.ServiceTemplateID,
+	}
+	if len(option.Versions) > 0 {
+		filter["version"] = map[string]interface{}{common.BKDBIN: option.Versions}
+	}
+
+	table := mongodb.Client().Table(common.BKTableNameServiceTemplateVersion)
+	total, err := table.Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, count failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := "-version"
+	if len(option.Page.Sort) > 0 {
+		sort = option.Page.Sort
+	}
+	versions := make([]metadata.ServiceTemplateVersion, 0)
+	if err := table.Find(filter).Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).Sort(sort).
+		All(kit.Ctx, &versions); err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, find failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleServiceTemplateVersion{
+		Count: total,
+		Info:  versions,
+	}, nil
+}
+
+func (p *processOperation) CreateServiceTemplateRollout(kit *rest.Kit, rollout metadata.ServiceTemplateRollout) (
+	*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+
+	if rollout.BizID <= 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField)
+	}
+	if rollout.ServiceTemplateID <= 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKServiceTemplateIDField)
+	}
+	if len(rollout.Waves) == 0 {
+		return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "waves")
+	}
+
+	id, err := mongodb.Client().NextSequence(kit.Ctx, common.BKTableNameServiceTemplateRollout)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, generate id failed, err: %v, rid: %s", err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommGenerateRecordIDFailed)
+	}
+
+	now := time.Now()
+	rollout.ID = int64(id)
+	if rollout.Status == "" {
+		rollout.Status = metadata.RolloutStatusRunning
+	}
+	if rollout.TaskIDs == nil {
+		rollout.TaskIDs = make([]string, 0)
+	}
+	rollout.Creator = kit.User
+	rollout.Modifier = kit.User
+	rollout.CreateTime = now
+	rollout.LastTime = now
+	rollout.SupplierAccount = kit.SupplierAccount
+	rollout.ActiveKey = metadata.RolloutActiveKey(rollout.ServiceTemplateID, rollout.ID, rollout.Status)
+
+	if err := mongodb.Client().Table(common.BKTableNameServiceTemplateRollout).Insert(kit.Ctx, &rollout); err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, insert failed, rollout: %+v, err: %v, rid: %s",
+			rollout, err, kit.Rid)
+		if mongodb.Client().IsDuplicatedError(err) {
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceRolloutInProgress, rollout.ServiceTemplateID)
+		}
+		return nil, kit.CCError.CCError(common.CCErrCommDBInsertFailed)
+	}
+	return &rollout, nil
+}
+
+// UpdateServiceTemplateRollout update the status, wave status or tasks of a rollout, if FromStatus is set,
+// the rollout is only updated when its current status is one of them. the changed fields are updated by the
+// condition in one operation, so that concurrent updates of the rollout do not overwrite each other.
+func (p *processOperation) UpdateServiceTemplateRollout(kit *rest.Kit, option metadata.UpdateServiceTemplateRolloutOption) (
+	*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		common.BKAppIDField: option.BizID,
+		common.BKFieldID:    option.ID,
+	}
+	table := mongodb.Client().Table(common.BKTableNameServiceTemplateRollout)
+
+	// the service template and the waves of a rollout are never changed, they can be checked before the update
+	rollout, err := p.getServiceTemplateRollout(kit, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	setDoc := map[string]interface{}{
+		common.ModifierField: kit.User,
+		common.LastTimeField: now,
+	}
+	if option.Status != "" {
+		setDoc[common.BKStatusField] = option.Status
+		setDoc["active_key"] = metadata.RolloutActiveKey(rollout.ServiceTemplateID, rollout.ID, option.Status)
+	}
+	if option.Wave != nil {
+		if option.Wave.Index < 0 || option.Wave.Index >= len(rollout.Waves) {
+			return nil, kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, "wave.index")
+		}
+		wavePrefix := fmt.Sprintf("waves.%d.", option.Wave.Index)
+		setDoc[wavePrefix+"status"] = option.Wave.Status
+		setDoc[wavePrefix+"message"] = option.Wave.Message
+		setDoc[wavePrefix+common.LastTimeField] = now
+	}
+	updates := []types.ModeUpdate{{Op: "set", Doc: setDoc}}
+	if len(option.TaskIDs) > 0 {
+		updates = append(updates, types.ModeUpdate{
+			Op:  "push",
+			Doc: map[string]interface{}{"task_ids": map[string]interface{}{"$each": option.TaskIDs}},
+		})
+	}
+
+	updateFilter := map[string]interface{}{
+		common.BKAppIDField: option.BizID,
+		common.BKFieldID:    option.ID,
+	}
+	if len(option.FromStatus) > 0 {
+		updateFilter[common.BKStatusField] = map[string]interface{}{common.BKDBIN: option.FromStatus}
+	}
+
+	matched, e := table.UpdateMultiModelCount(kit.Ctx, updateFilter, updates...)
+	if e != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, update rollout failed, filter: %+v, err: %v, rid: %s",
+			updateFilter, e, kit.Rid)
+		if mongodb.Client().IsDuplicatedError(e) {
+			return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceRolloutInProgress, rollout.ServiceTemplateID)
+		}
+		return nil, kit.CCError.CCError(common.CCErrCommDBUpdateFailed)
+	}
+	if matched == 0 {
+		blog.Errorf("UpdateServiceTemplateRollout failed, rollout %d status is not in %v, rid: %s", rollout.ID,
+			option.FromStatus, kit.Rid)
+		return nil, kit.CCError.CCErrorf(common.CCErrCoreServiceRolloutStatusConflict, rollout.ID)
+	}
+
+	return p.getServiceTemplateRollout(kit, filter)
+}
+
+// getServiceTemplateRollout get a rollout from the primary, so that the result of a former update can be read.
+func (p *processOperation) getServiceTemplateRollout(kit *rest.Kit, filter map[string]interface{}) (
+	*metadata.ServiceTemplateRollout, errors.CCErrorCoder) {
+
+	rollout := new(metadata.ServiceTemplateRollout)
+	err := mongodb.Client().Table(common.BKTableNameServiceTemplateRollout).
+		Find(filter, types.FindOpts{ReadPreference: common.PrimaryMode}).One(kit.Ctx, rollout)
+	if err != nil {
+		blog.Errorf("get service template rollout failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		if mongodb.Client().IsNotFoundError(err) {
+			return nil, kit.CCError.CCError(common.CCErrCommNotFound)
+		}
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+	return rollout, nil
+}
+
+func (p *processOperation) ListServiceTemplateRollouts(kit *rest.Kit, option metadata.ListServiceTemplateRolloutsOption) (
+	*metadata.MultipleServiceTemplateRollout, errors.CCErrorCoder) {
+
+	filter := map[string]interface{}{
+		common.BKAppIDField: option.BizID,
+	}
+	if option.ServiceTemplateID > 0 {
+		filter[common.BKServiceTemplateIDField] = option.ServiceTemplateID
+	}
+	if len(option.IDs) > 0 {
+		filter[common.BKFieldID] = map[string]interface{}{common.BKDBIN: option.IDs}
+	}
+	if len(option.Status) > 0 {
+		filter[common.BKStatusField] = map[string]interface{}{common.BKDBIN: option.Status}
+	}
+
+	table := mongodb.Client().Table(common.BKTableNameServiceTemplateRollout)
+	total, err := table.Find(filter).Count(kit.Ctx)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, count failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	sort := "-id"
+	if len(option.Page.Sort) > 0 {
+		sort = option.Page.Sort
+	}
+	rollouts := make([]metadata.ServiceTemplateRollout, 0)
+	if err := table.Find(filter).Start(uint64(option.Page.Start)).Limit(uint64(option.Page.Limit)).Sort(sort).
+		All(kit.Ctx, &rollouts); err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, find failed, filter: %+v, err: %v, rid: %s", filter, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleServiceTemplateRollout{
+		Count: total,
+		Info:  rollouts,
+	}, nil
+}
+
+// deleteServiceTemplateVersions delete the versions and rollouts of a removed service template
+func (p *processOperation) deleteServiceTemplateVersions(kit *rest.Kit, serviceTemplateID int64) errors.CCErrorCoder {
+	filter := map[string]interface{}{common.BKServiceTemplateIDField: serviceTemplateID}
+	for _, table := range []string{common.BKTableNameServiceTemplateVersion, common.BKTableNameServiceTemplateRollout} {
+		if err := mongodb.Client().Table(table).Delete(kit.Ctx, filter); err != nil {
+			blog.Errorf("delete service template versions failed, table: %s, filter: %+v, err: %v, rid: %s", table,
+				filter, err, kit.Rid)
+			return kit.CCError.CCError(common.CCErrCommDBDeleteFailed)
+		}
+	}
+	return nil
+}
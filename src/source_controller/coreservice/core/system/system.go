@@ -67,3 +67,26 @@ func (sm *systemManager) SearchConfigAdmin(kit *rest.Kit) (*metadata.ConfigAdmin
 
 	return conf, nil
 }
+
+// SearchTenants search the tenants, it is used to check the status of the tenants, so the tenants are not
+// limited by the request's supplier account.
+func (sm *systemManager) SearchTenants(kit *rest.Kit, option *metadata.ListTenantsOption) (*metadata.MultipleTenant,
+	errors.CCErrorCoder) {
+
+	cond := make(map[string]interface{})
+	if len(option.SupplierAccounts) > 0 {
+		cond[common.BKOwnerIDField] = map[string]interface{}{common.BKDBIN: option.SupplierAccounts}
+	}
+	if len(option.Status) > 0 {
+		cond["status"] = option.Status
+	}
+
+	tenants := make([]metadata.Tenant, 0)
+	err := mongodb.Client().Table(common.BKTableNameTenant).Find(cond).Sort(common.BKOwnerIDField).All(kit.Ctx, &tenants)
+	if err != nil {
+		blog.Errorf("SearchTenants failed, cond: %+v, err: %v, rid: %s", cond, err, kit.Rid)
+		return nil, kit.CCError.CCError(common.CCErrCommDBSelectFailed)
+	}
+
+	return &metadata.MultipleTenant{Count: uint64(len(tenants)), Info: tenants}, nil
+}
@@ -35,3 +35,8 @@ func (s *coreService) IsInstanceExist(kit *rest.Kit, objID string, instID uint64
 	}
 	return true, nil
 }
+
+// RecalculateInstances recalculate the instances, the recalculation is handed over to a background task if failed
+func (s *coreService) RecalculateInstances(kit *rest.Kit, objID string, instIDs []int64) error {
+	return s.core.InstanceOperation().RecalculateOrDeferModelInstance(kit, objID, instIDs)
+}
@@ -13,6 +13,8 @@
 package service
 
 import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/http/rest"
 	"configcenter/src/common/metadata"
 )
@@ -31,3 +33,296 @@ func (s *coreService) SearchAuthResource(ctx *rest.Contexts) {
 	}
 	ctx.RespEntityWithCount(count, info)
 }
+
+func (s *coreService) CreateAuthRole(ctx *rest.Contexts) {
+	role := metadata.AuthRole{}
+	if err := ctx.DecodeInto(&role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().CreateAuthRole(ctx.Kit, role)
+	if err != nil {
+		blog.Errorf("CreateAuthRole failed, role: %+v, err: %v, rid: %s", role, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) UpdateAuthRole(ctx *rest.Contexts) {
+	role := metadata.AuthRole{}
+	if err := ctx.DecodeInto(&role); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if role.ID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	result, err := s.core.AuthOperation().UpdateAuthRole(ctx.Kit, role.ID, role)
+	if err != nil {
+		blog.Errorf("UpdateAuthRole failed, role: %+v, err: %v, rid: %s", role, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) DeleteAuthRoles(ctx *rest.Contexts) {
+	option := metadata.DeleteAuthRBACOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteAuthRoles(ctx.Kit, option); err != nil {
+		blog.Errorf("DeleteAuthRoles failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) ListAuthRoles(ctx *rest.Contexts) {
+	option := metadata.ListAuthRolesOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListAuthRoles(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListAuthRoles failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) CreateAuthRoleBinding(ctx *rest.Contexts) {
+	binding := metadata.AuthRoleBinding{}
+	if err := ctx.DecodeInto(&binding); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().CreateAuthRoleBinding(ctx.Kit, binding)
+	if err != nil {
+		blog.Errorf("CreateAuthRoleBinding failed, binding: %+v, err: %v, rid: %s", binding, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) DeleteAuthRoleBindings(ctx *rest.Contexts) {
+	option := metadata.DeleteAuthRBACOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteAuthRoleBindings(ctx.Kit, option); err != nil {
+		blog.Errorf("DeleteAuthRoleBindings failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) ListAuthRoleBindings(ctx *rest.Contexts) {
+	option := metadata.ListAuthRoleBindingsOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListAuthRoleBindings(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListAuthRoleBindings failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) CreateAuthUserGroup(ctx *rest.Contexts) {
+	group := metadata.AuthUserGroup{}
+	if err := ctx.DecodeInto(&group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().CreateAuthUserGroup(ctx.Kit, group)
+	if err != nil {
+		blog.Errorf("CreateAuthUserGroup failed, group: %+v, err: %v, rid: %s", group, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) UpdateAuthUserGroup(ctx *rest.Contexts) {
+	group := metadata.AuthUserGroup{}
+	if err := ctx.DecodeInto(&group); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if group.ID <= 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKFieldID))
+		return
+	}
+
+	result, err := s.core.AuthOperation().UpdateAuthUserGroup(ctx.Kit, group.ID, group)
+	if err != nil {
+		blog.Errorf("UpdateAuthUserGroup failed, group: %+v, err: %v, rid: %s", group, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) DeleteAuthUserGroups(ctx *rest.Contexts) {
+	option := metadata.DeleteAuthRBACOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().DeleteAuthUserGroups(ctx.Kit, option); err != nil {
+		blog.Errorf("DeleteAuthUserGroups failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) ListAuthUserGroups(ctx *rest.Contexts) {
+	option := metadata.ListAuthUserGroupsOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListAuthUserGroups(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListAuthUserGroups failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) AddAuthUserGroupMembers(ctx *rest.Contexts) {
+	option := metadata.AuthUserGroupMembersOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().AddAuthUserGroupMembers(ctx.Kit, option); err != nil {
+		blog.Errorf("AddAuthUserGroupMembers failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) RemoveAuthUserGroupMembers(ctx *rest.Contexts) {
+	option := metadata.AuthUserGroupMembersOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().RemoveAuthUserGroupMembers(ctx.Kit, option); err != nil {
+		blog.Errorf("RemoveAuthUserGroupMembers failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) GetAuthPolicies(ctx *rest.Contexts) {
+	option := metadata.GetAuthPoliciesOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().GetAuthPolicies(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("GetAuthPolicies failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) CreateAPIToken(ctx *rest.Contexts) {
+	option := metadata.SaveAPITokenOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().CreateAPIToken(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("CreateAPIToken failed, name: %s, err: %v, rid: %s", option.Name, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) ListAPITokens(ctx *rest.Contexts) {
+	option := metadata.ListAPITokensOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.AuthOperation().ListAPITokens(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListAPITokens failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) RevokeAPITokens(ctx *rest.Contexts) {
+	option := metadata.RevokeAPITokensOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.AuthOperation().RevokeAPITokens(ctx.Kit, option); err != nil {
+		blog.Errorf("RevokeAPITokens failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
+func (s *coreService) VerifyAPIToken(ctx *rest.Contexts) {
+	option := metadata.VerifyAPITokenOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	// the token hash is a credential, so it is not logged
+	result, err := s.core.AuthOperation().VerifyAPIToken(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("VerifyAPIToken failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
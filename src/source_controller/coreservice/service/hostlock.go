@@ -68,3 +68,36 @@ func (s *coreService) QueryLockHost(ctx *rest.Contexts) {
 	result.Data.Count = int64(len(hostLockArr))
 	ctx.RespEntity(result.Data)
 }
+
+func (s *coreService) ListHostLocks(ctx *rest.Contexts) {
+	input := new(metadata.ListHostLocksOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	count, hostLockArr, err := s.core.HostOperation().ListHostLocks(ctx.Kit, input)
+	if nil != err {
+		blog.Errorf("ListHostLocks failed, list host locks failed, err: %v, input: %+v, rid: %s", err, input, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	result := metadata.ListHostLocksResult{}
+	result.Data.Info = hostLockArr
+	result.Data.Count = count
+	ctx.RespEntity(result.Data)
+}
+
+func (s *coreService) ExpireHostLocks(ctx *rest.Contexts) {
+	input := new(metadata.ExpireHostLocksOption)
+	if err := ctx.DecodeInto(input); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	count, err := s.core.HostOperation().ExpireHostLocks(ctx.Kit, input)
+	if nil != err {
+		blog.Errorf("ExpireHostLocks failed, expire host locks failed, err: %v, input: %+v, rid: %s", err, input, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(metadata.UpdatedCount{Count: count})
+}
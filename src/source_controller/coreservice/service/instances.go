@@ -47,6 +47,32 @@ func (s *coreService) UpdateModelInstances(ctx *rest.Contexts) {
 	ctx.RespEntityWithError(s.core.InstanceOperation().UpdateModelInstance(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
 }
 
+// RecalculateModelInstances recalculate the calculated attributes' values of the model instances
+func (s *coreService) RecalculateModelInstances(ctx *rest.Contexts) {
+	inputData := metadata.RecalculateModelInstance{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.InstanceOperation().RecalculateModelInstance(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
+}
+
+// RecalculateModelInstanceTask execute the background task to recalculate the model instances
+func (s *coreService) RecalculateModelInstanceTask(ctx *rest.Contexts) {
+	task := metadata.RecalculateModelInstanceTask{}
+	if err := ctx.DecodeInto(&task); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if err := s.core.InstanceOperation().RecalculateModelInstanceTask(ctx.Kit, task); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(nil)
+}
+
 func (s *coreService) SearchModelInstances(ctx *rest.Contexts) {
 	inputData := metadata.QueryCondition{}
 	if err := ctx.DecodeInto(&inputData); nil != err {
@@ -106,3 +106,7 @@ func (s *coreService) SearchUnique(kit *rest.Kit, objID string) (uniqueAttr []me
 func (s *coreService) UpdateModelInstance(kit *rest.Kit, objID string, param metadata.UpdateOption) (*metadata.UpdatedCount, error) {
 	return s.core.InstanceOperation().UpdateModelInstance(kit, objID, param)
 }
+
+func (s *coreService) HandleInstRefBeforeDelete(kit *rest.Kit, objID string, instIDs []int64) error {
+	return s.core.InstanceOperation().HandleInstRefBeforeDelete(kit, objID, instIDs)
+}
@@ -532,3 +532,33 @@ func (s *coreService) DeleteModelAttrUnique(ctx *rest.Contexts) {
 
 	ctx.RespEntityWithError(s.core.ModelOperation().DeleteModelAttrUnique(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), id))
 }
+
+func (s *coreService) SearchModelSchemaVersions(ctx *rest.Contexts) {
+	inputData := metadata.QueryCondition{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.ModelOperation().SearchModelSchemaVersions(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
+}
+
+func (s *coreService) DiffModelSchema(ctx *rest.Contexts) {
+	inputData := metadata.DiffModelSchemaOption{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.ModelOperation().DiffModelSchema(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
+}
+
+func (s *coreService) RollbackModelSchema(ctx *rest.Contexts) {
+	inputData := metadata.RollbackModelSchemaOption{}
+	if err := ctx.DecodeInto(&inputData); nil != err {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	ctx.RespEntityWithError(s.core.ModelOperation().RollbackModelSchema(ctx.Kit, ctx.Request.PathParameter("bk_obj_id"), inputData))
+}
@@ -95,3 +95,8 @@ func (s *coreService) CascadeDeleteInstances(kit *rest.Kit, objIDS []string) err
 
 	return nil
 }
+
+// RecalculateAllInstances recalculate all the instances of the model in a background task
+func (s *coreService) RecalculateAllInstances(kit *rest.Kit, objID string) error {
+	return s.core.InstanceOperation().DeferRecalculateModelInstance(kit, objID, nil)
+}
@@ -85,6 +85,11 @@ func (s *coreService) initAttrUnique(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/model/{bk_obj_id}/attributes/unique/{id}", Handler: s.UpdateModelAttrUnique})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/attributes/unique/{id}", Handler: s.DeleteModelAttrUnique})
 
+	// model schema versions
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/schema/versions", Handler: s.SearchModelSchemaVersions})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/schema/diff", Handler: s.DiffModelSchema})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/model/{bk_obj_id}/schema/rollback", Handler: s.RollbackModelSchema})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -100,6 +105,8 @@ func (s *coreService) initModelInstances(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/read/model/{bk_obj_id}/instances", Handler: s.SearchModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/instance", Handler: s.DeleteModelInstances})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/model/{bk_obj_id}/instance/cascade", Handler: s.CascadeDeleteModelInstances})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/model/{bk_obj_id}/instance/calculated", Handler: s.RecalculateModelInstances})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/update/model/instance/calculated/task", Handler: s.RecalculateModelInstanceTask})
 
 	utility.AddToRestfulWebService(web)
 }
@@ -187,6 +194,8 @@ func (s *coreService) host(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/host/lock", Handler: s.LockHost})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/host/lock", Handler: s.UnlockHost})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host/lock/search", Handler: s.QueryLockHost})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/host/lock", Handler: s.ListHostLocks})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/host/lock/expire", Handler: s.ExpireHostLocks})
 
 	// dynamic grouping handlers.
 	utility.AddHandler(rest.Action{
@@ -306,6 +315,8 @@ func (s *coreService) ccSystem(web *restful.WebService) {
 
 	utility.AddHandler(rest.Action{Verb: http.MethodGet, Path: "/find/system/config_admin", Handler: s.SearchConfigAdmin})
 
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/system/tenant", Handler: s.SearchTenants})
+
 	utility.AddToRestfulWebService(web)
 }
 
@@ -361,6 +372,26 @@ func (s *coreService) initAuth(web *restful.WebService) {
 	})
 
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/search/auth/resource", Handler: s.SearchAuthResource})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/auth/rbac/role", Handler: s.CreateAuthRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/auth/rbac/role", Handler: s.UpdateAuthRole})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/auth/rbac/role", Handler: s.DeleteAuthRoles})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auth/rbac/role", Handler: s.ListAuthRoles})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/auth/rbac/role_binding", Handler: s.CreateAuthRoleBinding})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/auth/rbac/role_binding", Handler: s.DeleteAuthRoleBindings})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auth/rbac/role_binding", Handler: s.ListAuthRoleBindings})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/auth/rbac/user_group", Handler: s.CreateAuthUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/auth/rbac/user_group", Handler: s.UpdateAuthUserGroup})
+	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/auth/rbac/user_group", Handler: s.DeleteAuthUserGroups})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auth/rbac/user_group", Handler: s.ListAuthUserGroups})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/auth/rbac/user_group/members/add", Handler: s.AddAuthUserGroupMembers})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/auth/rbac/user_group/members/remove", Handler: s.RemoveAuthUserGroupMembers})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/auth/rbac/policy", Handler: s.GetAuthPolicies})
+
+	// personal api tokens
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/auth/api_token", Handler: s.CreateAPIToken})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/auth/api_token", Handler: s.ListAPITokens})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/auth/api_token/revoke", Handler: s.RevokeAPITokens})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/find/auth/api_token/verify", Handler: s.VerifyAPIToken})
 
 	utility.AddToRestfulWebService(web)
 }
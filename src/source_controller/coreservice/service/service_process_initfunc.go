@@ -43,6 +43,13 @@ func (s *coreService) initProcess(web *restful.WebService) {
 	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/process/service_template/{service_template_id}", Handler: s.UpdateServiceTemplate})
 	utility.AddHandler(rest.Action{Verb: http.MethodDelete, Path: "/delete/process/service_template/{service_template_id}", Handler: s.DeleteServiceTemplate})
 
+	// service template version and rollout
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/process/service_template/version", Handler: s.CreateServiceTemplateVersion})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/process/service_template/version", Handler: s.ListServiceTemplateVersions})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/process/service_template/rollout", Handler: s.CreateServiceTemplateRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPut, Path: "/update/process/service_template/rollout", Handler: s.UpdateServiceTemplateRollout})
+	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/findmany/process/service_template/rollout", Handler: s.ListServiceTemplateRollouts})
+
 	// service instance
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/create/process/service_instance", Handler: s.CreateServiceInstance})
 	utility.AddHandler(rest.Action{Verb: http.MethodPost, Path: "/createmany/process/service_instance", Handler: s.CreateServiceInstances})
@@ -0,0 +1,114 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
+)
+
+func (s *coreService) CreateServiceTemplateVersion(ctx *rest.Contexts) {
+	option := metadata.CreateServiceTemplateVersionOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.ProcessOperation().CreateServiceTemplateVersion(ctx.Kit, option.BizID, option.ServiceTemplateID)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateVersion failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) ListServiceTemplateVersions(ctx *rest.Contexts) {
+	option := metadata.ListServiceTemplateVersionsOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+	if option.ServiceTemplateID == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKServiceTemplateIDField))
+		return
+	}
+
+	result, err := s.core.ProcessOperation().ListServiceTemplateVersions(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateVersions failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) CreateServiceTemplateRollout(ctx *rest.Contexts) {
+	rollout := metadata.ServiceTemplateRollout{}
+	if err := ctx.DecodeInto(&rollout); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.ProcessOperation().CreateServiceTemplateRollout(ctx.Kit, rollout)
+	if err != nil {
+		blog.Errorf("CreateServiceTemplateRollout failed, err: %v, rid: %s", err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) UpdateServiceTemplateRollout(ctx *rest.Contexts) {
+	option := metadata.UpdateServiceTemplateRolloutOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	result, err := s.core.ProcessOperation().UpdateServiceTemplateRollout(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("UpdateServiceTemplateRollout failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
+
+func (s *coreService) ListServiceTemplateRollouts(ctx *rest.Contexts) {
+	option := metadata.ListServiceTemplateRolloutsOption{}
+	if err := ctx.DecodeInto(&option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	if option.BizID == 0 {
+		ctx.RespAutoError(ctx.Kit.CCError.CCErrorf(common.CCErrCommParamsInvalid, common.BKAppIDField))
+		return
+	}
+
+	result, err := s.core.ProcessOperation().ListServiceTemplateRollouts(ctx.Kit, option)
+	if err != nil {
+		blog.Errorf("ListServiceTemplateRollouts failed, option: %+v, err: %v, rid: %s", option, err, ctx.Kit.Rid)
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(result)
+}
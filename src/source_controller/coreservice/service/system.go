@@ -14,6 +14,7 @@ package service
 
 import (
 	"configcenter/src/common/http/rest"
+	"configcenter/src/common/metadata"
 )
 
 func (s *coreService) GetSystemUserConfig(ctx *rest.Contexts) {
@@ -28,3 +29,18 @@ func (s *coreService) SearchConfigAdmin(ctx *rest.Contexts) {
 	}
 	ctx.RespEntity(conf)
 }
+
+func (s *coreService) SearchTenants(ctx *rest.Contexts) {
+	option := new(metadata.ListTenantsOption)
+	if err := ctx.DecodeInto(option); err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+
+	tenants, err := s.core.SystemOperation().SearchTenants(ctx.Kit, option)
+	if err != nil {
+		ctx.RespAutoError(err)
+		return
+	}
+	ctx.RespEntity(tenants)
+}
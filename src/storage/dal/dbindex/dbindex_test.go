@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbindex
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal/memory"
+	"configcenter/src/storage/dal/types"
+)
+
+func TestDiff(t *testing.T) {
+	expected := []types.Index{
+		{Name: "idx_a", Keys: map[string]int32{"a": 1}, Unique: true},
+		{Name: "idx_b", Keys: map[string]int32{"b": 1}},
+		{Name: "idx_c", Keys: map[string]int32{"c": 1}, ExpireAfterSeconds: 10},
+		{Name: "idx_d", Keys: map[string]int32{"d": 1, "e": 1}},
+	}
+	actual := []types.Index{
+		{Name: "_id_", Keys: map[string]int32{"_id": 1}},
+		{Name: "a_1", Keys: map[string]int32{"a": 1}, Unique: true},
+		{Name: "idx_b", Keys: map[string]int32{"b": 1}, Unique: true},
+		{Name: "idx_c", Keys: map[string]int32{"c": 1}},
+		{Name: "idx_d", Keys: map[string]int32{"d": 1, "e": -1}},
+	}
+
+	diff := Diff("cc_Test", expected, actual)
+	if len(diff.Missing) != 1 || diff.Missing[0].Name != "idx_d" {
+		t.Fatalf("index with different key orders should be missing, got: %+v", diff.Missing)
+	}
+	if len(diff.Extra) != 1 || diff.Extra[0].Name != "idx_d" || diff.Extra[0].Keys["e"] != -1 {
+		t.Fatalf("only the index with different key orders should be extra, got: %+v", diff.Extra)
+	}
+	if len(diff.Mismatched) != 2 || diff.Mismatched[0].Actual.Name != "idx_b" ||
+		diff.Mismatched[1].Actual.Name != "idx_c" {
+		t.Fatalf("indexes with different unique or ttl options should be mismatched, got: %+v", diff.Mismatched)
+	}
+
+	diff = Diff("cc_Test", expected[:1], actual[:2])
+	if !diff.IsEmpty() {
+		t.Fatalf("index with the same keys but different name should not drift, got: %+v", diff)
+	}
+}
+
+func TestUniqueIndex(t *testing.T) {
+	propertyIDs := map[uint64]string{1: "name", 2: "bk_host_innerip", 3: "bk_cloud_id"}
+
+	unique := metadata.ObjectUnique{ID: 10, ObjID: "switch", Keys: []metadata.UniqueKey{
+		{Kind: metadata.UniqueKeyKindProperty, ID: 1}}}
+	table, index, ok := UniqueIndex(unique, propertyIDs)
+	if !ok || table != common.BKTableNameBaseInst || index.Name != "bkcc_unique_switch_10" || index.Unique ||
+		!sameKeys(index.Keys, map[string]int32{common.BKObjIDField: 1, "name": 1}) {
+		t.Fatalf("custom model unique index is invalid, table: %s, index: %+v", table, index)
+	}
+
+	unique = metadata.ObjectUnique{ID: 11, ObjID: common.BKInnerObjIDHost, Keys: []metadata.UniqueKey{
+		{Kind: metadata.UniqueKeyKindProperty, ID: 2}, {Kind: metadata.UniqueKeyKindProperty, ID: 3}}}
+	table, index, ok = UniqueIndex(unique, propertyIDs)
+	if !ok || table != common.BKTableNameBaseHost ||
+		!sameKeys(index.Keys, map[string]int32{"bk_host_innerip": 1, "bk_cloud_id": 1}) {
+		t.Fatalf("host unique index is invalid, table: %s, index: %+v", table, index)
+	}
+
+	unique.Keys = append(unique.Keys, metadata.UniqueKey{Kind: metadata.UniqueKeyKindAssociation, ID: 4})
+	if _, _, ok = UniqueIndex(unique, propertyIDs); ok {
+		t.Fatalf("unique rule with association key should not be indexed")
+	}
+
+	unique.Keys = []metadata.UniqueKey{{Kind: metadata.UniqueKeyKindProperty, ID: 5}}
+	if _, _, ok = UniqueIndex(unique, propertyIDs); ok {
+		t.Fatalf("unique rule with unknown property should not be indexed")
+	}
+}
+
+func TestCheckAndReconcile(t *testing.T) {
+	ctx := context.Background()
+	db := memory.NewMemory()
+
+	attrs := []map[string]interface{}{
+		{common.BKFieldID: 1, common.BKObjIDField: "switch", common.BKPropertyIDField: "name"},
+		{common.BKFieldID: 2, common.BKObjIDField: "router", common.BKPropertyIDField: "name"},
+	}
+	if err := db.Table(common.BKTableNameObjAttDes).Insert(ctx, attrs); err != nil {
+		t.Fatal(err)
+	}
+	uniques := []metadata.ObjectUnique{
+		{ID: 1, ObjID: "switch", Keys: []metadata.UniqueKey{{Kind: metadata.UniqueKeyKindProperty, ID: 1}}},
+		{ID: 2, ObjID: "router", Keys: []metadata.UniqueKey{{Kind: metadata.UniqueKeyKindProperty, ID: 2}}},
+	}
+	if err := db.Table(common.BKTableNameObjUnique).Insert(ctx, uniques); err != nil {
+		t.Fatal(err)
+	}
+
+	table := db.Table(common.BKTableNameBaseInst)
+	existIndexes := []types.Index{
+		{Name: "bk_inst_id_1", Keys: map[string]int32{common.BKInstIDField: 1}},
+		{Name: "custom_1", Keys: map[string]int32{"custom": 1}},
+	}
+	for _, index := range existIndexes {
+		if err := table.CreateIndex(ctx, index); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diffs, err := Check(ctx, db, []string{common.BKTableNameBaseInst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 {
+		t.Fatalf("object base table should drift, got: %+v", diffs)
+	}
+	// the registered indexes, and one index for the two rules on the same keys
+	if len(diffs[0].Missing) != len(TableIndexes(common.BKTableNameBaseInst)) {
+		t.Fatalf("missing indexes are invalid, got: %+v", diffs[0].Missing)
+	}
+	if len(diffs[0].Extra) != 1 || len(diffs[0].Mismatched) != 1 {
+		t.Fatalf("extra or mismatched indexes are invalid, got: %+v", diffs[0])
+	}
+
+	result, err := Reconcile(ctx, db, diffs[0], ReconcileOption{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Created) != len(diffs[0].Missing) || len(result.Dropped) != 0 || len(result.Rebuilt) != 0 {
+		t.Fatalf("only the missing indexes should be created by default, got: %+v", result)
+	}
+
+	diffs, err = Check(ctx, db, []string{common.BKTableNameBaseInst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || len(diffs[0].Missing) != 0 {
+		t.Fatalf("missing indexes should be created, got: %+v", diffs)
+	}
+
+	_, err = Reconcile(ctx, db, diffs[0], ReconcileOption{DropExtra: true, RebuildMismatched: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diffs, err = Check(ctx, db, []string{common.BKTableNameBaseInst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 0 {
+		t.Fatalf("all indexes should be reconciled, got: %+v", diffs)
+	}
+
+	if _, err = Check(ctx, db, []string{"cc_NotExist"}); err == nil {
+		t.Fatalf("check unregistered table should fail")
+	}
+}
+
+func TestRegisterHostSecondaryKeys(t *testing.T) {
+	registered := tableIndexes[common.BKTableNameBaseHost]
+	defer func() {
+		tableIndexes[common.BKTableNameBaseHost] = registered
+	}()
+
+	RegisterHostSecondaryKeys([]string{" bk_sn", "", "bk_sn", common.BKHostOuterIPField, common.BKHostInnerIPField})
+	indexes := TableIndexes(common.BKTableNameBaseHost)
+	if len(indexes) != len(registered)+1 {
+		t.Fatalf("expect only the bk_sn index is registered, got %+v", indexes)
+	}
+	if index := indexes[len(indexes)-1]; index.Name != "bk_sn_1" || index.Keys["bk_sn"] != 1 || !index.Background {
+		t.Fatalf("unexpected bk_sn index: %+v", index)
+	}
+}
@@ -0,0 +1,245 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// idIndexName is the name of the default index on _id that every collection has, it is never reported.
+const idIndexName = "_id_"
+
+// Mismatch is an index in db that has the same keys with the expected one but different options.
+type Mismatch struct {
+	Expected types.Index `json:"expected"`
+	Actual   types.Index `json:"actual"`
+}
+
+// TableDiff is the difference between the expected indexes of a table and the indexes in db, the indexes are
+// matched by their keys, an index with a different name but the same keys is not a drift.
+type TableDiff struct {
+	Table      string        `json:"table"`
+	Missing    []types.Index `json:"missing"`
+	Extra      []types.Index `json:"extra"`
+	Mismatched []Mismatch    `json:"mismatched"`
+}
+
+// IsEmpty returns whether the table's indexes are the same with the expected ones.
+func (d *TableDiff) IsEmpty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Mismatched) == 0
+}
+
+// Diff compares the expected indexes of the table with the actual indexes in db.
+func Diff(table string, expected, actual []types.Index) TableDiff {
+	diff := TableDiff{
+		Table:      table,
+		Missing:    make([]types.Index, 0),
+		Extra:      make([]types.Index, 0),
+		Mismatched: make([]Mismatch, 0),
+	}
+
+	matched := make(map[int]bool)
+	for _, index := range expected {
+		found := false
+		for idx, exist := range actual {
+			if !sameKeys(index.Keys, exist.Keys) {
+				continue
+			}
+			found = true
+			matched[idx] = true
+			if index.Unique != exist.Unique || index.ExpireAfterSeconds != exist.ExpireAfterSeconds {
+				diff.Mismatched = append(diff.Mismatched, Mismatch{Expected: index, Actual: exist})
+			}
+			break
+		}
+		if !found {
+			diff.Missing = append(diff.Missing, index)
+		}
+	}
+
+	for idx, exist := range actual {
+		if !matched[idx] && exist.Name != idIndexName {
+			diff.Extra = append(diff.Extra, exist)
+		}
+	}
+	return diff
+}
+
+// Check diffs the expected indexes of the tables with the indexes in db, all the expected tables are checked if
+// tables is empty. only the tables that have drifted indexes are returned.
+func Check(ctx context.Context, db dal.RDB, tables []string) ([]TableDiff, error) {
+	expected, err := ExpectedIndexes(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tables) == 0 {
+		for table := range expected {
+			tables = append(tables, table)
+		}
+		sort.Strings(tables)
+	}
+
+	diffs := make([]TableDiff, 0)
+	for _, table := range tables {
+		indexes, exists := expected[table]
+		if !exists {
+			return nil, fmt.Errorf("table %s has no registered indexes", table)
+		}
+
+		actual, err := tableIndexesInDB(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+
+		diff := Diff(table, indexes, actual)
+		if !diff.IsEmpty() {
+			diffs = append(diffs, diff)
+		}
+	}
+	return diffs, nil
+}
+
+func tableIndexesInDB(ctx context.Context, db dal.RDB, table string) ([]types.Index, error) {
+	exists, err := db.HasTable(ctx, table)
+	if err != nil {
+		return nil, fmt.Errorf("check table %s exists failed, err: %v", table, err)
+	}
+	if !exists {
+		return nil, nil
+	}
+
+	indexes, err := db.Table(table).Indexes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("get table %s indexes failed, err: %v", table, err)
+	}
+	return indexes, nil
+}
+
+// CheckOption is the option to check the indexes of the tables, all the expected tables are checked if it's empty.
+type CheckOption struct {
+	Tables []string `json:"tables"`
+}
+
+// ReconcileOption controls which drifted indexes are reconciled. the missing indexes are always created, while
+// the extra and mismatched ones are only changed on demand, since they may be created by the operators on purpose.
+type ReconcileOption struct {
+	Tables            []string `json:"tables"`
+	DropExtra         bool     `json:"drop_extra"`
+	RebuildMismatched bool     `json:"rebuild_mismatched"`
+}
+
+// ReconcileResult is the names of the indexes that are changed in a table.
+type ReconcileResult struct {
+	Table   string   `json:"table"`
+	Created []string `json:"created"`
+	Dropped []string `json:"dropped"`
+	Rebuilt []string `json:"rebuilt"`
+}
+
+// Reconcile makes the table's indexes the same with the expected ones by the diff. the indexes are built in
+// background, so that the table can still be read and written during the reconciliation. a mismatched index is
+// dropped before it is rebuilt, because db does not allow two indexes on the same keys.
+func Reconcile(ctx context.Context, db dal.RDB, diff TableDiff, opt ReconcileOption) (*ReconcileResult, error) {
+	result := &ReconcileResult{
+		Table:   diff.Table,
+		Created: make([]string, 0),
+		Dropped: make([]string, 0),
+		Rebuilt: make([]string, 0),
+	}
+
+	if len(diff.Missing) > 0 {
+		exists, err := db.HasTable(ctx, diff.Table)
+		if err != nil {
+			return result, fmt.Errorf("check table %s exists failed, err: %v", diff.Table, err)
+		}
+		if !exists {
+			if err := db.CreateTable(ctx, diff.Table); err != nil && !db.IsDuplicatedError(err) {
+				return result, fmt.Errorf("create table %s failed, err: %v", diff.Table, err)
+			}
+		}
+	}
+
+	table := db.Table(diff.Table)
+	// the extra indexes are dropped first, so that a missing index can reuse the name of the dropped one.
+	if opt.DropExtra {
+		for _, index := range diff.Extra {
+			if err := table.DropIndex(ctx, index.Name); err != nil {
+				return result, fmt.Errorf("drop table %s index %s failed, err: %v", diff.Table, index.Name, err)
+			}
+			result.Dropped = append(result.Dropped, index.Name)
+		}
+	}
+
+	for _, index := range diff.Missing {
+		index.Background = true
+		if err := table.CreateIndex(ctx, index); err != nil {
+			return result, fmt.Errorf("create table %s index %s failed, err: %v", diff.Table, index.Name, err)
+		}
+		result.Created = append(result.Created, index.Name)
+	}
+
+	if opt.RebuildMismatched {
+		for _, mismatch := range diff.Mismatched {
+			if err := table.DropIndex(ctx, mismatch.Actual.Name); err != nil {
+				return result, fmt.Errorf("drop table %s index %s failed, err: %v", diff.Table, mismatch.Actual.Name,
+					err)
+			}
+
+			index := mismatch.Expected
+			index.Background = true
+			if err := table.CreateIndex(ctx, index); err != nil {
+				return result, fmt.Errorf("rebuild table %s index %s failed, err: %v", diff.Table, index.Name, err)
+			}
+			result.Rebuilt = append(result.Rebuilt, index.Name)
+		}
+	}
+	return result, nil
+}
+
+// ReconcileTables checks the indexes of the option's tables and reconciles the drifted ones table by table, the
+// results of the reconciled tables are returned even if it fails halfway.
+func ReconcileTables(ctx context.Context, db dal.RDB, opt ReconcileOption) ([]ReconcileResult, error) {
+	diffs, err := Check(ctx, db, opt.Tables)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ReconcileResult, 0, len(diffs))
+	for _, diff := range diffs {
+		result, err := Reconcile(ctx, db, diff, opt)
+		results = append(results, *result)
+		if err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// sameKeys checks whether the two indexes are on the same keys with the same orders.
+func sameKeys(a, b map[string]int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, order := range a {
+		if exist, ok := b[key]; !ok || exist != order {
+			return false
+		}
+	}
+	return true
+}
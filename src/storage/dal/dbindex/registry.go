@@ -0,0 +1,408 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dbindex declares the indexes of every collection cmdb uses, including the indexes derived from the
+// models' unique rules, and diffs them against the indexes that exist in the db so that they can be reconciled.
+package dbindex
+
+import (
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal/types"
+)
+
+// idUniqueIndex is the unique index on the auto increment id field that most of the tables have.
+var idUniqueIndex = types.Index{Name: "idx_unique_id", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true,
+	Background: true}
+
+// tableIndexes is the indexes that the built-in tables should have. the indexes were created by the upgraders
+// historically, when an upgrader replaced an index with another one on the same keys, only the latter is kept.
+// a table with no index except _id is registered with nil, so that its extra indexes are reported too.
+var tableIndexes = map[string][]types.Index{
+	common.BKTableNameBaseApp: {
+		{Name: "idx_unique_bizID", Keys: map[string]int32{common.BKAppIDField: 1}, Unique: true, Background: true},
+		{Name: "bk_biz_name_1", Keys: map[string]int32{common.BKAppNameField: 1}, Background: true},
+		{Name: "default_1", Keys: map[string]int32{common.BKDefaultField: 1}, Background: true},
+	},
+	common.BKTableNameBaseHost: {
+		{Name: "idx_unique_hostID", Keys: map[string]int32{common.BKHostIDField: 1}, Unique: true, Background: true},
+		{Name: "bk_host_name_1", Keys: map[string]int32{common.BKHostNameField: 1}, Background: true},
+		{Name: "bk_host_innerip_1", Keys: map[string]int32{common.BKHostInnerIPField: 1}, Background: true},
+		{Name: "bk_host_outerip_1", Keys: map[string]int32{common.BKHostOuterIPField: 1}, Background: true},
+		{Name: "innerIP_platID", Keys: map[string]int32{common.BKHostInnerIPField: 1, common.BKCloudIDField: 1},
+			Background: true},
+		{Name: "cloudInstID", Keys: map[string]int32{"bk_cloud_inst_id": 1}, Background: true},
+		{Name: "bk_cloud_id_1", Keys: map[string]int32{common.BKCloudIDField: 1}, Background: true},
+		{Name: "bk_os_type_1", Keys: map[string]int32{"bk_os_type": 1}, Background: true},
+	},
+	common.BKTableNameBaseModule: {
+		{Name: "idx_unique_moduleID", Keys: map[string]int32{common.BKModuleIDField: 1}, Unique: true,
+			Background: true},
+		{Name: "idx_unique_bizID_setID_moduleName", Keys: map[string]int32{common.BKAppIDField: 1,
+			common.BKSetIDField: 1, common.BKModuleNameField: 1}, Unique: true, Background: true},
+		{Name: "bk_module_name_1", Keys: map[string]int32{common.BKModuleNameField: 1}, Background: true},
+		{Name: "default_1", Keys: map[string]int32{common.BKDefaultField: 1}, Background: true},
+		{Name: "bk_biz_id_1", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+		{Name: "bk_set_id_1", Keys: map[string]int32{common.BKSetIDField: 1}, Background: true},
+		{Name: "bk_parent_id_1", Keys: map[string]int32{common.BKParentIDField: 1}, Background: true},
+	},
+	common.BKTableNameBaseSet: {
+		{Name: "idx_unique_setID", Keys: map[string]int32{common.BKSetIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_unique_parentID_setName", Keys: map[string]int32{common.BKParentIDField: 1,
+			common.BKSetNameField: 1}, Unique: true, Background: true},
+		{Name: "bk_parent_id_1", Keys: map[string]int32{common.BKParentIDField: 1}, Background: true},
+		{Name: "bk_biz_id_1", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+		{Name: "bk_set_name_1", Keys: map[string]int32{common.BKSetNameField: 1}, Background: true},
+	},
+	common.BKTableNameBaseInst: {
+		{Name: "idx_unique_instID", Keys: map[string]int32{common.BKInstIDField: 1}, Unique: true, Background: true},
+		{Name: "bk_obj_id_1", Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+		{Name: common.BKInstNameField, Keys: map[string]int32{common.BKInstNameField: 1}, Background: true},
+	},
+	common.BKTableNameBasePlat: {
+		{Name: "idx_unique_cloudID", Keys: map[string]int32{common.BKCloudIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_unique_cloudName", Keys: map[string]int32{common.BKCloudNameField: 1}, Unique: true,
+			Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+		{Name: "vpcID", Keys: map[string]int32{"bk_vpc_id": 1}, Background: true},
+	},
+	common.BKTableNameBaseProcess: {
+		{Name: "idx_unique_procID", Keys: map[string]int32{common.BKProcessIDField: 1}, Unique: true,
+			Background: true},
+		{Name: "bk_biz_id_1", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+	},
+	common.BKTableNameModuleHostConfig: {
+		{Name: "idx_unique_moduleID_hostID", Keys: map[string]int32{common.BKModuleIDField: 1,
+			common.BKHostIDField: 1}, Unique: true, Background: true},
+		{Name: "bk_biz_id_1", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "bk_host_id_1", Keys: map[string]int32{common.BKHostIDField: 1}, Background: true},
+		{Name: "bk_module_id_1", Keys: map[string]int32{common.BKModuleIDField: 1}, Background: true},
+		{Name: "bk_set_id_1", Keys: map[string]int32{common.BKSetIDField: 1}, Background: true},
+	},
+	common.BKTableNameObjDes: {
+		idUniqueIndex,
+		{Name: "idx_unique_objID", Keys: map[string]int32{common.BKOwnerIDField: 1, common.BKObjIDField: 1},
+			Unique: true, Background: true},
+		{Name: "bk_obj_id_1", Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+		{Name: "bk_classification_id_1", Keys: map[string]int32{common.BKClassificationIDField: 1},
+			Background: true},
+		{Name: "bk_obj_name_1", Keys: map[string]int32{common.BKObjNameField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+	},
+	common.BKTableNameObjAttDes: {
+		idUniqueIndex,
+		{Name: "idx_unique_objID_propertyID_bizID", Keys: map[string]int32{common.BKOwnerIDField: 1,
+			common.BKObjIDField: 1, common.BKPropertyIDField: 1, common.BKAppIDField: 1}, Unique: true,
+			Background: true},
+		{Name: "idx_unique_objID_propertyName_bizID", Keys: map[string]int32{common.BKOwnerIDField: 1,
+			common.BKObjIDField: 1, common.BKPropertyNameField: 1, common.BKAppIDField: 1}, Unique: true,
+			Background: true},
+		{Name: "bk_obj_id_1", Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+	},
+	common.BKTableNameObjClassification: {
+		idUniqueIndex,
+		{Name: "idx_unique_classificationID", Keys: map[string]int32{common.BKOwnerIDField: 1,
+			common.BKClassificationIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_unique_classificationName", Keys: map[string]int32{common.BKOwnerIDField: 1,
+			common.BKClassificationNameField: 1}, Unique: true, Background: true},
+		{Name: "bk_classification_id_1", Keys: map[string]int32{common.BKClassificationIDField: 1},
+			Background: true},
+		{Name: "bk_classification_name_1", Keys: map[string]int32{common.BKClassificationNameField: 1},
+			Background: true},
+	},
+	common.BKTableNamePropertyGroup: {
+		idUniqueIndex,
+		{Name: "idx_unique_objID_groupName", Keys: map[string]int32{common.BKOwnerIDField: 1, common.BKObjIDField: 1,
+			common.BKAppIDField: 1, common.BKPropertyGroupNameField: 1}, Unique: true, Background: true},
+		{Name: "bk_obj_id_1", Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+		{Name: "bk_group_id_1", Keys: map[string]int32{common.BKPropertyGroupIDField: 1}, Background: true},
+	},
+	common.BKTableNameObjUnique: {
+		idUniqueIndex,
+		{Name: common.BKObjIDField, Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+	},
+	common.BKTableNameObjSchemaVersion: {
+		{Name: "bk_obj_id_1_version_1_bk_supplier_account_1", Keys: map[string]int32{common.BKObjIDField: 1,
+			"version": 1, common.BKOwnerIDField: 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameObjAsst: {
+		idUniqueIndex,
+		{Name: "bk_obj_id_1", Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+		{Name: "bk_asst_obj_id_1", Keys: map[string]int32{common.BKAsstObjIDField: 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+	},
+	common.BKTableNameAsstDes: {
+		idUniqueIndex,
+		{Name: "idx_unique_asstID", Keys: map[string]int32{common.AssociationKindIDField: 1}, Background: true},
+	},
+	common.BKTableNameInstAsst: {
+		idUniqueIndex,
+		{Name: "bk_obj_id_1_bk_inst_id_1", Keys: map[string]int32{common.BKObjIDField: 1, common.BKInstIDField: 1},
+			Background: true},
+		{Name: "idx_objID_asstObjID_asstID", Keys: map[string]int32{common.BKObjIDField: -1,
+			common.BKAsstObjIDField: -1, common.AssociationKindIDField: -1}, Background: true},
+		{Name: "bk_idx_bk_asst_obj_id_bk_asst_inst_id", Keys: map[string]int32{common.BKAsstObjIDField: 1,
+			common.BKAsstInstIDField: 1}, Background: true},
+	},
+	common.BKTableNameTopoGraphics: {
+		{Name: "scope_type_1_scope_id_1_node_type_1_bk_obj_id_1_bk_inst_id_1", Keys: map[string]int32{
+			"scope_type": 1, "scope_id": 1, "node_type": 1, common.BKObjIDField: 1, common.BKInstIDField: 1},
+			Unique: true, Background: true},
+	},
+	common.BKTableNameAuditLog: {
+		{Name: "index_id", Keys: map[string]int32{common.BKFieldID: 1}, Background: true},
+		{Name: "index_operationTime", Keys: map[string]int32{common.BKOperationTimeField: 1}, Background: true},
+		{Name: "index_user", Keys: map[string]int32{common.BKUser: 1}, Background: true},
+		{Name: "index_resourceName", Keys: map[string]int32{common.BKResourceNameField: 1}, Background: true},
+		{Name: "index_operationTime_auditType_resourceType_action", Keys: map[string]int32{
+			common.BKOperationTimeField: 1, common.BKAuditTypeField: 1, common.BKResourceTypeField: 1,
+			common.BKOperationDetailField + "." + common.BKObjIDField: 1, common.BKActionField: 1},
+			Background: true},
+	},
+	common.BKTableNameSubscription: {
+		{Name: "idx_unique_subscriptionID", Keys: map[string]int32{common.BKSubscriptionIDField: 1}, Unique: true,
+			Background: true},
+		{Name: "idx_unique_subscriptionName", Keys: map[string]int32{common.BKOwnerIDField: 1,
+			common.BKSubscriptionNameField: 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameDynamicGroup: {
+		{Name: "id_1", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{Name: "bk_biz_id_1_id_1", Keys: map[string]int32{common.BKAppIDField: 1, common.BKFieldID: 1},
+			Background: true},
+		{Name: "bk_biz_id_1_name_1", Keys: map[string]int32{common.BKAppIDField: 1, common.BKFieldName: 1},
+			Unique: true, Background: true},
+	},
+	common.BKTableNameDelArchive: {
+		{Name: "idx_oid_coll", Keys: map[string]int32{"oid": 1, "coll": 1}, Unique: true, Background: true},
+		{Name: "idx_coll", Keys: map[string]int32{"coll": 1}, Background: true},
+	},
+	common.BKTableNameHostLock: {
+		{Name: "bk_host_id_1", Keys: map[string]int32{common.BKHostIDField: 1}, Background: true},
+		{Name: "expire_time_1", Keys: map[string]int32{common.BKHostLockExpireTimeField: 1}, Background: true,
+			ExpireAfterSeconds: 1},
+	},
+	common.BKTableNameNetcollectDevice: {
+		{Name: "device_id_1", Keys: map[string]int32{"device_id": 1}, Background: true},
+		{Name: "device_name_1", Keys: map[string]int32{"device_name": 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+	},
+	common.BKTableNameNetcollectProperty: {
+		{Name: "netcollect_property_id_1", Keys: map[string]int32{"netcollect_property_id": 1}, Background: true},
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BkSupplierAccount: 1}, Background: true},
+	},
+	common.BKTableNameChartConfig: {
+		{Name: "config_id", Keys: map[string]int32{"config_id": 1}, Unique: true, Background: true},
+		{Name: common.BKObjIDField, Keys: map[string]int32{common.BKObjIDField: 1}, Background: true},
+	},
+	common.BKTableNameChartPosition: {
+		{Name: common.BKAppIDField, Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+	},
+	common.BKTableNameServiceCategory: {
+		idUniqueIndex,
+		{Name: "idx_unique_Name_parentID_bizID", Keys: map[string]int32{common.BKOwnerIDField: 1,
+			common.BKFieldName: 1, common.BKParentIDField: 1, common.BKAppIDField: 1}, Unique: true,
+			Background: true},
+	},
+	common.BKTableNameServiceTemplate: {
+		idUniqueIndex,
+		{Name: "idx_unique_bizID_name", Keys: map[string]int32{common.BKAppIDField: 1, common.BKFieldName: 1},
+			Unique: true, Background: true},
+		{Name: "idx_bkBizID", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+	},
+	common.BKTableNameProcessTemplate: {
+		idUniqueIndex,
+		{Name: "idx_serviceTemplateID", Keys: map[string]int32{common.BKServiceTemplateIDField: 1},
+			Background: true},
+		{Name: "idx_bkBizID", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "bk_idx_service_template_id_bk_process_name", Keys: map[string]int32{
+			common.BKServiceTemplateIDField: 1, common.BKProcessNameField: 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameServiceInstance: {
+		idUniqueIndex,
+		{Name: "idx_bkBizID", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "idx_serviceTemplateID", Keys: map[string]int32{common.BKServiceTemplateIDField: 1},
+			Background: true},
+		{Name: "moduleID", Keys: map[string]int32{common.BKModuleIDField: 1}, Background: true},
+	},
+	common.BKTableNameProcessInstanceRelation: {
+		{Name: "idx_unique_serviceInstID_ProcID", Keys: map[string]int32{common.BKServiceInstanceIDField: 1,
+			common.BKProcessIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_unique_procID_hostID", Keys: map[string]int32{common.BKProcessIDField: 1,
+			common.BKHostIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_bkServiceInstanceID", Keys: map[string]int32{common.BKServiceInstanceIDField: 1},
+			Background: true},
+		{Name: "idx_bkProcessTemplateID", Keys: map[string]int32{common.BKProcessTemplateIDField: 1},
+			Background: true},
+		{Name: "idx_bkBizID", Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: "idx_bkProcessID", Keys: map[string]int32{common.BKProcessIDField: 1}, Background: true},
+	},
+	common.BKTableNameServiceTemplateVersion: {
+		{Name: "service_template_id_1_version_1_bk_supplier_account_1", Keys: map[string]int32{
+			common.BKServiceTemplateIDField: 1, "version": 1, common.BKOwnerIDField: 1}, Unique: true,
+			Background: true},
+	},
+	common.BKTableNameServiceTemplateRollout: {
+		{Name: "id_1", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{Name: "bk_biz_id_1_service_template_id_1", Keys: map[string]int32{common.BKAppIDField: 1,
+			common.BKServiceTemplateIDField: 1}, Background: true},
+		{Name: "active_key_1", Keys: map[string]int32{"active_key": 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameSetTemplate: {
+		idUniqueIndex,
+		{Name: "idx_unique_bizID_name", Keys: map[string]int32{common.BKAppIDField: 1, common.BKFieldName: 1},
+			Unique: true, Background: true},
+	},
+	common.BKTableNameSetServiceTemplateRelation: {
+		{Name: "idx_unique_setTemplateID_serviceTemplateID", Keys: map[string]int32{common.BKSetTemplateIDField: 1,
+			common.BKServiceTemplateIDField: 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameAPITask: {
+		{Name: "idx_taskID", Keys: map[string]int32{common.BKTaskIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_name_status_createTime", Keys: map[string]int32{common.BKFieldName: 1, common.BKStatusField: 1,
+			common.CreateTimeField: 1}, Background: true},
+		{Name: "idx_status_lastTime", Keys: map[string]int32{common.BKStatusField: 1, common.LastTimeField: 1},
+			Background: true},
+		{Name: "idx_name_flag_createTime", Keys: map[string]int32{common.BKFieldName: 1, "flag": 1,
+			common.CreateTimeField: 1}, Background: true},
+	},
+	common.BKTableNameSetTemplateSyncStatus: {
+		{Name: "idx_taskID", Keys: map[string]int32{common.BKTaskIDField: 1}, Background: true},
+		{Name: "idx_setID", Keys: map[string]int32{common.BKSetIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_createLastTime", Keys: map[string]int32{common.LastTimeField: 1, common.CreateTimeField: 1},
+			Background: true},
+		{Name: "idx_status", Keys: map[string]int32{common.BKStatusField: 1}, Background: true},
+	},
+	common.BKTableNameSetTemplateSyncHistory: {
+		{Name: "idx_taskID", Keys: map[string]int32{common.BKTaskIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_setID", Keys: map[string]int32{common.BKSetIDField: 1}, Background: true},
+		{Name: "idx_createLastTime", Keys: map[string]int32{common.LastTimeField: 1, common.CreateTimeField: 1},
+			Background: true},
+		{Name: "idx_status", Keys: map[string]int32{common.BKStatusField: 1}, Background: true},
+	},
+	common.BKTableNameHostApplyRule: {
+		idUniqueIndex,
+		{Name: common.BKAppIDField, Keys: map[string]int32{common.BKAppIDField: 1}, Background: true},
+		{Name: common.BKModuleIDField, Keys: map[string]int32{common.BKModuleIDField: 1}, Background: true},
+		{Name: "host_property_under_module", Keys: map[string]int32{common.BKModuleIDField: 1,
+			common.BKAttributeIDField: 1}, Unique: true, Background: true},
+		{Name: "idx_unique_bizID_moduleID_attrID", Keys: map[string]int32{common.BKAppIDField: 1,
+			common.BKModuleIDField: 1, common.BKAttributeIDField: 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameCloudSyncTask: {
+		{Name: "bk_task_id", Keys: map[string]int32{"bk_task_id": 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameCloudAccount: {
+		{Name: "bk_account_id", Keys: map[string]int32{"bk_account_id": 1}, Background: true},
+	},
+	common.BKTableNameCloudSyncHistory: {
+		{Name: "bk_history_id", Keys: map[string]int32{"bk_history_id": 1}, Background: true},
+	},
+	common.BKTableNameAuthRole: {
+		{Name: "id_1", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{Name: "name_1_bk_supplier_account_1", Keys: map[string]int32{common.BKFieldName: 1,
+			common.BKOwnerIDField: 1}, Unique: true, Background: true},
+	},
+	common.BKTableNameAuthRoleBinding: {
+		{Name: "id_1", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{Name: "subject_type_1_subject_1", Keys: map[string]int32{"subject_type": 1, "subject": 1},
+			Background: true},
+		{Name: "role_id_1", Keys: map[string]int32{"role_id": 1}, Background: true},
+	},
+	common.BKTableNameAuthUserGroup: {
+		{Name: "id_1", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{Name: "name_1_bk_supplier_account_1", Keys: map[string]int32{common.BKFieldName: 1,
+			common.BKOwnerIDField: 1}, Unique: true, Background: true},
+		{Name: "members_1", Keys: map[string]int32{"members": 1}, Background: true},
+	},
+	common.BKTableNameAPIToken: {
+		{Name: "id_1", Keys: map[string]int32{common.BKFieldID: 1}, Unique: true, Background: true},
+		{Name: "token_hash_1", Keys: map[string]int32{"token_hash": 1}, Unique: true, Background: true},
+		{Name: "user_1_bk_supplier_account_1", Keys: map[string]int32{"user": 1, common.BKOwnerIDField: 1},
+			Background: true},
+	},
+	common.BKTableNameTenant: {
+		{Name: "bk_supplier_account_1", Keys: map[string]int32{common.BKOwnerIDField: 1}, Unique: true,
+			Background: true},
+	},
+	common.BKTableNameChartData:         nil,
+	common.BKTableNameSystem:            nil,
+	common.BKTableNameHistory:           nil,
+	common.BKTableNameHostFavorite:      nil,
+	common.BKTableNameUserAPI:           nil,
+	common.BKTableNameUserCustom:        nil,
+	common.BKTableNameTransaction:       nil,
+	common.BKTableNameIDgenerator:       nil,
+	common.BKTableNameNetcollectConfig:  nil,
+	common.BKTableNameNetcollectReport:  nil,
+	common.BKTableNameNetcollectHistory: nil,
+	common.BKTableNameSlowQueryLog:      nil,
+}
+
+// RegisterHostSecondaryKeys declares the indexes of the host attributes which are the secondary keys of the host
+// cache, because the hosts are found with them in db when they are not cached. it must be called before the
+// indexes are checked or reconciled, the attribute which already has an index with the same key is skipped.
+func RegisterHostSecondaryKeys(fields []string) {
+	for _, field := range fields {
+		field = strings.TrimSpace(field)
+		if len(field) == 0 {
+			continue
+		}
+
+		index := types.Index{Name: field + "_1", Keys: map[string]int32{field: 1}, Background: true}
+		if hasSameKeys(tableIndexes[common.BKTableNameBaseHost], index) {
+			continue
+		}
+		tableIndexes[common.BKTableNameBaseHost] = append(tableIndexes[common.BKTableNameBaseHost], index)
+	}
+}
+
+// Tables returns the sorted names of the built-in tables that are registered.
+func Tables() []string {
+	tables := make([]string, 0, len(tableIndexes))
+	for table := range tableIndexes {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	return tables
+}
+
+// IsRegistered returns whether the table is a registered built-in table.
+func IsRegistered(table string) bool {
+	_, exists := tableIndexes[table]
+	return exists
+}
+
+// TableIndexes returns a copy of the registered indexes of the built-in table.
+func TableIndexes(table string) []types.Index {
+	indexes := make([]types.Index, 0, len(tableIndexes[table]))
+	for _, index := range tableIndexes[table] {
+		indexes = append(indexes, copyIndex(index))
+	}
+	return indexes
+}
+
+func copyIndex(index types.Index) types.Index {
+	keys := make(map[string]int32, len(index.Keys))
+	for key, order := range index.Keys {
+		keys[key] = order
+	}
+	index.Keys = keys
+	return index
+}
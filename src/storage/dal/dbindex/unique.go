@@ -0,0 +1,136 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package dbindex
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+)
+
+// uniqueIndexPrefix is the name prefix of the indexes derived from the models' unique rules.
+const uniqueIndexPrefix = "bkcc_unique_"
+
+// UniqueIndex returns the table and the index derived from the model's unique rule, propertyIDs maps the rule's
+// property key ids to the attributes' property ids. the index is not unique, because the rule is checked by
+// coreservice with the empty values ignored, the index only makes the check and the searches on these fields fast.
+// the rule with association keys can not be indexed, since these keys are not stored in the instance table.
+func UniqueIndex(unique metadata.ObjectUnique, propertyIDs map[uint64]string) (string, types.Index, bool) {
+	if len(unique.Keys) == 0 {
+		return "", types.Index{}, false
+	}
+
+	table := common.GetInstTableName(unique.ObjID)
+	keys := make(map[string]int32)
+	if table == common.BKTableNameBaseInst {
+		// the custom models share the same table, so the instances are always searched with the model id.
+		keys[common.BKObjIDField] = 1
+	}
+
+	for _, key := range unique.Keys {
+		if key.Kind != metadata.UniqueKeyKindProperty {
+			return "", types.Index{}, false
+		}
+		propertyID, exists := propertyIDs[key.ID]
+		if !exists {
+			return "", types.Index{}, false
+		}
+		keys[propertyID] = 1
+	}
+
+	index := types.Index{
+		Name:       fmt.Sprintf("%s%s_%d", uniqueIndexPrefix, unique.ObjID, unique.ID),
+		Keys:       keys,
+		Background: true,
+	}
+	return table, index, true
+}
+
+// UniqueIndexes reads all the models' unique rules from db, and returns the indexes derived from them by table.
+// the rules of different models may have the same keys in the shared instance table, only the first one is kept.
+func UniqueIndexes(ctx context.Context, db dal.RDB) (map[string][]types.Index, error) {
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := db.Table(common.BKTableNameObjUnique).Find(nil).All(ctx, &uniques); err != nil {
+		return nil, fmt.Errorf("find object unique rules failed, err: %v", err)
+	}
+	sort.Slice(uniques, func(i, j int) bool { return uniques[i].ID < uniques[j].ID })
+
+	keyIDs := make([]uint64, 0)
+	for _, unique := range uniques {
+		for _, key := range unique.Keys {
+			if key.Kind == metadata.UniqueKeyKindProperty {
+				keyIDs = append(keyIDs, key.ID)
+			}
+		}
+	}
+
+	propertyIDs := make(map[uint64]string)
+	if len(keyIDs) > 0 {
+		attrs := make([]metadata.Attribute, 0)
+		filter := map[string]interface{}{common.BKFieldID: map[string]interface{}{common.BKDBIN: keyIDs}}
+		err := db.Table(common.BKTableNameObjAttDes).Find(filter).Fields(common.BKFieldID,
+			common.BKPropertyIDField).All(ctx, &attrs)
+		if err != nil {
+			return nil, fmt.Errorf("find object unique rules' attributes failed, err: %v", err)
+		}
+		for _, attr := range attrs {
+			propertyIDs[uint64(attr.ID)] = attr.PropertyID
+		}
+	}
+
+	indexes := make(map[string][]types.Index)
+	for _, unique := range uniques {
+		table, index, ok := UniqueIndex(unique, propertyIDs)
+		if !ok || hasSameKeys(indexes[table], index) {
+			continue
+		}
+		indexes[table] = append(indexes[table], index)
+	}
+	return indexes, nil
+}
+
+// ExpectedIndexes returns the registered indexes of the built-in tables together with the indexes derived from
+// the models' unique rules, the derived index is dropped if a registered index of the table has the same keys.
+func ExpectedIndexes(ctx context.Context, db dal.RDB) (map[string][]types.Index, error) {
+	uniqueIndexes, err := UniqueIndexes(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := make(map[string][]types.Index, len(tableIndexes))
+	for table := range tableIndexes {
+		expected[table] = TableIndexes(table)
+	}
+	for table, indexes := range uniqueIndexes {
+		for _, index := range indexes {
+			if !hasSameKeys(expected[table], index) {
+				expected[table] = append(expected[table], index)
+			}
+		}
+	}
+	return expected, nil
+}
+
+func hasSameKeys(indexes []types.Index, index types.Index) bool {
+	for _, exist := range indexes {
+		if sameKeys(exist.Keys, index.Keys) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,519 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package dbtest is the conformance test suite of dal.DB, all the implementations, such as mongodb and
+// the in-memory db, should pass it, so that the unit tests with the in-memory db behave like mongodb.
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+type host struct {
+	ID      int64    `bson:"bk_host_id"`
+	IP      string   `bson:"bk_host_innerip"`
+	Biz     int64    `bson:"bk_biz_id"`
+	CPU     int64    `bson:"bk_cpu"`
+	Tags    []string `bson:"tags"`
+	Owner   string   `bson:"bk_supplier_account"`
+	Comment string   `bson:"comment,omitempty"`
+	Disk    *disk    `bson:"disk,omitempty"`
+}
+
+type disk struct {
+	Size int64  `bson:"size"`
+	Type string `bson:"type"`
+}
+
+func testHosts() []host {
+	return []host{
+		{ID: 1, IP: "127.0.0.1", Biz: 1, CPU: 4, Tags: []string{"db", "prod"}, Owner: "0", Disk: &disk{100, "ssd"}},
+		{ID: 2, IP: "127.0.0.2", Biz: 1, CPU: 8, Tags: []string{"web"}, Owner: "0", Comment: "web server"},
+		{ID: 3, IP: "127.0.0.3", Biz: 2, CPU: 16, Tags: []string{"web", "prod"}, Owner: "0", Disk: &disk{500, "hdd"}},
+		{ID: 4, IP: "10.0.0.1", Biz: 2, CPU: 2, Tags: []string{}, Owner: "1"},
+		{ID: 5, IP: "10.0.0.2", Biz: 3, CPU: 8, Tags: []string{"db"}, Owner: "1", Comment: "DB Server"},
+	}
+}
+
+// RunConformance run the conformance tests with the db, the tables used by the tests have a random
+// name, and they are dropped after the tests.
+func RunConformance(t *testing.T, db dal.DB) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, db dal.DB, table string)
+	}{
+		{name: "find", run: testFind},
+		{name: "filter", run: testFilter},
+		{name: "iterate", run: testIterate},
+		{name: "update", run: testUpdate},
+		{name: "upsert", run: testUpsert},
+		{name: "update multi model", run: testUpdateMultiModel},
+		{name: "delete", run: testDelete},
+		{name: "column", run: testColumn},
+		{name: "distinct", run: testDistinct},
+		{name: "index", run: testIndex},
+		{name: "aggregate", run: testAggregate},
+		{name: "table", run: testTable},
+		{name: "sequence", run: testSequence},
+	}
+
+	for _, test := range tests {
+		table := fmt.Sprintf("cc_DBTest_%d", time.Now().UnixNano())
+		t.Run(test.name, func(t *testing.T) {
+			defer db.DropTable(context.Background(), table)
+			test.run(t, db, table)
+		})
+	}
+}
+
+func insertHosts(t *testing.T, db dal.DB, table string) {
+	hosts := testHosts()
+	err := db.Table(table).Insert(context.Background(), hosts)
+	require.NoError(t, err)
+}
+
+// findIDs find the hosts with the filter and returns the sorted host ids.
+func findIDs(t *testing.T, db dal.DB, table string, filter map[string]interface{}) []int64 {
+	hosts := make([]host, 0)
+	err := db.Table(table).Find(filter).All(context.Background(), &hosts)
+	require.NoError(t, err)
+
+	ids := make([]int64, 0, len(hosts))
+	for _, h := range hosts {
+		ids = append(ids, h.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func testFind(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	// sort, start and limit
+	hosts := make([]host, 0)
+	err := db.Table(table).Find(nil).Sort("bk_cpu:-1,bk_host_id").Start(1).Limit(2).All(ctx, &hosts)
+	require.NoError(t, err)
+	require.Len(t, hosts, 2)
+	require.Equal(t, int64(2), hosts[0].ID)
+	require.Equal(t, int64(5), hosts[1].ID)
+
+	// fields, the other fields and _id are not returned.
+	docs := make([]map[string]interface{}, 0)
+	err = db.Table(table).Find(map[string]interface{}{common.BKHostIDField: 1}).Fields(common.BKHostInnerIPField).
+		All(ctx, &docs)
+	require.NoError(t, err)
+	require.Len(t, docs, 1)
+	require.Len(t, docs[0], 1)
+	require.Equal(t, "127.0.0.1", docs[0][common.BKHostInnerIPField])
+
+	// embedded fields
+	one := new(host)
+	err = db.Table(table).Find(map[string]interface{}{common.BKHostIDField: 3}).Fields("disk.size").One(ctx, one)
+	require.NoError(t, err)
+	require.Equal(t, &disk{Size: 500}, one.Disk)
+
+	// with object id
+	doc := make(map[string]interface{})
+	err = db.Table(table).Find(map[string]interface{}{common.BKHostIDField: 1}, types.FindOpts{WithObjectID: true}).
+		Fields(common.BKHostIDField).One(ctx, &doc)
+	require.NoError(t, err)
+	require.Contains(t, doc, "_id")
+
+	// one with sort
+	err = db.Table(table).Find(map[string]interface{}{common.BKAppIDField: 2}).Sort("-bk_cpu").One(ctx, one)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), one.ID)
+
+	// not found
+	err = db.Table(table).Find(map[string]interface{}{common.BKHostIDField: 100}).One(ctx, one)
+	require.True(t, db.IsNotFoundError(err))
+
+	// count ignores start and limit
+	cnt, err := db.Table(table).Find(map[string]interface{}{common.BKAppIDField: map[string]interface{}{
+		common.BKDBGTE: 2}}).Start(1).Limit(1).Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), cnt)
+}
+
+// iterateIDs walks through the iterator and returns the host ids in the order they are returned.
+func iterateIDs(t *testing.T, iter types.Iterator) []int64 {
+	ctx := context.Background()
+	defer iter.Close(ctx)
+
+	ids := make([]int64, 0)
+	for iter.Next(ctx) {
+		one := new(host)
+		require.NoError(t, iter.Decode(one))
+		ids = append(ids, one.ID)
+	}
+	require.NoError(t, iter.Err())
+	return ids
+}
+
+func testIterate(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	// iterate with sort, start and limit
+	iter := db.Table(table).Find(nil).Sort("bk_cpu:-1,bk_host_id").Start(1).Limit(3).Iter(ctx)
+	require.Equal(t, []int64{2, 5, 1}, iterateIDs(t, iter))
+
+	// iterate nothing
+	iter = db.Table(table).Find(map[string]interface{}{common.BKHostIDField: 100}).Iter(ctx)
+	require.Empty(t, iterateIDs(t, iter))
+
+	// keyset pagination by the id field, the pages are not full except the last one.
+	filter := map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBNE: 3}}
+	keyset := types.NewKeysetIter(db.Table(table), filter, common.BKHostIDField, 2)
+	require.Equal(t, []int64{1, 2, 4, 5}, iterateIDs(t, keyset))
+	require.EqualValues(t, 5, keyset.LastID())
+
+	// keyset pagination with the page size equals to the count, and resume after an id.
+	keyset = types.NewKeysetIter(db.Table(table), nil, common.BKHostIDField, 3).After(int64(2))
+	require.Equal(t, []int64{3, 4, 5}, iterateIDs(t, keyset))
+
+	// keyset pagination by _id with fields
+	keyset = types.NewKeysetIter(db.Table(table), nil, "_id", 1).Fields(common.BKHostIDField)
+	ids := iterateIDs(t, keyset)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	require.Equal(t, []int64{1, 2, 3, 4, 5}, ids)
+}
+
+func testFilter(t *testing.T, db dal.DB, table string) {
+	insertHosts(t, db, table)
+
+	cases := []struct {
+		filter map[string]interface{}
+		ids    []int64
+	}{
+		{filter: map[string]interface{}{common.BKAppIDField: 1}, ids: []int64{1, 2}},
+		{filter: map[string]interface{}{"tags": "prod"}, ids: []int64{1, 3}},
+		{filter: map[string]interface{}{"tags": []string{"web"}}, ids: []int64{2}},
+		{filter: map[string]interface{}{"disk.type": "ssd"}, ids: []int64{1}},
+		{filter: map[string]interface{}{"comment": nil}, ids: []int64{1, 3, 4}},
+		{filter: map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBIN: []int64{1, 5, 7}}},
+			ids: []int64{1, 5}},
+		{filter: map[string]interface{}{common.BKHostIDField: map[string]interface{}{common.BKDBNIN: []int64{1, 5}}},
+			ids: []int64{2, 3, 4}},
+		{filter: map[string]interface{}{"bk_cpu": map[string]interface{}{common.BKDBGT: 4, common.BKDBLTE: 16}},
+			ids: []int64{2, 3, 5}},
+		{filter: map[string]interface{}{"bk_cpu": map[string]interface{}{common.BKDBLT: 4.5}}, ids: []int64{1, 4}},
+		{filter: map[string]interface{}{common.BKAppIDField: map[string]interface{}{common.BKDBNE: 1}},
+			ids: []int64{3, 4, 5}},
+		{filter: map[string]interface{}{"comment": map[string]interface{}{common.BKDBExists: true}},
+			ids: []int64{2, 5}},
+		{filter: map[string]interface{}{"comment": map[string]interface{}{common.BKDBLIKE: "server",
+			common.BKDBOPTIONS: "i"}}, ids: []int64{2, 5}},
+		{filter: map[string]interface{}{common.BKHostInnerIPField: map[string]interface{}{common.BKDBLIKE: "^10\\."}},
+			ids: []int64{4, 5}},
+		{filter: map[string]interface{}{common.BKDBOR: []map[string]interface{}{{common.BKAppIDField: 3},
+			{"bk_cpu": 16}}}, ids: []int64{3, 5}},
+		{filter: map[string]interface{}{common.BKDBAND: []map[string]interface{}{{common.BKAppIDField: 1},
+			{"bk_cpu": 8}}}, ids: []int64{2}},
+		{filter: map[string]interface{}{"$nor": []map[string]interface{}{{common.BKAppIDField: 1},
+			{common.BKOwnerIDField: "1"}}}, ids: []int64{3}},
+		{filter: map[string]interface{}{"bk_cpu": map[string]interface{}{common.BKDBNot: map[string]interface{}{
+			common.BKDBGT: 4}}}, ids: []int64{1, 4}},
+		{filter: map[string]interface{}{"tags": map[string]interface{}{common.BKDBAll: []string{"web", "prod"}}},
+			ids: []int64{3}},
+		{filter: map[string]interface{}{"tags": map[string]interface{}{common.BKDBSize: 0}}, ids: []int64{4}},
+		{filter: map[string]interface{}{"tags": map[string]interface{}{"$elemMatch": map[string]interface{}{
+			common.BKDBEQ: "db"}}}, ids: []int64{1, 5}},
+	}
+
+	for _, c := range cases {
+		require.Equal(t, c.ids, findIDs(t, db, table, c.filter), "filter: %v", c.filter)
+	}
+}
+
+func testUpdate(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	err := db.Table(table).Update(ctx, map[string]interface{}{common.BKAppIDField: 1},
+		map[string]interface{}{"bk_cpu": 32, "disk.type": "nvme"})
+	require.NoError(t, err)
+
+	hosts := make([]host, 0)
+	err = db.Table(table).Find(map[string]interface{}{common.BKAppIDField: 1}).Sort(common.BKHostIDField).All(ctx,
+		&hosts)
+	require.NoError(t, err)
+	require.Len(t, hosts, 2)
+	require.Equal(t, int64(32), hosts[0].CPU)
+	require.Equal(t, &disk{Size: 100, Type: "nvme"}, hosts[0].Disk)
+	require.Equal(t, int64(32), hosts[1].CPU)
+	require.Equal(t, &disk{Type: "nvme"}, hosts[1].Disk)
+
+	// no matched documents is not an error
+	err = db.Table(table).Update(ctx, map[string]interface{}{common.BKAppIDField: 100},
+		map[string]interface{}{"bk_cpu": 1})
+	require.NoError(t, err)
+}
+
+func testUpsert(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	filter := map[string]interface{}{common.BKHostIDField: 1}
+	err := db.Table(table).Upsert(ctx, filter, map[string]interface{}{"bk_cpu": 64})
+	require.NoError(t, err)
+
+	one := new(host)
+	require.NoError(t, db.Table(table).Find(filter).One(ctx, one))
+	require.Equal(t, int64(64), one.CPU)
+	require.Equal(t, "127.0.0.1", one.IP)
+
+	// insert with the filter's fields
+	filter = map[string]interface{}{common.BKHostIDField: 6, common.BKAppIDField: 4}
+	err = db.Table(table).Upsert(ctx, filter, map[string]interface{}{common.BKHostInnerIPField: "10.0.0.6"})
+	require.NoError(t, err)
+
+	one = new(host)
+	require.NoError(t, db.Table(table).Find(filter).One(ctx, one))
+	require.Equal(t, host{ID: 6, Biz: 4, IP: "10.0.0.6"}, *one)
+}
+
+func testUpdateMultiModel(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	filter := map[string]interface{}{common.BKHostIDField: 1}
+	err := db.Table(table).UpdateMultiModel(ctx, filter,
+		types.ModeUpdate{Op: "inc", Doc: map[string]interface{}{"bk_cpu": 2}},
+		types.ModeUpdate{Op: types.UpdateOpAddToSet, Doc: map[string]interface{}{"tags": "db"}},
+		types.ModeUpdate{Op: "unset", Doc: map[string]interface{}{"disk": ""}},
+	)
+	require.NoError(t, err)
+
+	one := new(host)
+	require.NoError(t, db.Table(table).Find(filter).One(ctx, one))
+	require.Equal(t, int64(6), one.CPU)
+	require.Equal(t, []string{"db", "prod"}, one.Tags)
+	require.Nil(t, one.Disk)
+
+	err = db.Table(table).UpdateMultiModel(ctx, filter,
+		types.ModeUpdate{Op: types.UpdateOpPull, Doc: map[string]interface{}{"tags": "db"}})
+	require.NoError(t, err)
+
+	err = db.Table(table).UpdateMultiModel(ctx, filter,
+		types.ModeUpdate{Op: "push", Doc: map[string]interface{}{"tags": "cache"}})
+	require.NoError(t, err)
+
+	// a path can not be updated by multiple operators
+	err = db.Table(table).UpdateMultiModel(ctx, filter,
+		types.ModeUpdate{Op: "set", Doc: map[string]interface{}{"disk.size": 1}},
+		types.ModeUpdate{Op: "unset", Doc: map[string]interface{}{"disk": ""}},
+	)
+	require.Error(t, err)
+
+	require.NoError(t, db.Table(table).Find(filter).One(ctx, one))
+	require.Equal(t, []string{"prod", "cache"}, one.Tags)
+
+	// matched count, the documents whose value is not changed are counted too.
+	matched, err := db.Table(table).UpdateMultiModelCount(ctx, map[string]interface{}{common.BKAppIDField: 1},
+		types.ModeUpdate{Op: "set", Doc: map[string]interface{}{"bk_cpu": 8}})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), matched)
+
+	matched, err = db.Table(table).UpdateMultiModelCount(ctx, map[string]interface{}{common.BKAppIDField: 100},
+		types.ModeUpdate{Op: "inc", Doc: map[string]interface{}{"bk_cpu": 1}})
+	require.NoError(t, err)
+	require.Equal(t, uint64(0), matched)
+}
+
+func testDelete(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	err := db.Table(table).Delete(ctx, map[string]interface{}{common.BKAppIDField: 2})
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 5}, findIDs(t, db, table, nil))
+
+	// no matched documents is not an error
+	err = db.Table(table).Delete(ctx, map[string]interface{}{common.BKAppIDField: 2})
+	require.NoError(t, err)
+}
+
+func testColumn(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	require.NoError(t, db.Table(table).AddColumn(ctx, "comment", "new"))
+	require.NoError(t, db.Table(table).RenameColumn(ctx, "comment", "bk_comment"))
+
+	docs := make([]map[string]interface{}, 0)
+	err := db.Table(table).Find(nil).Sort(common.BKHostIDField).Fields("bk_comment").All(ctx, &docs)
+	require.NoError(t, err)
+	require.Len(t, docs, 5)
+	require.Equal(t, "new", docs[0]["bk_comment"])
+	require.Equal(t, "web server", docs[1]["bk_comment"])
+
+	require.NoError(t, db.Table(table).DropDocsColumn(ctx, "bk_comment", map[string]interface{}{
+		common.BKHostIDField: 1}))
+	require.Equal(t, []int64{2, 3, 4, 5}, findIDs(t, db, table, map[string]interface{}{
+		"bk_comment": map[string]interface{}{common.BKDBExists: true}}))
+
+	require.NoError(t, db.Table(table).DropColumn(ctx, "bk_comment"))
+	require.Empty(t, findIDs(t, db, table, map[string]interface{}{
+		"bk_comment": map[string]interface{}{common.BKDBExists: true}}))
+}
+
+func testDistinct(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	values, err := db.Table(table).Distinct(ctx, "tags", map[string]interface{}{common.BKOwnerIDField: "0"})
+	require.NoError(t, err)
+
+	tags := make([]string, 0)
+	for _, val := range values {
+		tags = append(tags, val.(string))
+	}
+	sort.Strings(tags)
+	require.Equal(t, []string{"db", "prod", "web"}, tags)
+}
+
+func testIndex(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	index := types.Index{
+		Keys:       map[string]int32{common.BKHostInnerIPField: 1, common.BKOwnerIDField: 1},
+		Name:       "idx_unique_ip",
+		Unique:     true,
+		Background: true,
+	}
+	require.NoError(t, db.Table(table).CreateIndex(ctx, index))
+	// create the same index again is not an error
+	require.NoError(t, db.Table(table).CreateIndex(ctx, index))
+
+	indexes, err := db.Table(table).Indexes(ctx)
+	require.NoError(t, err)
+	names := make([]string, 0)
+	for _, idx := range indexes {
+		names = append(names, idx.Name)
+	}
+	require.Contains(t, names, "idx_unique_ip")
+
+	err = db.Table(table).Insert(ctx, host{ID: 10, IP: "127.0.0.1", Owner: "0"})
+	require.True(t, db.IsDuplicatedError(err))
+
+	err = db.Table(table).Update(ctx, map[string]interface{}{common.BKHostIDField: 2},
+		map[string]interface{}{common.BKHostInnerIPField: "127.0.0.1"})
+	require.True(t, db.IsDuplicatedError(err))
+
+	// a different owner is not duplicated
+	require.NoError(t, db.Table(table).Insert(ctx, host{ID: 10, IP: "127.0.0.1", Owner: "1"}))
+
+	require.NoError(t, db.Table(table).DropIndex(ctx, "idx_unique_ip"))
+	require.NoError(t, db.Table(table).Insert(ctx, host{ID: 11, IP: "127.0.0.1", Owner: "0"}))
+}
+
+func testAggregate(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+	insertHosts(t, db, table)
+
+	type bizCount struct {
+		Biz   int64 `bson:"_id"`
+		Count int64 `bson:"count"`
+		CPU   int64 `bson:"cpu"`
+	}
+
+	pipeline := []map[string]interface{}{
+		{common.BKDBMatch: map[string]interface{}{common.BKOwnerIDField: "0"}},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":   "$" + common.BKAppIDField,
+			"count": map[string]interface{}{common.BKDBSum: 1},
+			"cpu":   map[string]interface{}{common.BKDBSum: "$bk_cpu"},
+		}},
+		{"$sort": map[string]interface{}{"_id": 1}},
+	}
+	counts := make([]bizCount, 0)
+	require.NoError(t, db.Table(table).AggregateAll(ctx, pipeline, &counts))
+	require.Equal(t, []bizCount{{Biz: 1, Count: 2, CPU: 12}, {Biz: 2, Count: 1, CPU: 16}}, counts)
+
+	// count the unique tags of each owner
+	pipeline = []map[string]interface{}{
+		{"$unwind": "$tags"},
+		{common.BKDBGroup: map[string]interface{}{
+			"_id":  "$" + common.BKOwnerIDField,
+			"tags": map[string]interface{}{common.BKDBAddToSet: "$tags"},
+		}},
+		{common.BKDBProject: map[string]interface{}{
+			"_id":   1,
+			"count": map[string]interface{}{common.BKDBSize: "$tags"},
+		}},
+		{"$sort": map[string]interface{}{"count": -1}},
+		{"$limit": 1},
+	}
+	tagCount := struct {
+		Owner string `bson:"_id"`
+		Count int64  `bson:"count"`
+	}{}
+	require.NoError(t, db.Table(table).AggregateOne(ctx, pipeline, &tagCount))
+	require.Equal(t, "0", tagCount.Owner)
+	require.Equal(t, int64(3), tagCount.Count)
+
+	total := struct {
+		Count int64 `bson:"total"`
+	}{}
+	pipeline = []map[string]interface{}{
+		{common.BKDBMatch: map[string]interface{}{"bk_cpu": map[string]interface{}{common.BKDBGTE: 8}}},
+		{common.BKDBCount: "total"},
+	}
+	require.NoError(t, db.Table(table).AggregateOne(ctx, pipeline, &total))
+	require.Equal(t, int64(3), total.Count)
+}
+
+func testTable(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+
+	exist, err := db.HasTable(ctx, table)
+	require.NoError(t, err)
+	require.False(t, exist)
+
+	require.NoError(t, db.CreateTable(ctx, table))
+	exist, err = db.HasTable(ctx, table)
+	require.NoError(t, err)
+	require.True(t, exist)
+
+	require.NoError(t, db.DropTable(ctx, table))
+	exist, err = db.HasTable(ctx, table)
+	require.NoError(t, err)
+	require.False(t, exist)
+}
+
+func testSequence(t *testing.T, db dal.DB, table string) {
+	ctx := context.Background()
+
+	first, err := db.NextSequence(ctx, table)
+	require.NoError(t, err)
+
+	sequences, err := db.NextSequences(ctx, table, 3)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{first + 1, first + 2, first + 3}, sequences)
+
+	next, err := db.NextSequence(ctx, table)
+	require.NoError(t, err)
+	require.Equal(t, first+4, next)
+}
@@ -0,0 +1,482 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// AggregateAll aggregate all operation
+func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(pipeline)
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+// AggregateOne aggregate one operation
+func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, result interface{}) error {
+	docs, err := c.aggregate(pipeline)
+	if err != nil {
+		return err
+	}
+
+	if len(docs) == 0 {
+		return types.ErrDocumentNotFound
+	}
+	return decodeDoc(docs[0], result)
+}
+
+// aggregate run the pipeline on the collection's documents, it supports the basic stages which is used
+// by cmdb, such as $match, $group, $project, $sort, $skip, $limit, $unwind, $count and $sample.
+func (c *Collection) aggregate(pipeline interface{}) ([]bsonx.Doc, error) {
+	stages, err := toDocs(pipeline)
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.RLock()
+	docs := make([]bsonx.Doc, 0)
+	if t := c.getTable(c.collName, false); t != nil {
+		for _, doc := range t.docs {
+			docs = append(docs, cloneDoc(doc))
+		}
+	}
+	c.lock.RUnlock()
+
+	for _, stage := range stages {
+		if len(stage) != 1 {
+			return nil, fmt.Errorf("a pipeline stage specification object must contain exactly one field")
+		}
+
+		docs, err = runStage(docs, stage[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
+
+func runStage(docs []bsonx.Doc, stage bsonx.Elem) ([]bsonx.Doc, error) {
+	switch stage.Key {
+	case "$match":
+		filter, ok := stage.Value.DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("the match filter must be an expression in an object")
+		}
+
+		result := make([]bsonx.Doc, 0)
+		for _, doc := range docs {
+			matched, err := match(doc, filter)
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				result = append(result, doc)
+			}
+		}
+		return result, nil
+
+	case "$group":
+		spec, ok := stage.Value.DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("a group's fields must be specified in an object")
+		}
+		return group(docs, spec)
+
+	case "$project":
+		spec, ok := stage.Value.DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("$project specification must be an object")
+		}
+		return projectStage(docs, spec)
+
+	case "$sort":
+		spec, ok := stage.Value.DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("the $sort key specification must be an object")
+		}
+
+		fields := make([]sortField, 0, len(spec))
+		for _, elem := range spec {
+			fields = append(fields, sortField{key: elem.Key, desc: floatValue(elem.Value) < 0})
+		}
+		sortDocs(docs, fields)
+		return docs, nil
+
+	case "$skip":
+		skip := int(floatValue(stage.Value))
+		if skip >= len(docs) {
+			return make([]bsonx.Doc, 0), nil
+		}
+		return docs[skip:], nil
+
+	case "$limit":
+		limit := int(floatValue(stage.Value))
+		if limit < len(docs) {
+			return docs[:limit], nil
+		}
+		return docs, nil
+
+	case "$sample":
+		spec, ok := stage.Value.DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("the $sample stage specification must be an object")
+		}
+		size := int(floatValue(spec.Lookup("size")))
+		rand.Shuffle(len(docs), func(i, j int) { docs[i], docs[j] = docs[j], docs[i] })
+		if size < len(docs) {
+			return docs[:size], nil
+		}
+		return docs, nil
+
+	case "$count":
+		return []bsonx.Doc{{{Key: stringValue(stage.Value), Value: bsonx.Int32(int32(len(docs)))}}}, nil
+
+	case "$unwind":
+		return unwind(docs, stage.Value)
+	}
+
+	return nil, fmt.Errorf("unsupported pipeline stage %s", stage.Key)
+}
+
+// evalExpr evaluate an aggregation expression with the document, it supports the field path like "$name",
+// the $size and $sum operators, and the embedded documents of expressions. the second returned value is
+// false when the field path is missing.
+func evalExpr(doc bsonx.Doc, expr bsonx.Val) (bsonx.Val, bool, error) {
+	switch expr.Type() {
+	case bsontype.String:
+		path := expr.StringValue()
+		if !strings.HasPrefix(path, "$") {
+			return expr, true, nil
+		}
+
+		values := lookup(doc, strings.TrimPrefix(path, "$"))
+		switch len(values) {
+		case 0:
+			return bsonx.Null(), false, nil
+		case 1:
+			return values[0], true, nil
+		}
+		return bsonx.Array(values), true, nil
+
+	case bsontype.EmbeddedDocument:
+		sub := expr.Document()
+		if !isOperatorDoc(expr) {
+			result := bsonx.Doc{}
+			for _, elem := range sub {
+				val, exist, err := evalExpr(doc, elem.Value)
+				if err != nil {
+					return bsonx.Val{}, false, err
+				}
+				if exist {
+					result = append(result, bsonx.Elem{Key: elem.Key, Value: val})
+				}
+			}
+			return bsonx.Document(result), true, nil
+		}
+
+		switch sub[0].Key {
+		case "$size":
+			val, _, err := evalExpr(doc, sub[0].Value)
+			if err != nil {
+				return bsonx.Val{}, false, err
+			}
+			arr, ok := val.ArrayOK()
+			if !ok {
+				return bsonx.Val{}, false, fmt.Errorf("the argument to $size must be an array, but was of type: %s",
+					val.Type())
+			}
+			return bsonx.Int32(int32(len(arr))), true, nil
+
+		case "$sum":
+			val, _, err := evalExpr(doc, sub[0].Value)
+			if err != nil {
+				return bsonx.Val{}, false, err
+			}
+			values := bsonx.Arr{val}
+			if arr, ok := val.ArrayOK(); ok {
+				values = arr
+			}
+			sum := bsonx.Int32(0)
+			for _, one := range values {
+				if isNumber(one) {
+					sum, _ = addNumber(sum, one)
+				}
+			}
+			return sum, true, nil
+
+		case "$literal":
+			return sub[0].Value, true, nil
+		}
+
+		return bsonx.Val{}, false, fmt.Errorf("unsupported expression operator %s", sub[0].Key)
+	}
+
+	return expr, true, nil
+}
+
+// group the documents with the _id expression, and calculate the accumulators of each group.
+func group(docs []bsonx.Doc, spec bsonx.Doc) ([]bsonx.Doc, error) {
+	idExpr, err := spec.LookupErr("_id")
+	if err != nil {
+		return nil, fmt.Errorf("a group specification must include an _id")
+	}
+
+	type groupDocs struct {
+		id   bsonx.Val
+		docs []bsonx.Doc
+	}
+	groups := make([]*groupDocs, 0)
+	for _, doc := range docs {
+		id, _, err := evalExpr(doc, idExpr)
+		if err != nil {
+			return nil, err
+		}
+
+		var hit *groupDocs
+		for _, g := range groups {
+			if equalVal(g.id, id) {
+				hit = g
+				break
+			}
+		}
+		if hit == nil {
+			hit = &groupDocs{id: id}
+			groups = append(groups, hit)
+		}
+		hit.docs = append(hit.docs, doc)
+	}
+
+	result := make([]bsonx.Doc, 0, len(groups))
+	for _, g := range groups {
+		doc := bsonx.Doc{{Key: "_id", Value: g.id}}
+		for _, field := range spec {
+			if field.Key == "_id" {
+				continue
+			}
+
+			acc, ok := field.Value.DocumentOK()
+			if !ok || len(acc) != 1 {
+				return nil, fmt.Errorf("the field '%s' must be an accumulator object", field.Key)
+			}
+
+			val, err := accumulate(g.docs, acc[0])
+			if err != nil {
+				return nil, err
+			}
+			doc = append(doc, bsonx.Elem{Key: field.Key, Value: val})
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+// accumulate calculate the accumulator with the documents in a group.
+func accumulate(docs []bsonx.Doc, acc bsonx.Elem) (bsonx.Val, error) {
+	values := make([]bsonx.Val, 0, len(docs))
+	for _, doc := range docs {
+		val, exist, err := evalExpr(doc, acc.Value)
+		if err != nil {
+			return bsonx.Val{}, err
+		}
+		if exist || acc.Key == "$first" || acc.Key == "$last" {
+			values = append(values, val)
+		}
+	}
+
+	switch acc.Key {
+	case "$sum", "$avg":
+		sum := bsonx.Int32(0)
+		count := 0
+		for _, val := range values {
+			if isNumber(val) {
+				sum, _ = addNumber(sum, val)
+				count++
+			}
+		}
+		if acc.Key == "$sum" {
+			return sum, nil
+		}
+		if count == 0 {
+			return bsonx.Null(), nil
+		}
+		return bsonx.Double(floatValue(sum) / float64(count)), nil
+
+	case "$first", "$last":
+		if len(values) == 0 {
+			return bsonx.Null(), nil
+		}
+		if acc.Key == "$first" {
+			return values[0], nil
+		}
+		return values[len(values)-1], nil
+
+	case "$max", "$min":
+		result := bsonx.Null()
+		for _, val := range values {
+			if val.Type() == bsontype.Null {
+				continue
+			}
+			c := compareVal(val, result)
+			if result.Type() == bsontype.Null || (acc.Key == "$max" && c > 0) || (acc.Key == "$min" && c < 0) {
+				result = val
+			}
+		}
+		return result, nil
+
+	case "$push":
+		return bsonx.Array(values), nil
+
+	case "$addToSet":
+		set := bsonx.Arr{}
+		for _, val := range values {
+			if !containsVal(set, val) {
+				set = append(set, val)
+			}
+		}
+		return bsonx.Array(set), nil
+	}
+
+	return bsonx.Val{}, fmt.Errorf("unsupported accumulator %s", acc.Key)
+}
+
+// projectStage reshape the documents with the $project specification, the fields can be included,
+// excluded or computed with expressions.
+func projectStage(docs []bsonx.Doc, spec bsonx.Doc) ([]bsonx.Doc, error) {
+	exclude := make([]string, 0)
+	includeID := true
+	hasInclusion := false
+	for _, elem := range spec {
+		isFlag := elem.Value.Type() == bsontype.Boolean || isNumber(elem.Value)
+		switch {
+		case elem.Key == "_id" && isFlag:
+			includeID = isTrue(elem.Value)
+		case isFlag && !isTrue(elem.Value):
+			exclude = append(exclude, elem.Key)
+		default:
+			hasInclusion = true
+		}
+	}
+
+	if hasInclusion && len(exclude) != 0 {
+		return nil, fmt.Errorf("cannot do exclusion on field %s in inclusion projection", exclude[0])
+	}
+
+	result := make([]bsonx.Doc, 0, len(docs))
+	for _, doc := range docs {
+		if !hasInclusion {
+			for _, field := range exclude {
+				doc = unsetPath(doc, field)
+			}
+			if !includeID {
+				doc = doc.Delete("_id")
+			}
+			result = append(result, doc)
+			continue
+		}
+
+		projected := bsonx.Doc{}
+		if id, err := doc.LookupErr("_id"); err == nil && includeID {
+			projected = append(projected, bsonx.Elem{Key: "_id", Value: id})
+		}
+
+		for _, elem := range spec {
+			if elem.Key == "_id" && (elem.Value.Type() == bsontype.Boolean || isNumber(elem.Value)) {
+				continue
+			}
+
+			var val bsonx.Val
+			var exist bool
+			var err error
+			if elem.Value.Type() == bsontype.Boolean || isNumber(elem.Value) {
+				val, exist = getPath(doc, elem.Key)
+			} else {
+				val, exist, err = evalExpr(doc, elem.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			if !exist {
+				continue
+			}
+
+			if elem.Key == "_id" {
+				projected = projected.Delete("_id")
+			}
+			projected, err = setPath(projected, elem.Key, val)
+			if err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, projected)
+	}
+	return result, nil
+}
+
+// unwind deconstruct the array field of the documents to a document for each element.
+func unwind(docs []bsonx.Doc, spec bsonx.Val) ([]bsonx.Doc, error) {
+	path := ""
+	preserve := false
+	switch spec.Type() {
+	case bsontype.String:
+		path = spec.StringValue()
+	case bsontype.EmbeddedDocument:
+		path = stringValue(spec.Document().Lookup("path"))
+		if val, err := spec.Document().LookupErr("preserveNullAndEmptyArrays"); err == nil {
+			preserve = isTrue(val)
+		}
+	default:
+		return nil, fmt.Errorf("expected either a string or an object as specification for $unwind stage")
+	}
+
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("path option to $unwind stage should be prefixed with a '$': %s", path)
+	}
+	path = strings.TrimPrefix(path, "$")
+
+	result := make([]bsonx.Doc, 0, len(docs))
+	for _, doc := range docs {
+		val, exist := getPath(doc, path)
+		arr, isArr := val.ArrayOK()
+		switch {
+		case !exist || val.Type() == bsontype.Null || (isArr && len(arr) == 0):
+			if preserve {
+				result = append(result, doc)
+			}
+			continue
+		case !isArr:
+			result = append(result, doc)
+			continue
+		}
+
+		for _, elem := range arr {
+			one, err := setPath(cloneDoc(doc), path, elem)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, one)
+		}
+	}
+	return result, nil
+}
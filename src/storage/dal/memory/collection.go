@@ -0,0 +1,517 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// archiveTables is the collections whose deleted documents is archived to the delete archive
+// collection, it's same with mongodb.
+var archiveTables = map[string]bool{
+	common.BKTableNameModuleHostConfig:        true,
+	common.BKTableNameBaseHost:                true,
+	common.BKTableNameBaseApp:                 true,
+	common.BKTableNameBaseSet:                 true,
+	common.BKTableNameBaseModule:              true,
+	common.BKTableNameSetTemplate:             true,
+	common.BKTableNameBaseInst:                true,
+	common.BKTableNameBaseProcess:             true,
+	common.BKTableNameProcessInstanceRelation: true,
+}
+
+// Collection implement types.Table interface
+type Collection struct {
+	collName string
+	*Memory
+}
+
+// Find 查询多个并反序列化到 Result
+func (c *Collection) Find(filter types.Filter, opts ...types.FindOpts) types.Find {
+	find := &Find{
+		Collection: c,
+		filter:     filter,
+		projection: make(map[string]int),
+	}
+
+	if len(opts) == 0 || !opts[0].WithObjectID {
+		find.projection["_id"] = 0
+	}
+	return find
+}
+
+// filterDocs find the documents which matches the filter, the caller must hold the lock.
+func (c *Collection) filterDocs(filter types.Filter) ([]int, error) {
+	t := c.getTable(c.collName, false)
+	if t == nil {
+		return make([]int, 0), nil
+	}
+
+	cond, err := toDoc(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make([]int, 0)
+	for idx, doc := range t.docs {
+		matched, err := match(doc, cond)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes, nil
+}
+
+// checkUnique check whether the document conflicts with the other documents in the unique indexes,
+// the document at index skip is not checked, which is the document itself when it's updated.
+func (t *table) checkUnique(collName string, doc bsonx.Doc, skip int) error {
+	for _, index := range t.indexes {
+		if !index.Unique && index.Name != idIndex.Name {
+			continue
+		}
+
+		for idx, exist := range t.docs {
+			if idx == skip {
+				continue
+			}
+
+			if sameIndexKey(index, doc, exist) {
+				return fmt.Errorf("E11000 duplicate key error collection: %s index: %s", collName, index.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// sameIndexKey check whether the two documents have the same index key, the missing field is null.
+func sameIndexKey(index types.Index, a, b bsonx.Doc) bool {
+	for key := range index.Keys {
+		av, aExist := getPath(a, key)
+		bv, bExist := getPath(b, key)
+		if !aExist {
+			av = bsonx.Null()
+		}
+		if !bExist {
+			bv = bsonx.Null()
+		}
+		if !equalVal(av, bv) {
+			return false
+		}
+	}
+	return true
+}
+
+// withObjectID add an object id to the document if it does not have the _id field.
+func withObjectID(doc bsonx.Doc) bsonx.Doc {
+	if _, err := doc.LookupErr("_id"); err == nil {
+		return doc
+	}
+	return append(bsonx.Doc{{Key: "_id", Value: bsonx.ObjectID(primitive.NewObjectID())}}, doc...)
+}
+
+// Insert 插入数据, docs 可以为 单个数据 或者 多个数据
+func (c *Collection) Insert(ctx context.Context, docs interface{}) error {
+	rows, err := toDocs(docs)
+	if err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.getTable(c.collName, true)
+	for _, row := range rows {
+		row = withObjectID(row)
+		if err := t.checkUnique(c.collName, row, -1); err != nil {
+			return err
+		}
+		t.docs = append(t.docs, row)
+	}
+	return nil
+}
+
+// Update 更新数据
+func (c *Collection) Update(ctx context.Context, filter types.Filter, doc interface{}) error {
+	data, err := toDoc(doc)
+	if err != nil {
+		return err
+	}
+
+	return c.updateMany(filter, bsonx.Doc{{Key: "$set", Value: bsonx.Document(data)}})
+}
+
+// Upsert 数据存在更新数据，否则新加数据。
+func (c *Collection) Upsert(ctx context.Context, filter types.Filter, doc interface{}) error {
+	data, err := toDoc(doc)
+	if err != nil {
+		return err
+	}
+	update := bsonx.Doc{{Key: "$set", Value: bsonx.Document(data)}}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	matched, err := c.filterDocs(filter)
+	if err != nil {
+		return err
+	}
+
+	t := c.getTable(c.collName, true)
+	if len(matched) != 0 {
+		return t.update(c.collName, matched[:1], update)
+	}
+
+	cond, err := toDoc(filter)
+	if err != nil {
+		return err
+	}
+
+	newDoc, err := applyUpdate(equalityFields(cond), update, true)
+	if err != nil {
+		return err
+	}
+	newDoc = withObjectID(newDoc)
+	if err := t.checkUnique(c.collName, newDoc, -1); err != nil {
+		return err
+	}
+	t.docs = append(t.docs, newDoc)
+	return nil
+}
+
+// equalityFields get the equality fields in the filter, which is used to create the document in upsert.
+func equalityFields(filter bsonx.Doc) bsonx.Doc {
+	doc := bsonx.Doc{}
+	for _, elem := range filter {
+		if elem.Key == "$and" {
+			for _, sub := range elem.Value.Array() {
+				if subDoc, ok := sub.DocumentOK(); ok {
+					doc = append(doc, equalityFields(subDoc)...)
+				}
+			}
+			continue
+		}
+
+		if strings.HasPrefix(elem.Key, "$") {
+			continue
+		}
+
+		if !isOperatorDoc(elem.Value) {
+			doc, _ = setPath(doc, elem.Key, elem.Value)
+			continue
+		}
+
+		if eq, err := elem.Value.Document().LookupErr("$eq"); err == nil {
+			doc, _ = setPath(doc, elem.Key, eq)
+		}
+	}
+	return doc
+}
+
+// UpdateMultiModel 根据不同的操作符去更新数据
+func (c *Collection) UpdateMultiModel(ctx context.Context, filter types.Filter, updateModel ...types.ModeUpdate) error {
+	_, err := c.UpdateMultiModelCount(ctx, filter, updateModel...)
+	return err
+}
+
+// UpdateMultiModelCount 根据不同的操作符去更新数据，并返回匹配的数据条数
+func (c *Collection) UpdateMultiModelCount(ctx context.Context, filter types.Filter,
+	updateModel ...types.ModeUpdate) (uint64, error) {
+
+	update := bsonx.Doc{}
+	for _, item := range updateModel {
+		if _, err := update.LookupErr("$" + item.Op); err == nil {
+			return 0, fmt.Errorf("%s appear multiple times", item.Op)
+		}
+
+		doc, err := toDoc(item.Doc)
+		if err != nil {
+			return 0, err
+		}
+		update = append(update, bsonx.Elem{Key: "$" + item.Op, Value: bsonx.Document(doc)})
+	}
+
+	return c.updateManyCount(filter, update)
+}
+
+func (c *Collection) updateMany(filter types.Filter, update bsonx.Doc) error {
+	_, err := c.updateManyCount(filter, update)
+	return err
+}
+
+// updateManyCount update the matched documents and returns the number of them
+func (c *Collection) updateManyCount(filter types.Filter, update bsonx.Doc) (uint64, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	matched, err := c.filterDocs(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(matched) == 0 {
+		return 0, nil
+	}
+	if err := c.getTable(c.collName, true).update(c.collName, matched, update); err != nil {
+		return 0, err
+	}
+	return uint64(len(matched)), nil
+}
+
+// update the documents at the indexes with the update operators, the caller must hold the lock.
+func (t *table) update(collName string, indexes []int, update bsonx.Doc) error {
+	for _, idx := range indexes {
+		newDoc, err := applyUpdate(t.docs[idx], update, false)
+		if err != nil {
+			return err
+		}
+
+		if err := t.checkUnique(collName, newDoc, idx); err != nil {
+			return err
+		}
+		t.docs[idx] = newDoc
+	}
+	return nil
+}
+
+// Delete 删除数据
+func (c *Collection) Delete(ctx context.Context, filter types.Filter) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	matched, err := c.filterDocs(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(matched) == 0 {
+		return nil
+	}
+
+	t := c.getTable(c.collName, true)
+	deleted := make(map[int]bool)
+	for _, idx := range matched {
+		deleted[idx] = true
+	}
+
+	docs := make([]bsonx.Doc, 0, len(t.docs)-len(matched))
+	archives := make([]bsonx.Doc, 0)
+	for idx, doc := range t.docs {
+		if !deleted[idx] {
+			docs = append(docs, doc)
+			continue
+		}
+
+		if archiveTables[c.collName] {
+			oid, _ := doc.Lookup("_id").ObjectIDOK()
+			archives = append(archives, bsonx.Doc{
+				{Key: "_id", Value: bsonx.ObjectID(primitive.NewObjectID())},
+				{Key: "oid", Value: bsonx.String(oid.Hex())},
+				{Key: "detail", Value: bsonx.Document(doc.Copy().Delete("_id"))},
+				{Key: "coll", Value: bsonx.String(c.collName)},
+			})
+		}
+	}
+	t.docs = docs
+
+	if len(archives) != 0 {
+		archive := c.getTable(common.BKTableNameDelArchive, true)
+		archive.docs = append(archive.docs, archives...)
+	}
+	return nil
+}
+
+// CreateIndex 创建索引
+func (c *Collection) CreateIndex(ctx context.Context, index types.Index) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.getTable(c.collName, true)
+	if index.Name == "" {
+		index.Name = indexName(index.Keys)
+	}
+
+	for _, exist := range t.indexes {
+		if sameIndexKeys(exist.Keys, index.Keys) {
+			// the index already exist, no matter what it's name is.
+			return nil
+		}
+
+		if exist.Name == index.Name {
+			return fmt.Errorf("There's already an index with name: %s", index.Name)
+		}
+	}
+
+	if index.Unique {
+		for idx, doc := range t.docs {
+			for _, other := range t.docs[idx+1:] {
+				if sameIndexKey(index, doc, other) {
+					return fmt.Errorf("E11000 duplicate key error collection: %s index: %s", c.collName, index.Name)
+				}
+			}
+		}
+	}
+
+	t.indexes = append(t.indexes, index)
+	return nil
+}
+
+func sameIndexKeys(a, b map[string]int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, order := range a {
+		if b[key] != order {
+			return false
+		}
+	}
+	return true
+}
+
+// indexName generate the default index name like mongodb, the keys are sorted because the map is not ordered.
+func indexName(keys map[string]int32) string {
+	fields := make([]string, 0, len(keys))
+	for key := range keys {
+		fields = append(fields, key)
+	}
+	sort.Strings(fields)
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, fmt.Sprintf("%s_%d", field, keys[field]))
+	}
+	return strings.Join(names, "_")
+}
+
+// DropIndex remove index by name
+func (c *Collection) DropIndex(ctx context.Context, indexName string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	t := c.getTable(c.collName, false)
+	if t == nil {
+		return fmt.Errorf("ns not found")
+	}
+
+	for idx, index := range t.indexes {
+		if index.Name == indexName && index.Name != idIndex.Name {
+			t.indexes = append(t.indexes[:idx], t.indexes[idx+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("index not found with name [%s]", indexName)
+}
+
+// Indexes get all indexes for the collection
+func (c *Collection) Indexes(ctx context.Context) ([]types.Index, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	t := c.getTable(c.collName, false)
+	if t == nil {
+		return nil, nil
+	}
+
+	indexes := make([]types.Index, len(t.indexes))
+	copy(indexes, t.indexes)
+	return indexes, nil
+}
+
+// AddColumn add a new column for the collection
+func (c *Collection) AddColumn(ctx context.Context, column string, value interface{}) error {
+	val, err := toVal(value)
+	if err != nil {
+		return err
+	}
+
+	filter := bsonx.Doc{{Key: column, Value: bsonx.Document(bsonx.Doc{{Key: "$exists", Value: bsonx.Boolean(false)}})}}
+	update := bsonx.Doc{{Key: "$set", Value: bsonx.Document(bsonx.Doc{{Key: column, Value: val}})}}
+	return c.updateMany(filter, update)
+}
+
+// RenameColumn rename a column for the collection
+func (c *Collection) RenameColumn(ctx context.Context, oldName, newColumn string) error {
+	update := bsonx.Doc{{Key: "$rename", Value: bsonx.Document(bsonx.Doc{{Key: oldName,
+		Value: bsonx.String(newColumn)}})}}
+	return c.updateMany(nil, update)
+}
+
+// DropColumn remove a column by the name
+func (c *Collection) DropColumn(ctx context.Context, field string) error {
+	return c.DropColumns(ctx, nil, []string{field})
+}
+
+// DropColumns remove many columns by the name
+func (c *Collection) DropColumns(ctx context.Context, filter types.Filter, fields []string) error {
+	unset := bsonx.Doc{}
+	for _, field := range fields {
+		unset = append(unset, bsonx.Elem{Key: field, Value: bsonx.String("")})
+	}
+	return c.updateMany(filter, bsonx.Doc{{Key: "$unset", Value: bsonx.Document(unset)}})
+}
+
+// DropDocsColumn remove a column by the name for doc use filter
+func (c *Collection) DropDocsColumn(ctx context.Context, field string, filter types.Filter) error {
+	return c.DropColumns(ctx, filter, []string{field})
+}
+
+// Distinct Finds the distinct values for a specified field across a single collection or view and returns the results in an
+// field the field for which to return distinct values.
+// filter query that specifies the documents from which to retrieve the distinct values.
+func (c *Collection) Distinct(ctx context.Context, field string, filter types.Filter) ([]interface{}, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	matched, err := c.filterDocs(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	t := c.getTable(c.collName, false)
+	values := make([]bsonx.Val, 0)
+	for _, idx := range matched {
+		for _, val := range lookup(t.docs[idx], field) {
+			elems := bsonx.Arr{val}
+			if arr, ok := val.ArrayOK(); ok {
+				elems = arr
+			}
+
+			for _, elem := range elems {
+				if !containsVal(values, elem) {
+					values = append(values, elem)
+				}
+			}
+		}
+	}
+
+	result := make([]interface{}, 0, len(values))
+	for _, val := range values {
+		v, err := decodeVal(val)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
@@ -0,0 +1,315 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// match check whether the document matches the filter, it supports the query operators which is used
+// by cmdb, an error is returned when the filter has an unsupported operator.
+func match(doc bsonx.Doc, filter bsonx.Doc) (bool, error) {
+	for _, elem := range filter {
+		matched, err := matchElem(doc, elem)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func matchElem(doc bsonx.Doc, elem bsonx.Elem) (bool, error) {
+	switch elem.Key {
+	case "$and", "$or", "$nor":
+		arr, ok := elem.Value.ArrayOK()
+		if !ok {
+			return false, fmt.Errorf("%s must be an array", elem.Key)
+		}
+
+		for _, sub := range arr {
+			subFilter, ok := sub.DocumentOK()
+			if !ok {
+				return false, fmt.Errorf("the element of %s must be a document", elem.Key)
+			}
+
+			matched, err := match(doc, subFilter)
+			if err != nil {
+				return false, err
+			}
+
+			switch {
+			case elem.Key == "$and" && !matched:
+				return false, nil
+			case elem.Key == "$or" && matched:
+				return true, nil
+			case elem.Key == "$nor" && matched:
+				return false, nil
+			}
+		}
+		return elem.Key != "$or", nil
+	}
+
+	if strings.HasPrefix(elem.Key, "$") {
+		return false, fmt.Errorf("unsupported top level query operator %s", elem.Key)
+	}
+
+	return matchValues(lookup(doc, elem.Key), elem.Value)
+}
+
+// isOperatorDoc check whether the value is a document of query operators, like {"$in": [1, 2]}.
+func isOperatorDoc(val bsonx.Val) bool {
+	doc, ok := val.DocumentOK()
+	if !ok || len(doc) == 0 {
+		return false
+	}
+	return strings.HasPrefix(doc[0].Key, "$")
+}
+
+// matchValues check whether the field values match the condition.
+func matchValues(values []bsonx.Val, cond bsonx.Val) (bool, error) {
+	if !isOperatorDoc(cond) {
+		if cond.Type() == bsontype.Regex {
+			return matchRegex(values, cond)
+		}
+		return matchEqual(values, cond), nil
+	}
+
+	ops := cond.Document()
+	for _, op := range ops {
+		matched, err := matchOperator(values, op, ops)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// expandArray returns the values and the elements of the array values, which is what the query
+// operators compare with.
+func expandArray(values []bsonx.Val) []bsonx.Val {
+	result := make([]bsonx.Val, 0, len(values))
+	for _, val := range values {
+		result = append(result, val)
+		if arr, ok := val.ArrayOK(); ok {
+			result = append(result, arr...)
+		}
+	}
+	return result
+}
+
+// matchEqual check whether any of the values or the elements of the array values equals the condition,
+// the missing field equals null.
+func matchEqual(values []bsonx.Val, cond bsonx.Val) bool {
+	if len(values) == 0 {
+		return cond.Type() == bsontype.Null
+	}
+
+	for _, val := range expandArray(values) {
+		if equalVal(val, cond) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOperator(values []bsonx.Val, op bsonx.Elem, ops bsonx.Doc) (bool, error) {
+	switch op.Key {
+	case "$eq":
+		return matchEqual(values, op.Value), nil
+
+	case "$ne":
+		return !matchEqual(values, op.Value), nil
+
+	case "$in", "$nin":
+		arr, ok := op.Value.ArrayOK()
+		if !ok {
+			return false, fmt.Errorf("%s needs an array", op.Key)
+		}
+
+		hit := false
+		for _, one := range arr {
+			var matched bool
+			if one.Type() == bsontype.Regex {
+				matched, _ = matchRegex(values, one)
+			} else {
+				matched = matchEqual(values, one)
+			}
+			if matched {
+				hit = true
+				break
+			}
+		}
+		if op.Key == "$in" {
+			return hit, nil
+		}
+		return !hit, nil
+
+	case "$gt", "$gte", "$lt", "$lte":
+		for _, val := range expandArray(values) {
+			// only the values with the same type is compared, like mongodb does.
+			if typeOrder(val.Type()) != typeOrder(op.Value.Type()) {
+				continue
+			}
+
+			c := compareVal(val, op.Value)
+			if (op.Key == "$gt" && c > 0) || (op.Key == "$gte" && c >= 0) || (op.Key == "$lt" && c < 0) ||
+				(op.Key == "$lte" && c <= 0) {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "$exists":
+		exists := len(values) != 0
+		return exists == isTrue(op.Value), nil
+
+	case "$regex":
+		pattern, options := "", ""
+		switch op.Value.Type() {
+		case bsontype.String:
+			pattern = op.Value.StringValue()
+		case bsontype.Regex:
+			pattern, options = op.Value.Regex()
+		default:
+			return false, fmt.Errorf("$regex needs a string or regex")
+		}
+
+		if opt, err := ops.LookupErr("$options"); err == nil {
+			options = stringValue(opt)
+		}
+		return matchRegex(values, bsonx.Regex(pattern, options))
+
+	case "$options":
+		// it's used with $regex
+		return true, nil
+
+	case "$not":
+		matched, err := matchValues(values, op.Value)
+		if err != nil {
+			return false, err
+		}
+		return !matched, nil
+
+	case "$all":
+		arr, ok := op.Value.ArrayOK()
+		if !ok {
+			return false, fmt.Errorf("$all needs an array")
+		}
+		if len(arr) == 0 {
+			return false, nil
+		}
+		for _, one := range arr {
+			if !matchEqual(values, one) {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case "$size":
+		size, ok := intValue(op.Value)
+		if !ok {
+			size = int64(floatValue(op.Value))
+		}
+		for _, val := range values {
+			if arr, ok := val.ArrayOK(); ok && int64(len(arr)) == size {
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case "$elemMatch":
+		cond, ok := op.Value.DocumentOK()
+		if !ok {
+			return false, fmt.Errorf("$elemMatch needs a document")
+		}
+
+		for _, val := range values {
+			arr, ok := val.ArrayOK()
+			if !ok {
+				continue
+			}
+
+			for _, elem := range arr {
+				var matched bool
+				var err error
+				if isOperatorDoc(op.Value) {
+					matched, err = matchValues([]bsonx.Val{elem}, op.Value)
+				} else if sub, ok := elem.DocumentOK(); ok {
+					matched, err = match(sub, cond)
+				}
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					return true, nil
+				}
+			}
+		}
+		return false, nil
+	}
+
+	return false, fmt.Errorf("unsupported query operator %s", op.Key)
+}
+
+// matchRegex check whether any of the string values matches the regex.
+func matchRegex(values []bsonx.Val, regex bsonx.Val) (bool, error) {
+	pattern, options := regex.Regex()
+	flags := ""
+	for _, opt := range options {
+		switch opt {
+		case 'i', 'm', 's':
+			flags += string(opt)
+		}
+	}
+	if len(flags) != 0 {
+		pattern = "(?" + flags + ")" + pattern
+	}
+
+	reg, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	for _, val := range expandArray(values) {
+		if val.Type() == bsontype.String && reg.MatchString(val.StringValue()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isTrue check whether the value is true like mongodb does, such as true, 1.
+func isTrue(val bsonx.Val) bool {
+	switch val.Type() {
+	case bsontype.Boolean:
+		return val.Boolean()
+	case bsontype.Null, bsontype.Undefined:
+		return false
+	}
+
+	if isNumber(val) {
+		return floatValue(val) != 0
+	}
+	return true
+}
@@ -0,0 +1,281 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// Find define a find operation
+type Find struct {
+	*Collection
+
+	projection map[string]int
+	filter     types.Filter
+	start      uint64
+	limit      uint64
+	sort       []sortField
+}
+
+type sortField struct {
+	key  string
+	desc bool
+}
+
+// Fields 查询字段
+func (f *Find) Fields(fields ...string) types.Find {
+	for _, field := range fields {
+		if len(field) <= 0 {
+			continue
+		}
+		f.projection[field] = 1
+	}
+	return f
+}
+
+// Sort 查询排序
+// sort支持多字段最左原则排序
+// sort值为"host_id, -host_name"和sort值为"host_id:1, host_name:-1"是一样的，都代表先按host_id递增排序，再按host_name递减排序
+func (f *Find) Sort(sort string) types.Find {
+	if sort == "" {
+		return f
+	}
+
+	f.sort = make([]sortField, 0)
+	for _, sortItem := range strings.Split(sort, ",") {
+		sortItemArr := strings.Split(strings.TrimSpace(sortItem), ":")
+		sortKey := strings.TrimLeft(sortItemArr[0], "+-")
+		if len(sortItemArr) == 2 {
+			f.sort = append(f.sort, sortField{key: sortKey, desc: strings.TrimSpace(sortItemArr[1]) == "-1"})
+		} else {
+			f.sort = append(f.sort, sortField{key: sortKey, desc: strings.HasPrefix(sortItemArr[0], "-")})
+		}
+	}
+	return f
+}
+
+// Start 查询上标
+func (f *Find) Start(start uint64) types.Find {
+	f.start = start
+	return f
+}
+
+// Limit 查询限制
+func (f *Find) Limit(limit uint64) types.Find {
+	f.limit = limit
+	return f
+}
+
+// find the matched documents with the sort, start and limit, and cut the fields with the projection.
+func (f *Find) find(limit uint64) ([]bsonx.Doc, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	matched, err := f.filterDocs(f.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	t := f.getTable(f.collName, false)
+	docs := make([]bsonx.Doc, 0, len(matched))
+	for _, idx := range matched {
+		docs = append(docs, t.docs[idx])
+	}
+
+	sortDocs(docs, f.sort)
+
+	if f.start >= uint64(len(docs)) {
+		return make([]bsonx.Doc, 0), nil
+	}
+	docs = docs[f.start:]
+
+	if limit != 0 && limit < uint64(len(docs)) {
+		docs = docs[:limit]
+	}
+
+	result := make([]bsonx.Doc, 0, len(docs))
+	for _, doc := range docs {
+		result = append(result, project(doc, f.projection))
+	}
+	return result, nil
+}
+
+// sortDocs sort the documents with the fields, a missing field is treated as null like mongodb does.
+func sortDocs(docs []bsonx.Doc, fields []sortField) {
+	if len(fields) == 0 {
+		return
+	}
+
+	sort.SliceStable(docs, func(i, j int) bool {
+		for _, field := range fields {
+			a, aExist := getPath(docs[i], field.key)
+			b, bExist := getPath(docs[j], field.key)
+			if !aExist {
+				a = bsonx.Null()
+			}
+			if !bExist {
+				b = bsonx.Null()
+			}
+
+			c := compareVal(a, b)
+			if c == 0 {
+				continue
+			}
+			if field.desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+}
+
+// project cut the document with the projection, the _id field is returned unless it's excluded.
+func project(doc bsonx.Doc, projection map[string]int) bsonx.Doc {
+	include := make([]string, 0)
+	for field, flag := range projection {
+		if field != "_id" && flag == 1 {
+			include = append(include, field)
+		}
+	}
+
+	excludeID := false
+	if flag, exist := projection["_id"]; exist && flag == 0 {
+		excludeID = true
+	}
+
+	if len(include) == 0 {
+		result := cloneDoc(doc)
+		if excludeID {
+			result = result.Delete("_id")
+		}
+		return result
+	}
+
+	// keep the fields' order same with the document.
+	sort.Slice(include, func(i, j int) bool {
+		return fieldOrder(doc, include[i]) < fieldOrder(doc, include[j])
+	})
+
+	result := bsonx.Doc{}
+	if !excludeID {
+		if id, err := doc.LookupErr("_id"); err == nil {
+			result = append(result, bsonx.Elem{Key: "_id", Value: id})
+		}
+	}
+
+	for _, field := range include {
+		val, exist := getPath(doc, field)
+		if !exist {
+			continue
+		}
+		result, _ = setPath(result, field, val)
+	}
+	return cloneDoc(result)
+}
+
+// fieldOrder returns the position of the field's top level key in the document.
+func fieldOrder(doc bsonx.Doc, field string) int {
+	key := strings.SplitN(field, ".", 2)[0]
+	for idx, elem := range doc {
+		if elem.Key == key {
+			return idx
+		}
+	}
+	return len(doc)
+}
+
+// All 查询多个
+func (f *Find) All(ctx context.Context, result interface{}) error {
+	docs, err := f.find(f.limit)
+	if err != nil {
+		return err
+	}
+	return decodeDocs(docs, result)
+}
+
+// One 查询一个
+func (f *Find) One(ctx context.Context, result interface{}) error {
+	docs, err := f.find(1)
+	if err != nil {
+		return err
+	}
+
+	if len(docs) == 0 {
+		return types.ErrDocumentNotFound
+	}
+	return decodeDoc(docs[0], result)
+}
+
+// Count 统计数量(非事务)
+func (f *Find) Count(ctx context.Context) (uint64, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	matched, err := f.filterDocs(f.filter)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(matched)), nil
+}
+
+// Iter 迭代查询，内存实现在创建迭代器时生成结果快照
+func (f *Find) Iter(ctx context.Context) types.Iterator {
+	docs, err := f.find(f.limit)
+	return &Iterator{docs: docs, pos: -1, err: err}
+}
+
+// Iterator is the iterator of a find operation on the snapshot of the matched documents
+type Iterator struct {
+	docs []bsonx.Doc
+	pos  int
+	err  error
+}
+
+// Next 移动到下一条数据
+func (i *Iterator) Next(ctx context.Context) bool {
+	if i.err != nil || i.pos+1 >= len(i.docs) {
+		return false
+	}
+	i.pos++
+	return true
+}
+
+// Decode 解析当前数据
+func (i *Iterator) Decode(result interface{}) error {
+	if i.err != nil {
+		return i.err
+	}
+	if i.pos < 0 || i.pos >= len(i.docs) {
+		return errors.New("iterator has no current document")
+	}
+	return decodeDoc(i.docs[i.pos], result)
+}
+
+// Err 返回迭代过程中的错误
+func (i *Iterator) Err() error {
+	return i.err
+}
+
+// Close 关闭迭代器
+func (i *Iterator) Close(ctx context.Context) error {
+	i.docs = nil
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package memory is an in-memory implementation of dal.DB, it's used by the unit tests which depend on
+// dal.DB, so that they can be run without a mongodb. all the data is lost when the process exits.
+package memory
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/storage/dal"
+	// decode the documents with the same bson registry as mongodb.
+	_ "configcenter/src/storage/dal/mongo/local"
+	"configcenter/src/storage/dal/redis"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// Memory implements dal.DB with the documents stored in memory. the transactions is not supported, the
+// operations in a transaction take effect immediately and can not be aborted.
+type Memory struct {
+	lock      sync.RWMutex
+	tables    map[string]*table
+	sequences map[string]uint64
+}
+
+var _ dal.DB = new(Memory)
+
+// NewMemory returns a new empty in-memory db.
+func NewMemory() *Memory {
+	return &Memory{
+		tables:    make(map[string]*table),
+		sequences: make(map[string]uint64),
+	}
+}
+
+// table is a collection's documents and indexes.
+type table struct {
+	docs    []bsonx.Doc
+	indexes []types.Index
+}
+
+// idIndex is the default index of every collection.
+var idIndex = types.Index{
+	Keys: map[string]int32{"_id": 1},
+	Name: "_id_",
+}
+
+func newTable() *table {
+	return &table{
+		docs:    make([]bsonx.Doc, 0),
+		indexes: []types.Index{idIndex},
+	}
+}
+
+// getTable get the collection's table, it's created when create is true and the table is not exist,
+// the caller must hold the lock.
+func (m *Memory) getTable(collName string, create bool) *table {
+	t, exist := m.tables[collName]
+	if !exist && create {
+		t = newTable()
+		m.tables[collName] = t
+	}
+	return t
+}
+
+// Table collection operation
+func (m *Memory) Table(collName string) types.Table {
+	return &Collection{
+		collName: collName,
+		Memory:   m,
+	}
+}
+
+// NextSequence 获取新序列号(非事务)
+func (m *Memory) NextSequence(ctx context.Context, sequenceName string) (uint64, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.sequences[sequenceName]++
+	return m.sequences[sequenceName], nil
+}
+
+// NextSequences 批量获取新序列号(非事务)
+func (m *Memory) NextSequences(ctx context.Context, sequenceName string, num int) ([]uint64, error) {
+	if num == 0 {
+		return make([]uint64, 0), nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	sequences := make([]uint64, num)
+	for i := 0; i < num; i++ {
+		m.sequences[sequenceName]++
+		sequences[i] = m.sequences[sequenceName]
+	}
+	return sequences, nil
+}
+
+// Ping is always success for the in-memory db.
+func (m *Memory) Ping() error {
+	return nil
+}
+
+// HasTable 判断是否存在集合
+func (m *Memory) HasTable(ctx context.Context, collName string) (bool, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	_, exist := m.tables[collName]
+	return exist, nil
+}
+
+// DropTable 移除集合
+func (m *Memory) DropTable(ctx context.Context, collName string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	delete(m.tables, collName)
+	return nil
+}
+
+// CreateTable 创建集合
+func (m *Memory) CreateTable(ctx context.Context, collName string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, exist := m.tables[collName]; exist {
+		return errors.New("collection already exists. NS: " + collName)
+	}
+	m.tables[collName] = newTable()
+	return nil
+}
+
+// IsDuplicatedError check duplicated error
+func (m *Memory) IsDuplicatedError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if strings.Contains(err.Error(), "E11000 duplicate") ||
+		strings.Contains(err.Error(), "There's already an index with name") {
+		return true
+	}
+	return err == types.ErrDuplicated
+}
+
+// IsNotFoundError check the not found error
+func (m *Memory) IsNotFoundError(err error) bool {
+	return err == types.ErrDocumentNotFound
+}
+
+// Close do nothing, the data is still available after close.
+func (m *Memory) Close() error {
+	return nil
+}
+
+// CommitTransaction do nothing, the operations in the transaction is already taken effect.
+func (m *Memory) CommitTransaction(ctx context.Context, cap *metadata.TxnCapable) error {
+	return nil
+}
+
+// AbortTransaction do nothing, the operations in the transaction can not be aborted.
+func (m *Memory) AbortTransaction(ctx context.Context, cap *metadata.TxnCapable) error {
+	return nil
+}
+
+// InitTxnManager do nothing, the transaction is not supported.
+func (m *Memory) InitTxnManager(r redis.Client) error {
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// cloneDoc deep copy a document, so that the stored documents are never changed by the callers.
+func cloneDoc(doc bsonx.Doc) bsonx.Doc {
+	raw, err := doc.MarshalBSON()
+	if err != nil {
+		return doc.Copy()
+	}
+	cloned, err := bsonx.ReadDoc(raw)
+	if err != nil {
+		return doc.Copy()
+	}
+	return cloned
+}
+
+// getPath get the value of the dotted path, the array element can be got with it's index.
+func getPath(doc bsonx.Doc, path string) (bsonx.Val, bool) {
+	val := bsonx.Document(doc)
+	for _, key := range strings.Split(path, ".") {
+		switch val.Type() {
+		case bsontype.EmbeddedDocument:
+			elem, err := val.Document().LookupElementErr(key)
+			if err != nil {
+				return bsonx.Val{}, false
+			}
+			val = elem.Value
+		case bsontype.Array:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(val.Array()) {
+				return bsonx.Val{}, false
+			}
+			val = val.Array()[idx]
+		default:
+			return bsonx.Val{}, false
+		}
+	}
+	return val, true
+}
+
+// setPath set the value of the dotted path, the embedded documents in the path is created if not exist.
+func setPath(doc bsonx.Doc, path string, value bsonx.Val) (bsonx.Doc, error) {
+	keys := strings.Split(path, ".")
+	val, err := setVal(bsonx.Document(doc), keys, value)
+	if err != nil {
+		return nil, err
+	}
+	return val.Document(), nil
+}
+
+func setVal(val bsonx.Val, keys []string, value bsonx.Val) (bsonx.Val, error) {
+	if len(keys) == 0 {
+		return value, nil
+	}
+
+	switch val.Type() {
+	case bsontype.EmbeddedDocument:
+		doc := val.Document()
+		child, err := doc.LookupElementErr(keys[0])
+		if err != nil {
+			child = bsonx.Elem{Key: keys[0], Value: bsonx.Document(bsonx.Doc{})}
+		}
+		newVal, err := setVal(child.Value, keys[1:], value)
+		if err != nil {
+			return bsonx.Val{}, err
+		}
+		return bsonx.Document(doc.Set(keys[0], newVal)), nil
+
+	case bsontype.Array:
+		arr := val.Array()
+		idx, err := strconv.Atoi(keys[0])
+		if err != nil || idx < 0 {
+			return bsonx.Val{}, fmt.Errorf("can not create field %s in an array", keys[0])
+		}
+		for len(arr) <= idx {
+			arr = append(arr, bsonx.Null())
+		}
+		child := arr[idx]
+		if child.Type() == bsontype.Null && len(keys) > 1 {
+			child = bsonx.Document(bsonx.Doc{})
+		}
+		newVal, err := setVal(child, keys[1:], value)
+		if err != nil {
+			return bsonx.Val{}, err
+		}
+		arr[idx] = newVal
+		return bsonx.Array(arr), nil
+	}
+
+	return bsonx.Val{}, fmt.Errorf("can not create field %s in a %s value", keys[0], val.Type())
+}
+
+// unsetPath remove the field of the dotted path.
+func unsetPath(doc bsonx.Doc, path string) bsonx.Doc {
+	keys := strings.Split(path, ".")
+	if len(keys) == 1 {
+		return doc.Delete(keys[0])
+	}
+
+	child, err := doc.LookupElementErr(keys[0])
+	if err != nil {
+		return doc
+	}
+
+	sub, ok := child.Value.DocumentOK()
+	if !ok {
+		return doc
+	}
+	return doc.Set(keys[0], bsonx.Document(unsetPath(sub, strings.Join(keys[1:], "."))))
+}
+
+// applyUpdate apply the update operators to the document, $setOnInsert only works when isInsert is true.
+func applyUpdate(doc bsonx.Doc, update bsonx.Doc, isInsert bool) (bsonx.Doc, error) {
+	if err := checkUpdateConflict(update); err != nil {
+		return nil, err
+	}
+
+	doc = cloneDoc(doc)
+	var err error
+	for _, op := range update {
+		fields, ok := op.Value.DocumentOK()
+		if !ok {
+			return nil, fmt.Errorf("update operator %s needs a document", op.Key)
+		}
+
+		for _, field := range fields {
+			if field.Key == "_id" && op.Key != "$setOnInsert" {
+				if old, exist := getPath(doc, "_id"); exist && !equalVal(old, field.Value) {
+					return nil, fmt.Errorf("the (immutable) field '_id' can not be altered")
+				}
+			}
+
+			doc, err = applyOperator(doc, op.Key, field, isInsert)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return doc, nil
+}
+
+// checkUpdateConflict check whether a path is updated by multiple operators, which is not allowed by mongodb.
+func checkUpdateConflict(update bsonx.Doc) error {
+	paths := make([]string, 0)
+	for _, op := range update {
+		fields, ok := op.Value.DocumentOK()
+		if !ok {
+			continue
+		}
+
+		for _, field := range fields {
+			updated := []string{field.Key}
+			if op.Key == "$rename" {
+				updated = append(updated, stringValue(field.Value))
+			}
+
+			for _, path := range updated {
+				for _, exist := range paths {
+					if path == exist || strings.HasPrefix(path, exist+".") || strings.HasPrefix(exist, path+".") {
+						return fmt.Errorf("Updating the path '%s' would create a conflict at '%s'", path, exist)
+					}
+				}
+				paths = append(paths, path)
+			}
+		}
+	}
+	return nil
+}
+
+func applyOperator(doc bsonx.Doc, op string, field bsonx.Elem, isInsert bool) (bsonx.Doc, error) {
+	switch op {
+	case "$set":
+		return setPath(doc, field.Key, field.Value)
+
+	case "$setOnInsert":
+		if !isInsert {
+			return doc, nil
+		}
+		return setPath(doc, field.Key, field.Value)
+
+	case "$unset":
+		return unsetPath(doc, field.Key), nil
+
+	case "$inc":
+		old, exist := getPath(doc, field.Key)
+		if !exist {
+			return setPath(doc, field.Key, field.Value)
+		}
+		sum, err := addNumber(old, field.Value)
+		if err != nil {
+			return nil, err
+		}
+		return setPath(doc, field.Key, sum)
+
+	case "$rename":
+		old, exist := getPath(doc, field.Key)
+		if !exist {
+			return doc, nil
+		}
+		doc = unsetPath(doc, field.Key)
+		return setPath(doc, stringValue(field.Value), old)
+
+	case "$push", "$addToSet":
+		arr := bsonx.Arr{}
+		if old, exist := getPath(doc, field.Key); exist {
+			oldArr, ok := old.ArrayOK()
+			if !ok {
+				return nil, fmt.Errorf("the field %s must be an array but is of type %s", field.Key, old.Type())
+			}
+			arr = append(arr, oldArr...)
+		}
+
+		values := bsonx.Arr{field.Value}
+		if sub, ok := field.Value.DocumentOK(); ok {
+			if each, err := sub.LookupErr("$each"); err == nil {
+				values = each.Array()
+			}
+		}
+
+		for _, value := range values {
+			if op == "$addToSet" && containsVal(arr, value) {
+				continue
+			}
+			arr = append(arr, value)
+		}
+		return setPath(doc, field.Key, bsonx.Array(arr))
+
+	case "$pull":
+		old, exist := getPath(doc, field.Key)
+		if !exist {
+			return doc, nil
+		}
+		oldArr, ok := old.ArrayOK()
+		if !ok {
+			return nil, fmt.Errorf("cannot apply $pull to a non-array value")
+		}
+
+		arr := bsonx.Arr{}
+		for _, elem := range oldArr {
+			pulled, err := matchPull(elem, field.Value)
+			if err != nil {
+				return nil, err
+			}
+			if !pulled {
+				arr = append(arr, elem)
+			}
+		}
+		return setPath(doc, field.Key, bsonx.Array(arr))
+	}
+
+	return nil, fmt.Errorf("unsupported update operator %s", op)
+}
+
+// matchPull check whether the array element matches the $pull condition.
+func matchPull(elem bsonx.Val, cond bsonx.Val) (bool, error) {
+	if isOperatorDoc(cond) {
+		return matchValues([]bsonx.Val{elem}, cond)
+	}
+
+	condDoc, isDoc := cond.DocumentOK()
+	elemDoc, elemIsDoc := elem.DocumentOK()
+	if isDoc && elemIsDoc {
+		return match(elemDoc, condDoc)
+	}
+	return equalVal(elem, cond), nil
+}
@@ -0,0 +1,375 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package memory
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// toDoc convert a document, such as a struct, a map or a bson.D to a bsonx.Doc, which is how the documents,
+// filters and update documents are handled in memory, so that they are handled with the same types as mongodb.
+func toDoc(doc interface{}) (bsonx.Doc, error) {
+	if doc == nil {
+		return bsonx.Doc{}, nil
+	}
+
+	switch d := doc.(type) {
+	case bsonx.Doc:
+		return d.Copy(), nil
+	case []byte:
+		return bsonx.ReadDoc(d)
+	case bson.Raw:
+		return bsonx.ReadDoc(d)
+	}
+
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return bsonx.ReadDoc(raw)
+}
+
+// toDocs convert a single document or a slice of documents to bsonx.Doc list.
+func toDocs(docs interface{}) ([]bsonx.Doc, error) {
+	value := reflect.ValueOf(docs)
+	for value.Kind() == reflect.Ptr {
+		value = value.Elem()
+	}
+
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		doc, err := toDoc(docs)
+		if err != nil {
+			return nil, err
+		}
+		return []bsonx.Doc{doc}, nil
+	}
+
+	// bson.D is a slice, but it's a single document.
+	if _, ok := value.Interface().(bson.D); ok {
+		doc, err := toDoc(docs)
+		if err != nil {
+			return nil, err
+		}
+		return []bsonx.Doc{doc}, nil
+	}
+
+	result := make([]bsonx.Doc, 0, value.Len())
+	for i := 0; i < value.Len(); i++ {
+		doc, err := toDoc(value.Index(i).Interface())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, doc)
+	}
+	return result, nil
+}
+
+// toVal convert a go value to a bsonx.Val.
+func toVal(value interface{}) (bsonx.Val, error) {
+	doc, err := toDoc(bson.M{"v": value})
+	if err != nil {
+		return bsonx.Val{}, err
+	}
+	return doc.Lookup("v"), nil
+}
+
+// decodeDoc decode the document to the result, the result is decoded with the bson default registry,
+// which is same with mongodb.
+func decodeDoc(doc bsonx.Doc, result interface{}) error {
+	raw, err := doc.MarshalBSON()
+	if err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, result)
+}
+
+// decodeDocs decode the documents to the result, which must be a slice address.
+func decodeDocs(docs []bsonx.Doc, result interface{}) error {
+	resultv := reflect.ValueOf(result)
+	if resultv.Kind() != reflect.Ptr || resultv.Elem().Kind() != reflect.Slice {
+		return errors.New("result argument must be a slice address")
+	}
+
+	elemt := resultv.Elem().Type().Elem()
+	slice := reflect.MakeSlice(resultv.Elem().Type(), 0, len(docs))
+	for _, doc := range docs {
+		elemp := reflect.New(elemt)
+		if err := decodeDoc(doc, elemp.Interface()); err != nil {
+			return err
+		}
+		slice = reflect.Append(slice, elemp.Elem())
+	}
+
+	resultv.Elem().Set(slice)
+	return nil
+}
+
+// decodeVal decode the value to a go value with the bson default registry.
+func decodeVal(val bsonx.Val) (interface{}, error) {
+	result := struct {
+		V interface{} `bson:"v"`
+	}{}
+	if err := decodeDoc(bsonx.Doc{{Key: "v", Value: val}}, &result); err != nil {
+		return nil, err
+	}
+	return result.V, nil
+}
+
+// lookup find the values of the dotted path in the document, the arrays in the path is traversed
+// like mongodb does, so a path may have multiple values.
+func lookup(doc bsonx.Doc, path string) []bsonx.Val {
+	return lookupVal(bsonx.Document(doc), strings.Split(path, "."))
+}
+
+func lookupVal(val bsonx.Val, keys []string) []bsonx.Val {
+	if len(keys) == 0 {
+		return []bsonx.Val{val}
+	}
+
+	switch val.Type() {
+	case bsontype.EmbeddedDocument:
+		elem, err := val.Document().LookupElementErr(keys[0])
+		if err != nil {
+			return nil
+		}
+		return lookupVal(elem.Value, keys[1:])
+
+	case bsontype.Array:
+		arr := val.Array()
+		// the key is the index of the array
+		if idx, err := strconv.Atoi(keys[0]); err == nil {
+			if idx < 0 || idx >= len(arr) {
+				return nil
+			}
+			return lookupVal(arr[idx], keys[1:])
+		}
+
+		result := make([]bsonx.Val, 0)
+		for _, elem := range arr {
+			if elem.Type() == bsontype.EmbeddedDocument {
+				result = append(result, lookupVal(elem, keys)...)
+			}
+		}
+		return result
+	}
+
+	return nil
+}
+
+// typeOrder is the compare order of the bson types, which is same with mongodb.
+func typeOrder(t bsontype.Type) int {
+	switch t {
+	case bsontype.MinKey:
+		return 1
+	case bsontype.Null, bsontype.Undefined:
+		return 2
+	case bsontype.Double, bsontype.Int32, bsontype.Int64, bsontype.Decimal128:
+		return 3
+	case bsontype.String, bsontype.Symbol:
+		return 4
+	case bsontype.EmbeddedDocument:
+		return 5
+	case bsontype.Array:
+		return 6
+	case bsontype.Binary:
+		return 7
+	case bsontype.ObjectID:
+		return 8
+	case bsontype.Boolean:
+		return 9
+	case bsontype.DateTime:
+		return 10
+	case bsontype.Timestamp:
+		return 11
+	case bsontype.Regex:
+		return 12
+	case bsontype.MaxKey:
+		return 14
+	default:
+		return 13
+	}
+}
+
+// compareVal compare two values with mongodb's comparison order, returns -1, 0 or 1.
+func compareVal(a, b bsonx.Val) int {
+	ao, bo := typeOrder(a.Type()), typeOrder(b.Type())
+	if ao != bo {
+		return compareInt(int64(ao), int64(bo))
+	}
+
+	switch ao {
+	case 3:
+		ai, aIsInt := intValue(a)
+		bi, bIsInt := intValue(b)
+		if aIsInt && bIsInt {
+			return compareInt(ai, bi)
+		}
+		af, bf := floatValue(a), floatValue(b)
+		switch {
+		case af < bf:
+			return -1
+		case af > bf:
+			return 1
+		}
+		return 0
+	case 4:
+		return strings.Compare(stringValue(a), stringValue(b))
+	case 5:
+		ad, bd := a.Document(), b.Document()
+		for i := 0; i < len(ad) && i < len(bd); i++ {
+			if c := strings.Compare(ad[i].Key, bd[i].Key); c != 0 {
+				return c
+			}
+			if c := compareVal(ad[i].Value, bd[i].Value); c != 0 {
+				return c
+			}
+		}
+		return compareInt(int64(len(ad)), int64(len(bd)))
+	case 6:
+		aa, ba := a.Array(), b.Array()
+		for i := 0; i < len(aa) && i < len(ba); i++ {
+			if c := compareVal(aa[i], ba[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInt(int64(len(aa)), int64(len(ba)))
+	case 7:
+		_, ab := a.Binary()
+		_, bb := b.Binary()
+		return bytes.Compare(ab, bb)
+	case 8:
+		ao, bo := a.ObjectID(), b.ObjectID()
+		return bytes.Compare(ao[:], bo[:])
+	case 9:
+		ab, bb := a.Boolean(), b.Boolean()
+		if ab == bb {
+			return 0
+		}
+		if !ab {
+			return -1
+		}
+		return 1
+	case 10:
+		return compareInt(a.DateTime(), b.DateTime())
+	case 11:
+		at, ai := a.Timestamp()
+		bt, bi := b.Timestamp()
+		if at != bt {
+			return compareInt(int64(at), int64(bt))
+		}
+		return compareInt(int64(ai), int64(bi))
+	case 12:
+		ap, aOpt := a.Regex()
+		bp, bOpt := b.Regex()
+		if c := strings.Compare(ap, bp); c != 0 {
+			return c
+		}
+		return strings.Compare(aOpt, bOpt)
+	}
+
+	return 0
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// equalVal check whether two values are equal, numbers with different types are equal when the
+// values are equal, like mongodb does.
+func equalVal(a, b bsonx.Val) bool {
+	return compareVal(a, b) == 0
+}
+
+// containsVal check whether the value is one of the values.
+func containsVal(values []bsonx.Val, val bsonx.Val) bool {
+	for _, one := range values {
+		if equalVal(one, val) {
+			return true
+		}
+	}
+	return false
+}
+
+func intValue(v bsonx.Val) (int64, bool) {
+	switch v.Type() {
+	case bsontype.Int32:
+		return int64(v.Int32()), true
+	case bsontype.Int64:
+		return v.Int64(), true
+	}
+	return 0, false
+}
+
+func floatValue(v bsonx.Val) float64 {
+	switch v.Type() {
+	case bsontype.Int32:
+		return float64(v.Int32())
+	case bsontype.Int64:
+		return float64(v.Int64())
+	case bsontype.Double:
+		return v.Double()
+	case bsontype.Decimal128:
+		f, _ := strconv.ParseFloat(v.Decimal128().String(), 64)
+		return f
+	}
+	return 0
+}
+
+func stringValue(v bsonx.Val) string {
+	if v.Type() == bsontype.Symbol {
+		return v.Symbol()
+	}
+	return v.StringValue()
+}
+
+// isNumber check whether the value is a number.
+func isNumber(v bsonx.Val) bool {
+	return typeOrder(v.Type()) == 3
+}
+
+// addNumber add two numbers, the result type is the wider one of the two.
+func addNumber(a, b bsonx.Val) (bsonx.Val, error) {
+	if !isNumber(a) || !isNumber(b) {
+		return bsonx.Val{}, fmt.Errorf("can not add non-numeric type %s and %s", a.Type(), b.Type())
+	}
+
+	if a.Type() == bsontype.Double || b.Type() == bsontype.Double || a.Type() == bsontype.Decimal128 ||
+		b.Type() == bsontype.Decimal128 {
+		return bsonx.Double(floatValue(a) + floatValue(b)), nil
+	}
+
+	ai, _ := intValue(a)
+	bi, _ := intValue(b)
+	if a.Type() == bsontype.Int32 && b.Type() == bsontype.Int32 {
+		sum := ai + bi
+		if sum <= int64(^uint32(0)>>1) && sum >= -int64(^uint32(0)>>1)-1 {
+			return bsonx.Int32(int32(sum)), nil
+		}
+	}
+	return bsonx.Int64(ai + bi), nil
+}
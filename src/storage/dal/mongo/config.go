@@ -50,6 +50,10 @@ type Config struct {
 	MaxIdleConns uint64
 	RsName       string
 	SocketTimeout   int
+	// SlowQueryThresholdMs the operations cost more than it are recorded as slow queries, 0 means not record
+	SlowQueryThresholdMs int64
+	// SlowQueryLogSizeMB the size of the capped slow query log table
+	SlowQueryLogSizeMB int64
 }
 
 // BuildURI return mongo uri according to  https://docs.mongodb.com/manual/reference/connection-string/
@@ -76,6 +80,8 @@ func (c Config) GetMongoConf() local.MongoConf {
 		URI:          c.BuildURI(),
 		RsName:       c.RsName,
 		SocketTimeout:  c.SocketTimeout,
+		SlowQueryThresholdMs: c.SlowQueryThresholdMs,
+		SlowQueryLogSizeMB:   c.SlowQueryLogSizeMB,
 	}
 }
 
@@ -86,6 +92,8 @@ func (c Config) GetMongoClient() (db dal.RDB, err error) {
 		URI:          c.BuildURI(),
 		RsName:       c.RsName,
 		SocketTimeout: c.SocketTimeout,
+		SlowQueryThresholdMs: c.SlowQueryThresholdMs,
+		SlowQueryLogSizeMB:   c.SlowQueryLogSizeMB,
 	}
 	db, err = local.NewMgo(mongoConf, time.Minute)
 	if err != nil {
@@ -0,0 +1,131 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"errors"
+
+	"configcenter/src/common"
+	"configcenter/src/storage/dal/types"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Iter 迭代查询，通过游标逐条获取数据，不会一次性将全部数据加载到内存
+func (f *Find) Iter(ctx context.Context) types.Iterator {
+	mtc.collectOperCount(f.collName, findOper)
+
+	iter := &Iterator{
+		collName:   f.collName,
+		projection: f.projection,
+		rid:        ctx.Value(common.ContextRequestIDField),
+	}
+
+	findOpts := &options.FindOptions{}
+	if len(f.projection) != 0 {
+		findOpts.Projection = f.projection
+	}
+	if f.start != 0 {
+		findOpts.SetSkip(f.start)
+	}
+	if f.limit != 0 {
+		findOpts.SetLimit(f.limit)
+	}
+	if len(f.sort) != 0 {
+		findOpts.SetSort(f.sort)
+	}
+	// 查询条件为空时候，mongodb 不返回数据
+	if f.filter == nil {
+		f.filter = bson.M{}
+	}
+
+	opt := f.collectionOption(ctx)
+
+	// the cursor keeps the session of the transaction if there is one, so that the
+	// subsequent get more operations are still in the same transaction.
+	sessCtx, _, useTxn, err := f.tm.GetTxnContext(ctx, f.dbc)
+	if err != nil {
+		iter.err = err
+		return iter
+	}
+	if useTxn {
+		ctx = sessCtx
+	}
+
+	cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
+	if err != nil {
+		mtc.collectErrorCount(f.collName, findOper)
+		iter.err = err
+		return iter
+	}
+	iter.cursor = cursor
+	return iter
+}
+
+// Iterator is the iterator of a find operation based on the mongodb cursor
+type Iterator struct {
+	collName   string
+	projection map[string]int
+	rid        interface{}
+	cursor     *mongo.Cursor
+	err        error
+}
+
+// Next 移动到下一条数据
+func (i *Iterator) Next(ctx context.Context) bool {
+	if i.err != nil || i.cursor == nil {
+		return false
+	}
+
+	if i.cursor.Next(ctx) {
+		return true
+	}
+
+	if err := i.cursor.Err(); err != nil {
+		mtc.collectErrorCount(i.collName, findOper)
+		i.err = err
+	}
+	return false
+}
+
+// Decode 解析当前数据
+func (i *Iterator) Decode(result interface{}) error {
+	if i.err != nil {
+		return i.err
+	}
+
+	if i.cursor == nil {
+		return errors.New("iterator is not ready")
+	}
+
+	if err := validHostType(i.collName, i.projection, result, i.rid); err != nil {
+		return err
+	}
+	return i.cursor.Decode(result)
+}
+
+// Err 返回迭代过程中的错误
+func (i *Iterator) Err() error {
+	return i.err
+}
+
+// Close 关闭迭代器
+func (i *Iterator) Close(ctx context.Context) error {
+	if i.cursor == nil {
+		return nil
+	}
+	return i.cursor.Close(ctx)
+}
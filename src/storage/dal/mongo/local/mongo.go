@@ -39,10 +39,11 @@ import (
 )
 
 type Mongo struct {
-	dbc    *mongo.Client
-	dbname string
-	sess   mongo.Session
-	tm     *TxnManager
+	dbc     *mongo.Client
+	dbname  string
+	sess    mongo.Session
+	tm      *TxnManager
+	slowLog *slowQueryLogger
 }
 
 var _ dal.DB = new(Mongo)
@@ -54,6 +55,10 @@ type MongoConf struct {
 	URI            string
 	RsName         string
 	SocketTimeout  int
+	// SlowQueryThresholdMs the operations cost more than it are recorded as slow queries, 0 means not record
+	SlowQueryThresholdMs int64
+	// SlowQueryLogSizeMB the size of the capped slow query log table
+	SlowQueryLogSizeMB int64
 }
 
 // NewMgo returns new RDB
@@ -96,9 +101,10 @@ func NewMgo(config MongoConf, timeout time.Duration) (*Mongo, error) {
 	initMongoMetric()
 
 	return &Mongo{
-		dbc:    client,
-		dbname: connStr.Database,
-		tm:     &TxnManager{},
+		dbc:     client,
+		dbname:  connStr.Database,
+		tm:      &TxnManager{},
+		slowLog: newSlowQueryLogger(client, connStr.Database, config.SlowQueryThresholdMs, config.SlowQueryLogSizeMB),
 	}, nil
 }
 
@@ -223,6 +229,7 @@ func (c *Collection) Find(filter types.Filter, opts ...types.FindOpts) types.Fin
 		return find
 	}
 
+	find.readPreference = opts[0].ReadPreference
 	if !opts[0].WithObjectID {
 		find.projection["_id"] = 0
 		return find
@@ -239,6 +246,8 @@ type Find struct {
 	start      int64
 	limit      int64
 	sort       bson.D
+	// readPreference the read preference set by the find option, it takes precedence over the context.
+	readPreference common.ReadPreferenceMode
 }
 
 // Fields 查询字段
@@ -257,29 +266,38 @@ func (f *Find) Fields(fields ...string) types.Find {
 // sort值为"host_id, -host_name"和sort值为"host_id:1, host_name:-1"是一样的，都代表先按host_id递增排序，再按host_name递减排序
 func (f *Find) Sort(sort string) types.Find {
 	if sort != "" {
-		sortArr := strings.Split(sort, ",")
-		f.sort = bson.D{}
-		for _, sortItem := range sortArr {
-			sortItemArr := strings.Split(strings.TrimSpace(sortItem), ":")
-			sortKey := strings.TrimLeft(sortItemArr[0], "+-")
-			if len(sortItemArr) == 2 {
-				sortDescFlag := strings.TrimSpace(sortItemArr[1])
-				if sortDescFlag == "-1" {
-					f.sort = append(f.sort, bson.E{sortKey, -1})
-				} else {
-					f.sort = append(f.sort, bson.E{sortKey, 1})
-				}
+		f.sort = parseSort(sort)
+	}
+
+	return f
+}
+
+func parseSort(sort string) bson.D {
+	result := bson.D{}
+	if sort == "" {
+		return result
+	}
+
+	sortArr := strings.Split(sort, ",")
+	for _, sortItem := range sortArr {
+		sortItemArr := strings.Split(strings.TrimSpace(sortItem), ":")
+		sortKey := strings.TrimLeft(sortItemArr[0], "+-")
+		if len(sortItemArr) == 2 {
+			sortDescFlag := strings.TrimSpace(sortItemArr[1])
+			if sortDescFlag == "-1" {
+				result = append(result, bson.E{sortKey, -1})
+			} else {
+				result = append(result, bson.E{sortKey, 1})
+			}
+		} else {
+			if strings.HasPrefix(sortItemArr[0], "-") {
+				result = append(result, bson.E{sortKey, -1})
 			} else {
-				if strings.HasPrefix(sortItemArr[0], "-") {
-					f.sort = append(f.sort, bson.E{sortKey, -1})
-				} else {
-					f.sort = append(f.sort, bson.E{sortKey, 1})
-				}
+				result = append(result, bson.E{sortKey, 1})
 			}
 		}
 	}
-
-	return f
+	return result
 }
 
 // Start 查询上标
@@ -312,7 +330,9 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 	rid := ctx.Value(common.ContextRequestIDField)
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(f.collName, findOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(f.collName, findOper, cost)
+		f.slowLog.record(ctx, f.collName, findOper, f.filter, f.sort, f.limit, cost)
 	}()
 
 	err := validHostType(f.collName, f.projection, result, rid)
@@ -338,7 +358,7 @@ func (f *Find) All(ctx context.Context, result interface{}) error {
 		f.filter = bson.M{}
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.collectionOption(ctx)
 
 	return f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
 		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
@@ -357,7 +377,9 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 	start := time.Now()
 	rid := ctx.Value(common.ContextRequestIDField)
 	defer func() {
-		mtc.collectOperDuration(f.collName, findOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(f.collName, findOper, cost)
+		f.slowLog.record(ctx, f.collName, findOper, f.filter, f.sort, f.limit, cost)
 	}()
 
 	err := validHostType(f.collName, f.projection, result, rid)
@@ -383,7 +405,7 @@ func (f *Find) One(ctx context.Context, result interface{}) error {
 		f.filter = bson.M{}
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.collectionOption(ctx)
 	return f.tm.AutoRunWithTxn(ctx, f.dbc, func(ctx context.Context) error {
 		cursor, err := f.dbc.Database(f.dbname).Collection(f.collName, opt).Find(ctx, f.filter, findOpts)
 		if err != nil {
@@ -406,14 +428,16 @@ func (f *Find) Count(ctx context.Context) (uint64, error) {
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(f.collName, countOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(f.collName, countOper, cost)
+		f.slowLog.record(ctx, f.collName, countOper, f.filter, nil, 0, cost)
 	}()
 
 	if f.filter == nil {
 		f.filter = bson.M{}
 	}
 
-	opt := getCollectionOption(ctx)
+	opt := f.collectionOption(ctx)
 
 	sessCtx, _, useTxn, err := f.tm.GetTxnContext(ctx, f.dbc)
 	if err != nil {
@@ -470,7 +494,9 @@ func (c *Collection) Update(ctx context.Context, filter types.Filter, doc interf
 	mtc.collectOperCount(c.collName, updateOper)
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, updateOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, updateOper, cost)
+		c.slowLog.record(ctx, c.collName, updateOper, filter, nil, 0, cost)
 	}()
 
 	if filter == nil {
@@ -495,7 +521,9 @@ func (c *Collection) Upsert(ctx context.Context, filter types.Filter, doc interf
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, upsertOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, upsertOper, cost)
+		c.slowLog.record(ctx, c.collName, upsertOper, filter, nil, 0, cost)
 	}()
 
 	// set upsert option
@@ -517,30 +545,42 @@ func (c *Collection) Upsert(ctx context.Context, filter types.Filter, doc interf
 
 // UpdateMultiModel 根据不同的操作符去更新数据
 func (c *Collection) UpdateMultiModel(ctx context.Context, filter types.Filter, updateModel ...types.ModeUpdate) error {
+	_, err := c.UpdateMultiModelCount(ctx, filter, updateModel...)
+	return err
+}
+
+// UpdateMultiModelCount 根据不同的操作符去更新数据，并返回匹配的数据条数
+func (c *Collection) UpdateMultiModelCount(ctx context.Context, filter types.Filter,
+	updateModel ...types.ModeUpdate) (uint64, error) {
+
 	mtc.collectOperCount(c.collName, updateOper)
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, updateOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, updateOper, cost)
+		c.slowLog.record(ctx, c.collName, updateOper, filter, nil, 0, cost)
 	}()
 
 	data := bson.M{}
 	for _, item := range updateModel {
 		if _, ok := data[item.Op]; ok {
-			return errors.New(item.Op + " appear multiple times")
+			return 0, errors.New(item.Op + " appear multiple times")
 		}
 		data["$"+item.Op] = item.Doc
 	}
 
-	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
-		_, err := c.dbc.Database(c.dbname).Collection(c.collName).UpdateMany(ctx, filter, data)
+	var matched uint64
+	err := c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
+		result, err := c.dbc.Database(c.dbname).Collection(c.collName).UpdateMany(ctx, filter, data)
 		if err != nil {
 			mtc.collectErrorCount(c.collName, updateOper)
 			return err
 		}
+		matched = uint64(result.MatchedCount)
 		return nil
 	})
-
+	return matched, err
 }
 
 // Delete 删除数据
@@ -549,7 +589,9 @@ func (c *Collection) Delete(ctx context.Context, filter types.Filter) error {
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, deleteOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, deleteOper, cost)
+		c.slowLog.record(ctx, c.collName, deleteOper, filter, nil, 0, cost)
 	}()
 
 	return c.tm.AutoRunWithTxn(ctx, c.dbc, func(ctx context.Context) error {
@@ -770,9 +812,14 @@ func (c *Collection) Indexes(ctx context.Context) ([]types.Index, error) {
 	defer cursor.Close(ctx)
 	var indexs []types.Index
 	for cursor.Next(ctx) {
-		idxResult := types.Index{}
+		// the ttl option is returned as expireAfterSeconds by db, which differs from the index's bson tag
+		idxResult := struct {
+			types.Index        `bson:",inline"`
+			ExpireAfterSeconds int32 `bson:"expireAfterSeconds"`
+		}{}
 		cursor.Decode(&idxResult)
-		indexs = append(indexs, idxResult)
+		idxResult.Index.ExpireAfterSeconds = idxResult.ExpireAfterSeconds
+		indexs = append(indexs, idxResult.Index)
 	}
 
 	return indexs, nil
@@ -899,7 +946,9 @@ func (c *Collection) AggregateAll(ctx context.Context, pipeline interface{}, res
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, aggregateOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, aggregateOper, cost)
+		c.slowLog.record(ctx, c.collName, aggregateOper, pipeline, nil, 0, cost)
 	}()
 
 	opt := getCollectionOption(ctx)
@@ -922,7 +971,9 @@ func (c *Collection) AggregateOne(ctx context.Context, pipeline interface{}, res
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, aggregateOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, aggregateOper, cost)
+		c.slowLog.record(ctx, c.collName, aggregateOper, pipeline, nil, 0, cost)
 	}()
 
 	opt := getCollectionOption(ctx)
@@ -951,7 +1002,9 @@ func (c *Collection) Distinct(ctx context.Context, field string, filter types.Fi
 
 	start := time.Now()
 	defer func() {
-		mtc.collectOperDuration(c.collName, distinctOper, time.Since(start))
+		cost := time.Since(start)
+		mtc.collectOperDuration(c.collName, distinctOper, cost)
+		c.slowLog.record(ctx, c.collName, distinctOper, filter, nil, 0, cost)
 	}()
 
 	if filter == nil {
@@ -1104,7 +1157,24 @@ const (
 	maxStalenessSeconds = 90 * time.Second
 )
 
+// collectionOption returns the collection option of the find, the read preference set by the find option
+// takes precedence over the one in the context.
+func (f *Find) collectionOption(ctx context.Context) *options.CollectionOptions {
+	if f.readPreference != common.NilMode {
+		ctx = util.SetDBReadPreference(ctx, f.readPreference)
+	}
+	return getCollectionOption(ctx)
+}
+
 func getCollectionOption(ctx context.Context) *options.CollectionOptions {
+	// reads in a transaction or following a write of the same request must see the written data, which may
+	// not be replicated to the secondaries yet, so they are always sent to the primary.
+	if isPrimaryRequired(ctx) {
+		return &options.CollectionOptions{
+			ReadPreference: readpref.Primary(),
+		}
+	}
+
 	var opt *options.CollectionOptions
 	switch util.GetDBReadPreference(ctx) {
 
@@ -1134,3 +1204,11 @@ func getCollectionOption(ctx context.Context) *options.CollectionOptions {
 
 	return opt
 }
+
+// isPrimaryRequired check if the reads of the context must be sent to the primary to read its own writes.
+func isPrimaryRequired(ctx context.Context) bool {
+	if txnID, ok := ctx.Value(common.TransactionIdHeader).(string); ok && len(txnID) != 0 {
+		return true
+	}
+	return util.IsReadAfterWrite(ctx)
+}
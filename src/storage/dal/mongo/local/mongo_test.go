@@ -23,6 +23,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/dbtest"
 	"configcenter/src/storage/dal/types"
 
 	"github.com/stretchr/testify/require"
@@ -1284,6 +1285,11 @@ func TestNextSequence(t *testing.T) {
 	require.Equal(t, uint64(2), id)
 }
 
+// TestConformance run the dal.DB conformance tests, which is also run with the in-memory db.
+func TestConformance(t *testing.T) {
+	dbtest.RunConformance(t, dbClient(t))
+}
+
 func TestDropDcocsColumn(t *testing.T) {
 	ctx := context.Background()
 	tableName := "tmptest_drop_doc_column"
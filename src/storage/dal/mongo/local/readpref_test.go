@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/util"
+
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestCollectionOption(t *testing.T) {
+	secondary := util.SetDBReadPreference(context.Background(), common.SecondaryPreferredMode)
+	txn := context.WithValue(secondary, common.TransactionIdHeader, "txn-id")
+	afterWrite, _ := util.SetReadAfterWrite(secondary, make(map[string][]string))
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		findPref common.ReadPreferenceMode
+		mode     readpref.Mode
+	}{
+		{name: "not set", ctx: context.Background(), mode: 0},
+		{name: "context", ctx: secondary, mode: readpref.SecondaryPreferredMode},
+		{name: "find option", ctx: secondary, findPref: common.NearestMode, mode: readpref.NearestMode},
+		{name: "transaction", ctx: txn, findPref: common.SecondaryMode, mode: readpref.PrimaryMode},
+		{name: "read after write", ctx: afterWrite, mode: readpref.PrimaryMode},
+	}
+
+	for _, test := range tests {
+		f := &Find{readPreference: test.findPref}
+		opt := f.collectionOption(test.ctx)
+		if test.mode == 0 {
+			if opt != nil {
+				t.Errorf("%s: expect no collection option, got %v", test.name, opt.ReadPreference.Mode())
+			}
+			continue
+		}
+		if opt == nil || opt.ReadPreference.Mode() != test.mode {
+			t.Errorf("%s: expect read preference mode %v, got option %v", test.name, test.mode, opt)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultSlowQueryLogSizeMB is the default size of the capped slow query log table
+	DefaultSlowQueryLogSizeMB = 64
+	// slowQueryQueueSize is the count of the slow queries waiting to be saved, the slow queries are always logged,
+	// but are not saved to the slow query log table when the queue is full, so that the db operations are never
+	// blocked by saving them.
+	slowQueryQueueSize = 1000
+	// shapePlaceholder replaces the values in the slow query's filter shape
+	shapePlaceholder = "?"
+)
+
+// slowQueryLogger records the db operations that cost more than the threshold to the log and the capped table.
+type slowQueryLogger struct {
+	threshold time.Duration
+	dbc       *mongo.Client
+	dbname    string
+	queue     chan *metadata.SlowQuery
+}
+
+// newSlowQueryLogger returns nil if the threshold is not set, which means the slow queries are not recorded.
+func newSlowQueryLogger(dbc *mongo.Client, dbname string, thresholdMs int64, sizeMB int64) *slowQueryLogger {
+	if thresholdMs <= 0 {
+		return nil
+	}
+	if sizeMB <= 0 {
+		sizeMB = DefaultSlowQueryLogSizeMB
+	}
+
+	l := &slowQueryLogger{
+		threshold: time.Duration(thresholdMs) * time.Millisecond,
+		dbc:       dbc,
+		dbname:    dbname,
+		queue:     make(chan *metadata.SlowQuery, slowQueryQueueSize),
+	}
+	go l.run(sizeMB)
+	return l
+}
+
+// record records the operation if it costs more than the threshold, the operations on the slow query log table
+// itself are ignored.
+func (l *slowQueryLogger) record(ctx context.Context, collection string, operation oper, filter interface{},
+	sort bson.D, limit int64, cost time.Duration) {
+
+	if l == nil || cost < l.threshold || collection == common.BKTableNameSlowQueryLog {
+		return
+	}
+
+	caller := util.ExtractRequestCallerFromContext(ctx)
+	if caller == "" {
+		caller = common.GetIdentification()
+	}
+
+	query := &metadata.SlowQuery{
+		Collection: collection,
+		Operation:  string(operation),
+		Filter:     filterShape(filter),
+		Sort:       sortString(sort),
+		Limit:      limit,
+		DurationMs: cost.Milliseconds(),
+		Rid:        util.ExtractRequestIDFromContext(ctx),
+		Caller:     caller,
+		AppCode:    util.ExtractRequestAppCodeFromContext(ctx),
+		CreateTime: time.Now(),
+	}
+	blog.Warnf("slow query, collection: %s, operation: %s, filter: %s, sort: %s, limit: %d, cost: %dms, caller: %s, "+
+		"app code: %s, rid: %s", query.Collection, query.Operation, query.Filter, query.Sort, query.Limit,
+		query.DurationMs, query.Caller, query.AppCode, query.Rid)
+
+	select {
+	case l.queue <- query:
+	default:
+	}
+}
+
+func (l *slowQueryLogger) run(sizeMB int64) {
+	l.createTable(sizeMB)
+	for query := range l.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		_, err := l.dbc.Database(l.dbname).Collection(common.BKTableNameSlowQueryLog).InsertOne(ctx, query)
+		cancel()
+		if err != nil {
+			blog.Errorf("save slow query failed, err: %v, rid: %s", err, query.Rid)
+		}
+	}
+}
+
+// createTable creates the capped slow query log table, so that the oldest slow queries are removed automatically.
+func (l *slowQueryLogger) createTable(sizeMB int64) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cmd := bson.D{
+		{Key: "create", Value: common.BKTableNameSlowQueryLog},
+		{Key: "capped", Value: true},
+		{Key: "size", Value: sizeMB << 20},
+	}
+	err := l.dbc.Database(l.dbname).RunCommand(ctx, cmd).Err()
+	if err != nil && !strings.Contains(err.Error(), "already exists") {
+		blog.Errorf("create capped table %s failed, err: %v", common.BKTableNameSlowQueryLog, err)
+	}
+}
+
+// filterShape returns the json of the filter with the values replaced by the placeholder, the json fields are
+// sorted, so that the filters on the same fields in different orders have the same shape.
+func filterShape(filter interface{}) string {
+	if filter == nil {
+		return "{}"
+	}
+
+	// the filter is wrapped, because the aggregate pipeline is an array which can not be marshaled directly.
+	raw, err := bson.Marshal(bson.M{"filter": filter})
+	if err != nil {
+		return fmt.Sprintf("%T", filter)
+	}
+	doc := bson.D{}
+	if err := bson.Unmarshal(raw, &doc); err != nil || len(doc) == 0 {
+		return fmt.Sprintf("%T", filter)
+	}
+
+	js, err := json.Marshal(normalizeShape(doc[0].Value))
+	if err != nil {
+		return fmt.Sprintf("%T", filter)
+	}
+	return string(js)
+}
+
+// normalizeShape replaces the values with the placeholder, an array of values like the $in values is collapsed into
+// one placeholder, while an array of documents like the $or conditions is kept.
+func normalizeShape(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.D:
+		shape := make(map[string]interface{}, len(v))
+		for _, elem := range v {
+			shape[elem.Key] = normalizeShape(elem.Value)
+		}
+		return shape
+	case primitive.M:
+		return normalizeMapShape(v)
+	case map[string]interface{}:
+		return normalizeMapShape(v)
+	case primitive.A:
+		return normalizeArrayShape(v)
+	case []interface{}:
+		return normalizeArrayShape(v)
+	default:
+		return shapePlaceholder
+	}
+}
+
+func normalizeMapShape(value map[string]interface{}) map[string]interface{} {
+	shape := make(map[string]interface{}, len(value))
+	for key, elem := range value {
+		shape[key] = normalizeShape(elem)
+	}
+	return shape
+}
+
+func normalizeArrayShape(value []interface{}) []interface{} {
+	shapes := make([]interface{}, 0, len(value))
+	for _, elem := range value {
+		shape := normalizeShape(elem)
+		if shape == shapePlaceholder {
+			return []interface{}{shapePlaceholder}
+		}
+		shapes = append(shapes, shape)
+	}
+	return shapes
+}
+
+// sortString returns the sort in the format that Find.Sort accepts.
+func sortString(sort bson.D) string {
+	fields := make([]string, 0, len(sort))
+	for _, elem := range sort {
+		fields = append(fields, fmt.Sprintf("%s:%v", elem.Key, elem.Value))
+	}
+	return strings.Join(fields, ",")
+}
+
+// ExplainSlowQuery explains the query plan of the slow query shape without executing it, the placeholders in the
+// filter are explained as null, the filter is explained as a find operation no matter what the operation is.
+func (c *Mongo) ExplainSlowQuery(ctx context.Context, opt *metadata.ExplainSlowQueryOption) (map[string]interface{},
+	error) {
+
+	filter := make(map[string]interface{})
+	if err := json.Unmarshal([]byte(opt.Filter), &filter); err != nil {
+		return nil, fmt.Errorf("filter is not a json object, err: %v", err)
+	}
+
+	find := bson.D{
+		{Key: "find", Value: opt.Collection},
+		{Key: "filter", Value: replacePlaceholder(filter)},
+	}
+	if sort := parseSort(opt.Sort); len(sort) > 0 {
+		find = append(find, bson.E{Key: "sort", Value: sort})
+	}
+	if opt.Limit > 0 {
+		find = append(find, bson.E{Key: "limit", Value: opt.Limit})
+	}
+
+	cmd := bson.D{{Key: "explain", Value: find}, {Key: "verbosity", Value: "queryPlanner"}}
+	raw, err := c.dbc.Database(c.dbname).RunCommand(ctx, cmd).DecodeBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	// convert the result to json, so that it can be returned to the caller as is.
+	js, err := bson.MarshalExtJSON(raw, false, false)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	if err := json.Unmarshal(js, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func replacePlaceholder(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, elem := range v {
+			v[key] = replacePlaceholder(elem)
+		}
+		return v
+	case []interface{}:
+		for idx, elem := range v {
+			v[idx] = replacePlaceholder(elem)
+		}
+		return v
+	case string:
+		if v == shapePlaceholder {
+			return nil
+		}
+		return v
+	default:
+		return v
+	}
+}
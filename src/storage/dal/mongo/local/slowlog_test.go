@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package local
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+	"configcenter/src/common/util"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFilterShape(t *testing.T) {
+	tests := []struct {
+		filter interface{}
+		shape  string
+	}{
+		{filter: nil, shape: `{}`},
+		{
+			filter: map[string]interface{}{"bk_host_id": 1, "bk_host_innerip": map[string]interface{}{"$in": []string{
+				"127.0.0.1", "127.0.0.2"}}},
+			shape: `{"bk_host_id":"?","bk_host_innerip":{"$in":["?"]}}`,
+		},
+		{
+			filter: mapstr.MapStr{"$or": []mapstr.MapStr{{"name": "a"}, {"bk_obj_id": "host", "id": 2}}},
+			shape:  `{"$or":[{"name":"?"},{"bk_obj_id":"?","id":"?"}]}`,
+		},
+		{
+			filter: bson.D{{Key: "b", Value: bson.M{"$exists": true}}, {Key: "a", Value: bson.A{}}},
+			shape:  `{"a":[],"b":{"$exists":"?"}}`,
+		},
+		{
+			filter: []bson.M{{"$match": bson.M{"bk_biz_id": 2}}, {"$limit": 10}},
+			shape:  `[{"$match":{"bk_biz_id":"?"}},{"$limit":"?"}]`,
+		},
+	}
+
+	for _, test := range tests {
+		if shape := filterShape(test.filter); shape != test.shape {
+			t.Errorf("filter %v shape should be %s, got: %s", test.filter, test.shape, shape)
+		}
+	}
+}
+
+func TestRecordCaller(t *testing.T) {
+	l := &slowQueryLogger{threshold: time.Millisecond, queue: make(chan *metadata.SlowQuery, 2)}
+
+	header := make(http.Header)
+	header.Set(common.BKHTTPCallerModule, types.CC_MODULE_HOST)
+	header.Set(common.BKHTTPRequestAppCode, "bk_app")
+	l.record(util.NewContextFromHTTPHeader(header), common.BKTableNameBaseHost, findOper, nil, nil, 0, time.Second)
+	query := <-l.queue
+	if query.Caller != types.CC_MODULE_HOST || query.AppCode != "bk_app" {
+		t.Fatalf("caller should be taken from the request, got caller: %s, app code: %s", query.Caller,
+			query.AppCode)
+	}
+
+	// the operation that is not run for a request from another module is called by the module itself
+	l.record(context.Background(), common.BKTableNameBaseHost, findOper, nil, nil, 0, time.Second)
+	query = <-l.queue
+	if query.Caller != common.GetIdentification() || query.AppCode != "" {
+		t.Fatalf("caller should be the module itself, got caller: %s, app code: %s", query.Caller, query.AppCode)
+	}
+}
+
+func TestSortString(t *testing.T) {
+	sort := parseSort("bk_host_id, -name, create_time:-1")
+	if str := sortString(sort); str != "bk_host_id:1,name:-1,create_time:-1" {
+		t.Fatalf("sort string is invalid, got: %s", str)
+	}
+	if str := sortString(parseSort(sortString(sort))); str != "bk_host_id:1,name:-1,create_time:-1" {
+		t.Fatalf("sort string should be parsed back, got: %s", str)
+	}
+}
+
+func TestReplacePlaceholder(t *testing.T) {
+	filter := map[string]interface{}{"a": "?", "b": map[string]interface{}{"$in": []interface{}{"?"}}, "c": "x"}
+	replaced := replacePlaceholder(filter).(map[string]interface{})
+	if replaced["a"] != nil || replaced["b"].(map[string]interface{})["$in"].([]interface{})[0] != nil ||
+		replaced["c"] != "x" {
+		t.Fatalf("placeholders should be replaced with null, got: %v", replaced)
+	}
+}
@@ -193,6 +193,10 @@ func (c *client) SAdd(ctx context.Context, key string, members ...interface{}) I
 	return c.cli.SAdd(key, members...)
 }
 
+func (c *client) Scan(ctx context.Context, cursor uint64, match string, count int64) ScanResult {
+	return c.cli.Scan(cursor, match, count)
+}
+
 func (c *client) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) StatusResult {
 	return c.cli.Set(key, value, expiration)
 }
@@ -55,6 +55,7 @@ type Commands interface {
 	RPopLPush(ctx context.Context, source, destination string) StringResult
 	RPush(ctx context.Context, key string, values ...interface{}) IntResult
 	SAdd(ctx context.Context, key string, members ...interface{}) IntResult
+	Scan(ctx context.Context, cursor uint64, match string, count int64) ScanResult
 	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) StatusResult
 	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) BoolResult
 	SMembers(ctx context.Context, key string) StringSliceResult
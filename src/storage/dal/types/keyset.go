@@ -0,0 +1,171 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package types
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// defaultKeysetPageSize is the page size used when the keyset iterator's page size is not set
+const defaultKeysetPageSize = 1000
+
+// KeysetIter walks through all the documents that match the filter in the ascending order of
+// the id field page by page. each page is found with the id field greater than the last one of
+// the previous page instead of skipping the previous documents, so a deep page costs the same as
+// the first one. the id field must be unique, e.g. "_id" or the id field of the table such as
+// "bk_host_id" and "id".
+type KeysetIter struct {
+	table    Table
+	filter   Filter
+	idField  string
+	pageSize uint64
+	fields   []string
+	// idType is used to decode the id field of the current document
+	idType reflect.Type
+
+	lastID interface{}
+	page   Iterator
+	// count is the number of documents read in the current page
+	count uint64
+	done  bool
+	err   error
+}
+
+// NewKeysetIter create a keyset pagination iterator of the table
+func NewKeysetIter(table Table, filter Filter, idField string, pageSize uint64) *KeysetIter {
+	if pageSize == 0 {
+		pageSize = defaultKeysetPageSize
+	}
+
+	idType := reflect.StructOf([]reflect.StructField{{
+		Name: "ID",
+		Type: reflect.TypeOf((*interface{})(nil)).Elem(),
+		Tag:  reflect.StructTag(fmt.Sprintf(`bson:"%s"`, idField)),
+	}})
+
+	return &KeysetIter{
+		table:    table,
+		filter:   filter,
+		idField:  idField,
+		pageSize: pageSize,
+		idType:   idType,
+	}
+}
+
+// Fields set the fields to be returned, the id field is always returned.
+func (k *KeysetIter) Fields(fields ...string) *KeysetIter {
+	k.fields = append(k.fields, fields...)
+	return k
+}
+
+// After set the iterator to start after the document with the id, which is used to
+// resume an iteration with the LastID of the previous one.
+func (k *KeysetIter) After(lastID interface{}) *KeysetIter {
+	k.lastID = lastID
+	return k
+}
+
+// LastID returns the id of the last document returned by Next, which is nil before
+// the first document is returned if After is not set.
+func (k *KeysetIter) LastID() interface{} {
+	return k.lastID
+}
+
+// Next prepares the next document for Decode, the next page is fetched when the current one is finished.
+func (k *KeysetIter) Next(ctx context.Context) bool {
+	for {
+		if k.err != nil || k.done {
+			return false
+		}
+
+		if k.page == nil {
+			k.page = k.nextPage(ctx)
+			k.count = 0
+		}
+
+		if k.page.Next(ctx) {
+			holder := reflect.New(k.idType)
+			if err := k.page.Decode(holder.Interface()); err != nil {
+				k.err = err
+				return false
+			}
+			k.lastID = holder.Elem().Field(0).Interface()
+			k.count++
+			return true
+		}
+
+		if err := k.page.Err(); err != nil {
+			k.err = err
+			return false
+		}
+
+		if err := k.page.Close(ctx); err != nil {
+			k.err = err
+			return false
+		}
+		k.page = nil
+
+		// a page that is not full means there is no more documents
+		if k.count < k.pageSize {
+			k.done = true
+		}
+	}
+}
+
+func (k *KeysetIter) nextPage(ctx context.Context) Iterator {
+	filter := k.filter
+	if k.lastID != nil {
+		idCond := map[string]interface{}{k.idField: map[string]interface{}{"$gt": k.lastID}}
+		if filter == nil {
+			filter = idCond
+		} else {
+			filter = map[string]interface{}{"$and": []interface{}{filter, idCond}}
+		}
+	}
+
+	find := k.table.Find(filter, FindOpts{WithObjectID: k.idField == "_id"})
+	if len(k.fields) != 0 {
+		find = find.Fields(append(k.fields, k.idField)...)
+	}
+	return find.Sort(k.idField).Limit(k.pageSize).Iter(ctx)
+}
+
+// Decode decodes the current document into result.
+func (k *KeysetIter) Decode(result interface{}) error {
+	if k.err != nil {
+		return k.err
+	}
+	if k.page == nil {
+		return fmt.Errorf("keyset iterator has no current document")
+	}
+	return k.page.Decode(result)
+}
+
+// Err returns the error occurred during the iteration, if any.
+func (k *KeysetIter) Err() error {
+	return k.err
+}
+
+// Close closes the page being iterated.
+func (k *KeysetIter) Close(ctx context.Context) error {
+	if k.page == nil {
+		return nil
+	}
+	err := k.page.Close(ctx)
+	k.page = nil
+	return err
+}
+
+var _ Iterator = new(KeysetIter)
@@ -15,6 +15,8 @@ package types
 import (
 	"context"
 	"errors"
+
+	"configcenter/src/common"
 )
 
 // Errors defines
@@ -46,6 +48,9 @@ type Table interface {
 	Upsert(ctx context.Context, filter Filter, doc interface{}) error
 	// UpdateMultiModel  data based on operators.
 	UpdateMultiModel(ctx context.Context, filter Filter, updateModel ...ModeUpdate) error
+	// UpdateMultiModelCount update data based on operators like UpdateMultiModel, and returns the number of
+	// the matched documents, which is used to tell if a conditional update takes effect.
+	UpdateMultiModelCount(ctx context.Context, filter Filter, updateModel ...ModeUpdate) (uint64, error)
 
 	// Delete 删除数据
 	Delete(ctx context.Context, filter Filter) error
@@ -91,6 +96,22 @@ type Find interface {
 	One(ctx context.Context, result interface{}) error
 	// Count 统计数量(非事务)
 	Count(ctx context.Context) (uint64, error)
+	// Iter 返回逐条遍历查询结果的迭代器，用于处理大结果集，避免一次性加载全部数据
+	Iter(ctx context.Context) Iterator
+}
+
+// Iterator is used to walk through the documents of a find operation one by one.
+// it must be closed after use.
+type Iterator interface {
+	// Next prepares the next document for Decode, returns false when there is no more
+	// document or an error occurred, use Err to tell the difference.
+	Next(ctx context.Context) bool
+	// Decode decodes the current document into result.
+	Decode(result interface{}) error
+	// Err returns the error occurred during the iteration, if any.
+	Err() error
+	// Close closes the iterator and releases the resources it holds.
+	Close(ctx context.Context) error
 }
 
 // ModeUpdate  根据不同的操作符去更新数据
@@ -110,4 +131,7 @@ type Index struct {
 
 type FindOpts struct {
 	WithObjectID bool
+	// ReadPreference the read preference of this find, it takes precedence over the one in the context.
+	// reads in a transaction or marked as following a write are always sent to the primary regardless of it.
+	ReadPreference common.ReadPreferenceMode
 }
@@ -0,0 +1,557 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modelspec
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/apimachinery/coreservice"
+	"configcenter/src/common"
+	"configcenter/src/common/auditlog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/http/rest"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// Applier loads the live schema and applies the plan by the core service's model apis, and saves the audit logs of
+// the classifications, models, attribute groups and attributes the same as the topo server does.
+type Applier struct {
+	client  coreservice.CoreServiceClientInterface
+	header  http.Header
+	ownerID string
+	user    string
+}
+
+// NewApplier new an applier, the header must have the supplier account and user
+func NewApplier(client coreservice.CoreServiceClientInterface, header http.Header) *Applier {
+	return &Applier{
+		client:  client,
+		header:  header,
+		ownerID: util.GetOwnerID(header),
+		user:    util.GetUser(header),
+	}
+}
+
+// LoadLiveSchema loads the live schema of the items declared in the spec
+func (a *Applier) LoadLiveSchema(ctx context.Context, spec *Spec) (*LiveSchema, error) {
+	live := &LiveSchema{
+		Classifications:  make(map[string]metadata.Classification),
+		AssociationKinds: make(map[string]metadata.AssociationKind),
+		Models:           make(map[string]*LiveModel),
+	}
+
+	if len(spec.Classifications) > 0 {
+		clsIDs := make([]string, 0)
+		for _, cls := range spec.Classifications {
+			clsIDs = append(clsIDs, cls.ClassificationID)
+		}
+		cond := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.BKClassificationIDField: mapstr.MapStr{common.BKDBIN: clsIDs}},
+		}
+		result, err := a.client.Model().ReadModelClassification(ctx, a.header, cond)
+		if err != nil {
+			return nil, fmt.Errorf("read classifications failed, err: %v", err)
+		}
+		if err := result.CCError(); err != nil {
+			return nil, fmt.Errorf("read classifications failed, err: %v", err)
+		}
+		for _, cls := range result.Data.Info {
+			live.Classifications[cls.ClassificationID] = cls
+		}
+	}
+
+	if len(spec.AssociationKinds) > 0 {
+		asstIDs := make([]string, 0)
+		for _, kind := range spec.AssociationKinds {
+			asstIDs = append(asstIDs, kind.AssociationKindID)
+		}
+		cond := &metadata.QueryCondition{
+			Condition: mapstr.MapStr{common.AssociationKindIDField: mapstr.MapStr{common.BKDBIN: asstIDs}},
+		}
+		result, err := a.client.Association().ReadAssociationType(ctx, a.header, cond)
+		if err != nil {
+			return nil, fmt.Errorf("read association kinds failed, err: %v", err)
+		}
+		if err := result.CCError(); err != nil {
+			return nil, fmt.Errorf("read association kinds failed, err: %v", err)
+		}
+		for _, kind := range result.Data.Info {
+			live.AssociationKinds[kind.AssociationKindID] = *kind
+		}
+	}
+
+	for _, model := range spec.Models {
+		liveModel, err := a.loadLiveModel(ctx, model.ObjectID)
+		if err != nil {
+			return nil, err
+		}
+		if liveModel != nil {
+			live.Models[model.ObjectID] = liveModel
+		}
+	}
+	return live, nil
+}
+
+// loadLiveModel loads a model with its global attribute groups, attributes and unique rules, returns nil if the
+// model does not exist.
+func (a *Applier) loadLiveModel(ctx context.Context, objID string) (*LiveModel, error) {
+	modelCond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: objID}}
+	modelResult, err := a.client.Model().ReadModel(ctx, a.header, modelCond)
+	if err != nil {
+		return nil, fmt.Errorf("read model %s failed, err: %v", objID, err)
+	}
+	if err := modelResult.CCError(); err != nil {
+		return nil, fmt.Errorf("read model %s failed, err: %v", objID, err)
+	}
+	if len(modelResult.Data.Info) == 0 {
+		return nil, nil
+	}
+
+	liveModel := &LiveModel{Object: modelResult.Data.Info[0].Spec}
+
+	globalCond := metadata.QueryCondition{Condition: mapstr.MapStr{common.BKAppIDField: 0}}
+	groupResult, err := a.client.Model().ReadAttributeGroup(ctx, a.header, objID, globalCond)
+	if err != nil {
+		return nil, fmt.Errorf("read model %s's groups failed, err: %v", objID, err)
+	}
+	if err := groupResult.CCError(); err != nil {
+		return nil, fmt.Errorf("read model %s's groups failed, err: %v", objID, err)
+	}
+	liveModel.Groups = groupResult.Data.Info
+
+	attributes, err := a.readAttributes(ctx, objID)
+	if err != nil {
+		return nil, err
+	}
+	liveModel.Attributes = attributes
+
+	uniqueCond := metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: objID}}
+	uniqueResult, err := a.client.Model().ReadModelAttrUnique(ctx, a.header, uniqueCond)
+	if err != nil {
+		return nil, fmt.Errorf("read model %s's unique rules failed, err: %v", objID, err)
+	}
+	if err := uniqueResult.CCError(); err != nil {
+		return nil, fmt.Errorf("read model %s's unique rules failed, err: %v", objID, err)
+	}
+	liveModel.Uniques = uniqueResult.Data.Info
+	return liveModel, nil
+}
+
+func (a *Applier) readAttributes(ctx context.Context, objID string) ([]metadata.Attribute, error) {
+	cond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKAppIDField: 0}}
+	result, err := a.client.Model().ReadModelAttr(ctx, a.header, objID, cond)
+	if err != nil {
+		return nil, fmt.Errorf("read model %s's attributes failed, err: %v", objID, err)
+	}
+	if err := result.CCError(); err != nil {
+		return nil, fmt.Errorf("read model %s's attributes failed, err: %v", objID, err)
+	}
+	return result.Data.Info, nil
+}
+
+// CheckDataLoss checks if the destructive steps of the plan would destroy the instances' data,
+// returns an error with the attributes that hold data, so that nothing is applied.
+func (a *Applier) CheckDataLoss(ctx context.Context, plan *Plan) error {
+	for _, step := range plan.DestructiveSteps() {
+		cond := &metadata.QueryCondition{
+			Fields: []string{step.Key},
+			Page:   metadata.BasePage{Limit: 1},
+			Condition: mapstr.MapStr{
+				step.Key: mapstr.MapStr{
+					common.BKDBExists: true,
+					common.BKDBNIN:    []interface{}{nil, ""},
+				},
+			},
+		}
+		result, err := a.client.Instance().ReadInstance(ctx, a.header, step.ObjID, cond)
+		if err != nil {
+			return fmt.Errorf("check data of attribute %s.%s failed, err: %v", step.ObjID, step.Key, err)
+		}
+		if err := result.CCError(); err != nil {
+			return fmt.Errorf("check data of attribute %s.%s failed, err: %v", step.ObjID, step.Key, err)
+		}
+		if result.Data.Count > 0 || len(result.Data.Info) > 0 {
+			return fmt.Errorf("step \"%s\" would destroy the data of attribute %s.%s held by the instances, "+
+				"please migrate the data first", step, step.ObjID, step.Key)
+		}
+	}
+	return nil
+}
+
+// Apply applies the steps of the plan in order, it stops at the first failed step. the handler is called
+// after each step is applied.
+func (a *Applier) Apply(ctx context.Context, plan *Plan, handler func(step Step)) error {
+	if err := a.CheckDataLoss(ctx, plan); err != nil {
+		return err
+	}
+
+	for _, step := range plan.Steps {
+		if err := a.applyStep(ctx, step); err != nil {
+			return fmt.Errorf("apply step \"%s\" failed, err: %v", step, err)
+		}
+		if handler != nil {
+			handler(step)
+		}
+	}
+	return nil
+}
+
+func (a *Applier) applyStep(ctx context.Context, step Step) error {
+	switch step.Kind {
+	case KindClassification:
+		return a.applyClassification(ctx, step)
+	case KindAssociationKind:
+		return a.applyAssociationKind(ctx, step)
+	case KindModel:
+		return a.applyModel(ctx, step)
+	case KindGroup:
+		return a.applyGroup(ctx, step)
+	case KindAttribute:
+		return a.applyAttribute(ctx, step)
+	case KindUnique:
+		return a.applyUnique(ctx, step)
+	default:
+		return fmt.Errorf("unknown step kind %s", step.Kind)
+	}
+}
+
+func (a *Applier) applyClassification(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionCreate:
+		cls := step.Data.(metadata.Classification)
+		cls.OwnerID = a.ownerID
+		result, err := a.client.Model().CreateModelClassification(ctx, a.header,
+			&metadata.CreateOneModelClassification{Data: cls})
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveCreateAuditLog(ctx, step, int64(result.Data.Created.ID))
+	case ActionUpdate:
+		auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditUpdate, step.ID)
+		if err != nil {
+			return err
+		}
+		option := &metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: step.ID},
+			Data:      step.Data.(mapstr.MapStr),
+		}
+		result, err := a.client.Model().UpdateModelClassification(ctx, a.header, option)
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveAuditLog(ctx, auditLog)
+	}
+	return fmt.Errorf("unsupported action %s", step.Action)
+}
+
+func (a *Applier) applyAssociationKind(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionCreate:
+		kind := step.Data.(metadata.AssociationKind)
+		kind.OwnerID = a.ownerID
+		result, err := a.client.Association().CreateAssociationType(ctx, a.header,
+			&metadata.CreateAssociationKind{Data: kind})
+		if err != nil {
+			return err
+		}
+		return result.CCError()
+	case ActionUpdate:
+		option := &metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: step.ID},
+			Data:      step.Data.(mapstr.MapStr),
+		}
+		result, err := a.client.Association().UpdateAssociationType(ctx, a.header, option)
+		if err != nil {
+			return err
+		}
+		return result.CCError()
+	}
+	return fmt.Errorf("unsupported action %s", step.Action)
+}
+
+func (a *Applier) applyModel(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionCreate:
+		now := metadata.Now()
+		object := step.Data.(metadata.Object)
+		object.OwnerID = a.ownerID
+		object.Creator = a.user
+		object.Modifier = a.user
+		object.CreateTime = &now
+		object.LastTime = &now
+		result, err := a.client.Model().CreateModel(ctx, a.header, &metadata.CreateModel{Spec: object})
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveCreateAuditLog(ctx, step, int64(result.Data.Created.ID))
+	case ActionUpdate:
+		data := step.Data.(mapstr.MapStr)
+		data[common.ModifierField] = a.user
+		auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditUpdate, step.ID)
+		if err != nil {
+			return err
+		}
+		option := &metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: step.ID},
+			Data:      data,
+		}
+		result, err := a.client.Model().UpdateModel(ctx, a.header, option)
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveAuditLog(ctx, auditLog)
+	}
+	return fmt.Errorf("unsupported action %s", step.Action)
+}
+
+func (a *Applier) applyGroup(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionCreate:
+		group := step.Data.(metadata.Group)
+		group.OwnerID = a.ownerID
+		result, err := a.client.Model().CreateAttributeGroup(ctx, a.header, step.ObjID,
+			metadata.CreateModelAttributeGroup{Data: group})
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveCreateAuditLog(ctx, step, int64(result.Data.Created.ID))
+	case ActionUpdate:
+		auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditUpdate, step.ID)
+		if err != nil {
+			return err
+		}
+		option := metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: step.ID},
+			Data:      step.Data.(mapstr.MapStr),
+		}
+		result, err := a.client.Model().UpdateAttributeGroup(ctx, a.header, step.ObjID, option)
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveAuditLog(ctx, auditLog)
+	case ActionDelete:
+		auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditDelete, step.ID)
+		if err != nil {
+			return err
+		}
+		option := metadata.DeleteOption{Condition: mapstr.MapStr{common.BKFieldID: step.ID}}
+		result, err := a.client.Model().DeleteAttributeGroup(ctx, a.header, step.ObjID, option)
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveAuditLog(ctx, auditLog)
+	}
+	return fmt.Errorf("unsupported action %s", step.Action)
+}
+
+func (a *Applier) applyAttribute(ctx context.Context, step Step) error {
+	switch step.Action {
+	case ActionCreate:
+		now := metadata.Now()
+		attr := step.Data.(metadata.Attribute)
+		attr.OwnerID = a.ownerID
+		attr.Creator = a.user
+		attr.CreateTime = &now
+		attr.LastTime = &now
+		result, err := a.client.Model().CreateModelAttrs(ctx, a.header, step.ObjID,
+			&metadata.CreateModelAttributes{Attributes: []metadata.Attribute{attr}})
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		if len(result.Data.Exceptions) > 0 {
+			return fmt.Errorf("%s", result.Data.Exceptions[0].Message)
+		}
+		if len(result.Data.Created) == 0 {
+			return fmt.Errorf("attribute %s.%s is not created", step.ObjID, step.Key)
+		}
+		return a.saveCreateAuditLog(ctx, step, int64(result.Data.Created[0].ID))
+	case ActionUpdate:
+		auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditUpdate, step.ID)
+		if err != nil {
+			return err
+		}
+		option := &metadata.UpdateOption{
+			Condition: mapstr.MapStr{common.BKFieldID: step.ID},
+			Data:      step.Data.(mapstr.MapStr),
+		}
+		result, err := a.client.Model().UpdateModelAttrs(ctx, a.header, step.ObjID, option)
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveAuditLog(ctx, auditLog)
+	case ActionDelete:
+		auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditDelete, step.ID)
+		if err != nil {
+			return err
+		}
+		option := &metadata.DeleteOption{Condition: mapstr.MapStr{common.BKFieldID: step.ID}}
+		result, err := a.client.Model().DeleteModelAttr(ctx, a.header, step.ObjID, option)
+		if err != nil {
+			return err
+		}
+		if err := result.CCError(); err != nil {
+			return err
+		}
+		return a.saveAuditLog(ctx, auditLog)
+	}
+	return fmt.Errorf("unsupported action %s", step.Action)
+}
+
+func (a *Applier) applyUnique(ctx context.Context, step Step) error {
+	if step.Action == ActionDelete {
+		result, err := a.client.Model().DeleteModelAttrUnique(ctx, a.header, step.ObjID, uint64(step.ID))
+		if err != nil {
+			return err
+		}
+		return result.CCError()
+	}
+
+	// the attributes are read when applying the unique rule, because they may be created by the former steps
+	attributes, err := a.readAttributes(ctx, step.ObjID)
+	if err != nil {
+		return err
+	}
+	attrIDs := make(map[string]int64)
+	for _, attr := range attributes {
+		attrIDs[attr.PropertyID] = attr.ID
+	}
+
+	keys := make([]metadata.UniqueKey, 0)
+	for _, propertyID := range step.UniqueKeys {
+		attrID, exists := attrIDs[propertyID]
+		if !exists {
+			return fmt.Errorf("attribute %s.%s does not exist", step.ObjID, propertyID)
+		}
+		keys = append(keys, metadata.UniqueKey{Kind: metadata.UniqueKeyKindProperty, ID: uint64(attrID)})
+	}
+
+	switch step.Action {
+	case ActionCreate:
+		unique := metadata.ObjectUnique{
+			ObjID:     step.ObjID,
+			MustCheck: step.MustCheck,
+			Keys:      keys,
+			OwnerID:   a.ownerID,
+			LastTime:  metadata.Now(),
+		}
+		result, err := a.client.Model().CreateModelAttrUnique(ctx, a.header, step.ObjID,
+			metadata.CreateModelAttrUnique{Data: unique})
+		if err != nil {
+			return err
+		}
+		return result.CCError()
+	case ActionUpdate:
+		update := metadata.UpdateUniqueRequest{
+			MustCheck: step.MustCheck,
+			Keys:      keys,
+			LastTime:  metadata.Now(),
+		}
+		result, err := a.client.Model().UpdateModelAttrUnique(ctx, a.header, step.ObjID, uint64(step.ID),
+			metadata.UpdateModelAttrUnique{Data: update})
+		if err != nil {
+			return err
+		}
+		return result.CCError()
+	}
+	return fmt.Errorf("unsupported action %s", step.Action)
+}
+
+// newKit new a kit for the audit log utilities, the error resources are not loaded by cmdb_ctl, so an empty one is
+// used to make the error codes still available.
+func (a *Applier) newKit(ctx context.Context) *rest.Kit {
+	ccErr := util.GetDefaultCCError(a.header)
+	if ccErr == nil {
+		ccErr = errors.NewFromCtx(make(map[string]errors.ErrorCode)).CreateDefaultCCErrorIf(util.GetLanguage(a.header))
+	}
+	return &rest.Kit{
+		Rid:             util.GetHTTPCCRequestID(a.header),
+		Header:          a.header,
+		Ctx:             ctx,
+		CCError:         ccErr,
+		User:            a.user,
+		SupplierAccount: a.ownerID,
+	}
+}
+
+// generateAuditLog generates the audit log of the step's item with its current data, so the update and delete
+// audit logs must be generated before the step is applied, and the create ones after it.
+func (a *Applier) generateAuditLog(ctx context.Context, step Step, action metadata.ActionType, id int64) (
+	*metadata.AuditLog, error) {
+
+	parameter := auditlog.NewGenerateAuditCommonParameter(a.newKit(ctx), action)
+	if action == metadata.AuditUpdate {
+		parameter.WithUpdateFields(step.Data.(mapstr.MapStr))
+	}
+
+	switch step.Kind {
+	case KindClassification:
+		return auditlog.NewObjectClsAuditLog(a.client).GenerateAuditLog(parameter, id, nil)
+	case KindModel:
+		return auditlog.NewObjectAuditLog(a.client).GenerateAuditLog(parameter, id, nil)
+	case KindGroup:
+		return auditlog.NewAttributeGroupAuditLog(a.client).GenerateAuditLog(parameter, id, nil)
+	case KindAttribute:
+		return auditlog.NewObjectAttributeAuditLog(a.client).GenerateAuditLog(parameter, id, nil)
+	}
+	return nil, fmt.Errorf("audit log of step kind %s is not supported", step.Kind)
+}
+
+func (a *Applier) saveCreateAuditLog(ctx context.Context, step Step, id int64) error {
+	auditLog, err := a.generateAuditLog(ctx, step, metadata.AuditCreate, id)
+	if err != nil {
+		return fmt.Errorf("%s is created, but generate audit log failed, err: %v", step.Key, err)
+	}
+	return a.saveAuditLog(ctx, auditLog)
+}
+
+func (a *Applier) saveAuditLog(ctx context.Context, auditLog *metadata.AuditLog) error {
+	result, err := a.client.Audit().SaveAuditLog(ctx, a.header, *auditLog)
+	if err != nil {
+		return fmt.Errorf("save audit log failed, err: %v", err)
+	}
+	if err := result.CCError(); err != nil {
+		return fmt.Errorf("save audit log failed, err: %v", err)
+	}
+	return nil
+}
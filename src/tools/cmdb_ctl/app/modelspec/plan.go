@@ -0,0 +1,664 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modelspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// LiveSchema is the schema in cmdb of the items that are declared in the spec
+type LiveSchema struct {
+	Classifications  map[string]metadata.Classification
+	AssociationKinds map[string]metadata.AssociationKind
+	Models           map[string]*LiveModel
+}
+
+// LiveModel is a model in cmdb with its attribute groups, attributes and unique rules
+type LiveModel struct {
+	Object     metadata.Object
+	Groups     []metadata.Group
+	Attributes []metadata.Attribute
+	Uniques    []metadata.ObjectUnique
+}
+
+// Action the action of a plan step
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+// Kind the kind of item that a plan step operates
+type Kind string
+
+const (
+	KindClassification  Kind = "classification"
+	KindAssociationKind Kind = "association_kind"
+	KindModel           Kind = "model"
+	KindGroup           Kind = "group"
+	KindAttribute       Kind = "attribute"
+	KindUnique          Kind = "unique"
+)
+
+// Step is one operation of the plan
+type Step struct {
+	Action Action
+	Kind   Kind
+	// ObjID the model that the group, attribute or unique rule belongs to
+	ObjID string
+	// Key the identity of the item, like bk_property_id of an attribute or the keys of a unique rule
+	Key string
+	// ID the live item's id, used by update and delete
+	ID int64
+	// Changes the changed fields of an update step
+	Changes []string
+	// Destructive whether this step may destroy the instances' data
+	Destructive bool
+	// Data the item to create, or the changed fields to update
+	Data interface{}
+	// UniqueKeys the bk_property_id of the unique rule's keys, they are converted to the attribute ids when applying,
+	// because the attributes may be created by the former steps.
+	UniqueKeys []string
+	MustCheck  bool
+}
+
+// String returns the description of the step
+func (s Step) String() string {
+	name := s.Key
+	if s.ObjID != "" && s.Kind != KindModel {
+		name = s.ObjID + "." + s.Key
+	}
+
+	var desc string
+	switch s.Action {
+	case ActionCreate:
+		desc = fmt.Sprintf("+ create %s %s", s.Kind, name)
+	case ActionUpdate:
+		desc = fmt.Sprintf("~ update %s %s (%s)", s.Kind, name, strings.Join(s.Changes, ", "))
+	case ActionDelete:
+		desc = fmt.Sprintf("- delete %s %s", s.Kind, name)
+	}
+
+	if s.Destructive {
+		desc += " [destructive]"
+	}
+	return desc
+}
+
+// Plan is the ordered steps that make the live schema the same as the spec
+type Plan struct {
+	Steps []Step
+}
+
+// HasChanges returns whether the plan has any step
+func (p *Plan) HasChanges() bool {
+	return len(p.Steps) > 0
+}
+
+// DestructiveSteps returns the steps that may destroy the instances' data
+func (p *Plan) DestructiveSteps() []Step {
+	steps := make([]Step, 0)
+	for _, step := range p.Steps {
+		if step.Destructive {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// planner computes the plan, the steps are collected into phases so that an item is always created before it is
+// referenced and deleted after its references are deleted.
+type planner struct {
+	classifications  []Step
+	associationKinds []Step
+	models           []Step
+	groups           []Step
+	attributes       []Step
+	deleteUniques    []Step
+	deleteAttributes []Step
+	uniques          []Step
+	deleteGroups     []Step
+}
+
+// BuildPlan computes the plan that makes the live schema the same as the spec. the classifications, association
+// kinds and models that are not in the spec are left untouched, and so are the preset or default items of a model.
+func BuildPlan(spec *Spec, live *LiveSchema) (*Plan, error) {
+	p := new(planner)
+
+	for _, cls := range spec.Classifications {
+		p.planClassification(cls, live.Classifications)
+	}
+
+	for _, kind := range spec.AssociationKinds {
+		if err := p.planAssociationKind(kind, live.AssociationKinds); err != nil {
+			return nil, err
+		}
+	}
+
+	for idx := range spec.Models {
+		if err := p.planModel(&spec.Models[idx], live.Models[spec.Models[idx].ObjectID]); err != nil {
+			return nil, err
+		}
+	}
+
+	plan := new(Plan)
+	for _, phase := range [][]Step{p.classifications, p.associationKinds, p.models, p.groups, p.attributes,
+		p.deleteUniques, p.deleteAttributes, p.uniques, p.deleteGroups} {
+		plan.Steps = append(plan.Steps, phase...)
+	}
+	return plan, nil
+}
+
+func (p *planner) planClassification(cls ClassificationSpec, live map[string]metadata.Classification) {
+	icon := cls.ClassificationIcon
+	if icon == "" {
+		icon = "icon-cc-default"
+	}
+
+	liveCls, exists := live[cls.ClassificationID]
+	if !exists {
+		p.classifications = append(p.classifications, Step{
+			Action: ActionCreate,
+			Kind:   KindClassification,
+			Key:    cls.ClassificationID,
+			Data: metadata.Classification{
+				ClassificationID:   cls.ClassificationID,
+				ClassificationName: cls.ClassificationName,
+				ClassificationType: cls.ClassificationType,
+				ClassificationIcon: icon,
+			},
+		})
+		return
+	}
+
+	desired := mapstr.MapStr{
+		"bk_classification_name": cls.ClassificationName,
+		"bk_classification_type": cls.ClassificationType,
+	}
+	if cls.ClassificationIcon != "" {
+		desired["bk_classification_icon"] = cls.ClassificationIcon
+	}
+
+	changes, data := diffFields(desired, liveCls.ToMapStr())
+	if len(changes) > 0 {
+		p.classifications = append(p.classifications, Step{Action: ActionUpdate, Kind: KindClassification,
+			Key: cls.ClassificationID, ID: liveCls.ID, Changes: changes, Data: data})
+	}
+}
+
+func (p *planner) planAssociationKind(kind AssociationKindSpec, live map[string]metadata.AssociationKind) error {
+	direction := kind.Direction
+	if direction == "" {
+		direction = string(metadata.DestinationToSource)
+	}
+
+	liveKind, exists := live[kind.AssociationKindID]
+	if !exists {
+		isPre := false
+		p.associationKinds = append(p.associationKinds, Step{
+			Action: ActionCreate,
+			Kind:   KindAssociationKind,
+			Key:    kind.AssociationKindID,
+			Data: metadata.AssociationKind{
+				AssociationKindID:       kind.AssociationKindID,
+				AssociationKindName:     kind.AssociationKindName,
+				SourceToDestinationNote: kind.SourceToDestinationNote,
+				DestinationToSourceNote: kind.DestinationToSourceNote,
+				Direction:               metadata.AssociationDirection(direction),
+				IsPre:                   &isPre,
+			},
+		})
+		return nil
+	}
+
+	desired := mapstr.MapStr{
+		"bk_asst_name": kind.AssociationKindName,
+		"src_des":      kind.SourceToDestinationNote,
+		"dest_des":     kind.DestinationToSourceNote,
+		"direction":    direction,
+	}
+	current := mapstr.MapStr{
+		"bk_asst_name": liveKind.AssociationKindName,
+		"src_des":      liveKind.SourceToDestinationNote,
+		"dest_des":     liveKind.DestinationToSourceNote,
+		"direction":    liveKind.Direction,
+	}
+
+	changes, data := diffFields(desired, current)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if liveKind.IsPre != nil && *liveKind.IsPre {
+		return fmt.Errorf("association kind %s is preset, it can not be changed", kind.AssociationKindID)
+	}
+	p.associationKinds = append(p.associationKinds, Step{Action: ActionUpdate, Kind: KindAssociationKind,
+		Key: kind.AssociationKindID, ID: liveKind.ID, Changes: changes, Data: data})
+	return nil
+}
+
+func (p *planner) planModel(model *ModelSpec, live *LiveModel) error {
+	icon := model.ObjIcon
+	if icon == "" {
+		icon = "icon-cc-default"
+	}
+
+	groups := model.Groups
+	attributes := model.Attributes
+	uniques := model.Uniques
+
+	if live == nil {
+		p.models = append(p.models, Step{
+			Action: ActionCreate,
+			Kind:   KindModel,
+			ObjID:  model.ObjectID,
+			Key:    model.ObjectID,
+			Data: metadata.Object{
+				ObjCls:      model.ObjCls,
+				ObjIcon:     icon,
+				ObjectID:    model.ObjectID,
+				ObjectName:  model.ObjectName,
+				Description: model.Description,
+			},
+		})
+
+		// a new model has a default group, the instance name attribute and a must check unique rule like the ones
+		// created by the topo server, the spec can override them.
+		groups, attributes, uniques = withModelDefaults(model)
+		live = new(LiveModel)
+	} else {
+		desired := mapstr.MapStr{
+			common.BKObjNameField:          model.ObjectName,
+			common.BKClassificationIDField: model.ObjCls,
+			common.BKDescriptionField:      model.Description,
+		}
+		if model.ObjIcon != "" {
+			desired[common.BKObjIconField] = model.ObjIcon
+		}
+		current := mapstr.MapStr{
+			common.BKObjNameField:          live.Object.ObjectName,
+			common.BKClassificationIDField: live.Object.ObjCls,
+			common.BKDescriptionField:      live.Object.Description,
+			common.BKObjIconField:          live.Object.ObjIcon,
+		}
+
+		changes, data := diffFields(desired, current)
+		if len(changes) > 0 {
+			p.models = append(p.models, Step{Action: ActionUpdate, Kind: KindModel, ObjID: model.ObjectID,
+				Key: model.ObjectID, ID: live.Object.ID, Changes: changes, Data: data})
+		}
+	}
+
+	p.planGroups(model.ObjectID, groups, live.Groups)
+	retyped, err := p.planAttributes(model.ObjectID, attributes, live.Attributes)
+	if err != nil {
+		return err
+	}
+	p.planUniques(model.ObjectID, uniques, live, retyped)
+	return nil
+}
+
+// withModelDefaults returns the groups, attributes and unique rules of a new model with the defaults added
+func withModelDefaults(model *ModelSpec) ([]GroupSpec, []AttributeSpec, []UniqueSpec) {
+	groups := model.Groups
+	hasDefaultGroup := false
+	for _, group := range groups {
+		if group.GroupID == common.BKDefaultField {
+			hasDefaultGroup = true
+		}
+	}
+	if !hasDefaultGroup {
+		index := int64(-1)
+		groups = append([]GroupSpec{{GroupID: common.BKDefaultField, GroupName: "Default", GroupIndex: &index}},
+			groups...)
+	}
+
+	attributes := model.Attributes
+	hasInstName := false
+	for _, attr := range attributes {
+		if attr.PropertyID == common.BKInstNameField {
+			hasInstName = true
+		}
+	}
+	if !hasInstName {
+		index := int64(-1)
+		attributes = append([]AttributeSpec{{
+			PropertyID:    common.BKInstNameField,
+			PropertyName:  common.DefaultInstName,
+			PropertyGroup: common.BKDefaultField,
+			PropertyIndex: &index,
+			PropertyType:  common.FieldTypeSingleChar,
+			IsRequired:    true,
+		}}, attributes...)
+	}
+
+	uniques := model.Uniques
+	hasMustCheck := false
+	for _, unique := range uniques {
+		if unique.MustCheck {
+			hasMustCheck = true
+		}
+	}
+	if !hasMustCheck {
+		uniques = append(uniques, UniqueSpec{MustCheck: true, Keys: []string{common.BKInstNameField}})
+	}
+	return groups, attributes, uniques
+}
+
+func (p *planner) planGroups(objID string, groups []GroupSpec, live []metadata.Group) {
+	liveMap := make(map[string]metadata.Group)
+	for _, group := range live {
+		if group.BizID != 0 {
+			continue
+		}
+		liveMap[group.GroupID] = group
+	}
+
+	specMap := make(map[string]bool)
+	for idx, group := range groups {
+		specMap[group.GroupID] = true
+
+		liveGroup, exists := liveMap[group.GroupID]
+		if !exists {
+			index := int64(idx)
+			if group.GroupIndex != nil {
+				index = *group.GroupIndex
+			}
+			p.groups = append(p.groups, Step{
+				Action: ActionCreate,
+				Kind:   KindGroup,
+				ObjID:  objID,
+				Key:    group.GroupID,
+				Data: metadata.Group{
+					GroupID:    group.GroupID,
+					GroupName:  group.GroupName,
+					GroupIndex: index,
+					ObjectID:   objID,
+					IsDefault:  group.GroupID == common.BKDefaultField,
+					IsCollapse: group.IsCollapse,
+				},
+			})
+			continue
+		}
+
+		desired := mapstr.MapStr{
+			metadata.GroupFieldGroupName: group.GroupName,
+			"is_collapse":                group.IsCollapse,
+		}
+		if group.GroupIndex != nil {
+			desired[metadata.GroupFieldGroupIndex] = *group.GroupIndex
+		}
+
+		changes, data := diffFields(desired, liveGroup.ToMapStr())
+		if len(changes) > 0 {
+			p.groups = append(p.groups, Step{Action: ActionUpdate, Kind: KindGroup, ObjID: objID,
+				Key: group.GroupID, ID: liveGroup.ID, Changes: changes, Data: data})
+		}
+	}
+
+	for _, group := range live {
+		if group.BizID != 0 || group.IsDefault || group.IsPre || specMap[group.GroupID] {
+			continue
+		}
+		p.deleteGroups = append(p.deleteGroups, Step{Action: ActionDelete, Kind: KindGroup, ObjID: objID,
+			Key: group.GroupID, ID: group.ID})
+	}
+}
+
+// planAttributes plans the attributes, returns the attributes whose type is changed, they are deleted and
+// created again, so the unique rules that use them need to be rebuilt.
+func (p *planner) planAttributes(objID string, attributes []AttributeSpec, live []metadata.Attribute) (
+	map[string]bool, error) {
+
+	liveMap := make(map[string]metadata.Attribute)
+	for _, attr := range live {
+		if attr.BizID != 0 {
+			continue
+		}
+		liveMap[attr.PropertyID] = attr
+	}
+
+	retyped := make(map[string]bool)
+	specMap := make(map[string]bool)
+	for idx := range attributes {
+		attr := attributes[idx]
+		specMap[attr.PropertyID] = true
+		desired := attr.toAttribute(objID, int64(idx))
+
+		liveAttr, exists := liveMap[attr.PropertyID]
+		if exists && liveAttr.PropertyType != attr.PropertyType {
+			if liveAttr.IsPre {
+				return nil, fmt.Errorf("attribute %s.%s is preset, its type can not be changed", objID,
+					attr.PropertyID)
+			}
+
+			// the type can not be updated, so the attribute is deleted and created again, which drops its data
+			p.deleteAttributes = append(p.deleteAttributes, Step{Action: ActionDelete, Kind: KindAttribute,
+				ObjID: objID, Key: attr.PropertyID, ID: liveAttr.ID, Destructive: true},
+				Step{Action: ActionCreate, Kind: KindAttribute, ObjID: objID, Key: attr.PropertyID, Data: desired})
+			retyped[attr.PropertyID] = true
+			continue
+		}
+
+		if !exists {
+			p.attributes = append(p.attributes, Step{Action: ActionCreate, Kind: KindAttribute, ObjID: objID,
+				Key: attr.PropertyID, Data: desired})
+			continue
+		}
+
+		changes, data := diffFields(attr.updatableFields(), liveAttr.ToMapStr())
+		if len(changes) > 0 {
+			p.attributes = append(p.attributes, Step{Action: ActionUpdate, Kind: KindAttribute, ObjID: objID,
+				Key: attr.PropertyID, ID: liveAttr.ID, Changes: changes, Data: data})
+		}
+	}
+
+	for _, attr := range live {
+		if attr.BizID != 0 || attr.IsPre || specMap[attr.PropertyID] {
+			continue
+		}
+		p.deleteAttributes = append(p.deleteAttributes, Step{Action: ActionDelete, Kind: KindAttribute,
+			ObjID: objID, Key: attr.PropertyID, ID: attr.ID, Destructive: true})
+	}
+	return retyped, nil
+}
+
+func (a AttributeSpec) groupID() string {
+	if a.PropertyGroup == "" {
+		return common.BKDefaultField
+	}
+	return a.PropertyGroup
+}
+
+func (a AttributeSpec) editable() bool {
+	if a.IsEditable == nil {
+		return true
+	}
+	return *a.IsEditable
+}
+
+func (a AttributeSpec) toAttribute(objID string, defaultIndex int64) metadata.Attribute {
+	index := defaultIndex
+	if a.PropertyIndex != nil {
+		index = *a.PropertyIndex
+	}
+
+	return metadata.Attribute{
+		ObjectID:      objID,
+		PropertyID:    a.PropertyID,
+		PropertyName:  a.PropertyName,
+		PropertyGroup: a.groupID(),
+		PropertyIndex: index,
+		PropertyType:  a.PropertyType,
+		Option:        a.Option,
+		Unit:          a.Unit,
+		Placeholder:   a.Placeholder,
+		IsEditable:    a.editable(),
+		IsRequired:    a.IsRequired,
+		IsOnly:        a.PropertyID == common.BKInstNameField,
+		IsPre:         a.PropertyID == common.BKInstNameField,
+		Description:   a.Description,
+	}
+}
+
+func (a AttributeSpec) updatableFields() mapstr.MapStr {
+	fields := mapstr.MapStr{
+		metadata.AttributeFieldPropertyName:  a.PropertyName,
+		metadata.AttributeFieldPropertyGroup: a.groupID(),
+		metadata.AttributeFieldOption:        a.Option,
+		metadata.AttributeFieldUnit:          a.Unit,
+		metadata.AttributeFieldPlaceHolder:   a.Placeholder,
+		metadata.AttributeFieldIsEditable:    a.editable(),
+		metadata.AttributeFieldIsRequired:    a.IsRequired,
+		metadata.AttributeFieldDescription:   a.Description,
+	}
+	if a.PropertyIndex != nil {
+		fields[metadata.AttributeFieldPropertyIndex] = *a.PropertyIndex
+	}
+	return fields
+}
+
+// planUniques plans the unique rules, they are matched by their keys, the unique rules with association keys
+// can not be declared in the spec, so they are left untouched.
+func (p *planner) planUniques(objID string, uniques []UniqueSpec, live *LiveModel, retyped map[string]bool) {
+	propertyIDs := make(map[uint64]string)
+	for _, attr := range live.Attributes {
+		propertyIDs[uint64(attr.ID)] = attr.PropertyID
+	}
+
+	liveMap := make(map[string]metadata.ObjectUnique)
+	rebuilt := make(map[string]bool)
+	for _, unique := range live.Uniques {
+		keys, ok := uniquePropertyKeys(unique, propertyIDs)
+		if !ok {
+			continue
+		}
+		key := uniqueKeyString(keys)
+		liveMap[key] = unique
+		for _, propertyID := range keys {
+			if retyped[propertyID] {
+				rebuilt[key] = true
+			}
+		}
+	}
+
+	specMap := make(map[string]bool)
+	hasMustCheck := false
+	for _, unique := range uniques {
+		key := uniqueKeyString(unique.Keys)
+		specMap[key] = true
+		if unique.MustCheck {
+			hasMustCheck = true
+		}
+
+		liveUnique, exists := liveMap[key]
+		if !exists || rebuilt[key] {
+			p.uniques = append(p.uniques, Step{Action: ActionCreate, Kind: KindUnique, ObjID: objID, Key: key,
+				UniqueKeys: unique.Keys, MustCheck: unique.MustCheck})
+			continue
+		}
+
+		if liveUnique.MustCheck != unique.MustCheck {
+			p.uniques = append(p.uniques, Step{Action: ActionUpdate, Kind: KindUnique, ObjID: objID, Key: key,
+				ID: int64(liveUnique.ID), Changes: []string{"must_check"}, UniqueKeys: unique.Keys,
+				MustCheck: unique.MustCheck})
+		}
+	}
+
+	for _, unique := range live.Uniques {
+		keys, ok := uniquePropertyKeys(unique, propertyIDs)
+		// a model must have a must check unique rule, so it is kept if the spec does not declare one
+		if !ok || unique.Ispre || (unique.MustCheck && !hasMustCheck) {
+			continue
+		}
+		key := uniqueKeyString(keys)
+		if specMap[key] && !rebuilt[key] {
+			continue
+		}
+		p.deleteUniques = append(p.deleteUniques, Step{Action: ActionDelete, Kind: KindUnique, ObjID: objID,
+			Key: key, ID: int64(unique.ID)})
+	}
+}
+
+// uniquePropertyKeys returns the bk_property_id of the unique rule's keys, returns false if it has a key that is
+// not an attribute.
+func uniquePropertyKeys(unique metadata.ObjectUnique, propertyIDs map[uint64]string) ([]string, bool) {
+	keys := make([]string, 0)
+	for _, key := range unique.Keys {
+		if key.Kind != metadata.UniqueKeyKindProperty {
+			return nil, false
+		}
+		propertyID, exists := propertyIDs[key.ID]
+		if !exists {
+			return nil, false
+		}
+		keys = append(keys, propertyID)
+	}
+	return keys, true
+}
+
+// uniqueKeyString returns the identity of a unique rule, which is its sorted keys
+func uniqueKeyString(keys []string) string {
+	sorted := make([]string, len(keys))
+	copy(sorted, keys)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
+
+// diffFields returns the fields whose desired values are different from the current values, and the desired
+// values of the changed fields.
+func diffFields(desired, current mapstr.MapStr) ([]string, mapstr.MapStr) {
+	changes := make([]string, 0)
+	data := mapstr.MapStr{}
+	for field, val := range desired {
+		if isValueEqual(val, current[field]) {
+			continue
+		}
+		changes = append(changes, field)
+		data[field] = val
+	}
+	sort.Strings(changes)
+	return changes, data
+}
+
+// isValueEqual compare the values by their json form, so that the same values decoded from different sources with
+// different go types are regarded as equal, nil and empty string are regarded as equal too.
+func isValueEqual(a, b interface{}) bool {
+	if isEmptyValue(a) && isEmptyValue(b) {
+		return true
+	}
+
+	aJson, aErr := json.Marshal(a)
+	bJson, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+	return string(aJson) == string(bJson)
+}
+
+func isEmptyValue(val interface{}) bool {
+	if val == nil {
+		return true
+	}
+	str, ok := val.(string)
+	return ok && str == ""
+}
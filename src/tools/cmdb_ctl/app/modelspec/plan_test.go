@@ -0,0 +1,183 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package modelspec_test
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/tools/cmdb_ctl/app/modelspec"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const specYAML = `
+classifications:
+  - bk_classification_id: database
+    bk_classification_name: Database
+association_kinds:
+  - bk_asst_id: replicate
+    bk_asst_name: Replicate
+    src_des: replicates to
+    dest_des: replicated from
+models:
+  - bk_obj_id: mysql
+    bk_obj_name: MySQL
+    bk_classification_id: database
+    groups:
+      - bk_group_id: runtime
+        bk_group_name: Runtime
+    attributes:
+      - bk_property_id: port
+        bk_property_name: Port
+        bk_property_type: int
+        bk_property_group: runtime
+        option:
+          min: "1"
+          max: "65535"
+      - bk_property_id: version
+        bk_property_name: Version
+        bk_property_type: enum
+        option:
+          - id: "5.7"
+            name: "5.7"
+            type: text
+    uniques:
+      - must_check: false
+        keys: [bk_inst_name, port]
+`
+
+func stepStrings(plan *modelspec.Plan) []string {
+	steps := make([]string, 0)
+	for _, step := range plan.Steps {
+		steps = append(steps, step.String())
+	}
+	return steps
+}
+
+func TestBuildPlanForNewModel(t *testing.T) {
+	spec, err := modelspec.ParseYAML([]byte(specYAML))
+	require.NoError(t, err)
+
+	plan, err := modelspec.BuildPlan(spec, &modelspec.LiveSchema{})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"+ create classification database",
+		"+ create association_kind replicate",
+		"+ create model mysql",
+		"+ create group mysql.default",
+		"+ create group mysql.runtime",
+		"+ create attribute mysql.bk_inst_name",
+		"+ create attribute mysql.port",
+		"+ create attribute mysql.version",
+		"+ create unique mysql.bk_inst_name,port",
+		"+ create unique mysql.bk_inst_name",
+	}, stepStrings(plan))
+	assert.Empty(t, plan.DestructiveSteps())
+}
+
+func TestBuildPlanForExistingModel(t *testing.T) {
+	spec, err := modelspec.ParseYAML([]byte(specYAML))
+	require.NoError(t, err)
+
+	live := &modelspec.LiveSchema{
+		Classifications: map[string]metadata.Classification{
+			"database": {ID: 1, ClassificationID: "database", ClassificationName: "Database",
+				ClassificationIcon: "icon-cc-default"},
+		},
+		AssociationKinds: map[string]metadata.AssociationKind{
+			"replicate": {ID: 2, AssociationKindID: "replicate", AssociationKindName: "Replicate",
+				SourceToDestinationNote: "replicates to", DestinationToSourceNote: "replicated from",
+				Direction: metadata.DestinationToSource},
+		},
+		Models: map[string]*modelspec.LiveModel{
+			"mysql": {
+				Object: metadata.Object{ID: 3, ObjectID: "mysql", ObjectName: "mysql", ObjCls: "database",
+					ObjIcon: "icon-cc-default"},
+				Groups: []metadata.Group{
+					{ID: 4, GroupID: "default", GroupName: "Default", GroupIndex: -1, IsDefault: true},
+					{ID: 5, GroupID: "runtime", GroupName: "Runtime", GroupIndex: 1},
+					{ID: 6, GroupID: "legacy", GroupName: "Legacy", GroupIndex: 2},
+				},
+				Attributes: []metadata.Attribute{
+					{ID: 7, PropertyID: "bk_inst_name", PropertyName: "实例名", PropertyGroup: "default",
+						PropertyType: "singlechar", IsPre: true, IsEditable: true, IsRequired: true},
+					{ID: 8, PropertyID: "port", PropertyName: "Port", PropertyGroup: "runtime", PropertyType: "int",
+						Option: map[string]interface{}{"min": "1", "max": "65535"}, IsEditable: true},
+					{ID: 9, PropertyID: "version", PropertyName: "Version", PropertyGroup: "default",
+						PropertyType: "singlechar", IsEditable: true},
+					{ID: 10, PropertyID: "owner", PropertyName: "Owner", PropertyGroup: "legacy",
+						PropertyType: "singlechar", IsEditable: true},
+				},
+				Uniques: []metadata.ObjectUnique{
+					{ID: 11, MustCheck: true, Keys: []metadata.UniqueKey{{Kind: "property", ID: 7}}},
+					{ID: 12, MustCheck: false, Keys: []metadata.UniqueKey{{Kind: "property", ID: 9}}},
+				},
+			},
+		},
+	}
+
+	plan, err := modelspec.BuildPlan(spec, live)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"~ update model mysql (bk_obj_name)",
+		"- delete unique mysql.version",
+		"- delete attribute mysql.version [destructive]",
+		"+ create attribute mysql.version",
+		"- delete attribute mysql.owner [destructive]",
+		"+ create unique mysql.bk_inst_name,port",
+		"- delete group mysql.legacy",
+	}, stepStrings(plan))
+
+	destructive := plan.DestructiveSteps()
+	require.Len(t, destructive, 2)
+	assert.Equal(t, "version", destructive[0].Key)
+	assert.Equal(t, "owner", destructive[1].Key)
+}
+
+func TestBuildPlanRefusePresetTypeChange(t *testing.T) {
+	spec := &modelspec.Spec{Models: []modelspec.ModelSpec{{
+		ObjectID: "host", ObjectName: "Host", ObjCls: "bk_host_manage",
+		Attributes: []modelspec.AttributeSpec{
+			{PropertyID: "bk_host_innerip", PropertyName: "Inner IP", PropertyType: "longchar"},
+		},
+	}}}
+	require.NoError(t, spec.Validate())
+
+	live := &modelspec.LiveSchema{Models: map[string]*modelspec.LiveModel{
+		"host": {
+			Object: metadata.Object{ObjectID: "host", ObjectName: "Host", ObjCls: "bk_host_manage"},
+			Attributes: []metadata.Attribute{
+				{ID: 1, PropertyID: "bk_host_innerip", PropertyName: "Inner IP", PropertyType: "singlechar",
+					IsPre: true},
+			},
+		},
+	}}
+
+	_, err := modelspec.BuildPlan(spec, live)
+	assert.Error(t, err)
+}
+
+func TestSpecValidate(t *testing.T) {
+	_, err := modelspec.ParseJSON([]byte(`{"models": [{"bk_obj_id": "mysql", "bk_obj_name": "MySQL",
+		"bk_classification_id": "database", "uniques": [{"keys": ["port"]}]}]}`))
+	assert.Error(t, err)
+
+	_, err = modelspec.ParseJSON([]byte(`{"models": [{"bk_obj_id": "mysql", "bk_obj_name": "MySQL",
+		"bk_classification_id": "database", "attributes": [{"bk_property_id": "port", "bk_property_name": "Port",
+		"bk_property_type": "int", "bk_property_group": "runtime"}]}]}`))
+	assert.Error(t, err)
+}
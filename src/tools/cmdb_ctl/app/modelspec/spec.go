@@ -0,0 +1,274 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package modelspec defines the declarative spec of the model schema, which includes the classifications,
+// association kinds, models, attribute groups, attributes and unique rules, and computes and applies the plan
+// that makes the live schema the same as the spec.
+package modelspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"configcenter/src/common"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Spec is the declarative schema spec, the fields use the same names as the cmdb api.
+type Spec struct {
+	Classifications  []ClassificationSpec  `json:"classifications"`
+	AssociationKinds []AssociationKindSpec `json:"association_kinds"`
+	Models           []ModelSpec           `json:"models"`
+}
+
+// ClassificationSpec the spec of a model classification
+type ClassificationSpec struct {
+	ClassificationID   string `json:"bk_classification_id"`
+	ClassificationName string `json:"bk_classification_name"`
+	ClassificationType string `json:"bk_classification_type"`
+	ClassificationIcon string `json:"bk_classification_icon"`
+}
+
+// AssociationKindSpec the spec of an association kind
+type AssociationKindSpec struct {
+	AssociationKindID       string `json:"bk_asst_id"`
+	AssociationKindName     string `json:"bk_asst_name"`
+	SourceToDestinationNote string `json:"src_des"`
+	DestinationToSourceNote string `json:"dest_des"`
+	Direction               string `json:"direction"`
+}
+
+// ModelSpec the spec of a model with its attribute groups, attributes and unique rules
+type ModelSpec struct {
+	ObjectID    string `json:"bk_obj_id"`
+	ObjectName  string `json:"bk_obj_name"`
+	ObjCls      string `json:"bk_classification_id"`
+	ObjIcon     string `json:"bk_obj_icon"`
+	Description string `json:"description"`
+
+	Groups     []GroupSpec     `json:"groups"`
+	Attributes []AttributeSpec `json:"attributes"`
+	Uniques    []UniqueSpec    `json:"uniques"`
+}
+
+// GroupSpec the spec of an attribute group
+type GroupSpec struct {
+	GroupID    string `json:"bk_group_id"`
+	GroupName  string `json:"bk_group_name"`
+	GroupIndex *int64 `json:"bk_group_index"`
+	IsCollapse bool   `json:"is_collapse"`
+}
+
+// AttributeSpec the spec of a model attribute
+type AttributeSpec struct {
+	PropertyID   string `json:"bk_property_id"`
+	PropertyName string `json:"bk_property_name"`
+	// PropertyGroup the attribute's group id, default group is used if not set
+	PropertyGroup string `json:"bk_property_group"`
+	// PropertyIndex the attribute's index in its group, it is not managed if not set
+	PropertyIndex *int64      `json:"bk_property_index"`
+	PropertyType  string      `json:"bk_property_type"`
+	Option        interface{} `json:"option"`
+	Unit          string      `json:"unit"`
+	Placeholder   string      `json:"placeholder"`
+	// IsEditable whether the attribute is editable, default is true
+	IsEditable  *bool  `json:"editable"`
+	IsRequired  bool   `json:"isrequired"`
+	Description string `json:"description"`
+}
+
+// UniqueSpec the spec of a unique rule, the keys are the attributes' bk_property_id
+type UniqueSpec struct {
+	MustCheck bool     `json:"must_check"`
+	Keys      []string `json:"keys"`
+}
+
+// LoadFile load the spec from a yaml or json file, the file is regarded as json if its extension is .json
+func LoadFile(path string) (*Spec, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read spec file %s failed, err: %v", path, err)
+	}
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return ParseJSON(content)
+	}
+	return ParseYAML(content)
+}
+
+// ParseJSON parse the spec from json content
+func ParseJSON(content []byte) (*Spec, error) {
+	spec := new(Spec)
+	if err := json.Unmarshal(content, spec); err != nil {
+		return nil, fmt.Errorf("parse spec failed, err: %v", err)
+	}
+
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// ParseYAML parse the spec from yaml content, the content is converted to json first so that the spec
+// and the attribute options are decoded in the same way as the json spec.
+func ParseYAML(content []byte) (*Spec, error) {
+	var data interface{}
+	if err := yaml.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("parse spec failed, err: %v", err)
+	}
+
+	jsonData, err := convertYAMLValue(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse spec failed, err: %v", err)
+	}
+
+	jsonContent, err := json.Marshal(jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("parse spec failed, err: %v", err)
+	}
+	return ParseJSON(jsonContent)
+}
+
+// convertYAMLValue convert the map[interface{}]interface{} decoded by yaml to map[string]interface{}
+func convertYAMLValue(value interface{}) (interface{}, error) {
+	switch val := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{})
+		for k, v := range val {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("key %v is not a string", k)
+			}
+			converted, err := convertYAMLValue(v)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = converted
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(val))
+		for idx, v := range val {
+			converted, err := convertYAMLValue(v)
+			if err != nil {
+				return nil, err
+			}
+			result[idx] = converted
+		}
+		return result, nil
+	default:
+		return value, nil
+	}
+}
+
+// Validate check if the spec is valid, it only checks the structure of the spec,
+// the values like the attribute's option are validated by the cmdb when applying.
+func (s *Spec) Validate() error {
+	clsIDs := make(map[string]bool)
+	for _, cls := range s.Classifications {
+		if cls.ClassificationID == "" || cls.ClassificationName == "" {
+			return fmt.Errorf("classification %s must have bk_classification_id and bk_classification_name",
+				cls.ClassificationID)
+		}
+		if clsIDs[cls.ClassificationID] {
+			return fmt.Errorf("classification %s is duplicated", cls.ClassificationID)
+		}
+		clsIDs[cls.ClassificationID] = true
+	}
+
+	asstIDs := make(map[string]bool)
+	for _, kind := range s.AssociationKinds {
+		if kind.AssociationKindID == "" {
+			return fmt.Errorf("association kind must have bk_asst_id")
+		}
+		if asstIDs[kind.AssociationKindID] {
+			return fmt.Errorf("association kind %s is duplicated", kind.AssociationKindID)
+		}
+		asstIDs[kind.AssociationKindID] = true
+	}
+
+	objIDs := make(map[string]bool)
+	for idx := range s.Models {
+		model := &s.Models[idx]
+		if model.ObjectID == "" || model.ObjectName == "" || model.ObjCls == "" {
+			return fmt.Errorf("model %s must have bk_obj_id, bk_obj_name and bk_classification_id", model.ObjectID)
+		}
+		if objIDs[model.ObjectID] {
+			return fmt.Errorf("model %s is duplicated", model.ObjectID)
+		}
+		objIDs[model.ObjectID] = true
+
+		if err := model.validate(); err != nil {
+			return fmt.Errorf("model %s is invalid, %v", model.ObjectID, err)
+		}
+	}
+	return nil
+}
+
+func (m *ModelSpec) validate() error {
+	// the default group and the instance name attribute always exist, so they can be referenced without declaring
+	groupIDs := make(map[string]bool)
+	for _, group := range m.Groups {
+		if group.GroupID == "" || group.GroupName == "" {
+			return fmt.Errorf("group %s must have bk_group_id and bk_group_name", group.GroupID)
+		}
+		if groupIDs[group.GroupID] {
+			return fmt.Errorf("group %s is duplicated", group.GroupID)
+		}
+		groupIDs[group.GroupID] = true
+	}
+
+	propertyIDs := make(map[string]bool)
+	for _, attr := range m.Attributes {
+		if attr.PropertyID == "" || attr.PropertyName == "" || attr.PropertyType == "" {
+			return fmt.Errorf("attribute %s must have bk_property_id, bk_property_name and bk_property_type",
+				attr.PropertyID)
+		}
+		if propertyIDs[attr.PropertyID] {
+			return fmt.Errorf("attribute %s is duplicated", attr.PropertyID)
+		}
+		propertyIDs[attr.PropertyID] = true
+
+		if attr.PropertyGroup != "" && attr.PropertyGroup != common.BKDefaultField && !groupIDs[attr.PropertyGroup] {
+			return fmt.Errorf("attribute %s's group %s is not in the spec", attr.PropertyID, attr.PropertyGroup)
+		}
+	}
+
+	uniqueKeys := make(map[string]bool)
+	mustCheckCount := 0
+	for _, unique := range m.Uniques {
+		if len(unique.Keys) == 0 {
+			return fmt.Errorf("unique rule must have keys")
+		}
+		if unique.MustCheck {
+			mustCheckCount++
+		}
+		if mustCheckCount > 1 {
+			return fmt.Errorf("model can not have multiple must check unique rules")
+		}
+		for _, key := range unique.Keys {
+			if key != common.BKInstNameField && !propertyIDs[key] {
+				return fmt.Errorf("unique rule's key %s is not in the spec's attributes", key)
+			}
+		}
+		key := uniqueKeyString(unique.Keys)
+		if uniqueKeys[key] {
+			return fmt.Errorf("unique rule [%s] is duplicated", key)
+		}
+		uniqueKeys[key] = true
+	}
+	return nil
+}
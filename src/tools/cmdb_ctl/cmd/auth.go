@@ -23,7 +23,7 @@ import (
 	"time"
 
 	"configcenter/src/ac"
-	"configcenter/src/ac/iam"
+	"configcenter/src/ac/authorizer"
 	"configcenter/src/ac/meta"
 	"configcenter/src/apimachinery"
 	"configcenter/src/apimachinery/discovery"
@@ -44,6 +44,7 @@ type authConf struct {
 	resource     string
 	resourceFile string
 	logv         int32
+	mode         string
 }
 
 func NewAuthCommand() *cobra.Command {
@@ -84,6 +85,7 @@ func (c *authConf) addFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().StringVarP(&c.resource, "resource", "r", "", "the resource for authorize")
 	cmd.PersistentFlags().StringVarP(&c.resourceFile, "rsc-file", "f", "", "the resource file path for authorize")
 	cmd.PersistentFlags().Int32VarP(&c.logv, "logV", "v", 0, "the log level of request, default request body log level is 4")
+	cmd.PersistentFlags().StringVar(&c.mode, "mode", authorizer.ModeIAM, "the authorize mode, iam or rbac")
 }
 
 type authService struct {
@@ -118,7 +120,7 @@ func newAuthService(c *authConf) (*authService, error) {
 		return nil, fmt.Errorf("new api machinery failed, err: %v", err)
 	}
 	service := &authService{
-		authorizer: iam.NewAuthorizer(clientSet),
+		authorizer: authorizer.NewAuthorizerWithMode(clientSet, c.mode),
 	}
 
 	if c.resource != "" {
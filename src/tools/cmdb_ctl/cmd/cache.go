@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+	"configcenter/src/common/util"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewCacheCommand())
+}
+
+type cacheConf struct {
+	resource   string
+	fullScan   bool
+	sampleSize int64
+	repair     bool
+}
+
+func NewCacheCommand() *cobra.Command {
+	conf := new(cacheConf)
+
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "verify or rebuild cache in cache service",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "verify the resource's cache with the data in mongodb",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opt := metadata.VerifyCacheOption{
+				Resource:   metadata.CacheResource(conf.resource),
+				FullScan:   conf.fullScan,
+				SampleSize: conf.sampleSize,
+				Repair:     conf.repair,
+			}
+			if err := opt.Validate(); err != nil {
+				return err
+			}
+			return runCacheRequest("/verify/cache", opt)
+		},
+	}
+	verifyCmd.Flags().BoolVar(&conf.fullScan, "full", false, "scan all the resources and cached keys, otherwise only verify the sampled resources")
+	verifyCmd.Flags().Int64Var(&conf.sampleSize, "sample-size", 200, "the count of resources to be sampled to verify, range is [1,1000]")
+	verifyCmd.Flags().BoolVar(&conf.repair, "repair", false, "repair the drifted cache keys")
+	cmd.AddCommand(verifyCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "rebuild",
+		Short: "drop the resource's cache and rebuild it with the data in mongodb",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opt := metadata.RebuildCacheOption{Resource: metadata.CacheResource(conf.resource)}
+			if err := opt.Resource.Validate(); err != nil {
+				return err
+			}
+			return runCacheRequest("/rebuild/cache", opt)
+		},
+	})
+
+	cmd.PersistentFlags().StringVar(&conf.resource, "resource", "host", "the cached resource, can be host or biz")
+	return cmd
+}
+
+// runCacheRequest send the request to one of the cache service, and print the result.
+func runCacheRequest(path string, opt interface{}) error {
+	server, err := getServerAddr(types.CC_MODULE_CACHESERVICE)
+	if err != nil {
+		return err
+	}
+
+	optByte, _ := json.Marshal(opt)
+	rid := util.GenerateRID()
+	fmt.Printf(">> server: %s\n>> rid: %s\n>> request options: %s\n", server, rid, string(optByte))
+
+	url := fmt.Sprintf("http://%s/cache/v3%s", server, path)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(optByte))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("HTTP_BLUEKING_SUPPLIER_ID", "0")
+	req.Header.Add("BK_User", "cmdb_tool")
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Cc_Request_Id", rid)
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := new(metadata.VerifyCacheResponse)
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+
+	if !result.Result {
+		return fmt.Errorf("request failed, err: %s", result.ErrMsg)
+	}
+
+	js, _ := json.MarshalIndent(result.Data, "", "    ")
+	fmt.Print(WithGreenColor(fmt.Sprintf("%s cache success, result: %s", result.Data.Resource, string(js))))
+	return nil
+}
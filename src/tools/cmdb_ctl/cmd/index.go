@@ -0,0 +1,173 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/types"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/dbindex"
+	dbtypes "configcenter/src/storage/dal/types"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewIndexCommand())
+}
+
+type indexConf struct {
+	tables            []string
+	dropExtra         bool
+	rebuildMismatched bool
+}
+
+func NewIndexCommand() *cobra.Command {
+	conf := new(indexConf)
+
+	cmd := &cobra.Command{
+		Use:   "index",
+		Short: "check or reconcile the db indexes with the registered ones by admin server",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "check",
+		Short: "report the missing, extra and mismatched indexes of the tables",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			diffs := make([]dbindex.TableDiff, 0)
+			opt := dbindex.CheckOption{Tables: conf.tables}
+			if err := runIndexRequest("/find/system/db/index", opt, &diffs); err != nil {
+				return err
+			}
+			printIndexDiffs(diffs)
+			return nil
+		},
+	})
+
+	reconcileCmd := &cobra.Command{
+		Use:   "reconcile",
+		Short: "create the missing indexes in background, and drop or rebuild the drifted ones on demand",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			results := make([]dbindex.ReconcileResult, 0)
+			opt := dbindex.ReconcileOption{
+				Tables:            conf.tables,
+				DropExtra:         conf.dropExtra,
+				RebuildMismatched: conf.rebuildMismatched,
+			}
+			if err := runIndexRequest("/migrate/system/db/index", opt, &results); err != nil {
+				return err
+			}
+			for _, result := range results {
+				fmt.Print(WithGreenColor(fmt.Sprintf("%s created: %v, dropped: %v, rebuilt: %v", result.Table,
+					result.Created, result.Dropped, result.Rebuilt)))
+			}
+			return nil
+		},
+	}
+	reconcileCmd.Flags().BoolVar(&conf.dropExtra, "drop-extra", false, "drop the indexes that are not registered")
+	reconcileCmd.Flags().BoolVar(&conf.rebuildMismatched, "rebuild-mismatched", false,
+		"drop and rebuild the indexes whose unique or ttl option differs from the registered one")
+	cmd.AddCommand(reconcileCmd)
+
+	cmd.PersistentFlags().StringSliceVar(&conf.tables, "tables", nil,
+		"the tables to be checked, separated by comma, all the registered tables are checked if not set")
+	return cmd
+}
+
+// runIndexRequest send the request to one of the admin server, and decode the result data into data.
+func runIndexRequest(path string, opt interface{}, data interface{}) error {
+	server, err := getServerAddr(types.CC_MODULE_MIGRATE)
+	if err != nil {
+		return err
+	}
+
+	optByte, _ := json.Marshal(opt)
+	rid := util.GenerateRID()
+	fmt.Printf(">> server: %s\n>> rid: %s\n>> request options: %s\n", server, rid, string(optByte))
+
+	url := fmt.Sprintf("http://%s/migrate/v3%s", server, path)
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(optByte))
+	if err != nil {
+		return err
+	}
+	req.Header.Add("HTTP_BLUEKING_SUPPLIER_ID", "0")
+	req.Header.Add("BK_User", "cmdb_tool")
+	req.Header.Add("Content-Type", "application/json")
+	req.Header.Add("Cc_Request_Id", rid)
+	resp, err := new(http.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	result := &struct {
+		metadata.BaseResp `json:",inline"`
+		Data              interface{} `json:"data"`
+	}{Data: data}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return err
+	}
+
+	if !result.Result {
+		return fmt.Errorf("request failed, err: %s", result.ErrMsg)
+	}
+	return nil
+}
+
+func printIndexDiffs(diffs []dbindex.TableDiff) {
+	if len(diffs) == 0 {
+		fmt.Print(WithGreenColor("all the indexes are the same with the registered ones"))
+		return
+	}
+
+	for _, diff := range diffs {
+		fmt.Print(WithBlueColor(diff.Table))
+		for _, index := range diff.Missing {
+			fmt.Print(WithRedColor("missing: " + formatIndex(index)))
+		}
+		for _, index := range diff.Extra {
+			fmt.Print(WithRedColor("extra: " + formatIndex(index)))
+		}
+		for _, mismatch := range diff.Mismatched {
+			fmt.Print(WithRedColor(fmt.Sprintf("mismatched: %s, expected: %s", formatIndex(mismatch.Actual),
+				formatIndex(mismatch.Expected))))
+		}
+	}
+}
+
+func formatIndex(index dbtypes.Index) string {
+	keys := make([]string, 0, len(index.Keys))
+	for key, order := range index.Keys {
+		keys = append(keys, fmt.Sprintf("%s:%d", key, order))
+	}
+	sort.Strings(keys)
+
+	desc := fmt.Sprintf("%s {%s}", index.Name, strings.Join(keys, ", "))
+	if index.Unique {
+		desc += " unique"
+	}
+	if index.ExpireAfterSeconds != 0 {
+		desc += fmt.Sprintf(" ttl:%ds", index.ExpireAfterSeconds)
+	}
+	return desc
+}
@@ -38,6 +38,8 @@ const (
 ./tool_ctl limiter ls
 # 配置策略，对url限制请求次数
 ./tool_ctl limiter set --rule='{"rulename":"rule1","appcode":"gse","user":"admin","ip":"","method":"POST","url":"^/api/v3/module/search/[^\\s/]+/[0-9]+/[0-9]+/?$","limit":1000,"ttl":60,"denyall":false}'
+# 配置策略，使用令牌桶算法，每个应用单独计算配额
+./tool_ctl limiter set --rule='{"rulename":"rule2","url":"^/api/v3/.*$","limit":100,"ttl":1,"algorithm":"token_bucket","quota_by":["appcode"]}'
 # 配置策略，将url直接禁掉
 ./tool_ctl limiter set --rule='{"rulename":"rule1","appcode":"gse","user":"admin","url":"^/api/v3/module/search/[^\\s/]+/[0-9]+/[0-9]+/?$","denyall":true}'
 # 获取某些策略详情
@@ -62,9 +64,15 @@ const (
 | limit    | int64  | 否   | api请求限制总次数                                            |
 | ttl      | int64  | 否   | 策略存活时间，单位为秒                                       |
 | denyall  | bool   | 否   | 是否直接禁掉请求，默认为false，为true时忽略limit和ttl参数    |
+| algorithm | string | 否  | 限流算法，可选fixed_window、sliding_window、token_bucket，默认为fixed_window |
+| quota_by | array  | 否   | 按维度单独计算配额，可选appcode、user、biz、ip，不配置时命中策略的请求共享配额 |
  
 appcode、user、ip、method、url需要至少配置一项  
-denyall配置为false的情况下，limit和ttl配置才能生效
+denyall配置为false的情况下，limit和ttl配置才能生效  
+fixed_window在每ttl秒内最多允许limit次请求，sliding_window在任意ttl秒内最多允许limit次请求，
+token_bucket允许limit次突发请求，并在ttl秒内补充limit次  
+各apiserver通过redis共享计数，当前的配额使用情况可以通过GET /api/v3/limiter/usage?rulenames=rule1,rule2查询（需要配置管理的编辑权限），
+被限流的请求返回429状态码，并通过Retry-After和X-RateLimit-*响应头返回配额信息
 ********************************************************
 		`
 )
@@ -0,0 +1,194 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"configcenter/src/apimachinery"
+	"configcenter/src/apimachinery/discovery"
+	"configcenter/src/apimachinery/util"
+	"configcenter/src/common"
+	"configcenter/src/common/backbone/service_mange/zk"
+	"configcenter/src/tools/cmdb_ctl/app/config"
+	"configcenter/src/tools/cmdb_ctl/app/modelspec"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(NewModelCommand())
+}
+
+type modelConf struct {
+	file            string
+	supplierAccount string
+	user            string
+}
+
+func NewModelCommand() *cobra.Command {
+	conf := new(modelConf)
+
+	cmd := &cobra.Command{
+		Use:   "model",
+		Short: "manage the model schema declared in a spec file",
+		Run: func(cmd *cobra.Command, args []string) {
+			_ = cmd.Help()
+		},
+	}
+
+	subCmds := make([]*cobra.Command, 0)
+
+	subCmds = append(subCmds, &cobra.Command{
+		Use:   "diff",
+		Short: "show the plan that makes the live model schema the same as the spec",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelDiffCmd(conf)
+		},
+	})
+
+	subCmds = append(subCmds, &cobra.Command{
+		Use:   "apply",
+		Short: "apply the plan that makes the live model schema the same as the spec",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runModelApplyCmd(conf)
+		},
+	})
+
+	for _, subCmd := range subCmds {
+		cmd.AddCommand(subCmd)
+	}
+	conf.addFlags(cmd)
+
+	return cmd
+}
+
+func (c *modelConf) addFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVarP(&c.file, "file", "f", "", "the yaml or json file path of the model schema spec")
+	cmd.PersistentFlags().StringVar(&c.supplierAccount, "supplier-account", "0", "the supplier account of the models")
+	cmd.PersistentFlags().StringVar(&c.user, "user", "admin", "the user who operates the models")
+}
+
+type modelService struct {
+	applier *modelspec.Applier
+	spec    *modelspec.Spec
+}
+
+func newModelService(c *modelConf) (*modelService, error) {
+	if c.file == "" {
+		return nil, errors.New("spec file must be set via file flag")
+	}
+
+	spec, err := modelspec.LoadFile(c.file)
+	if err != nil {
+		return nil, err
+	}
+
+	client := zk.NewZkClient(config.Conf.ZkAddr, 40*time.Second)
+	if err := client.Start(); err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
+	}
+	if err := client.Ping(); err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
+	}
+	serviceDiscovery, err := discovery.NewServiceDiscovery(client)
+	if err != nil {
+		return nil, fmt.Errorf("connect regdiscv [%s] failed: %v", config.Conf.ZkAddr, err)
+	}
+	apiMachineryConfig := &util.APIMachineryConfig{
+		QPS:       1000,
+		Burst:     2000,
+		TLSConfig: nil,
+	}
+	clientSet, err := apimachinery.NewApiMachinery(apiMachineryConfig, serviceDiscovery)
+	if err != nil {
+		return nil, fmt.Errorf("new api machinery failed, err: %v", err)
+	}
+
+	header := make(http.Header)
+	header.Add(common.BKHTTPOwnerID, c.supplierAccount)
+	header.Add(common.BKHTTPHeaderUser, c.user)
+	header.Add("Content-Type", "application/json")
+
+	return &modelService{
+		applier: modelspec.NewApplier(clientSet.CoreService(), header),
+		spec:    spec,
+	}, nil
+}
+
+func (s *modelService) plan(ctx context.Context) (*modelspec.Plan, error) {
+	live, err := s.applier.LoadLiveSchema(ctx, s.spec)
+	if err != nil {
+		return nil, err
+	}
+	return modelspec.BuildPlan(s.spec, live)
+}
+
+func printModelPlan(plan *modelspec.Plan) {
+	if !plan.HasChanges() {
+		_, _ = fmt.Fprint(os.Stdout, WithGreenColor("the model schema is the same as the spec"))
+		return
+	}
+	for _, step := range plan.Steps {
+		if step.Destructive {
+			_, _ = fmt.Fprint(os.Stdout, WithRedColor(step.String()))
+			continue
+		}
+		_, _ = fmt.Fprint(os.Stdout, WithBlueColor(step.String()))
+	}
+}
+
+func runModelDiffCmd(c *modelConf) error {
+	srv, err := newModelService(c)
+	if err != nil {
+		return err
+	}
+
+	plan, err := srv.plan(context.Background())
+	if err != nil {
+		return err
+	}
+	printModelPlan(plan)
+	return nil
+}
+
+func runModelApplyCmd(c *modelConf) error {
+	srv, err := newModelService(c)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	plan, err := srv.plan(ctx)
+	if err != nil {
+		return err
+	}
+	printModelPlan(plan)
+	if !plan.HasChanges() {
+		return nil
+	}
+
+	err = srv.applier.Apply(ctx, plan, func(step modelspec.Step) {
+		_, _ = fmt.Fprint(os.Stdout, WithGreenColor(fmt.Sprintf("applied: %s", step)))
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(os.Stdout, WithGreenColor(fmt.Sprintf("apply %d steps successfully", len(plan.Steps))))
+	return nil
+}
@@ -13,7 +13,11 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+
+	"configcenter/src/common/types"
+	"configcenter/src/tools/cmdb_ctl/app/config"
 )
 
 func WithRedColor(str string) string {
@@ -26,3 +30,32 @@ func WithGreenColor(str string) string {
 func WithBlueColor(str string) string {
 	return fmt.Sprintf("%c[1;40;34m>> %s %c[0m\n", 0x1B, str, 0x1B)
 }
+
+// getServerAddr get the address of one of the module's servers registered in zookeeper.
+func getServerAddr(module string) (string, error) {
+	zk, err := config.NewZkService(config.Conf.ZkAddr)
+	if err != nil {
+		fmt.Printf("new zk client failed, err: %v\n", err)
+		return "", err
+	}
+
+	zkPath := types.CC_SERV_BASEPATH + "/" + module
+	children, err := zk.ZkCli.GetChildren(zkPath)
+	if err != nil {
+		fmt.Printf("get %s server failed, err: %v\n", module, err)
+		return "", err
+	}
+
+	for _, child := range children {
+		node, err := zk.ZkCli.Get(zkPath + "/" + child)
+		if err != nil {
+			return "", err
+		}
+		svr := new(types.ServerInfo)
+		if err := json.Unmarshal([]byte(node), svr); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s:%d", svr.RegisterIP, svr.Port), nil
+	}
+	return "", fmt.Errorf("no %s server", module)
+}
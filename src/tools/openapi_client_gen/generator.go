@@ -0,0 +1,430 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"configcenter/src/common/openapi"
+)
+
+type options struct {
+	spec    string
+	pkg     string
+	client  string
+	base    string
+	tag     string
+	output  string
+	imports map[string]string
+}
+
+// method is an api method of the generated client
+type method struct {
+	Name    string
+	Comment string
+	// Verb is the request method of the apimachinery rest client, like Post
+	Verb     string
+	SubPath  string
+	PathArgs []string
+	Queries  []query
+	// BodyType is the type of the request body, the body is nil if it's empty
+	BodyType string
+	// DataType is the type of the data of the response, only the error is returned if it's empty
+	DataType string
+	// DataRef means the data is a struct type and its pointer is returned
+	DataRef   bool
+	ZeroValue string
+}
+
+type query struct {
+	Name string
+	Arg  string
+}
+
+type generator struct {
+	opt     *options
+	doc     *openapi.Document
+	imports map[string]string
+}
+
+var pathParamRegexp = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// reservedArgs is the names used in the generated methods, the arguments are renamed if they are the same
+var reservedArgs = map[string]bool{"ctx": true, "h": true, "option": true, "ret": true, "err": true,
+	"subPath": true}
+
+// generate generates the go client of the operations of the document
+func generate(doc *openapi.Document, opt *options) ([]byte, error) {
+	g := &generator{
+		opt: opt,
+		doc: doc,
+		imports: map[string]string{
+			"context":  "context",
+			"http":     "net/http",
+			"rest":     "configcenter/src/apimachinery/rest",
+			"util":     "configcenter/src/apimachinery/util",
+			"blog":     "configcenter/src/common/blog",
+			"errors":   "configcenter/src/common/errors",
+			"metadata": opt.imports["metadata"],
+		},
+	}
+
+	methods := g.methods()
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no operation is found under %s", opt.base)
+	}
+
+	// the standard packages are grouped before the others
+	stdImports, imports := make([]string, 0), make([]string, 0)
+	for _, path := range g.imports {
+		if strings.Contains(strings.Split(path, "/")[0], ".") || strings.HasPrefix(path, "configcenter/") {
+			imports = append(imports, path)
+			continue
+		}
+		stdImports = append(stdImports, path)
+	}
+	sort.Strings(stdImports)
+	sort.Strings(imports)
+
+	buf := new(bytes.Buffer)
+	err := clientTemplate.Execute(buf, map[string]interface{}{
+		"Package":    opt.pkg,
+		"Client":     opt.client,
+		"Base":       strings.TrimRight(opt.base, "/"),
+		"StdImports": stdImports,
+		"Imports":    imports,
+		"Methods":    methods,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("execute template failed, err: %v", err)
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code failed, err: %v", err)
+	}
+	return code, nil
+}
+
+// methods returns the methods of the operations under the base path, they are sorted by the path and verb
+func (g *generator) methods() []*method {
+	base := strings.TrimRight(g.opt.base, "/")
+	paths := make([]string, 0)
+	for path := range g.doc.Paths {
+		if strings.HasPrefix(path, base+"/") {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	methods := make([]*method, 0)
+	names := make(map[string]bool)
+	for _, path := range paths {
+		operations := g.doc.Paths[path].Operations()
+		verbs := make([]string, 0)
+		for verb := range operations {
+			verbs = append(verbs, verb)
+		}
+		sort.Strings(verbs)
+
+		for _, verb := range verbs {
+			operation := operations[verb]
+			if len(g.opt.tag) != 0 && !hasTag(operation, g.opt.tag) {
+				continue
+			}
+			if len(operation.OperationID) == 0 {
+				fmt.Fprintf(os.Stderr, "skip %s %s, it has no operation id\n", verb, path)
+				continue
+			}
+
+			m := g.method(verb, strings.TrimPrefix(path, base), operation)
+			for idx := 2; names[m.Name]; idx++ {
+				m.Name = fmt.Sprintf("%s%d", exportedName(operation.OperationID), idx)
+			}
+			names[m.Name] = true
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+func (g *generator) method(verb, subPath string, operation *openapi.Operation) *method {
+	m := &method{
+		Name:    exportedName(operation.OperationID),
+		Comment: fmt.Sprintf("%s %s", verb, subPath),
+		Verb:    strings.Title(strings.ToLower(verb)),
+	}
+	if len(operation.Summary) != 0 {
+		m.Comment += ", " + operation.Summary
+	}
+
+	used := make(map[string]bool)
+	m.SubPath = pathParamRegexp.ReplaceAllStringFunc(strings.Replace(subPath, "%", "%%", -1),
+		func(segment string) string {
+			m.PathArgs = append(m.PathArgs, argName(segment[1:len(segment)-1], used))
+			return "%s"
+		})
+
+	for _, param := range operation.Parameters {
+		if param.In == openapi.InQuery {
+			m.Queries = append(m.Queries, query{Name: param.Name, Arg: argName(param.Name, used)})
+		}
+	}
+
+	if operation.RequestBody != nil {
+		if content, exist := operation.RequestBody.Content["application/json"]; exist {
+			m.BodyType, _ = g.goType(content.Schema, true)
+		}
+	}
+
+	if response, exist := operation.Responses["200"]; exist {
+		if content, exist := response.Content["application/json"]; exist && content.Schema != nil {
+			data := content.Schema.Properties["data"]
+			if data != nil && (data.Ref != "" || data.Type != "") {
+				m.DataType, m.DataRef = g.goType(data, false)
+				m.ZeroValue = zeroValue(m.DataType, m.DataRef)
+			}
+		}
+	}
+	return m
+}
+
+// goType returns the go type of the schema, the component schemas of the known packages are their go types,
+// returns if the type is a struct type.
+func (g *generator) goType(schema *openapi.Schema, pointer bool) (string, bool) {
+	if schema == nil {
+		return "interface{}", false
+	}
+
+	if schema.Ref != "" {
+		name := schema.RefName()
+		if idx := strings.LastIndex(name, "."); idx > 0 {
+			if path, exist := g.opt.imports[name[:idx]]; exist {
+				g.imports[name[:idx]] = path
+				if pointer {
+					return "*" + name, true
+				}
+				return name, true
+			}
+		}
+		return "map[string]interface{}", false
+	}
+
+	switch schema.Type {
+	case openapi.TypeArray:
+		item, _ := g.goType(schema.Items, false)
+		return "[]" + item, false
+	case openapi.TypeObject:
+		if schema.AdditionalProperties != nil {
+			value, _ := g.goType(schema.AdditionalProperties, false)
+			return "map[string]" + value, false
+		}
+		return "map[string]interface{}", false
+	case openapi.TypeString:
+		switch schema.Format {
+		case "date-time":
+			g.imports["time"] = "time"
+			return "time.Time", false
+		case "byte":
+			return "[]byte", false
+		}
+		return "string", false
+	case openapi.TypeInteger:
+		if schema.Format == "int32" {
+			return "int32", false
+		}
+		return "int64", false
+	case openapi.TypeNumber:
+		if schema.Format == "float" {
+			return "float32", false
+		}
+		return "float64", false
+	case openapi.TypeBoolean:
+		return "bool", false
+	default:
+		return "interface{}", false
+	}
+}
+
+func zeroValue(goType string, ref bool) string {
+	switch {
+	case ref:
+		return "nil"
+	case goType == "string":
+		return `""`
+	case goType == "bool":
+		return "false"
+	case goType == "time.Time":
+		return "time.Time{}"
+	case strings.HasPrefix(goType, "int") || strings.HasPrefix(goType, "float"):
+		return "0"
+	default:
+		return "nil"
+	}
+}
+
+func hasTag(operation *openapi.Operation, tag string) bool {
+	for _, one := range operation.Tags {
+		if one == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// exportedName returns the exported go name of the operation id
+func exportedName(operationID string) string {
+	name := camelCase(operationID)
+	if name == "" {
+		return name
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToUpper(runes[0])
+	return string(runes)
+}
+
+// argName returns the unexported unique argument name of the parameter, like bkBizID of bk_biz_id
+func argName(param string, used map[string]bool) string {
+	name := camelCase(param)
+	if name == "" {
+		name = "param"
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	name = string(runes)
+
+	if token.IsKeyword(name) || reservedArgs[name] {
+		name += "Param"
+	}
+	candidate := name
+	for idx := 2; used[candidate]; idx++ {
+		candidate = fmt.Sprintf("%s%d", name, idx)
+	}
+	used[candidate] = true
+	return candidate
+}
+
+// camelCase converts the snake case name to the camel case name, id is converted to ID like the go names
+func camelCase(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for idx, part := range parts {
+		if idx == 0 {
+			continue
+		}
+		if strings.ToLower(part) == "id" {
+			parts[idx] = "ID"
+			continue
+		}
+		parts[idx] = strings.ToUpper(part[:1]) + part[1:]
+	}
+	name = strings.Join(parts, "")
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "P" + name
+	}
+	return name
+}
+
+// licenseHeader is the license header of the generated files
+const licenseHeader = `/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */`
+
+var clientTemplate = template.Must(template.New("client").Parse(licenseHeader + `
+
+// Code generated by openapi_client_gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	"{{.}}"
+{{- end}}
+{{range .Imports}}
+	"{{.}}"
+{{- end}}
+)
+
+type {{.Client}}ClientInterface interface {
+{{- range .Methods}}
+	{{template "signature" .}}
+{{- end}}
+}
+
+func New{{.Client}}ClientInterface(c *util.Capability) {{.Client}}ClientInterface {
+	return &client{
+		client: rest.NewRESTClient(c, "{{.Base}}"),
+	}
+}
+
+type client struct {
+	client rest.ClientInterface
+}
+{{range .Methods}}
+// {{.Name}} {{.Comment}}
+func (c *client) {{template "signature" .}} {
+	ret := new(struct {
+		metadata.BaseResp ` + "`json:\",inline\"`" + `
+{{- if .DataType}}
+		Data {{.DataType}} ` + "`json:\"data\"`" + `
+{{- end}}
+	})
+	subPath := "{{.SubPath}}"
+
+	err := c.client.{{.Verb}}().
+		WithContext(ctx).
+		Body({{if .BodyType}}option{{else}}nil{{end}}).
+		SubResourcef(subPath{{range .PathArgs}}, {{.}}{{end}}).
+{{- range .Queries}}
+		WithParam("{{.Name}}", {{.Arg}}).
+{{- end}}
+		WithHeaders(h).
+		Do().
+		Into(ret)
+
+	if err != nil {
+		blog.Errorf("{{.Name}} failed, http request failed, err: %+v", err)
+		return {{if .DataType}}{{.ZeroValue}}, {{end}}errors.CCHttpError
+	}
+	if ret.CCError() != nil {
+		return {{if .DataType}}{{.ZeroValue}}, {{end}}ret.CCError()
+	}
+
+	return {{if .DataType}}{{if .DataRef}}&{{end}}ret.Data, {{end}}nil
+}
+{{end}}
+{{- define "signature" -}}
+{{.Name}}(ctx context.Context, h http.Header
+{{- range .PathArgs}}, {{.}} string{{end}}
+{{- range .Queries}}, {{.Arg}} string{{end}}
+{{- if .BodyType}}, option {{.BodyType}}{{end}}) (
+{{- if .DataType}}{{if .DataRef}}*{{end}}{{.DataType}}, {{end}}errors.CCErrorCoder)
+{{- end}}`))
@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// openapi_client_gen generates the go client of the apis in an OpenAPI document of cmdb, the generated client
+// is the same as the apimachinery clients, so it can be used with the capability of the apimachinery.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"configcenter/src/common/openapi"
+)
+
+// importsFlag is the go packages of the component schemas, like metadata=configcenter/src/common/metadata
+type importsFlag map[string]string
+
+func (f importsFlag) String() string {
+	pairs := make([]string, 0)
+	for name, path := range f {
+		pairs = append(pairs, name+"="+path)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f importsFlag) Set(value string) error {
+	pair := strings.SplitN(value, "=", 2)
+	if len(pair) != 2 || pair[0] == "" || pair[1] == "" {
+		return fmt.Errorf("invalid import %s, the format is name=path", value)
+	}
+	f[pair[0]] = pair[1]
+	return nil
+}
+
+func main() {
+	imports := importsFlag{
+		"metadata":     "configcenter/src/common/metadata",
+		"watch":        "configcenter/src/common/watch",
+		"mapstr":       "configcenter/src/common/mapstr",
+		"querybuilder": "configcenter/src/common/querybuilder",
+	}
+	opt := new(options)
+	flag.StringVar(&opt.spec, "spec", "", "the OpenAPI document file, - means the stdin")
+	flag.StringVar(&opt.pkg, "package", "", "the package name of the generated client")
+	flag.StringVar(&opt.client, "client", "", "the client name, like EventServer")
+	flag.StringVar(&opt.base, "base", "", "the base path of the client, like /event/v3, other paths are skipped")
+	flag.StringVar(&opt.tag, "tag", "", "only the operations with the tag are generated if it's set")
+	flag.StringVar(&opt.output, "output", "", "the generated go file, the stdout is used if it's not set")
+	flag.Var(imports, "import", "the go package of the component schemas, like metadata=configcenter/src/common/metadata")
+	flag.Parse()
+	opt.imports = imports
+
+	if err := run(opt); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(opt *options) error {
+	if opt.spec == "" || opt.pkg == "" || opt.client == "" || opt.base == "" {
+		return fmt.Errorf("spec, package, client and base must be set")
+	}
+
+	var content []byte
+	var err error
+	if opt.spec == "-" {
+		content, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		content, err = ioutil.ReadFile(opt.spec)
+	}
+	if err != nil {
+		return fmt.Errorf("read spec failed, err: %v", err)
+	}
+
+	doc := new(openapi.Document)
+	if err := json.Unmarshal(content, doc); err != nil {
+		return fmt.Errorf("unmarshal spec failed, err: %v", err)
+	}
+
+	code, err := generate(doc, opt)
+	if err != nil {
+		return err
+	}
+
+	if opt.output == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return ioutil.WriteFile(opt.output, code, 0644)
+}
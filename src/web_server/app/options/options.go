@@ -13,6 +13,7 @@
 package options
 
 import (
+	"configcenter/src/common"
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/redis"
 
@@ -76,6 +77,10 @@ type Config struct {
 	ConfigMap    map[string]string
 	AuthCenter   AppInfo
 	DisableOperationStatistic bool
+	// ExportReadPreference the db read preference of the export requests
+	ExportReadPreference common.ReadPreferenceMode
+	// SystemToken the shared secret that proves the requests to the api server come from the web server
+	SystemToken string
 }
 
 type AppInfo struct {
@@ -22,8 +22,10 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/resource/esb"
 	"configcenter/src/common/types"
+	"configcenter/src/common/util"
 	"configcenter/src/storage/dal/redis"
 	"configcenter/src/web_server/app/options"
 	"configcenter/src/web_server/logics"
@@ -123,6 +125,7 @@ func (w *WebServer) onServerConfigUpdate(previous, current cc.ProcessConfig) {
 	w.Config.Site.ResourcesPath, _ = cc.String("webServer.site.resourcesPath")
 	w.Config.Site.BkLoginUrl, _ = cc.String("webServer.site.bkLoginUrl")
 	w.Config.Site.AppCode, _ = cc.String("webServer.site.appCode")
+	w.Config.SystemToken, _ = cc.String("apiServer.tenant.systemToken")
 	w.Config.Site.CheckUrl, _ = cc.String("webServer.site.checkUrl")
 
 	authscheme, err := cc.String("webServer.site.authscheme")
@@ -163,6 +166,17 @@ func (w *WebServer) onServerConfigUpdate(previous, current cc.ProcessConfig) {
 	}
 	w.Config.DisableOperationStatistic, _ = cc.Bool("operationServer.disableOperationStatistic")
 
+	// exports read from the secondaries by default to reduce the load of the primary
+	w.Config.ExportReadPreference = common.SecondaryPreferredMode
+	if readPreference, err := cc.String("webServer.export.readPreference"); err == nil {
+		mode, err := util.ParseReadPreference(readPreference)
+		if err != nil {
+			blog.Errorf("parse webServer.export.readPreference config failed, use secondaryPreferred instead, err: %v", err)
+		} else {
+			w.Config.ExportReadPreference = mode
+		}
+	}
+
 }
 
 //Stop the ccapi server
@@ -295,6 +295,27 @@ func getDataFromByExcelRow(ctx context.Context, row *xlsx.Row, rowIndex int, fie
 			} else {
 				blog.Debug("get excel cell value error, field:%s, value:%s, error:%s, rid: %s", fieldName, host[fieldName], "not a valid organization type", rid)
 			}
+		case common.FieldTypeInstRef:
+			// convert referenced instance ids, eg: "1,2,3" => [1,2,3], "1" => 1
+			refItems := strings.Split(util.GetStrByInterface(host[fieldName]), ",")
+			refSlice := make([]int64, 0)
+			for _, v := range refItems {
+				if strings.TrimSpace(v) == "" {
+					continue
+				}
+				refID, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+				if err != nil {
+					blog.Debug("get excel cell value error, field:%s, value:%s, error:%s, rid: %s", fieldName, host[fieldName], "not a valid inst_ref type", rid)
+					refSlice = nil
+					break
+				}
+				refSlice = append(refSlice, refID)
+			}
+			if len(refSlice) == 1 {
+				host[fieldName] = refSlice[0]
+			} else if refSlice != nil {
+				host[fieldName] = refSlice
+			}
 		case common.FieldTypeUser:
 			// convert userNames,  eg: " admin(admin),xiaoming(小明 ),leo(li hong),  " => "admin,xiaoming,leo"
 			userNames := util.GetStrByInterface(host[fieldName])
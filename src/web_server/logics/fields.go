@@ -283,6 +283,7 @@ func getPropertyTypeAliasName(propertyType string, defLang lang.DefaultCCLanguag
 	case common.FieldTypeOrganization:
 	case common.FieldTypeBool:
 	case common.FieldTypeTimeZone:
+	case common.FieldTypeInstRef:
 
 	}
 	if "" == name {
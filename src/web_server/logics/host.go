@@ -32,9 +32,10 @@ import (
 	"github.com/rentiansheng/xlsx"
 )
 
-// GetHostData get host data from excel
+// GetHostData get host data from excel, when lastHostID is set, the hosts matching the export condition
+// are paged by the host id greater than it instead of the page start.
 func (lgc *Logics) GetHostData(appID int64, hostIDStr string, hostFields []string, exportCondStr string,
-	header http.Header, defLang lang.DefaultCCLanguageIf) ([]mapstr.MapStr, error) {
+	lastHostID int64, header http.Header, defLang lang.DefaultCCLanguageIf) ([]mapstr.MapStr, error) {
 	rid := util.GetHTTPCCRequestID(header)
 	defErr := lgc.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
 
@@ -114,6 +115,13 @@ func (lgc *Logics) GetHostData(appID int64, hostIDStr string, hostFields []strin
 		if exportCond.Page.Limit <= 0 || exportCond.Page.Limit > common.BKMaxExportLimit {
 			return nil, errors.New(defLang.Languagef("export_page_limit_err", common.BKMaxExportLimit))
 		}
+		// page the hosts by the host id instead of skipping the previous pages, so that a deep page is as
+		// cheap as the first one, and no host is missed or duplicated when hosts change between the pages.
+		if lastHostID > 0 {
+			exportCond.Page.Sort = common.BKHostIDField
+			exportCond.Page.Start = 0
+			exportCond.Condition = appendHostIDAfterCond(exportCond.Condition, lastHostID)
+		}
 		sHostCond["ip"] = exportCond.Ip
 		sHostCond["page"] = exportCond.Page
 
@@ -142,6 +150,41 @@ func (lgc *Logics) GetHostData(appID int64, hostIDStr string, hostFields []strin
 	return result.Data.Info, nil
 }
 
+// appendHostIDAfterCond add the host id greater than lastHostID condition to the host condition
+func appendHostIDAfterCond(conds []metadata.SearchCondition, lastHostID int64) []metadata.SearchCondition {
+	idCond := metadata.ConditionItem{
+		Field:    common.BKHostIDField,
+		Operator: common.BKDBGT,
+		Value:    lastHostID,
+	}
+
+	for idx, cond := range conds {
+		if cond.ObjectID == common.BKInnerObjIDHost {
+			conds[idx].Condition = append(conds[idx].Condition, idCond)
+			return conds
+		}
+	}
+
+	return append(conds, metadata.SearchCondition{
+		ObjectID:  common.BKInnerObjIDHost,
+		Fields:    make([]string, 0),
+		Condition: []metadata.ConditionItem{idCond},
+	})
+}
+
+// GetExportLastHostID get the host id of the last exported host, which is used to get the next page of hosts
+func GetExportLastHostID(hostInfo []mapstr.MapStr) (int64, error) {
+	if len(hostInfo) == 0 {
+		return 0, nil
+	}
+
+	host, err := hostInfo[len(hostInfo)-1].MapStr(common.BKInnerObjIDHost)
+	if err != nil {
+		return 0, err
+	}
+	return host.Int64(common.BKHostIDField)
+}
+
 // GetImportHosts get import hosts
 // return inst array data, errmsg collection, error
 func (lgc *Logics) GetImportHosts(f *xlsx.File, header http.Header, defLang lang.DefaultCCLanguageIf, modelBizID int64) (map[int]map[string]interface{}, []string, error) {
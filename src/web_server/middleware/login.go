@@ -56,9 +56,13 @@ func ValidLogin(config options.Config, disc discovery.DiscoveryInterface) gin.Ha
 			userName, _ := session.Get(common.WEBSessionUinKey).(string)
 			ownerID, _ := session.Get(common.WEBSessionOwnerUinKey).(string)
 			language := webCommon.GetLanguageByHTTPRequest(c)
-			c.Request.Header.Add(common.BKHTTPHeaderUser, userName)
-			c.Request.Header.Add(common.BKHTTPLanguage, language)
-			c.Request.Header.Add(common.BKHTTPOwnerID, ownerID)
+			c.Request.Header.Set(common.BKHTTPHeaderUser, userName)
+			c.Request.Header.Set(common.BKHTTPLanguage, language)
+			c.Request.Header.Set(common.BKHTTPOwnerID, ownerID)
+			// the supplier account is the login user's, the web server requests the api server as a system app
+			c.Request.Header.Del(common.BKHTTPOwner)
+			c.Request.Header.Set(common.BKHTTPRequestAppCode, config.Site.AppCode)
+			c.Request.Header.Set(common.BKHTTPSystemToken, config.SystemToken)
 
 			if path1 == "api" {
 				servers, err := disc.ApiServer().GetServers()
@@ -0,0 +1,214 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package external contains the common functions of the login plugins that authenticate users with an
+// external login system, such as ldap and openid connect.
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	cc "configcenter/src/common/backbone/configcenter"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/gin-gonic/gin"
+	"github.com/holmeswang/contrib/sessions"
+)
+
+// sessionLoginUserKey is the session key of the user that is verified by the external login system
+const sessionLoginUserKey = "external_login_user"
+
+// LoginExpireSeconds is how long the login of a user is valid
+const LoginExpireSeconds = 24 * 60 * 60
+
+type sessionUser struct {
+	UserName  string `json:"username"`
+	ChName    string `json:"chname"`
+	Phone     string `json:"phone"`
+	Email     string `json:"email"`
+	LoginTime int64  `json:"login_time"`
+}
+
+// SaveLoginUser save the user that is verified by the external login system to the session
+func SaveLoginUser(c *gin.Context, user *metadata.LoginUserInfo) error {
+	value, err := json.Marshal(&sessionUser{
+		UserName:  user.UserName,
+		ChName:    user.ChName,
+		Phone:     user.Phone,
+		Email:     user.Email,
+		LoginTime: time.Now().Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	session := sessions.Default(c)
+	session.Set(sessionLoginUserKey, string(value))
+	return session.Save()
+}
+
+// LoadLoginUser get the user that is verified by the external login system from the session,
+// returns false if the user has not logged in or the login is expired.
+func LoadLoginUser(c *gin.Context) (*metadata.LoginUserInfo, bool) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	session := sessions.Default(c)
+
+	value, ok := session.Get(sessionLoginUserKey).(string)
+	if !ok || value == "" {
+		blog.V(5).Infof("external login user not found in session, rid: %s", rid)
+		return nil, false
+	}
+
+	user := new(sessionUser)
+	if err := json.Unmarshal([]byte(value), user); err != nil {
+		blog.Errorf("unmarshal external login user %s failed, err: %v, rid: %s", value, err, rid)
+		return nil, false
+	}
+
+	if time.Now().Unix()-user.LoginTime >= LoginExpireSeconds {
+		blog.V(5).Infof("login of user %s is expired, rid: %s", user.UserName, rid)
+		return nil, false
+	}
+
+	return &metadata.LoginUserInfo{
+		UserName: user.UserName,
+		ChName:   user.ChName,
+		Phone:    user.Phone,
+		Email:    user.Email,
+		OnwerUin: common.BKDefaultOwnerID,
+	}, true
+}
+
+// GetSiteLoginURL returns the login page url of cmdb site, the user is redirected to the current page after login
+func GetSiteLoginURL(c *gin.Context, input *metadata.LogoutRequestParams) string {
+	return fmt.Sprintf("%s/login?c_url=%s%s", GetSiteURL(input), GetSiteURL(input), c.Request.URL.String())
+}
+
+// GetSiteURL returns the url of cmdb site without the trailing slash
+func GetSiteURL(input *metadata.LogoutRequestParams) string {
+	var siteURL string
+	var err error
+	if input != nil && common.LogoutHTTPSchemeHTTPS == input.HTTPScheme {
+		siteURL, err = cc.String("webServer.site.httpsDomainUrl")
+	} else {
+		siteURL, err = cc.String("webServer.site.domainUrl")
+	}
+	if err != nil {
+		siteURL = ""
+	}
+	return strings.TrimRight(siteURL, "/")
+}
+
+// String get the string config, returns the default value if it is not set
+func String(key, defaultValue string) string {
+	value, err := cc.String(key)
+	if err != nil || value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// Bool get the bool config, returns false if it is not set
+func Bool(key string) bool {
+	value, err := cc.Bool(key)
+	if err != nil {
+		return false
+	}
+	return value
+}
+
+// StringSlice get the comma separated string config
+func StringSlice(key string) []string {
+	return SplitList(String(key, ""))
+}
+
+// SplitList split the comma separated string, the empty items are dropped
+func SplitList(value string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ParseGroupMapping parse the group mapping config in the format of
+// "external_group1:cmdb_group1,external_group2:cmdb_group2", the external group name can contain ':'
+// so that the last ':' is used to separate the group names.
+func ParseGroupMapping(value string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, item := range SplitList(value) {
+		index := strings.LastIndex(item, ":")
+		if index <= 0 || index == len(item)-1 {
+			return nil, fmt.Errorf("group mapping %s is invalid", item)
+		}
+		mapping[strings.TrimSpace(item[:index])] = strings.TrimSpace(item[index+1:])
+	}
+	return mapping, nil
+}
+
+// GroupPolicy is the group related configs of an external login system
+type GroupPolicy struct {
+	// AllowedGroups is the external groups that are allowed to login, all users are allowed if it's empty
+	AllowedGroups []string
+	// Mapping is the external group to cmdb user group mapping
+	Mapping map[string]string
+}
+
+// LoadGroupPolicy load the group policy configs with the config key prefix, such as webServer.ldap
+func LoadGroupPolicy(prefix string) (*GroupPolicy, error) {
+	mapping, err := ParseGroupMapping(String(prefix+".groupMapping", ""))
+	if err != nil {
+		return nil, err
+	}
+	return &GroupPolicy{
+		AllowedGroups: StringSlice(prefix + ".allowedGroups"),
+		Mapping:       mapping,
+	}, nil
+}
+
+// Apply check if the user with the external groups is allowed to login, and set the cmdb user groups
+// that the user belongs to.
+func (p *GroupPolicy) Apply(user *metadata.LoginUserInfo, groups []string) error {
+	if len(p.AllowedGroups) > 0 {
+		allowed := false
+		for _, group := range groups {
+			if util.InStrArr(p.AllowedGroups, group) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return fmt.Errorf("user %s is not in any of the allowed groups", user.UserName)
+		}
+	}
+
+	user.Groups = make([]string, 0)
+	user.ManagedGroups = make([]string, 0)
+	for external, group := range p.Mapping {
+		if !util.InStrArr(user.ManagedGroups, group) {
+			user.ManagedGroups = append(user.ManagedGroups, group)
+		}
+		if util.InStrArr(groups, external) && !util.InStrArr(user.Groups, group) {
+			user.Groups = append(user.Groups, group)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// the BER identifier classes
+const (
+	classUniversal   byte = 0x00
+	classApplication byte = 0x40
+	classContext     byte = 0x80
+)
+
+const typeConstructed byte = 0x20
+
+// the universal tags used by ldap
+const (
+	tagBoolean     byte = 0x01
+	tagInteger     byte = 0x02
+	tagOctetString byte = 0x04
+	tagEnumerated  byte = 0x0a
+	tagSequence    byte = 0x10
+	tagSet         byte = 0x11
+)
+
+// maxPacketLength limits the length of a packet received from the server
+const maxPacketLength = 16 << 20
+
+// packet is a BER encoded element, it only supports the low tag numbers which are all that ldap uses.
+// a constructed packet has children, a primitive packet has value.
+type packet struct {
+	class       byte
+	constructed bool
+	tag         byte
+	value       []byte
+	children    []*packet
+}
+
+func newConstructed(class, tag byte, children ...*packet) *packet {
+	return &packet{class: class, constructed: true, tag: tag, children: children}
+}
+
+func newSequence(children ...*packet) *packet {
+	return newConstructed(classUniversal, tagSequence, children...)
+}
+
+func newString(class, tag byte, value string) *packet {
+	return &packet{class: class, tag: tag, value: []byte(value)}
+}
+
+func newOctetString(value string) *packet {
+	return newString(classUniversal, tagOctetString, value)
+}
+
+func newInteger(class, tag byte, value int64) *packet {
+	return &packet{class: class, tag: tag, value: encodeInteger(value)}
+}
+
+func newBoolean(value bool) *packet {
+	if value {
+		return &packet{class: classUniversal, tag: tagBoolean, value: []byte{0xff}}
+	}
+	return &packet{class: classUniversal, tag: tagBoolean, value: []byte{0x00}}
+}
+
+// is check if the packet has the class and tag
+func (p *packet) is(class, tag byte) bool {
+	return p.class == class && p.tag == tag
+}
+
+func (p *packet) str() string {
+	return string(p.value)
+}
+
+func (p *packet) int() (int64, error) {
+	if len(p.value) == 0 || len(p.value) > 8 {
+		return 0, fmt.Errorf("invalid integer length %d", len(p.value))
+	}
+	// sign extend with the first byte
+	var value int64
+	if p.value[0]&0x80 != 0 {
+		value = -1
+	}
+	for _, b := range p.value {
+		value = value<<8 | int64(b)
+	}
+	return value, nil
+}
+
+func (p *packet) bool() bool {
+	return len(p.value) > 0 && p.value[0] != 0
+}
+
+func (p *packet) encode() []byte {
+	value := p.value
+	if p.constructed {
+		value = make([]byte, 0)
+		for _, child := range p.children {
+			value = append(value, child.encode()...)
+		}
+	}
+
+	identifier := p.class | p.tag
+	if p.constructed {
+		identifier |= typeConstructed
+	}
+	data := append([]byte{identifier}, encodeLength(len(value))...)
+	return append(data, value...)
+}
+
+func encodeLength(length int) []byte {
+	if length < 0x80 {
+		return []byte{byte(length)}
+	}
+	bytes := make([]byte, 0)
+	for l := length; l > 0; l >>= 8 {
+		bytes = append([]byte{byte(l)}, bytes...)
+	}
+	return append([]byte{0x80 | byte(len(bytes))}, bytes...)
+}
+
+// encodeInteger encode the integer with the minimum two's complement bytes
+func encodeInteger(value int64) []byte {
+	bytes := make([]byte, 8)
+	for i := 7; i >= 0; i-- {
+		bytes[i] = byte(value)
+		value >>= 8
+	}
+	start := 0
+	for start < 7 {
+		if bytes[start] == 0x00 && bytes[start+1]&0x80 == 0 {
+			start++
+			continue
+		}
+		if bytes[start] == 0xff && bytes[start+1]&0x80 != 0 {
+			start++
+			continue
+		}
+		break
+	}
+	return bytes[start:]
+}
+
+// readPacket read a whole packet from the reader
+func readPacket(reader io.Reader) (*packet, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, err
+	}
+
+	length := int(header[1])
+	lengthBytes := make([]byte, 0)
+	if header[1]&0x80 != 0 {
+		count := int(header[1] & 0x7f)
+		if count == 0 || count > 4 {
+			return nil, fmt.Errorf("unsupported length bytes count %d", count)
+		}
+		lengthBytes = make([]byte, count)
+		if _, err := io.ReadFull(reader, lengthBytes); err != nil {
+			return nil, err
+		}
+		length = 0
+		for _, b := range lengthBytes {
+			length = length<<8 | int(b)
+		}
+	}
+	if length > maxPacketLength {
+		return nil, fmt.Errorf("packet length %d exceeds the limit", length)
+	}
+
+	data := make([]byte, 0, 2+len(lengthBytes)+length)
+	data = append(data, header...)
+	data = append(data, lengthBytes...)
+	value := make([]byte, length)
+	if _, err := io.ReadFull(reader, value); err != nil {
+		return nil, err
+	}
+	data = append(data, value...)
+
+	p, _, err := decodePacket(data)
+	return p, err
+}
+
+// decodePacket decode a packet from the data, returns the packet and the count of bytes it consumes
+func decodePacket(data []byte) (*packet, int, error) {
+	if len(data) < 2 {
+		return nil, 0, errors.New("packet is truncated")
+	}
+
+	identifier := data[0]
+	if identifier&0x1f == 0x1f {
+		return nil, 0, errors.New("high tag number is not supported")
+	}
+	p := &packet{
+		class:       identifier & 0xc0,
+		constructed: identifier&typeConstructed != 0,
+		tag:         identifier & 0x1f,
+	}
+
+	offset := 2
+	length := int(data[1])
+	if data[1]&0x80 != 0 {
+		count := int(data[1] & 0x7f)
+		if count == 0 || count > 4 {
+			return nil, 0, fmt.Errorf("unsupported length bytes count %d", count)
+		}
+		if len(data) < offset+count {
+			return nil, 0, errors.New("packet is truncated")
+		}
+		length = 0
+		for _, b := range data[offset : offset+count] {
+			length = length<<8 | int(b)
+		}
+		offset += count
+	}
+	if length < 0 || len(data) < offset+length {
+		return nil, 0, errors.New("packet is truncated")
+	}
+
+	value := data[offset : offset+length]
+	if !p.constructed {
+		p.value = value
+		return p, offset + length, nil
+	}
+
+	for len(value) > 0 {
+		child, consumed, err := decodePacket(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		p.children = append(p.children, child)
+		value = value[consumed:]
+	}
+	return p, offset + length, nil
+}
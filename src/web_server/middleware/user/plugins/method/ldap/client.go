@@ -0,0 +1,288 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the application tags of the ldap protocol operations, defined in RFC 4511
+const (
+	appBindRequest       byte = 0
+	appBindResponse      byte = 1
+	appUnbindRequest     byte = 2
+	appSearchRequest     byte = 3
+	appSearchResultEntry byte = 4
+	appSearchResultDone  byte = 5
+	appSearchResultRef   byte = 19
+)
+
+// the ldap result codes that are handled
+const (
+	resultSuccess            int64 = 0
+	resultSizeLimitExceeded  int64 = 4
+	resultInvalidCredentials int64 = 49
+)
+
+// scopeWholeSubtree is the search scope of the base object and all its subordinates
+const scopeWholeSubtree int64 = 2
+
+const ldapVersion = 3
+
+// resultError is the error returned by the ldap server
+type resultError struct {
+	Code    int64
+	Message string
+}
+
+func (e *resultError) Error() string {
+	return fmt.Sprintf("ldap result code %d: %s", e.Code, e.Message)
+}
+
+// isResultCode check if the error is an ldap result error of the code
+func isResultCode(err error, code int64) bool {
+	resultErr, ok := err.(*resultError)
+	return ok && resultErr.Code == code
+}
+
+// entry is an entry returned by a search, the attribute names are lower cased
+type entry struct {
+	DN         string
+	Attributes map[string][]string
+}
+
+// get returns the first value of the attribute
+func (e *entry) get(attr string) string {
+	values := e.Attributes[strings.ToLower(attr)]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (e *entry) values(attr string) []string {
+	return e.Attributes[strings.ToLower(attr)]
+}
+
+// searchRequest is the parameters of a search operation
+type searchRequest struct {
+	BaseDN     string
+	Scope      int64
+	Filter     string
+	Attributes []string
+	SizeLimit  int64
+}
+
+// conn is a minimal ldap v3 client connection which supports the simple bind and search operations
+type conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+	msgID   int64
+}
+
+// dial connect to the ldap server of the url, the url scheme is ldap or ldaps
+func dial(rawURL string, timeout time.Duration, insecureSkipVerify bool) (*conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse ldap url %s failed, err: %v", rawURL, err)
+	}
+
+	host := u.Host
+	dialer := &net.Dialer{Timeout: timeout}
+	var netConn net.Conn
+	switch strings.ToLower(u.Scheme) {
+	case "ldap":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "389")
+		}
+		netConn, err = dialer.Dial("tcp", host)
+	case "ldaps":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "636")
+		}
+		netConn, err = tls.DialWithDialer(dialer, "tcp", host, &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: insecureSkipVerify,
+		})
+	default:
+		return nil, fmt.Errorf("ldap url scheme %s is not supported", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to ldap server %s failed, err: %v", host, err)
+	}
+
+	return &conn{conn: netConn, reader: bufio.NewReader(netConn), timeout: timeout}, nil
+}
+
+// close unbind and close the connection
+func (c *conn) close() {
+	c.msgID++
+	msg := newSequence(newInteger(classUniversal, tagInteger, c.msgID),
+		&packet{class: classApplication, tag: appUnbindRequest})
+	_ = c.conn.SetDeadline(time.Now().Add(c.timeout))
+	_, _ = c.conn.Write(msg.encode())
+	_ = c.conn.Close()
+}
+
+// send a request operation and returns the message id
+func (c *conn) send(op *packet) (int64, error) {
+	c.msgID++
+	msg := newSequence(newInteger(classUniversal, tagInteger, c.msgID), op)
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	if _, err := c.conn.Write(msg.encode()); err != nil {
+		return 0, fmt.Errorf("send ldap request failed, err: %v", err)
+	}
+	return c.msgID, nil
+}
+
+// receive the response operation of the message id
+func (c *conn) receive(msgID int64) (*packet, error) {
+	for {
+		msg, err := readPacket(c.reader)
+		if err != nil {
+			return nil, fmt.Errorf("read ldap response failed, err: %v", err)
+		}
+		if len(msg.children) < 2 {
+			return nil, fmt.Errorf("ldap response is invalid")
+		}
+		id, err := msg.children[0].int()
+		if err != nil {
+			return nil, fmt.Errorf("ldap response message id is invalid, err: %v", err)
+		}
+		// ignore the unsolicited notifications and the responses of the abandoned requests
+		if id != msgID {
+			continue
+		}
+		return msg.children[1], nil
+	}
+}
+
+// parseResult parse the LDAPResult of a response
+func parseResult(op *packet) error {
+	if len(op.children) < 3 {
+		return fmt.Errorf("ldap result is invalid")
+	}
+	code, err := op.children[0].int()
+	if err != nil {
+		return fmt.Errorf("ldap result code is invalid, err: %v", err)
+	}
+	if code != resultSuccess {
+		return &resultError{Code: code, Message: op.children[2].str()}
+	}
+	return nil
+}
+
+// bind authenticate the connection with the dn and password using the simple bind
+func (c *conn) bind(dn, password string) error {
+	op := newConstructed(classApplication, appBindRequest,
+		newInteger(classUniversal, tagInteger, ldapVersion),
+		newOctetString(dn),
+		newString(classContext, 0, password),
+	)
+
+	msgID, err := c.send(op)
+	if err != nil {
+		return err
+	}
+	resp, err := c.receive(msgID)
+	if err != nil {
+		return err
+	}
+	if !resp.is(classApplication, appBindResponse) {
+		return fmt.Errorf("unexpected ldap bind response tag %d", resp.tag)
+	}
+	return parseResult(resp)
+}
+
+// search the entries, the entries that are returned before the size limit exceeded are returned with the error
+func (c *conn) search(req *searchRequest) ([]*entry, error) {
+	filter, err := compileFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	attributes := newSequence()
+	for _, attr := range req.Attributes {
+		if attr != "" {
+			attributes.children = append(attributes.children, newOctetString(attr))
+		}
+	}
+
+	op := newConstructed(classApplication, appSearchRequest,
+		newOctetString(req.BaseDN),
+		newInteger(classUniversal, tagEnumerated, req.Scope),
+		// never dereference aliases
+		newInteger(classUniversal, tagEnumerated, 0),
+		newInteger(classUniversal, tagInteger, req.SizeLimit),
+		newInteger(classUniversal, tagInteger, int64(c.timeout/time.Second)),
+		newBoolean(false),
+		filter,
+		attributes,
+	)
+
+	msgID, err := c.send(op)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*entry, 0)
+	for {
+		resp, err := c.receive(msgID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case resp.is(classApplication, appSearchResultEntry):
+			e, err := parseEntry(resp)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, e)
+		case resp.is(classApplication, appSearchResultRef):
+			// referrals are not followed
+			continue
+		case resp.is(classApplication, appSearchResultDone):
+			return entries, parseResult(resp)
+		default:
+			return nil, fmt.Errorf("unexpected ldap search response tag %d", resp.tag)
+		}
+	}
+}
+
+func parseEntry(op *packet) (*entry, error) {
+	if len(op.children) < 2 {
+		return nil, fmt.Errorf("ldap search result entry is invalid")
+	}
+
+	e := &entry{DN: op.children[0].str(), Attributes: make(map[string][]string)}
+	for _, attr := range op.children[1].children {
+		if len(attr.children) < 2 {
+			return nil, fmt.Errorf("ldap search result attribute is invalid")
+		}
+		name := strings.ToLower(attr.children[0].str())
+		for _, value := range attr.children[1].children {
+			e.Attributes[name] = append(e.Attributes[name], value.str())
+		}
+	}
+	return e, nil
+}
@@ -0,0 +1,209 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// the context tags of the search filter choices, defined in RFC 4511
+const (
+	filterAnd            byte = 0
+	filterOr             byte = 1
+	filterNot            byte = 2
+	filterEqualityMatch  byte = 3
+	filterSubstrings     byte = 4
+	filterGreaterOrEqual byte = 5
+	filterLessOrEqual    byte = 6
+	filterPresent        byte = 7
+	filterApproxMatch    byte = 8
+)
+
+// the context tags of the substring filter choices
+const (
+	substringInitial byte = 0
+	substringAny     byte = 1
+	substringFinal   byte = 2
+)
+
+// escapeFilter escape the value that is used in a search filter, defined in RFC 4515
+func escapeFilter(value string) string {
+	builder := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '*', '(', ')', 0:
+			builder.WriteString(fmt.Sprintf("\\%02x", c))
+		default:
+			builder.WriteByte(c)
+		}
+	}
+	return builder.String()
+}
+
+// compileFilter compile the string representation of a search filter to its BER packet,
+// the and, or, not, equality, substrings, greater or equal, less or equal, present and approx filters are supported.
+func compileFilter(filter string) (*packet, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, fmt.Errorf("filter is empty")
+	}
+	if filter[0] != '(' {
+		filter = "(" + filter + ")"
+	}
+
+	p, pos, err := parseFilter(filter, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pos != len(filter) {
+		return nil, fmt.Errorf("filter %s has unexpected characters at %d", filter, pos)
+	}
+	return p, nil
+}
+
+// parseFilter parse the filter starts at pos, returns the packet and the position after the filter
+func parseFilter(filter string, pos int) (*packet, int, error) {
+	if pos >= len(filter) || filter[pos] != '(' {
+		return nil, 0, fmt.Errorf("filter %s expects '(' at %d", filter, pos)
+	}
+	pos++
+	if pos >= len(filter) {
+		return nil, 0, fmt.Errorf("filter %s is truncated", filter)
+	}
+
+	switch filter[pos] {
+	case '&', '|':
+		tag := filterAnd
+		if filter[pos] == '|' {
+			tag = filterOr
+		}
+		p := newConstructed(classContext, tag)
+		pos++
+		for pos < len(filter) && filter[pos] == '(' {
+			child, next, err := parseFilter(filter, pos)
+			if err != nil {
+				return nil, 0, err
+			}
+			p.children = append(p.children, child)
+			pos = next
+		}
+		if pos >= len(filter) || filter[pos] != ')' {
+			return nil, 0, fmt.Errorf("filter %s expects ')' at %d", filter, pos)
+		}
+		return p, pos + 1, nil
+	case '!':
+		child, next, err := parseFilter(filter, pos+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if next >= len(filter) || filter[next] != ')' {
+			return nil, 0, fmt.Errorf("filter %s expects ')' at %d", filter, next)
+		}
+		return newConstructed(classContext, filterNot, child), next + 1, nil
+	default:
+		end := strings.IndexByte(filter[pos:], ')')
+		if end < 0 {
+			return nil, 0, fmt.Errorf("filter %s expects ')'", filter)
+		}
+		p, err := parseItem(filter[pos : pos+end])
+		if err != nil {
+			return nil, 0, err
+		}
+		return p, pos + end + 1, nil
+	}
+}
+
+// parseItem parse a simple filter item such as "uid=admin"
+func parseItem(item string) (*packet, error) {
+	index := strings.IndexByte(item, '=')
+	if index <= 0 {
+		return nil, fmt.Errorf("filter item %s is invalid", item)
+	}
+
+	attr := item[:index]
+	rawValue := item[index+1:]
+	tag := filterEqualityMatch
+	switch attr[len(attr)-1] {
+	case '>':
+		tag = filterGreaterOrEqual
+	case '<':
+		tag = filterLessOrEqual
+	case '~':
+		tag = filterApproxMatch
+	}
+	if tag != filterEqualityMatch {
+		attr = attr[:len(attr)-1]
+	}
+	if attr == "" {
+		return nil, fmt.Errorf("filter item %s has no attribute", item)
+	}
+
+	if tag == filterEqualityMatch && rawValue == "*" {
+		return newString(classContext, filterPresent, attr), nil
+	}
+
+	if tag == filterEqualityMatch && strings.Contains(rawValue, "*") {
+		parts := strings.Split(rawValue, "*")
+		substrings := newSequence()
+		for i, part := range parts {
+			if part == "" {
+				continue
+			}
+			value, err := unescapeFilter(part)
+			if err != nil {
+				return nil, err
+			}
+			partTag := substringAny
+			if i == 0 {
+				partTag = substringInitial
+			} else if i == len(parts)-1 {
+				partTag = substringFinal
+			}
+			substrings.children = append(substrings.children, newString(classContext, partTag, value))
+		}
+		return newConstructed(classContext, filterSubstrings, newOctetString(attr), substrings), nil
+	}
+
+	value, err := unescapeFilter(rawValue)
+	if err != nil {
+		return nil, err
+	}
+	return newConstructed(classContext, tag, newOctetString(attr), newOctetString(value)), nil
+}
+
+// unescapeFilter decode the \XX escaped characters of a filter value
+func unescapeFilter(value string) (string, error) {
+	if !strings.Contains(value, "\\") {
+		return value, nil
+	}
+
+	builder := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			builder.WriteByte(value[i])
+			continue
+		}
+		if i+3 > len(value) {
+			return "", fmt.Errorf("filter value %s has invalid escape", value)
+		}
+		decoded, err := hex.DecodeString(value[i+1 : i+3])
+		if err != nil {
+			return "", fmt.Errorf("filter value %s has invalid escape", value)
+		}
+		builder.Write(decoded)
+		i += 2
+	}
+	return builder.String(), nil
+}
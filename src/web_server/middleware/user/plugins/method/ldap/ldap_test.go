@@ -0,0 +1,351 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package ldap
+
+import (
+	"bufio"
+	"net"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"configcenter/src/web_server/middleware/user/plugins/method/external"
+)
+
+const (
+	serviceDN       = "cn=service,dc=example,dc=com"
+	servicePassword = "service-secret"
+)
+
+// fakeDirectory is a stand-in ldap server which serves the bind and search operations from memory
+type fakeDirectory struct {
+	listener  net.Listener
+	passwords map[string]string
+	entries   []*entry
+}
+
+func newFakeDirectory(t *testing.T) *fakeDirectory {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed, err: %v", err)
+	}
+
+	d := &fakeDirectory{
+		listener: listener,
+		passwords: map[string]string{
+			serviceDN:                               servicePassword,
+			"uid=alice,ou=people,dc=example,dc=com": "alice-secret",
+			"uid=bob,ou=people,dc=example,dc=com":   "bob-secret",
+		},
+		entries: []*entry{
+			{DN: "uid=alice,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+				"uid": {"alice"}, "cn": {"Alice"}, "mail": {"alice@example.com"},
+				"memberof": {"cn=ops,ou=groups,dc=example,dc=com"},
+			}},
+			{DN: "uid=bob,ou=people,dc=example,dc=com", Attributes: map[string][]string{
+				"uid": {"bob"}, "cn": {"Bob"},
+			}},
+			{DN: "cn=dba,ou=groups,dc=example,dc=com", Attributes: map[string][]string{
+				"cn": {"dba"}, "member": {"uid=alice,ou=people,dc=example,dc=com"},
+			}},
+		},
+	}
+	go d.serve()
+	return d
+}
+
+func (d *fakeDirectory) url() string {
+	return "ldap://" + d.listener.Addr().String()
+}
+
+func (d *fakeDirectory) serve() {
+	for {
+		netConn, err := d.listener.Accept()
+		if err != nil {
+			return
+		}
+		go d.handle(netConn)
+	}
+}
+
+func (d *fakeDirectory) handle(netConn net.Conn) {
+	defer netConn.Close()
+	reader := bufio.NewReader(netConn)
+	boundDN := ""
+	for {
+		msg, err := readPacket(reader)
+		if err != nil || len(msg.children) < 2 {
+			return
+		}
+		msgID, _ := msg.children[0].int()
+		op := msg.children[1]
+		reply := func(resp *packet) {
+			_, _ = netConn.Write(newSequence(newInteger(classUniversal, tagInteger, msgID), resp).encode())
+		}
+		result := func(tag byte, code int64) *packet {
+			return newConstructed(classApplication, tag, newInteger(classUniversal, tagEnumerated, code),
+				newOctetString(""), newOctetString(""))
+		}
+
+		switch op.tag {
+		case appBindRequest:
+			dn, password := op.children[1].str(), op.children[2].str()
+			if expect, exist := d.passwords[dn]; !exist || expect != password {
+				reply(result(appBindResponse, resultInvalidCredentials))
+				continue
+			}
+			boundDN = dn
+			reply(result(appBindResponse, resultSuccess))
+		case appSearchRequest:
+			if boundDN == "" {
+				reply(result(appSearchResultDone, 50))
+				continue
+			}
+			baseDN := op.children[0].str()
+			sizeLimit, _ := op.children[3].int()
+			count := int64(0)
+			code := resultSuccess
+			for _, e := range d.entries {
+				if !strings.HasSuffix(e.DN, baseDN) || !matchFilter(op.children[6], e) {
+					continue
+				}
+				if sizeLimit > 0 && count >= sizeLimit {
+					code = resultSizeLimitExceeded
+					break
+				}
+				count++
+				attrs := newSequence()
+				for name, values := range e.Attributes {
+					set := newConstructed(classUniversal, tagSet)
+					for _, value := range values {
+						set.children = append(set.children, newOctetString(value))
+					}
+					attrs.children = append(attrs.children, newSequence(newOctetString(name), set))
+				}
+				reply(newConstructed(classApplication, appSearchResultEntry, newOctetString(e.DN), attrs))
+			}
+			reply(result(appSearchResultDone, code))
+		case appUnbindRequest:
+			return
+		}
+	}
+}
+
+// matchFilter evaluate the compiled filter against the entry
+func matchFilter(filter *packet, e *entry) bool {
+	switch filter.tag {
+	case filterAnd:
+		for _, child := range filter.children {
+			if !matchFilter(child, e) {
+				return false
+			}
+		}
+		return true
+	case filterOr:
+		for _, child := range filter.children {
+			if matchFilter(child, e) {
+				return true
+			}
+		}
+		return false
+	case filterNot:
+		return !matchFilter(filter.children[0], e)
+	case filterPresent:
+		return len(e.values(filter.str())) > 0
+	case filterEqualityMatch:
+		for _, value := range e.values(filter.children[0].str()) {
+			if strings.EqualFold(value, filter.children[1].str()) {
+				return true
+			}
+		}
+		return false
+	case filterSubstrings:
+		for _, value := range e.values(filter.children[0].str()) {
+			rest := strings.ToLower(value)
+			matched := true
+			for _, part := range filter.children[1].children {
+				sub := strings.ToLower(part.str())
+				switch part.tag {
+				case substringInitial:
+					matched = matched && strings.HasPrefix(rest, sub)
+				case substringFinal:
+					matched = matched && strings.HasSuffix(rest, sub)
+				default:
+					index := strings.Index(rest, sub)
+					matched = matched && index >= 0
+					if index >= 0 {
+						rest = rest[index+len(sub):]
+					}
+				}
+			}
+			if matched {
+				return true
+			}
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+func newTestConfig(url string) *config {
+	return &config{
+		URL:             url,
+		Timeout:         5 * time.Second,
+		BindDN:          serviceDN,
+		BindPassword:    servicePassword,
+		UserBaseDN:      "ou=people,dc=example,dc=com",
+		UserFilter:      "(&(uid=*)(uid=%s))",
+		UserListFilter:  "(uid=*)",
+		UserNameAttr:    "uid",
+		DisplayNameAttr: "cn",
+		EmailAttr:       "mail",
+		PhoneAttr:       "telephoneNumber",
+		GroupAttr:       "memberOf",
+		GroupFilter:     "(member=%s)",
+		GroupNameAttr:   "cn",
+		GroupPolicy:     &external.GroupPolicy{Mapping: map[string]string{}},
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	directory := newFakeDirectory(t)
+	defer directory.listener.Close()
+
+	cfg := newTestConfig(directory.url())
+	cfg.GroupBaseDN = "ou=groups,dc=example,dc=com"
+	cfg.GroupPolicy = &external.GroupPolicy{
+		AllowedGroups: []string{"ops"},
+		Mapping:       map[string]string{"ops": "operators", "dba": "dbas", "dev": "developers"},
+	}
+
+	user, err := cfg.authenticate("alice", "alice-secret")
+	if err != nil {
+		t.Fatalf("authenticate alice failed, err: %v", err)
+	}
+	if user.UserName != "alice" || user.ChName != "Alice" || user.Email != "alice@example.com" {
+		t.Errorf("unexpected user info: %+v", user)
+	}
+	sort.Strings(user.Groups)
+	if strings.Join(user.Groups, ",") != "dbas,operators" {
+		t.Errorf("unexpected groups: %v", user.Groups)
+	}
+	if len(user.ManagedGroups) != 3 {
+		t.Errorf("unexpected managed groups: %v", user.ManagedGroups)
+	}
+
+	if _, err := cfg.authenticate("alice", "wrong"); err != ErrInvalidCredentials {
+		t.Errorf("authenticate with wrong password expects invalid credentials, got %v", err)
+	}
+	if _, err := cfg.authenticate("alice", ""); err != ErrInvalidCredentials {
+		t.Errorf("authenticate with empty password expects invalid credentials, got %v", err)
+	}
+	if _, err := cfg.authenticate("nobody", "secret"); err != ErrInvalidCredentials {
+		t.Errorf("authenticate unknown user expects invalid credentials, got %v", err)
+	}
+	if _, err := cfg.authenticate("*", "alice-secret"); err != ErrInvalidCredentials {
+		t.Errorf("authenticate with wildcard user name expects invalid credentials, got %v", err)
+	}
+	// bob is not in the allowed groups
+	if _, err := cfg.authenticate("bob", "bob-secret"); err == nil {
+		t.Errorf("authenticate user not in allowed groups expects error")
+	}
+}
+
+func TestListUsers(t *testing.T) {
+	directory := newFakeDirectory(t)
+	defer directory.listener.Close()
+
+	users, err := newTestConfig(directory.url()).listUsers()
+	if err != nil {
+		t.Fatalf("list users failed, err: %v", err)
+	}
+	names := make([]string, 0)
+	for _, user := range users {
+		names = append(names, user.EnName+":"+user.CnName)
+	}
+	sort.Strings(names)
+	if strings.Join(names, ",") != "alice:Alice,bob:Bob" {
+		t.Errorf("unexpected users: %v", names)
+	}
+}
+
+func TestCompileFilter(t *testing.T) {
+	cases := []struct {
+		filter string
+		valid  bool
+	}{
+		{filter: "(uid=alice)", valid: true},
+		{filter: "uid=alice", valid: true},
+		{filter: "(&(objectClass=person)(|(uid=a*)(cn=*b*c))(!(mail=*)))", valid: true},
+		{filter: "(uidNumber>=1000)", valid: true},
+		{filter: "(cn=a\\2ab)", valid: true},
+		{filter: "(cn=a\\2)", valid: false},
+		{filter: "(&(uid=alice)", valid: false},
+		{filter: "(=alice)", valid: false},
+		{filter: "(uid=alice))", valid: false},
+	}
+	for _, c := range cases {
+		p, err := compileFilter(c.filter)
+		if (err == nil) != c.valid {
+			t.Errorf("compile filter %s expects valid %v, got err %v", c.filter, c.valid, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		decoded, _, err := decodePacket(p.encode())
+		if err != nil {
+			t.Errorf("decode filter %s failed, err: %v", c.filter, err)
+			continue
+		}
+		if string(decoded.encode()) != string(p.encode()) {
+			t.Errorf("filter %s is changed after decoding", c.filter)
+		}
+	}
+
+	p, _ := compileFilter("(cn=a\\2ab)")
+	if p.children[1].str() != "a*b" {
+		t.Errorf("unexpected unescaped value %s", p.children[1].str())
+	}
+}
+
+func TestEscapeFilter(t *testing.T) {
+	if escaped := escapeFilter("a*(b)\\"); escaped != "a\\2a\\28b\\29\\5c" {
+		t.Errorf("unexpected escaped value %s", escaped)
+	}
+}
+
+func TestEncodeInteger(t *testing.T) {
+	for _, value := range []int64{0, 1, 127, 128, 255, 256, -1, -128, -129, 1 << 40} {
+		p := newInteger(classUniversal, tagInteger, value)
+		got, err := p.int()
+		if err != nil || got != value {
+			t.Errorf("integer %d is decoded as %d, err: %v", value, got, err)
+		}
+	}
+}
+
+func TestFirstRDNValue(t *testing.T) {
+	cases := map[string]string{
+		"cn=ops,ou=groups,dc=example,dc=com": "ops",
+		"cn=a\\,b,dc=com":                    "a\\,b",
+		"ops":                                "ops",
+	}
+	for dn, expect := range cases {
+		if got := firstRDNValue(dn); got != expect {
+			t.Errorf("first rdn value of %s expects %s, got %s", dn, expect, got)
+		}
+	}
+}
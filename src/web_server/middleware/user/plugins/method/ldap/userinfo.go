@@ -0,0 +1,338 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package ldap is the login plugin which authenticates the users with an ldap or active directory server,
+// the user is searched by the service account then bound with its own password, and the groups of the user
+// are got from the member of attribute or searched from the group base dn.
+package ldap
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	ccErrors "configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+	"configcenter/src/web_server/middleware/user/plugins/method/external"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "ldap system",
+		Version:    common.BKLDAPLoginPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+const configPrefix = "webServer.ldap"
+
+// ErrInvalidCredentials is returned when the user does not exist or the password is wrong
+var ErrInvalidCredentials = errors.New("invalid user name or password")
+
+// config is the configs of the ldap server
+type config struct {
+	URL                string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	// BindDN and BindPassword is the service account used to search the users and groups,
+	// the searches are anonymous if BindDN is empty.
+	BindDN       string
+	BindPassword string
+	UserBaseDN   string
+	// UserFilter is the filter to search the login user, %s is replaced by the escaped user name
+	UserFilter string
+	// UserListFilter is the filter to search all the users of cmdb
+	UserListFilter  string
+	UserNameAttr    string
+	DisplayNameAttr string
+	EmailAttr       string
+	PhoneAttr       string
+	// GroupAttr is the user attribute which contains the groups of the user, such as memberOf
+	GroupAttr   string
+	GroupBaseDN string
+	// GroupFilter is the filter to search the groups of the user, %s is replaced by the escaped user dn
+	GroupFilter   string
+	GroupNameAttr string
+	GroupPolicy   *external.GroupPolicy
+}
+
+func loadConfig() (*config, error) {
+	cfg := &config{
+		URL:                external.String(configPrefix+".url", ""),
+		InsecureSkipVerify: external.Bool(configPrefix + ".insecureSkipVerify"),
+		Timeout:            10 * time.Second,
+		BindDN:             external.String(configPrefix+".bindDN", ""),
+		BindPassword:       external.String(configPrefix+".bindPassword", ""),
+		UserBaseDN:         external.String(configPrefix+".userBaseDN", ""),
+		UserFilter:         external.String(configPrefix+".userFilter", "(uid=%s)"),
+		UserNameAttr:       external.String(configPrefix+".userNameAttr", "uid"),
+		DisplayNameAttr:    external.String(configPrefix+".displayNameAttr", "cn"),
+		EmailAttr:          external.String(configPrefix+".emailAttr", "mail"),
+		PhoneAttr:          external.String(configPrefix+".phoneAttr", "telephoneNumber"),
+		GroupAttr:          external.String(configPrefix+".groupAttr", "memberOf"),
+		GroupBaseDN:        external.String(configPrefix+".groupBaseDN", ""),
+		GroupFilter:        external.String(configPrefix+".groupFilter", "(member=%s)"),
+		GroupNameAttr:      external.String(configPrefix+".groupNameAttr", "cn"),
+	}
+	cfg.UserListFilter = external.String(configPrefix+".userListFilter", "("+cfg.UserNameAttr+"=*)")
+
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("%s.url is not set", configPrefix)
+	}
+	if cfg.UserBaseDN == "" {
+		return nil, fmt.Errorf("%s.userBaseDN is not set", configPrefix)
+	}
+	if timeout := external.String(configPrefix+".timeoutSeconds", ""); timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("%s.timeoutSeconds %s is invalid", configPrefix, timeout)
+		}
+		cfg.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	policy, err := external.LoadGroupPolicy(configPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GroupPolicy = policy
+	return cfg, nil
+}
+
+// connect to the ldap server and bind with the service account
+func (cfg *config) connect() (*conn, error) {
+	c, err := dial(cfg.URL, cfg.Timeout, cfg.InsecureSkipVerify)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.bind(cfg.BindDN, cfg.BindPassword); err != nil {
+		c.close()
+		return nil, fmt.Errorf("bind ldap service account %s failed, err: %v", cfg.BindDN, err)
+	}
+	return c, nil
+}
+
+// authenticate the user with the password, returns ErrInvalidCredentials if the user does not exist or
+// the password is wrong.
+func (cfg *config) authenticate(userName, password string) (*metadata.LoginUserInfo, error) {
+	// an ldap simple bind with an empty password is an unauthenticated bind which always succeeds
+	if userName == "" || password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	c, err := cfg.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	entries, err := c.search(&searchRequest{
+		BaseDN: cfg.UserBaseDN,
+		Scope:  scopeWholeSubtree,
+		Filter: fmt.Sprintf(cfg.UserFilter, escapeFilter(userName)),
+		Attributes: []string{cfg.UserNameAttr, cfg.DisplayNameAttr, cfg.EmailAttr, cfg.PhoneAttr,
+			cfg.GroupAttr},
+		SizeLimit: 2,
+	})
+	if err != nil && !isResultCode(err, resultSizeLimitExceeded) {
+		return nil, fmt.Errorf("search ldap user %s failed, err: %v", userName, err)
+	}
+	if len(entries) == 0 {
+		return nil, ErrInvalidCredentials
+	}
+	if len(entries) > 1 {
+		return nil, fmt.Errorf("multiple ldap users match the user name %s", userName)
+	}
+	userEntry := entries[0]
+
+	if err := c.bind(userEntry.DN, password); err != nil {
+		if isResultCode(err, resultInvalidCredentials) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("bind ldap user %s failed, err: %v", userEntry.DN, err)
+	}
+
+	groups, err := cfg.getGroups(c, userEntry)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &metadata.LoginUserInfo{
+		UserName: userEntry.get(cfg.UserNameAttr),
+		ChName:   userEntry.get(cfg.DisplayNameAttr),
+		Email:    userEntry.get(cfg.EmailAttr),
+		Phone:    userEntry.get(cfg.PhoneAttr),
+		OnwerUin: common.BKDefaultOwnerID,
+	}
+	if user.UserName == "" {
+		user.UserName = userName
+	}
+	if user.ChName == "" {
+		user.ChName = user.UserName
+	}
+
+	if err := cfg.GroupPolicy.Apply(user, groups); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// getGroups get the group names of the user, the connection is bound as the user
+func (cfg *config) getGroups(c *conn, userEntry *entry) ([]string, error) {
+	groups := make([]string, 0)
+	for _, dn := range userEntry.values(cfg.GroupAttr) {
+		if name := firstRDNValue(dn); name != "" && !util.InStrArr(groups, name) {
+			groups = append(groups, name)
+		}
+	}
+
+	if cfg.GroupBaseDN == "" {
+		return groups, nil
+	}
+
+	// the user may have no permission to search the groups, so rebind with the service account
+	if err := c.bind(cfg.BindDN, cfg.BindPassword); err != nil {
+		return nil, fmt.Errorf("bind ldap service account %s failed, err: %v", cfg.BindDN, err)
+	}
+	entries, err := c.search(&searchRequest{
+		BaseDN:     cfg.GroupBaseDN,
+		Scope:      scopeWholeSubtree,
+		Filter:     fmt.Sprintf(cfg.GroupFilter, escapeFilter(userEntry.DN)),
+		Attributes: []string{cfg.GroupNameAttr},
+	})
+	if err != nil && !isResultCode(err, resultSizeLimitExceeded) {
+		return nil, fmt.Errorf("search ldap groups of user %s failed, err: %v", userEntry.DN, err)
+	}
+	for _, groupEntry := range entries {
+		name := groupEntry.get(cfg.GroupNameAttr)
+		if name == "" {
+			name = firstRDNValue(groupEntry.DN)
+		}
+		if name != "" && !util.InStrArr(groups, name) {
+			groups = append(groups, name)
+		}
+	}
+	return groups, nil
+}
+
+// listUsers list all the users that match the user list filter
+func (cfg *config) listUsers() ([]*metadata.LoginSystemUserInfo, error) {
+	c, err := cfg.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer c.close()
+
+	entries, err := c.search(&searchRequest{
+		BaseDN:     cfg.UserBaseDN,
+		Scope:      scopeWholeSubtree,
+		Filter:     cfg.UserListFilter,
+		Attributes: []string{cfg.UserNameAttr, cfg.DisplayNameAttr},
+	})
+	if err != nil && !isResultCode(err, resultSizeLimitExceeded) {
+		return nil, fmt.Errorf("search ldap users failed, err: %v", err)
+	}
+
+	users := make([]*metadata.LoginSystemUserInfo, 0)
+	for _, userEntry := range entries {
+		name := userEntry.get(cfg.UserNameAttr)
+		if name == "" {
+			continue
+		}
+		displayName := userEntry.get(cfg.DisplayNameAttr)
+		if displayName == "" {
+			displayName = name
+		}
+		users = append(users, &metadata.LoginSystemUserInfo{CnName: displayName, EnName: name})
+	}
+	return users, nil
+}
+
+// firstRDNValue returns the value of the first relative distinguished name of the dn, such as "ops" of
+// "cn=ops,ou=groups,dc=example,dc=com", the value is returned as is if it is not a dn.
+func firstRDNValue(dn string) string {
+	rdn := dn
+	for i := 0; i < len(dn); i++ {
+		if dn[i] == '\\' {
+			i++
+			continue
+		}
+		if dn[i] == ',' {
+			rdn = dn[:i]
+			break
+		}
+	}
+
+	index := strings.IndexByte(rdn, '=')
+	if index < 0 {
+		return strings.TrimSpace(rdn)
+	}
+	return strings.TrimSpace(rdn[index+1:])
+}
+
+type user struct{}
+
+// LoginUser get the user that has logged in by ldap from the session
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo,
+	bool) {
+
+	user, ok := external.LoadLoginUser(c)
+	if !ok {
+		return nil, false
+	}
+	user.Language = webCommon.GetLanguageByHTTPRequest(c)
+	return user, true
+}
+
+// GetLoginUrl returns the login page of cmdb, the user name and password are posted to the login page
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	return external.GetSiteLoginURL(c, input)
+}
+
+// GetUserList list the users from the ldap server
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo,
+	*ccErrors.RawErrorInfo) {
+
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	cfg, err := loadConfig()
+	if err != nil {
+		blog.Errorf("load ldap config failed, err: %v, rid: %s", err, rid)
+		return nil, &ccErrors.RawErrorInfo{ErrCode: common.CCErrCommConfMissItem, Args: []interface{}{configPrefix}}
+	}
+
+	users, err := cfg.listUsers()
+	if err != nil {
+		blog.Errorf("list ldap users failed, err: %v, rid: %s", err, rid)
+		return nil, &ccErrors.RawErrorInfo{ErrCode: common.CCErrWebGetUserListFail}
+	}
+	return users, nil
+}
+
+// VerifyPassword verify the user name and password with the ldap server
+func (m *user) VerifyPassword(c *gin.Context, config map[string]string, userName, password string) (
+	*metadata.LoginUserInfo, error) {
+
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return cfg.authenticate(userName, password)
+}
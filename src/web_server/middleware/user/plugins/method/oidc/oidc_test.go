@@ -0,0 +1,315 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"configcenter/src/web_server/middleware/user/plugins/method/external"
+)
+
+const (
+	testClientID     = "cmdb"
+	testClientSecret = "cmdb-secret"
+	testKeyID        = "test-key"
+)
+
+// fakeIdentityProvider is a stand-in openid connect identity provider, it issues the id token with the
+// claims of the test when the authorization code is exchanged with the right pkce code verifier.
+type fakeIdentityProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+
+	lock sync.Mutex
+	// codes is the code challenge and nonce of the issued authorization codes
+	codes  map[string][2]string
+	claims map[string]interface{}
+}
+
+func newFakeIdentityProvider(t *testing.T) *fakeIdentityProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate rsa key failed, err: %v", err)
+	}
+
+	idp := &fakeIdentityProvider{key: key, codes: make(map[string][2]string)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": testKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", idp.handleToken)
+	idp.server = httptest.NewServer(mux)
+	return idp
+}
+
+// authorize simulates the user logs in at the authorization endpoint, returns the authorization code
+func (idp *fakeIdentityProvider) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parse authorization url failed, err: %v", err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("client_id") != testClientID ||
+		!strings.Contains(query.Get("scope"), "openid") {
+		t.Fatalf("unexpected authorization request: %s", authURL)
+	}
+
+	idp.lock.Lock()
+	defer idp.lock.Unlock()
+	code := "code-" + query.Get("state")
+	idp.codes[code] = [2]string{query.Get("code_challenge"), query.Get("nonce")}
+	return code
+}
+
+func (idp *fakeIdentityProvider) handleToken(w http.ResponseWriter, r *http.Request) {
+	writeError := func(code string) {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": code})
+	}
+
+	clientID, secret, ok := r.BasicAuth()
+	if !ok || clientID != testClientID || secret != testClientSecret {
+		writeError("invalid_client")
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeError("invalid_request")
+		return
+	}
+
+	idp.lock.Lock()
+	issued, exist := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	claims := make(map[string]interface{})
+	for k, v := range idp.claims {
+		claims[k] = v
+	}
+	idp.lock.Unlock()
+
+	if !exist || codeChallenge(r.PostForm.Get("code_verifier")) != issued[0] {
+		writeError("invalid_grant")
+		return
+	}
+	if _, exist := claims["nonce"]; !exist {
+		claims["nonce"] = issued[1]
+	}
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     signToken(idp.key, "RS256", testKeyID, claims),
+	})
+}
+
+func signToken(key crypto.Signer, algorithm, keyID string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": algorithm, "kid": keyID, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, _ := ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (idp *fakeIdentityProvider) config() *config {
+	return &config{
+		Issuer:           idp.server.URL,
+		ClientID:         testClientID,
+		ClientSecret:     testClientSecret,
+		RedirectURL:      "http://cmdb.example.com/login/callback",
+		Scopes:           []string{"openid", "profile"},
+		Timeout:          5 * time.Second,
+		UserNameClaim:    "preferred_username",
+		DisplayNameClaim: "name",
+		EmailClaim:       "email",
+		PhoneClaim:       "phone_number",
+		GroupsClaim:      "groups",
+		GroupPolicy: &external.GroupPolicy{
+			AllowedGroups: []string{"cmdb-users"},
+			Mapping:       map[string]string{"cmdb-admins": "admins", "cmdb-users": "users", "dev": "developers"},
+		},
+	}
+}
+
+// login runs the authorization code flow and returns the login user
+func (idp *fakeIdentityProvider) login(t *testing.T, cfg *config) (*loginState, string) {
+	state, err := newLoginState(cfg.RedirectURL, "http://cmdb.example.com/")
+	if err != nil {
+		t.Fatalf("new login state failed, err: %v", err)
+	}
+	authURL, err := getProvider(cfg).authCodeURL(context.Background(), cfg, state.State, state.Nonce,
+		codeChallenge(state.CodeVerifier))
+	if err != nil {
+		t.Fatalf("get authorization url failed, err: %v", err)
+	}
+	return state, idp.authorize(t, authURL)
+}
+
+func (idp *fakeIdentityProvider) defaultClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"iss":                idp.server.URL,
+		"sub":                "user-1",
+		"aud":                testClientID,
+		"exp":                time.Now().Add(time.Hour).Unix(),
+		"iat":                time.Now().Unix(),
+		"preferred_username": "alice",
+		"name":               "Alice",
+		"email":              "alice@example.com",
+		"groups":             []string{"cmdb-users", "cmdb-admins", "other"},
+	}
+}
+
+func TestLogin(t *testing.T) {
+	idp := newFakeIdentityProvider(t)
+	defer idp.server.Close()
+
+	cfg := idp.config()
+	idp.claims = idp.defaultClaims()
+	state, code := idp.login(t, cfg)
+
+	user, err := cfg.login(context.Background(), code, state)
+	if err != nil {
+		t.Fatalf("login failed, err: %v", err)
+	}
+	if user.UserName != "alice" || user.ChName != "Alice" || user.Email != "alice@example.com" {
+		t.Errorf("unexpected user info: %+v", user)
+	}
+	sort.Strings(user.Groups)
+	if strings.Join(user.Groups, ",") != "admins,users" {
+		t.Errorf("unexpected groups: %v", user.Groups)
+	}
+	if len(user.ManagedGroups) != 3 {
+		t.Errorf("unexpected managed groups: %v", user.ManagedGroups)
+	}
+
+	// the authorization code can only be used once
+	if _, err := cfg.login(context.Background(), code, state); err == nil {
+		t.Errorf("login with a used authorization code expects error")
+	}
+}
+
+func TestLoginWithWrongCodeVerifier(t *testing.T) {
+	idp := newFakeIdentityProvider(t)
+	defer idp.server.Close()
+
+	cfg := idp.config()
+	idp.claims = idp.defaultClaims()
+	state, code := idp.login(t, cfg)
+	state.CodeVerifier = "wrong-verifier-wrong-verifier-wrong-verifier"
+
+	if _, err := cfg.login(context.Background(), code, state); err == nil {
+		t.Errorf("login with wrong code verifier expects error")
+	}
+}
+
+func TestLoginWithInvalidIDToken(t *testing.T) {
+	idp := newFakeIdentityProvider(t)
+	defer idp.server.Close()
+
+	cases := map[string]func(claims map[string]interface{}){
+		"wrong nonce":       func(claims map[string]interface{}) { claims["nonce"] = "another" },
+		"wrong issuer":      func(claims map[string]interface{}) { claims["iss"] = "https://evil.example.com" },
+		"wrong audience":    func(claims map[string]interface{}) { claims["aud"] = "another" },
+		"expired":           func(claims map[string]interface{}) { claims["exp"] = time.Now().Add(-time.Hour).Unix() },
+		"not allowed group": func(claims map[string]interface{}) { claims["groups"] = []string{"other"} },
+		"multiple audiences without azp": func(claims map[string]interface{}) {
+			claims["aud"] = []string{testClientID, "another"}
+		},
+	}
+
+	for name, modify := range cases {
+		cfg := idp.config()
+		idp.claims = idp.defaultClaims()
+		modify(idp.claims)
+		state, code := idp.login(t, cfg)
+		if _, err := cfg.login(context.Background(), code, state); err == nil {
+			t.Errorf("case %s: login expects error", name)
+		}
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	claims := map[string]interface{}{"sub": "alice"}
+
+	rsaToken := signToken(rsaKey, "RS256", "", claims)
+	if err := verifySignature(rsaToken, "RS256", &rsaKey.PublicKey); err != nil {
+		t.Errorf("verify rs256 token failed, err: %v", err)
+	}
+	ecToken := signToken(ecKey, "ES256", "", claims)
+	if err := verifySignature(ecToken, "ES256", &ecKey.PublicKey); err != nil {
+		t.Errorf("verify es256 token failed, err: %v", err)
+	}
+
+	anotherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if err := verifySignature(rsaToken, "RS256", &anotherKey.PublicKey); err == nil {
+		t.Errorf("verify token with another key expects error")
+	}
+	if err := verifySignature(rsaToken, "ES256", &rsaKey.PublicKey); err == nil {
+		t.Errorf("verify token with mismatched algorithm expects error")
+	}
+	if err := verifySignature(rsaToken, "HS256", &rsaKey.PublicKey); err == nil {
+		t.Errorf("verify token with hmac algorithm expects error")
+	}
+	if err := verifySignature(rsaToken, "none", &rsaKey.PublicKey); err == nil {
+		t.Errorf("verify token with none algorithm expects error")
+	}
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// the example of RFC 7636 Appendix B
+	if challenge := codeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"); challenge !=
+		"E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("unexpected code challenge %s", challenge)
+	}
+}
@@ -0,0 +1,307 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// keysRefreshInterval is the minimum interval to refresh the json web key set when an unknown key id is met,
+// so that the tokens with random key ids can not make cmdb request the identity provider all the time.
+const keysRefreshInterval = 10 * time.Second
+
+// maxResponseSize limits the size of the responses of the identity provider
+const maxResponseSize = 1 << 20
+
+// discoveryDocument is the provider metadata, defined in OpenID Connect Discovery 1.0
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse is the response of the token endpoint
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// provider is an openid connect identity provider, the provider metadata and keys are cached
+type provider struct {
+	issuer string
+	client *http.Client
+
+	lock            sync.Mutex
+	discovery       *discoveryDocument
+	keys            map[string]crypto.PublicKey
+	keysRefreshedAt time.Time
+}
+
+var providers = struct {
+	sync.Mutex
+	cache map[string]*provider
+}{cache: make(map[string]*provider)}
+
+// getProvider returns the cached provider of the issuer
+func getProvider(cfg *config) *provider {
+	providers.Lock()
+	defer providers.Unlock()
+
+	key := fmt.Sprintf("%s|%v", cfg.Issuer, cfg.InsecureSkipVerify)
+	if p, exist := providers.cache[key]; exist {
+		return p
+	}
+	p := newProvider(cfg.Issuer, cfg.Timeout, cfg.InsecureSkipVerify)
+	providers.cache[key] = p
+	return p
+}
+
+func newProvider(issuer string, timeout time.Duration, insecureSkipVerify bool) *provider {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: insecureSkipVerify}
+	return &provider{
+		issuer: issuer,
+		client: &http.Client{Timeout: timeout, Transport: transport},
+		keys:   make(map[string]crypto.PublicKey),
+	}
+}
+
+// getDiscovery get the provider metadata from the well known configuration endpoint of the issuer
+func (p *provider) getDiscovery(ctx context.Context) (*discoveryDocument, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	doc := new(discoveryDocument)
+	wellKnown := strings.TrimRight(p.issuer, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, doc); err != nil {
+		return nil, fmt.Errorf("get openid configuration failed, err: %v", err)
+	}
+	if doc.Issuer != p.issuer {
+		return nil, fmt.Errorf("openid configuration issuer %s does not match %s", doc.Issuer, p.issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, errors.New("openid configuration lacks the authorization, token or jwks endpoint")
+	}
+
+	p.discovery = doc
+	return doc, nil
+}
+
+// getKey get the public key of the key id, the json web key set is refreshed if the key is not found
+func (p *provider) getKey(ctx context.Context, keyID string) (crypto.PublicKey, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if key := p.findKey(keyID); key != nil {
+		return key, nil
+	}
+	if time.Since(p.keysRefreshedAt) < keysRefreshInterval {
+		return nil, fmt.Errorf("id token signing key %s is not found", keyID)
+	}
+
+	keySet := new(jsonWebKeySet)
+	if err := p.getJSON(ctx, doc.JWKSURI, keySet); err != nil {
+		return nil, fmt.Errorf("get json web key set failed, err: %v", err)
+	}
+	keys := make(map[string]crypto.PublicKey)
+	for _, jwk := range keySet.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("parse json web key %s failed, err: %v", jwk.KeyID, err)
+		}
+		if key != nil {
+			keys[jwk.KeyID] = key
+		}
+	}
+	p.keys = keys
+	p.keysRefreshedAt = time.Now()
+
+	if key := p.findKey(keyID); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("id token signing key %s is not found", keyID)
+}
+
+// findKey find the key of the key id, the only key is used if the token has no key id
+func (p *provider) findKey(keyID string) crypto.PublicKey {
+	if keyID == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key
+		}
+	}
+	return p.keys[keyID]
+}
+
+// authCodeURL returns the url of the authorization endpoint that the user is redirected to for login
+func (p *provider) authCodeURL(ctx context.Context, cfg *config, state, nonce, codeChallenge string) (string,
+	error) {
+
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	params := url.Values{}
+	params.Set("response_type", "code")
+	params.Set("client_id", cfg.ClientID)
+	params.Set("redirect_uri", cfg.RedirectURL)
+	params.Set("scope", strings.Join(cfg.Scopes, " "))
+	params.Set("state", state)
+	params.Set("nonce", nonce)
+	params.Set("code_challenge", codeChallenge)
+	params.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(doc.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return doc.AuthorizationEndpoint + separator + params.Encode(), nil
+}
+
+// exchange the authorization code for the id token with the pkce code verifier
+func (p *provider) exchange(ctx context.Context, cfg *config, code, codeVerifier string) (string, error) {
+	doc, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", cfg.RedirectURL)
+	form.Set("client_id", cfg.ClientID)
+	form.Set("code_verifier", codeVerifier)
+
+	req, err := http.NewRequest(http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if cfg.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(cfg.ClientID), url.QueryEscape(cfg.ClientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("request token endpoint failed, err: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", fmt.Errorf("read token response failed, err: %v", err)
+	}
+	token := new(tokenResponse)
+	if err := json.Unmarshal(body, token); err != nil {
+		return "", fmt.Errorf("unmarshal token response failed, status: %d, err: %v", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		return "", fmt.Errorf("exchange authorization code failed, status: %d, error: %s, description: %s",
+			resp.StatusCode, token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response has no id token, check if the openid scope is requested")
+	}
+	return token.IDToken, nil
+}
+
+// verifyIDToken verify the signature and claims of the id token, returns the claims
+func (p *provider) verifyIDToken(ctx context.Context, cfg *config, rawToken, nonce string) (
+	map[string]interface{}, error) {
+
+	header, claims, err := parseToken(rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := p.getKey(ctx, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(rawToken, header.Algorithm, key); err != nil {
+		return nil, err
+	}
+
+	if err := validateClaims(claims, p.issuer, cfg.ClientID, nonce, time.Now()); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (p *provider) getJSON(ctx context.Context, rawURL string, result interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed, status: %d", rawURL, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(result)
+}
+
+// randomString returns a url safe random string of the bytes length
+func randomString(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// codeChallenge returns the S256 pkce code challenge of the code verifier, defined in RFC 7636
+func codeChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,257 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package oidc
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// clockSkew is the allowed clock difference between cmdb and the identity provider
+const clockSkew = time.Minute
+
+// jwtHeader is the JOSE header of the id token
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+// jsonWebKey is a public key of the json web key set
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+// publicKey convert the json web key to the public key, returns nil if the key type is not supported
+func (k *jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errors.New("rsa public exponent is too large")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, nil
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("ec public key is not on the curve")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	default:
+		return nil, nil
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	bytes, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("decode json web key failed, err: %v", err)
+	}
+	return new(big.Int).SetBytes(bytes), nil
+}
+
+// parseToken parse the header and claims of the jwt without verifying it
+func parseToken(token string) (*jwtHeader, map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, errors.New("id token is not a jws compact serialization")
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode id token header failed, err: %v", err)
+	}
+	header := new(jwtHeader)
+	if err := json.Unmarshal(headerBytes, header); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal id token header failed, err: %v", err)
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("decode id token payload failed, err: %v", err)
+	}
+	claims := make(map[string]interface{})
+	decoder := json.NewDecoder(strings.NewReader(string(payload)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&claims); err != nil {
+		return nil, nil, fmt.Errorf("unmarshal id token claims failed, err: %v", err)
+	}
+	return header, claims, nil
+}
+
+// verifySignature verify the jws signature of the token with the key, only the asymmetric algorithms are
+// supported, "none" and the HMAC algorithms are rejected because the client secret is not a signing key.
+func verifySignature(token string, algorithm string, key crypto.PublicKey) error {
+	index := strings.LastIndex(token, ".")
+	signed := token[:index]
+	signature, err := base64.RawURLEncoding.DecodeString(token[index+1:])
+	if err != nil {
+		return fmt.Errorf("decode id token signature failed, err: %v", err)
+	}
+
+	var hash crypto.Hash
+	switch algorithm {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512", "ES512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("id token signing algorithm %s is not supported", algorithm)
+	}
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "RS") {
+			return fmt.Errorf("id token algorithm %s does not match the rsa key", algorithm)
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return errors.New("id token signature is invalid")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		if !strings.HasPrefix(algorithm, "ES") {
+			return fmt.Errorf("id token algorithm %s does not match the ec key", algorithm)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8
+		if len(signature) != 2*size {
+			return errors.New("id token signature is invalid")
+		}
+		r := new(big.Int).SetBytes(signature[:size])
+		s := new(big.Int).SetBytes(signature[size:])
+		if !ecdsa.Verify(pub, digest, r, s) {
+			return errors.New("id token signature is invalid")
+		}
+		return nil
+	default:
+		return errors.New("id token signing key type is not supported")
+	}
+}
+
+// validateClaims validate the standard claims of the id token, defined in OpenID Connect Core 3.1.3.7
+func validateClaims(claims map[string]interface{}, issuer, clientID, nonce string, now time.Time) error {
+	if iss, _ := claims["iss"].(string); iss != issuer {
+		return fmt.Errorf("id token issuer %s does not match %s", iss, issuer)
+	}
+
+	audiences := make([]string, 0)
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, aud)
+	case []interface{}:
+		for _, one := range aud {
+			if value, ok := one.(string); ok {
+				audiences = append(audiences, value)
+			}
+		}
+	}
+	matched := false
+	for _, aud := range audiences {
+		if aud == clientID {
+			matched = true
+			break
+		}
+	}
+	if !matched {
+		return fmt.Errorf("id token audience %v does not contain the client id", audiences)
+	}
+	if len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != clientID {
+			return fmt.Errorf("id token authorized party %s is not the client id", azp)
+		}
+	}
+
+	exp, err := numericDate(claims["exp"])
+	if err != nil {
+		return fmt.Errorf("id token expiration time is invalid, err: %v", err)
+	}
+	if now.After(exp.Add(clockSkew)) {
+		return errors.New("id token is expired")
+	}
+	if _, exist := claims["iat"]; exist {
+		iat, err := numericDate(claims["iat"])
+		if err != nil {
+			return fmt.Errorf("id token issued at time is invalid, err: %v", err)
+		}
+		if iat.After(now.Add(clockSkew)) {
+			return errors.New("id token is issued in the future")
+		}
+	}
+
+	if value, _ := claims["nonce"].(string); value != nonce {
+		return errors.New("id token nonce does not match")
+	}
+	return nil
+}
+
+func numericDate(value interface{}) (time.Time, error) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, fmt.Errorf("%v is not a number", value)
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(seconds), 0), nil
+}
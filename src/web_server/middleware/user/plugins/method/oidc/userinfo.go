@@ -0,0 +1,325 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package oidc is the login plugin which authenticates the users with an openid connect identity provider
+// using the authorization code flow with pkce, the groups of the user are got from the groups claim of
+// the id token.
+package oidc
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	ccErrors "configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	webCommon "configcenter/src/web_server/common"
+	"configcenter/src/web_server/middleware/user/plugins/manager"
+	"configcenter/src/web_server/middleware/user/plugins/method/external"
+
+	"github.com/gin-gonic/gin"
+	"github.com/holmeswang/contrib/sessions"
+)
+
+func init() {
+	plugin := &metadata.LoginPluginInfo{
+		Name:       "openid connect system",
+		Version:    common.BKOIDCLoginPluginVersion,
+		HandleFunc: &user{},
+	}
+	manager.RegisterPlugin(plugin)
+}
+
+const configPrefix = "webServer.oidc"
+
+// sessionStateKey is the session key of the login state which is checked in the callback
+const sessionStateKey = "oidc_login_state"
+
+// stateExpireSeconds is how long the user can take to login at the identity provider
+const stateExpireSeconds = 10 * 60
+
+// config is the configs of the openid connect client
+type config struct {
+	Issuer             string
+	ClientID           string
+	ClientSecret       string
+	RedirectURL        string
+	Scopes             []string
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+	UserNameClaim      string
+	DisplayNameClaim   string
+	EmailClaim         string
+	PhoneClaim         string
+	GroupsClaim        string
+	GroupPolicy        *external.GroupPolicy
+}
+
+func loadConfig(input *metadata.LogoutRequestParams) (*config, error) {
+	cfg := &config{
+		Issuer:             external.String(configPrefix+".issuer", ""),
+		ClientID:           external.String(configPrefix+".clientID", ""),
+		ClientSecret:       external.String(configPrefix+".clientSecret", ""),
+		RedirectURL:        external.String(configPrefix+".redirectURL", external.GetSiteURL(input)+"/login/callback"),
+		Scopes:             external.SplitList(external.String(configPrefix+".scopes", "openid,profile,email")),
+		InsecureSkipVerify: external.Bool(configPrefix + ".insecureSkipVerify"),
+		Timeout:            10 * time.Second,
+		UserNameClaim:      external.String(configPrefix+".userNameClaim", "preferred_username"),
+		DisplayNameClaim:   external.String(configPrefix+".displayNameClaim", "name"),
+		EmailClaim:         external.String(configPrefix+".emailClaim", "email"),
+		PhoneClaim:         external.String(configPrefix+".phoneClaim", "phone_number"),
+		GroupsClaim:        external.String(configPrefix+".groupsClaim", "groups"),
+	}
+
+	if cfg.Issuer == "" {
+		return nil, fmt.Errorf("%s.issuer is not set", configPrefix)
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("%s.clientID is not set", configPrefix)
+	}
+	if !util.InStrArr(cfg.Scopes, "openid") {
+		cfg.Scopes = append([]string{"openid"}, cfg.Scopes...)
+	}
+	if timeout := external.String(configPrefix+".timeoutSeconds", ""); timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("%s.timeoutSeconds %s is invalid", configPrefix, timeout)
+		}
+		cfg.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	policy, err := external.LoadGroupPolicy(configPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cfg.GroupPolicy = policy
+	return cfg, nil
+}
+
+// loginState is the state of a login which is saved in the session before the user is redirected to the
+// identity provider, and checked when the identity provider calls back.
+type loginState struct {
+	State        string `json:"state"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier"`
+	// CallbackURL is the redirect uri of the authorization request, the token request must use the same one
+	CallbackURL string `json:"callback_url"`
+	// RedirectURL is the cmdb page that the user is redirected to after login
+	RedirectURL string `json:"redirect_url"`
+	CreateTime  int64  `json:"create_time"`
+}
+
+func newLoginState(callbackURL, redirectURL string) (*loginState, error) {
+	state, err := randomString(24)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomString(24)
+	if err != nil {
+		return nil, err
+	}
+	// the code verifier is 43 characters which is the minimum length of RFC 7636
+	verifier, err := randomString(32)
+	if err != nil {
+		return nil, err
+	}
+	return &loginState{
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		CallbackURL:  callbackURL,
+		RedirectURL:  redirectURL,
+		CreateTime:   time.Now().Unix(),
+	}, nil
+}
+
+// buildUser build the login user from the verified id token claims
+func (cfg *config) buildUser(claims map[string]interface{}) (*metadata.LoginUserInfo, error) {
+	user := &metadata.LoginUserInfo{
+		UserName: claimString(claims, cfg.UserNameClaim),
+		ChName:   claimString(claims, cfg.DisplayNameClaim),
+		Email:    claimString(claims, cfg.EmailClaim),
+		Phone:    claimString(claims, cfg.PhoneClaim),
+		OnwerUin: common.BKDefaultOwnerID,
+	}
+	if user.UserName == "" {
+		user.UserName = claimString(claims, "sub")
+	}
+	if user.UserName == "" {
+		return nil, fmt.Errorf("id token has no %s or sub claim", cfg.UserNameClaim)
+	}
+	if user.ChName == "" {
+		user.ChName = user.UserName
+	}
+
+	groups := make([]string, 0)
+	switch value := claims[cfg.GroupsClaim].(type) {
+	case string:
+		groups = append(groups, value)
+	case []interface{}:
+		for _, one := range value {
+			if group, ok := one.(string); ok {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	if err := cfg.GroupPolicy.Apply(user, groups); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func claimString(claims map[string]interface{}, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	default:
+		return ""
+	}
+}
+
+type user struct{}
+
+// LoginUser get the user that has logged in by the identity provider from the session
+func (m *user) LoginUser(c *gin.Context, config map[string]string, isMultiOwner bool) (*metadata.LoginUserInfo,
+	bool) {
+
+	user, ok := external.LoadLoginUser(c)
+	if !ok {
+		return nil, false
+	}
+	user.Language = webCommon.GetLanguageByHTTPRequest(c)
+	return user, true
+}
+
+// GetLoginUrl returns the authorization endpoint url of the identity provider, the login state is saved
+// in the session so that the callback can be verified.
+func (m *user) GetLoginUrl(c *gin.Context, config map[string]string, input *metadata.LogoutRequestParams) string {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	siteURL := external.GetSiteURL(input)
+
+	cfg, err := loadConfig(input)
+	if err != nil {
+		blog.Errorf("load oidc config failed, err: %v, rid: %s", err, rid)
+		return siteURL
+	}
+
+	state, err := newLoginState(cfg.RedirectURL, siteURL+c.Request.URL.String())
+	if err != nil {
+		blog.Errorf("generate oidc login state failed, err: %v, rid: %s", err, rid)
+		return siteURL
+	}
+
+	loginURL, err := getProvider(cfg).authCodeURL(c.Request.Context(), cfg, state.State, state.Nonce,
+		codeChallenge(state.CodeVerifier))
+	if err != nil {
+		blog.Errorf("get oidc authorization url failed, err: %v, rid: %s", err, rid)
+		return siteURL
+	}
+
+	value, err := json.Marshal(state)
+	if err != nil {
+		blog.Errorf("marshal oidc login state failed, err: %v, rid: %s", err, rid)
+		return siteURL
+	}
+	session := sessions.Default(c)
+	session.Set(sessionStateKey, string(value))
+	if err := session.Save(); err != nil {
+		blog.Errorf("save oidc login state failed, err: %v, rid: %s", err, rid)
+		return siteURL
+	}
+	return loginURL
+}
+
+// GetUserList returns the current login user, openid connect has no standard way to list the users of
+// the identity provider.
+func (m *user) GetUserList(c *gin.Context, config map[string]string) ([]*metadata.LoginSystemUserInfo,
+	*ccErrors.RawErrorInfo) {
+
+	users := make([]*metadata.LoginSystemUserInfo, 0)
+	if user, ok := external.LoadLoginUser(c); ok {
+		users = append(users, &metadata.LoginSystemUserInfo{CnName: user.ChName, EnName: user.UserName})
+	}
+	return users, nil
+}
+
+// HandleLoginCallback verify the callback of the identity provider, exchange the authorization code for
+// the id token and get the login user from the id token.
+func (m *user) HandleLoginCallback(c *gin.Context, config map[string]string) (*metadata.LoginUserInfo, string,
+	error) {
+
+	if errCode := c.Query("error"); errCode != "" {
+		return nil, "", fmt.Errorf("identity provider returns error %s: %s", errCode, c.Query("error_description"))
+	}
+
+	// the login state can only be used once
+	session := sessions.Default(c)
+	value, _ := session.Get(sessionStateKey).(string)
+	session.Delete(sessionStateKey)
+	if err := session.Save(); err != nil {
+		return nil, "", fmt.Errorf("delete oidc login state failed, err: %v", err)
+	}
+	if value == "" {
+		return nil, "", errors.New("oidc login state is not found in session")
+	}
+	state := new(loginState)
+	if err := json.Unmarshal([]byte(value), state); err != nil {
+		return nil, "", fmt.Errorf("unmarshal oidc login state failed, err: %v", err)
+	}
+	if time.Now().Unix()-state.CreateTime > stateExpireSeconds {
+		return nil, "", errors.New("oidc login state is expired")
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(state.State)) != 1 {
+		return nil, "", errors.New("oidc login state does not match")
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		return nil, "", errors.New("authorization code is not returned")
+	}
+
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg.RedirectURL = state.CallbackURL
+
+	user, err := cfg.login(c.Request.Context(), code, state)
+	if err != nil {
+		return nil, "", err
+	}
+	return user, state.RedirectURL, nil
+}
+
+// login exchange the authorization code and verify the id token
+func (cfg *config) login(ctx context.Context, code string, state *loginState) (*metadata.LoginUserInfo, error) {
+	p := getProvider(cfg)
+	rawToken, err := p.exchange(ctx, cfg, code, state.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := p.verifyIDToken(ctx, cfg, rawToken, state.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.buildUser(claims)
+}
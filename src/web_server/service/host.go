@@ -34,6 +34,13 @@ import (
 	"github.com/rentiansheng/xlsx"
 )
 
+const (
+	// exportLastHostIDField is the form field of the last host id of the previous export page
+	exportLastHostIDField = "last_host_id"
+	// exportLastHostIDHeader is the response header of the last host id of the exported page
+	exportLastHostIDHeader = "Bk-Last-Host-Id"
+)
+
 // ImportHost import host
 func (s *Service) ImportHost(c *gin.Context) {
 	rid := util.GetHTTPCCRequestID(c.Request.Header)
@@ -105,6 +112,7 @@ func (s *Service) ExportHost(c *gin.Context) {
 	rid := util.GetHTTPCCRequestID(c.Request.Header)
 	ctx := util.NewContextFromGinContext(c)
 	webCommon.SetProxyHeader(c)
+	s.setExportReadPreference(c)
 	header := c.Request.Header
 	defLang := s.Language.CreateDefaultCCLanguageIf(util.GetLanguage(header))
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(header))
@@ -122,6 +130,21 @@ func (s *Service) ExportHost(c *gin.Context) {
 		return
 	}
 
+	// last_host_id is the id of the last host of the previous page, which is returned in the
+	// exportLastHostIDHeader header, the next page is exported after it instead of the page start.
+	var lastHostID int64
+	if lastHostIDStr := c.PostForm(exportLastHostIDField); lastHostIDStr != "" {
+		lastHostID, err = strconv.ParseInt(lastHostIDStr, 10, 64)
+		if err != nil || lastHostID < 0 {
+			blog.Errorf("ExportHost failed, %s is invalid, err: %v, val: %s, rid: %s", exportLastHostIDField, err,
+				lastHostIDStr, rid)
+			err := defErr.CCErrorf(common.CCErrCommParamsNeedInt, exportLastHostIDField)
+			reply := getReturnStr(err.GetCode(), err.Error(), nil)
+			_, _ = c.Writer.Write([]byte(reply))
+			return
+		}
+	}
+
 	objID := common.BKInnerObjIDHost
 	filterFields := logics.GetFilterFields(objID)
 	customFields := logics.GetCustomFields(filterFields, customFieldsStr)
@@ -138,7 +161,7 @@ func (s *Service) ExportHost(c *gin.Context) {
 		hostFields = append(hostFields, property.ID)
 	}
 
-	hostInfo, err := s.Logics.GetHostData(appID, hostIDStr, hostFields, exportCondStr, header, defLang)
+	hostInfo, err := s.Logics.GetHostData(appID, hostIDStr, hostFields, exportCondStr, lastHostID, header, defLang)
 	if err != nil {
 		blog.Errorf("ExportHost failed, get hosts failed, err: %+v, bk_host_id:%s, export_condition:%s, rid: %s", err, hostIDStr, exportCondStr, rid)
 		reply := getReturnStr(common.CCErrWebGetHostFail, defErr.Errorf(common.CCErrWebGetHostFail, err.Error()).Error(), nil)
@@ -192,6 +215,11 @@ func (s *Service) ExportHost(c *gin.Context) {
 		return
 	}
 	logics.AddDownExcelHttpHeader(c, "bk_cmdb_export_host.xlsx")
+	if exportLastHostID, err := logics.GetExportLastHostID(hostInfo); err != nil {
+		blog.Errorf("ExportHost failed, get the last exported host id failed, err: %v, rid: %s", err, rid)
+	} else {
+		c.Header(exportLastHostIDHeader, strconv.FormatInt(exportLastHostID, 10))
+	}
 	c.File(dirFileName)
 
 	if err := os.Remove(dirFileName); err != nil {
@@ -13,6 +13,7 @@
 package service
 
 import (
+	"net/http"
 	"strings"
 	"time"
 
@@ -22,6 +23,8 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 	"configcenter/src/web_server/middleware/user"
+	"configcenter/src/web_server/middleware/user/plugins"
+	"configcenter/src/web_server/middleware/user/plugins/method/external"
 
 	"github.com/gin-gonic/gin"
 	"github.com/holmeswang/contrib/sessions"
@@ -56,7 +59,24 @@ func (s *Service) LoginUser(c *gin.Context) {
 		c.HTML(200, "login.html", gin.H{
 			"error": defErr.CCError(common.CCErrWebNeedFillinUsernamePasswd).Error(),
 		})
+		return
 	}
+
+	// the login plugins such as ldap verify the user name and password by themselves
+	plugin := plugins.CurrentPlugin(c, s.Config.LoginVersion)
+	if verifier, ok := plugin.(metadata.LoginPasswordPluginInterface); ok {
+		loginUser, err := verifier.VerifyPassword(c, s.Config.ConfigMap, userName, password)
+		if err != nil {
+			blog.Errorf("verify password of user %s failed, err: %v, rid: %s", userName, err, rid)
+			c.HTML(200, "login.html", gin.H{
+				"error": defErr.CCError(common.CCErrWebUsernamePasswdWrong).Error(),
+			})
+			return
+		}
+		s.loginExternalUser(c, loginUser, s.getLoginRedirectURL(c))
+		return
+	}
+
 	userInfo, err := cc.String("webServer.session.userInfo")
 	if err != nil {
 		c.HTML(200, "login.html", gin.H{
@@ -83,13 +103,7 @@ func (s *Service) LoginUser(c *gin.Context) {
 			}
 			userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli)
 			userManger.LoginUser(c)
-			var redirectURL string
-			if c.Param("c_url") != "" {
-				redirectURL = c.Param("c_url")
-			} else {
-				redirectURL = s.Config.Site.DomainUrl
-			}
-			c.Redirect(302, redirectURL)
+			c.Redirect(302, s.getLoginRedirectURL(c))
 			return
 		}
 	}
@@ -98,3 +112,100 @@ func (s *Service) LoginUser(c *gin.Context) {
 	})
 	return
 }
+
+func (s *Service) getLoginRedirectURL(c *gin.Context) string {
+	if c.Param("c_url") != "" {
+		return c.Param("c_url")
+	}
+	return s.Config.Site.DomainUrl
+}
+
+// LoginCallback is called back by the external login system such as openid connect after the user logs in
+func (s *Service) LoginCallback(c *gin.Context) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(c.Request.Header))
+
+	plugin := plugins.CurrentPlugin(c, s.Config.LoginVersion)
+	handler, ok := plugin.(metadata.LoginCallbackPluginInterface)
+	if !ok {
+		blog.Errorf("login version %s does not support login callback, rid: %s", s.Config.LoginVersion, rid)
+		c.String(http.StatusNotFound, defErr.CCErrorf(common.CCErrWebUnknownLoginVersion,
+			s.Config.LoginVersion).Error())
+		return
+	}
+
+	loginUser, redirectURL, err := handler.HandleLoginCallback(c, s.Config.ConfigMap)
+	if err != nil {
+		blog.Errorf("handle login callback failed, err: %v, rid: %s", err, rid)
+		c.String(http.StatusUnauthorized, defErr.CCError(common.CCErrWebLoginFailed).Error())
+		return
+	}
+	if redirectURL == "" {
+		redirectURL = s.Config.Site.DomainUrl
+	}
+	s.loginExternalUser(c, loginUser, redirectURL)
+}
+
+// loginExternalUser save the user verified by the external login system to the session, synchronize the
+// user's groups and redirect the user to the redirect url.
+func (s *Service) loginExternalUser(c *gin.Context, loginUser *metadata.LoginUserInfo, redirectURL string) {
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(c.Request.Header))
+
+	if err := external.SaveLoginUser(c, loginUser); err != nil {
+		blog.Errorf("save login user %s to session failed, err: %v, rid: %s", loginUser.UserName, err, rid)
+		c.String(http.StatusInternalServerError, defErr.CCError(common.CCErrWebLoginFailed).Error())
+		return
+	}
+
+	// failed to synchronize the groups does not affect the login
+	s.syncLoginUserGroups(c, loginUser)
+
+	userManger := user.NewUser(*s.Config, s.Engine, s.CacheCli)
+	if !userManger.LoginUser(c) {
+		blog.Errorf("login user %s failed, rid: %s", loginUser.UserName, rid)
+		c.String(http.StatusInternalServerError, defErr.CCError(common.CCErrWebLoginFailed).Error())
+		return
+	}
+	c.Redirect(302, redirectURL)
+}
+
+// syncLoginUserGroups synchronize the user's membership of the cmdb user groups that are mapped from the
+// groups of the external login system, the user groups are used by the built-in rbac authorizer.
+// the mapped user groups need to be created by the administrators beforehand.
+func (s *Service) syncLoginUserGroups(c *gin.Context, loginUser *metadata.LoginUserInfo) {
+	if len(loginUser.ManagedGroups) == 0 {
+		return
+	}
+
+	rid := util.GetHTTPCCRequestID(c.Request.Header)
+	header := util.BuildHeader(loginUser.UserName, loginUser.OnwerUin)
+	header.Set(common.BKHTTPCCRequestID, rid)
+
+	// the user's membership is changed atomically so that the concurrent logins of the members of the same
+	// group do not overwrite each other.
+	joined, left := make([]string, 0), make([]string, 0)
+	for _, name := range loginUser.ManagedGroups {
+		if util.InStrArr(loginUser.Groups, name) {
+			joined = append(joined, name)
+		} else {
+			left = append(left, name)
+		}
+	}
+
+	auth := s.CoreAPI.CoreService().Auth()
+	if len(joined) > 0 {
+		option := &metadata.AuthUserGroupMembersOption{Names: joined, Members: []string{loginUser.UserName}}
+		if err := auth.AddAuthUserGroupMembers(c.Request.Context(), header, option); err != nil {
+			blog.Errorf("add user %s to user groups %v failed, err: %v, rid: %s", loginUser.UserName, joined, err,
+				rid)
+		}
+	}
+	if len(left) > 0 {
+		option := &metadata.AuthUserGroupMembersOption{Names: left, Members: []string{loginUser.UserName}}
+		if err := auth.RemoveAuthUserGroupMembers(c.Request.Context(), header, option); err != nil {
+			blog.Errorf("remove user %s from user groups %v failed, err: %v, rid: %s", loginUser.UserName, left,
+				err, rid)
+		}
+	}
+}
@@ -94,6 +94,7 @@ func (s *Service) ExportNetDevice(c *gin.Context) {
 	defLang := s.Language.CreateDefaultCCLanguageIf(language)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(language)
 	webCommon.SetProxyHeader(c)
+	s.setExportReadPreference(c)
 
 	deviceIDstr := c.PostForm(common.BKDeviceIDField)
 	deviceInfo, err := s.Logics.GetNetDeviceData(c.Request.Header, deviceIDstr)
@@ -95,6 +95,7 @@ func (s *Service) ExportNetProperty(c *gin.Context) {
 	defLang := s.Language.CreateDefaultCCLanguageIf(language)
 	defErr := s.CCErr.CreateDefaultCCErrorIf(language)
 	webCommon.SetProxyHeader(c)
+	s.setExportReadPreference(c)
 
 	netPropertyIDStr := c.PostForm(common.BKNetcollectPropertyIDField)
 	netPropertyInfo, err := s.Logics.GetNetPropertyData(c.Request.Header, netPropertyIDStr)
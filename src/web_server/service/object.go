@@ -236,6 +236,7 @@ func (s *Service) ExportObject(c *gin.Context) {
 	ctx := util.NewContextFromGinContext(c)
 
 	webCommon.SetProxyHeader(c)
+	s.setExportReadPreference(c)
 
 	ownerID := c.Param(common.BKOwnerIDField)
 	objID := c.Param(common.BKObjIDField)
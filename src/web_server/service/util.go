@@ -15,6 +15,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// setExportReadPreference set the db read preference of the export request, so that the searches of it can
+// be sent to the secondaries.
+func (s *Service) setExportReadPreference(c *gin.Context) {
+	if s.Config.ExportReadPreference == common.NilMode {
+		return
+	}
+	util.SetHTTPReadPreference(c.Request.Header, s.Config.ExportReadPreference)
+}
+
 func parseModelBizID(data string) (int64, error) {
 	model := &struct {
 		BizID int64 `json:"bk_biz_id"`